@@ -1532,6 +1532,7 @@ func getTerraformPluginsDownloadURLs() []string {
 		"google":    commands.TfGooglePluginVersion,
 		"openstack": commands.TfOpenStackPluginVersion,
 		"aws":       commands.TfAWSPluginVersion,
+		"azurerm":   commands.TfAzureRMPluginVersion,
 	}
 
 	var pluginURLs []string
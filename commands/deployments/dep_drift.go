@@ -0,0 +1,165 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/helper/tabutil"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/rest"
+)
+
+func init() {
+	var check bool
+	var nodes []string
+	var driftCmd = &cobra.Command{
+		Use:   "drift <id>",
+		Short: "Show or check the infrastructure drift of a deployment",
+		Long: `Show the last infrastructure drift reports of the nodes of a deployment <id>.
+Drift reports describe the resources of nodes infrastructures that were modified outside of Yorc.
+Use the --check flag to start a new drift check, only nodes whose infrastructure is managed by Terraform are checked.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			if check {
+				location, err := checkDrift(client, args[0], nodes)
+				if err != nil {
+					return err
+				}
+				fmt.Println("Drift check submitted. path :", location)
+				return nil
+			}
+			reports, err := getDriftReports(client, args[0])
+			if err != nil {
+				return err
+			}
+			if len(reports) == 0 {
+				fmt.Printf("No drift report for deployment %q\n", args[0])
+				return nil
+			}
+			colorize := !NoColor
+			if colorize {
+				defer color.Unset()
+			}
+			fmt.Println(formatDriftReports(colorize, reports))
+			return nil
+		},
+	}
+	driftCmd.Flags().BoolVarP(&check, "check", "c", false, "Start a new drift check instead of showing the last reports")
+	driftCmd.Flags().StringSliceVarP(&nodes, "node", "n", nil, "Restrict the drift check to the given nodes. By default all nodes are checked.")
+	DeploymentsCmd.AddCommand(driftCmd)
+}
+
+func checkDrift(client httputil.HTTPClient, deploymentID string, nodes []string) (string, error) {
+	request, err := client.NewRequest("POST", path.Join("/deployments", deploymentID, "drift"), nil)
+	if err != nil {
+		return "", errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	if len(nodes) > 0 {
+		query := request.URL.Query()
+		for _, node := range nodes {
+			query.Add("node", node)
+		}
+		request.URL.RawQuery = query.Encode()
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		httputil.PrintErrors(response.Body)
+		return "", errors.Errorf("failed to check drift of deployment %q: expecting HTTP Status code 202, got %d, reason %q", deploymentID, response.StatusCode, response.Status)
+	}
+	return response.Header.Get("Location"), nil
+}
+
+func getDriftReports(client httputil.HTTPClient, deploymentID string) ([]prov.DriftReport, error) {
+	request, err := client.NewRequest("GET", path.Join("/deployments", deploymentID, "drift"), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	request.Header.Add("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		httputil.PrintErrors(response.Body)
+		return nil, errors.Errorf("failed to get drift reports of deployment %q: expecting HTTP Status code 200, got %d, reason %q", deploymentID, response.StatusCode, response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	var collection rest.DriftReportsCollection
+	err = json.Unmarshal(body, &collection)
+	return collection.Reports, errors.Wrap(err, "failed to decode drift reports")
+}
+
+func formatDriftReports(colorize bool, reports []prov.DriftReport) string {
+	table := tabutil.NewTable()
+	table.AddHeaders("Node", "Status", "Check Date", "Details")
+	for _, report := range reports {
+		status := "in sync"
+		details := report.Summary
+		switch {
+		case report.Error != "":
+			status = "check failed"
+			details = report.Error
+		case report.Drifted:
+			status = "drifted"
+			if len(report.Changes) > 0 {
+				details = strings.Join(report.Changes, ", ")
+			}
+		}
+		table.AddRow(report.NodeName, getColoredDriftStatus(colorize, status), report.CheckDate.Format(time.RFC3339), details)
+	}
+	return table.Render()
+}
+
+func getColoredDriftStatus(colorize bool, status string) string {
+	if !colorize {
+		return status
+	}
+	switch status {
+	case "in sync":
+		return color.New(color.FgHiGreen, color.Bold).SprintFunc()(status)
+	case "drifted":
+		return color.New(color.FgHiYellow, color.Bold).SprintFunc()(status)
+	default:
+		return color.New(color.FgHiRed, color.Bold).SprintFunc()(status)
+	}
+}
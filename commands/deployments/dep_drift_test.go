@@ -0,0 +1,70 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/prov"
+)
+
+func Test_driftCommands(t *testing.T) {
+	client := &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "POST", req.Method)
+		require.Equal(t, "/deployments/myDep/drift", req.URL.Path)
+		require.Equal(t, []string{"Compute", "Network"}, req.URL.Query()["node"])
+		res := &httptest.ResponseRecorder{Code: 202, HeaderMap: http.Header{"Location": []string{"/deployments/myDep/tasks/t1"}}}
+		return res.Result(), nil
+	}}
+	location, err := checkDrift(client, "myDep", []string{"Compute", "Network"})
+	require.NoError(t, err)
+	require.Equal(t, "/deployments/myDep/tasks/t1", location)
+
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "GET", req.Method)
+		require.Equal(t, "/deployments/myDep/drift", req.URL.Path)
+		res := &httptest.ResponseRecorder{Code: 200, Body: bytes.NewBufferString(`{"reports":[{"node_name":"Compute","check_date":"2020-01-02T03:04:05Z","drifted":true,"changes":["~ openstack_compute_instance_v2.Compute-0"]}]}`)}
+		return res.Result(), nil
+	}
+	reports, err := getDriftReports(client, "myDep")
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.Equal(t, "Compute", reports[0].NodeName)
+	require.True(t, reports[0].Drifted)
+
+	output := formatDriftReports(false, append(reports, prov.DriftReport{NodeName: "Network", Error: "failure"}))
+	require.Contains(t, output, "drifted")
+	require.Contains(t, output, "~ openstack_compute_instance_v2.Compute-0")
+	require.Contains(t, output, "check failed")
+
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		return (&httptest.ResponseRecorder{Code: 204}).Result(), nil
+	}
+	reports, err = getDriftReports(client, "myDep")
+	require.NoError(t, err)
+	require.Len(t, reports, 0)
+
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		res := &httptest.ResponseRecorder{Code: 400, Body: bytes.NewBufferString(`{"errors":[]}`)}
+		return res.Result(), nil
+	}
+	_, err = checkDrift(client, "myDep", nil)
+	require.Error(t, err)
+}
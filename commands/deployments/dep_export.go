@@ -0,0 +1,147 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/rest"
+)
+
+func init() {
+	var outputFile string
+	var exportCmd = &cobra.Command{
+		Use:   "export <id>",
+		Short: "Export a deployment into an archive",
+		Long: `Export a deployment <id> into a zip archive that could be imported later on this Yorc cluster or on another one.
+The archive contains the original CSAR, the stored topology, instances and attributes states, relationships instances,
+tasks history and Terraform states. The deployment should not have any running task.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			deploymentID := args[0]
+			if outputFile == "" {
+				outputFile = deploymentID + ".zip"
+			}
+			err = exportDeployment(client, deploymentID, outputFile)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deployment %q exported into %q\n", deploymentID, outputFile)
+			return nil
+		},
+	}
+	exportCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path of the generated archive. Defaults to <id>.zip in the current directory.")
+	DeploymentsCmd.AddCommand(exportCmd)
+
+	var newDeploymentID string
+	var importCmd = &cobra.Command{
+		Use:   "import <archive_path>",
+		Short: "Import a deployment from an archive",
+		Long: `Import a deployment from a zip archive generated by the "export" command.
+By default the deployment is imported with its original id, use the --id flag to import it with another id.
+Tasks ids that are already used on this Yorc cluster are changed during import.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(ClientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			result, err := importDeployment(client, args[0], newDeploymentID)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Deployment %q imported with id %q\n", result.OriginalDeploymentID, result.DeploymentID)
+			for original, remapped := range result.RemappedTasks {
+				fmt.Printf("  task %q imported with id %q\n", original, remapped)
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringVarP(&newDeploymentID, "id", "", "", fmt.Sprintf("Specify a new id for the imported deployment. This id should not already exists, should respect the following format: %q", rest.YorcDeploymentIDPattern))
+	DeploymentsCmd.AddCommand(importCmd)
+}
+
+func exportDeployment(client httputil.HTTPClient, deploymentID, outputFile string) error {
+	request, err := client.NewRequest("GET", path.Join("/deployments", deploymentID, "export"), nil)
+	if err != nil {
+		return errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	request.Header.Add("Accept", "application/zip")
+	response, err := client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		httputil.PrintErrors(response.Body)
+		return errors.Errorf("failed to export deployment %q: expecting HTTP Status code 200, got %d, reason %q", deploymentID, response.StatusCode, response.Status)
+	}
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive %q", outputFile)
+	}
+	defer f.Close()
+	_, err = io.Copy(f, response.Body)
+	return errors.Wrapf(err, "failed to write archive %q", outputFile)
+}
+
+func importDeployment(client httputil.HTTPClient, archivePath, deploymentID string) (*rest.DeploymentImportResult, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open archive %q", archivePath)
+	}
+	defer f.Close()
+	request, err := client.NewRequest("POST", "/deployments/import", f)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	if deploymentID != "" {
+		query := request.URL.Query()
+		query.Set("id", deploymentID)
+		request.URL.RawQuery = query.Encode()
+	}
+	request.Header.Add("Content-Type", "application/zip")
+	request.Header.Add("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		httputil.PrintErrors(response.Body)
+		return nil, errors.Errorf("failed to import deployment: expecting HTTP Status code 201, got %d, reason %q", response.StatusCode, response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg)
+	}
+	result := new(rest.DeploymentImportResult)
+	err = json.Unmarshal(body, result)
+	return result, errors.Wrap(err, "failed to decode import result")
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_exportImportDeployment(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "yorc-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	archive := filepath.Join(tmpDir, "myDep.zip")
+
+	client := &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "/deployments/myDep/export", req.URL.Path)
+		res := &httptest.ResponseRecorder{Code: 200, Body: bytes.NewBufferString("archive content")}
+		return res.Result(), nil
+	}}
+	err = exportDeployment(client, "myDep", archive)
+	require.NoError(t, err)
+	content, err := ioutil.ReadFile(archive)
+	require.NoError(t, err)
+	require.Equal(t, "archive content", string(content))
+
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "/deployments/import", req.URL.Path)
+		require.Equal(t, "newDep", req.URL.Query().Get("id"))
+		require.Equal(t, "application/zip", req.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		require.Equal(t, "archive content", string(body))
+		res := &httptest.ResponseRecorder{Code: 201, Body: bytes.NewBufferString(`{"deployment_id":"newDep","original_deployment_id":"myDep","remapped_tasks":{"t1":"t2"}}`)}
+		return res.Result(), nil
+	}
+	result, err := importDeployment(client, archive, "newDep")
+	require.NoError(t, err)
+	require.Equal(t, "newDep", result.DeploymentID)
+	require.Equal(t, "myDep", result.OriginalDeploymentID)
+	require.Equal(t, map[string]string{"t1": "t2"}, result.RemappedTasks)
+
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		res := &httptest.ResponseRecorder{Code: 409, Body: bytes.NewBufferString(`{"errors":[]}`)}
+		return res.Result(), nil
+	}
+	_, err = importDeployment(client, archive, "")
+	require.Error(t, err)
+}
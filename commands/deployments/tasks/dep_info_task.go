@@ -22,6 +22,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/pkg/errors"
@@ -36,6 +37,7 @@ import (
 
 func init() {
 	var withSteps bool
+	var withTimeline bool
 	var infoTaskCmd = &cobra.Command{
 		Use:   "info <DeploymentId> <TaskId>",
 		Short: "Get information about a deployment task",
@@ -45,15 +47,16 @@ func init() {
 			if err != nil {
 				return err
 			}
-			return taskInfo(client, args, withSteps)
+			return taskInfo(client, args, withSteps, withTimeline)
 		},
 	}
 
 	infoTaskCmd.PersistentFlags().BoolVarP(&withSteps, "steps", "w", false, "Show steps of the related workflow associated to the task")
+	infoTaskCmd.PersistentFlags().BoolVarP(&withTimeline, "timeline", "t", false, "Show a timeline of the task steps as a textual Gantt chart")
 	tasksCmd.AddCommand(infoTaskCmd)
 }
 
-func taskInfo(client httputil.HTTPClient, args []string, withSteps bool) error {
+func taskInfo(client httputil.HTTPClient, args []string, withSteps, withTimeline bool) error {
 	if len(args) != 2 {
 		return errors.Errorf("Expecting a deployment id and a task id (got %d parameters)", len(args))
 	}
@@ -83,6 +86,21 @@ func taskInfo(client httputil.HTTPClient, args []string, withSteps bool) error {
 	fmt.Println("Task: ", task.ID)
 	fmt.Println("Task status:", task.Status)
 	fmt.Println("Task type:", task.Type)
+	if task.CreatedBy != "" {
+		fmt.Println("Task created by:", task.CreatedBy)
+	}
+	if task.CreationDate != nil {
+		fmt.Println("Task creation date:", task.CreationDate.Format(time.RFC3339))
+	}
+	if task.StartDate != nil {
+		fmt.Println("Task start date:", task.StartDate.Format(time.RFC3339))
+	}
+	if task.EndDate != nil {
+		fmt.Println("Task end date:", task.EndDate.Format(time.RFC3339))
+	}
+	if task.Retries > 0 {
+		fmt.Println("Task retries:", task.Retries)
+	}
 	fmt.Println("Task outputs:")
 
 	if task.Outputs != nil {
@@ -102,6 +120,10 @@ func taskInfo(client httputil.HTTPClient, args []string, withSteps bool) error {
 		displayStepTables(client, args)
 	}
 
+	if withTimeline {
+		return displayTimeline(client, args)
+	}
+
 	return nil
 }
 
@@ -135,10 +157,10 @@ func displayStepTables(client httputil.HTTPClient, args []string) {
 	}
 	fmt.Println("Steps:")
 	tasksTable := tabutil.NewTable()
-	tasksTable.AddHeaders("Name", "Status")
+	tasksTable.AddHeaders("Name", "Status", "Server")
 	errs := make([]error, 0)
 	for _, step := range steps {
-		tasksTable.AddRow(step.Name, getColoredTaskStepStatus(colorize, step.Status))
+		tasksTable.AddRow(step.Name, getColoredTaskStepStatus(colorize, step.Status), step.ServerID)
 	}
 	fmt.Println(tasksTable.Render())
 	if len(errs) > 0 {
@@ -156,7 +178,7 @@ func getColoredTaskStepStatus(colorize bool, status string) string {
 	switch strings.ToLower(status) {
 	case "error":
 		return color.New(color.FgHiRed, color.Bold).SprintFunc()(status)
-	case "canceled", "running":
+	case "canceled", "running", "waiting":
 		return color.New(color.FgHiYellow, color.Bold).SprintFunc()(status)
 	case "done":
 		return color.New(color.FgHiGreen, color.Bold).SprintFunc()(status)
@@ -26,6 +26,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 type httpClientMockInfo struct {
@@ -56,6 +57,27 @@ func (c *httpClientMockInfo) Do(req *http.Request) (*http.Response, error) {
 		return w.Result(), nil
 	}
 
+	if strings.HasSuffix(req.URL.String(), "timeline") {
+		start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+		end := start.Add(10 * time.Second)
+		timeline := rest.TaskTimeline{
+			ID:        "taskID",
+			TargetID:  "deploymentID",
+			Status:    "Done",
+			StartDate: &start,
+			EndDate:   &end,
+			Steps: []tasks.TaskStep{
+				{Name: "stepOne", Status: "done", StartDate: &start, EndDate: &end},
+			},
+		}
+		b, err := json.Marshal(timeline)
+		if err != nil {
+			return nil, errors.New("failed to build http client mock response")
+		}
+		w.Write(b)
+		return w.Result(), nil
+	}
+
 	task := rest.Task{
 		ID:           "taskID",
 		TargetID:     "deploymentID",
@@ -100,31 +122,36 @@ func (c *httpClientMockInfo) PostForm(path string, data url.Values) (*http.Respo
 }
 
 func TestTaskInfo(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID", "taskID"}, false)
+	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID", "taskID"}, false, false)
 	require.NoError(t, err, "Failed to get task info")
 }
 
 func TestTaskInfoWithSteps(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID", "taskID"}, true)
+	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID", "taskID"}, true, false)
+	require.NoError(t, err, "Failed to get task info")
+}
+
+func TestTaskInfoWithTimeline(t *testing.T) {
+	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID", "taskID"}, false, true)
 	require.NoError(t, err, "Failed to get task info")
 }
 
 func TestTaskInfoWithoutTaskID(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID"}, false)
+	err := taskInfo(&httpClientMockInfo{}, []string{"deploymentID"}, false, false)
 	require.Error(t, err, "Expected error due to missing argument")
 }
 
 func TestHostInfoWithRequestError(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"bad_request", "taskID"}, false)
+	err := taskInfo(&httpClientMockInfo{}, []string{"bad_request", "taskID"}, false, false)
 	require.Error(t, err, "Expected error due to request error")
 }
 
 func TestHostInfoWithHTTPFailure(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"fails", "taskID"}, false)
+	err := taskInfo(&httpClientMockInfo{}, []string{"fails", "taskID"}, false, false)
 	require.Error(t, err, "Expected error due to HTTP failure")
 }
 
 func TestHostInfoWithJSONError(t *testing.T) {
-	err := taskInfo(&httpClientMockInfo{}, []string{"bad_json", "taskID"}, false)
+	err := taskInfo(&httpClientMockInfo{}, []string{"bad_json", "taskID"}, false, false)
 	require.Error(t, err, "Expected error due to JSON error")
 }
@@ -0,0 +1,137 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/helper/tabutil"
+	"github.com/ystia/yorc/v4/rest"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// timelineWidth is the number of characters used to render the whole task duration
+const timelineWidth = 50
+
+func displayTimeline(client httputil.HTTPClient, args []string) error {
+	request, err := client.NewRequest("GET", path.Join("/deployments", args[0], "tasks", args[1], "timeline"), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, args[0]+"/"+args[1], "deployment/task", http.StatusOK)
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	var timeline rest.TaskTimeline
+	err = json.Unmarshal(body, &timeline)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode task timeline")
+	}
+	fmt.Println("Timeline:")
+	fmt.Println(renderTimeline(timeline, time.Now(), timelineWidth))
+	return nil
+}
+
+// renderTimeline renders steps of the given timeline as a textual Gantt chart.
+//
+// Steps still running end at the given now date.
+func renderTimeline(timeline rest.TaskTimeline, now time.Time, width int) string {
+	begin, end := timelineBounds(timeline, now)
+	if begin == nil {
+		return "Task not started yet"
+	}
+	total := end.Sub(*begin)
+	table := tabutil.NewTable()
+	table.AddHeaders("Step", "Status", "Server", "Retries", "Start", "Duration", fmt.Sprintf("Timeline (%s)", total.Round(time.Second)))
+	for _, step := range timeline.Steps {
+		if step.StartDate == nil {
+			table.AddRow(step.Name, step.Status, step.ServerID, step.Retries, "", "", "")
+			continue
+		}
+		stepEnd := stepEndDate(step, now)
+		table.AddRow(step.Name, step.Status, step.ServerID, step.Retries,
+			"+"+step.StartDate.Sub(*begin).Round(time.Second).String(),
+			stepEnd.Sub(*step.StartDate).Round(time.Millisecond).String(),
+			timelineBar(step.StartDate.Sub(*begin), stepEnd.Sub(*begin), total, width))
+	}
+	return table.Render()
+}
+
+// timelineBounds returns the dates of the beginning and of the end of the timeline
+func timelineBounds(timeline rest.TaskTimeline, now time.Time) (*time.Time, time.Time) {
+	begin := timeline.StartDate
+	end := now
+	if timeline.EndDate != nil {
+		end = *timeline.EndDate
+	}
+	for _, step := range timeline.Steps {
+		if step.StartDate == nil {
+			continue
+		}
+		if begin == nil || step.StartDate.Before(*begin) {
+			begin = step.StartDate
+		}
+		if stepEnd := stepEndDate(step, now); stepEnd.After(end) {
+			end = stepEnd
+		}
+	}
+	return begin, end
+}
+
+func stepEndDate(step tasks.TaskStep, now time.Time) time.Time {
+	if step.EndDate != nil {
+		return *step.EndDate
+	}
+	status, err := tasks.ParseTaskStepStatus(step.Status)
+	if err == nil && (status == tasks.TaskStepStatusRUNNING || status == tasks.TaskStepStatusWAITING) {
+		return now
+	}
+	return *step.StartDate
+}
+
+// timelineBar renders a bar from start to end on a line of the given width representing the total duration
+func timelineBar(start, end, total time.Duration, width int) string {
+	if total <= 0 {
+		return "|" + strings.Repeat("=", width) + "|"
+	}
+	from := int(int64(start) * int64(width) / int64(total))
+	to := int((int64(end)*int64(width) + int64(total) - 1) / int64(total))
+	if from >= width {
+		from = width - 1
+	}
+	if to <= from {
+		to = from + 1
+	}
+	if to > width {
+		to = width
+	}
+	return "|" + strings.Repeat(" ", from) + strings.Repeat("=", to-from) + strings.Repeat(" ", width-to) + "|"
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystia/yorc/v4/rest"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+func TestTimelineBar(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		total      time.Duration
+		width      int
+		want       string
+	}{
+		{"Full", 0, 10 * time.Second, 10 * time.Second, 10, "|==========|"},
+		{"FirstHalf", 0, 5 * time.Second, 10 * time.Second, 10, "|=====     |"},
+		{"SecondHalf", 5 * time.Second, 10 * time.Second, 10 * time.Second, 10, "|     =====|"},
+		{"Instant", 3 * time.Second, 3 * time.Second, 10 * time.Second, 10, "|   =      |"},
+		{"AtEnd", 10 * time.Second, 10 * time.Second, 10 * time.Second, 10, "|         =|"},
+		{"NoDuration", 0, 0, 0, 4, "|====|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, timelineBar(tt.start, tt.end, tt.total, tt.width))
+		})
+	}
+}
+
+func TestRenderTimeline(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	stepOneEnd := start.Add(4 * time.Second)
+	stepTwoStart := start.Add(5 * time.Second)
+	now := start.Add(10 * time.Second)
+
+	out := renderTimeline(rest.TaskTimeline{}, now, 10)
+	assert.Equal(t, "Task not started yet", out)
+
+	out = renderTimeline(rest.TaskTimeline{
+		StartDate: &start,
+		Steps: []tasks.TaskStep{
+			{Name: "stepOne", Status: "done", ServerID: "server-1", StartDate: &start, EndDate: &stepOneEnd},
+			{Name: "stepTwo", Status: "running", ServerID: "server-2", StartDate: &stepTwoStart, Retries: 1},
+			{Name: "stepThree", Status: "initial"},
+		},
+	}, now, 10)
+	assert.Contains(t, out, "Timeline (10s)")
+	assert.Contains(t, out, "|====      |")
+	assert.Contains(t, out, "|     =====|")
+	assert.Contains(t, out, "+5s")
+	assert.Contains(t, out, "stepThree")
+}
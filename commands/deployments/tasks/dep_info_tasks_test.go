@@ -73,6 +73,6 @@ func (c *httpClientInfoTask) PostForm(path string, data url.Values) (*http.Respo
 }
 
 func TestGetTaskInfo(t *testing.T) {
-	err := taskInfo(&httpClientInfoTask{}, []string{"deployment123", "task123"}, false)
+	err := taskInfo(&httpClientInfoTask{}, []string{"deployment123", "task123"}, false, false)
 	require.NoError(t, err, "Failed to get info task")
 }
@@ -0,0 +1,77 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/ystia/yorc/v4/commands"
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/config"
+)
+
+func init() {
+	commands.RootCmd.AddCommand(registryCmd)
+	commands.ConfigureYorcClientCommand(registryCmd, regViper, &cfgFile, &noColor)
+}
+
+var regViper = viper.New()
+var clientConfig config.Client
+
+var noColor bool
+var cfgFile string
+
+var registryCmd = &cobra.Command{
+	Use:           "registry",
+	Aliases:       []string{"reg", "r"},
+	Short:         "Perform commands on the Yorc registry",
+	Long:          `Allow to list what is registered in the Yorc registry and where it comes from (builtin or plugins)`,
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		clientConfig = commands.GetYorcClientConfig(regViper, cfgFile)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := cmd.Help()
+		if err != nil {
+			fmt.Print(err)
+		}
+	},
+}
+
+// getRegistryEntity makes a GET request on the given registry path and decodes the result into entity
+func getRegistryEntity(client httputil.HTTPClient, path string, entity interface{}) error {
+	request, err := client.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Add("Accept", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, "", "registry", http.StatusOK)
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, entity)
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/helper/tabutil"
+	"github.com/ystia/yorc/v4/rest"
+)
+
+func init() {
+	registryCmd.AddCommand(newRegistryListCommand("delegates", "List delegate executors", listDelegates))
+	registryCmd.AddCommand(newRegistryListCommand("implementations", "List operation executors", listImplementations))
+	registryCmd.AddCommand(newRegistryListCommand("action_operators", "List action operators", listActionOperators, "actions"))
+	registryCmd.AddCommand(newRegistryListCommand("definitions", "List TOSCA definitions", listDefinitions, "defs"))
+	registryCmd.AddCommand(newRegistryListCommand("vaults", "List vault client builders", listVaults))
+	registryCmd.AddCommand(newRegistryListCommand("infra_usage_collectors", "List infrastructure usage collectors", listInfraUsageCollectors, "infras"))
+}
+
+func newRegistryListCommand(use, short string, listFn func(client httputil.HTTPClient) error, aliases ...string) *cobra.Command {
+	return &cobra.Command{
+		Use:     use,
+		Aliases: aliases,
+		Short:   short,
+		Long:    short + " registered in the Yorc registry with their origin.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			return listFn(client)
+		},
+	}
+}
+
+func listDelegates(client httputil.HTTPClient) error {
+	var delegates rest.RegistryDelegatesCollection
+	err := getRegistryEntity(client, "/registry/delegates", &delegates)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Node Type", "Origin")
+	for _, d := range delegates.Delegates {
+		table.AddRow(d.Match, d.Origin)
+	}
+	fmt.Println("Delegates executors:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func listImplementations(client httputil.HTTPClient) error {
+	var implementations rest.RegistryImplementationsCollection
+	err := getRegistryEntity(client, "/registry/implementations", &implementations)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Implementation Artifact", "Origin")
+	for _, i := range implementations.Implementations {
+		table.AddRow(i.Artifact, i.Origin)
+	}
+	fmt.Println("Operation executors:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func listActionOperators(client httputil.HTTPClient) error {
+	var operators rest.RegistryActionOperatorsCollection
+	err := getRegistryEntity(client, "/registry/action_operators", &operators)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Action Type", "Origin")
+	for _, o := range operators.ActionOperators {
+		table.AddRow(o.ActionType, o.Origin)
+	}
+	fmt.Println("Action operators:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func listDefinitions(client httputil.HTTPClient) error {
+	var definitions rest.RegistryDefinitionsCollection
+	err := getRegistryEntity(client, "/registry/definitions", &definitions)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Name", "Version", "Origin")
+	for _, d := range definitions.Definitions {
+		table.AddRow(d.Name, d.Version, d.Origin)
+	}
+	fmt.Println("TOSCA definitions:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func listVaults(client httputil.HTTPClient) error {
+	var vaults rest.RegistryVaultsCollection
+	err := getRegistryEntity(client, "/registry/vaults", &vaults)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("ID", "Origin")
+	for _, v := range vaults.VaultClientBuilders {
+		table.AddRow(v.ID, v.Origin)
+	}
+	fmt.Println("Vault client builders:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func listInfraUsageCollectors(client httputil.HTTPClient) error {
+	var collectors rest.RegistryInfraUsageCollectorsCollection
+	err := getRegistryEntity(client, "/registry/infra_usage_collectors", &collectors)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Infrastructure", "Origin")
+	for _, c := range collectors.InfraUsageCollectors {
+		table.AddRow(c.Name, c.Origin)
+	}
+	fmt.Println("Infrastructure usage collectors:")
+	fmt.Println(table.Render())
+	return nil
+}
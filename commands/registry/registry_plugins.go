@@ -0,0 +1,94 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/helper/tabutil"
+	"github.com/ystia/yorc/v4/rest"
+)
+
+func init() {
+	registryCmd.AddCommand(pluginsCmd)
+}
+
+var pluginsCmd = &cobra.Command{
+	Use:     "plugins",
+	Aliases: []string{"origins"},
+	Short:   "List registry capabilities grouped by origin",
+	Long: `List everything registered in the Yorc registry grouped by origin.
+For origins that are plugins, the plugin version and state are also displayed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := httputil.GetClient(clientConfig)
+		if err != nil {
+			httputil.ErrExit(err)
+		}
+		return listPlugins(client)
+	},
+}
+
+func listPlugins(client httputil.HTTPClient) error {
+	var plugins rest.RegistryPluginsCollection
+	err := getRegistryEntity(client, "/registry/plugins", &plugins)
+	if err != nil {
+		return err
+	}
+	table := tabutil.NewTable()
+	table.AddHeaders("Origin", "Version", "Status", "Restarts", "Capabilities")
+	for _, o := range plugins.Plugins {
+		var version, status, restarts string
+		if o.Plugin != nil {
+			version = o.Plugin.Version
+			status = o.Plugin.Status
+			restarts = fmt.Sprint(o.Plugin.Restarts)
+		}
+		capabilities := getCapabilities(o)
+		if len(capabilities) == 0 {
+			table.AddRow(o.Origin, version, status, restarts, "")
+			continue
+		}
+		for i, c := range capabilities {
+			if i == 0 {
+				table.AddRow(o.Origin, version, status, restarts, c)
+			} else {
+				table.AddRow("", "", "", "", c)
+			}
+		}
+	}
+	fmt.Println("Registry origins:")
+	fmt.Println(table.Render())
+	return nil
+}
+
+func getCapabilities(o rest.RegistryOrigin) []string {
+	res := make([]string, 0)
+	add := func(kind string, values []string) {
+		if len(values) > 0 {
+			res = append(res, fmt.Sprintf("%s: %s", kind, strings.Join(values, ", ")))
+		}
+	}
+	add("delegates", o.Delegates)
+	add("implementations", o.Implementations)
+	add("action operators", o.ActionOperators)
+	add("definitions", o.Definitions)
+	add("vaults", o.VaultClientBuilders)
+	add("infrastructure usage collectors", o.InfraUsageCollectors)
+	return res
+}
@@ -61,6 +61,10 @@ var (
 	// TfGooglePluginVersion is the Terraform Google plugin lowest supported version
 	TfGooglePluginVersion           = "tf Google plugin version"
 	tfGooglePluginVersionConstraint = versionToConstraint("~>", TfGooglePluginVersion, "minor")
+
+	// TfAzureRMPluginVersion is the Terraform Azure plugin lowest supported version
+	TfAzureRMPluginVersion           = "tf AzureRM plugin version"
+	tfAzureRMPluginVersionConstraint = versionToConstraint("~>", TfAzureRMPluginVersion, "minor")
 )
 
 var ansibleConfiguration = map[string]interface{}{
@@ -89,12 +93,19 @@ var consulConfiguration = map[string]interface{}{
 	"consul.tls_handshake_timeout":  config.DefaultConsulTLSHandshakeTimeout,
 }
 
+var standaloneConfiguration = map[string]interface{}{
+	"standalone.enabled":        false,
+	"standalone.data_directory": "",
+	"standalone.address":        "",
+}
+
 var terraformConfiguration = map[string]interface{}{
 	"terraform.plugins_dir":                         "",
 	"terraform.consul_plugin_version_constraint":    tfConsulPluginVersionConstraint,
 	"terraform.aws_plugin_version_constraint":       tfAWSPluginVersionConstraint,
 	"terraform.google_plugin_version_constraint":    tfGooglePluginVersionConstraint,
 	"terraform.openstack_plugin_version_constraint": tfOpenStackPluginVersionConstraint,
+	"terraform.azurerm_plugin_version_constraint":   tfAzureRMPluginVersionConstraint,
 	"terraform.keep_generated_files":                false,
 }
 
@@ -245,6 +256,10 @@ func setConfig() {
 	//Flags definition for Yorc server
 	serverCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is /etc/yorc/config.yorc.json)")
 	serverCmd.PersistentFlags().String("plugins_directory", config.DefaultPluginDir, "The name of the plugins directory of the Yorc server")
+	serverCmd.PersistentFlags().Bool("plugins_watch_directory", false, "Watch the plugins directory to load, upgrade or unload plugins at runtime when their files are added, updated or removed")
+	serverCmd.PersistentFlags().Duration("plugins_drain_timeout", config.DefaultPluginsDrainTimeout, "Timeout to wait for in-flight executions of a plugin to finish when unloading or upgrading it. After this delay the previous plugin is stopped.")
+	serverCmd.PersistentFlags().Duration("plugins_health_check_interval", config.DefaultPluginsHealthCheckInterval, "Interval between two health checks of plugins. Crashed plugins are automatically restarted.")
+	serverCmd.PersistentFlags().Duration("plugins_restart_max_backoff", config.DefaultPluginsRestartMaxBackoff, "Maximum delay between two restart attempts of a crashed plugin.")
 	serverCmd.PersistentFlags().StringP("working_directory", "w", "", "The name of the working directory of the Yorc server")
 	serverCmd.PersistentFlags().Int("workers_number", config.DefaultWorkersNumber, "Number of workers in the Yorc server. If not set the default value will be used")
 	serverCmd.PersistentFlags().Duration("graceful_shutdown_timeout", config.DefaultServerGracefulShutdownTimeout, "Timeout to  wait for a graceful shutdown of the Yorc server. After this delay the server immediately exits.")
@@ -252,6 +267,7 @@ func setConfig() {
 	serverCmd.PersistentFlags().Duration("wf_step_graceful_termination_timeout", config.DefaultWfStepGracefulTerminationTimeout, "Timeout to wait for a graceful termination of a workflow step during concurrent workflow step failure. After this delay the step is set on error.")
 	serverCmd.PersistentFlags().Duration("purged_deployments_eviction_timeout", config.DefaultPurgedDeploymentsEvictionTimeout, "When a deployment is purged an event is keep to trace that the purge was actually done, this timeout controls the retention time of such events.")
 	serverCmd.PersistentFlags().String("server_id", host, "The server ID used to identify the server node in a cluster.")
+	serverCmd.PersistentFlags().StringSlice("server_tags", []string{}, "Tags advertised by the server node. Only the task executions on locations whose required tags are all advertised by the server are processed by this server.")
 	serverCmd.PersistentFlags().Bool("disable_ssh_agent", false, "Allow disabling ssh-agent use for SSH authentication on provisioned computes. Default is false. If true, compute credentials must provide a path to a private key file instead of key content.")
 	serverCmd.PersistentFlags().String("locations_file_path", "", "File path to locations configuration. This configuration is taken in account for the first time the server starts.")
 	serverCmd.PersistentFlags().Int("concurrency_limit_for_upgrades", config.DefaultUpgradesConcurrencyLimit, "Limit of concurrency used in Upgrade processes. If not set the default value will be used")
@@ -262,6 +278,7 @@ func setConfig() {
 	serverCmd.PersistentFlags().Duration("tasks_dispatcher_long_poll_wait_time", config.DefaultTasksDispatcherLongPollWaitTime, "Wait time when long polling for executions tasks to dispatch to workers")
 	serverCmd.PersistentFlags().Duration("tasks_dispatcher_lock_wait_time", config.DefaultTasksDispatcherLockWaitTime, "Wait time for acquiring a lock for an execution task")
 	serverCmd.PersistentFlags().Duration("tasks_dispatcher_metrics_refresh_time", config.DefaultTasksDispatcherMetricsRefreshTime, "Tasks dispatcher metrics refresh time")
+	serverCmd.PersistentFlags().Float64("tasks_dispatcher_max_workers_share_per_target", config.DefaultTasksDispatcherMaxWorkersSharePerTarget, "Maximum share (between 0 and 1) of workers that can be used at the same time by executions of a same deployment. 1 means no limit.")
 
 	// Flags definition for Yorc HTTP REST API
 	serverCmd.PersistentFlags().Int("http_port", config.DefaultHTTPPort, "Port number for the Yorc HTTP REST API. If omitted or set to '0' then the default port number is used, any positive integer will be used as it, and finally any negative value will let use a random port.")
@@ -286,6 +303,11 @@ func setConfig() {
 	serverCmd.PersistentFlags().Int("consul_publisher_max_routines", config.DefaultConsulPubMaxRoutines, "Maximum number of parallelism used to store TOSCA definitions in Consul. If you increase the default value you may need to tweak the ulimit max open files. If set to 0 or less the default value will be used")
 	serverCmd.PersistentFlags().Duration("consul_tls_handshake_timeout", config.DefaultConsulTLSHandshakeTimeout, "Maximum duration to wait for a TLS handshake with Consul")
 
+	//Flags definition for the standalone mode
+	serverCmd.PersistentFlags().Bool("standalone_enabled", false, "Run Yorc without Consul, an embedded agent persisting data into a local directory replaces the Consul agent")
+	serverCmd.PersistentFlags().String("standalone_data_directory", "", "Directory where the standalone mode persists data. Defaults to a standalone directory within the working directory")
+	serverCmd.PersistentFlags().String("standalone_address", "", "Address on which the embedded agent of the standalone mode serves the Consul HTTP API (format: <host>:<port>). Defaults to a random port on the loopback interface")
+
 	serverCmd.PersistentFlags().Bool("ansible_use_openssh", false, "Prefer OpenSSH over Paramiko a Python implementation of SSH (the default) to provision remote hosts")
 	serverCmd.PersistentFlags().Bool("ansible_debug", false, "Prints massive debug information from Ansible")
 	serverCmd.PersistentFlags().Int("ansible_connection_retries", 5, "Number of retries in case of Ansible SSH connection failure")
@@ -305,21 +327,32 @@ func setConfig() {
 	serverCmd.PersistentFlags().StringP("terraform_aws_plugin_version_constraint", "", tfAWSPluginVersionConstraint, "Terraform AWS plugin version constraint.")
 	serverCmd.PersistentFlags().StringP("terraform_openstack_plugin_version_constraint", "", tfOpenStackPluginVersionConstraint, "Terraform OpenStack plugin version constraint.")
 	serverCmd.PersistentFlags().StringP("terraform_google_plugin_version_constraint", "", tfGooglePluginVersionConstraint, "Terraform Google plugin version constraint.")
+	serverCmd.PersistentFlags().StringP("terraform_azurerm_plugin_version_constraint", "", tfAzureRMPluginVersionConstraint, "Terraform Azure plugin version constraint.")
 
 	//Bind Consul persistent flags
 	for key := range consulConfiguration {
 		viper.BindPFlag(key, serverCmd.PersistentFlags().Lookup(toFlatKey(key)))
 	}
 
+	//Bind standalone mode persistent flags
+	for key := range standaloneConfiguration {
+		viper.BindPFlag(key, serverCmd.PersistentFlags().Lookup(toFlatKey(key)))
+	}
+
 	//Bind Flags for Yorc server
 	viper.BindPFlag("working_directory", serverCmd.PersistentFlags().Lookup("working_directory"))
 	viper.BindPFlag("plugins_directory", serverCmd.PersistentFlags().Lookup("plugins_directory"))
+	viper.BindPFlag("plugins_watch_directory", serverCmd.PersistentFlags().Lookup("plugins_watch_directory"))
+	viper.BindPFlag("plugins_drain_timeout", serverCmd.PersistentFlags().Lookup("plugins_drain_timeout"))
+	viper.BindPFlag("plugins_health_check_interval", serverCmd.PersistentFlags().Lookup("plugins_health_check_interval"))
+	viper.BindPFlag("plugins_restart_max_backoff", serverCmd.PersistentFlags().Lookup("plugins_restart_max_backoff"))
 	viper.BindPFlag("workers_number", serverCmd.PersistentFlags().Lookup("workers_number"))
 	viper.BindPFlag("server_graceful_shutdown_timeout", serverCmd.PersistentFlags().Lookup("graceful_shutdown_timeout"))
 	viper.BindPFlag("resources_prefix", serverCmd.PersistentFlags().Lookup("resources_prefix"))
 	viper.BindPFlag("wf_step_graceful_termination_timeout", serverCmd.PersistentFlags().Lookup("wf_step_graceful_termination_timeout"))
 	viper.BindPFlag("purged_deployments_eviction_timeout", serverCmd.PersistentFlags().Lookup("purged_deployments_eviction_timeout"))
 	viper.BindPFlag("server_id", serverCmd.PersistentFlags().Lookup("server_id"))
+	viper.BindPFlag("server_tags", serverCmd.PersistentFlags().Lookup("server_tags"))
 	viper.BindPFlag("disable_ssh_agent", serverCmd.PersistentFlags().Lookup("disable_ssh_agent"))
 	viper.BindPFlag("locations_file_path", serverCmd.PersistentFlags().Lookup("locations_file_path"))
 	viper.BindPFlag("concurrency_limit_for_upgrades", serverCmd.PersistentFlags().Lookup("concurrency_limit_for_upgrades"))
@@ -330,6 +363,7 @@ func setConfig() {
 	viper.BindPFlag("tasks.dispatcher.long_poll_wait_time", serverCmd.PersistentFlags().Lookup("tasks_dispatcher_long_poll_wait_time"))
 	viper.BindPFlag("tasks.dispatcher.lock_wait_time", serverCmd.PersistentFlags().Lookup("tasks_dispatcher_lock_wait_time"))
 	viper.BindPFlag("tasks.dispatcher.metrics_refresh_time", serverCmd.PersistentFlags().Lookup("tasks_dispatcher_metrics_refresh_time"))
+	viper.BindPFlag("tasks.dispatcher.max_workers_share_per_target", serverCmd.PersistentFlags().Lookup("tasks_dispatcher_max_workers_share_per_target"))
 
 	//Bind Flags Yorc HTTP REST API
 	viper.BindPFlag("http_port", serverCmd.PersistentFlags().Lookup("http_port"))
@@ -355,6 +389,10 @@ func setConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 	viper.BindEnv("working_directory")
 	viper.BindEnv("plugins_directory")
+	viper.BindEnv("plugins_watch_directory")
+	viper.BindEnv("plugins_drain_timeout")
+	viper.BindEnv("plugins_health_check_interval")
+	viper.BindEnv("plugins_restart_max_backoff")
 	viper.BindEnv("server_graceful_shutdown_timeout")
 	viper.BindEnv("workers_number")
 	viper.BindEnv("http_port")
@@ -366,6 +404,7 @@ func setConfig() {
 	viper.BindEnv("SSL_verify")
 	viper.BindEnv("resources_prefix")
 	viper.BindEnv("server_id")
+	viper.BindEnv("server_tags")
 	viper.BindEnv("disable_ssh_agent")
 	viper.BindEnv("locations_file_path")
 	viper.BindEnv("concurrency_limit_for_upgrades")
@@ -378,11 +417,17 @@ func setConfig() {
 		viper.BindEnv(key, toEnvVar(key))
 	}
 
+	//Bind standalone mode environment variables flags
+	for key := range standaloneConfiguration {
+		viper.BindEnv(key, toEnvVar(key))
+	}
+
 	viper.BindEnv("wf_step_graceful_termination_timeout")
 	viper.BindEnv("purged_deployments_eviction_timeout")
 	viper.BindEnv("tasks.dispatcher.long_poll_wait_time")
 	viper.BindEnv("tasks.dispatcher.lock_wait_time")
 	viper.BindEnv("tasks.dispatcher.metrics_refresh_time")
+	viper.BindEnv("tasks.dispatcher.max_workers_share_per_target")
 
 	//Bind Ansible environment variables flags
 	for key := range ansibleConfiguration {
@@ -398,6 +443,9 @@ func setConfig() {
 	viper.SetDefault("working_directory", "work")
 	viper.SetDefault("server_graceful_shutdown_timeout", config.DefaultServerGracefulShutdownTimeout)
 	viper.SetDefault("plugins_directory", config.DefaultPluginDir)
+	viper.SetDefault("plugins_drain_timeout", config.DefaultPluginsDrainTimeout)
+	viper.SetDefault("plugins_health_check_interval", config.DefaultPluginsHealthCheckInterval)
+	viper.SetDefault("plugins_restart_max_backoff", config.DefaultPluginsRestartMaxBackoff)
 	viper.SetDefault("http_port", config.DefaultHTTPPort)
 	viper.SetDefault("http_address", config.DefaultHTTPAddress)
 	viper.SetDefault("resources_prefix", "yorc-")
@@ -414,12 +462,18 @@ func setConfig() {
 	viper.SetDefault("tasks.dispatcher.long_poll_wait_time", config.DefaultTasksDispatcherLongPollWaitTime)
 	viper.SetDefault("tasks.dispatcher.lock_wait_time", config.DefaultTasksDispatcherLockWaitTime)
 	viper.SetDefault("tasks.dispatcher.metrics_refresh_time", config.DefaultTasksDispatcherMetricsRefreshTime)
+	viper.SetDefault("tasks.dispatcher.max_workers_share_per_target", config.DefaultTasksDispatcherMaxWorkersSharePerTarget)
 
 	// Consul configuration default settings
 	for key, value := range consulConfiguration {
 		viper.SetDefault(key, value)
 	}
 
+	// Standalone mode configuration default settings
+	for key, value := range standaloneConfiguration {
+		viper.SetDefault(key, value)
+	}
+
 	// Ansible configuration default settings
 	for key, value := range ansibleConfiguration {
 		viper.SetDefault(key, value)
@@ -0,0 +1,86 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/server"
+)
+
+func init() {
+	var outputFile string
+	var backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Backup the whole state of a Yorc cluster",
+		Long: `Backup the whole state of a Yorc cluster into a zip archive.
+This command uses the Yorc server configuration to connect to Consul and to configured stores.
+The archive contains data stored into Consul and into every configured store. A cluster-wide lock is held during
+the backup, new task executions are not dispatched and running ones should end before the backup starts.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFile == "" {
+				outputFile = "yorc-backup-" + time.Now().Format("20060102-150405") + ".zip"
+			}
+			f, err := os.Create(outputFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create archive %q", outputFile)
+			}
+			defer f.Close()
+			err = server.BackupCluster(GetConfig(), f)
+			if err != nil {
+				f.Close()
+				os.Remove(outputFile)
+				return err
+			}
+			fmt.Printf("Yorc cluster backed up into %q\n", outputFile)
+			return nil
+		},
+	}
+	backupCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path of the generated archive. Defaults to yorc-backup-<date>.zip in the current directory.")
+	serverCmd.AddCommand(backupCmd)
+
+	var restoreCmd = &cobra.Command{
+		Use:   "restore <archive_path>",
+		Short: "Restore the whole state of a Yorc cluster from a backup",
+		Long: `Restore the whole state of a Yorc cluster from a zip archive generated by the "backup" command.
+This command uses the Yorc server configuration to connect to Consul and to configured stores.
+The current state of the cluster is replaced by the one from the archive. The archive should have been generated
+by a version of Yorc using the same data schema version. Yorc servers should be restarted after a restore.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, err := zip.OpenReader(args[0])
+			if err != nil {
+				return errors.Wrapf(err, "failed to open archive %q", args[0])
+			}
+			defer archive.Close()
+			err = server.RestoreCluster(GetConfig(), &archive.Reader)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Yorc cluster restored from %q, Yorc servers should now be restarted\n", args[0])
+			return nil
+		},
+	}
+	serverCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/internal/backup"
+)
+
+func init() {
+	var request backup.MigrationRequest
+	var noWait bool
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate data from a store to another one",
+		Long: `Migrate data of the given store types from a store to another one.
+Stores are referenced by their names in the Yorc servers configuration, default stores are named
+"defaultFileStoreWithCache" and "defaultConsulStore".
+Data are read by prefix from the source store, written into the target store and then read back from the target
+store to verify them. An interrupted migration is resumed from its last migrated prefix by running this command again.
+Data are not removed from the source store and Yorc servers stores configuration is not updated.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			return migrate(client, request, noWait, time.Second)
+		},
+	}
+	migrateCmd.Flags().StringVar(&request.From, "from", "", "Name of the store data are read from")
+	migrateCmd.Flags().StringVar(&request.To, "to", "", "Name of the store data are written to")
+	migrateCmd.Flags().StringSliceVar(&request.Types, "types", nil, "Comma-separated list of migrated store types (Deployment, Log, Event). Defaults to all types.")
+	migrateCmd.Flags().BoolVar(&noWait, "no-wait", false, "Do not wait for the migration to complete")
+	migrateCmd.MarkFlagRequired("from")
+	migrateCmd.MarkFlagRequired("to")
+	storageCmd.AddCommand(migrateCmd)
+}
+
+// migrate starts a migration and prints its progress every pollInterval until it completes
+func migrate(client httputil.HTTPClient, request backup.MigrationRequest, noWait bool, pollInterval time.Duration) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal storage migration request")
+	}
+	req, err := client.NewRequest("POST", "/server/storage/migrations", bytes.NewBuffer(body))
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		httputil.ErrExit(errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg))
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, "", "storage migration", http.StatusAccepted)
+	migration := new(backup.Migration)
+	if err = decodeMigration(response, migration); err != nil {
+		return err
+	}
+	location := response.Header.Get("Location")
+	fmt.Printf("Storage migration %q from store %q to store %q started for types %s\n", migration.ID, migration.From, migration.To, strings.Join(migration.Types, ", "))
+	if noWait {
+		return nil
+	}
+
+	var lastProgress string
+	for {
+		progress := fmt.Sprintf("%d/%d prefixes migrated, %d keys migrated", migration.CompletedPrefixes, migration.TotalPrefixes, migration.MigratedKeys)
+		if progress != lastProgress {
+			fmt.Println(progress)
+			lastProgress = progress
+		}
+		switch migration.Status {
+		case backup.MigrationStatusDone:
+			fmt.Printf("Storage migration %q done\n", migration.ID)
+			return nil
+		case backup.MigrationStatusFailed:
+			return errors.Errorf("storage migration %q failed: %s", migration.ID, migration.Error)
+		}
+		time.Sleep(pollInterval)
+		migration, err = getMigration(client, location, migration.ID)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func getMigration(client httputil.HTTPClient, location, id string) (*backup.Migration, error) {
+	req, err := client.NewRequest("GET", location, nil)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	req.Header.Add("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		httputil.ErrExit(errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg))
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, id, "storage migration", http.StatusOK)
+	migration := new(backup.Migration)
+	return migration, decodeMigration(response, migration)
+}
+
+func decodeMigration(response *http.Response, migration *backup.Migration) error {
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read storage migration")
+	}
+	return errors.Wrap(json.Unmarshal(body, migration), "failed to decode storage migration")
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/internal/backup"
+)
+
+// httpMockClient sends requests to a handler simulating the storage migrations endpoints
+type httpMockClient struct {
+	handler http.Handler
+}
+
+func (c *httpMockClient) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	return http.NewRequest(method, path, body)
+}
+
+func (c *httpMockClient) Do(req *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+func (c *httpMockClient) Get(path string) (*http.Response, error) {
+	return &http.Response{}, nil
+}
+
+func (c *httpMockClient) Head(path string) (*http.Response, error) {
+	return &http.Response{}, nil
+}
+
+func (c *httpMockClient) Post(path string, contentType string, body io.Reader) (*http.Response, error) {
+	return &http.Response{}, nil
+}
+
+func (c *httpMockClient) PostForm(path string, data url.Values) (*http.Response, error) {
+	return &http.Response{}, nil
+}
+
+func TestMigrate(t *testing.T) {
+	var polls int
+	var request backup.MigrationRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("/server/storage/migrations", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &request))
+		w.Header().Set("Location", "/server/storage/migrations/s1-to-s2")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(backup.Migration{MigrationRequest: request, ID: "s1-to-s2", Status: backup.MigrationStatusRunning, TotalPrefixes: 2})
+	})
+	mux.HandleFunc("/server/storage/migrations/s1-to-s2", func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		m := backup.Migration{MigrationRequest: request, ID: "s1-to-s2", Status: backup.MigrationStatusRunning, TotalPrefixes: 2, CompletedPrefixes: 1, MigratedKeys: 10}
+		if polls > 1 {
+			m.Status = backup.MigrationStatusFailed
+			m.Error = "something went wrong"
+		}
+		json.NewEncoder(w).Encode(m)
+	})
+	client := &httpMockClient{handler: mux}
+
+	err := migrate(client, backup.MigrationRequest{From: "s1", To: "s2", Types: []string{"Log"}}, true, 0)
+	require.NoError(t, err)
+	require.Equal(t, backup.MigrationRequest{From: "s1", To: "s2", Types: []string{"Log"}}, request)
+	require.Equal(t, 0, polls)
+
+	err = migrate(client, backup.MigrationRequest{From: "s1", To: "s2"}, false, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "something went wrong")
+	require.Equal(t, 2, polls)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/ystia/yorc/v4/commands/httputil"
+	"github.com/ystia/yorc/v4/storage"
+)
+
+func init() {
+	var request storage.KeyRotationRequest
+	var keyFile string
+	var noWait bool
+	var rotateKeyCmd = &cobra.Command{
+		Use:   "rotate-key <store name>",
+		Short: "Rotate the encryption key of an encrypted store",
+		Long: `Rotate the encryption key of an encrypted store and re-encrypt its values.
+The key read from the given file is added to the store keyring and becomes its primary key used to encrypt values.
+Without key file, the key ID should reference a key already in the store keyring.
+Without key ID, values encrypted with another key than the current primary key are re-encrypted.
+Values are re-encrypted in background while Yorc servers are running, previous keys are kept to read values.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keyFile != "" {
+				key, err := ioutil.ReadFile(keyFile)
+				if err != nil {
+					return errors.Wrapf(err, "failed to read key file %q", keyFile)
+				}
+				request.Key = strings.TrimSpace(string(key))
+			}
+			client, err := httputil.GetClient(clientConfig)
+			if err != nil {
+				httputil.ErrExit(err)
+			}
+			return rotateKey(client, args[0], request, noWait, time.Second)
+		},
+	}
+	rotateKeyCmd.Flags().StringVar(&request.KeyID, "key-id", "", "ID of the new primary key")
+	rotateKeyCmd.Flags().StringVar(&keyFile, "key-file", "", "Path to a file containing the new 32 characters key")
+	rotateKeyCmd.Flags().BoolVar(&noWait, "no-wait", false, "Do not wait for values to be re-encrypted")
+	storageCmd.AddCommand(rotateKeyCmd)
+}
+
+// rotateKey rotates the key of the given store and polls its status every pollInterval until values are re-encrypted
+func rotateKey(client httputil.HTTPClient, storeName string, request storage.KeyRotationRequest, noWait bool, pollInterval time.Duration) error {
+	if request.Key != "" && request.KeyID == "" {
+		return errors.New("a key ID is required to add a new key")
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal key rotation request")
+	}
+	req, err := client.NewRequest("POST", path.Join("/server/storage/stores", storeName, "rotate_key"), bytes.NewBuffer(body))
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		httputil.ErrExit(errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg))
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, storeName, "store", http.StatusAccepted)
+	rotation := new(storage.KeyRotation)
+	if err = decodeKeyRotation(response, rotation); err != nil {
+		return err
+	}
+	location := response.Header.Get("Location")
+	fmt.Printf("Store %q primary key is %q (keys: %s), re-encrypting values\n", rotation.Store, rotation.PrimaryKeyID, strings.Join(rotation.KeyIDs, ", "))
+	if noWait {
+		return nil
+	}
+
+	for {
+		switch rotation.Status {
+		case storage.KeyRotationStatusDone:
+			fmt.Printf("%d values re-encrypted\n", rotation.ReencryptedValues)
+			return nil
+		case storage.KeyRotationStatusFailed:
+			return errors.Errorf("key rotation of store %q failed: %s", storeName, rotation.Error)
+		}
+		time.Sleep(pollInterval)
+		rotation, err = getKeyRotation(client, location, storeName)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func getKeyRotation(client httputil.HTTPClient, location, storeName string) (*storage.KeyRotation, error) {
+	req, err := client.NewRequest("GET", location, nil)
+	if err != nil {
+		httputil.ErrExit(err)
+	}
+	req.Header.Add("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		httputil.ErrExit(errors.Wrap(err, httputil.YorcAPIDefaultErrorMsg))
+	}
+	defer response.Body.Close()
+	httputil.HandleHTTPStatusCode(response, storeName, "key rotation", http.StatusOK)
+	rotation := new(storage.KeyRotation)
+	return rotation, decodeKeyRotation(response, rotation)
+}
+
+func decodeKeyRotation(response *http.Response, rotation *storage.KeyRotation) error {
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read key rotation")
+	}
+	return errors.Wrap(json.Unmarshal(body, rotation), "failed to decode key rotation")
+}
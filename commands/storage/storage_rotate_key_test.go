@@ -0,0 +1,63 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/storage"
+)
+
+func TestRotateKey(t *testing.T) {
+	var request storage.KeyRotationRequest
+	var polls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/server/storage/stores/myStore/rotate_key", func(w http.ResponseWriter, r *http.Request) {
+		rotation := storage.KeyRotation{Store: "myStore", Status: storage.KeyRotationStatusRunning, PrimaryKeyID: request.KeyID, KeyIDs: []string{"default", request.KeyID}}
+		if r.Method == http.MethodPost {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(body, &request))
+			w.Header().Set("Location", "/server/storage/stores/myStore/rotate_key")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(rotation)
+			return
+		}
+		polls++
+		if polls > 1 {
+			rotation.Status = storage.KeyRotationStatusDone
+			rotation.ReencryptedValues = 3
+		}
+		json.NewEncoder(w).Encode(rotation)
+	})
+	client := &httpMockClient{handler: mux}
+
+	err := rotateKey(client, "myStore", storage.KeyRotationRequest{KeyID: "key2", Key: "anotherverystrongpassword32chars"}, true, 0)
+	require.NoError(t, err)
+	require.Equal(t, storage.KeyRotationRequest{KeyID: "key2", Key: "anotherverystrongpassword32chars"}, request)
+	require.Equal(t, 0, polls)
+
+	err = rotateKey(client, "myStore", storage.KeyRotationRequest{KeyID: "key2"}, false, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, polls)
+
+	err = rotateKey(client, "myStore", storage.KeyRotationRequest{Key: "anotherverystrongpassword32chars"}, false, 0)
+	require.Error(t, err, "a key ID is required to add a key")
+}
@@ -49,6 +49,15 @@ const DefaultHTTPAddress string = "0.0.0.0"
 // DefaultPluginDir is the default path for the plugin directory
 const DefaultPluginDir = "plugins"
 
+// DefaultPluginsDrainTimeout is the default timeout to wait for in-flight executions of a plugin to finish before stopping it when unloading or upgrading it
+const DefaultPluginsDrainTimeout = 5 * time.Minute
+
+// DefaultPluginsHealthCheckInterval is the default interval between two health checks of plugins
+const DefaultPluginsHealthCheckInterval = 30 * time.Second
+
+// DefaultPluginsRestartMaxBackoff is the default maximum delay between two restart attempts of a crashed plugin
+const DefaultPluginsRestartMaxBackoff = 5 * time.Minute
+
 // DefaultServerGracefulShutdownTimeout is the default timeout for a graceful shutdown of a Yorc server before exiting
 const DefaultServerGracefulShutdownTimeout = 5 * time.Minute
 
@@ -81,6 +90,10 @@ const DefaultTasksDispatcherLockWaitTime = 50 * time.Millisecond
 // DefaultTasksDispatcherMetricsRefreshTime is the default refresh time for the Tasks dispatcher metrics
 const DefaultTasksDispatcherMetricsRefreshTime = 5 * time.Minute
 
+// DefaultTasksDispatcherMaxWorkersSharePerTarget is the default maximum share of workers that can be used
+// by executions of a same target (typically a deployment). 1 means no limit.
+const DefaultTasksDispatcherMaxWorkersSharePerTarget = 1.0
+
 // DefaultUpgradesConcurrencyLimit is the default limit of concurrency used in Upgrade processes
 const DefaultUpgradesConcurrencyLimit = 1000
 
@@ -93,10 +106,17 @@ const DefaultSSHConnectionRetryBackoff = 1 * time.Second
 // DefaultSSHConnectionMaxRetries is the default maximum number of retries before giving up (number of attempts is number of retries + 1)
 const DefaultSSHConnectionMaxRetries uint64 = 3
 
+// DefaultStorageCompactionInterval is the default interval between two compactions of logs and events stores
+const DefaultStorageCompactionInterval = 1 * time.Hour
+
 // Configuration holds config information filled by Cobra and Viper (see commands package for more information)
 type Configuration struct {
 	Ansible                          Ansible       `yaml:"ansible,omitempty" mapstructure:"ansible"`
 	PluginsDirectory                 string        `yaml:"plugins_directory,omitempty" mapstructure:"plugins_directory"`
+	PluginsWatchDirectory            bool          `yaml:"plugins_watch_directory,omitempty" mapstructure:"plugins_watch_directory"`
+	PluginsDrainTimeout              time.Duration `yaml:"plugins_drain_timeout,omitempty" mapstructure:"plugins_drain_timeout"`
+	PluginsHealthCheckInterval       time.Duration `yaml:"plugins_health_check_interval,omitempty" mapstructure:"plugins_health_check_interval"`
+	PluginsRestartMaxBackoff         time.Duration `yaml:"plugins_restart_max_backoff,omitempty" mapstructure:"plugins_restart_max_backoff"`
 	WorkingDirectory                 string        `yaml:"working_directory,omitempty" mapstructure:"working_directory"`
 	WorkersNumber                    int           `yaml:"workers_number,omitempty" mapstructure:"workers_number"`
 	ServerGracefulShutdownTimeout    time.Duration `yaml:"server_graceful_shutdown_timeout,omitempty" mapstructure:"server_graceful_shutdown_timeout"`
@@ -109,12 +129,14 @@ type Configuration struct {
 	SSLVerify                        bool          `yaml:"ssl_verify,omitempty" mapstructure:"ssl_verify"`
 	ResourcesPrefix                  string        `yaml:"resources_prefix,omitempty" mapstructure:"resources_prefix"`
 	Consul                           Consul        `yaml:"consul,omitempty" mapstructure:"consul"`
+	Standalone                       Standalone    `yaml:"standalone,omitempty" mapstructure:"standalone"`
 	Telemetry                        Telemetry     `yaml:"telemetry,omitempty" mapstructure:"telemetry"`
 	LocationsFilePath                string        `yaml:"locations_file_path,omitempty" mapstructure:"locations_file_path"`
 	Vault                            DynamicMap    `yaml:"vault,omitempty" mapstructure:"vault"`
 	WfStepGracefulTerminationTimeout time.Duration `yaml:"wf_step_graceful_termination_timeout,omitempty" mapstructure:"wf_step_graceful_termination_timeout"`
 	PurgedDeploymentsEvictionTimeout time.Duration `yaml:"purged_deployments_eviction_timeout,omitempty" mapstructure:"purged_deployments_eviction_timeout"`
 	ServerID                         string        `yaml:"server_id,omitempty" mapstructure:"server_id"`
+	ServerTags                       []string      `yaml:"server_tags,omitempty" mapstructure:"server_tags"`
 	Terraform                        Terraform     `yaml:"terraform,omitempty" mapstructure:"terraform"`
 	DisableSSHAgent                  bool          `yaml:"disable_ssh_agent,omitempty" mapstructure:"disable_ssh_agent"`
 	Tasks                            Tasks         `yaml:"tasks,omitempty" mapstructure:"tasks"`
@@ -179,6 +201,13 @@ type Consul struct {
 	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout,omitempty" mapstructure:"tls_handshake_timeout"`
 }
 
+// Standalone holds the configuration of the standalone mode where an embedded agent replaces the Consul agent
+type Standalone struct {
+	Enabled       bool   `yaml:"enabled,omitempty" mapstructure:"enabled"`
+	DataDirectory string `yaml:"data_directory,omitempty" mapstructure:"data_directory"`
+	Address       string `yaml:"address,omitempty" mapstructure:"address"`
+}
+
 // Telemetry holds the configuration for the telemetry service
 type Telemetry struct {
 	StatsdAddress           string `yaml:"statsd_address,omitempty" mapstructure:"statsd_address"`
@@ -196,12 +225,26 @@ type Terraform struct {
 	AWSPluginVersionConstraint       string `yaml:"aws_plugin_version_constraint,omitempty" mapstructure:"aws_plugin_version_constraint"`
 	GooglePluginVersionConstraint    string `yaml:"google_plugin_version_constraint,omitempty" mapstructure:"google_plugin_version_constraint"`
 	OpenStackPluginVersionConstraint string `yaml:"openstack_plugin_version_constraint,omitempty" mapstructure:"openstack_plugin_version_constraint"`
+	AzureRMPluginVersionConstraint   string `yaml:"azurerm_plugin_version_constraint,omitempty" mapstructure:"azurerm_plugin_version_constraint"`
 	KeepGeneratedFiles               bool   `yaml:"keep_generated_files,omitempty" mapstructure:"keep_generated_files"`
 }
 
 // Tasks processing configuration
 type Tasks struct {
 	Dispatcher Dispatcher `yaml:"dispatcher,omitempty" mapstructure:"dispatcher" json:"dispatcher,omitempty"`
+	// ExecutorsConcurrencyLimits defines the maximum number of operations that could be run
+	// at the same time across the Yorc cluster by a given executor
+	ExecutorsConcurrencyLimits []ExecutorConcurrencyLimit `yaml:"executors_concurrency_limits,omitempty" mapstructure:"executors_concurrency_limits" json:"executors_concurrency_limits,omitempty"`
+}
+
+// ExecutorConcurrencyLimit configuration
+//
+// Executor is either a node type match of a delegate executor or an implementation artifact type
+// of an operation executor, as listed in the registry. The limit applies to every operation run by
+// the executor registered under this name.
+type ExecutorConcurrencyLimit struct {
+	Executor                string `yaml:"executor" mapstructure:"executor" json:"executor"`
+	MaxConcurrentOperations int    `yaml:"max_concurrent_operations" mapstructure:"max_concurrent_operations" json:"max_concurrent_operations"`
 }
 
 // Dispatcher configuration
@@ -209,6 +252,9 @@ type Dispatcher struct {
 	LongPollWaitTime   time.Duration `yaml:"long_poll_wait_time,omitempty" mapstructure:"long_poll_wait_time" json:"long_poll_wait_time,omitempty"`
 	LockWaitTime       time.Duration `yaml:"lock_wait_time,omitempty" mapstructure:"lock_wait_time" json:"lock_wait_time,omitempty"`
 	MetricsRefreshTime time.Duration `yaml:"metrics_refresh_time,omitempty" mapstructure:"metrics_refresh_time" json:"metrics_refresh_time,omitempty"`
+	// MaxWorkersSharePerTarget is the maximum share (between 0 and 1) of workers that can be used
+	// at the same time by executions of a same target
+	MaxWorkersSharePerTarget float64 `yaml:"max_workers_share_per_target,omitempty" mapstructure:"max_workers_share_per_target" json:"max_workers_share_per_target,omitempty"`
 }
 
 // Storage configuration
@@ -216,6 +262,21 @@ type Storage struct {
 	Reset             bool       `yaml:"reset,omitempty" json:"reset,omitempty" mapstructure:"reset"`
 	Stores            []Store    `yaml:"stores,omitempty" json:"stores,omitempty" mapstructure:"stores"`
 	DefaultProperties DynamicMap `yaml:"default_properties,omitempty" json:"default_properties,omitempty" mapstructure:"default_properties"`
+	Retention         Retention  `yaml:"retention,omitempty" json:"retention,omitempty" mapstructure:"retention"`
+}
+
+// Retention configures retention policies of logs and events stores
+type Retention struct {
+	CompactionInterval time.Duration   `yaml:"compaction_interval,omitempty" json:"compaction_interval,omitempty" mapstructure:"compaction_interval"`
+	Logs               RetentionPolicy `yaml:"logs,omitempty" json:"logs,omitempty" mapstructure:"logs"`
+	Events             RetentionPolicy `yaml:"events,omitempty" json:"events,omitempty" mapstructure:"events"`
+}
+
+// RetentionPolicy configures which logs or events are kept for each deployment
+type RetentionPolicy struct {
+	MaxAge                  time.Duration `yaml:"max_age,omitempty" json:"max_age,omitempty" mapstructure:"max_age"`
+	MaxEntriesPerDeployment int           `yaml:"max_entries_per_deployment,omitempty" json:"max_entries_per_deployment,omitempty" mapstructure:"max_entries_per_deployment"`
+	MinLevel                string        `yaml:"min_level,omitempty" json:"min_level,omitempty" mapstructure:"min_level"`
 }
 
 // Store configuration
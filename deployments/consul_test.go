@@ -43,6 +43,9 @@ func TestRunConsulDeploymentsPackageTests(t *testing.T) {
 		t.Run("testDefinitionStore", func(t *testing.T) {
 			testDefinitionStore(t)
 		})
+		t.Run("testDriftReports", func(t *testing.T) {
+			testDriftReports(t)
+		})
 		t.Run("testDeploymentNodes", func(t *testing.T) {
 			testDeploymentNodes(t, srv)
 		})
@@ -163,6 +166,13 @@ func TestRunConsulDeploymentsPackageTests(t *testing.T) {
 		})
 	})
 
+	t.Run("TestGetNodeLocationName", func(t *testing.T) {
+		deploymentID := testutil.BuildDeploymentID(t)
+		err := StoreDeploymentDefinition(context.Background(), deploymentID, "testdata/location_name.yml")
+		require.NoError(t, err)
+		testGetNodeLocationName(t, deploymentID)
+	})
+
 	t.Run("CommonsTestsOn_test_topology_substitution.yml", func(t *testing.T) {
 		deploymentID := testutil.BuildDeploymentID(t)
 		err := StoreDeploymentDefinition(context.Background(), deploymentID, "testdata/test_topology_substitution.yml")
@@ -0,0 +1,62 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov"
+)
+
+// StoreDriftReport stores the drift report of a node, replacing any previous report of this node
+func StoreDriftReport(ctx context.Context, deploymentID string, report prov.DriftReport) error {
+	if report.NodeName == "" {
+		return errors.New("node name of a drift report is mandatory")
+	}
+	value, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal drift report of node %q", report.NodeName)
+	}
+	err = consulutil.StoreConsulKey(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "drift", report.NodeName), value)
+	return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+}
+
+// GetDriftReports returns the last drift reports of the nodes of a deployment sorted by node name
+//
+// Nodes that were never checked have no report.
+func GetDriftReports(ctx context.Context, deploymentID string) ([]prov.DriftReport, error) {
+	kvs, err := consulutil.List(path.Join(consulutil.DeploymentKVPrefix, deploymentID, "drift") + "/")
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	reports := make([]prov.DriftReport, 0, len(kvs))
+	for key, value := range kvs {
+		var report prov.DriftReport
+		if err = json.Unmarshal(value, &report); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal drift report stored in key %q", key)
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].NodeName < reports[j].NodeName
+	})
+	return reports, nil
+}
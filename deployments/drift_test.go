@@ -0,0 +1,55 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deployments
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/prov"
+)
+
+func testDriftReports(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+
+	reports, err := GetDriftReports(ctx, deploymentID)
+	require.NoError(t, err)
+	require.Len(t, reports, 0)
+
+	checkDate := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, StoreDriftReport(ctx, deploymentID, prov.DriftReport{NodeName: "Network", TaskID: "t1", CheckDate: checkDate}))
+	require.NoError(t, StoreDriftReport(ctx, deploymentID, prov.DriftReport{NodeName: "Compute", TaskID: "t1", CheckDate: checkDate, Drifted: true,
+		Changes: []string{"~ openstack_compute_instance_v2.Compute-0"}, Summary: "Plan: 0 to add, 1 to change, 0 to destroy."}))
+	// A new report replaces the previous one of the node
+	require.NoError(t, StoreDriftReport(ctx, deploymentID, prov.DriftReport{NodeName: "Network", TaskID: "t2", CheckDate: checkDate, Error: "failure"}))
+	require.Error(t, StoreDriftReport(ctx, deploymentID, prov.DriftReport{}))
+
+	reports, err = GetDriftReports(ctx, deploymentID)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	require.Equal(t, "Compute", reports[0].NodeName)
+	require.True(t, reports[0].Drifted)
+	require.Equal(t, []string{"~ openstack_compute_instance_v2.Compute-0"}, reports[0].Changes)
+	require.True(t, checkDate.Equal(reports[0].CheckDate))
+	require.Equal(t, "Network", reports[1].NodeName)
+	require.Equal(t, "t2", reports[1].TaskID)
+	require.Equal(t, "failure", reports[1].Error)
+}
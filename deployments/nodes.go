@@ -858,6 +858,26 @@ func GetNodeMetadata(ctx context.Context, deploymentID, nodeName, key string) (b
 	return true, value, nil
 }
 
+// GetNodeLocationName returns the location name defined in metadata of the given node or of the
+// first node hosting it that defines one, or an empty string if none defines it.
+// An empty location name is considered as undefined.
+func GetNodeLocationName(ctx context.Context, deploymentID, nodeName string) (string, error) {
+	for nodeName != "" {
+		found, locationName, err := GetNodeMetadata(ctx, deploymentID, nodeName, tosca.MetadataLocationNameKey)
+		if err != nil {
+			return "", err
+		}
+		if found && locationName != "" {
+			return locationName, nil
+		}
+		nodeName, err = GetHostedOnNode(ctx, deploymentID, nodeName)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
 // NodeHasAttribute returns true if the node type has an attribute named attributeName defined
 //
 // exploreParents switch enable attribute check on parent types
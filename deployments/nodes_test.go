@@ -736,6 +736,28 @@ func testDeleteNode(t *testing.T) {
 	require.Len(t, instancesValues, 0, "Expected an empty map, got %+v", instancesValues)
 }
 
+func testGetNodeLocationName(t *testing.T, deploymentID string) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		nodeName string
+		want     string
+	}{
+		{"LocationOnNode", "Compute", "myLocation"},
+		{"EmptyLocationOnHost", "Middle", "myLocation"},
+		{"EmptyLocationOnIntermediateHost", "App", "myLocation"},
+		{"NoLocation", "Standalone", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetNodeLocationName(ctx, deploymentID, tt.nodeName)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func testNodeHasAttribute(t *testing.T, deploymentID string) {
 	type args struct {
 		nodeName       string
@@ -42,6 +42,9 @@ var lock sync.Mutex
 
 var builtinTypes = make([]string, 0)
 
+// builtinTypesOrigins maps builtin types paths to the origin that registered them
+var builtinTypesOrigins = make(map[string]string)
+
 // getLatestCommonsTypesKeyPaths() returns all the path keys corresponding to the last version of a type
 // that is stored under the consulutil.CommonsTypesKVPrefix.
 // For example, one path key could be _yorc/commons_types/some_type/2.0.0 if the last version
@@ -120,6 +123,7 @@ func CommonDefinition(ctx context.Context, definitionName, origin string, defini
 		if !collections.ContainsString(builtinTypes, topologyPrefix) {
 			builtinTypes = append(builtinTypes, topologyPrefix)
 		}
+		builtinTypesOrigins[topologyPrefix] = origin
 	}()
 
 	keys, err := storage.GetStore(types.StoreTypeDeployment).Keys(topologyPrefix)
@@ -142,6 +146,24 @@ func CommonDefinition(ctx context.Context, definitionName, origin string, defini
 	return errGroup.Wait()
 }
 
+// UnregisterCommonDefinitions removes TOSCA definitions registered by the given origin from the
+// list of builtin types supported by this instance of Yorc.
+//
+// Definitions are kept in the store as they may still be referenced by existing deployments.
+func UnregisterCommonDefinitions(origin string) {
+	lock.Lock()
+	defer lock.Unlock()
+	remaining := make([]string, 0, len(builtinTypes))
+	for _, p := range builtinTypes {
+		if o, ok := builtinTypesOrigins[p]; ok && o == origin {
+			delete(builtinTypesOrigins, p)
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	builtinTypes = remaining
+}
+
 // Deployment stores a whole deployment.
 func Deployment(ctx context.Context, topology tosca.Topology, deploymentID, rootDefPath string) error {
 	errGroup, ctx := errgroup.WithContext(ctx)
@@ -168,20 +190,26 @@ func GetCommonsDefinitionsList() ([]Definition, error) {
 		// So let use latest values of each stored builtin types in Consul
 		builtinTypes, _ = getLatestCommonsTypesKeyPaths()
 	}
-	res := make([]Definition, len(builtinTypes))
+	res := make([]Definition, 0, len(builtinTypes))
 	for _, p := range builtinTypes {
-		d := Definition{}
-		d.Version = path.Base(p)
-		p = path.Dir(p)
-		d.Name = path.Base(p)
-		res = append(res, d)
-		exist, value, err := consulutil.GetStringValue(path.Join(p, "metadata", yorcOriginConsulKey))
-		if err != nil {
-			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		d := Definition{
+			Name:    path.Base(path.Dir(p)),
+			Version: path.Base(p),
 		}
-		if exist {
-			d.Version = value
+		if origin, ok := builtinTypesOrigins[p]; ok {
+			d.Origin = origin
+		} else {
+			// Not registered by this instance, lookup the origin stored along with the definition
+			metadata := make(map[string]string)
+			exist, err := storage.GetStore(types.StoreTypeDeployment).Get(path.Join(p, "metadata"), &metadata)
+			if err != nil {
+				return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+			}
+			if exist {
+				d.Origin = metadata[yorcOriginConsulKey]
+			}
 		}
+		res = append(res, d)
 	}
 	return res, nil
 }
@@ -30,6 +30,7 @@ import (
 
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/internal/standalone"
 	"github.com/ystia/yorc/v4/log"
 )
 
@@ -72,7 +73,11 @@ func newTestConsulInstance(t *testing.T, cfg *config.Configuration) (*testutil.T
 		c.Args = []string{"-ui"}
 		c.LogLevel = logLevel
 	}
-	srv1, err := testutil.NewTestServerConfig(cb)
+	newTestServer := testutil.NewTestServerConfig
+	if standalone.TestServerEnabled() {
+		newTestServer = standalone.NewTestServer
+	}
+	srv1, err := newTestServer(cb)
 	if err != nil {
 		t.Fatalf("Failed to create consul server: %v", err)
 	}
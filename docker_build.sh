@@ -37,6 +37,7 @@ tf_consul_plugin_version=$(grep tf_consul_plugin_version ${script_dir}/versions.
 tf_aws_plugin_version=$(grep tf_aws_plugin_version ${script_dir}/versions.yaml | awk '{print $2}')
 tf_openstack_plugin_version=$(grep tf_openstack_plugin_version ${script_dir}/versions.yaml | awk '{print $2}')
 tf_google_plugin_version=$(grep tf_google_plugin_version ${script_dir}/versions.yaml | awk '{print $2}')
+tf_azurerm_plugin_version=$(grep tf_azurerm_plugin_version ${script_dir}/versions.yaml | awk '{print $2}')
 
 CI_TAG=""
 CI_PULL_REQUEST=""
@@ -75,6 +76,7 @@ docker build ${BUILD_ARGS} \
         --build-arg "TF_AWS_PLUGIN_VERSION=${tf_aws_plugin_version}" \
         --build-arg "TF_OPENSTACK_PLUGIN_VERSION=${tf_openstack_plugin_version}" \
         --build-arg "TF_GOOGLE_PLUGIN_VERSION=${tf_google_plugin_version}" \
+        --build-arg "TF_AZURERM_PLUGIN_VERSION=${tf_azurerm_plugin_version}" \
         -t "ystia/yorc:${DOCKER_TAG:-latest}" .
 
 if [[ "${GITHUB_ACTIONS}" == "true" ]]; then
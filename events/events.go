@@ -271,6 +271,11 @@ func LogsEvents(ctx context.Context, deploymentID string, waitIndex uint64, time
 	return getLogsOrEvents(ctx, deploymentID, waitIndex, timeout, false)
 }
 
+// SearchLogs returns the logs matching the given query
+func SearchLogs(ctx context.Context, query store.LogSearchQuery) (store.LogSearchResult, error) {
+	return storage.SearchLogs(ctx, storage.GetStore(types.StoreTypeLog), query)
+}
+
 // GetStatusEventsIndex returns the latest index of InstanceStatus events for a given deployment
 func GetStatusEventsIndex(deploymentID string) (uint64, error) {
 	return storage.GetStore(types.StoreTypeEvent).GetLastModifyIndex(path.Join(consulutil.EventsPrefix, deploymentID))
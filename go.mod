@@ -13,6 +13,7 @@ require (
 	github.com/abice/go-enum v0.2.3
 	github.com/alecthomas/participle v0.3.0
 	github.com/armon/go-metrics v0.3.0
+	github.com/armon/go-radix v0.0.0-20180808171621-7fddfc383310
 	github.com/asaskevich/govalidator v0.0.0-20200108200545-475eaeb16496 // indirect
 	github.com/blang/semver v3.5.1+incompatible
 	github.com/bradleyjkemp/cupaloy v2.3.0+incompatible // indirect
@@ -92,6 +93,7 @@ require (
 	github.com/stretchr/testify v1.4.0
 	github.com/tmc/dot v0.0.0-20180926222610-6d252d5ff882
 	github.com/ystia/tdt2go v0.3.0
+	go.etcd.io/bbolt v1.3.5
 	golang.org/x/crypto v0.0.0-20200510223506-06a226fb4e37
 	golang.org/x/net v0.0.0-20200202094626-16171245cfb2
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45 // indirect
@@ -266,13 +266,18 @@ func (cs *consulStore) StoreConsulKeyWithFlags(key string, value []byte, flags u
 // In the case where the transaction has to be split, and a pre-operation and
 // post-operation are provided, this function will add the pre and post operations
 // to the operations, else if will execute the operations within a single transaction.
+// Pre and post operations are optional, they can be nil.
 func ExecuteSplittableTransaction(ops api.KVTxnOps, preOpSplit, postOpSplit *api.KVTxnOp) error {
 
 	var newOps api.KVTxnOps
 	if len(ops) > maxNbTransactionOps {
-		newOps = append(newOps, preOpSplit)
+		if preOpSplit != nil {
+			newOps = append(newOps, preOpSplit)
+		}
 		newOps = append(newOps, ops...)
-		newOps = append(newOps, postOpSplit)
+		if postOpSplit != nil {
+			newOps = append(newOps, postOpSplit)
+		}
 	} else {
 		newOps = ops
 	}
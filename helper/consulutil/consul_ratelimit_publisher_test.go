@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/internal/standalone"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -42,7 +43,11 @@ func TestConsulStore(t *testing.T) {
 		c.Args = []string{"-ui"}
 		c.LogLevel = logLevel
 	}
-	srv, err := testutil.NewTestServerConfig(cb)
+	newTestServer := testutil.NewTestServerConfig
+	if standalone.TestServerEnabled() {
+		newTestServer = standalone.NewTestServer
+	}
+	srv, err := newTestServer(cb)
 	if err != nil {
 		t.Fatalf("Failed to create consul server: %v", err)
 	}
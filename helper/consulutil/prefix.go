@@ -61,3 +61,12 @@ const PurgedDeploymentKVPrefix string = yorcPrefix + "/purged"
 
 // StoresPrefix is the prefix in Consul KV store for stores
 const StoresPrefix string = yorcPrefix + "/stores"
+
+// StoresMigrationsPrefix is the prefix in Consul KV store for the progress of data migrations between stores
+const StoresMigrationsPrefix string = YorcManagementPrefix + "/stores_migrations"
+
+// StoresKeyRotationsPrefix is the prefix in Consul KV store for the progress of encryption key rotations of stores
+const StoresKeyRotationsPrefix string = YorcManagementPrefix + "/stores_key_rotations"
+
+// StoresUsageKey is the key in Consul KV store of the logs and events stores usage computed by the last compaction
+const StoresUsageKey string = YorcManagementPrefix + "/stores_usage"
@@ -17,6 +17,7 @@ package consulutil
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -29,6 +30,9 @@ import (
 // YorcService is the service name for yorc as a Consul service
 const YorcService = "yorc"
 
+// ServerTagsServiceMetaKey is the Consul service metadata key used to advertise the comma-separated list of tags of a Yorc server
+const ServerTagsServiceMetaKey = "server_tags"
+
 // RegisterServerAsConsulService allows to register the Yorc server as a Consul service
 func RegisterServerAsConsulService(cfg config.Configuration, cc *api.Client, chShutdown chan struct{}) error {
 	log.Printf("Register Yorc as Consul Service for node %q", cfg.ServerID)
@@ -58,6 +62,7 @@ func RegisterServerAsConsulService(cfg config.Configuration, cc *api.Client, chS
 	service = &api.AgentServiceRegistration{
 		Name: YorcService,
 		Tags: []string{"server", cfg.ServerID},
+		Meta: map[string]string{ServerTagsServiceMetaKey: strings.Join(cfg.ServerTags, ",")},
 		Port: cfg.HTTPPort,
 		Check: &api.AgentServiceCheck{
 			Name:          "HTTP check on yorc port",
@@ -0,0 +1,368 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backup allows to backup and restore the whole state of a Yorc cluster and to migrate data between stores.
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/server/info"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+// backupFormatVersion is the version of the backup archive layout.
+// It should be incremented on each incompatible change of the archive content.
+const backupFormatVersion = 1
+
+// Entries of a backup archive
+const (
+	backupMetadataEntry     = "metadata.json"
+	backupConsulEntry       = "consul.json"
+	backupStoresEntryPrefix = "stores/"
+)
+
+// runningExecutionsWaitTimeout is the maximum duration to wait for running task executions to finish
+// before performing a backup or a restore
+const runningExecutionsWaitTimeout = 5 * time.Minute
+
+// executionLockPrefix is the prefix of locks held by dispatchers on running task executions
+const executionLockPrefix = ".processingLock-"
+
+// Consul KV prefixes always saved into Consul part of backups
+var consulPrefixes = []string{
+	consulutil.DeploymentKVPrefix,
+	consulutil.CommonsTypesKVPrefix,
+	consulutil.TasksPrefix,
+	consulutil.ExecutionsTaskPrefix,
+	consulutil.WorkflowsPrefix,
+	consulutil.LocationsPrefix,
+	consulutil.HostsPoolPrefix,
+	consulutil.MonitoringKVPrefix,
+	consulutil.SchedulingKVPrefix,
+	consulutil.PurgedDeploymentKVPrefix,
+}
+
+// storeContent describes keys prefixes managed by a store type
+type storeContent struct {
+	// rootPrefixes are prefixes listed as a whole
+	rootPrefixes []string
+	// deploymentsPrefixes are prefixes listed per deployment
+	deploymentsPrefixes []string
+}
+
+var storesContents = map[types.StoreType]storeContent{
+	types.StoreTypeDeployment: {
+		rootPrefixes:        []string{consulutil.CommonsTypesKVPrefix},
+		deploymentsPrefixes: []string{consulutil.DeploymentKVPrefix},
+	},
+	types.StoreTypeLog: {
+		deploymentsPrefixes: []string{consulutil.LogsPrefix},
+	},
+	types.StoreTypeEvent: {
+		deploymentsPrefixes: []string{consulutil.EventsPrefix},
+	},
+}
+
+type backupMetadata struct {
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion string    `json:"schema_version"`
+	YorcVersion   string    `json:"yorc_version"`
+	BackupDate    time.Time `json:"backup_date"`
+	Stores        []string  `json:"stores"`
+}
+
+type backupKV struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+	Flags uint64 `json:"flags,omitempty"`
+}
+
+type backupStoreValue struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type maintenanceInProgressError struct{}
+
+func (e maintenanceInProgressError) Error() string {
+	return "another maintenance operation (backup or restore) is already in progress"
+}
+
+// IsMaintenanceInProgressError checks if an error is due to another backup or restore already in progress
+func IsMaintenanceInProgressError(err error) bool {
+	_, ok := errors.Cause(err).(maintenanceInProgressError)
+	return ok
+}
+
+type runningExecutionsError struct {
+	timeout time.Duration
+}
+
+func (e runningExecutionsError) Error() string {
+	return fmt.Sprintf("task executions still running after %s", e.timeout)
+}
+
+// IsRunningExecutionsError checks if an error is due to task executions that are still running
+// after waiting for their end
+func IsRunningExecutionsError(err error) bool {
+	_, ok := errors.Cause(err).(runningExecutionsError)
+	return ok
+}
+
+// Backup writes into w a zip archive containing the whole state of the Yorc cluster.
+//
+// The archive contains data stored into Consul under Yorc prefixes and the content of every configured store
+// that does not rely on Consul. It records the data schema version to prevent restoring it on an incompatible
+// version of Yorc.
+// A cluster-wide lock is held during the backup, new task executions are not dispatched and running ones
+// should end before the backup starts.
+// An error checkable with IsMaintenanceInProgressError is returned if another backup or restore is in progress.
+// An error checkable with IsRunningExecutionsError is returned if running task executions do not end in time.
+func Backup(ctx context.Context, cc *api.Client, w io.Writer) error {
+	lock, err := acquireMaintenanceLock(cc)
+	if err != nil {
+		return err
+	}
+	defer releaseMaintenanceLock(lock)
+
+	err = waitForRunningExecutions(ctx, runningExecutionsWaitTimeout)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting a backup of the Yorc cluster")
+	kvs, err := backupConsulPrefixes(getConsulPrefixes())
+	if err != nil {
+		return err
+	}
+	deploymentIDs, err := getDeploymentsIDs()
+	if err != nil {
+		return err
+	}
+	storesValues := make(map[types.StoreType][]backupStoreValue)
+	metadata := backupMetadata{
+		FormatVersion: backupFormatVersion,
+		SchemaVersion: consulutil.YorcSchemaVersion,
+		YorcVersion:   info.YorcVersion,
+		BackupDate:    time.Now(),
+		Stores:        make([]string, 0),
+	}
+	for _, name := range types.StoreTypeNames() {
+		storeType, err := types.ParseStoreType(name)
+		if err != nil {
+			return err
+		}
+		if storage.IsConsulStore(storeType) {
+			continue
+		}
+		storesValues[storeType], err = backupStore(ctx, storage.GetStore(storeType), storesContents[storeType], deploymentIDs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to backup %s store", storeType)
+		}
+		metadata.Stores = append(metadata.Stores, storeType.String())
+	}
+
+	zw := zip.NewWriter(w)
+	err = writeJSONEntry(zw, backupMetadataEntry, metadata)
+	if err != nil {
+		return err
+	}
+	err = writeJSONEntry(zw, backupConsulEntry, kvs)
+	if err != nil {
+		return err
+	}
+	for storeType, values := range storesValues {
+		err = writeJSONEntry(zw, storeEntryName(storeType), values)
+		if err != nil {
+			return err
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write backup archive")
+	}
+	log.Printf("Backup of the Yorc cluster done")
+	return nil
+}
+
+// getConsulPrefixes returns Yorc prefixes holding data stored directly into Consul
+// and prefixes of stores implemented using Consul
+func getConsulPrefixes() []string {
+	prefixes := append([]string{}, consulPrefixes...)
+	for storeType, content := range storesContents {
+		if !storage.IsConsulStore(storeType) {
+			continue
+		}
+		for _, p := range append(content.rootPrefixes, content.deploymentsPrefixes...) {
+			if !containsPrefix(prefixes, p) {
+				prefixes = append(prefixes, p)
+			}
+		}
+	}
+	return prefixes
+}
+
+func containsPrefix(prefixes []string, prefix string) bool {
+	for _, p := range prefixes {
+		if p == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func storeEntryName(storeType types.StoreType) string {
+	return backupStoresEntryPrefix + storeType.String() + ".json"
+}
+
+func acquireMaintenanceLock(cc *api.Client) (*api.Lock, error) {
+	lock, err := cc.LockOpts(&api.LockOptions{
+		Key:         consulutil.MaintenanceLockKey,
+		Value:       []byte(time.Now().Format(time.RFC3339)),
+		LockTryOnce: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	lockCh, err := lock.Lock(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if lockCh == nil {
+		return nil, errors.WithStack(maintenanceInProgressError{})
+	}
+	return lock, nil
+}
+
+func releaseMaintenanceLock(lock *api.Lock) {
+	err := lock.Unlock()
+	if err != nil {
+		log.Printf("[WARNING] failed to release maintenance lock: %v", err)
+		return
+	}
+	// will fail if another instance takes it
+	lock.Destroy()
+}
+
+// waitForRunningExecutions waits for the release of all locks held by dispatchers on task executions
+func waitForRunningExecutions(ctx context.Context, timeout time.Duration) error {
+	timeAfter := time.After(timeout)
+	for {
+		running, err := hasRunningExecutions()
+		if err != nil || !running {
+			return err
+		}
+		log.Debugf("Waiting for running task executions to finish before starting maintenance operation")
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-timeAfter:
+			return errors.WithStack(runningExecutionsError{timeout})
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func hasRunningExecutions() (bool, error) {
+	kvps, _, err := consulutil.GetKV().List(consulutil.ExecutionsTaskPrefix+"/"+executionLockPrefix, nil)
+	if err != nil {
+		return false, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for _, kvp := range kvps {
+		if kvp.Session != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getDeploymentsIDs returns IDs of existing and purged deployments
+func getDeploymentsIDs() ([]string, error) {
+	ids := make([]string, 0)
+	for _, prefix := range []string{consulutil.DeploymentKVPrefix, consulutil.PurgedDeploymentKVPrefix} {
+		keys, _, err := consulutil.GetKV().Keys(prefix+"/", "/", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		for _, k := range keys {
+			id := path.Base(k)
+			if !containsPrefix(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// backupConsulPrefixes returns all Consul KV pairs under the given prefixes.
+// Locks are ignored as they are related to running processes.
+func backupConsulPrefixes(prefixes []string) ([]backupKV, error) {
+	result := make([]backupKV, 0)
+	for _, prefix := range prefixes {
+		kvps, _, err := consulutil.GetKV().List(prefix+"/", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		for _, kvp := range kvps {
+			if kvp.Session != "" || strings.HasSuffix(kvp.Key, "/") {
+				continue
+			}
+			result = append(result, backupKV{Key: kvp.Key, Value: kvp.Value, Flags: kvp.Flags})
+		}
+	}
+	return result, nil
+}
+
+func backupStore(ctx context.Context, s store.Store, content storeContent, deploymentIDs []string) ([]backupStoreValue, error) {
+	keys := append([]string{}, content.rootPrefixes...)
+	for _, prefix := range content.deploymentsPrefixes {
+		for _, id := range deploymentIDs {
+			keys = append(keys, path.Join(prefix, id))
+		}
+	}
+	result := make([]backupStoreValue, 0)
+	for _, k := range keys {
+		kvs, _, err := s.List(ctx, k, 0, 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list store values under %q", k)
+		}
+		for _, kv := range kvs {
+			result = append(result, backupStoreValue{Key: kv.Key, Value: kv.RawValue})
+		}
+	}
+	return result, nil
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive entry %q", name)
+	}
+	return errors.Wrapf(json.NewEncoder(fw).Encode(v), "failed to write archive entry %q", name)
+}
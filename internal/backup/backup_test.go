@@ -0,0 +1,121 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+)
+
+func TestIsYorcKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{"DeploymentKey", consulutil.DeploymentKVPrefix + "/dep1/status", true},
+		{"TaskKey", consulutil.TasksPrefix + "/t1/status", true},
+		{"LogKey", consulutil.LogsPrefix + "/dep1/1", true},
+		{"HostsPoolKey", consulutil.HostsPoolPrefix + "/loc/host1/status", true},
+		{"ServiceKey", consulutil.YorcServicePrefix + "/srv1", false},
+		{"ManagementKey", consulutil.MaintenanceLockKey, false},
+		{"PrefixOnly", consulutil.TasksPrefix, false},
+		{"OtherKey", "other/key", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isYorcKey(tt.key))
+		})
+	}
+}
+
+func TestCheckMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata backupMetadata
+		wantErr  bool
+	}{
+		{"SameVersions", backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: consulutil.YorcSchemaVersion}, false},
+		{"OtherFormat", backupMetadata{FormatVersion: backupFormatVersion + 1, SchemaVersion: consulutil.YorcSchemaVersion}, true},
+		{"OlderSchema", backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: "1.0.0"}, true},
+		{"NewerSchema", backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: "99.0.0"}, true},
+		{"InvalidSchema", backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: "notaversion"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMetadata(tt.metadata)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.True(t, IsInvalidBackupError(err), "unexpected error type: %v", err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRestoreInvalidArchive(t *testing.T) {
+	validMetadata := backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: consulutil.YorcSchemaVersion, Stores: []string{"Deployment"}}
+	tests := []struct {
+		name    string
+		entries map[string]interface{}
+	}{
+		{"MissingMetadata", map[string]interface{}{backupConsulEntry: []backupKV{}}},
+		{"IncompatibleSchema", map[string]interface{}{
+			backupMetadataEntry: backupMetadata{FormatVersion: backupFormatVersion, SchemaVersion: "1.0.0"},
+			backupConsulEntry:   []backupKV{},
+		}},
+		{"MissingConsulEntry", map[string]interface{}{backupMetadataEntry: validMetadata}},
+		{"UnexpectedConsulKey", map[string]interface{}{
+			backupMetadataEntry: validMetadata,
+			backupConsulEntry:   []backupKV{{Key: consulutil.YorcServicePrefix + "/srv1"}},
+		}},
+		{"MissingStoreEntry", map[string]interface{}{
+			backupMetadataEntry: validMetadata,
+			backupConsulEntry:   []backupKV{},
+		}},
+		{"UnexpectedStoreKey", map[string]interface{}{
+			backupMetadataEntry: validMetadata,
+			backupConsulEntry:   []backupKV{},
+			"stores/Deployment.json": []backupStoreValue{
+				{Key: consulutil.LogsPrefix + "/dep1/1", Value: json.RawMessage(`{}`)},
+			},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			zw := zip.NewWriter(buf)
+			for name, content := range tt.entries {
+				require.NoError(t, writeJSONEntry(zw, name, content))
+			}
+			require.NoError(t, zw.Close())
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err)
+
+			// Archive is checked before any access to Consul
+			err = Restore(context.Background(), nil, zr)
+			require.Error(t, err)
+			require.True(t, IsInvalidBackupError(err), "unexpected error type: %v", err)
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+// Statuses of a data migration between stores
+const (
+	MigrationStatusRunning = "running"
+	MigrationStatusDone    = "done"
+	MigrationStatusFailed  = "failed"
+)
+
+// migrationBatchSize is the maximum number of key-values written at once into the target store
+const migrationBatchSize = 500
+
+// migrationPageTimeout is the time waited for values modified after the previous page of a listing
+const migrationPageTimeout = 10 * time.Millisecond
+
+// migrationCompletedPrefixesKey is the key under a migration key where a key is stored for each completed prefix
+const migrationCompletedPrefixesKey = "completed"
+
+// MigrationRequest describes a data migration between two configured stores
+type MigrationRequest struct {
+	// From is the name of the store data are read from
+	From string `json:"from"`
+	// To is the name of the store data are written to
+	To string `json:"to"`
+	// Types are the names of the migrated store types, all types are migrated if empty
+	Types []string `json:"types,omitempty"`
+}
+
+// Migration describes the progress of a data migration between two stores.
+//
+// It is saved into Consul after each migrated prefix, so an interrupted migration is resumed
+// by requesting it again. Completed prefixes are stored in their own keys as there may be
+// thousands of them.
+type Migration struct {
+	MigrationRequest
+	ID                string     `json:"id"`
+	Status            string     `json:"status"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           *time.Time `json:"end_date,omitempty"`
+	TotalPrefixes     int        `json:"total_prefixes"`
+	CompletedPrefixes int        `json:"completed_prefixes"`
+	MigratedKeys      int        `json:"migrated_keys"`
+	Error             string     `json:"error,omitempty"`
+}
+
+type invalidMigrationError struct {
+	reason string
+}
+
+func (e invalidMigrationError) Error() string {
+	return fmt.Sprintf("invalid stores migration: %s", e.reason)
+}
+
+// IsInvalidMigrationError checks if an error is due to an invalid migration request
+func IsInvalidMigrationError(err error) bool {
+	_, ok := errors.Cause(err).(invalidMigrationError)
+	return ok
+}
+
+type migrationNotFoundError struct {
+	id string
+}
+
+func (e migrationNotFoundError) Error() string {
+	return fmt.Sprintf("no stores migration found with id %q", e.id)
+}
+
+// IsMigrationNotFoundError checks if an error is due to an unknown migration
+func IsMigrationNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(migrationNotFoundError)
+	return ok
+}
+
+type verificationError struct {
+	prefix string
+	reason string
+}
+
+func (e verificationError) Error() string {
+	return fmt.Sprintf("verification of migrated data under %q failed: %s", e.prefix, e.reason)
+}
+
+// MigrationID returns the ID of a migration between the two given stores
+func MigrationID(from, to string) string {
+	return from + "-to-" + to
+}
+
+// StartMigration starts migrating data of the given store types from one configured store to another one.
+//
+// Keys of each store type are listed by prefix from the source store, written into the target store and then
+// read back from the target store to verify them. The progress is saved after each prefix, if a previous migration
+// between the same stores and for the same types did not complete, it is resumed from its last migrated prefix.
+// Data are not removed from the source store and stores configuration is not changed.
+// Like for Backup, a cluster-wide lock is held during the migration and running task executions should end
+// before the migration starts.
+// The migration runs in background, its progress is retrieved using GetMigration.
+// An error checkable with IsInvalidMigrationError is returned if the request is not valid.
+// An error checkable with IsMaintenanceInProgressError is returned if another maintenance operation is in progress.
+func StartMigration(cc *api.Client, cfg config.Configuration, request MigrationRequest) (*Migration, error) {
+	storeTypes, err := checkMigrationRequest(&request)
+	if err != nil {
+		return nil, err
+	}
+	from, err := storage.NewStoreByName(cfg, request.From)
+	if err != nil {
+		if storage.IsStoreNotFoundError(err) {
+			return nil, errors.WithStack(invalidMigrationError{err.Error()})
+		}
+		return nil, err
+	}
+	to, err := storage.NewStoreByName(cfg, request.To)
+	if err != nil {
+		if storage.IsStoreNotFoundError(err) {
+			return nil, errors.WithStack(invalidMigrationError{err.Error()})
+		}
+		return nil, err
+	}
+
+	lock, err := acquireMaintenanceLock(cc)
+	if err != nil {
+		return nil, err
+	}
+
+	m, completed, err := prepareMigration(request)
+	if err != nil {
+		releaseMaintenanceLock(lock)
+		return nil, err
+	}
+	result := *m
+
+	go func() {
+		defer releaseMaintenanceLock(lock)
+		ctx := context.Background()
+		err := waitForRunningExecutions(ctx, runningExecutionsWaitTimeout)
+		if err == nil {
+			err = runMigration(ctx, m, completed, storeTypes, from, to)
+		}
+		endDate := time.Now()
+		m.EndDate = &endDate
+		m.Status = MigrationStatusDone
+		if err != nil {
+			log.Printf("[ERROR] Stores migration %q failed: %+v", m.ID, err)
+			m.Status = MigrationStatusFailed
+			m.Error = err.Error()
+		} else {
+			log.Printf("Stores migration %q done: %d keys migrated and verified", m.ID, m.MigratedKeys)
+		}
+		if err = saveMigration(m); err != nil {
+			log.Printf("[WARNING] failed to save stores migration %q status: %v", m.ID, err)
+		}
+	}()
+	return &result, nil
+}
+
+// GetMigration returns the progress of a data migration between two stores
+//
+// An error checkable with IsMigrationNotFoundError is returned if there is no migration with this ID.
+func GetMigration(id string) (*Migration, error) {
+	m := new(Migration)
+	found, err := getMigration(id, m)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.WithStack(migrationNotFoundError{id})
+	}
+	return m, nil
+}
+
+func getMigration(id string, m *Migration) (bool, error) {
+	kvp, _, err := consulutil.GetKV().Get(path.Join(consulutil.StoresMigrationsPrefix, id), nil)
+	if err != nil {
+		return false, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return false, nil
+	}
+	return true, errors.Wrapf(json.Unmarshal(kvp.Value, m), "failed to read stores migration %q", id)
+}
+
+func saveMigration(m *Migration) error {
+	return consulutil.StoreConsulKeyWithJSONValue(path.Join(consulutil.StoresMigrationsPrefix, m.ID), m)
+}
+
+// saveCompletedPrefix records that the given prefix was migrated and saves the migration progress
+func saveCompletedPrefix(m *Migration, prefix string) error {
+	err := consulutil.StoreConsulKeyAsString(path.Join(consulutil.StoresMigrationsPrefix, m.ID, migrationCompletedPrefixesKey, prefix), "")
+	if err != nil {
+		return err
+	}
+	return saveMigration(m)
+}
+
+// getCompletedPrefixes returns the prefixes already migrated by the given migration
+func getCompletedPrefixes(id string) (map[string]bool, error) {
+	completedKey := path.Join(consulutil.StoresMigrationsPrefix, id, migrationCompletedPrefixesKey) + "/"
+	keys, _, err := consulutil.GetKV().Keys(completedKey, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	completed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		completed[strings.TrimPrefix(key, completedKey)] = true
+	}
+	return completed, nil
+}
+
+// checkMigrationRequest validates the request, sets default types and returns the store types to migrate
+func checkMigrationRequest(request *MigrationRequest) ([]types.StoreType, error) {
+	if request.From == "" || request.To == "" {
+		return nil, errors.WithStack(invalidMigrationError{"source and target stores names are required"})
+	}
+	if request.From == request.To {
+		return nil, errors.WithStack(invalidMigrationError{"source and target stores should be different"})
+	}
+	if strings.Contains(request.From, "/") || strings.Contains(request.To, "/") {
+		return nil, errors.WithStack(invalidMigrationError{"stores names should not contain '/'"})
+	}
+	if len(request.Types) == 0 {
+		request.Types = types.StoreTypeNames()
+	}
+	storeTypes := make([]types.StoreType, 0, len(request.Types))
+	for _, name := range request.Types {
+		storeType, err := types.ParseStoreType(name)
+		if err != nil {
+			return nil, errors.WithStack(invalidMigrationError{fmt.Sprintf("unknown store type %q", name)})
+		}
+		storeTypes = append(storeTypes, storeType)
+	}
+	return storeTypes, nil
+}
+
+// prepareMigration returns a new migration or the previous one between the same stores and for the same types
+// if it did not complete, as well as the prefixes it already migrated
+func prepareMigration(request MigrationRequest) (*Migration, map[string]bool, error) {
+	id := MigrationID(request.From, request.To)
+	previous := new(Migration)
+	found, err := getMigration(id, previous)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found && previous.Status != MigrationStatusDone && sameTypes(previous.Types, request.Types) {
+		completed, err := getCompletedPrefixes(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("Resuming stores migration %q: %d prefixes already migrated", id, len(completed))
+		previous.Status = MigrationStatusRunning
+		previous.EndDate = nil
+		previous.Error = ""
+		previous.CompletedPrefixes = len(completed)
+		return previous, completed, saveMigration(previous)
+	}
+	_, err = consulutil.GetKV().DeleteTree(path.Join(consulutil.StoresMigrationsPrefix, id, migrationCompletedPrefixesKey)+"/", nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	m := &Migration{
+		MigrationRequest: request,
+		ID:               id,
+		Status:           MigrationStatusRunning,
+		StartDate:        time.Now(),
+	}
+	log.Printf("Starting stores migration %q for types %v", id, request.Types)
+	return m, make(map[string]bool), saveMigration(m)
+}
+
+func sameTypes(t1, t2 []string) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for _, t := range t1 {
+		if !containsPrefix(t2, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func runMigration(ctx context.Context, m *Migration, completed map[string]bool, storeTypes []types.StoreType, from, to store.Store) error {
+	deploymentIDs, err := getDeploymentsIDs()
+	if err != nil {
+		return err
+	}
+	prefixes := make([]string, 0)
+	for _, storeType := range storeTypes {
+		content := storesContents[storeType]
+		prefixes = append(prefixes, content.rootPrefixes...)
+		for _, prefix := range content.deploymentsPrefixes {
+			for _, id := range deploymentIDs {
+				prefixes = append(prefixes, path.Join(prefix, id))
+			}
+		}
+	}
+	return migratePrefixes(ctx, m, prefixes, completed, from, to, saveCompletedPrefix)
+}
+
+// migratePrefixes migrates keys under the given prefixes which are not already completed and calls save
+// after each one
+func migratePrefixes(ctx context.Context, m *Migration, prefixes []string, completed map[string]bool, from, to store.Store, save func(*Migration, string) error) error {
+	m.TotalPrefixes = len(prefixes)
+	for _, prefix := range prefixes {
+		if completed[prefix] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+		count, err := migratePrefix(ctx, prefix, from, to)
+		if err != nil {
+			return err
+		}
+		m.MigratedKeys += count
+		m.CompletedPrefixes++
+		log.Debugf("Stores migration %q: %d/%d prefixes migrated", m.ID, m.CompletedPrefixes, m.TotalPrefixes)
+		if err = save(m, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migratePrefix copies keys under the given prefix and verifies them, it returns the number of migrated keys
+func migratePrefix(ctx context.Context, prefix string, from, to store.Store) (int, error) {
+	// digests of migrated values by key, used to verify the target store
+	digests := make(map[string]uint64)
+	err := listPages(ctx, from, prefix, func(kvs []store.KeyValueOut) error {
+		batch := make([]store.KeyValueIn, 0, migrationBatchSize)
+		for _, kv := range kvs {
+			kv, err := sourceKeyValue(prefix, kv, digests)
+			if err != nil {
+				return err
+			}
+			digests[kv.Key] = valueDigest(kv.Value)
+			batch = append(batch, store.KeyValueIn{Key: kv.Key, Value: json.RawMessage(kv.RawValue)})
+			if len(batch) == migrationBatchSize {
+				if err = to.SetCollection(ctx, batch); err != nil {
+					return errors.Wrapf(err, "failed to write target store values under %q", prefix)
+				}
+				batch = make([]store.KeyValueIn, 0, migrationBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			if err := to.SetCollection(ctx, batch); err != nil {
+				return errors.Wrapf(err, "failed to write target store values under %q", prefix)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to migrate source store values under %q", prefix)
+	}
+	return len(digests), verifyPrefix(ctx, prefix, digests, to)
+}
+
+// listPages calls f with the successive pages of values listed under the given prefix.
+//
+// Some stores like elastic limit the number of values returned by a listing, next values are
+// listed using the last index of the previous page until no more values are returned.
+func listPages(ctx context.Context, s store.Store, prefix string, f func([]store.KeyValueOut) error) error {
+	var index uint64
+	var timeout time.Duration
+	for {
+		kvs, lastIndex, err := s.List(ctx, prefix, index, timeout)
+		if err != nil {
+			return err
+		}
+		if len(kvs) == 0 || (index > 0 && lastIndex <= index) {
+			return nil
+		}
+		if err = f(kvs); err != nil {
+			return err
+		}
+		index = lastIndex
+		timeout = migrationPageTimeout
+	}
+}
+
+// sourceKeyValue returns a key-value read from a source store with its original key.
+//
+// Some stores like elastic don't keep original keys of logs and events, those keys are then rebuilt
+// from the values timestamps and fields added by the store are removed. As several values may have
+// the same timestamp, a rebuilt key already used by another value is shifted by a nanosecond.
+func sourceKeyValue(prefix string, kv store.KeyValueOut, usedKeys map[string]uint64) (store.KeyValueOut, error) {
+	if strings.HasPrefix(kv.Key, prefix+"/") {
+		return kv, nil
+	}
+	timestamp, ok := kv.Value["timestamp"].(string)
+	if !ok || timestamp == "" {
+		return kv, errors.Errorf("failed to determine the original key of value %q listed under %q", kv.Key, prefix)
+	}
+	key := path.Join(prefix, timestamp)
+	if _, used := usedKeys[key]; used {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return kv, errors.Wrapf(err, "failed to parse timestamp of value %q listed under %q", kv.Key, prefix)
+		}
+		for used {
+			t = t.Add(time.Nanosecond)
+			key = path.Join(prefix, t.Format(time.RFC3339Nano))
+			_, used = usedKeys[key]
+		}
+	}
+	value := make(map[string]interface{}, len(kv.Value))
+	for k, v := range kv.Value {
+		if k != "iid" && k != "iidStr" {
+			value[k] = v
+		}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return kv, errors.Wrapf(err, "failed to marshal value %q listed under %q", kv.Key, prefix)
+	}
+	return store.KeyValueOut{Key: key, LastModifyIndex: kv.LastModifyIndex, Value: value, RawValue: raw}, nil
+}
+
+// valueDigest returns a digest of a value which doesn't depend on the way it is encoded by stores
+func valueDigest(value map[string]interface{}) uint64 {
+	// maps keys are sorted when marshaled
+	b, _ := json.Marshal(value)
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
+
+// verifyPrefix checks that migrated values are read back from the target store.
+//
+// Values found using their keys should be the same than source values, given by their digests. As some
+// stores don't keep original keys, values not found using their keys are only counted.
+func verifyPrefix(ctx context.Context, prefix string, digests map[string]uint64, to store.Store) error {
+	if len(digests) == 0 {
+		return nil
+	}
+	var found, others int
+	err := listPages(ctx, to, prefix, func(kvs []store.KeyValueOut) error {
+		for _, kv := range kvs {
+			digest, ok := digests[kv.Key]
+			if !ok {
+				others++
+				continue
+			}
+			found++
+			if digest != valueDigest(kv.Value) {
+				return errors.WithStack(verificationError{prefix, fmt.Sprintf("value of key %q differs", kv.Key)})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if _, ok := errors.Cause(err).(verificationError); ok {
+			return err
+		}
+		return errors.Wrapf(err, "failed to list target store values under %q", prefix)
+	}
+	if missing := len(digests) - found - others; missing > 0 {
+		return errors.WithStack(verificationError{prefix, fmt.Sprintf("%d values are missing", missing)})
+	}
+	return nil
+}
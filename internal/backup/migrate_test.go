@@ -0,0 +1,275 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+// memStore is a minimal in-memory store keeping raw JSON values
+type memStore struct {
+	lock    sync.Mutex
+	values  map[string][]byte
+	indexes map[string]uint64
+	index   uint64
+	// pageSize allows to mimic stores limiting the number of values returned by a listing
+	pageSize int
+	// withoutKeys allows to mimic stores not keeping original keys: keys are documents IDs and all values are listed
+	withoutKeys bool
+	// sets counts SetCollection calls
+	sets int
+}
+
+func newMemStore() *memStore {
+	return &memStore{values: make(map[string][]byte), indexes: make(map[string]uint64)}
+}
+
+func (s *memStore) setRaw(k string, b []byte) {
+	s.index++
+	s.values[k] = b
+	s.indexes[k] = s.index
+}
+
+func (s *memStore) Set(ctx context.Context, k string, v interface{}) error {
+	return s.SetCollection(ctx, []store.KeyValueIn{{Key: k, Value: v}})
+}
+
+func (s *memStore) SetCollection(ctx context.Context, keyValues []store.KeyValueIn) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sets++
+	for _, kv := range keyValues {
+		b, err := json.Marshal(kv.Value)
+		if err != nil {
+			return err
+		}
+		k := kv.Key
+		if s.withoutKeys {
+			k = fmt.Sprintf("doc-%d", s.index+1)
+		}
+		s.setRaw(k, b)
+	}
+	return nil
+}
+
+func (s *memStore) Get(k string, v interface{}) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.values[k]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, v)
+}
+
+func (s *memStore) Exist(k string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, ok := s.values[k]
+	return ok, nil
+}
+
+func (s *memStore) Keys(k string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *memStore) Delete(ctx context.Context, k string, recursive bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.values, k)
+	delete(s.indexes, k)
+	return nil
+}
+
+func (s *memStore) GetLastModifyIndex(k string) (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.index, nil
+}
+
+func (s *memStore) List(ctx context.Context, k string, waitIndex uint64, timeout time.Duration) ([]store.KeyValueOut, uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := make([]string, 0)
+	for key := range s.values {
+		if (strings.HasPrefix(key, k+"/") || s.withoutKeys) && s.indexes[key] > waitIndex {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.indexes[keys[i]] < s.indexes[keys[j]] })
+	lastIndex := s.index
+	if s.pageSize > 0 && len(keys) > s.pageSize {
+		keys = keys[:s.pageSize]
+		lastIndex = s.indexes[keys[len(keys)-1]]
+	}
+	kvs := make([]store.KeyValueOut, 0, len(keys))
+	for _, key := range keys {
+		var value map[string]interface{}
+		if err := json.Unmarshal(s.values[key], &value); err != nil {
+			return nil, 0, err
+		}
+		kvs = append(kvs, store.KeyValueOut{Key: key, LastModifyIndex: s.indexes[key], Value: value, RawValue: s.values[key]})
+	}
+	return kvs, lastIndex, nil
+}
+
+func populateMemStore(t *testing.T, s *memStore, prefix string, count int) {
+	kvs := make([]store.KeyValueIn, 0, count)
+	for i := 0; i < count; i++ {
+		timestamp := time.Date(2020, 1, 1, 0, 0, i, 0, time.UTC).Format(time.RFC3339Nano)
+		kvs = append(kvs, store.KeyValueIn{
+			Key:   path.Join(prefix, timestamp),
+			Value: map[string]interface{}{"timestamp": timestamp, "content": fmt.Sprintf("log %d", i)},
+		})
+	}
+	require.NoError(t, s.SetCollection(context.Background(), kvs))
+}
+
+func TestCheckMigrationRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   MigrationRequest
+		wantTypes []string
+		wantErr   bool
+	}{
+		{"AllTypesByDefault", MigrationRequest{From: "s1", To: "s2"}, []string{"Deployment", "Log", "Event"}, false},
+		{"SomeTypes", MigrationRequest{From: "s1", To: "s2", Types: []string{"Log", "Event"}}, []string{"Log", "Event"}, false},
+		{"MissingSource", MigrationRequest{To: "s2"}, nil, true},
+		{"SameStores", MigrationRequest{From: "s1", To: "s1"}, nil, true},
+		{"InvalidName", MigrationRequest{From: "s1/x", To: "s2"}, nil, true},
+		{"UnknownType", MigrationRequest{From: "s1", To: "s2", Types: []string{"Log", "Other"}}, nil, true},
+		{"TypesAreCaseSensitive", MigrationRequest{From: "s1", To: "s2", Types: []string{"log"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storeTypes, err := checkMigrationRequest(&tt.request)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.True(t, IsInvalidMigrationError(err), "unexpected error type: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantTypes, tt.request.Types)
+			require.Len(t, storeTypes, len(tt.wantTypes))
+		})
+	}
+}
+
+func TestMigratePrefixes(t *testing.T) {
+	ctx := context.Background()
+	from := newMemStore()
+	// the source store returns values by pages
+	from.pageSize = migrationBatchSize + 5
+	to := newMemStore()
+	p1 := path.Join(consulutil.LogsPrefix, "dep1")
+	p2 := path.Join(consulutil.LogsPrefix, "dep2")
+	p3 := path.Join(consulutil.LogsPrefix, "dep3")
+	populateMemStore(t, from, p1, 3)
+	populateMemStore(t, from, p2, migrationBatchSize+10)
+
+	// p1 was migrated by an interrupted migration
+	m := &Migration{ID: "test", CompletedPrefixes: 1}
+	var saved []string
+	err := migratePrefixes(ctx, m, []string{p1, p2, p3}, map[string]bool{p1: true}, from, to, func(_ *Migration, prefix string) error {
+		saved = append(saved, prefix)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, m.TotalPrefixes)
+	require.Equal(t, 3, m.CompletedPrefixes)
+	require.Equal(t, migrationBatchSize+10, m.MigratedKeys)
+	require.Equal(t, []string{p2, p3}, saved)
+	// Keys are written by batches of each page
+	require.Equal(t, 3, to.sets)
+	require.Len(t, to.values, migrationBatchSize+10)
+	for k, v := range from.values {
+		if strings.HasPrefix(k, p2) {
+			require.JSONEq(t, string(v), string(to.values[k]))
+		}
+	}
+}
+
+func TestMigratePrefixWithoutOriginalKeys(t *testing.T) {
+	ctx := context.Background()
+	prefix := path.Join(consulutil.EventsPrefix, "dep1")
+
+	// Mimic an elastic store as source: keys are documents IDs and fields are added to values
+	from := newMemStore()
+	from.withoutKeys = true
+	from.setRaw("doc1", []byte(`{"timestamp":"2020-01-01T00:00:01Z","status":"done","iid":"1577836801000000000","iidStr":"1577836801000000000"}`))
+	// Values with the same timestamp should not overwrite each other
+	from.setRaw("doc2", []byte(`{"timestamp":"2020-01-01T00:00:01Z","status":"failed","iid":"1577836801000000000","iidStr":"1577836801000000000"}`))
+	from.setRaw("doc3", []byte(`{"timestamp":"2020-01-01T00:00:01.000000001Z","status":"initial","iid":"1577836801000000001","iidStr":"1577836801000000001"}`))
+	to := newMemStore()
+	count, err := migratePrefix(ctx, prefix, from, to)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.Len(t, to.values, 3)
+	require.JSONEq(t, `{"timestamp":"2020-01-01T00:00:01Z","status":"done"}`, string(to.values[path.Join(prefix, "2020-01-01T00:00:01Z")]))
+	require.JSONEq(t, `{"timestamp":"2020-01-01T00:00:01Z","status":"failed"}`, string(to.values[path.Join(prefix, "2020-01-01T00:00:01.000000001Z")]))
+	require.JSONEq(t, `{"timestamp":"2020-01-01T00:00:01.000000001Z","status":"initial"}`, string(to.values[path.Join(prefix, "2020-01-01T00:00:01.000000002Z")]))
+
+	// Mimic an elastic store as target: values are verified by count
+	from = newMemStore()
+	populateMemStore(t, from, prefix, 2)
+	to = newMemStore()
+	to.withoutKeys = true
+	count, err = migratePrefix(ctx, prefix, from, to)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestVerifyPrefix(t *testing.T) {
+	ctx := context.Background()
+	prefix := path.Join(consulutil.LogsPrefix, "dep1")
+	from := newMemStore()
+	populateMemStore(t, from, prefix, 2)
+	values, _, err := from.List(ctx, prefix, 0, 0)
+	require.NoError(t, err)
+	digests := make(map[string]uint64)
+	for _, kv := range values {
+		digests[kv.Key] = valueDigest(kv.Value)
+	}
+
+	to := newMemStore()
+	to.setRaw(values[0].Key, values[0].RawValue)
+	to.setRaw(values[1].Key, []byte(`{"timestamp":"other"}`))
+	err = verifyPrefix(ctx, prefix, digests, to)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "differs")
+
+	require.NoError(t, to.Delete(ctx, values[1].Key, false))
+	err = verifyPrefix(ctx, prefix, digests, to)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 values are missing")
+
+	// Values are counted through all the pages of the target store
+	to.pageSize = 1
+	to.setRaw(values[1].Key, values[1].RawValue)
+	require.NoError(t, verifyPrefix(ctx, prefix, digests, to))
+}
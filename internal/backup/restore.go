@@ -0,0 +1,229 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/server/upgradeschema"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+type invalidBackupError struct {
+	reason string
+}
+
+func (e invalidBackupError) Error() string {
+	return fmt.Sprintf("invalid backup archive: %s", e.reason)
+}
+
+// IsInvalidBackupError checks if an error is due to an invalid or incompatible backup archive given to Restore
+func IsInvalidBackupError(err error) bool {
+	_, ok := errors.Cause(err).(invalidBackupError)
+	return ok
+}
+
+// Restore replaces the whole state of the Yorc cluster by the one saved into an archive generated by Backup.
+//
+// An error checkable with IsInvalidBackupError is returned if the archive is not a valid backup archive
+// or if it was generated using a data schema version incompatible with this version of Yorc.
+// Like for Backup, a cluster-wide lock is held during the restore and running task executions should end
+// before the restore starts.
+// Yorc servers should be restarted after a restore as they may keep some data in cache.
+func Restore(ctx context.Context, cc *api.Client, archive *zip.Reader) error {
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+	var metadata backupMetadata
+	err := readJSONEntry(entries, backupMetadataEntry, &metadata)
+	if err != nil {
+		return err
+	}
+	err = checkMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	var kvs []backupKV
+	err = readJSONEntry(entries, backupConsulEntry, &kvs)
+	if err != nil {
+		return err
+	}
+	for _, kv := range kvs {
+		if !isYorcKey(kv.Key) {
+			return errors.WithStack(invalidBackupError{fmt.Sprintf("unexpected Consul key %q", kv.Key)})
+		}
+	}
+	storesValues := make(map[types.StoreType][]backupStoreValue, len(metadata.Stores))
+	for _, name := range metadata.Stores {
+		storeType, err := types.ParseStoreType(name)
+		if err != nil {
+			return errors.WithStack(invalidBackupError{err.Error()})
+		}
+		var values []backupStoreValue
+		err = readJSONEntry(entries, storeEntryName(storeType), &values)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if !storesContents[storeType].contains(v.Key) {
+				return errors.WithStack(invalidBackupError{fmt.Sprintf("unexpected key %q for %s store", v.Key, storeType)})
+			}
+		}
+		storesValues[storeType] = values
+	}
+
+	lock, err := acquireMaintenanceLock(cc)
+	if err != nil {
+		return err
+	}
+	defer releaseMaintenanceLock(lock)
+
+	err = waitForRunningExecutions(ctx, runningExecutionsWaitTimeout)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting a restore of the Yorc cluster from a backup made on %s", metadata.BackupDate)
+	// Stores cleanup relies on deployments currently known in Consul so it should be done first
+	deploymentIDs, err := getDeploymentsIDs()
+	if err != nil {
+		return err
+	}
+	for _, name := range types.StoreTypeNames() {
+		storeType, err := types.ParseStoreType(name)
+		if err != nil {
+			return err
+		}
+		if storage.IsConsulStore(storeType) {
+			continue
+		}
+		err = clearStore(ctx, storage.GetStore(storeType), storesContents[storeType], deploymentIDs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clear %s store", storeType)
+		}
+	}
+	for _, prefix := range getConsulPrefixes() {
+		err = consulutil.Delete(prefix+"/", true)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, errGrp, consulStore := consulutil.WithContext(ctx)
+	for _, kv := range kvs {
+		consulStore.StoreConsulKeyWithFlags(kv.Key, kv.Value, kv.Flags)
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return errors.Wrap(err, "failed to restore Consul data")
+	}
+	for storeType, values := range storesValues {
+		keyValues := make([]store.KeyValueIn, 0, len(values))
+		for _, v := range values {
+			keyValues = append(keyValues, store.KeyValueIn{Key: v.Key, Value: v.Value})
+		}
+		err = storage.GetStore(storeType).SetCollection(ctx, keyValues)
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore %s store", storeType)
+		}
+	}
+	log.Printf("Restore of the Yorc cluster done, Yorc servers should be restarted")
+	return nil
+}
+
+func checkMetadata(metadata backupMetadata) error {
+	if metadata.FormatVersion != backupFormatVersion {
+		return errors.WithStack(invalidBackupError{fmt.Sprintf("unsupported archive format version %d, expecting %d", metadata.FormatVersion, backupFormatVersion)})
+	}
+	err := upgradeschema.CheckSchemaVersionCompatibility(metadata.SchemaVersion)
+	if err != nil {
+		return errors.WithStack(invalidBackupError{err.Error()})
+	}
+	return nil
+}
+
+// isYorcKey checks if a key is under one of the prefixes that may be saved into a backup
+func isYorcKey(key string) bool {
+	if hasOneOfPrefixes(key, consulPrefixes) {
+		return true
+	}
+	for _, content := range storesContents {
+		if content.contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c storeContent) contains(key string) bool {
+	return hasOneOfPrefixes(key, c.rootPrefixes) || hasOneOfPrefixes(key, c.deploymentsPrefixes)
+}
+
+func hasOneOfPrefixes(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func clearStore(ctx context.Context, s store.Store, content storeContent, deploymentIDs []string) error {
+	for _, prefix := range content.rootPrefixes {
+		err := s.Delete(ctx, prefix, true)
+		if err != nil {
+			return err
+		}
+	}
+	for _, prefix := range content.deploymentsPrefixes {
+		for _, id := range deploymentIDs {
+			err := s.Delete(ctx, path.Join(prefix, id), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readJSONEntry(entries map[string]*zip.File, name string, v interface{}) error {
+	f, ok := entries[name]
+	if !ok {
+		return errors.WithStack(invalidBackupError{fmt.Sprintf("missing entry %q", name)})
+	}
+	r, err := f.Open()
+	if err != nil {
+		return errors.WithStack(invalidBackupError{fmt.Sprintf("failed to open entry %q: %v", name, err)})
+	}
+	defer r.Close()
+	err = json.NewDecoder(r).Decode(v)
+	if err != nil {
+		return errors.WithStack(invalidBackupError{fmt.Sprintf("failed to decode entry %q: %v", name, err)})
+	}
+	return nil
+}
@@ -44,6 +44,7 @@ func TestConsul(t *testing.T) {
 		testPurgeDeployment(t, cfg, srv, client)
 		testPurgeDeploymentPreChecks(t, cfg, srv, client)
 		testEnsurePurgeFailedStatus(t, cfg, srv, client)
+		testExportImportDeployment(t, cfg, srv, client)
 	})
 
 }
@@ -0,0 +1,320 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operations
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/collections"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/server/info"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// exportFormatVersion is the version of the deployment archive layout.
+// It should be incremented on each incompatible change of the archive content.
+const exportFormatVersion = 1
+
+// Entries of a deployment archive
+const (
+	exportMetadataEntry = "metadata.json"
+	exportCSAREntry     = "csar/deployment.zip"
+	exportOverlayPrefix = "overlay/"
+	exportStoreEntry    = "store.json"
+	exportKVEntry       = "kv.json"
+	exportTasksEntry    = "tasks.json"
+)
+
+// Deployment sub-trees that are stored directly into Consul KV rather than in the deployment store
+var kvOwnedDeploymentKeys = []string{
+	"status",
+	"tasks",
+	"terraform-state",
+	"topology/instances",
+	"topology/relationship_instances",
+}
+
+// Keys that hold runtime information that should not be exported
+var runtimeOnlyKeys = []string{
+	".blockingOp",
+	".runningExecutions",
+	".runningExecutionsLock",
+}
+
+type exportMetadata struct {
+	FormatVersion int       `json:"format_version"`
+	DeploymentID  string    `json:"deployment_id"`
+	YorcVersion   string    `json:"yorc_version"`
+	ExportDate    time.Time `json:"export_date"`
+}
+
+// exportedKV is a Consul KV pair with a key relative to its parent (deployment, task, ...)
+type exportedKV struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+	Flags uint64 `json:"flags,omitempty"`
+}
+
+// exportedStoreValue is a deployment store value with a key relative to the deployment
+type exportedStoreValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type exportedTask struct {
+	ID    string       `json:"id"`
+	KV    []exportedKV `json:"kv"`
+	Steps []exportedKV `json:"steps,omitempty"`
+}
+
+// ExportDeployment writes into w a zip archive containing everything needed to recreate the given deployment
+// on another Yorc cluster using ImportDeployment.
+//
+// The archive contains the original CSAR, the deployment overlay, the stored topology, instances and relationships
+// instances states, tasks history and Terraform states.
+// The deployment should not have any running task.
+func ExportDeployment(ctx context.Context, deploymentID, workingDirectory string, w io.Writer) error {
+	// Returns an error checkable with deployments.IsDeploymentNotFoundError if the deployment does not exist
+	_, err := deployments.GetDeploymentStatus(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	taskIDs, err := deployments.GetDeploymentTaskList(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	hasLivingTask, livingTaskID, livingTaskStatus, err := tasks.HasLivingTasks(taskIDs, nil)
+	if err != nil {
+		return err
+	}
+	if hasLivingTask {
+		return tasks.NewAnotherLivingTaskAlreadyExistsError(livingTaskID, deploymentID, livingTaskStatus)
+	}
+
+	storeValues, err := exportDeploymentStore(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	storeKeys := make(map[string]struct{}, len(storeValues))
+	for _, sv := range storeValues {
+		storeKeys[sv.Key] = struct{}{}
+	}
+	kvs, err := exportKVTree(path.Join(consulutil.DeploymentKVPrefix, deploymentID), func(key string) bool {
+		_, ok := storeKeys[key]
+		return ok
+	})
+	if err != nil {
+		return err
+	}
+	exportedTasks := make([]exportedTask, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		et, err := exportTask(taskID)
+		if err != nil {
+			return err
+		}
+		exportedTasks = append(exportedTasks, et)
+	}
+
+	zw := zip.NewWriter(w)
+	err = writeJSONEntry(zw, exportMetadataEntry, exportMetadata{
+		FormatVersion: exportFormatVersion,
+		DeploymentID:  deploymentID,
+		YorcVersion:   info.YorcVersion,
+		ExportDate:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	deploymentDir := filepath.Join(workingDirectory, "deployments", deploymentID)
+	err = writeFileEntry(zw, exportCSAREntry, filepath.Join(deploymentDir, "deployment.zip"))
+	if err != nil {
+		return err
+	}
+	err = writeDirEntries(zw, exportOverlayPrefix, filepath.Join(deploymentDir, "overlay"))
+	if err != nil {
+		return err
+	}
+	err = writeJSONEntry(zw, exportStoreEntry, storeValues)
+	if err != nil {
+		return err
+	}
+	err = writeJSONEntry(zw, exportKVEntry, kvs)
+	if err != nil {
+		return err
+	}
+	err = writeJSONEntry(zw, exportTasksEntry, exportedTasks)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(zw.Close(), "failed to write deployment archive")
+}
+
+func isExcludedKey(relKey string, excludedKeys []string) bool {
+	for _, k := range excludedKeys {
+		if relKey == k || strings.HasPrefix(relKey, k+"/") {
+			return true
+		}
+	}
+	return collections.ContainsString(runtimeOnlyKeys, strings.SplitN(relKey, "/", 2)[0])
+}
+
+// exportDeploymentStore returns all values of the deployment store related to the given deployment
+func exportDeploymentStore(ctx context.Context, deploymentID string) ([]exportedStoreValue, error) {
+	depPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID)
+	result := make([]exportedStoreValue, 0)
+	err := exportStoreKeys(ctx, storage.GetStore(types.StoreTypeDeployment), depPrefix, depPrefix, &result)
+	return result, err
+}
+
+func exportStoreKeys(ctx context.Context, s store.Store, depPrefix, key string, result *[]exportedStoreValue) error {
+	keys, err := s.Keys(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list deployment store keys under %q", key)
+	}
+	for _, k := range keys {
+		relKey := strings.TrimPrefix(k, depPrefix+"/")
+		if isExcludedKey(relKey, kvOwnedDeploymentKeys) {
+			continue
+		}
+		if relKey == "topology" {
+			// topology is shared between the deployment store and Consul KV
+			err = exportStoreKeys(ctx, s, depPrefix, k, result)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		kvs, _, err := s.List(ctx, k, 0, 0)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list deployment store values under %q", k)
+		}
+		if len(kvs) == 0 {
+			// k is a leaf
+			var value map[string]interface{}
+			exist, err := s.Get(k, &value)
+			if err != nil {
+				return errors.Wrapf(err, "failed to get deployment store value %q", k)
+			}
+			if exist {
+				*result = append(*result, exportedStoreValue{Key: relKey, Value: value})
+			}
+			continue
+		}
+		for _, kv := range kvs {
+			*result = append(*result, exportedStoreValue{Key: strings.TrimPrefix(kv.Key, depPrefix+"/"), Value: kv.Value})
+		}
+	}
+	return nil
+}
+
+// exportKVTree returns all Consul KV pairs under prefix with keys relative to this prefix.
+// Keys for which skip returns true are ignored.
+func exportKVTree(prefix string, skip func(relKey string) bool) ([]exportedKV, error) {
+	kvps, _, err := consulutil.GetKV().List(prefix+"/", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	result := make([]exportedKV, 0, len(kvps))
+	for _, kvp := range kvps {
+		relKey := strings.TrimPrefix(kvp.Key, prefix+"/")
+		if relKey == "" || strings.HasSuffix(relKey, "/") || isExcludedKey(relKey, nil) {
+			continue
+		}
+		if skip != nil && skip(relKey) {
+			continue
+		}
+		result = append(result, exportedKV{Key: relKey, Value: kvp.Value, Flags: kvp.Flags})
+	}
+	return result, nil
+}
+
+func exportTask(taskID string) (exportedTask, error) {
+	et := exportedTask{ID: taskID}
+	var err error
+	et.KV, err = exportKVTree(path.Join(consulutil.TasksPrefix, taskID), nil)
+	if err != nil {
+		return et, err
+	}
+	et.Steps, err = exportKVTree(path.Join(consulutil.WorkflowsPrefix, taskID), nil)
+	return et, err
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive entry %q", name)
+	}
+	return errors.Wrapf(json.NewEncoder(fw).Encode(v), "failed to write archive entry %q", name)
+}
+
+func writeFileEntry(zw *zip.Writer, name, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to open %q", filePath)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %q", filePath)
+	}
+	header, err := zip.FileInfoHeader(fi)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive entry %q", name)
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	fw, err := zw.CreateHeader(header)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create archive entry %q", name)
+	}
+	_, err = io.Copy(fw, f)
+	return errors.Wrapf(err, "failed to write archive entry %q", name)
+}
+
+func writeDirEntries(zw *zip.Writer, prefix, dirPath string) error {
+	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(dirPath, func(filePath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return writeFileEntry(zw, prefix+filepath.ToSlash(relPath), filePath)
+	})
+}
@@ -0,0 +1,201 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operations
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+func TestIsExcludedKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		relKey string
+		want   bool
+	}{
+		{"Status", "status", true},
+		{"Instance", "topology/instances/Compute/0/attributes/state", true},
+		{"InstancesPrefixOnly", "topology/instancesXYZ", false},
+		{"Node", "topology/nodes/Compute", false},
+		{"BlockingFlag", ".blockingOp", true},
+		{"RunningExecution", ".runningExecutions/123", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isExcludedKey(tt.relKey, kvOwnedDeploymentKeys))
+		})
+	}
+}
+
+func TestRemapTerraformState(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   string
+		newID   string
+		want    string
+		wantErr bool
+	}{
+		{"StateV3", `{"modules":[{"resources":{"consul_keys.attrs":{"primary":{"attributes":{"key.1234.path":"_yorc/deployments/dep1/topology/instances/Compute/0/attributes/ip"}}}}}]}`, "dep2",
+			`{"modules":[{"resources":{"consul_keys.attrs":{"primary":{"attributes":{"key.1234.path":"_yorc/deployments/dep2/topology/instances/Compute/0/attributes/ip"}}}}}]}`, false},
+		{"StateV4", `{"resources":[{"type":"consul_keys","instances":[{"attributes":{"key":[{"path":"_yorc/deployments/dep1/topology/instances/Compute/0"}]}}]}]}`, "dep1-copy",
+			`{"resources":[{"instances":[{"attributes":{"key":[{"path":"_yorc/deployments/dep1-copy/topology/instances/Compute/0"}]}}],"type":"consul_keys"}]}`, false},
+		{"NoReference", `{"modules":[{"resources":{"openstack_compute_instance_v2.Compute-0":{"primary":{"id":"1234"}}}}]}`, "dep2",
+			`{"modules":[{"resources":{"openstack_compute_instance_v2.Compute-0":{"primary":{"id":"1234"}}}}]}`, false},
+		{"ReferenceInUnknownAttribute", `{"modules":[{"resources":{"consul_keys.attrs":{"primary":{"attributes":{"key.1234.value":"_yorc/deployments/dep1/topology"}}}}}]}`, "dep2", "", true},
+		{"ResourceNameEmbeddingID", `{"modules":[{"resources":{"openstack_networking_secgroup_v2.yorc-dep1-SG":{"primary":{"attributes":{"name":"yorc-dep1-SG"}}}}}]}`, "dep2", "", true},
+		{"UppercasedResourceName", `{"resources":[{"instances":[{"attributes":{"name":"YORC-DEP1-COMPUTE-0"}}]}]}`, "dep2", "", true},
+		{"NotJSON", `not a state`, "dep2", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := remapTerraformState([]byte(tt.state), "dep1", tt.newID)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestImportDeploymentInvalidArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+	}{
+		{"MissingMetadata", map[string]string{exportStoreEntry: "[]"}},
+		{"BadFormatVersion", map[string]string{exportMetadataEntry: `{"format_version": 42, "deployment_id": "dep"}`}},
+		{"BadJSON", map[string]string{exportMetadataEntry: `{"format_version": 1, "deployment_id": "dep"}`, exportStoreEntry: "{"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			zw := zip.NewWriter(buf)
+			for name, content := range tt.entries {
+				fw, err := zw.Create(name)
+				require.NoError(t, err)
+				_, err = fw.Write([]byte(content))
+				require.NoError(t, err)
+			}
+			require.NoError(t, zw.Close())
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			require.NoError(t, err)
+
+			_, err = ImportDeployment(context.Background(), zr, "", os.TempDir())
+			require.Error(t, err)
+			require.True(t, IsInvalidArchiveError(err), "unexpected error %v", err)
+		})
+	}
+}
+
+func TestExtractArchiveEntryIllegalPath(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	_, err := zw.Create("overlay/../../evil.sh")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	rootDir, err := ioutil.TempDir("", "yorc-import")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootDir)
+	err = extractArchiveEntry(zr.File[0], filepath.Join(rootDir, "dep"), filepath.Join(rootDir, "dep", "overlay", "..", "..", "evil.sh"))
+	require.Error(t, err)
+	require.True(t, IsInvalidArchiveError(err))
+}
+
+func testExportImportDeployment(t *testing.T, cfg config.Configuration, srv *testutil.TestServer, client *api.Client) {
+	ctx := context.Background()
+	deploymentID := "testExportImportDeployment"
+	loadTestYaml(t, deploymentID)
+	err := deployments.SetDeploymentStatus(ctx, deploymentID, deployments.DEPLOYED)
+	require.NoError(t, err)
+
+	taskID := "testExportImportDeploymentTask"
+	srv.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.TasksPrefix, taskID, "targetId"):                                     []byte(deploymentID),
+		path.Join(consulutil.TasksPrefix, taskID, "status"):                                       []byte(strconv.Itoa(int(tasks.TaskStatusDONE))),
+		path.Join(consulutil.TasksPrefix, taskID, "type"):                                         []byte(strconv.Itoa(int(tasks.TaskTypeDeploy))),
+		path.Join(consulutil.WorkflowsPrefix, taskID, "step1"):                                    []byte("done"),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "tasks", taskID):                   nil,
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "terraform-state", "Compute"):      []byte(`{"path":"_yorc/deployments/` + deploymentID + `/topology/instances/Compute/0"}`),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/Compute/0/id"): []byte("0"),
+	})
+	depDir := filepath.Join(cfg.WorkingDirectory, "deployments", deploymentID)
+	require.NoError(t, os.MkdirAll(filepath.Join(depDir, "overlay"), 0775))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(depDir, "overlay", "topology.yaml"), []byte("tosca"), 0664))
+
+	buf := new(bytes.Buffer)
+	err = ExportDeployment(ctx, deploymentID, cfg.WorkingDirectory, buf)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	// Importing with the same ID should fail
+	_, err = ImportDeployment(ctx, zr, "", cfg.WorkingDirectory)
+	require.Error(t, err)
+	require.True(t, IsDeploymentAlreadyExistsError(err))
+
+	newID := deploymentID + "Imported"
+	result, err := ImportDeployment(ctx, zr, newID, cfg.WorkingDirectory)
+	require.NoError(t, err)
+	require.Equal(t, newID, result.DeploymentID)
+	require.Equal(t, deploymentID, result.OriginalDeploymentID)
+	newTaskID, ok := result.RemappedTasks[taskID]
+	require.True(t, ok, "task ID should have been remapped as it already exists")
+
+	status, err := deployments.GetDeploymentStatus(ctx, newID)
+	require.NoError(t, err)
+	require.Equal(t, deployments.DEPLOYED, status)
+
+	nodes, err := deployments.GetNodes(ctx, newID)
+	require.NoError(t, err)
+	require.NotEmpty(t, nodes)
+
+	taskList, err := deployments.GetDeploymentTaskList(ctx, newID)
+	require.NoError(t, err)
+	require.Equal(t, []string{newTaskID}, taskList)
+	target, err := tasks.GetTaskTarget(newTaskID)
+	require.NoError(t, err)
+	require.Equal(t, newID, target)
+
+	kvp, _, err := consulutil.GetKV().Get(path.Join(consulutil.DeploymentKVPrefix, newID, "terraform-state", "Compute"), nil)
+	require.NoError(t, err)
+	require.NotNil(t, kvp)
+	require.Equal(t, `{"path":"_yorc/deployments/`+newID+`/topology/instances/Compute/0"}`, string(kvp.Value))
+
+	content, err := ioutil.ReadFile(filepath.Join(cfg.WorkingDirectory, "deployments", newID, "overlay", "topology.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, "tosca", string(content))
+}
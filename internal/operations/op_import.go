@@ -0,0 +1,358 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operations
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+type invalidArchiveError struct {
+	reason string
+}
+
+func (e invalidArchiveError) Error() string {
+	return fmt.Sprintf("invalid deployment archive: %s", e.reason)
+}
+
+// IsInvalidArchiveError checks if an error is due to an invalid deployment archive given to ImportDeployment
+func IsInvalidArchiveError(err error) bool {
+	_, ok := errors.Cause(err).(invalidArchiveError)
+	return ok
+}
+
+type deploymentAlreadyExistsError struct {
+	deploymentID string
+}
+
+func (e deploymentAlreadyExistsError) Error() string {
+	return fmt.Sprintf("deployment with id %q already exists", e.deploymentID)
+}
+
+// IsDeploymentAlreadyExistsError checks if an error is due to an import on an existing deployment
+func IsDeploymentAlreadyExistsError(err error) bool {
+	_, ok := errors.Cause(err).(deploymentAlreadyExistsError)
+	return ok
+}
+
+// ImportResult describes a deployment recreated by ImportDeployment
+type ImportResult struct {
+	// DeploymentID is the ID of the imported deployment
+	DeploymentID string
+	// OriginalDeploymentID is the ID of the deployment when it was exported
+	OriginalDeploymentID string
+	// RemappedTasks contains tasks IDs that were already used on this cluster and were changed during import.
+	// Keys are the original tasks IDs and values the new ones.
+	RemappedTasks map[string]string
+}
+
+// ImportDeployment recreates a deployment from an archive generated by ExportDeployment.
+//
+// If deploymentID is empty the original deployment ID is used. Otherwise the deployment is imported under this new ID.
+// An error checkable with IsDeploymentAlreadyExistsError is returned if a deployment with the same ID already exists.
+// Tasks IDs that are already used on this cluster are remapped to new IDs.
+// An error checkable with IsInvalidArchiveError is returned if the archive is not a valid deployment archive.
+func ImportDeployment(ctx context.Context, archive *zip.Reader, deploymentID, workingDirectory string) (*ImportResult, error) {
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+	var metadata exportMetadata
+	err := readJSONEntry(entries, exportMetadataEntry, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.FormatVersion != exportFormatVersion {
+		return nil, errors.WithStack(invalidArchiveError{fmt.Sprintf("unsupported archive format version %d, expecting %d", metadata.FormatVersion, exportFormatVersion)})
+	}
+	var storeValues []exportedStoreValue
+	err = readJSONEntry(entries, exportStoreEntry, &storeValues)
+	if err != nil {
+		return nil, err
+	}
+	var kvs []exportedKV
+	err = readJSONEntry(entries, exportKVEntry, &kvs)
+	if err != nil {
+		return nil, err
+	}
+	var importedTasks []exportedTask
+	err = readJSONEntry(entries, exportTasksEntry, &importedTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{
+		DeploymentID:         deploymentID,
+		OriginalDeploymentID: metadata.DeploymentID,
+		RemappedTasks:        make(map[string]string),
+	}
+	if result.DeploymentID == "" {
+		result.DeploymentID = metadata.DeploymentID
+	}
+	exist, err := deployments.DoesDeploymentExists(ctx, result.DeploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		return nil, errors.WithStack(deploymentAlreadyExistsError{result.DeploymentID})
+	}
+	for _, t := range importedTasks {
+		exist, err = tasks.TaskExists(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exist {
+			result.RemappedTasks[t.ID] = fmt.Sprint(uuid.NewV4())
+		}
+	}
+
+	if result.DeploymentID != result.OriginalDeploymentID {
+		for i := range kvs {
+			if !strings.HasPrefix(kvs[i].Key, "terraform-state/") {
+				continue
+			}
+			// Terraform states may reference keys of the deployment in Consul
+			kvs[i].Value, err = remapTerraformState(kvs[i].Value, result.OriginalDeploymentID, result.DeploymentID)
+			if err != nil {
+				return nil, errors.WithStack(invalidArchiveError{fmt.Sprintf("Terraform state %q can't be imported under deployment id %q: %v", kvs[i].Key, result.DeploymentID, err)})
+			}
+		}
+	}
+
+	log.Printf("Importing deployment %q as %q", metadata.DeploymentID, result.DeploymentID)
+	err = performImportDeployment(ctx, entries, result, storeValues, kvs, importedTasks, workingDirectory)
+	if err != nil {
+		cleanupErr := cleanupFailedImport(ctx, result, importedTasks, workingDirectory)
+		if cleanupErr != nil {
+			log.Printf("[WARNING] failed to cleanup deployment %q after an import failure: %v", result.DeploymentID, cleanupErr)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func performImportDeployment(ctx context.Context, entries map[string]*zip.File, result *ImportResult, storeValues []exportedStoreValue, kvs []exportedKV, importedTasks []exportedTask, workingDirectory string) error {
+	deploymentDir := filepath.Join(workingDirectory, "deployments", result.DeploymentID)
+	for name, f := range entries {
+		var target string
+		switch {
+		case name == exportCSAREntry:
+			target = filepath.Join(deploymentDir, "deployment.zip")
+		case strings.HasPrefix(name, exportOverlayPrefix) && !f.FileInfo().IsDir():
+			target = filepath.Join(deploymentDir, "overlay", filepath.FromSlash(strings.TrimPrefix(name, exportOverlayPrefix)))
+		default:
+			continue
+		}
+		err := extractArchiveEntry(f, deploymentDir, target)
+		if err != nil {
+			return err
+		}
+	}
+
+	depPrefix := path.Join(consulutil.DeploymentKVPrefix, result.DeploymentID)
+	keyValues := make([]store.KeyValueIn, 0, len(storeValues))
+	for _, sv := range storeValues {
+		keyValues = append(keyValues, store.KeyValueIn{Key: path.Join(depPrefix, sv.Key), Value: sv.Value})
+	}
+	err := storage.GetStore(types.StoreTypeDeployment).SetCollection(ctx, keyValues)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store deployment %q definition", result.DeploymentID)
+	}
+
+	_, errGrp, consulStore := consulutil.WithContext(ctx)
+	for _, t := range importedTasks {
+		taskID := importedTaskID(result, t.ID)
+		for _, kv := range t.KV {
+			if kv.Key == "targetId" {
+				kv.Value = []byte(result.DeploymentID)
+			}
+			consulStore.StoreConsulKeyWithFlags(path.Join(consulutil.TasksPrefix, taskID, kv.Key), kv.Value, kv.Flags)
+		}
+		for _, kv := range t.Steps {
+			consulStore.StoreConsulKeyWithFlags(path.Join(consulutil.WorkflowsPrefix, taskID, kv.Key), kv.Value, kv.Flags)
+		}
+	}
+
+	// The deployment status is stored at the end as it makes the deployment visible
+	var statusKV *exportedKV
+	for i := range kvs {
+		kv := kvs[i]
+		switch {
+		case kv.Key == "status":
+			statusKV = &kv
+			continue
+		case strings.HasPrefix(kv.Key, "tasks/"):
+			kv.Key = path.Join("tasks", importedTaskID(result, path.Base(kv.Key)))
+		}
+		consulStore.StoreConsulKeyWithFlags(path.Join(depPrefix, kv.Key), kv.Value, kv.Flags)
+	}
+	err = errGrp.Wait()
+	if err != nil {
+		return errors.Wrapf(err, "failed to store deployment %q runtime data", result.DeploymentID)
+	}
+	if statusKV == nil {
+		return errors.WithStack(invalidArchiveError{"missing deployment status"})
+	}
+	return consulutil.StoreConsulKeyWithFlags(path.Join(depPrefix, statusKV.Key), statusKV.Value, statusKV.Flags)
+}
+
+func cleanupFailedImport(ctx context.Context, result *ImportResult, importedTasks []exportedTask, workingDirectory string) error {
+	var merr *multierror.Error
+	for _, t := range importedTasks {
+		taskID := importedTaskID(result, t.ID)
+		merr = multierror.Append(merr, tasks.DeleteTask(taskID))
+		merr = multierror.Append(merr, consulutil.Delete(path.Join(consulutil.WorkflowsPrefix, taskID)+"/", true))
+	}
+	merr = multierror.Append(merr, deployments.DeleteDeployment(ctx, result.DeploymentID))
+	merr = multierror.Append(merr, os.RemoveAll(filepath.Join(workingDirectory, "deployments", result.DeploymentID)))
+	return merr.ErrorOrNil()
+}
+
+func importedTaskID(result *ImportResult, originalID string) string {
+	if newID, ok := result.RemappedTasks[originalID]; ok {
+		return newID
+	}
+	return originalID
+}
+
+// terraformStatePathAttribute matches the attributes of a Terraform state holding the path of a Consul key,
+// either nested (state format v4) or flattened (state format v3, like "key.1234.path")
+var terraformStatePathAttribute = regexp.MustCompile(`^(?:.+\.)?path$`)
+
+// stateRemapper rewrites references to the original deployment in a decoded Terraform state
+type stateRemapper struct {
+	originalID     string
+	originalPrefix string
+	newPrefix      string
+	// leftovers are locations of the state still referencing the original deployment
+	leftovers []string
+}
+
+// remapTerraformState replaces references to Consul keys of the original deployment by references to keys of the new
+// deployment in a Terraform state.
+//
+// Only the attributes holding the path of a Consul key are rewritten. An error is returned if the state references
+// the original deployment elsewhere, for instance in resources names, as those references could not be safely remapped.
+func remapTerraformState(value []byte, originalID, newID string) ([]byte, error) {
+	var state interface{}
+	err := json.Unmarshal(value, &state)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode Terraform state")
+	}
+	r := &stateRemapper{
+		originalID:     originalID,
+		originalPrefix: path.Join(consulutil.DeploymentKVPrefix, originalID),
+		newPrefix:      path.Join(consulutil.DeploymentKVPrefix, newID),
+	}
+	state = r.remap(state, "", "")
+	if len(r.leftovers) > 0 {
+		sort.Strings(r.leftovers)
+		return nil, errors.Errorf("original deployment id %q is referenced in %s", originalID, strings.Join(r.leftovers, ", "))
+	}
+	return json.Marshal(state)
+}
+
+// remap rewrites the Consul keys paths of the original deployment found in known attributes of a state value
+// and records other references to the original deployment
+func (r *stateRemapper) remap(v interface{}, key, location string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if containsDeploymentID(k, r.originalID) {
+				r.leftovers = append(r.leftovers, location+"/"+k)
+			}
+			t[k] = r.remap(child, k, location+"/"+k)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = r.remap(child, key, fmt.Sprintf("%s/%d", location, i))
+		}
+	case string:
+		if terraformStatePathAttribute.MatchString(key) && (t == r.originalPrefix || strings.HasPrefix(t, r.originalPrefix+"/")) {
+			return r.newPrefix + strings.TrimPrefix(t, r.originalPrefix)
+		}
+		if containsDeploymentID(t, r.originalID) {
+			r.leftovers = append(r.leftovers, location)
+		}
+	}
+	return v
+}
+
+// containsDeploymentID checks if a deployment ID appears in a string, ignoring case as some
+// infrastructures resources names embedding deployment IDs are lowercased
+func containsDeploymentID(s, deploymentID string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(deploymentID))
+}
+
+func readJSONEntry(entries map[string]*zip.File, name string, v interface{}) error {
+	f, ok := entries[name]
+	if !ok {
+		return errors.WithStack(invalidArchiveError{fmt.Sprintf("missing entry %q", name)})
+	}
+	r, err := f.Open()
+	if err != nil {
+		return errors.WithStack(invalidArchiveError{fmt.Sprintf("failed to open entry %q: %v", name, err)})
+	}
+	defer r.Close()
+	err = json.NewDecoder(r).Decode(v)
+	if err != nil {
+		return errors.WithStack(invalidArchiveError{fmt.Sprintf("failed to decode entry %q: %v", name, err)})
+	}
+	return nil
+}
+
+func extractArchiveEntry(f *zip.File, rootDir, target string) error {
+	// Prevent from writing files outside of the deployment directory
+	if !strings.HasPrefix(filepath.Clean(target), filepath.Clean(rootDir)+string(os.PathSeparator)) {
+		return errors.WithStack(invalidArchiveError{fmt.Sprintf("illegal file path %q", f.Name)})
+	}
+	err := os.MkdirAll(filepath.Dir(target), 0775)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory for %q", target)
+	}
+	r, err := f.Open()
+	if err != nil {
+		return errors.WithStack(invalidArchiveError{fmt.Sprintf("failed to open entry %q: %v", f.Name, err)})
+	}
+	defer r.Close()
+	targetFile, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode()|0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create %q", target)
+	}
+	defer targetFile.Close()
+	_, err = io.Copy(targetFile, r)
+	return errors.Wrapf(err, "failed to extract %q", target)
+}
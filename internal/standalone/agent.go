@@ -0,0 +1,203 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package standalone provides an embedded agent implementing the subset of the Consul HTTP API used by Yorc.
+//
+// It allows to run Yorc on a single node without an external Consul agent: the key/value store,
+// sessions, locks and blocking queries are served by the agent and the key/value store is persisted
+// into a local directory. The agent is not clustered and does not support ACLs.
+package standalone
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/ystia/yorc/v4/log"
+)
+
+// DefaultAddress is the address on which the agent listens by default: the loopback interface on a random port
+const DefaultAddress = "127.0.0.1:0"
+
+const defaultDatacenter = "dc1"
+
+// Config holds the configuration of a standalone agent
+type Config struct {
+	// DataDirectory is the directory where the key/value store is persisted, the store is only kept in memory if empty
+	DataDirectory string
+	// Address is the TCP address on which the agent serves the Consul HTTP API
+	Address string
+	// NodeName is the name of the Consul node simulated by the agent
+	NodeName string
+	// Datacenter is the name of the Consul datacenter simulated by the agent
+	Datacenter string
+	// ChecksTLSSkipVerify disables the verification of certificates by HTTPS health checks
+	ChecksTLSSkipVerify bool
+}
+
+// Agent is a running standalone agent
+type Agent struct {
+	cfg      Config
+	nodeID   string
+	state    *state
+	listener net.Listener
+	server   *http.Server
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// Start loads the content of the data directory and starts serving the Consul HTTP API
+func Start(cfg Config) (*Agent, error) {
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
+	}
+	if cfg.Datacenter == "" {
+		cfg.Datacenter = defaultDatacenter
+	}
+	if cfg.NodeName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get hostname to use it as standalone node name")
+		}
+		cfg.NodeName = hostname
+	}
+
+	a := &Agent{
+		cfg:    cfg,
+		nodeID: uuid.NewV4().String(),
+		state:  newState(),
+		stopCh: make(chan struct{}),
+	}
+	if cfg.DataDirectory != "" {
+		j, err := openJournal(cfg.DataDirectory, a.state)
+		if err != nil {
+			return nil, err
+		}
+		a.state.journal = j
+	}
+	err := a.state.releaseOrphanLocks()
+	if err != nil {
+		a.state.journal.close()
+		return nil, err
+	}
+
+	a.listener, err = net.Listen("tcp", cfg.Address)
+	if err != nil {
+		a.state.journal.close()
+		return nil, errors.Wrapf(err, "failed to listen on %q for the standalone agent", cfg.Address)
+	}
+	a.server = &http.Server{Handler: a.handler()}
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		if err := a.server.Serve(a.listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] Standalone agent stopped serving requests: %v", err)
+		}
+	}()
+	go a.maintain()
+	log.Printf("Standalone agent serving the Consul HTTP API on %s using data directory %q", a.Address(), cfg.DataDirectory)
+	return a, nil
+}
+
+// Address returns the address on which the agent serves the Consul HTTP API
+func (a *Agent) Address() string {
+	return a.listener.Addr().String()
+}
+
+func (a *Agent) advertiseAddress() string {
+	host, _, err := net.SplitHostPort(a.Address())
+	if err != nil {
+		return a.Address()
+	}
+	return host
+}
+
+// Stop stops serving requests and running checks and flushes the data directory
+func (a *Agent) Stop() error {
+	var err error
+	a.stopOnce.Do(func() {
+		close(a.stopCh)
+		// Blocking queries may last several minutes so do not wait for active requests
+		err = a.server.Close()
+		a.wg.Wait()
+		a.state.lock.Lock()
+		defer a.state.lock.Unlock()
+		if closeErr := a.state.journal.close(); err == nil {
+			err = closeErr
+		}
+	})
+	return errors.Wrap(err, "failed to stop standalone agent")
+}
+
+// maintain expires sessions and reaps tombstones until the agent is stopped
+func (a *Agent) maintain() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	lastReap := time.Now()
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case now := <-ticker.C:
+			err := a.state.expireSessions(now)
+			if err != nil {
+				log.Printf("[WARN] Standalone agent failed to expire sessions: %v", err)
+			}
+			if now.Sub(lastReap) > time.Minute {
+				a.state.reapTombstones(now)
+				lastReap = now
+			}
+		}
+	}
+}
+
+func (a *Agent) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/", a.handleKV)
+	mux.HandleFunc("/v1/txn", a.handleTxn)
+	mux.HandleFunc("/v1/session/create", a.handleSessionCreate)
+	mux.HandleFunc("/v1/session/destroy/", a.handleSessionDestroy)
+	mux.HandleFunc("/v1/session/renew/", a.handleSessionRenew)
+	mux.HandleFunc("/v1/session/info/", a.handleSessionInfo)
+	mux.HandleFunc("/v1/session/list", a.handleSessionList)
+	mux.HandleFunc("/v1/session/node/", a.handleSessionList)
+	mux.HandleFunc("/v1/agent/self", a.handleAgentSelf)
+	mux.HandleFunc("/v1/agent/services", a.handleAgentServices)
+	mux.HandleFunc("/v1/agent/checks", a.handleAgentChecks)
+	mux.HandleFunc("/v1/agent/service/register", a.handleServiceRegister)
+	mux.HandleFunc("/v1/agent/service/deregister/", a.handleServiceDeregister)
+	mux.HandleFunc("/v1/health/service/", a.handleHealthService)
+	mux.HandleFunc("/v1/catalog/nodes", a.handleCatalogNodes)
+	mux.HandleFunc("/v1/status/leader", a.handleStatusLeader)
+	mux.HandleFunc("/v1/status/peers", a.handleStatusPeers)
+	mux.HandleFunc("/v1/snapshot", a.handleSnapshot)
+	return mux
+}
+
+// notifyAll wakes up all blocking queries
+//
+// It should be called with the state lock held.
+func (s *state) notifyAll() {
+	for w := range s.watchers {
+		close(w.ch)
+		delete(s.watchers, w)
+	}
+}
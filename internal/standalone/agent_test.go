@@ -0,0 +1,520 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAgent(t *testing.T, dataDir string) (*Agent, *api.Client) {
+	a, err := Start(Config{DataDirectory: dataDir, NodeName: "node1"})
+	require.NoError(t, err)
+	consulCfg := api.DefaultConfig()
+	consulCfg.Address = a.Address()
+	client, err := api.NewClient(consulCfg)
+	require.NoError(t, err)
+	return a, client
+}
+
+// consulParityTests are run against the standalone agent and against a real Consul server when
+// the consul binary is available, in order to check that the agent behaves as Consul does
+var consulParityTests = []struct {
+	name string
+	test func(t *testing.T, client *api.Client)
+}{
+	{"KV", testKV},
+	{"KVCAS", testKVCAS},
+	{"KVIndexes", testKVIndexes},
+	{"Txn", testTxn},
+	{"BlockingQuery", testBlockingQuery},
+	{"Locks", testLocks},
+	{"Sessions", testSessions},
+	{"LockDelay", testLockDelay},
+}
+
+func TestConsulParity(t *testing.T) {
+	t.Run("Standalone", func(t *testing.T) {
+		for _, tt := range consulParityTests {
+			t.Run(tt.name, func(t *testing.T) {
+				a, client := newTestAgent(t, "")
+				defer a.Stop()
+				tt.test(t, client)
+			})
+		}
+	})
+	t.Run("Consul", func(t *testing.T) {
+		consulPath, err := exec.LookPath("consul")
+		if err != nil || (testAgentDir != "" && filepath.Dir(consulPath) == testAgentDir) {
+			t.Skip("consul not found on $PATH")
+		}
+		for _, tt := range consulParityTests {
+			t.Run(tt.name, func(t *testing.T) {
+				srv, err := testutil.NewTestServerConfig(func(c *testutil.TestServerConfig) {
+					c.LogLevel = "warn"
+				})
+				require.NoError(t, err)
+				defer srv.Stop()
+				consulCfg := api.DefaultConfig()
+				consulCfg.Address = srv.HTTPAddr
+				client, err := api.NewClient(consulCfg)
+				require.NoError(t, err)
+				tt.test(t, client)
+			})
+		}
+	})
+}
+
+func testKV(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	pair, _, err := kv.Get("a/b", nil)
+	require.NoError(t, err)
+	assert.Nil(t, pair)
+
+	for _, k := range []string{"a/b", "a/c/d", "a/c/e", "b"} {
+		_, err = kv.Put(&api.KVPair{Key: k, Value: []byte(k), Flags: 42}, nil)
+		require.NoError(t, err)
+	}
+	pair, meta, err := kv.Get("a/b", nil)
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	assert.Equal(t, "a/b", string(pair.Value))
+	assert.Equal(t, uint64(42), pair.Flags)
+	assert.Equal(t, pair.ModifyIndex, meta.LastIndex)
+
+	pairs, _, err := kv.List("a/", nil)
+	require.NoError(t, err)
+	require.Len(t, pairs, 3)
+	assert.Equal(t, "a/c/e", pairs[2].Key)
+
+	keys, _, err := kv.Keys("a/", "/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "a/c/"}, keys)
+
+	keys, _, err = kv.Keys("", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/b", "a/c/d", "a/c/e", "b"}, keys)
+
+	_, err = kv.Delete("a/b", nil)
+	require.NoError(t, err)
+	pair, _, err = kv.Get("a/b", nil)
+	require.NoError(t, err)
+	assert.Nil(t, pair)
+
+	_, err = kv.DeleteTree("a/", nil)
+	require.NoError(t, err)
+	pairs, _, err = kv.List("a/", nil)
+	require.NoError(t, err)
+	assert.Len(t, pairs, 0)
+}
+
+func testKVCAS(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	ok, _, err := kv.CAS(&api.KVPair{Key: "k", Value: []byte("1")}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _, err = kv.CAS(&api.KVPair{Key: "k", Value: []byte("2")}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "cas with a 0 index should fail on existing keys")
+
+	pair, _, err := kv.Get("k", nil)
+	require.NoError(t, err)
+	ok, _, err = kv.CAS(&api.KVPair{Key: "k", Value: []byte("2"), ModifyIndex: pair.ModifyIndex}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, _, err = kv.DeleteCAS(&api.KVPair{Key: "k", ModifyIndex: pair.ModifyIndex}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "delete cas with a stale index should fail")
+
+	ok, _, err = kv.CAS(&api.KVPair{Key: "missing", Value: []byte("1"), ModifyIndex: pair.ModifyIndex}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "cas with a non 0 index should fail on missing keys")
+	pair, _, err = kv.Get("missing", nil)
+	require.NoError(t, err)
+	assert.Nil(t, pair)
+	ok, _, err = kv.DeleteCAS(&api.KVPair{Key: "missing", ModifyIndex: 42}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok, "delete cas should succeed on missing keys")
+}
+
+func testKVIndexes(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	_, err := kv.Put(&api.KVPair{Key: "i/1", Value: []byte("1")}, nil)
+	require.NoError(t, err)
+	pair, meta, err := kv.Get("i/1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	assert.Equal(t, pair.CreateIndex, pair.ModifyIndex)
+	assert.Equal(t, pair.ModifyIndex, meta.LastIndex)
+	createIndex := pair.CreateIndex
+
+	_, err = kv.Put(&api.KVPair{Key: "i/1", Value: []byte("2")}, nil)
+	require.NoError(t, err)
+	pair, _, err = kv.Get("i/1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, createIndex, pair.CreateIndex)
+	assert.True(t, pair.ModifyIndex > createIndex)
+	lastWrite := pair.ModifyIndex
+
+	id, _, err := client.Session().Create(&api.SessionEntry{}, nil)
+	require.NoError(t, err)
+	_, meta, err = kv.Get("i/missing", nil)
+	require.NoError(t, err)
+	assert.Equal(t, lastWrite, meta.LastIndex, "missing keys should have the index of the key/value store, not changed by sessions")
+
+	_, err = kv.Put(&api.KVPair{Key: "i/2", Value: []byte("2")}, nil)
+	require.NoError(t, err)
+	_, err = kv.Delete("i/2", nil)
+	require.NoError(t, err)
+	pairs, meta, err := kv.List("i/", nil)
+	require.NoError(t, err)
+	require.Len(t, pairs, 1)
+	assert.True(t, meta.LastIndex > lastWrite, "deleted keys should change the index of their prefix")
+
+	ok, _, err := kv.Acquire(&api.KVPair{Key: "i/lock", Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pair, _, err = kv.Get("i/lock", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), pair.LockIndex)
+	ok, _, err = kv.Release(&api.KVPair{Key: "i/lock", Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _, err = kv.Acquire(&api.KVPair{Key: "i/lock", Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	pair, _, err = kv.Get("i/lock", nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), pair.LockIndex)
+	assert.Equal(t, id, pair.Session)
+}
+
+func testTxn(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	ok, resp, _, err := kv.Txn(api.KVTxnOps{
+		{Verb: api.KVCheckNotExists, Key: "t/1"},
+		{Verb: api.KVSet, Key: "t/1", Value: []byte("1")},
+		{Verb: api.KVSet, Key: "t/2", Value: []byte("2")},
+		{Verb: api.KVGet, Key: "t/1"},
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, "1", string(resp.Results[2].Value))
+
+	ok, resp, _, err = kv.Txn(api.KVTxnOps{
+		{Verb: api.KVSet, Key: "t/3", Value: []byte("3")},
+		{Verb: api.KVDeleteTree, Key: "t/"},
+		{Verb: api.KVCheckNotExists, Key: "t/1"},
+		{Verb: api.KVCheckNotExists, Key: "t/4"},
+		{Verb: api.KVGet, Key: "t/5"},
+	}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, 4, resp.Errors[0].OpIndex)
+
+	keys, _, err := kv.Keys("t/", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t/1", "t/2"}, keys, "failed transaction should be rolled back")
+}
+
+func testBlockingQuery(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	_, meta, err := kv.List("w/", nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, meta2, err := kv.List("w/", &api.QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 200 * time.Millisecond})
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	assert.Equal(t, meta.LastIndex, meta2.LastIndex)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		kv.Put(&api.KVPair{Key: "other", Value: []byte("v")}, nil)
+		time.Sleep(100 * time.Millisecond)
+		kv.Put(&api.KVPair{Key: "w/1", Value: []byte("v")}, nil)
+	}()
+	start = time.Now()
+	pairs, meta2, err := kv.List("w/", &api.QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 10 * time.Second})
+	require.NoError(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.True(t, meta2.LastIndex > meta.LastIndex)
+	require.Len(t, pairs, 1)
+
+	// Deleted keys should also wake up blocking queries
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		kv.Delete("w/1", nil)
+	}()
+	pairs, meta3, err := kv.List("w/", &api.QueryOptions{WaitIndex: meta2.LastIndex, WaitTime: 10 * time.Second})
+	require.NoError(t, err)
+	assert.True(t, meta3.LastIndex > meta2.LastIndex)
+	assert.Len(t, pairs, 0)
+}
+
+func testLocks(t *testing.T, client *api.Client) {
+	lock, err := client.LockOpts(&api.LockOptions{Key: "lock", LockTryOnce: true, LockWaitTime: 100 * time.Millisecond})
+	require.NoError(t, err)
+	lostCh, err := lock.Lock(nil)
+	require.NoError(t, err)
+	require.NotNil(t, lostCh)
+
+	lock2, err := client.LockOpts(&api.LockOptions{Key: "lock", LockTryOnce: true, LockWaitTime: 100 * time.Millisecond})
+	require.NoError(t, err)
+	ch, err := lock2.Lock(nil)
+	require.NoError(t, err)
+	assert.Nil(t, ch, "lock should already be held")
+
+	require.NoError(t, lock.Unlock())
+	ch, err = lock2.Lock(nil)
+	require.NoError(t, err)
+	assert.NotNil(t, ch)
+	require.NoError(t, lock2.Unlock())
+
+	sem, err := client.SemaphorePrefix("sem", 1)
+	require.NoError(t, err)
+	semCh, err := sem.Acquire(nil)
+	require.NoError(t, err)
+	require.NotNil(t, semCh)
+	require.NoError(t, sem.Release())
+}
+
+func testSessions(t *testing.T, client *api.Client) {
+	kv := client.KV()
+	nodeName, err := client.Agent().NodeName()
+	require.NoError(t, err)
+
+	id, _, err := client.Session().Create(&api.SessionEntry{Name: "s", Behavior: api.SessionBehaviorDelete}, nil)
+	require.NoError(t, err)
+	ok, _, err := kv.Acquire(&api.KVPair{Key: "locked", Value: []byte("v"), Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, _, err = kv.Acquire(&api.KVPair{Key: "locked", Value: []byte("v"), Session: "unknown"}, nil)
+	require.Error(t, err)
+
+	sessions, _, err := client.Session().List(nil)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, nodeName, sessions[0].Node)
+
+	_, err = client.Session().Destroy(id, nil)
+	require.NoError(t, err)
+	pair, _, err := kv.Get("locked", nil)
+	require.NoError(t, err)
+	assert.Nil(t, pair, "keys locked by a session with the delete behavior should be deleted")
+
+	_, _, err = client.Session().Create(&api.SessionEntry{Name: "ttl", TTL: "10ms"}, nil)
+	require.Error(t, err, "sessions TTL should be at least 10s")
+
+	_, _, err = client.Session().Create(&api.SessionEntry{Name: "s", Checks: []string{"service:unknown"}}, nil)
+	require.Error(t, err)
+}
+
+func testLockDelay(t *testing.T, client *api.Client) {
+	kv := client.KV()
+
+	id, _, err := client.Session().Create(nil, nil)
+	require.NoError(t, err)
+	sess, _, err := client.Session().Info(id, nil)
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	assert.Equal(t, 15*time.Second, sess.LockDelay, "sessions should have a default lock delay")
+	_, err = client.Session().Destroy(id, nil)
+	require.NoError(t, err)
+
+	id, _, err = client.Session().Create(&api.SessionEntry{LockDelay: time.Second}, nil)
+	require.NoError(t, err)
+	ok, _, err := kv.Acquire(&api.KVPair{Key: "delayed", Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = client.Session().Destroy(id, nil)
+	require.NoError(t, err)
+
+	id, _, err = client.Session().Create(&api.SessionEntry{}, nil)
+	require.NoError(t, err)
+	ok, _, err = kv.Acquire(&api.KVPair{Key: "delayed", Session: id}, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "keys should not be acquired during the lock delay of the session that held them")
+	time.Sleep(1500 * time.Millisecond)
+	ok, _, err = kv.Acquire(&api.KVPair{Key: "delayed", Session: id}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Releasing a key does not apply the lock delay
+	ok, _, err = kv.Release(&api.KVPair{Key: "delayed", Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	id2, _, err := client.Session().Create(&api.SessionEntry{}, nil)
+	require.NoError(t, err)
+	ok, _, err = kv.Acquire(&api.KVPair{Key: "delayed", Session: id2}, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSessionsTTL(t *testing.T) {
+	a, client := newTestAgent(t, "")
+	defer a.Stop()
+	kv := client.KV()
+
+	id, _, err := client.Session().Create(&api.SessionEntry{Name: "ttl", TTL: "10s"}, nil)
+	require.NoError(t, err)
+	ok, _, err := kv.Acquire(&api.KVPair{Key: "locked", Value: []byte("v"), Session: id}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// As Consul does, sessions expire after twice their TTL
+	require.NoError(t, a.state.expireSessions(time.Now().Add(15*time.Second)))
+	sess, _, err := client.Session().Info(id, nil)
+	require.NoError(t, err)
+	require.NotNil(t, sess)
+	require.NoError(t, a.state.expireSessions(time.Now().Add(25*time.Second)))
+	sess, _, err = client.Session().Info(id, nil)
+	require.NoError(t, err)
+	assert.Nil(t, sess)
+	pair, _, err := kv.Get("locked", nil)
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	assert.Equal(t, "", pair.Session, "expired session should have released its locks")
+}
+
+func TestServicesHealth(t *testing.T) {
+	healthy := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	a, client := newTestAgent(t, "")
+	defer a.Stop()
+	nodeName, err := client.Agent().NodeName()
+	require.NoError(t, err)
+	assert.Equal(t, "node1", nodeName)
+
+	err = client.Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Name: "svc",
+		Tags: []string{"t1"},
+		Meta: map[string]string{"k": "v"},
+		Check: &api.AgentServiceCheck{
+			HTTP:     ts.URL,
+			Interval: "100ms",
+			Status:   api.HealthCritical,
+		},
+	})
+	require.NoError(t, err)
+
+	var entries []*api.ServiceEntry
+	var meta *api.QueryMeta
+	for i := 0; i < 50; i++ {
+		entries, meta, err = client.Health().Service("svc", "t1", true, nil)
+		require.NoError(t, err)
+		if len(entries) > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.Len(t, entries, 1)
+	assert.Equal(t, "v", entries[0].Service.Meta["k"])
+	require.Len(t, entries[0].Checks, 2)
+	assert.Equal(t, "service:svc", entries[0].Checks[1].CheckID)
+
+	id, _, err := client.Session().Create(&api.SessionEntry{Checks: []string{"serfHealth", "service:svc"}}, nil)
+	require.NoError(t, err)
+
+	healthy = false
+	entries, _, err = client.Health().Service("svc", "", true, &api.QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 5 * time.Second})
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+	sess, _, err := client.Session().Info(id, nil)
+	require.NoError(t, err)
+	assert.Nil(t, sess, "session relying on a critical check should be invalidated")
+
+	require.NoError(t, client.Agent().ServiceDeregister("svc"))
+	entries, _, err = client.Health().Service("svc", "", false, nil)
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func TestPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "standalone")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, client := newTestAgent(t, dir)
+	kv := client.KV()
+	_, err = kv.Put(&api.KVPair{Key: "p/1", Value: []byte("1")}, nil)
+	require.NoError(t, err)
+	_, err = kv.Put(&api.KVPair{Key: "p/2", Value: []byte("2")}, nil)
+	require.NoError(t, err)
+	_, err = kv.Delete("p/1", nil)
+	require.NoError(t, err)
+	id, _, err := client.Session().Create(nil, nil)
+	require.NoError(t, err)
+	_, _, err = kv.Acquire(&api.KVPair{Key: "p/lock", Session: id}, nil)
+	require.NoError(t, err)
+	_, meta, err := kv.List("p/", nil)
+	require.NoError(t, err)
+	snapshot, _, err := client.Snapshot().Save(nil)
+	require.NoError(t, err)
+	snapshotData, err := ioutil.ReadAll(snapshot)
+	require.NoError(t, err)
+	snapshot.Close()
+	require.NoError(t, a.Stop())
+
+	// Simulate a crash during the write of the last record
+	f, err := os.OpenFile(dir+"/"+journalFileName, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	f.WriteString(`{"Index":`)
+	f.Close()
+
+	a, client = newTestAgent(t, dir)
+	defer a.Stop()
+	kv = client.KV()
+	keys, meta2, err := kv.Keys("p/", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p/2", "p/lock"}, keys)
+	assert.True(t, meta2.LastIndex > meta.LastIndex, "indexes should keep increasing after a restart")
+	pair, _, err := kv.Get("p/lock", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "", pair.Session, "locks should be released on restart")
+
+	_, err = kv.DeleteTree("p/", nil)
+	require.NoError(t, err)
+	err = client.Snapshot().Restore(nil, bytes.NewReader(snapshotData))
+	require.NoError(t, err)
+	keys, _, err = kv.Keys("p/", "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p/2", "p/lock"}, keys)
+}
@@ -0,0 +1,341 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/log"
+)
+
+const (
+	defaultCheckTimeout = 10 * time.Second
+	maxCheckOutputSize  = 4 * 1024
+)
+
+type service struct {
+	entry  api.AgentService
+	checks []*check
+	stopCh chan struct{}
+}
+
+type check struct {
+	health     api.HealthCheck
+	definition api.AgentServiceCheck
+}
+
+// registerService registers or replaces a service of the agent and starts its checks
+func (a *Agent) registerService(body []byte) error {
+	reg := api.AgentServiceRegistration{}
+	err := json.Unmarshal(body, &reg)
+	if err != nil {
+		return errors.Wrap(err, "request decode failed")
+	}
+	if reg.Name == "" {
+		return errors.New("Missing service name")
+	}
+	if reg.ID == "" {
+		reg.ID = reg.Name
+	}
+	definitions := reg.Checks
+	if reg.Check != nil {
+		definitions = append(api.AgentServiceChecks{reg.Check}, definitions...)
+	}
+
+	s := a.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t := s.newWriteTxn()
+	if prev, ok := s.services[reg.ID]; ok {
+		s.removeService(t, prev)
+	}
+	svc := &service{
+		entry: api.AgentService{
+			ID:                reg.ID,
+			Service:           reg.Name,
+			Tags:              reg.Tags,
+			Meta:              reg.Meta,
+			Port:              reg.Port,
+			Address:           reg.Address,
+			EnableTagOverride: reg.EnableTagOverride,
+			CreateIndex:       t.index,
+			ModifyIndex:       t.index,
+		},
+		stopCh: make(chan struct{}),
+	}
+	for i, def := range definitions {
+		c := &check{definition: *def}
+		c.health = api.HealthCheck{
+			Node:        a.cfg.NodeName,
+			CheckID:     def.CheckID,
+			Name:        def.Name,
+			Status:      def.Status,
+			Notes:       def.Notes,
+			ServiceID:   reg.ID,
+			ServiceName: reg.Name,
+			ServiceTags: reg.Tags,
+		}
+		if c.health.CheckID == "" {
+			c.health.CheckID = "service:" + reg.ID
+			if len(definitions) > 1 {
+				c.health.CheckID += fmt.Sprintf(":%d", i+1)
+			}
+		}
+		if c.health.Name == "" {
+			c.health.Name = fmt.Sprintf("Service '%s' check", reg.Name)
+		}
+		if c.health.Status == "" {
+			c.health.Status = api.HealthCritical
+		}
+		svc.checks = append(svc.checks, c)
+	}
+	s.services[reg.ID] = svc
+	t.changedTopic(healthTopic)
+	err = t.commit()
+	for _, c := range svc.checks {
+		a.startCheck(svc, c)
+	}
+	return err
+}
+
+// deregisterService deregisters a service of the agent and stops its checks
+func (s *state) deregisterService(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	svc, ok := s.services[id]
+	if !ok {
+		return errors.Errorf("Unknown service %q", id)
+	}
+	t := s.newWriteTxn()
+	s.removeService(t, svc)
+	return t.commit()
+}
+
+// removeService stops checks of a service and invalidates sessions relying on them
+//
+// It should be called with the state lock held.
+func (s *state) removeService(t *writeTxn, svc *service) {
+	close(svc.stopCh)
+	delete(s.services, svc.entry.ID)
+	for _, c := range svc.checks {
+		s.invalidateSessionsOfCheck(t, c.health.CheckID)
+	}
+	t.changedTopic(healthTopic)
+}
+
+// checkStatus returns the status of a check of the agent
+//
+// It should be called with the state lock held.
+func (s *state) checkStatus(checkID string) (string, bool) {
+	if checkID == serfHealthCheckID {
+		return api.HealthPassing, true
+	}
+	for _, svc := range s.services {
+		for _, c := range svc.checks {
+			if c.health.CheckID == checkID {
+				return c.health.Status, true
+			}
+		}
+	}
+	return "", false
+}
+
+// updateCheck updates the status of a check, sessions relying on a check becoming critical are invalidated
+func (s *state) updateCheck(svc *service, c *check, status, output string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.services[svc.entry.ID] != svc || (c.health.Status == status && c.health.Output == output) {
+		return nil
+	}
+	t := s.newWriteTxn()
+	c.health.Status = status
+	c.health.Output = output
+	if status == api.HealthCritical {
+		s.invalidateSessionsOfCheck(t, c.health.CheckID)
+	}
+	t.changedTopic(healthTopic)
+	return t.commit()
+}
+
+// serviceEntries returns health information about instances of a service
+//
+// It should be called with the state lock held.
+func (a *Agent) serviceEntries(name, tag string, passingOnly bool) []*api.ServiceEntry {
+	res := make([]*api.ServiceEntry, 0)
+	for _, id := range sortedServiceIDs(a.state.services) {
+		svc := a.state.services[id]
+		if svc.entry.Service != name || (tag != "" && !contains(svc.entry.Tags, tag)) {
+			continue
+		}
+		checks := api.HealthChecks{a.nodeCheck()}
+		passing := true
+		for _, c := range svc.checks {
+			hc := c.health
+			checks = append(checks, &hc)
+			passing = passing && hc.Status == api.HealthPassing
+		}
+		if passingOnly && !passing {
+			continue
+		}
+		svcEntry := svc.entry
+		res = append(res, &api.ServiceEntry{
+			Node:    a.node(),
+			Service: &svcEntry,
+			Checks:  checks,
+		})
+	}
+	return res
+}
+
+func (a *Agent) node() *api.Node {
+	return &api.Node{
+		ID:         a.nodeID,
+		Node:       a.cfg.NodeName,
+		Address:    a.advertiseAddress(),
+		Datacenter: a.cfg.Datacenter,
+		TaggedAddresses: map[string]string{
+			"lan": a.advertiseAddress(),
+			"wan": a.advertiseAddress(),
+		},
+	}
+}
+
+func (a *Agent) nodeCheck() *api.HealthCheck {
+	return &api.HealthCheck{
+		Node:    a.cfg.NodeName,
+		CheckID: serfHealthCheckID,
+		Name:    "Serf Health Status",
+		Status:  api.HealthPassing,
+		Output:  "Agent alive and reachable",
+	}
+}
+
+// startCheck periodically runs HTTP and TCP checks, other kinds of checks keep their initial status
+func (a *Agent) startCheck(svc *service, c *check) {
+	def := c.definition
+	if def.HTTP == "" && def.TCP == "" {
+		return
+	}
+	interval, err := time.ParseDuration(def.Interval)
+	if err != nil || interval <= 0 {
+		log.Printf("[WARN] Invalid interval %q for check %q, it will not be run", def.Interval, c.health.CheckID)
+		return
+	}
+	timeout := defaultCheckTimeout
+	if d, err := time.ParseDuration(def.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: def.TLSSkipVerify || a.cfg.ChecksTLSSkipVerify},
+		},
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			var status, output string
+			if def.HTTP != "" {
+				status, output = runHTTPCheck(client, def)
+			} else {
+				status, output = runTCPCheck(def.TCP, timeout)
+			}
+			err := a.state.updateCheck(svc, c, status, output)
+			if err != nil {
+				log.Printf("[WARN] Failed to update status of check %q: %v", c.health.CheckID, err)
+			}
+			select {
+			case <-svc.stopCh:
+				return
+			case <-a.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func runHTTPCheck(client *http.Client, def api.AgentServiceCheck) (string, string) {
+	method := def.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, def.HTTP, nil)
+	if err != nil {
+		return api.HealthCritical, err.Error()
+	}
+	for k, values := range def.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return api.HealthCritical, err.Error()
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxCheckOutputSize))
+	output := fmt.Sprintf("HTTP %s %s: %s Output: %s", method, def.HTTP, resp.Status, body)
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return api.HealthPassing, output
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return api.HealthWarning, output
+	default:
+		return api.HealthCritical, output
+	}
+}
+
+func runTCPCheck(address string, timeout time.Duration) (string, string) {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return api.HealthCritical, err.Error()
+	}
+	conn.Close()
+	return api.HealthPassing, fmt.Sprintf("TCP connect %s: Success", address)
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedServiceIDs(services map[string]*service) []string {
+	ids := make([]string, 0, len(services))
+	for id := range services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,331 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/log"
+)
+
+// setQueryMeta sets headers expected by Consul clients on query responses
+func setQueryMeta(w http.ResponseWriter, index uint64) {
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(minIndex(index), 10))
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	w.Header().Set("X-Consul-LastContact", "0")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Debugf("Standalone agent failed to write response: %v", err)
+	}
+}
+
+func badRequest(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func internalError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func methodNotAllowed(w http.ResponseWriter) {
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// parseBlockingParams returns the index and the wait time of a blocking query
+func parseBlockingParams(q url.Values) (uint64, time.Duration, error) {
+	var index uint64
+	var wait time.Duration
+	var err error
+	if v := q.Get("index"); v != "" {
+		index, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "Invalid index")
+		}
+	}
+	if v := q.Get("wait"); v != "" {
+		wait, err = time.ParseDuration(v)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "Invalid wait time")
+		}
+	}
+	return index, wait, nil
+}
+
+func (a *Agent) handleSessionCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	id, err := a.state.createSession(a.cfg.NodeName, body)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{ ID string }{id})
+}
+
+func (a *Agent) handleSessionDestroy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	err := a.state.destroySession(strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/"))
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, true)
+}
+
+func (a *Agent) handleSessionRenew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/v1/session/renew/")
+	e := a.state.renewSession(id)
+	if e == nil {
+		http.Error(w, "Session id '"+id+"' not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, []*api.SessionEntry{e})
+}
+
+func (a *Agent) handleSessionInfo(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/session/info/")
+	waitIndex, wait, err := parseBlockingParams(r.URL.Query())
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	var entries []*api.SessionEntry
+	index := a.state.blockingQuery(r.Context(), waitIndex, wait, matchTopic(sessionTopic), func() uint64 {
+		entries = nil
+		if sess, ok := a.state.sessions[id]; ok {
+			e := sess.entry
+			entries = []*api.SessionEntry{&e}
+		}
+		return a.state.sessionIndex
+	})
+	setQueryMeta(w, index)
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (a *Agent) handleSessionList(w http.ResponseWriter, r *http.Request) {
+	node := strings.TrimPrefix(r.URL.Path, "/v1/session/node/")
+	if node == r.URL.Path {
+		node = ""
+	}
+	waitIndex, wait, err := parseBlockingParams(r.URL.Query())
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	var entries []*api.SessionEntry
+	index := a.state.blockingQuery(r.Context(), waitIndex, wait, matchTopic(sessionTopic), func() uint64 {
+		entries = a.state.listSessions(node)
+		return a.state.sessionIndex
+	})
+	setQueryMeta(w, index)
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (a *Agent) handleAgentSelf(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]map[string]interface{}{
+		"Config": {
+			"Datacenter": a.cfg.Datacenter,
+			"NodeName":   a.cfg.NodeName,
+			"NodeID":     a.nodeID,
+			"Server":     true,
+			"Revision":   "standalone",
+		},
+		"Member": {
+			"Name":   a.cfg.NodeName,
+			"Addr":   a.advertiseAddress(),
+			"Status": 1,
+			"Tags":   map[string]string{"dc": a.cfg.Datacenter, "role": "consul"},
+		},
+	})
+}
+
+func (a *Agent) handleAgentServices(w http.ResponseWriter, r *http.Request) {
+	a.state.lock.Lock()
+	defer a.state.lock.Unlock()
+	services := make(map[string]api.AgentService, len(a.state.services))
+	for id, svc := range a.state.services {
+		services[id] = svc.entry
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+func (a *Agent) handleAgentChecks(w http.ResponseWriter, r *http.Request) {
+	a.state.lock.Lock()
+	defer a.state.lock.Unlock()
+	checks := make(map[string]api.AgentCheck)
+	for _, svc := range a.state.services {
+		for _, c := range svc.checks {
+			checks[c.health.CheckID] = api.AgentCheck{
+				Node:        c.health.Node,
+				CheckID:     c.health.CheckID,
+				Name:        c.health.Name,
+				Status:      c.health.Status,
+				Notes:       c.health.Notes,
+				Output:      c.health.Output,
+				ServiceID:   c.health.ServiceID,
+				ServiceName: c.health.ServiceName,
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, checks)
+}
+
+func (a *Agent) handleServiceRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	err = a.registerService(body)
+	if err != nil {
+		badRequest(w, err)
+	}
+}
+
+func (a *Agent) handleServiceDeregister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	err := a.state.deregisterService(strings.TrimPrefix(r.URL.Path, "/v1/agent/service/deregister/"))
+	if err != nil {
+		internalError(w, err)
+	}
+}
+
+func (a *Agent) handleHealthService(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/v1/health/service/")
+	q := r.URL.Query()
+	_, passingOnly := q["passing"]
+	waitIndex, wait, err := parseBlockingParams(q)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	var entries []*api.ServiceEntry
+	index := a.state.blockingQuery(r.Context(), waitIndex, wait, matchTopic(healthTopic), func() uint64 {
+		entries = a.serviceEntries(name, q.Get("tag"), passingOnly)
+		return a.state.healthIndex
+	})
+	setQueryMeta(w, index)
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// handleCatalogNodes lists the single node of the agent, it never changes so blocking queries last until their wait time
+func (a *Agent) handleCatalogNodes(w http.ResponseWriter, r *http.Request) {
+	waitIndex, wait, err := parseBlockingParams(r.URL.Query())
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	index := a.state.blockingQuery(r.Context(), waitIndex, wait, matchTopic(catalogTopic), func() uint64 {
+		return 1
+	})
+	setQueryMeta(w, index)
+	writeJSON(w, http.StatusOK, []*api.Node{a.node()})
+}
+
+func (a *Agent) handleStatusLeader(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.Address())
+}
+
+func (a *Agent) handleStatusPeers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []string{a.Address()})
+}
+
+// handleSnapshot saves or restores the key/value store, sessions are not part of snapshots
+func (a *Agent) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	s := a.state
+	switch r.Method {
+	case http.MethodGet:
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		setQueryMeta(w, s.index)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		err := writeSnapshot(w, s)
+		if err != nil {
+			log.Printf("[WARN] Standalone agent failed to write snapshot: %v", err)
+		}
+	case http.MethodPut:
+		data, err := readSnapshot(r.Body)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		err = s.restoreSnapshot(data)
+		if err != nil {
+			internalError(w, err)
+		}
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+// restoreSnapshot replaces the key/value store by the given snapshot and invalidates all sessions
+func (s *state) restoreSnapshot(data *snapshotData) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sessions = make(map[string]*session)
+	for _, e := range data.Entries {
+		e.Session = ""
+	}
+	s.restore(data)
+	s.index++
+	s.kvIndex = s.index
+	s.reapIndex = s.index
+	s.sessionIndex = s.index
+	s.notifyAll()
+	if s.journal == nil {
+		return nil
+	}
+	return s.journal.compact(s)
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	return ioutil.ReadAll(r.Body)
+}
@@ -0,0 +1,268 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	radix "github.com/armon/go-radix"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/log"
+)
+
+const (
+	snapshotFileName = "state.snapshot"
+	journalFileName  = "state.journal"
+	// journalCompactionThreshold is the number of journal records after which a new snapshot is written
+	journalCompactionThreshold = 20000
+)
+
+// journalRecord is a line of the journal, it holds keys changed by a write transaction
+type journalRecord struct {
+	Index  uint64
+	Set    []*kvEntry `json:",omitempty"`
+	Delete []string   `json:",omitempty"`
+}
+
+// snapshotData is the content of a snapshot
+type snapshotData struct {
+	Index   uint64
+	Entries []*kvEntry
+}
+
+// journal persists the key/value store into a directory.
+//
+// The directory contains a snapshot of the store and a journal of changes done since this snapshot.
+// On startup the journal is replayed on top of the snapshot and a new snapshot is written.
+// Writes are flushed to the operating system but not synced to the disk.
+type journal struct {
+	dir     string
+	f       *os.File
+	w       *bufio.Writer
+	records int
+}
+
+// openJournal loads the content of the given directory into the given state
+func openJournal(dir string, s *state) (*journal, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create standalone data directory %q", dir)
+	}
+	j := &journal{dir: dir}
+	err = j.loadSnapshot(s)
+	if err != nil {
+		return nil, err
+	}
+	err = j.replay(s)
+	if err != nil {
+		return nil, err
+	}
+	err = j.compact(s)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *journal) loadSnapshot(s *state) error {
+	f, err := os.Open(filepath.Join(j.dir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open standalone snapshot")
+	}
+	defer f.Close()
+	data, err := readSnapshot(f)
+	if err != nil {
+		return err
+	}
+	s.restore(data)
+	return nil
+}
+
+func (j *journal) replay(s *state) error {
+	f, err := os.Open(filepath.Join(j.dir, journalFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open standalone journal")
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("[WARN] Ignoring truncated last record of standalone journal")
+			}
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read standalone journal")
+		}
+		record := new(journalRecord)
+		err = json.Unmarshal(line, record)
+		if err != nil {
+			log.Printf("[WARN] Ignoring corrupted end of standalone journal: %v", err)
+			return nil
+		}
+		if record.Index <= s.index {
+			// already in the snapshot
+			continue
+		}
+		for _, e := range record.Set {
+			s.tree.Insert(e.Key, e)
+		}
+		for _, k := range record.Delete {
+			s.tree.Delete(k)
+		}
+		s.index = record.Index
+		s.kvIndex = record.Index
+		s.reapIndex = record.Index
+	}
+}
+
+// append writes the given changes to the journal
+func (j *journal) append(index uint64, changed map[string]*kvEntry) error {
+	record := journalRecord{Index: index}
+	for k, e := range changed {
+		if e.isTombstone() {
+			record.Delete = append(record.Delete, k)
+		} else {
+			record.Set = append(record.Set, e)
+		}
+	}
+	b, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode standalone journal record")
+	}
+	b = append(b, '\n')
+	_, err = j.w.Write(b)
+	if err == nil {
+		err = j.w.Flush()
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write standalone journal record")
+	}
+	j.records++
+	return nil
+}
+
+// compact writes a snapshot of the given state and starts a new empty journal
+func (j *journal) compact(s *state) error {
+	tmpFile := filepath.Join(j.dir, snapshotFileName+".tmp")
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create standalone snapshot")
+	}
+	err = writeSnapshot(f, s)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write standalone snapshot")
+	}
+	err = os.Rename(tmpFile, filepath.Join(j.dir, snapshotFileName))
+	if err != nil {
+		return errors.Wrap(err, "failed to write standalone snapshot")
+	}
+
+	if j.f != nil {
+		j.f.Close()
+	}
+	j.f, err = os.OpenFile(filepath.Join(j.dir, journalFileName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create standalone journal")
+	}
+	j.w = bufio.NewWriter(j.f)
+	j.records = 0
+	return nil
+}
+
+func (j *journal) close() error {
+	if j == nil || j.f == nil {
+		return nil
+	}
+	err := j.w.Flush()
+	if closeErr := j.f.Close(); err == nil {
+		err = closeErr
+	}
+	j.f = nil
+	return err
+}
+
+// persist journals the given changes if the state is backed by a directory
+//
+// It should be called with the state lock held.
+func (s *state) persist(index uint64, changed map[string]*kvEntry) error {
+	if s.journal == nil {
+		return nil
+	}
+	err := s.journal.append(index, changed)
+	if err != nil || s.journal.records < journalCompactionThreshold {
+		return err
+	}
+	return s.journal.compact(s)
+}
+
+// restore replaces the content of the key/value store by the given snapshot data
+//
+// It should be called with the state lock held.
+func (s *state) restore(data *snapshotData) {
+	s.tree = radix.New()
+	for _, e := range data.Entries {
+		s.tree.Insert(e.Key, e)
+	}
+	if data.Index > s.index {
+		s.index = data.Index
+	}
+	s.kvIndex = s.index
+	s.reapIndex = s.index
+}
+
+// writeSnapshot writes live entries of the key/value store as a gzipped JSON document
+//
+// It should be called with the state lock held.
+func writeSnapshot(w io.Writer, s *state) error {
+	data := snapshotData{Index: s.index, Entries: s.list("")}
+	zw := gzip.NewWriter(w)
+	err := json.NewEncoder(zw).Encode(data)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func readSnapshot(r io.Reader) (*snapshotData, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read standalone snapshot")
+	}
+	defer zr.Close()
+	data := new(snapshotData)
+	err = json.NewDecoder(zr).Decode(data)
+	return data, errors.Wrap(err, "failed to decode standalone snapshot")
+}
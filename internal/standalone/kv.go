@@ -0,0 +1,337 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+)
+
+// write runs f in a write transaction, changes are rolled back if f returns an error
+func (s *state) write(f func(t *writeTxn) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t := s.newWriteTxn()
+	err := f(t)
+	if err != nil {
+		t.rollback()
+		return err
+	}
+	if len(t.changed) == 0 && len(t.topics) == 0 {
+		return nil
+	}
+	return t.commit()
+}
+
+func (a *Agent) handleKV(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch r.Method {
+	case http.MethodGet:
+		a.kvGet(w, r, key)
+	case http.MethodPut:
+		a.kvPut(w, r, key)
+	case http.MethodDelete:
+		a.kvDelete(w, r, key)
+	default:
+		methodNotAllowed(w)
+	}
+}
+
+func (a *Agent) kvGet(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	_, recurse := q["recurse"]
+	_, keysOnly := q["keys"]
+	_, raw := q["raw"]
+	waitIndex, wait, err := parseBlockingParams(q)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	var entries []*kvEntry
+	var index uint64
+	if recurse || keysOnly {
+		index = a.state.blockingQuery(r.Context(), waitIndex, wait, matchPrefix(key), func() uint64 {
+			entries = a.state.list(key)
+			return a.state.prefixIndex(key)
+		})
+	} else {
+		index = a.state.blockingQuery(r.Context(), waitIndex, wait, matchKey(key), func() uint64 {
+			entries = nil
+			if e := a.state.get(key); e != nil {
+				entries = []*kvEntry{e}
+			}
+			return a.state.keyIndex(key)
+		})
+	}
+	setQueryMeta(w, index)
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if keysOnly {
+		writeJSON(w, http.StatusOK, listKeys(entries, key, q.Get("separator")))
+		return
+	}
+	if raw {
+		w.Write(entries[0].Value)
+		return
+	}
+	pairs := make(api.KVPairs, len(entries))
+	for i, e := range entries {
+		pairs[i] = e.toKVPair(true)
+	}
+	writeJSON(w, http.StatusOK, pairs)
+}
+
+// listKeys returns keys of the given entries, if a separator is given keys are truncated after the first separator following the prefix
+func listKeys(entries []*kvEntry, prefix, separator string) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		k := e.Key
+		if separator != "" {
+			if i := strings.Index(k[len(prefix):], separator); i >= 0 {
+				k = k[:len(prefix)+i+len(separator)]
+			}
+		}
+		if len(keys) == 0 || keys[len(keys)-1] != k {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (a *Agent) kvPut(w http.ResponseWriter, r *http.Request, key string) {
+	if key == "" {
+		badRequest(w, errors.New("Missing key name"))
+		return
+	}
+	q := r.URL.Query()
+	var flags uint64
+	var err error
+	if v := q.Get("flags"); v != "" {
+		flags, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			badRequest(w, errors.Wrap(err, "invalid flags"))
+			return
+		}
+	}
+	var casIndex uint64
+	_, isCAS := q["cas"]
+	if isCAS {
+		casIndex, err = strconv.ParseUint(q.Get("cas"), 10, 64)
+		if err != nil {
+			badRequest(w, errors.Wrap(err, "invalid cas index"))
+			return
+		}
+	}
+	value, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	if len(value) == 0 {
+		value = nil
+	}
+
+	var ok bool
+	err = a.state.write(func(t *writeTxn) error {
+		var err error
+		switch {
+		case q.Get("acquire") != "":
+			_, ok, err = t.acquire(key, value, flags, q.Get("acquire"))
+		case q.Get("release") != "":
+			_, ok = t.release(key, value, flags, q.Get("release"))
+		case isCAS:
+			_, ok = t.cas(key, value, flags, casIndex)
+		default:
+			t.set(key, value, flags)
+			ok = true
+		}
+		return err
+	})
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ok)
+}
+
+func (a *Agent) kvDelete(w http.ResponseWriter, r *http.Request, key string) {
+	q := r.URL.Query()
+	_, recurse := q["recurse"]
+	_, isCAS := q["cas"]
+	var casIndex uint64
+	var err error
+	if isCAS {
+		casIndex, err = strconv.ParseUint(q.Get("cas"), 10, 64)
+		if err != nil {
+			badRequest(w, errors.Wrap(err, "invalid cas index"))
+			return
+		}
+	}
+	ok := true
+	err = a.state.write(func(t *writeTxn) error {
+		switch {
+		case recurse:
+			t.deleteTree(key)
+		case isCAS:
+			ok = t.deleteCAS(key, casIndex)
+		default:
+			t.delete(key)
+		}
+		return nil
+	})
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ok)
+}
+
+// txnOp is an operation of a transaction, only key/value operations are supported
+type txnOp struct {
+	KV *api.KVTxnOp
+}
+
+func (a *Agent) handleTxn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		methodNotAllowed(w)
+		return
+	}
+	var ops []txnOp
+	err := json.NewDecoder(r.Body).Decode(&ops)
+	if err != nil {
+		badRequest(w, errors.Wrap(err, "Failed to parse body"))
+		return
+	}
+	resp := api.TxnResponse{}
+	errTxnRollback := errors.New("transaction rolled back")
+	err = a.state.write(func(t *writeTxn) error {
+		for i, op := range ops {
+			if op.KV == nil {
+				resp.Errors = append(resp.Errors, &api.TxnError{OpIndex: i, What: "only KV operations are supported"})
+				continue
+			}
+			results, what := applyKVTxnOp(t, op.KV)
+			if what != "" {
+				resp.Errors = append(resp.Errors, &api.TxnError{OpIndex: i, What: what})
+				continue
+			}
+			for _, p := range results {
+				resp.Results = append(resp.Results, &api.TxnResult{KV: p})
+			}
+		}
+		if len(resp.Errors) > 0 {
+			return errTxnRollback
+		}
+		return nil
+	})
+	a.state.lock.Lock()
+	setQueryMeta(w, a.state.index)
+	a.state.lock.Unlock()
+	if err == errTxnRollback {
+		resp.Results = nil
+		writeJSON(w, http.StatusConflict, resp)
+		return
+	}
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// applyKVTxnOp applies a key/value operation of a transaction, it returns the operation results or the reason of its failure
+func applyKVTxnOp(t *writeTxn, op *api.KVTxnOp) (api.KVPairs, string) {
+	var value []byte
+	if len(op.Value) > 0 {
+		value = op.Value
+	}
+	switch op.Verb {
+	case api.KVSet:
+		return api.KVPairs{t.set(op.Key, value, op.Flags).toKVPair(false)}, ""
+	case api.KVCAS:
+		e, ok := t.cas(op.Key, value, op.Flags, op.Index)
+		if !ok {
+			return nil, fmt.Sprintf("failed to set key %q, index is stale", op.Key)
+		}
+		return api.KVPairs{e.toKVPair(false)}, ""
+	case api.KVLock:
+		e, ok, err := t.acquire(op.Key, value, op.Flags, op.Session)
+		if err != nil {
+			return nil, err.Error()
+		}
+		if !ok {
+			return nil, fmt.Sprintf("failed to lock key %q, lock is already held", op.Key)
+		}
+		return api.KVPairs{e.toKVPair(false)}, ""
+	case api.KVUnlock:
+		e, ok := t.release(op.Key, value, op.Flags, op.Session)
+		if !ok {
+			return nil, fmt.Sprintf("failed to unlock key %q, lock isn't held, or is held by another session", op.Key)
+		}
+		return api.KVPairs{e.toKVPair(false)}, ""
+	case api.KVGet:
+		e := t.s.get(op.Key)
+		if e == nil {
+			return nil, fmt.Sprintf("key %q doesn't exist", op.Key)
+		}
+		return api.KVPairs{e.toKVPair(true)}, ""
+	case api.KVGetTree:
+		var res api.KVPairs
+		for _, e := range t.s.list(op.Key) {
+			res = append(res, e.toKVPair(true))
+		}
+		return res, ""
+	case api.KVCheckIndex:
+		e := t.s.get(op.Key)
+		if e == nil || e.ModifyIndex != op.Index {
+			return nil, fmt.Sprintf("current modify index of key %q != %d", op.Key, op.Index)
+		}
+		return api.KVPairs{e.toKVPair(false)}, ""
+	case api.KVCheckSession:
+		e := t.s.get(op.Key)
+		if e == nil || e.Session != op.Session {
+			return nil, fmt.Sprintf("current session of key %q != %q", op.Key, op.Session)
+		}
+		return api.KVPairs{e.toKVPair(false)}, ""
+	case api.KVCheckNotExists:
+		if t.s.get(op.Key) != nil {
+			return nil, fmt.Sprintf("key %q exists", op.Key)
+		}
+		return nil, ""
+	case api.KVDelete:
+		t.delete(op.Key)
+		return nil, ""
+	case api.KVDeleteCAS:
+		if !t.deleteCAS(op.Key, op.Index) {
+			return nil, fmt.Sprintf("failed to delete key %q, index is stale", op.Key)
+		}
+		return nil, ""
+	case api.KVDeleteTree:
+		t.deleteTree(op.Key)
+		return nil, ""
+	default:
+		return nil, fmt.Sprintf("unknown KV verb %q", op.Verb)
+	}
+}
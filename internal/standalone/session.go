@@ -0,0 +1,270 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	sessionBehaviorRelease = "release"
+	sessionBehaviorDelete  = "delete"
+	// serfHealthCheckID is the ID of the node health check, always passing for the standalone agent
+	serfHealthCheckID = "serfHealth"
+	// defaultLockDelay is the lock delay of sessions created without specifying it, as in Consul
+	defaultLockDelay = 15 * time.Second
+	// maxLockDelay is the maximum lock delay of sessions, as in Consul
+	maxLockDelay = 60 * time.Second
+	// minSessionTTL and maxSessionTTL are the bounds of sessions TTLs, as in Consul
+	minSessionTTL = 10 * time.Second
+	maxSessionTTL = 24 * time.Hour
+)
+
+type session struct {
+	entry api.SessionEntry
+	// ttl is the duration after which the session is invalidated if not renewed
+	ttl       time.Duration
+	expiresAt time.Time
+}
+
+// sessionRequest is the body of a session creation request, LockDelay is sent as a duration string by clients
+type sessionRequest struct {
+	Name      string
+	Node      string
+	LockDelay interface{}
+	Checks    []string
+	Behavior  string
+	TTL       string
+}
+
+func parseLockDelay(v interface{}) (time.Duration, error) {
+	var d time.Duration
+	switch lockDelay := v.(type) {
+	case nil:
+		return defaultLockDelay, nil
+	case float64:
+		// Consul considers numbers lower than a millisecond as seconds
+		d = time.Duration(lockDelay)
+		if lockDelay < float64(time.Millisecond) {
+			d = time.Duration(lockDelay) * time.Second
+		}
+	case string:
+		var err error
+		d, err = time.ParseDuration(lockDelay)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.Errorf("invalid LockDelay %v", v)
+	}
+	if d > maxLockDelay {
+		d = maxLockDelay
+	}
+	return d, nil
+}
+
+// createSession creates a new session for the given node
+func (s *state) createSession(node string, body []byte) (string, error) {
+	req := sessionRequest{}
+	if len(body) > 0 {
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return "", errors.Wrap(err, "request decode failed")
+		}
+	}
+	if req.Node == "" {
+		req.Node = node
+	}
+	if req.Node != node {
+		return "", errors.Errorf("Failed to create session: node %q not found", req.Node)
+	}
+	if req.Checks == nil {
+		req.Checks = []string{serfHealthCheckID}
+	}
+	switch req.Behavior {
+	case "":
+		req.Behavior = sessionBehaviorRelease
+	case sessionBehaviorRelease, sessionBehaviorDelete:
+	default:
+		return "", errors.Errorf("Invalid Behavior setting %q", req.Behavior)
+	}
+	lockDelay, err := parseLockDelay(req.LockDelay)
+	if err != nil {
+		return "", err
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil {
+			return "", errors.Wrapf(err, "Request TTL decode failed")
+		}
+		if ttl != 0 && (ttl < minSessionTTL || ttl > maxSessionTTL) {
+			return "", errors.Errorf("Invalid Session TTL '%d', must be between [%v=%v]", ttl, minSessionTTL, maxSessionTTL)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, checkID := range req.Checks {
+		status, ok := s.checkStatus(checkID)
+		if !ok {
+			return "", errors.Errorf("Missing check '%s' registration", checkID)
+		}
+		if status == api.HealthCritical {
+			return "", errors.Errorf("Check '%s' is in critical state", checkID)
+		}
+	}
+	t := s.newWriteTxn()
+	sess := &session{
+		entry: api.SessionEntry{
+			CreateIndex: t.index,
+			ID:          uuid.NewV4().String(),
+			Name:        req.Name,
+			Node:        req.Node,
+			Checks:      req.Checks,
+			LockDelay:   lockDelay,
+			Behavior:    req.Behavior,
+			TTL:         req.TTL,
+		},
+		ttl: ttl,
+	}
+	if ttl > 0 {
+		// Consul applies a grace period of the TTL duration before invalidating sessions
+		sess.expiresAt = t.now.Add(2 * ttl)
+	}
+	s.sessions[sess.entry.ID] = sess
+	t.changedTopic(sessionTopic)
+	return sess.entry.ID, t.commit()
+}
+
+// renewSession resets the TTL of a session, it returns nil if the session does not exist
+func (s *state) renewSession(id string) *api.SessionEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	if sess.ttl > 0 {
+		sess.expiresAt = time.Now().Add(2 * sess.ttl)
+	}
+	e := sess.entry
+	return &e
+}
+
+// destroySession invalidates a session
+func (s *state) destroySession(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t := s.newWriteTxn()
+	s.invalidateSession(t, id)
+	return t.commit()
+}
+
+// invalidateSession removes a session and releases or deletes keys locked by it according to its behavior.
+//
+// Keys locked by the session can't be acquired again until the end of its lock delay.
+func (s *state) invalidateSession(t *writeTxn, id string) {
+	sess, ok := s.sessions[id]
+	if !ok {
+		return
+	}
+	delete(s.sessions, id)
+	t.changedTopic(sessionTopic)
+	for _, e := range s.list("") {
+		if e.Session != id {
+			continue
+		}
+		if sess.entry.LockDelay > 0 {
+			s.lockDelays[e.Key] = t.now.Add(sess.entry.LockDelay)
+		}
+		if sess.entry.Behavior == sessionBehaviorDelete {
+			t.delete(e.Key)
+		} else {
+			t.unlock(e)
+		}
+	}
+}
+
+// invalidateSessionsOfCheck invalidates sessions relying on the given check
+func (s *state) invalidateSessionsOfCheck(t *writeTxn, checkID string) {
+	for id, sess := range s.sessions {
+		for _, c := range sess.entry.Checks {
+			if c == checkID {
+				s.invalidateSession(t, id)
+				break
+			}
+		}
+	}
+}
+
+// expireSessions invalidates sessions whose TTL expired
+func (s *state) expireSessions(now time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var expired []string
+	for id, sess := range s.sessions {
+		if sess.ttl > 0 && now.After(sess.expiresAt) {
+			expired = append(expired, id)
+		}
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	t := s.newWriteTxn()
+	for _, id := range expired {
+		s.invalidateSession(t, id)
+	}
+	return t.commit()
+}
+
+// releaseOrphanLocks unlocks keys locked by sessions that do not exist anymore, this happens on restart as sessions are not persisted
+func (s *state) releaseOrphanLocks() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t := s.newWriteTxn()
+	for _, e := range s.list("") {
+		if _, ok := s.sessions[e.Session]; e.Session != "" && !ok {
+			t.unlock(e)
+		}
+	}
+	if len(t.changed) == 0 {
+		return nil
+	}
+	return t.commit()
+}
+
+// listSessions returns sessions of the given node or of all nodes if node is empty.
+//
+// It should be called with the state lock held.
+func (s *state) listSessions(node string) []*api.SessionEntry {
+	res := make([]*api.SessionEntry, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		if node == "" || sess.entry.Node == node {
+			e := sess.entry
+			res = append(res, &e)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreateIndex < res[j].CreateIndex
+	})
+	return res
+}
@@ -0,0 +1,308 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	radix "github.com/armon/go-radix"
+	"github.com/hashicorp/consul/api"
+)
+
+// tombstoneTTL is the duration during which deleted keys are remembered to compute the index of blocking queries
+const tombstoneTTL = 15 * time.Minute
+
+// maxQueryWaitTime is the maximum duration of a blocking query
+const maxQueryWaitTime = 10 * time.Minute
+
+// defaultQueryWaitTime is the duration of a blocking query when not specified
+const defaultQueryWaitTime = 5 * time.Minute
+
+const (
+	healthTopic   = "health"
+	sessionTopic  = "session"
+	catalogTopic  = "catalog"
+	kvTopicPrefix = "kv:"
+)
+
+// kvEntry is a key/value pair of the store.
+//
+// Deleted keys are kept as tombstones for some time in order to let blocking queries on their prefix
+// know that something changed.
+type kvEntry struct {
+	Key         string
+	Value       []byte `json:",omitempty"`
+	Flags       uint64 `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+	LockIndex   uint64 `json:",omitempty"`
+	Session     string `json:",omitempty"`
+
+	deletedAt time.Time
+}
+
+func (e *kvEntry) isTombstone() bool {
+	return !e.deletedAt.IsZero()
+}
+
+func (e *kvEntry) toKVPair(withValue bool) *api.KVPair {
+	p := &api.KVPair{
+		Key:         e.Key,
+		Flags:       e.Flags,
+		CreateIndex: e.CreateIndex,
+		ModifyIndex: e.ModifyIndex,
+		LockIndex:   e.LockIndex,
+		Session:     e.Session,
+	}
+	if withValue {
+		p.Value = e.Value
+	}
+	return p
+}
+
+func (e *kvEntry) clone() *kvEntry {
+	c := *e
+	return &c
+}
+
+type watcher struct {
+	match func(topic string) bool
+	ch    chan struct{}
+}
+
+// state holds everything managed by the standalone agent: the key/value store, sessions and services.
+//
+// All accesses are serialized using a single lock, writes are done through a writeTxn in order to
+// be atomically applied, journaled and notified to blocking queries.
+type state struct {
+	lock sync.Mutex
+	// index is the index of the last write
+	index uint64
+	// kvIndex is the index of the last change of the key/value store
+	kvIndex uint64
+	// reapIndex is the highest index of reaped tombstones, indexes of prefixes can't be lower than it
+	reapIndex uint64
+	// healthIndex is the index of the last change of services or checks
+	healthIndex uint64
+	// sessionIndex is the index of the last change of sessions
+	sessionIndex uint64
+	// tree holds entries of the key/value store, walking it returns keys in lexical order
+	tree     *radix.Tree
+	watchers map[*watcher]struct{}
+	journal  *journal
+	sessions map[string]*session
+	services map[string]*service
+	// lockDelays holds the time until which keys can't be acquired after the invalidation of the session locking them
+	lockDelays map[string]time.Time
+}
+
+func newState() *state {
+	return &state{
+		index:      1,
+		tree:       radix.New(),
+		watchers:   make(map[*watcher]struct{}),
+		sessions:   make(map[string]*session),
+		services:   make(map[string]*service),
+		lockDelays: make(map[string]time.Time),
+	}
+}
+
+// get returns the live entry for the given key or nil if it does not exist
+func (s *state) get(key string) *kvEntry {
+	e := s.entry(key)
+	if e == nil || e.isTombstone() {
+		return nil
+	}
+	return e
+}
+
+// entry returns the entry (possibly a tombstone) for the given key or nil if it does not exist
+func (s *state) entry(key string) *kvEntry {
+	v, ok := s.tree.Get(key)
+	if !ok {
+		return nil
+	}
+	return v.(*kvEntry)
+}
+
+// walkPrefix calls f on each entry (including tombstones) having the given prefix, in keys order, until f returns false
+func (s *state) walkPrefix(prefix string, f func(e *kvEntry) bool) {
+	s.tree.WalkPrefix(prefix, func(_ string, v interface{}) bool {
+		return !f(v.(*kvEntry))
+	})
+}
+
+// list returns live entries having the given prefix
+func (s *state) list(prefix string) []*kvEntry {
+	var res []*kvEntry
+	s.walkPrefix(prefix, func(e *kvEntry) bool {
+		if !e.isTombstone() {
+			res = append(res, e)
+		}
+		return true
+	})
+	return res
+}
+
+// keyIndex returns the index of the last change of the given key.
+//
+// As Consul does, the index of the whole KV store is returned when the key does not exist.
+func (s *state) keyIndex(key string) uint64 {
+	if e := s.get(key); e != nil {
+		return minIndex(e.ModifyIndex)
+	}
+	return minIndex(s.kvIndex)
+}
+
+// prefixIndex returns the index of the last change of a key having the given prefix.
+//
+// As Consul does, the index of the whole KV store is returned when no key (even deleted)
+// has the given prefix or when the prefix is empty.
+func (s *state) prefixIndex(prefix string) uint64 {
+	if prefix == "" {
+		return minIndex(s.kvIndex)
+	}
+	var index uint64
+	s.walkPrefix(prefix, func(e *kvEntry) bool {
+		if e.ModifyIndex > index {
+			index = e.ModifyIndex
+		}
+		return true
+	})
+	if index == 0 {
+		index = s.kvIndex
+	}
+	if index < s.reapIndex {
+		index = s.reapIndex
+	}
+	return minIndex(index)
+}
+
+// minIndex ensures that returned indexes are never 0 as a 0 index disables blocking queries
+func minIndex(index uint64) uint64 {
+	if index == 0 {
+		return 1
+	}
+	return index
+}
+
+// reapTombstones removes tombstones older than tombstoneTTL and expired lock delays
+func (s *state) reapTombstones(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var reaped []*kvEntry
+	s.tree.Walk(func(_ string, v interface{}) bool {
+		e := v.(*kvEntry)
+		if e.isTombstone() && now.Sub(e.deletedAt) > tombstoneTTL {
+			reaped = append(reaped, e)
+		}
+		return false
+	})
+	for _, e := range reaped {
+		s.tree.Delete(e.Key)
+		if e.ModifyIndex > s.reapIndex {
+			s.reapIndex = e.ModifyIndex
+		}
+	}
+	for k, expires := range s.lockDelays {
+		if now.After(expires) {
+			delete(s.lockDelays, k)
+		}
+	}
+}
+
+// blockingQuery implements Consul blocking queries semantic.
+//
+// f is called with the state lock held, it should compute the query result and return its index.
+// If this index is not greater than waitIndex, the query waits until a topic matched by match
+// is changed or until the wait time elapsed.
+func (s *state) blockingQuery(ctx context.Context, waitIndex uint64, wait time.Duration, match func(topic string) bool, f func() uint64) uint64 {
+	if wait <= 0 {
+		wait = defaultQueryWaitTime
+	}
+	if wait > maxQueryWaitTime {
+		wait = maxQueryWaitTime
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		s.lock.Lock()
+		index := f()
+		if waitIndex == 0 || index > waitIndex {
+			s.lock.Unlock()
+			return index
+		}
+		w := &watcher{match: match, ch: make(chan struct{})}
+		s.watchers[w] = struct{}{}
+		s.lock.Unlock()
+
+		select {
+		case <-w.ch:
+			continue
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		s.lock.Lock()
+		delete(s.watchers, w)
+		index = f()
+		s.lock.Unlock()
+		return index
+	}
+}
+
+// notify wakes up blocking queries watching one of the given topics.
+//
+// It should be called with the state lock held.
+func (s *state) notify(topics []string) {
+	if len(topics) == 0 {
+		return
+	}
+	for w := range s.watchers {
+		for _, t := range topics {
+			if w.match(t) {
+				close(w.ch)
+				delete(s.watchers, w)
+				break
+			}
+		}
+	}
+}
+
+func kvTopic(key string) string {
+	return kvTopicPrefix + key
+}
+
+func matchKey(key string) func(string) bool {
+	t := kvTopic(key)
+	return func(topic string) bool {
+		return topic == t
+	}
+}
+
+func matchPrefix(prefix string) func(string) bool {
+	t := kvTopic(prefix)
+	return func(topic string) bool {
+		return strings.HasPrefix(topic, t)
+	}
+}
+
+func matchTopic(t string) func(string) bool {
+	return func(topic string) bool {
+		return topic == t
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/pkg/errors"
+)
+
+// TestServerEnvName is the name of the environment variable allowing to run tests using a standalone agent.
+//
+// Tests run against a real Consul server unless this variable is set to true.
+const TestServerEnvName = "YORC_TEST_STANDALONE_CONSUL"
+
+// testAgentEnvName is set on the test agent processes started by NewTestServer
+const testAgentEnvName = "YORC_STANDALONE_TEST_AGENT"
+
+// testAgentCommand is the name of the command run by the Consul testutil package
+const testAgentCommand = "consul"
+
+var (
+	testAgentOnce sync.Once
+	testAgentDir  string
+	testAgentErr  error
+)
+
+func init() {
+	if os.Getenv(testAgentEnvName) == "true" && filepath.Base(os.Args[0]) == testAgentCommand {
+		os.Exit(runTestAgent(os.Args[1:]))
+	}
+}
+
+// TestServerEnabled returns true if tests should use a standalone agent instead of Consul
+func TestServerEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(TestServerEnvName))
+	return enabled
+}
+
+// NewTestServer starts a standalone agent for tests.
+//
+// The agent runs in a child process started and stopped by the Consul testutil package: the tests binary
+// is added in front of the PATH as the consul command and serves the Consul HTTP API when run this way.
+// Stopping the returned server stops this process.
+func NewTestServer(cb testutil.ServerConfigCallback) (*testutil.TestServer, error) {
+	testAgentOnce.Do(installTestAgent)
+	if testAgentErr != nil {
+		return nil, testAgentErr
+	}
+	return testutil.NewTestServerConfig(cb)
+}
+
+// installTestAgent makes the tests binary available as the consul command
+func installTestAgent() {
+	exe, err := os.Executable()
+	if err != nil {
+		testAgentErr = errors.Wrap(err, "failed to install the standalone test agent")
+		return
+	}
+	testAgentDir, err = ioutil.TempDir("", "yorc-standalone")
+	if err != nil {
+		testAgentErr = errors.Wrap(err, "failed to install the standalone test agent")
+		return
+	}
+	err = os.Symlink(exe, filepath.Join(testAgentDir, testAgentCommand))
+	if err == nil {
+		err = os.Setenv("PATH", testAgentDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	if err == nil {
+		err = os.Setenv(testAgentEnvName, "true")
+	}
+	testAgentErr = errors.Wrap(err, "failed to install the standalone test agent")
+}
+
+// runTestAgent serves the Consul HTTP API on the port defined by the configuration file given
+// by the Consul testutil package, until the process is interrupted
+func runTestAgent(args []string) int {
+	var configFile string
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == "-config-file" {
+			configFile = args[i+1]
+		}
+	}
+	if len(args) == 0 || args[0] != "agent" || configFile == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s agent -config-file <file>\n", testAgentCommand)
+		return 1
+	}
+	b, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read config file: %v\n", err)
+		return 1
+	}
+	cfg := new(testutil.TestServerConfig)
+	err = json.Unmarshal(b, cfg)
+	if err != nil || cfg.Ports == nil {
+		fmt.Fprintf(os.Stderr, "invalid config file %q: %v\n", configFile, err)
+		return 1
+	}
+
+	agent, err := Start(Config{
+		Address:    net.JoinHostPort("127.0.0.1", strconv.Itoa(cfg.Ports.HTTP)),
+		NodeName:   cfg.NodeName,
+		Datacenter: cfg.Datacenter,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+	err = agent.Stop()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"net"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestServer(t *testing.T) {
+	srv, err := NewTestServer(func(c *testutil.TestServerConfig) {
+		c.NodeName = "testNode"
+	})
+	require.NoError(t, err)
+	stopped := false
+	defer func() {
+		if !stopped {
+			srv.Stop()
+		}
+	}()
+
+	consulCfg := api.DefaultConfig()
+	consulCfg.Address = srv.HTTPAddr
+	client, err := api.NewClient(consulCfg)
+	require.NoError(t, err)
+	nodeName, err := client.Agent().NodeName()
+	require.NoError(t, err)
+	assert.Equal(t, "testNode", nodeName)
+	srv.SetKV(t, "test/key", []byte("value"))
+	assert.Equal(t, "value", srv.GetKVString(t, "test/key"))
+
+	stopped = true
+	require.NoError(t, srv.Stop())
+	_, err = net.Dial("tcp", srv.HTTPAddr)
+	assert.Error(t, err, "stopping the server should stop the agent listener")
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standalone
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// writeTxn groups changes of the state that are applied atomically using a single index.
+//
+// It should be used with the state lock held.
+type writeTxn struct {
+	s     *state
+	index uint64
+	now   time.Time
+	// previous values of changed keys in order to rollback, nil means that the key did not exist
+	undo    []*kvEntry
+	undoKey []string
+	changed map[string]*kvEntry
+	topics  []string
+}
+
+func (s *state) newWriteTxn() *writeTxn {
+	return &writeTxn{
+		s:       s,
+		index:   s.index + 1,
+		now:     time.Now(),
+		changed: make(map[string]*kvEntry),
+	}
+}
+
+// commit makes changes visible to readers, journals them and wakes up watching blocking queries
+func (t *writeTxn) commit() error {
+	t.s.index = t.index
+	if len(t.changed) > 0 {
+		t.s.kvIndex = t.index
+	}
+	for _, topic := range t.topics {
+		switch topic {
+		case healthTopic:
+			t.s.healthIndex = t.index
+		case sessionTopic:
+			t.s.sessionIndex = t.index
+		}
+	}
+	err := t.s.persist(t.index, t.changed)
+	t.s.notify(t.topics)
+	return err
+}
+
+// rollback restores the state as it was before the transaction
+func (t *writeTxn) rollback() {
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		if t.undo[i] == nil {
+			t.s.tree.Delete(t.undoKey[i])
+		} else {
+			t.s.tree.Insert(t.undoKey[i], t.undo[i])
+		}
+	}
+	t.undo = nil
+	t.undoKey = nil
+	t.changed = make(map[string]*kvEntry)
+	t.topics = nil
+}
+
+func (t *writeTxn) store(e *kvEntry) {
+	if _, ok := t.changed[e.Key]; !ok {
+		t.undo = append(t.undo, t.s.entry(e.Key))
+		t.undoKey = append(t.undoKey, e.Key)
+		t.topics = append(t.topics, kvTopic(e.Key))
+	}
+	e.ModifyIndex = t.index
+	t.s.tree.Insert(e.Key, e)
+	t.changed[e.Key] = e
+}
+
+// set stores a value, locks are kept as is
+func (t *writeTxn) set(key string, value []byte, flags uint64) *kvEntry {
+	e := &kvEntry{Key: key, Value: value, Flags: flags, CreateIndex: t.index}
+	if prev := t.s.get(key); prev != nil {
+		e.CreateIndex = prev.CreateIndex
+		e.LockIndex = prev.LockIndex
+		e.Session = prev.Session
+	}
+	t.store(e)
+	return e
+}
+
+// cas stores a value only if the key was not modified since the given index.
+//
+// A 0 index means that the key should not exist.
+func (t *writeTxn) cas(key string, value []byte, flags, index uint64) (*kvEntry, bool) {
+	prev := t.s.get(key)
+	if (index == 0 && prev != nil) || (index != 0 && (prev == nil || prev.ModifyIndex != index)) {
+		return prev, false
+	}
+	return t.set(key, value, flags), true
+}
+
+// acquire stores a value and locks the key using the given session.
+//
+// As Consul does, keys can't be acquired during the lock delay of the session that was invalidated while locking them.
+func (t *writeTxn) acquire(key string, value []byte, flags uint64, sessionID string) (*kvEntry, bool, error) {
+	if _, ok := t.s.sessions[sessionID]; !ok {
+		return nil, false, errors.Errorf("invalid session %q", sessionID)
+	}
+	prev := t.s.get(key)
+	if expires, ok := t.s.lockDelays[key]; ok && t.now.Before(expires) {
+		return prev, false, nil
+	}
+	if prev != nil && prev.Session != "" && prev.Session != sessionID {
+		return prev, false, nil
+	}
+	e := &kvEntry{Key: key, Value: value, Flags: flags, CreateIndex: t.index, LockIndex: 1, Session: sessionID}
+	if prev != nil {
+		e.CreateIndex = prev.CreateIndex
+		e.LockIndex = prev.LockIndex
+		if prev.Session != sessionID {
+			e.LockIndex++
+		}
+	}
+	t.store(e)
+	return e, true, nil
+}
+
+// release stores a value and unlocks the key if it is locked by the given session
+func (t *writeTxn) release(key string, value []byte, flags uint64, sessionID string) (*kvEntry, bool) {
+	prev := t.s.get(key)
+	if prev == nil || prev.Session != sessionID {
+		return prev, false
+	}
+	e := prev.clone()
+	e.Value = value
+	e.Flags = flags
+	e.Session = ""
+	t.store(e)
+	return e, true
+}
+
+// unlock unlocks the key without changing its value
+func (t *writeTxn) unlock(e *kvEntry) {
+	n := e.clone()
+	n.Session = ""
+	t.store(n)
+}
+
+// delete removes a key
+func (t *writeTxn) delete(key string) {
+	prev := t.s.get(key)
+	if prev == nil {
+		return
+	}
+	e := prev.clone()
+	e.Value = nil
+	e.Session = ""
+	e.deletedAt = t.now
+	t.store(e)
+}
+
+// deleteCAS removes a key only if it was not modified since the given index
+func (t *writeTxn) deleteCAS(key string, index uint64) bool {
+	prev := t.s.get(key)
+	if prev == nil {
+		return true
+	}
+	if prev.ModifyIndex != index {
+		return false
+	}
+	t.delete(key)
+	return true
+}
+
+// deleteTree removes all keys having the given prefix
+func (t *writeTxn) deleteTree(prefix string) {
+	for _, e := range t.s.list(prefix) {
+		t.delete(e.Key)
+	}
+}
+
+// changedTopic records a change of a topic not related to the key/value store
+func (t *writeTxn) changedTopic(topic string) {
+	t.topics = append(t.topics, topic)
+}
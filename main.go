@@ -22,6 +22,8 @@ import (
 	_ "github.com/ystia/yorc/v4/commands/deployments/workflows"
 	_ "github.com/ystia/yorc/v4/commands/hostspool"
 	_ "github.com/ystia/yorc/v4/commands/locations"
+	_ "github.com/ystia/yorc/v4/commands/registry"
+	_ "github.com/ystia/yorc/v4/commands/storage"
 	"github.com/ystia/yorc/v4/log"
 )
 
@@ -0,0 +1,93 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"net/rpc"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+)
+
+// Metadata holds descriptive information about a plugin
+type Metadata struct {
+	// Version of the plugin
+	Version string
+	// Description is a short human readable description of the plugin
+	Description string
+}
+
+// MetadataProvider is the interface that allows a plugin to report its metadata
+type MetadataProvider interface {
+	// GetMetadata returns the plugin metadata
+	GetMetadata() (Metadata, error)
+}
+
+// MetadataPlugin is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+type MetadataPlugin struct {
+	Metadata Metadata
+}
+
+// MetadataServer is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+type MetadataServer struct {
+	Metadata Metadata
+}
+
+// Server is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+func (p *MetadataPlugin) Server(b *plugin.MuxBroker) (interface{}, error) {
+	return &MetadataServer{Metadata: p.Metadata}, nil
+}
+
+// MetadataClient is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+type MetadataClient struct {
+	Client *rpc.Client
+}
+
+// Client is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+func (p *MetadataPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &MetadataClient{Client: c}, nil
+}
+
+// GetMetadataResponse is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+type GetMetadataResponse struct {
+	Metadata Metadata
+	Error    *RPCError
+}
+
+// GetMetadata is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+func (s *MetadataServer) GetMetadata(_ interface{}, reply *GetMetadataResponse) error {
+	*reply = GetMetadataResponse{
+		Metadata: s.Metadata,
+	}
+	return nil
+}
+
+// GetMetadata is public for use by reflexion and should be considered as private to this package.
+// Please do not use it directly.
+func (c *MetadataClient) GetMetadata() (Metadata, error) {
+	var resp GetMetadataResponse
+	err := c.Client.Call("Plugin.GetMetadata", new(interface{}), &resp)
+	if err != nil {
+		return Metadata{}, errors.Wrap(err, "Failed to get plugin metadata")
+	}
+	return resp.Metadata, toError(resp.Error)
+}
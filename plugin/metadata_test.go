@@ -0,0 +1,47 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataClient_GetMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want Metadata
+	}{
+		{"TestFullMetadata", Metadata{Version: "1.2.0", Description: "My custom plugin"}},
+		{"TestVersionOnly", Metadata{Version: "0.1.0"}},
+		{"TestNoMetadata", Metadata{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, _ := createClientServer(t, &ServeOpts{Metadata: tt.want})
+			defer c.Close()
+			raw, err := c.Dispense(MetadataPluginName)
+			require.NoError(t, err)
+			got, err := raw.(MetadataProvider).GetMetadata()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -39,6 +39,8 @@ const (
 	ActionPluginName = "action"
 	// InfraUsageCollectorPluginName is the name of InfraUsageCollector Plugins it could be used as a lookup key in Client.Dispense
 	InfraUsageCollectorPluginName = "infraUsageCollector"
+	// MetadataPluginName is the name of Metadata Plugins it could be used as a lookup key in Client.Dispense
+	MetadataPluginName = "metadata"
 )
 
 // HandshakeConfig are used to just do a basic handshake between
@@ -74,6 +76,8 @@ type ServeOpts struct {
 	ActionTypes                        []string
 	InfraUsageCollectorFunc            InfraUsageCollectorFunc
 	InfraUsageCollectorSupportedInfras []string
+	// Metadata are descriptive information about the plugin like its version reported to Yorc
+	Metadata Metadata
 }
 
 // Serve serves a plugin. This function never returns and should be the final
@@ -108,6 +112,7 @@ func getPlugins(opts *ServeOpts) map[string]plugin.Plugin {
 		DefinitionsPluginName:         &DefinitionsPlugin{Definitions: opts.Definitions},
 		ConfigManagerPluginName:       &ConfigManagerPlugin{&defaultConfigManager{}},
 		InfraUsageCollectorPluginName: &InfraUsageCollectorPlugin{F: opts.InfraUsageCollectorFunc, SupportedInfras: opts.InfraUsageCollectorSupportedInfras},
+		MetadataPluginName:            &MetadataPlugin{Metadata: opts.Metadata},
 	}
 }
 
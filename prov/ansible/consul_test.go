@@ -43,4 +43,22 @@ func TestRunConsulAnsiblePackageTests(t *testing.T) {
 	t.Run("TestLogAnsibleOutputInConsulFromScriptFailure", func(t *testing.T) {
 		testLogAnsibleOutputInConsulFromScriptFailure(t)
 	})
+	t.Run("TestRunExecutionUnits", func(t *testing.T) {
+		testRunExecutionUnits(t)
+	})
+	t.Run("TestExecuteInstances", func(t *testing.T) {
+		testExecuteInstances(t)
+	})
+	t.Run("TestNativeExecution", func(t *testing.T) {
+		testNativeExecution(t, srv)
+	})
+	t.Run("TestInstallGalaxyRequirements", func(t *testing.T) {
+		testInstallGalaxyRequirements(t)
+	})
+	t.Run("TestInstallGalaxyRequirementsLegacyRolesFormat", func(t *testing.T) {
+		testInstallGalaxyRequirementsLegacyRolesFormat(t)
+	})
+	t.Run("TestInstallGalaxyRequirementsErrors", func(t *testing.T) {
+		testInstallGalaxyRequirementsErrors(t)
+	})
 }
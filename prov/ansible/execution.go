@@ -137,6 +137,8 @@ type execution interface {
 
 type ansibleRunner interface {
 	runAnsible(ctx context.Context, retry bool, currentInstance, ansibleRecipePath string) error
+	// withExecution returns a copy of the runner using the given common execution
+	withExecution(e *executionCommon) ansibleRunner
 }
 
 type executionCommon struct {
@@ -177,6 +179,8 @@ type executionCommon struct {
 	cli                      *client.Client
 	containerID              string
 	vaultToken               string
+	instancesExecution       *tosca.InstancesExecution
+	galaxyPaths              *galaxyPaths
 }
 
 // Handling a command standard output and standard error
@@ -227,6 +231,13 @@ func newExecution(ctx context.Context, cfg config.Configuration, taskID, deploym
 		execScript := &executionScript{executionCommon: execCommon, isPython: isPython}
 		execCommon.ansibleRunner = execScript
 		exec = execScript
+		isNative, explicit, err := isNativeSSHExecution(ctx, cfg, deploymentID, nodeName, operation)
+		if err != nil {
+			return nil, err
+		}
+		if isNative {
+			exec = &executionNative{executionCommon: execCommon, isPython: isPython, explicit: explicit}
+		}
 	} else if isAnsible || isAlienAnsible {
 		execAnsible := &executionAnsible{executionCommon: execCommon, isAlienAnsible: isAlienAnsible}
 		execCommon.ansibleRunner = execAnsible
@@ -753,11 +764,27 @@ func (e *executionCommon) resolveExecution(ctx context.Context) error {
 	if err = e.addRunnablesSpecificInputsAndOutputs(); err != nil {
 		return err
 	}
+	if err = e.resolveInstancesExecution(ctx); err != nil {
+		return err
+	}
 	return e.resolveContext(ctx)
 
 }
 
+// instanceRunFunc runs an operation on the hosts of the given execution, currentInstance being the instance
+// of the other side of the relationship for per-instance operations
+type instanceRunFunc func(e *executionCommon, ctx context.Context, retry bool, currentInstance string) error
+
 func (e *executionCommon) execute(ctx context.Context, retry bool) error {
+	return e.executeWith(ctx, retry, (*executionCommon).executeWithCurrentInstance)
+}
+
+// executeWith runs the operation using the given function, once per instance of the other side of
+// the relationship for per-instance operations
+func (e *executionCommon) executeWith(ctx context.Context, retry bool, run instanceRunFunc) error {
+	if e.instancesExecution != nil {
+		return e.executeInstances(ctx, retry, run)
+	}
 	if e.isPerInstanceOperation {
 		var nodeName string
 		var instances []string
@@ -773,13 +800,13 @@ func (e *executionCommon) execute(ctx context.Context, retry bool) error {
 			instanceName := operations.GetInstanceName(nodeName, instanceID)
 			log.Debugf("Executing operation %q, on node %q, with current instance %q", e.operation.Name, e.NodeName, instanceName)
 			ctx = events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: instanceID})
-			err := e.executeWithCurrentInstance(ctx, retry, instanceName)
+			err := run(e, ctx, retry, instanceName)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
-		return e.executeWithCurrentInstance(ctx, retry, "")
+		return run(e, ctx, retry, "")
 	}
 	return nil
 }
@@ -948,79 +975,20 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 		if err != nil {
 			return err
 		}
+		varInputs, err := e.resolveHostVarInputs(ctx, instanceName, currentInstance)
+		if err != nil {
+			return err
+		}
 		var perInstanceInputsBuffer bytes.Buffer
-		for _, varInput := range e.VarInputsNames {
-			if varInput == "INSTANCE" {
-				perInstanceInputsBuffer.WriteString(fmt.Sprintf("INSTANCE: %q\n", instanceName))
-			} else if varInput == "SOURCE_INSTANCE" {
-				if !e.isPerInstanceOperation {
-					perInstanceInputsBuffer.WriteString(fmt.Sprintf("SOURCE_INSTANCE: %q\n", instanceName))
-				} else {
-					if e.isRelationshipTargetNode {
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("SOURCE_INSTANCE: %q\n", currentInstance))
-					} else {
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("SOURCE_INSTANCE: %q\n", instanceName))
-					}
-				}
-			} else if varInput == "TARGET_INSTANCE" {
-				if !e.isPerInstanceOperation {
-					perInstanceInputsBuffer.WriteString(fmt.Sprintf("TARGET_INSTANCE: %q\n", instanceName))
-				} else {
-					if e.isRelationshipTargetNode {
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("TARGET_INSTANCE: %q\n", instanceName))
-					} else {
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("TARGET_INSTANCE: %q\n", currentInstance))
-					}
-				}
-			} else {
-				for _, envInput := range e.EnvInputs {
-					if envInput.Name == varInput && (envInput.InstanceName == instanceName || e.isPerInstanceOperation && envInput.InstanceName == currentInstance) {
-						v, err := e.encodeEnvInputValue(envInput, ansibleRecipePath)
-						if err != nil {
-							return err
-						}
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("%s: %s\n", varInput, v))
-						goto NEXT
-					}
-				}
-				if e.operation.RelOp.IsRelationshipOperation {
-					var hostedOn bool
-					hostedOn, err = deployments.IsTypeDerivedFrom(ctx, e.deploymentID, e.relationshipType, "tosca.relationships.HostedOn")
-					if err != nil {
-						return err
-					} else if hostedOn {
-						// In case of operation for relationships derived from HostedOn we should match the inputs with the same instanceID
-						instanceIDIdx := strings.LastIndex(instanceName, "_")
-						// Get index
-						if instanceIDIdx > 0 {
-							instanceID := instanceName[instanceIDIdx:]
-							for _, envInput := range e.EnvInputs {
-								if envInput.Name == varInput && strings.HasSuffix(envInput.InstanceName, instanceID) {
-									v, err := e.encodeEnvInputValue(envInput, ansibleRecipePath)
-									if err != nil {
-										return err
-									}
-									perInstanceInputsBuffer.WriteString(fmt.Sprintf("%s: %s\n", varInput, v))
-									goto NEXT
-								}
-							}
-						}
-					}
-				}
-				// Not found with the combination inputName/instanceName let's use the first that matches the input name
-				for _, envInput := range e.EnvInputs {
-					if envInput.Name == varInput {
-						v, err := e.encodeEnvInputValue(envInput, ansibleRecipePath)
-						if err != nil {
-							return err
-						}
-						perInstanceInputsBuffer.WriteString(fmt.Sprintf("%s: %s\n", varInput, v))
-						goto NEXT
-					}
+		for _, varInput := range varInputs {
+			v := fmt.Sprintf("%q", varInput.value)
+			if varInput.envInput != nil {
+				v, err = e.encodeEnvInputValue(varInput.envInput, ansibleRecipePath)
+				if err != nil {
+					return err
 				}
-				return errors.Errorf("Unable to find a suitable input for input name %q and instance %q", varInput, instanceName)
 			}
-		NEXT:
+			perInstanceInputsBuffer.WriteString(fmt.Sprintf("%s: %s\n", varInput.name, v))
 		}
 		if perInstanceInputsBuffer.Len() > 0 {
 			if err = ioutil.WriteFile(filepath.Join(ansibleHostVarsPath, host.host+".yml"), perInstanceInputsBuffer.Bytes(), 0664); err != nil {
@@ -1059,6 +1027,13 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 		return err
 	}
 
+	// Installing collections and roles provided by the deployment
+	e.galaxyPaths, err = installGalaxyRequirements(ctx, e.deploymentID, e.OverlayPath, ansiblePath)
+	if err != nil {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, e.deploymentID).RegisterAsString(err.Error())
+		return err
+	}
+
 	// Generating Ansible config
 	if err = e.generateAnsibleConfigurationFile(ansiblePath, ansibleRecipePath); err != nil {
 		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, e.deploymentID).RegisterAsString(err.Error())
@@ -1115,63 +1090,142 @@ func (e *executionCommon) executeWithCurrentInstance(ctx context.Context, retry
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, e.deploymentID).RegisterAsString(err.Error())
 				return err
 			}
-			for _, line := range records {
-				splits := strings.Split(line[0], "_")
-				instanceID := splits[len(splits)-1]
-				if instanceID != fileInstanceID {
-					continue
+			if err = e.storeOutputs(ctx, fileInstanceID, records); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+
+}
+
+// storeOutputs stores the outputs of the operation retrieved from the records of an outputs file
+// generated on the host of the given instance
+func (e *executionCommon) storeOutputs(ctx context.Context, fileInstanceID string, records [][]string) error {
+	for _, line := range records {
+		splits := strings.Split(line[0], "_")
+		instanceID := splits[len(splits)-1]
+		if instanceID != fileInstanceID {
+			continue
+		}
+		if e.Outputs[line[0]] != taskContextOutput {
+			if strings.HasPrefix(e.Outputs[line[0]], "attribute_mapping") {
+				// attribute_mapping/<node_name>/<instance_name>/<attribute_name_or_capability_name>/<nested_attribute_name><nested_attribute_name><nested_attribute_name>...
+				data := strings.Split(e.Outputs[line[0]], "/")
+				nodeName := data[1]
+				instanceName := data[2]
+				attributeOrCapabilityName := data[3]
+				var parameters []string
+				if len(data) > 3 {
+					parameters = data[4:]
 				}
-				if e.Outputs[line[0]] != taskContextOutput {
-					if strings.HasPrefix(e.Outputs[line[0]], "attribute_mapping") {
-						// attribute_mapping/<node_name>/<instance_name>/<attribute_name_or_capability_name>/<nested_attribute_name><nested_attribute_name><nested_attribute_name>...
-						data := strings.Split(e.Outputs[line[0]], "/")
-						nodeName := data[1]
-						instanceName := data[2]
-						attributeOrCapabilityName := data[3]
-						var parameters []string
-						if len(data) > 3 {
-							parameters = data[4:]
-						}
-						if err = deployments.ResolveAttributeMapping(ctx, e.deploymentID, nodeName, instanceName, attributeOrCapabilityName, line[1], parameters...); err != nil {
-							return err
+				if err := deployments.ResolveAttributeMapping(ctx, e.deploymentID, nodeName, instanceName, attributeOrCapabilityName, line[1], parameters...); err != nil {
+					return err
+				}
+			} else {
+				// TODO this should be part of the deployments package
+				if err := consulutil.StoreConsulKeyAsString(path.Join(consulutil.DeploymentKVPrefix, e.deploymentID, "topology", e.Outputs[line[0]]), line[1]); err != nil {
+					return err
+				}
+				// Notify attributes on value change
+				ind := strings.LastIndex(e.Outputs[line[0]], "/outputs/")
+				if ind != -1 {
+					outputPath := e.Outputs[line[0]][ind+len("/outputs/"):]
+					data := strings.Split(outputPath, "/")
+					if len(data) > 2 {
+						notifier := &deployments.OperationOutputNotifier{
+							InstanceName:  instanceID,
+							NodeName:      e.NodeName,
+							InterfaceName: data[0],
+							OperationName: data[1],
+							OutputName:    data[2],
 						}
-					} else {
-						// TODO this should be part of the deployments package
-						if err = consulutil.StoreConsulKeyAsString(path.Join(consulutil.DeploymentKVPrefix, e.deploymentID, "topology", e.Outputs[line[0]]), line[1]); err != nil {
+						if err := notifier.NotifyValueChange(ctx, e.deploymentID); err != nil {
 							return err
 						}
-						// Notify attributes on value change
-						ind := strings.LastIndex(e.Outputs[line[0]], "/outputs/")
-						if ind != -1 {
-							outputPath := e.Outputs[line[0]][ind+len("/outputs/"):]
-							data := strings.Split(outputPath, "/")
-							if len(data) > 2 {
-								notifier := &deployments.OperationOutputNotifier{
-									InstanceName:  instanceID,
-									NodeName:      e.NodeName,
-									InterfaceName: data[0],
-									OperationName: data[1],
-									OutputName:    data[2],
-								}
-								err = notifier.NotifyValueChange(ctx, e.deploymentID)
-								if err != nil {
-									return err
-								}
-							}
-						}
-					}
-				} else {
-					err := tasks.SetTaskData(e.taskID, e.NodeName+"-"+instanceID+"-"+strings.Join(splits[0:len(splits)-1], "_"), line[1])
-					if err != nil {
-						return err
 					}
-
 				}
 			}
+		} else {
+			err := tasks.SetTaskData(e.taskID, e.NodeName+"-"+instanceID+"-"+strings.Join(splits[0:len(splits)-1], "_"), line[1])
+			if err != nil {
+				return err
+			}
 		}
 	}
 	return nil
+}
+
+// hostVarInput is the value of an input depending on the host on which the operation is run,
+// either an instance name or an environment input
+type hostVarInput struct {
+	name     string
+	value    string
+	envInput *operations.EnvInput
+}
+
+// resolveHostVarInputs returns the values of inputs depending on the host of the given instance,
+// currentInstance being the instance of the other side of the relationship for per-instance operations
+func (e *executionCommon) resolveHostVarInputs(ctx context.Context, instanceName, currentInstance string) ([]hostVarInput, error) {
+	varInputs := make([]hostVarInput, 0, len(e.VarInputsNames))
+	for _, varInput := range e.VarInputsNames {
+		if varInput == "INSTANCE" {
+			varInputs = append(varInputs, hostVarInput{name: varInput, value: instanceName})
+		} else if varInput == "SOURCE_INSTANCE" {
+			if e.isPerInstanceOperation && e.isRelationshipTargetNode {
+				varInputs = append(varInputs, hostVarInput{name: varInput, value: currentInstance})
+			} else {
+				varInputs = append(varInputs, hostVarInput{name: varInput, value: instanceName})
+			}
+		} else if varInput == "TARGET_INSTANCE" {
+			if e.isPerInstanceOperation && !e.isRelationshipTargetNode {
+				varInputs = append(varInputs, hostVarInput{name: varInput, value: currentInstance})
+			} else {
+				varInputs = append(varInputs, hostVarInput{name: varInput, value: instanceName})
+			}
+		} else {
+			envInput, err := e.findHostEnvInput(ctx, varInput, instanceName, currentInstance)
+			if err != nil {
+				return nil, err
+			}
+			varInputs = append(varInputs, hostVarInput{name: varInput, envInput: envInput})
+		}
+	}
+	return varInputs, nil
+}
 
+// findHostEnvInput returns the environment input of the given name to use on the host of the given instance
+func (e *executionCommon) findHostEnvInput(ctx context.Context, varInput, instanceName, currentInstance string) (*operations.EnvInput, error) {
+	for _, envInput := range e.EnvInputs {
+		if envInput.Name == varInput && (envInput.InstanceName == instanceName || e.isPerInstanceOperation && envInput.InstanceName == currentInstance) {
+			return envInput, nil
+		}
+	}
+	if e.operation.RelOp.IsRelationshipOperation {
+		hostedOn, err := deployments.IsTypeDerivedFrom(ctx, e.deploymentID, e.relationshipType, "tosca.relationships.HostedOn")
+		if err != nil {
+			return nil, err
+		} else if hostedOn {
+			// In case of operation for relationships derived from HostedOn we should match the inputs with the same instanceID
+			instanceIDIdx := strings.LastIndex(instanceName, "_")
+			// Get index
+			if instanceIDIdx > 0 {
+				instanceID := instanceName[instanceIDIdx:]
+				for _, envInput := range e.EnvInputs {
+					if envInput.Name == varInput && strings.HasSuffix(envInput.InstanceName, instanceID) {
+						return envInput, nil
+					}
+				}
+			}
+		}
+	}
+	// Not found with the combination inputName/instanceName let's use the first that matches the input name
+	for _, envInput := range e.EnvInputs {
+		if envInput.Name == varInput {
+			return envInput, nil
+		}
+	}
+	return nil, errors.Errorf("Unable to find a suitable input for input name %q and instance %q", varInput, instanceName)
 }
 
 func (e *executionCommon) checkAnsibleRetriableError(ctx context.Context, err error) error {
@@ -1402,11 +1456,18 @@ func (e *executionCommon) generateAnsibleConfigurationFile(
 	// directory path
 	ansibleConfig[ansibleConfigDefaultsHeader]["retry_files_save_path"] = ansibleRecipePath
 	if e.CacheFacts {
-		ansibleFactCaching["fact_caching_connection"] = path.Join(ansiblePath, "facts_cache")
-
 		for k, v := range ansibleFactCaching {
 			ansibleConfig[ansibleConfigDefaultsHeader][k] = v
 		}
+		ansibleConfig[ansibleConfigDefaultsHeader]["fact_caching_connection"] = path.Join(ansiblePath, "facts_cache")
+	}
+	if e.galaxyPaths != nil {
+		if e.galaxyPaths.collections != "" {
+			ansibleConfig[ansibleConfigDefaultsHeader]["collections_paths"] = galaxyConfigPath(e.galaxyPaths.collections, defaultCollectionsPaths)
+		}
+		if e.galaxyPaths.roles != "" {
+			ansibleConfig[ansibleConfigDefaultsHeader]["roles_path"] = galaxyConfigPath(e.galaxyPaths.roles, defaultRolesPath)
+		}
 	}
 
 	// Ansible configuration user-defined values provided in Yorc Server configuration
@@ -65,6 +65,10 @@ type executionAnsible struct {
 	isAlienAnsible bool
 }
 
+func (e *executionAnsible) withExecution(execCommon *executionCommon) ansibleRunner {
+	return &executionAnsible{executionCommon: execCommon, isAlienAnsible: e.isAlienAnsible}
+}
+
 func (e *executionAnsible) runAnsible(ctx context.Context, retry bool, currentInstance, ansibleRecipePath string) error {
 	var err error
 	if !e.isAlienAnsible {
@@ -0,0 +1,360 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/helper/stringutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+// LocationNativeSSHOperationsProperty is the location property allowing to run Bash and Python operations
+// of nodes of this location directly over SSH instead of using Ansible
+const LocationNativeSSHOperationsProperty = "native_ssh_operations"
+
+// nativeSSHParallelism is the number of hosts on which an operation is run concurrently,
+// it is the same as the default number of Ansible forks
+const nativeSSHParallelism = 5
+
+// executionNative runs Bash and Python operations directly over SSH.
+//
+// Scripts are run using the same wrappers than with Ansible so their inputs and outputs are handled the same way.
+type executionNative struct {
+	*executionCommon
+	isPython bool
+	// explicit is true if running the operation over SSH is required by its implementation artifact type,
+	// otherwise the operation falls back on Ansible when it can't be run natively
+	explicit bool
+}
+
+// isNativeSSHExecution returns true if the given operation should be run natively over SSH, either because of
+// the type of its implementation artifact or because of the location of the node, and if this is explicitly
+// required by the artifact type.
+func isNativeSSHExecution(ctx context.Context, cfg config.Configuration, deploymentID, nodeName string, operation prov.Operation) (bool, bool, error) {
+	for _, artifactType := range []string{implementationArtifactSSHBash, implementationArtifactSSHPython} {
+		explicit, err := deployments.IsTypeDerivedFrom(ctx, deploymentID, operation.ImplementationArtifact, artifactType)
+		if err != nil || explicit {
+			return explicit, explicit, err
+		}
+	}
+	props, err := getNodeLocationProperties(ctx, cfg, deploymentID, nodeName)
+	if err != nil {
+		return false, false, err
+	}
+	return props.GetBool(LocationNativeSSHOperationsProperty), false, nil
+}
+
+// getUnsupportedReason returns why the operation can't be run natively, or an empty string if it can
+func (e *executionNative) getUnsupportedReason() string {
+	if e.isOrchestratorOperation {
+		return "operations hosted on the orchestrator are not supported"
+	}
+	for _, host := range e.hosts {
+		if host.bastion != nil {
+			return "connections through a bastion host are not supported"
+		}
+	}
+	return ""
+}
+
+func (e *executionNative) execute(ctx context.Context, retry bool) error {
+	if reason := e.getUnsupportedReason(); reason != "" {
+		if e.explicit {
+			return errors.Errorf("operation %q on node %q can't be run over SSH: %s", e.operation.Name, e.NodeName, reason)
+		}
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelDEBUG, e.deploymentID).Registerf("Running operation %q on node %q using Ansible as %s", e.operation.Name, e.NodeName, reason)
+		return e.executionCommon.execute(ctx, retry)
+	}
+	return e.executeWith(ctx, retry, func(c *executionCommon, ctx context.Context, retry bool, currentInstance string) error {
+		return (&executionNative{executionCommon: c, isPython: e.isPython, explicit: e.explicit}).executeOnHosts(ctx, currentInstance)
+	})
+}
+
+// executeOnHosts runs the operation on each host of the execution, up to nativeSSHParallelism hosts concurrently
+func (e *executionNative) executeOnHosts(ctx context.Context, currentInstance string) error {
+	hostNames := make([]string, 0, len(e.hosts))
+	for hostName := range e.hosts {
+		hostNames = append(hostNames, hostName)
+	}
+	sort.Strings(hostNames)
+	units := make([]executionUnit, 0, len(hostNames))
+	for _, hostName := range hostNames {
+		exec := &executionNative{executionCommon: e.copyWithHosts(hostName), isPython: e.isPython, explicit: e.explicit}
+		hostName := hostName
+		units = append(units, executionUnit{instanceID: e.hosts[hostName].instanceID, run: func(ctx context.Context, retry bool) error {
+			return exec.executeOnHost(ctx, hostName, currentInstance)
+		}})
+	}
+	settings := tosca.InstancesExecution{Parallelism: nativeSSHParallelism, MaxFailures: len(units)}
+	_, _, err := runExecutionUnits(ctx, e.deploymentID, settings, units, false, 0)
+	return err
+}
+
+// getSSHClient returns a client connecting to the given host
+func (e *executionNative) getSSHClient(ctx context.Context, host *hostConnection) (*sshutil.SSHClient, error) {
+	creds, err := e.getSSHCredentials(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sshConfig := &ssh.ClientConfig{
+		User:            creds.user,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         e.cfg.SSHConnectionTimeout,
+	}
+	for keyName, pk := range creds.privateKeys {
+		keyAuth, err := sshutil.ReadSSHPrivateKey(pk)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read key %q", keyName)
+		}
+		sshConfig.Auth = append(sshConfig.Auth, keyAuth)
+	}
+	if creds.password != "" {
+		sshConfig.Auth = append(sshConfig.Auth, ssh.Password(creds.password))
+	}
+	port := host.port
+	if port == 0 {
+		port = 22
+	}
+	return &sshutil.SSHClient{
+		Config:       sshConfig,
+		Host:         host.host,
+		Port:         port,
+		MaxRetries:   e.cfg.SSHConnectionMaxRetries,
+		RetryBackoff: e.cfg.SSHConnectionRetryBackoff,
+	}, nil
+}
+
+// executeOnHost copies the script, its wrapper and artifacts on the host of the given instance,
+// runs the wrapper with inputs set as environment variables and retrieves the operation outputs
+func (e *executionNative) executeOnHost(ctx context.Context, instanceName, currentInstance string) error {
+	host := e.hosts[instanceName]
+	client, err := e.getSSHClient(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	// Remote paths are relative to the home directory of the user
+	e.OperationRemoteBaseDir = stringutil.UniqueTimestampedName(e.cfg.Ansible.OperationRemoteBaseDir+"_", "")
+	if e.operation.RelOp.IsRelationshipOperation {
+		e.OperationRemotePath = path.Join(e.OperationRemoteBaseDir, e.NodeName, e.relationshipType, e.operation.Name)
+	} else {
+		e.OperationRemotePath = path.Join(e.OperationRemoteBaseDir, e.NodeName, e.operation.Name)
+	}
+	if !e.KeepOperationRemotePath {
+		defer func() {
+			_, err := client.RunCommand(fmt.Sprintf(`rm -rf "$HOME"/%s`, shellQuote(e.OperationRemoteBaseDir)))
+			if err != nil {
+				log.Debugf("Failed to remove directory %q on host %q: %+v", e.OperationRemoteBaseDir, host.host, err)
+			}
+		}()
+	}
+
+	if err = e.copyScriptAndArtifacts(ctx, client); err != nil {
+		return errors.Wrapf(err, "failed to copy operation files on host %q", host.host)
+	}
+	env, err := e.getEnvFileContent(ctx, instanceName, currentInstance)
+	if err != nil {
+		return err
+	}
+	if err = client.CopyFile(strings.NewReader(env), path.Join(e.OperationRemotePath, "env"), "0600"); err != nil {
+		return errors.Wrapf(err, "failed to copy operation environment on host %q", host.host)
+	}
+
+	if err = e.runWrapper(ctx, client); err != nil {
+		return errors.Wrapf(err, "operation %q failed on host %q", e.operation.Name, host.host)
+	}
+
+	if !e.HaveOutput {
+		return nil
+	}
+	out, err := client.RunCommand(fmt.Sprintf(`cat "$HOME"/%s`, shellQuote(path.Join(e.OperationRemotePath, "out.csv"))))
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve outputs of operation %q on host %q", e.operation.Name, host.host)
+	}
+	r := csv.NewReader(strings.NewReader(out))
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse outputs of operation %q on host %q", e.operation.Name, host.host)
+	}
+	return e.storeOutputs(ctx, host.instanceID, records)
+}
+
+// copyScriptAndArtifacts copies the script, its wrapper and the artifacts archives on the host and extracts them
+func (e *executionNative) copyScriptAndArtifacts(ctx context.Context, client *sshutil.SSHClient) error {
+	script, err := os.Open(filepath.Join(e.OverlayPath, e.Primary))
+	if err != nil {
+		return err
+	}
+	defer script.Close()
+	if err = client.CopyFile(script, path.Join(e.OperationRemotePath, e.BasePrimary), "0744"); err != nil {
+		return err
+	}
+
+	wrapper, err := e.getWrapper()
+	if err != nil {
+		return err
+	}
+	if err = client.CopyFile(bytes.NewReader(wrapper), path.Join(e.OperationRemotePath, "wrapper"), "0744"); err != nil {
+		return err
+	}
+
+	if len(e.Artifacts) == 0 {
+		return nil
+	}
+	tmpDir, err := ioutil.TempDir("", "yorc-native-artifacts")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	for artifactName, artifactPath := range e.Artifacts {
+		tarPath := filepath.Join(tmpDir, artifactName+".tar")
+		if err = buildArchive(e.OverlayPath, artifactPath, tarPath); err != nil {
+			return err
+		}
+		tarFile, err := os.Open(tarPath)
+		if err != nil {
+			return err
+		}
+		remoteTarPath := path.Join(e.OperationRemotePath, artifactName+".tar")
+		err = client.CopyFile(tarFile, remoteTarPath, "0600")
+		tarFile.Close()
+		if err != nil {
+			return err
+		}
+		_, err = client.RunCommand(fmt.Sprintf(`cd "$HOME"/%s && tar -xf %s && rm -f %s`, shellQuote(e.OperationRemotePath), shellQuote(artifactName+".tar"), shellQuote(artifactName+".tar")))
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract artifact %q", artifactName)
+		}
+	}
+	return nil
+}
+
+// getWrapper returns the wrapper running the script, which is the same than the one used with Ansible
+func (e *executionNative) getWrapper() ([]byte, error) {
+	tmpl := template.New("execTemplate").Delims("[[[", "]]]")
+	tmpl = tmpl.Funcs(getExecutionScriptTemplateFnMap(e.executionCommon, "", func() string { return "" }))
+	wrapper := scriptCustomWrapper
+	if e.isPython {
+		wrapper = pythonCustomWrapper
+	}
+	tmpl, err := tmpl.Parse(wrapper)
+	if err != nil {
+		return nil, err
+	}
+	var buffer bytes.Buffer
+	if err = tmpl.Execute(&buffer, e.executionCommon); err != nil {
+		return nil, errors.Wrap(err, "Failed to Generate wrapper template")
+	}
+	return buffer.Bytes(), nil
+}
+
+// getEnvFileContent returns a shell script exporting the inputs, artifacts and context of the operation on the host
+// of the given instance, using the same environment variables names than with Ansible
+func (e *executionNative) getEnvFileContent(ctx context.Context, instanceName, currentInstance string) (string, error) {
+	var b strings.Builder
+	for _, envInput := range e.EnvInputs {
+		name := envInput.Name
+		if envInput.InstanceName != "" {
+			name = envInput.InstanceName + "_" + envInput.Name
+		}
+		fmt.Fprintf(&b, "export %s=%s\n", name, shellQuote(envInput.Value))
+	}
+	for artifactName, artifactPath := range e.Artifacts {
+		fmt.Fprintf(&b, "export %s=\"$HOME\"/%s\n", artifactName, shellQuote(path.Join(e.OperationRemotePath, artifactPath)))
+	}
+	for k, v := range e.Context {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(v))
+	}
+	for k, v := range e.CapabilitiesCtx {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(v.RawString()))
+	}
+	varInputs, err := e.resolveHostVarInputs(ctx, instanceName, currentInstance)
+	if err != nil {
+		return "", err
+	}
+	for _, varInput := range varInputs {
+		value := varInput.value
+		if varInput.envInput != nil {
+			value = varInput.envInput.Value
+		}
+		// The wrappers remove a leading space added to these values when they are set by Ansible
+		fmt.Fprintf(&b, "export %s=%s\n", varInput.name, shellQuote(" "+value))
+	}
+	return b.String(), nil
+}
+
+// getWrapperCommand returns the command loading the environment and running the wrapper
+func (e *executionNative) getWrapperCommand() string {
+	remotePath := `"$HOME"/` + shellQuote(e.OperationRemotePath)
+	interpreter := "/bin/bash"
+	if e.isPython {
+		interpreter = "$(command -v python3 || command -v python)"
+	}
+	cmd := fmt.Sprintf(". %[1]s/env && rm -f %[1]s/env && %[2]s %[1]s/wrapper", remotePath, interpreter)
+	return "/bin/bash -l -c " + shellQuote(cmd)
+}
+
+// runWrapper runs the wrapper on the host, logging its standard output as info events and its
+// standard error as warning events
+func (e *executionNative) runWrapper(ctx context.Context, client *sshutil.SSHClient) error {
+	session, err := client.GetSessionWrapper()
+	if err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go e.logOutput(ctx, &wg, session.Stdout, events.LogLevelINFO)
+	go e.logOutput(ctx, &wg, session.Stderr, events.LogLevelWARN)
+	err = session.RunCommand(ctx, e.getWrapperCommand())
+	wg.Wait()
+	return err
+}
+
+func (e *executionNative) logOutput(ctx context.Context, wg *sync.WaitGroup, output io.Reader, level events.LogLevel) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		events.WithContextOptionalFields(ctx).NewLogEntry(level, e.deploymentID).RegisterAsString(scanner.Text())
+	}
+}
+
+// shellQuote quotes a string so that it is interpreted literally by a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
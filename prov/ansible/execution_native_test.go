@@ -0,0 +1,119 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/operations"
+	yorc_testutil "github.com/ystia/yorc/v4/testutil"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"Empty", "", "''"},
+		{"Simple", "value", "'value'"},
+		{"Spaces", "some value", "'some value'"},
+		{"Variables", "$HOME `id`", "'$HOME `id`'"},
+		{"Quotes", `it's "quoted"`, `'it'\''s "quoted"'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, shellQuote(tt.s))
+		})
+	}
+}
+
+func testNativeExecution(t *testing.T, srv1 *testutil.TestServer) {
+	deploymentID := yorc_testutil.BuildDeploymentID(t)
+	err := deployments.StoreDeploymentDefinition(context.Background(), deploymentID, "testdata/nativeTemplate.yml")
+	require.NoError(t, err, "Can't store deployment definition")
+
+	srv1.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/ComputeA/0/attributes/ip_address"):                       []byte("10.10.10.1"),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/ComputeA/0/capabilities/endpoint/attributes/ip_address"): []byte("10.10.10.1"),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/NodeA/0/attributes/state"):                               []byte("initial"),
+		path.Join(consulutil.TasksPrefix, "taskIDNotUsedForNow", "type"):                                                                    []byte("0"),
+	})
+
+	newTestExecution := func(t *testing.T, operation string) execution {
+		op, err := operations.GetOperation(context.Background(), deploymentID, "NodeA", operation, "", "", nil)
+		require.NoError(t, err)
+		exec, err := newExecution(context.Background(), GetConfig(), "taskIDNotUsedForNow", deploymentID, "NodeA", op, nil)
+		require.NoError(t, err)
+		return exec
+	}
+
+	t.Run("BashOverSSH", func(t *testing.T) {
+		exec, ok := newTestExecution(t, "standard.create").(*executionNative)
+		require.True(t, ok, "expecting a native execution")
+		assert.True(t, exec.explicit)
+		assert.False(t, exec.isPython)
+		assert.Equal(t, "", exec.getUnsupportedReason())
+
+		exec.OperationRemotePath = "tmp/.yorc/NodeA/standard.create"
+		env, err := exec.getEnvFileContent(context.Background(), "NodeA_0", "")
+		require.NoError(t, err)
+		assert.Contains(t, env, "export NodeA_0_A1='/var/www'\n")
+		assert.Contains(t, env, "export NodeA_0_A2='10.10.10.1'\n")
+		assert.Contains(t, env, "export A1=' /var/www'\n")
+		assert.Contains(t, env, "export INSTANCE=' NodeA_0'\n")
+		assert.Contains(t, env, "export NODE='NodeA'\n")
+		assert.Equal(t, `/bin/bash -l -c '. "$HOME"/'\''tmp/.yorc/NodeA/standard.create'\''/env && rm -f "$HOME"/'\''tmp/.yorc/NodeA/standard.create'\''/env && /bin/bash "$HOME"/'\''tmp/.yorc/NodeA/standard.create'\''/wrapper'`,
+			exec.getWrapperCommand())
+
+		wrapper, err := exec.getWrapper()
+		require.NoError(t, err)
+		assert.Contains(t, string(wrapper), ". $HOME/tmp/.yorc/NodeA/standard.create/create.sh")
+	})
+
+	t.Run("PythonOverSSH", func(t *testing.T) {
+		exec, ok := newTestExecution(t, "standard.configure").(*executionNative)
+		require.True(t, ok, "expecting a native execution")
+		assert.True(t, exec.explicit)
+		assert.True(t, exec.isPython)
+
+		env, err := exec.getEnvFileContent(context.Background(), "NodeA_0", "")
+		require.NoError(t, err)
+		assert.Contains(t, env, `export A1=' it'\''s quoted'`+"\n")
+		assert.Contains(t, exec.getWrapperCommand(), "$(command -v python3 || command -v python)")
+	})
+
+	t.Run("BashWithAnsible", func(t *testing.T) {
+		_, ok := newTestExecution(t, "standard.start").(*executionScript)
+		assert.True(t, ok, "expecting a script execution")
+	})
+
+	t.Run("UnsupportedOnOrchestrator", func(t *testing.T) {
+		exec := newTestExecution(t, "standard.create").(*executionNative)
+		exec.isOrchestratorOperation = true
+		assert.NotEqual(t, "", exec.getUnsupportedReason())
+		err := exec.execute(context.Background(), false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "can't be run over SSH")
+	})
+}
@@ -207,6 +207,10 @@ func getExecutionScriptTemplateFnMap(e *executionCommon, ansibleRecipePath strin
 	}
 }
 
+func (e *executionScript) withExecution(execCommon *executionCommon) ansibleRunner {
+	return &executionScript{executionCommon: execCommon, isPython: e.isPython}
+}
+
 func (e *executionScript) runAnsible(ctx context.Context, retry bool, currentInstance, ansibleRecipePath string) error {
 	var err error
 	e.ScriptToRun, err = filepath.Abs(filepath.Join(e.OverlayPath, e.Primary))
@@ -742,9 +742,10 @@ func testExecutionGenerateAnsibleConfig(t *testing.T) {
 	require.NoError(t, err, "Error generating ansible config file")
 	resultMap, content = readAnsibleConfigSettings(t, cfgPath)
 	assert.Equal(t,
-		initialConfigMapLength+len(ansibleFactCaching)+1,
+		initialConfigMapLength+len(ansibleFactCaching)+2,
 		len(resultMap[ansibleConfigDefaultsHeader]),
 		"Missing entries in ansible config file with fact caching, content: %q", content)
+	assert.Equal(t, path.Join("ansiblePath", "facts_cache"), resultMap[ansibleConfigDefaultsHeader]["fact_caching_connection"])
 
 	// Test with ansible config settings in Yorc server configuration
 	// one of them overriding Yorc default ansible config setting
@@ -784,6 +785,10 @@ func (a *ansibleRunnerTest) runAnsible(ctx context.Context, retry bool, currentI
 	return nil
 }
 
+func (a *ansibleRunnerTest) withExecution(e *executionCommon) ansibleRunner {
+	return a
+}
+
 func testExecutionCleanup(t *testing.T) {
 
 	// Create a temporary directory where to create the ansible config file
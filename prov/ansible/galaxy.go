@@ -0,0 +1,278 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/executil"
+	"github.com/ystia/yorc/v4/log"
+)
+
+// galaxyRequirementsFile is the name of the Ansible Galaxy requirements file expected at the root of a CSAR
+const galaxyRequirementsFile = "requirements.yml"
+
+// galaxyCacheDir is the name of the per-deployment directory where collections and roles are installed.
+// Each version of the requirements is installed into a sub-directory named after its checksum.
+const galaxyCacheDir = "galaxy"
+
+// Default Ansible search paths kept after the deployment ones so that collections and roles
+// installed on the orchestrator host remain available
+const defaultCollectionsPaths = "~/.ansible/collections:/usr/share/ansible/collections"
+const defaultRolesPath = "~/.ansible/roles:/usr/share/ansible/roles:/etc/ansible/roles"
+
+// galaxyInstallLocks prevents concurrent operations of a deployment from installing its requirements at the same time
+var galaxyInstallLocks sync.Map
+
+// runGalaxyCommand runs an ansible-galaxy command and returns its combined output.
+// It is a variable to allow to mock it in tests.
+var runGalaxyCommand = func(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := executil.Command(ctx, "ansible-galaxy", args...)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+type galaxyRequirements struct {
+	Collections []interface{} `yaml:"collections,omitempty"`
+	Roles       []interface{} `yaml:"roles,omitempty"`
+}
+
+// galaxyPaths are the paths where the collections and roles of a deployment are installed.
+// They are empty if the CSAR does not provide the corresponding requirements.
+type galaxyPaths struct {
+	collections string
+	roles       string
+}
+
+// parseGalaxyRequirements parses a requirements file which is either a list of roles (legacy format)
+// or a map of collections and roles
+func parseGalaxyRequirements(content []byte) (*galaxyRequirements, error) {
+	reqs := new(galaxyRequirements)
+	var roles []interface{}
+	if err := yaml.Unmarshal(content, &roles); err == nil {
+		reqs.Roles = roles
+		return reqs, nil
+	}
+	if err := yaml.Unmarshal(content, reqs); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse Ansible Galaxy requirements file %q", galaxyRequirementsFile)
+	}
+	return reqs, nil
+}
+
+// resolveGalaxyRequirements checks that all requirements refer to tarballs vendored in the CSAR,
+// as they are installed offline, and makes their paths absolute.
+// It returns the list of referenced files.
+func resolveGalaxyRequirements(reqs *galaxyRequirements, csarPath string) ([]string, error) {
+	var files []string
+	resolve := func(kind string, entries []interface{}, srcKey string) error {
+		for i, entry := range entries {
+			var src string
+			var m map[interface{}]interface{}
+			switch v := entry.(type) {
+			case string:
+				src = v
+			case map[interface{}]interface{}:
+				m = v
+				if s, ok := m[srcKey].(string); ok {
+					src = s
+				} else if s, ok := m["name"].(string); ok && srcKey == "source" {
+					src = s
+				}
+			}
+			if src == "" {
+				return errors.Errorf("invalid Ansible Galaxy %s requirement at index %d: missing source", kind, i)
+			}
+			p := src
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(csarPath, p)
+			}
+			if !strings.HasPrefix(filepath.Clean(p), filepath.Clean(csarPath)+string(os.PathSeparator)) {
+				return errors.Errorf("Ansible Galaxy %s requirement %q should refer to a file inside the CSAR", kind, src)
+			}
+			if fi, err := os.Stat(p); err != nil || fi.IsDir() {
+				return errors.Errorf("Ansible Galaxy %s requirement %q should refer to a tarball vendored in the CSAR as requirements are installed offline", kind, src)
+			}
+			files = append(files, p)
+			if m == nil {
+				if srcKey != "source" {
+					entries[i] = p
+					continue
+				}
+				m = make(map[interface{}]interface{})
+				entries[i] = m
+			}
+			if srcKey == "source" {
+				// Collections tarballs are installed from their name when their type is file
+				m["name"] = p
+				m["type"] = "file"
+				delete(m, "source")
+			} else {
+				m[srcKey] = p
+			}
+		}
+		return nil
+	}
+	if err := resolve("collection", reqs.Collections, "source"); err != nil {
+		return nil, err
+	}
+	if err := resolve("role", reqs.Roles, "src"); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// computeGalaxyChecksum returns a checksum of the requirements file and of the tarballs it references
+func computeGalaxyChecksum(content []byte, files []string) (string, error) {
+	h := sha256.New()
+	h.Write(content)
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	for _, f := range sorted {
+		fi, err := os.Open(f)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, fi)
+		fi.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// installGalaxyRequirements installs the collections and roles listed in the requirements file of a CSAR
+// into a cache directory of the deployment. Requirements are installed only once and reused by the next
+// operations of the deployment as long as the requirements file and tarballs do not change.
+//
+// Requirements are installed into a temporary directory renamed once the installation succeeded, so that
+// operations never use a partial installation. Previous installations are kept as running operations may
+// still use them.
+func installGalaxyRequirements(ctx context.Context, deploymentID, csarPath, ansiblePath string) (*galaxyPaths, error) {
+	paths := new(galaxyPaths)
+	content, err := ioutil.ReadFile(filepath.Join(csarPath, galaxyRequirementsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return paths, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read Ansible Galaxy requirements file %q", galaxyRequirementsFile)
+	}
+	reqs, err := parseGalaxyRequirements(content)
+	if err != nil {
+		return nil, err
+	}
+	files, err := resolveGalaxyRequirements(reqs, csarPath)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := computeGalaxyChecksum(content, files)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute Ansible Galaxy requirements checksum")
+	}
+
+	cachePath := filepath.Join(ansiblePath, galaxyCacheDir)
+	installPath := filepath.Join(cachePath, checksum)
+	if len(reqs.Collections) > 0 {
+		paths.collections = filepath.Join(installPath, "collections")
+	}
+	if len(reqs.Roles) > 0 {
+		paths.roles = filepath.Join(installPath, "roles")
+	}
+
+	lock, _ := galaxyInstallLocks.LoadOrStore(cachePath, new(sync.Mutex))
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if fi, err := os.Stat(installPath); err == nil && fi.IsDir() {
+		log.Debugf("Reusing Ansible Galaxy requirements installed in %q for deployment %q", installPath, deploymentID)
+		return paths, nil
+	}
+
+	if err = os.MkdirAll(cachePath, 0775); err != nil {
+		return nil, errors.Wrap(err, "failed to create Ansible Galaxy requirements cache")
+	}
+	tmpPath, err := ioutil.TempDir(cachePath, ".install-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Ansible Galaxy requirements cache")
+	}
+	// Nothing is left once the installation is renamed
+	defer os.RemoveAll(tmpPath)
+	resolved, err := yaml.Marshal(reqs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate Ansible Galaxy requirements file")
+	}
+	resolvedPath := filepath.Join(tmpPath, galaxyRequirementsFile)
+	if err = ioutil.WriteFile(resolvedPath, resolved, 0664); err != nil {
+		return nil, errors.Wrap(err, "failed to write Ansible Galaxy requirements file")
+	}
+
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).Registerf(
+		"Installing %d Ansible collection(s) and %d role(s) provided by the deployment", len(reqs.Collections), len(reqs.Roles))
+	if paths.collections != "" {
+		// Dependencies are not resolved as they would be downloaded from Galaxy
+		args := []string{"collection", "install", "--no-deps", "-r", resolvedPath, "-p", filepath.Join(tmpPath, "collections")}
+		if galaxySupportsOffline(ctx, tmpPath) {
+			args = append(args, "--offline")
+		}
+		out, err := runGalaxyCommand(ctx, tmpPath, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to install Ansible collections: %s", out)
+		}
+		log.Debugf("ansible-galaxy collection install output: %s", out)
+	}
+	if paths.roles != "" {
+		out, err := runGalaxyCommand(ctx, tmpPath, "role", "install", "--no-deps", "-r", resolvedPath, "-p", filepath.Join(tmpPath, "roles"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to install Ansible roles: %s", out)
+		}
+		log.Debugf("ansible-galaxy role install output: %s", out)
+	}
+
+	if err = os.Rename(tmpPath, installPath); err != nil {
+		return nil, errors.Wrap(err, "failed to install Ansible Galaxy requirements into the cache")
+	}
+	return paths, nil
+}
+
+// galaxySupportsOffline returns true if the installed ansible-galaxy command supports the --offline
+// option of collections installs, which is not the case before ansible-core 2.13
+func galaxySupportsOffline(ctx context.Context, dir string) bool {
+	out, err := runGalaxyCommand(ctx, dir, "collection", "install", "--help")
+	return err == nil && strings.Contains(out, "--offline")
+}
+
+// galaxyConfigPath returns an Ansible search path starting with the deployment path
+func galaxyConfigPath(deploymentPath, defaultPath string) string {
+	return fmt.Sprintf("%s:%s", deploymentPath, defaultPath)
+}
@@ -0,0 +1,228 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ystia/yorc/v4/config"
+)
+
+type galaxyCommandsRecorder struct {
+	commands [][]string
+	err      error
+	// help is the output of help commands, which are not recorded
+	help string
+}
+
+func (r *galaxyCommandsRecorder) run(ctx context.Context, dir string, args ...string) (string, error) {
+	if args[len(args)-1] == "--help" {
+		return r.help, nil
+	}
+	r.commands = append(r.commands, args)
+	return "output", r.err
+}
+
+// mockGalaxyCommand replaces the ansible-galaxy command runner and returns a function restoring it
+func mockGalaxyCommand(r *galaxyCommandsRecorder) func() {
+	previous := runGalaxyCommand
+	runGalaxyCommand = r.run
+	return func() { runGalaxyCommand = previous }
+}
+
+func writeTestCSAR(t *testing.T, requirements string, files ...string) string {
+	csarPath, err := ioutil.TempDir("", "csar")
+	require.NoError(t, err)
+	if requirements != "" {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(csarPath, galaxyRequirementsFile), []byte(requirements), 0664))
+	}
+	for _, f := range files {
+		p := filepath.Join(csarPath, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0775))
+		require.NoError(t, ioutil.WriteFile(p, []byte("tarball "+f), 0664))
+	}
+	return csarPath
+}
+
+func testInstallGalaxyRequirements(t *testing.T) {
+	requirements := `
+collections:
+  - collections/community-general-3.0.0.tar.gz
+  - name: collections/ansible-posix-1.2.0.tar.gz
+    type: file
+roles:
+  - src: roles/myrole.tar.gz
+    name: myrole
+`
+	csarPath := writeTestCSAR(t, requirements, "collections/community-general-3.0.0.tar.gz", "collections/ansible-posix-1.2.0.tar.gz", "roles/myrole.tar.gz")
+	defer os.RemoveAll(csarPath)
+	ansiblePath, err := ioutil.TempDir("", "ansible")
+	require.NoError(t, err)
+	defer os.RemoveAll(ansiblePath)
+
+	recorder := &galaxyCommandsRecorder{help: "  --offline             Install collection artifacts (tarballs) without contacting any distribution servers."}
+	defer mockGalaxyCommand(recorder)()
+
+	paths, err := installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+	require.NoError(t, err)
+	installPath := filepath.Dir(paths.collections)
+	assert.Equal(t, filepath.Join(ansiblePath, galaxyCacheDir), filepath.Dir(installPath))
+	assert.Equal(t, filepath.Join(installPath, "roles"), paths.roles)
+
+	// Requirements are installed offline into a temporary directory renamed once installed
+	require.Len(t, recorder.commands, 2)
+	tmpPath := filepath.Dir(recorder.commands[0][4])
+	resolvedPath := filepath.Join(tmpPath, galaxyRequirementsFile)
+	assert.Equal(t, []string{"collection", "install", "--no-deps", "-r", resolvedPath, "-p", filepath.Join(tmpPath, "collections"), "--offline"}, recorder.commands[0])
+	assert.Equal(t, []string{"role", "install", "--no-deps", "-r", resolvedPath, "-p", filepath.Join(tmpPath, "roles")}, recorder.commands[1])
+	_, err = os.Stat(tmpPath)
+	assert.True(t, os.IsNotExist(err))
+	resolvedPath = filepath.Join(installPath, galaxyRequirementsFile)
+
+	// Generated requirements refer to the tarballs using absolute paths
+	content, err := ioutil.ReadFile(resolvedPath)
+	require.NoError(t, err)
+	resolved := struct {
+		Collections []map[string]string `yaml:"collections"`
+		Roles       []map[string]string `yaml:"roles"`
+	}{}
+	require.NoError(t, yaml.Unmarshal(content, &resolved))
+	assert.Equal(t, []map[string]string{
+		{"name": filepath.Join(csarPath, "collections/community-general-3.0.0.tar.gz"), "type": "file"},
+		{"name": filepath.Join(csarPath, "collections/ansible-posix-1.2.0.tar.gz"), "type": "file"},
+	}, resolved.Collections)
+	assert.Equal(t, []map[string]string{
+		{"src": filepath.Join(csarPath, "roles/myrole.tar.gz"), "name": "myrole"},
+	}, resolved.Roles)
+
+	// Requirements are reused by next operations
+	_, err = installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+	require.NoError(t, err)
+	require.Len(t, recorder.commands, 2, "requirements should not be installed again")
+
+	// Requirements are installed again if a tarball changes, without removing the installation used by running operations
+	require.NoError(t, ioutil.WriteFile(filepath.Join(csarPath, "roles/myrole.tar.gz"), []byte("new version"), 0664))
+	newPaths, err := installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+	require.NoError(t, err)
+	require.Len(t, recorder.commands, 4, "requirements should be installed again")
+	assert.NotEqual(t, paths.roles, newPaths.roles)
+	_, err = os.Stat(installPath)
+	assert.NoError(t, err)
+
+	// --offline is not used if ansible-galaxy doesn't support it
+	recorder.help = "usage: ansible-galaxy collection install [-h]"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(csarPath, "roles/myrole.tar.gz"), []byte("another version"), 0664))
+	_, err = installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+	require.NoError(t, err)
+	require.Len(t, recorder.commands, 6)
+	assert.NotContains(t, recorder.commands[4], "--offline")
+	assert.Contains(t, recorder.commands[4], "--no-deps")
+}
+
+func testInstallGalaxyRequirementsLegacyRolesFormat(t *testing.T) {
+	csarPath := writeTestCSAR(t, "- roles/myrole.tar.gz\n", "roles/myrole.tar.gz")
+	defer os.RemoveAll(csarPath)
+	ansiblePath, err := ioutil.TempDir("", "ansible")
+	require.NoError(t, err)
+	defer os.RemoveAll(ansiblePath)
+
+	recorder := &galaxyCommandsRecorder{}
+	defer mockGalaxyCommand(recorder)()
+
+	paths, err := installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+	require.NoError(t, err)
+	assert.Equal(t, "", paths.collections)
+	assert.Equal(t, filepath.Join(ansiblePath, galaxyCacheDir), filepath.Dir(filepath.Dir(paths.roles)))
+	assert.Equal(t, "roles", filepath.Base(paths.roles))
+	require.Len(t, recorder.commands, 1)
+	assert.Equal(t, "role", recorder.commands[0][0])
+}
+
+func testInstallGalaxyRequirementsErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements string
+		files        []string
+		galaxyErr    error
+		wantErr      string
+	}{
+		{"RemoteCollection", "collections:\n  - name: community.general\n    version: 3.0.0\n", nil, nil, "vendored in the CSAR"},
+		{"MissingRoleSource", "roles:\n  - name: myrole\n", nil, nil, "missing source"},
+		{"OutsideOfCSAR", "roles:\n  - src: ../myrole.tar.gz\n", nil, nil, "inside the CSAR"},
+		{"InvalidYAML", "collections: [", nil, nil, "failed to parse"},
+		{"InstallFailure", "collections:\n  - c.tar.gz\n", []string{"c.tar.gz"}, errors.New("exit status 1"), "failed to install Ansible collections"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csarPath := writeTestCSAR(t, tt.requirements, tt.files...)
+			defer os.RemoveAll(csarPath)
+			ansiblePath, err := ioutil.TempDir("", "ansible")
+			require.NoError(t, err)
+			defer os.RemoveAll(ansiblePath)
+
+			recorder := &galaxyCommandsRecorder{err: tt.galaxyErr}
+			defer mockGalaxyCommand(recorder)()
+
+			_, err = installGalaxyRequirements(context.Background(), "d1", csarPath, ansiblePath)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			// A failed installation should not be reused
+			entries, _ := ioutil.ReadDir(filepath.Join(ansiblePath, galaxyCacheDir))
+			assert.Len(t, entries, 0)
+		})
+	}
+}
+
+func TestInstallGalaxyRequirementsWithoutRequirementsFile(t *testing.T) {
+	csarPath := writeTestCSAR(t, "")
+	defer os.RemoveAll(csarPath)
+	recorder := &galaxyCommandsRecorder{}
+	defer mockGalaxyCommand(recorder)()
+
+	paths, err := installGalaxyRequirements(context.Background(), "d1", csarPath, "ansiblePath")
+	require.NoError(t, err)
+	assert.Equal(t, &galaxyPaths{}, paths)
+	assert.Len(t, recorder.commands, 0)
+}
+
+func TestGenerateAnsibleConfigWithGalaxyPaths(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", path.Base(t.Name()))
+	require.NoError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(tempdir)
+
+	execution := &executionCommon{
+		cfg:         config.Configuration{WorkingDirectory: tempdir},
+		galaxyPaths: &galaxyPaths{collections: "/d1/galaxy/collections", roles: "/d1/galaxy/roles"},
+	}
+	err = execution.generateAnsibleConfigurationFile("ansiblePath", tempdir)
+	require.NoError(t, err, "Error generating ansible config file")
+
+	resultMap, _ := readAnsibleConfigSettings(t, path.Join(tempdir, "ansible.cfg"))
+	collectionsPaths := resultMap[ansibleConfigDefaultsHeader]["collections_paths"]
+	assert.True(t, strings.HasPrefix(collectionsPaths, "/d1/galaxy/collections:"), "unexpected collections_paths %q", collectionsPaths)
+	rolesPath := resultMap[ansibleConfigDefaultsHeader]["roles_path"]
+	assert.True(t, strings.HasPrefix(rolesPath, "/d1/galaxy/roles:"), "unexpected roles_path %q", rolesPath)
+}
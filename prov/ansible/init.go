@@ -21,6 +21,8 @@ const (
 	implementationArtifactPython       = "tosca.artifacts.Implementation.Python"
 	implementationArtifactAnsible      = "tosca.artifacts.Implementation.Ansible"
 	implementationArtifactAnsibleAlien = "org.alien4cloud.artifacts.AnsiblePlaybook"
+	implementationArtifactSSHBash      = "yorc.artifacts.Implementation.SSH.Bash"
+	implementationArtifactSSHPython    = "yorc.artifacts.Implementation.SSH.Python"
 )
 
 func init() {
@@ -32,6 +34,8 @@ func init() {
 			implementationArtifactPython,
 			implementationArtifactAnsible,
 			implementationArtifactAnsibleAlien,
+			implementationArtifactSSHBash,
+			implementationArtifactSSHPython,
 		}, executor, registry.BuiltinOrigin)
 	reg.RegisterActionOperator([]string{"ansible-job-monitoring"}, &actionOperator{executor: executor}, registry.BuiltinOrigin)
 }
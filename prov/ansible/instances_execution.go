@@ -0,0 +1,291 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fvbommel/sortorder"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/locations"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/prov/operations"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+// LocationInstancesParallelismProperty is the location property defining the default maximum number
+// of instances on which an operation is run concurrently
+const LocationInstancesParallelismProperty = "instances_parallelism"
+
+// LocationInstancesBatchSizeProperty is the location property defining the default number of instances
+// of a batch when operations are run by batches
+const LocationInstancesBatchSizeProperty = "instances_batch_size"
+
+// LocationInstancesBatchPauseProperty is the location property defining the default time to wait between two batches
+const LocationInstancesBatchPauseProperty = "instances_batch_pause"
+
+// LocationInstancesMaxFailuresProperty is the location property defining the default number of failed instances
+// tolerated before aborting the execution of an operation
+const LocationInstancesMaxFailuresProperty = "instances_max_failures"
+
+// executionUnit is a run of an operation done by a single ansible execution
+type executionUnit struct {
+	// instanceID is the ID of the instance on which the operation is run or, for per-instance operations,
+	// the ID of the instance of the other side of the relationship
+	instanceID string
+	run        func(ctx context.Context, retry bool) error
+}
+
+// resolveInstancesExecution resolves the instances execution settings defined by the workflow step, by the operation
+// implementation or by the location of the node, in this order of precedence.
+//
+// Settings are left unset if none are defined, in which case the operation is run as a whole.
+func (e *executionCommon) resolveInstancesExecution(ctx context.Context) error {
+	e.instancesExecution = e.operation.InstancesExecution
+	if e.instancesExecution == nil && e.OperationImplementation != nil {
+		e.instancesExecution = e.OperationImplementation.InstancesExecution
+	}
+	if e.instancesExecution == nil {
+		var err error
+		e.instancesExecution, err = getLocationInstancesExecution(ctx, e.cfg, e.deploymentID, e.NodeName)
+		if err != nil {
+			return err
+		}
+	}
+	if e.instancesExecution == nil {
+		return nil
+	}
+	if e.instancesExecution.Parallelism < 0 || e.instancesExecution.BatchSize < 0 || e.instancesExecution.Pause < 0 || e.instancesExecution.MaxFailures < 0 {
+		return errors.Errorf("invalid instances execution settings %+v for operation %q on node %q: values should not be negative", *e.instancesExecution, e.operation.Name, e.NodeName)
+	}
+	return nil
+}
+
+// getLocationInstancesExecution returns the default instances execution settings defined by the location
+// of the given node, or nil if it doesn't define a parallelism or a batch size
+func getLocationInstancesExecution(ctx context.Context, cfg config.Configuration, deploymentID, nodeName string) (*tosca.InstancesExecution, error) {
+	props, err := getNodeLocationProperties(ctx, cfg, deploymentID, nodeName)
+	if err != nil || props == nil {
+		return nil, err
+	}
+	if !props.IsSet(LocationInstancesParallelismProperty) && !props.IsSet(LocationInstancesBatchSizeProperty) {
+		return nil, nil
+	}
+	return &tosca.InstancesExecution{
+		Parallelism: props.GetInt(LocationInstancesParallelismProperty),
+		BatchSize:   props.GetInt(LocationInstancesBatchSizeProperty),
+		Pause:       props.GetDuration(LocationInstancesBatchPauseProperty),
+		MaxFailures: props.GetInt(LocationInstancesMaxFailuresProperty),
+	}, nil
+}
+
+// getNodeLocationProperties returns the properties of the location of the given node,
+// or nil if the node has no location or if its location is not defined
+func getNodeLocationProperties(ctx context.Context, cfg config.Configuration, deploymentID, nodeName string) (config.DynamicMap, error) {
+	locationName, err := deployments.GetNodeLocationName(ctx, deploymentID, nodeName)
+	if err != nil || locationName == "" {
+		return nil, err
+	}
+	locationMgr, err := locations.GetManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	locationsConfigs, err := locationMgr.GetLocations()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range locationsConfigs {
+		if l.Name == locationName {
+			return l.Properties, nil
+		}
+	}
+	return nil, nil
+}
+
+// copyWithHosts returns a copy of the execution running the operation on the given hosts only.
+// Copies are used to run the operation concurrently as executions and hosts keep the state of a run.
+func (e *executionCommon) copyWithHosts(hostNames ...string) *executionCommon {
+	c := *e
+	c.hosts = make(map[string]*hostConnection, len(hostNames))
+	for _, hostName := range hostNames {
+		host := *e.hosts[hostName]
+		c.hosts[hostName] = &host
+	}
+	c.ansibleRunner = e.ansibleRunner.withExecution(&c)
+	return &c
+}
+
+// getExecutionUnits returns a unit per instance of the other side of the relationship for per-instance
+// operations and a unit per instance of the node for other operations, units being run using the given function
+func (e *executionCommon) getExecutionUnits(run instanceRunFunc) []executionUnit {
+	hostNames := make([]string, 0, len(e.hosts))
+	for hostName := range e.hosts {
+		hostNames = append(hostNames, hostName)
+	}
+	sort.Sort(sortorder.Natural(hostNames))
+
+	units := make([]executionUnit, 0)
+	if e.isPerInstanceOperation {
+		nodeName, instances := e.operation.RelOp.TargetNodeName, e.targetNodeInstances
+		if e.isRelationshipTargetNode {
+			nodeName, instances = e.NodeName, e.sourceNodeInstances
+		}
+		for _, instanceID := range instances {
+			exec := e.copyWithHosts(hostNames...)
+			instanceName := operations.GetInstanceName(nodeName, instanceID)
+			units = append(units, executionUnit{instanceID: instanceID, run: func(ctx context.Context, retry bool) error {
+				return run(exec, ctx, retry, instanceName)
+			}})
+		}
+		return units
+	}
+
+	for _, hostName := range hostNames {
+		exec := e.copyWithHosts(hostName)
+		units = append(units, executionUnit{instanceID: e.hosts[hostName].instanceID, run: func(ctx context.Context, retry bool) error {
+			return run(exec, ctx, retry, "")
+		}})
+	}
+	return units
+}
+
+// executeInstances runs the operation by batches of instances according to the instances execution settings.
+//
+// If units are run on the instances of the node, the returned error is a prov.InstancesError giving
+// the instances on which the operation failed or was not run.
+func (e *executionCommon) executeInstances(ctx context.Context, retry bool, run instanceRunFunc) error {
+	units := e.getExecutionUnits(run)
+	failed, skipped, err := runExecutionUnits(ctx, e.deploymentID, *e.instancesExecution, units, retry, e.cfg.Ansible.ConnectionRetries)
+	if err == nil || e.isPerInstanceOperation || e.isRelationshipTargetNode {
+		return err
+	}
+	instErr := &prov.InstancesError{Err: err, Failed: make([]string, 0, len(failed)), Skipped: make([]string, 0, len(skipped))}
+	for _, i := range failed {
+		instErr.Failed = append(instErr.Failed, units[i].instanceID)
+	}
+	for _, i := range skipped {
+		instErr.Skipped = append(instErr.Skipped, units[i].instanceID)
+	}
+	return instErr
+}
+
+// runExecutionUnits runs units by batches, up to the configured parallelism of units of a batch being run concurrently.
+//
+// No more units are started once more units than tolerated have failed or once the context is cancelled.
+// It returns the indexes of failed units and of units that were not run, and an error if any unit was not run successfully.
+func runExecutionUnits(ctx context.Context, deploymentID string, settings tosca.InstancesExecution, units []executionUnit, retry bool, connectionRetries int) ([]int, []int, error) {
+	batchSize := settings.BatchSize
+	if batchSize == 0 || batchSize > len(units) {
+		batchSize = len(units)
+	}
+	parallelism := settings.Parallelism
+	if parallelism == 0 {
+		parallelism = 1
+		if settings.BatchSize > 0 {
+			parallelism = batchSize
+		}
+	}
+
+	var lock sync.Mutex
+	errs := make([]error, len(units))
+	started := make([]bool, len(units))
+	var failuresCount int
+	aborted := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return failuresCount > settings.MaxFailures || ctx.Err() != nil
+	}
+
+	for start := 0; start < len(units) && !aborted(); start += batchSize {
+		end := start + batchSize
+		if end > len(units) {
+			end = len(units)
+		}
+		if start > 0 && settings.Pause > 0 {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).Registerf("Waiting %s before running the next batch of instances", settings.Pause)
+			select {
+			case <-time.After(settings.Pause):
+			case <-ctx.Done():
+			}
+			if aborted() {
+				break
+			}
+		}
+		if batchSize < len(units) {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).Registerf("Running batch of instances %d to %d out of %d", start+1, end, len(units))
+		}
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, parallelism)
+		for i := start; i < end; i++ {
+			slots <- struct{}{}
+			if aborted() {
+				break
+			}
+			started[i] = true
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				unitCtx := events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: units[i].instanceID})
+				if err := runExecutionUnit(unitCtx, deploymentID, units[i], retry, connectionRetries); err != nil {
+					lock.Lock()
+					errs[i] = err
+					failuresCount++
+					lock.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	failed := make([]int, 0)
+	skipped := make([]int, 0)
+	for i := range units {
+		if errs[i] != nil {
+			failed = append(failed, i)
+		} else if !started[i] {
+			skipped = append(skipped, i)
+		}
+	}
+	if len(failed) > 0 {
+		return failed, skipped, errors.Wrapf(errs[failed[0]], "operation failed on %d out of %d instances (%d not run)", len(failed), len(units), len(skipped))
+	}
+	if len(skipped) > 0 {
+		return failed, skipped, errors.Wrapf(ctx.Err(), "operation not run on %d out of %d instances", len(skipped), len(units))
+	}
+	return failed, skipped, nil
+}
+
+// runExecutionUnit runs a unit, running it again on Ansible retriable errors up to the given number of connection retries
+func runExecutionUnit(ctx context.Context, deploymentID string, unit executionUnit, retry bool, connectionRetries int) error {
+	err := unit.run(ctx, retry)
+	for i := 0; i < connectionRetries && IsRetriable(err); i++ {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).Registerf("Caught a retriable error from Ansible: '%v'. Let's retry in few seconds (%d/%d)", err, i+1, connectionRetries)
+		time.Sleep(time.Duration(rand.Int63n(10)) * time.Second)
+		err = unit.run(ctx, true)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ansible
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+// unitsRecorder records the execution of test units
+type unitsRecorder struct {
+	lock           sync.Mutex
+	running        int
+	maxRunning     int
+	started        []int
+	finishedBefore map[int]int
+}
+
+func (r *unitsRecorder) units(count int, failing ...int) []executionUnit {
+	r.finishedBefore = make(map[int]int)
+	units := make([]executionUnit, count)
+	var finished int
+	for i := range units {
+		i := i
+		units[i].run = func(ctx context.Context, retry bool) error {
+			r.lock.Lock()
+			r.running++
+			if r.running > r.maxRunning {
+				r.maxRunning = r.running
+			}
+			r.started = append(r.started, i)
+			r.finishedBefore[i] = finished
+			r.lock.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.running--
+			finished++
+			for _, f := range failing {
+				if f == i {
+					return errors.Errorf("unit %d failed", i)
+				}
+			}
+			return nil
+		}
+	}
+	return units
+}
+
+func testRunExecutionUnits(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Sequential", func(t *testing.T) {
+		r := &unitsRecorder{}
+		failed, skipped, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{}, r.units(3), false, 0)
+		require.NoError(t, err)
+		assert.Empty(t, failed)
+		assert.Empty(t, skipped)
+		assert.Equal(t, 1, r.maxRunning)
+		assert.Equal(t, []int{0, 1, 2}, r.started)
+	})
+	t.Run("Parallel", func(t *testing.T) {
+		r := &unitsRecorder{}
+		_, _, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{Parallelism: 3}, r.units(10), false, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, r.maxRunning)
+		assert.Len(t, r.started, 10)
+	})
+	t.Run("Rolling", func(t *testing.T) {
+		r := &unitsRecorder{}
+		_, _, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{BatchSize: 2, Pause: 20 * time.Millisecond}, r.units(5), false, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, r.maxRunning)
+		// Units of a batch are started once all units of previous batches are finished
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, i-i%2, r.finishedBefore[i], "unit %d started before the end of previous batches", i)
+		}
+	})
+	t.Run("AbortOnFailures", func(t *testing.T) {
+		r := &unitsRecorder{}
+		failed, skipped, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{BatchSize: 2, MaxFailures: 1}, r.units(8, 1, 2), false, 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unit 1 failed")
+		assert.Equal(t, []int{1, 2}, failed)
+		assert.Equal(t, []int{4, 5, 6, 7}, skipped)
+	})
+	t.Run("ToleratedFailures", func(t *testing.T) {
+		r := &unitsRecorder{}
+		failed, skipped, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{Parallelism: 2, MaxFailures: 2}, r.units(6, 0, 3), false, 0)
+		require.Error(t, err)
+		assert.Equal(t, []int{0, 3}, failed)
+		assert.Empty(t, skipped)
+		assert.Len(t, r.started, 6)
+	})
+	t.Run("Cancelled", func(t *testing.T) {
+		r := &unitsRecorder{}
+		ctx, cancel := context.WithCancel(ctx)
+		units := r.units(4)
+		run := units[0].run
+		units[0].run = func(ctx context.Context, retry bool) error {
+			defer cancel()
+			return run(ctx, retry)
+		}
+		failed, skipped, err := runExecutionUnits(ctx, "dep", tosca.InstancesExecution{BatchSize: 1}, units, false, 0)
+		require.Error(t, err)
+		assert.Empty(t, failed)
+		assert.Equal(t, []int{1, 2, 3}, skipped)
+	})
+}
+
+// hostsRunnerTest is an ansible runner failing when run on given hosts
+type hostsRunnerTest struct {
+	e           *executionCommon
+	failedHosts map[string]bool
+}
+
+func (r *hostsRunnerTest) runAnsible(ctx context.Context, retry bool, currentInstance, ansibleRecipePath string) error {
+	for hostName := range r.e.hosts {
+		if r.failedHosts[hostName] {
+			return errors.Errorf("failed on %s", hostName)
+		}
+	}
+	return nil
+}
+
+func (r *hostsRunnerTest) withExecution(e *executionCommon) ansibleRunner {
+	return &hostsRunnerTest{e: e, failedHosts: r.failedHosts}
+}
+
+func testExecuteInstances(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", path.Base(t.Name()))
+	require.NoError(t, err, "Failed to create temporary directory")
+	defer os.RemoveAll(tempdir)
+
+	cfg := config.Configuration{WorkingDirectory: tempdir}
+	cfg.Ansible.HostedOperations.UnsandboxedOperationsAllowed = true
+	e := &executionCommon{
+		cfg:                     cfg,
+		deploymentID:            "testDeployment",
+		taskID:                  "testTaskID",
+		NodeName:                "NodeA",
+		operation:               prov.Operation{Name: "standard.start"},
+		isOrchestratorOperation: true,
+		hosts: map[string]*hostConnection{
+			"NodeA_0":  &hostConnection{host: "NodeA_0", instanceID: "0"},
+			"NodeA_1":  &hostConnection{host: "NodeA_1", instanceID: "1"},
+			"NodeA_2":  &hostConnection{host: "NodeA_2", instanceID: "2"},
+			"NodeA_10": &hostConnection{host: "NodeA_10", instanceID: "10"},
+		},
+		instancesExecution: &tosca.InstancesExecution{BatchSize: 1},
+	}
+	e.ansibleRunner = (&hostsRunnerTest{failedHosts: map[string]bool{"NodeA_1": true}}).withExecution(e)
+
+	err = e.execute(context.Background(), false)
+	require.Error(t, err)
+	instErr, ok := errors.Cause(err).(*prov.InstancesError)
+	require.True(t, ok, "expecting an instances error, got %T", err)
+	assert.Equal(t, []string{"1"}, instErr.Failed)
+	assert.Equal(t, []string{"2", "10"}, instErr.Skipped)
+	assert.True(t, prov.IsFailedInstance(err, "1"))
+	assert.False(t, prov.IsFailedInstance(err, "0"))
+	assert.True(t, prov.IsSkippedInstance(err, "10"))
+}
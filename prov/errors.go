@@ -0,0 +1,49 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prov
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/collections"
+)
+
+// InstancesError is returned by an operation executor when an operation failed on some node instances only
+type InstancesError struct {
+	// Err is the error that occurred on failed instances
+	Err error
+	// Failed are the IDs of the instances on which the operation failed
+	Failed []string
+	// Skipped are the IDs of the instances on which the operation was not run because the execution was aborted
+	Skipped []string
+}
+
+func (e *InstancesError) Error() string {
+	return e.Err.Error()
+}
+
+// IsFailedInstance returns true if the given error doesn't come from an InstancesError or if the
+// operation failed on the given instance according to it
+func IsFailedInstance(err error, instanceID string) bool {
+	instErr, ok := errors.Cause(err).(*InstancesError)
+	return !ok || collections.ContainsString(instErr.Failed, instanceID)
+}
+
+// IsSkippedInstance returns true if the given error comes from an InstancesError and if the operation was
+// not run on the given instance according to it
+func IsSkippedInstance(err error, instanceID string) bool {
+	instErr, ok := errors.Cause(err).(*InstancesError)
+	return ok && collections.ContainsString(instErr.Skipped, instanceID)
+}
@@ -0,0 +1,227 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/prov/operations"
+	"github.com/ystia/yorc/v4/testutil"
+)
+
+func Test_executionCommon_wrapCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		locationProps config.DynamicMap
+		jobInfo       *jobInfo
+		innerCmd      string
+		wantPattern   *regexp.Regexp
+		wantErr       bool
+	}{
+		{"TestBasicGeneration", config.DynamicMap{},
+			&jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "~"},
+			"ping -c 3 1.1.1.1", regexp.MustCompile(`^cat <<'EOF' > ~/b-[-a-f0-9]+.pbs\n#!/bin/bash\n\ncd "\$PBS_O_WORKDIR"\nping -c 3 1.1.1.1\nEOF\ncd ~ && qsub -V -N 'MyJob' -l select=1 ~/b-[-a-f0-9]+.pbs; rm -f ~/b-[-a-f0-9]+.pbs$`), false},
+		{"TestWithInlineOptsGeneration", config.DynamicMap{},
+			&jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "~", ExecutionOptions: executionOptions{InScriptOptions: []string{"#PBS -r n", "not dash prefixed so will not appear", "#another one"}}},
+			"ping -c 3 1.1.1.1", regexp.MustCompile(`^cat <<'EOF' > ~/b-[-a-f0-9]+.pbs\n#!/bin/bash\n#PBS -r n\n#another one\n\ncd "\$PBS_O_WORKDIR"\nping -c 3 1.1.1.1\nEOF\ncd ~ && qsub -V -N 'MyJob' -l select=1 ~/b-[-a-f0-9]+.pbs; rm -f ~/b-[-a-f0-9]+.pbs$`), false},
+		{"TestWithSourceEnvFileAndWorkingDir", config.DynamicMap{},
+			&jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "/scratch/john", EnvFile: "~/.bash_profile"},
+			"ping -c 3 1.1.1.1", regexp.MustCompile(`^\[ -f ~/.bash_profile \] && \{ source ~/.bash_profile ; \} ;mkdir -p /scratch/john;cat <<'EOF' > /scratch/john/b-[-a-f0-9]+.pbs\n#!/bin/bash\n\ncd "\$PBS_O_WORKDIR"\nping -c 3 1.1.1.1\nEOF\ncd /scratch/john && qsub -V -N 'MyJob' -l select=1 /scratch/john/b-[-a-f0-9]+.pbs; rm -f /scratch/john/b-[-a-f0-9]+.pbs$`), false},
+		{"TestTorqueResources", config.DynamicMap{"torque": true},
+			&jobInfo{Name: "MyJob", Nodes: 2, Cpus: 4, Mem: "1024kb", Walltime: "00:10:00", Queue: "batch", WorkingDir: "~"},
+			"hostname", regexp.MustCompile(`cd ~ && qsub -V -N 'MyJob' -l nodes=2:ppn=4 -l mem=2048kb -l walltime=00:10:00 -q 'batch' ~/b-[-a-f0-9]+.pbs; rm -f ~/b-[-a-f0-9]+.pbs$`), false},
+		{"TestInvalidTorqueMemory", config.DynamicMap{"torque": true},
+			&jobInfo{Name: "MyJob", Nodes: 2, Mem: "1G", WorkingDir: "~"},
+			"hostname", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{
+				locationProps: tt.locationProps,
+				jobInfo:       tt.jobInfo,
+			}
+			got, err := e.wrapCommand(tt.innerCmd)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("executionCommon.wrapCommand() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !tt.wantPattern.MatchString(got) {
+				t.Errorf("executionCommon.wrapCommand() = %v, want %v", got, tt.wantPattern.String())
+			}
+		})
+	}
+}
+
+func Test_executionCommon_buildJobOpts(t *testing.T) {
+	tests := []struct {
+		name          string
+		locationProps config.DynamicMap
+		jobInfo       *jobInfo
+		want          string
+	}{
+		{"PBSProAllOptions", config.DynamicMap{},
+			&jobInfo{Name: "MyJob", Nodes: 2, Cpus: 8, Mem: "1048576kb", Walltime: "00:30:00", Queue: "workq", Account: "acc", Opts: []string{"-m abe", "-M john@example.com"}},
+			" -N 'MyJob' -l select=2:ncpus=8:mem=1048576kb -l walltime=00:30:00 -q 'workq' -A 'acc' -m abe -M john@example.com"},
+		{"TorqueAllOptions", config.DynamicMap{"torque": true},
+			&jobInfo{Name: "MyJob", Nodes: 2, Cpus: 8, Mem: "1048576kb", Walltime: "00:30:00", Queue: "batch", Account: "acc"},
+			" -N 'MyJob' -l nodes=2:ppn=8 -l mem=2097152kb -l walltime=00:30:00 -q 'batch' -A 'acc'"},
+		{"DefaultNodes", config.DynamicMap{},
+			&jobInfo{Name: "MyJob"},
+			" -N 'MyJob' -l select=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{locationProps: tt.locationProps, jobInfo: tt.jobInfo}
+			got, err := e.buildJobOpts()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func testExecutionCommonBuildJobInfo(t *testing.T) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/simple_job.yaml")
+	require.NoError(t, err)
+
+	deploymentIDOpts := deploymentID + "-with-opts"
+	err = deployments.StoreDeploymentDefinition(ctx, deploymentIDOpts, "testdata/job_with_options.yaml")
+	require.NoError(t, err)
+
+	type fields struct {
+		locationProps config.DynamicMap
+		deploymentID  string
+		EnvInputs     []*operations.EnvInput
+		Primary       string
+	}
+
+	tests := []struct {
+		name            string
+		fields          fields
+		wantErr         bool
+		expectedJobInfo jobInfo
+	}{
+		{"CheckDefaultValues", fields{config.DynamicMap{}, deploymentID, make([]*operations.EnvInput, 0), "primary"}, false,
+			jobInfo{Name: deploymentID, Nodes: 1, MonitoringTimeInterval: 5 * time.Second, Inputs: make(map[string]string), WorkingDir: home}},
+		{"ChecklocationPropertiesValues", fields{config.DynamicMap{"default_job_name": "myjobname", "job_monitoring_time_interval": "1s"}, deploymentID, make([]*operations.EnvInput, 0), "primary"}, false,
+			jobInfo{Name: "myjobname", Nodes: 1, MonitoringTimeInterval: time.Second, Inputs: make(map[string]string), WorkingDir: home}},
+		{"CheckErrorIfNoCommandAndPrimary", fields{config.DynamicMap{}, deploymentID, make([]*operations.EnvInput, 0), ""}, true, jobInfo{}},
+		{"CheckErrorIfAccountingEnforced", fields{config.DynamicMap{"enforce_accounting": true}, deploymentID, make([]*operations.EnvInput, 0), "primary"}, true, jobInfo{}},
+		{"CheckInputs", fields{config.DynamicMap{}, deploymentID, []*operations.EnvInput{{Name: "MY_INPUT", Value: "value"}, {Name: "credentials", Value: "secret"}}, "primary"}, false,
+			jobInfo{Name: deploymentID, Nodes: 1, MonitoringTimeInterval: 5 * time.Second, Inputs: map[string]string{"MY_INPUT": "value"}, WorkingDir: home}},
+		{"CheckOptions", fields{config.DynamicMap{"enforce_accounting": true}, deploymentIDOpts, make([]*operations.EnvInput, 0), ""}, false,
+			jobInfo{Name: "MyPBSJob", Nodes: 2, Cpus: 8, Mem: "1048576kb", Walltime: "00:30:00", Queue: "workq", Account: "account_test",
+				Opts: []string{"-m abe", "-M john@example.com"}, MonitoringTimeInterval: 10 * time.Second, Inputs: make(map[string]string),
+				WorkingDir: "/scratch/john", EnvFile: "~/.bash_profile",
+				ExecutionOptions: executionOptions{
+					Command:         "sh",
+					Args:            []string{"-c", "echo ${MESSAGE}"},
+					InScriptOptions: []string{"#PBS -r n"},
+					EnvVars:         []string{"MESSAGE=hello"},
+				}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{
+				locationProps: tt.fields.locationProps,
+				deploymentID:  tt.fields.deploymentID,
+				NodeName:      "Job",
+				EnvInputs:     tt.fields.EnvInputs,
+				Primary:       tt.fields.Primary,
+			}
+			err := e.buildJobInfo(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("executionCommon.buildJobInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.expectedJobInfo, *e.jobInfo)
+			}
+		})
+	}
+}
+
+func testExecutionCommonPrepareAndSubmitJob(t *testing.T) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/simple_job.yaml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                   string
+		PrimaryFile            string
+		jobInfo                *jobInfo
+		qsubOutput             string
+		expectedCommandPattern *regexp.Regexp
+		expectedJobID          string
+		wantErr                bool
+	}{
+		{"CheckProvidedBatchScript", "primary.pbs",
+			&jobInfo{Name: "MyJob", Nodes: 4, WorkingDir: home},
+			"1234.pbsserver", regexp.MustCompile(`^cd ~ && qsub -V -N 'MyJob' -l select=4 ~/primary.pbs$`),
+			"1234.pbsserver", false},
+		{"CheckWrappedCommand", "",
+			&jobInfo{Name: "MyJob", Nodes: 4, WorkingDir: home,
+				ExecutionOptions: executionOptions{
+					Command: "cat",
+					Args:    []string{"/etc/os-release"},
+					EnvVars: []string{"KEY=value"},
+				}},
+			"1235",
+			regexp.MustCompile(`^export KEY='value';cat <<'EOF' > ~/b-[-a-f0-9]+.pbs\n#!/bin/bash\n\ncd "\$PBS_O_WORKDIR"\ncat '/etc/os-release' \nEOF\ncd ~ && qsub -V -N 'MyJob' -l select=4 ~/b-[-a-f0-9]+.pbs; rm -f ~/b-[-a-f0-9]+.pbs$`),
+			"1235", false},
+		{"CheckUnexpectedQsubOutput", "primary.pbs",
+			&jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: home},
+			"qsub: Unknown queue", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{
+				locationProps: config.DynamicMap{},
+				deploymentID:  deploymentID,
+				NodeName:      "Job",
+				EnvInputs:     make([]*operations.EnvInput, 0),
+				PrimaryFile:   tt.PrimaryFile,
+				jobInfo:       tt.jobInfo,
+			}
+
+			e.client = &sshutil.MockSSHClient{
+				MockRunCommand: func(cmd string) (string, error) {
+					if tt.expectedCommandPattern != nil && !tt.expectedCommandPattern.MatchString(cmd) {
+						return "", errors.Errorf("unexpected command: %q", cmd)
+					}
+					return tt.qsubOutput, nil
+				},
+			}
+			err := e.prepareAndSubmitJob(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("executionCommon.prepareAndSubmitJob() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.expectedJobID, e.jobInfo.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/locations"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tasks"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+// reservationJob is the script of the job holding the resources of a node allocation
+// until it is deleted, as an interactive job submitted with qsub -I would do
+const reservationJob = "sleep infinity"
+
+const defaultMonitoringTimeInterval = 5 * time.Second
+
+type defaultExecutor struct {
+}
+
+func getJobExecution(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation, stepName string) (execution, error) {
+	isJob, err := deployments.IsNodeDerivedFrom(ctx, deploymentID, nodeName, "yorc.nodes.pbs.Job")
+	if err != nil {
+		return nil, err
+	}
+	if !isJob {
+		return nil, errors.Errorf("operation %q supported only for nodes derived from %q", operation.Name, "yorc.nodes.pbs.Job")
+	}
+	return newExecution(ctx, conf, taskID, deploymentID, nodeName, stepName, operation)
+}
+
+func (e *defaultExecutor) ExecAsyncOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation, stepName string) (*prov.Action, time.Duration, error) {
+	log.Debugf("PBS defaultExecutor: Execute the operation async: %+v", operation)
+
+	exec, err := getJobExecution(ctx, conf, taskID, deploymentID, nodeName, operation, stepName)
+	if err != nil {
+		return nil, 0, err
+	}
+	return exec.executeAsync(ctx)
+}
+
+func (e *defaultExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
+	log.Debugf("PBS defaultExecutor: Execute the operation: %+v", operation)
+
+	exec, err := getJobExecution(ctx, conf, taskID, deploymentID, nodeName, operation, "")
+	if err != nil {
+		return err
+	}
+	return exec.execute(ctx)
+}
+
+func (e *defaultExecutor) ExecDelegate(ctx context.Context, cfg config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
+	instances, err := tasks.GetInstances(ctx, taskID, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	var locationProps config.DynamicMap
+	locationMgr, err := locations.GetManager(cfg)
+	if err == nil {
+		locationProps, err = locationMgr.GetLocationPropertiesForNode(ctx, deploymentID, nodeName, infrastructureType)
+	}
+	if err != nil {
+		return err
+	}
+
+	operation := strings.ToLower(delegateOperation)
+	switch {
+	case operation == "install":
+		err = e.installNode(ctx, cfg, locationProps, deploymentID, nodeName, instances, operation)
+	case operation == "uninstall":
+		err = e.uninstallNode(ctx, cfg, locationProps, deploymentID, nodeName, instances, operation)
+	default:
+		return errors.Errorf("Unsupported operation %q", delegateOperation)
+	}
+	return err
+}
+
+func (e *defaultExecutor) installNode(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, instances []string, operation string) error {
+	for _, instance := range instances {
+		err := deployments.SetInstanceStateWithContextualLogs(events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: instance}), deploymentID, nodeName, instance, tosca.NodeStateCreating)
+		if err != nil {
+			return err
+		}
+	}
+	infra, err := generateInfrastructure(ctx, locationProps, deploymentID, nodeName, operation)
+	if err != nil {
+		return err
+	}
+
+	return e.createInfrastructure(ctx, cfg, locationProps, deploymentID, nodeName, infra)
+}
+
+func (e *defaultExecutor) uninstallNode(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, instances []string, operation string) error {
+	for _, instance := range instances {
+		err := deployments.SetInstanceStateWithContextualLogs(events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: instance}), deploymentID, nodeName, instance, tosca.NodeStateDeleting)
+		if err != nil {
+			return err
+		}
+	}
+	infra, err := generateInfrastructure(ctx, locationProps, deploymentID, nodeName, operation)
+	if err != nil {
+		return err
+	}
+
+	return e.destroyInfrastructure(ctx, cfg, locationProps, deploymentID, nodeName, infra)
+}
+
+func (e *defaultExecutor) createInfrastructure(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, infra *infrastructure) error {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Creating the PBS infrastructure")
+	var g errgroup.Group
+	for _, compute := range infra.nodes {
+		func(ctx context.Context, comp *nodeAllocation) {
+			g.Go(func() error {
+				// Return an sshClient configured using the user credentials provided in the yorc.nodes.pbs.Compute node definition,
+				// or if not provided, the user credentials specified in the location configuration
+				sshClient, err := getSSHClient(cfg, comp.credentials, locationProps)
+				if err != nil {
+					events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(err.Error())
+					return err
+				}
+				return e.createNodeAllocation(ctx, sshClient, locationProps, comp, deploymentID, nodeName)
+			})
+		}(events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: compute.instanceName}), compute)
+	}
+
+	if err := g.Wait(); err != nil {
+		err = errors.Wrapf(err, "Failed to create PBS infrastructure for deploymentID:%q, node name:%s", deploymentID, nodeName)
+		log.Debugf("%+v", err)
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(err.Error())
+		return err
+	}
+
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Successfully creating the PBS infrastructure")
+	return nil
+}
+
+func (e *defaultExecutor) destroyInfrastructure(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, infra *infrastructure) error {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Destroying the PBS infrastructure")
+	var g errgroup.Group
+	for _, compute := range infra.nodes {
+		func(ctx context.Context, comp *nodeAllocation) {
+			g.Go(func() error {
+				sshClient, err := getSSHClient(cfg, comp.credentials, locationProps)
+				if err != nil {
+					events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(err.Error())
+					return err
+				}
+				return e.destroyNodeAllocation(ctx, sshClient, comp, deploymentID, nodeName)
+			})
+		}(events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.InstanceID: compute.instanceName}), compute)
+	}
+
+	if err := g.Wait(); err != nil {
+		err = errors.Wrapf(err, "Failed to destroy PBS infrastructure for deploymentID:%q, node name:%s", deploymentID, nodeName)
+		log.Debugf("%+v", err)
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(err.Error())
+		return err
+	}
+
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Successfully destroying the PBS infrastructure")
+	return nil
+}
+
+// createNodeAllocation submits a reservation job holding the compute resources and waits for this job to run
+// to retrieve the allocated node
+func (e *defaultExecutor) createNodeAllocation(ctx context.Context, sshClient sshutil.Client, locationProps config.DynamicMap, nodeAlloc *nodeAllocation, deploymentID, nodeName string) error {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString(fmt.Sprintf("Creating node allocation for: deploymentID:%q, node name:%q", deploymentID, nodeName))
+
+	torque := locationProps.GetBool("torque")
+	resourcesOpts, err := buildResourcesOptions(1, nodeAlloc.cpu, nodeAlloc.memory, torque)
+	if err != nil {
+		return err
+	}
+	var opts string
+	if nodeAlloc.walltime != "" {
+		opts += fmt.Sprintf(" -l walltime=%s", nodeAlloc.walltime)
+	}
+	if nodeAlloc.queue != "" {
+		opts += fmt.Sprintf(" -q '%s'", nodeAlloc.queue)
+	}
+	if nodeAlloc.account != "" {
+		opts += fmt.Sprintf(" -A '%s'", nodeAlloc.account)
+	}
+
+	// The reservation job script is read from the standard input and its outputs are discarded
+	qsubCmd := fmt.Sprintf("echo '%s' | qsub -N '%s'%s%s -j oe -o /dev/null", reservationJob, nodeAlloc.jobName, resourcesOpts, opts)
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelDEBUG, deploymentID).RegisterAsString(fmt.Sprintf("Run the command: %s", qsubCmd))
+	out, err := sshClient.RunCommand(qsubCmd)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to allocate PBS resource: %s", out)
+	}
+	jobID, err := retrieveJobID(out)
+	if err != nil {
+		return err
+	}
+	err = deployments.SetInstanceAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "job_id", jobID)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set attribute (job_id) for node name:%q, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString(fmt.Sprintf("qsub command submitted the allocation job with ID:%q", jobID))
+
+	info, err := e.waitForAllocation(ctx, sshClient, locationProps, jobID)
+	if err != nil {
+		if ctx.Err() != nil {
+			// The deployment has been cancelled, give up the pending allocation
+			log.Debugf("%s: Cancellation message has been sent: the pending job allocation (%s) has to be removed", deploymentID, jobID)
+			if err := cancelJobID(jobID, sshClient); err != nil {
+				log.Printf("[Warning] an error occurred during cancelling jobID:%q", jobID)
+			} else {
+				// Drain the related jobID compute attribute
+				deployments.SetInstanceAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "job_id", "")
+			}
+		}
+		return err
+	}
+
+	host := getExecHost(info)
+	if host == "" {
+		return errors.Errorf("PBS returned no execution host for allocation job %q", jobID)
+	}
+	err = deployments.SetInstanceCapabilityAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "endpoint", "ip_address", host)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set capability attribute (ip_address) for node name:%s, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+	err = deployments.SetInstanceAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "ip_address", host)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set attribute (ip_address) for node name:%q, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+	err = deployments.SetInstanceAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "node_name", host)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set attribute (node_name) for node name:%q, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+	err = deployments.SetInstanceAttribute(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "queue", info["queue"])
+	if err != nil {
+		return errors.Wrapf(err, "Failed to set attribute (queue) for node name:%q, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+
+	// Update the instance state
+	return deployments.SetInstanceStateWithContextualLogs(ctx, deploymentID, nodeName, nodeAlloc.instanceName, tosca.NodeStateStarted)
+}
+
+// waitForAllocation waits for a reservation job to run and returns its information
+func (e *defaultExecutor) waitForAllocation(ctx context.Context, sshClient sshutil.Client, locationProps config.DynamicMap, jobID string) (map[string]string, error) {
+	interval := locationProps.GetDurationOrDefault("job_monitoring_time_interval", defaultMonitoringTimeInterval)
+	if interval <= 0 {
+		interval = defaultMonitoringTimeInterval
+	}
+	torque := locationProps.GetBool("torque")
+	for {
+		info, err := getJobInfo(sshClient, jobID, torque)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get allocation job info with jobID:%q", jobID)
+		}
+		state := getJobState(info)
+		switch state {
+		case "RUNNING":
+			return info, nil
+		case "QUEUED", "HELD", "WAITING", "TRANSITING", "MOVED":
+			log.Debugf("PBS allocation job %q is %s", jobID, state)
+		default:
+			return nil, errors.Errorf("allocation job with ID:%q is unexpectedly in state:%q", jobID, state)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (e *defaultExecutor) destroyNodeAllocation(ctx context.Context, sshClient sshutil.Client, nodeAlloc *nodeAllocation, deploymentID, nodeName string) error {
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString(fmt.Sprintf("Destroying node allocation for: deploymentID:%q, node name:%q, instance name:%q", deploymentID, nodeName, nodeAlloc.instanceName))
+
+	jobID, err := deployments.GetInstanceAttributeValue(ctx, deploymentID, nodeName, nodeAlloc.instanceName, "job_id")
+	if err != nil {
+		return errors.Wrapf(err, "Failed to retrieve PBS job ID for node name:%q, instance name:%q", nodeName, nodeAlloc.instanceName)
+	}
+	if jobID == nil || jobID.RawString() == "" {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).Registerf("No job ID found for node name:%q, instance name:%q. We assume it has already been deleted", nodeName, nodeAlloc.instanceName)
+	} else {
+		if err := cancelJobID(jobID.RawString(), sshClient); err != nil {
+			if !strings.Contains(err.Error(), unknownJob) && !strings.Contains(err.Error(), finishedJob) {
+				return err
+			}
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).Registerf("Job ID:%q not found. We assume it has already been deleted", jobID.RawString())
+		} else {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString(fmt.Sprintf("Cancelling Job ID:%q", jobID.RawString()))
+		}
+	}
+	// Update the instance state
+	return deployments.SetInstanceStateWithContextualLogs(ctx, deploymentID, nodeName, nodeAlloc.instanceName, tosca.NodeStateDeleted)
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+// qstatMock returns a mock of SSH client answering to qsub and qstat commands.
+// qstat returns successively the content of the given testdata files, the last one being kept afterwards.
+func qstatMock(t *testing.T, commands *[]string, qstatFiles ...string) *sshutil.MockSSHClient {
+	var mu sync.Mutex
+	var qstatCalls int
+	return &sshutil.MockSSHClient{
+		MockRunCommand: func(cmd string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			*commands = append(*commands, cmd)
+			switch {
+			case strings.Contains(cmd, "qsub"):
+				return "1234.pbsserver\n", nil
+			case strings.HasPrefix(cmd, "qstat"):
+				f := qstatFiles[len(qstatFiles)-1]
+				if qstatCalls < len(qstatFiles) {
+					f = qstatFiles[qstatCalls]
+				}
+				qstatCalls++
+				content, err := ioutil.ReadFile(path.Join("testdata", f))
+				require.NoError(t, err)
+				return string(content), nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func testExecutorCreateNodeAllocation(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := path.Base(t.Name())
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/simplePBSNodeAllocation.yaml")
+	require.NoError(t, err)
+
+	infra := &infrastructure{}
+	err = generateNodeAllocation(ctx, pbsTestLocationProps, deploymentID, "Compute", "0", infra)
+	require.NoError(t, err)
+
+	var commands []string
+	sshClient := qstatMock(t, &commands, "qstat_queued.txt", "qstat_running.txt")
+	locationProps := config.DynamicMap{"job_monitoring_time_interval": "10ms"}
+	e := &defaultExecutor{}
+	err = e.createNodeAllocation(ctx, sshClient, locationProps, infra.nodes[0], deploymentID, "Compute")
+	require.NoError(t, err)
+
+	require.Len(t, commands, 3)
+	require.Equal(t, "echo 'sleep infinity' | qsub -N 'xyz' -l select=1:ncpus=4:mem=1953125kb -l walltime=01:00:00 -q 'workq' -A 'account_test' -j oe -o /dev/null", commands[0])
+	require.Equal(t, "qstat -f -x 1234.pbsserver", commands[1])
+
+	jobID, err := deployments.GetInstanceAttributeValue(ctx, deploymentID, "Compute", "0", "job_id")
+	require.NoError(t, err)
+	require.NotNil(t, jobID)
+	require.Equal(t, "1234.pbsserver", jobID.RawString())
+
+	ipAddress, err := deployments.GetInstanceCapabilityAttributeValue(ctx, deploymentID, "Compute", "0", "endpoint", "ip_address")
+	require.NoError(t, err)
+	require.NotNil(t, ipAddress)
+	require.Equal(t, "node01", ipAddress.RawString())
+
+	queue, err := deployments.GetInstanceAttributeValue(ctx, deploymentID, "Compute", "0", "queue")
+	require.NoError(t, err)
+	require.NotNil(t, queue)
+	require.Equal(t, "workq", queue.RawString())
+
+	state, err := deployments.GetInstanceState(ctx, deploymentID, "Compute", "0")
+	require.NoError(t, err)
+	require.Equal(t, tosca.NodeStateStarted, state)
+}
+
+func testExecutorCreateNodeAllocationFailure(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := path.Base(t.Name())
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/simplePBSNodeAllocationWithoutProps.yaml")
+	require.NoError(t, err)
+
+	infra := &infrastructure{}
+	err = generateNodeAllocation(ctx, pbsTestLocationProps, deploymentID, "Compute", "0", infra)
+	require.NoError(t, err)
+
+	var commands []string
+	sshClient := qstatMock(t, &commands, "qstat_failed.txt")
+	locationProps := config.DynamicMap{"job_monitoring_time_interval": "10ms", "torque": true}
+	e := &defaultExecutor{}
+	err = e.createNodeAllocation(ctx, sshClient, locationProps, infra.nodes[0], deploymentID, "Compute")
+	require.Error(t, err, "an error is expected as the allocation job has failed")
+
+	require.Len(t, commands, 2)
+	require.Equal(t, "echo 'sleep infinity' | qsub -N 'executorCreateNodeAllocationFailure' -l nodes=1 -j oe -o /dev/null", commands[0])
+	require.Equal(t, "qstat -f 1234.pbsserver", commands[1])
+}
+
+func testExecutorDestroyNodeAllocation(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := path.Base(t.Name())
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/simplePBSNodeAllocation.yaml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		jobID      string
+		qdelErr    error
+		wantQdel   bool
+		wantErr    bool
+		qdelOutput string
+	}{
+		{"DeleteAllocation", "1234.pbsserver", nil, true, false, ""},
+		{"AlreadyDeletedAllocation", "1234.pbsserver", errors.New("exit status 153"), true, false, "qdel: Unknown Job Id 1234.pbsserver"},
+		{"QdelFailure", "1234.pbsserver", errors.New("exit status 1"), true, true, "qdel: Server could not connect to MOM"},
+		{"NoJobID", "", nil, false, false, ""},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceName := strings.Repeat("0", i+1)
+			err := deployments.SetInstanceAttribute(ctx, deploymentID, "Compute", instanceName, "job_id", tt.jobID)
+			require.NoError(t, err)
+
+			var commands []string
+			sshClient := &sshutil.MockSSHClient{
+				MockRunCommand: func(cmd string) (string, error) {
+					commands = append(commands, cmd)
+					return tt.qdelOutput, tt.qdelErr
+				},
+			}
+			e := &defaultExecutor{}
+			err = e.destroyNodeAllocation(ctx, sshClient, &nodeAllocation{instanceName: instanceName}, deploymentID, "Compute")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantQdel {
+				require.Equal(t, []string{"qdel " + tt.jobID}, commands)
+			} else {
+				require.Len(t, commands, 0)
+			}
+			state, err := deployments.GetInstanceState(ctx, deploymentID, "Compute", instanceName)
+			require.NoError(t, err)
+			require.Equal(t, tosca.NodeStateDeleted, state)
+		})
+	}
+}
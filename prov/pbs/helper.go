@@ -0,0 +1,377 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/tosca/types"
+)
+
+// reQsub matches a job ID returned by qsub as "1234.pbsserver" or "1234[].pbsserver" for job arrays
+const reQsub = `^(\d+(\[\d*\])?(\.[\w.-]+)?)$`
+
+// Messages returned by qstat and qdel for unknown jobs and for finished jobs which history is not requested
+const unknownJob = "Unknown Job Id"
+const finishedJob = "Job has finished"
+
+type noJobFound struct {
+	msg string
+}
+
+func (jid *noJobFound) Error() string {
+	return jid.msg
+}
+
+func isNoJobFoundError(err error) bool {
+	cause := errors.Cause(err)
+	_, ok := cause.(*noJobFound)
+	return ok
+}
+
+// getSSHClient returns a SSH client with PBS credentials from node or job configuration provided by the deployment,
+// or by the yorc PBS location configuration
+func getSSHClient(cfg config.Configuration, credentials *types.Credential, locationProps config.DynamicMap) (*sshutil.SSHClient, error) {
+	// Check mandatory PBS configuration
+	if err := checkLocationConfig(locationProps); err != nil {
+		log.Printf("Unable to provide SSH client due to:%+v", err)
+		return nil, err
+	}
+	if credentials.Token == "" && len(credentials.Keys) == 0 {
+		return nil, errors.New("PBS missing authentication details in deployment properties, password or private_key should be set")
+	}
+	keys, err := sshutil.GetKeysFromCredentialsDataType(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get SSH client
+	SSHConfig := &ssh.ClientConfig{
+		User:            credentials.User,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         locationProps.GetDurationOrDefault("ssh_connection_timeout", cfg.SSHConnectionTimeout),
+	}
+
+	// Set an authentication method. At least one authentication method
+	// has to be set, private/public key or password.
+	for keyName, pk := range keys {
+		keyAuth, err := sshutil.ReadSSHPrivateKey(pk)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read key %q", keyName)
+		}
+		SSHConfig.Auth = append(SSHConfig.Auth, keyAuth)
+	}
+
+	if credentials.Token != "" {
+		SSHConfig.Auth = append(SSHConfig.Auth, ssh.Password(credentials.Token))
+	}
+
+	port, err := strconv.Atoi(locationProps.GetString("port"))
+	if err != nil {
+		return nil, errors.Wrap(err, "PBS location port is not a valid port")
+	}
+
+	return &sshutil.SSHClient{
+		Config:       SSHConfig,
+		Host:         locationProps.GetString("url"),
+		Port:         port,
+		MaxRetries:   locationProps.GetUint64OrDefault("ssh_connection_max_retries", cfg.SSHConnectionMaxRetries),
+		RetryBackoff: locationProps.GetDurationOrDefault("ssh_connection_retry_backoff", cfg.SSHConnectionRetryBackoff),
+	}, nil
+}
+
+// getUserCredentials returns user credentials from a node property, or a capability property.
+// the property name is provided by propertyName parameter, and its type is supposed to be tosca.datatypes.Credential
+func getUserCredentials(ctx context.Context, locationProps config.DynamicMap, deploymentID, nodeName, capabilityName string) (*types.Credential, error) {
+	var err error
+	var credentialsValue *deployments.TOSCAValue
+	if capabilityName != "" {
+		credentialsValue, err = deployments.GetCapabilityPropertyValue(ctx, deploymentID, nodeName, capabilityName, "credentials")
+	} else {
+		credentialsValue, err = deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "credentials")
+	}
+	if err != nil {
+		return nil, err
+	}
+	creds := new(types.Credential)
+	if credentialsValue != nil && credentialsValue.RawString() != "" {
+		err = mapstructure.Decode(credentialsValue.Value, creds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode credentials for node %q", nodeName)
+		}
+	}
+
+	// Get user credentials provided by the deployment, if any
+	if creds.User != "" {
+		if creds.Token == "" && len(creds.Keys) == 0 {
+			return nil, errors.New("PBS missing authentication details in deployment properties, password or private_key should be set")
+		}
+		return creds, nil
+	}
+
+	// Get user credentials from the location properties
+	if err := checkLocationUserConfig(locationProps); err != nil {
+		log.Printf("Unable to provide SSH client due to:%+v", err)
+		return nil, err
+	}
+	creds.User = strings.TrimSpace(locationProps.GetString("user_name"))
+	creds.User = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("pbs.user_name", creds.User).(string)
+	privateKey := strings.TrimSpace(locationProps.GetString("private_key"))
+	if privateKey != "" {
+		privateKey = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("pbs.private_key", privateKey).(string)
+		if creds.Keys == nil {
+			creds.Keys = make(map[string]string)
+		}
+		creds.Keys["default"] = privateKey
+	}
+	creds.Token = strings.TrimSpace(locationProps.GetString("password"))
+	creds.Token = config.DefaultConfigTemplateResolver.ResolveValueWithTemplates("pbs.password", creds.Token).(string)
+	return creds, nil
+}
+
+// checkLocationConfig checks PBS location mandatory configuration parameters :
+// - url (PBS client's node address)
+// - port (PBS client's node port)
+// returns error in case of inconsistent configuration, or nil if configuration ok
+func checkLocationConfig(locationProps config.DynamicMap) error {
+	if strings.TrimSpace(locationProps.GetString("url")) == "" {
+		return errors.New("PBS location url is not set")
+	}
+
+	if strings.TrimSpace(locationProps.GetString("port")) == "" {
+		return errors.New("PBS location port is not set")
+	}
+
+	return nil
+}
+
+// checkLocationUserConfig checks PBS location configuration parameters related to user credentials
+// necessary for connect using ssh to the PBS client node
+// - user_name
+// - password or private_key
+// returns error in case of inconsistent configuration, or nil if configuration seems ok
+func checkLocationUserConfig(locationProps config.DynamicMap) error {
+	if strings.TrimSpace(locationProps.GetString("user_name")) == "" {
+		return errors.New("PBS location user_name is not set")
+	}
+
+	// Check an authentication method was specified
+	if strings.TrimSpace(locationProps.GetString("password")) == "" &&
+		strings.TrimSpace(locationProps.GetString("private_key")) == "" {
+		return errors.New("PBS location missing authentication details, password or private_key should be set")
+	}
+
+	return nil
+}
+
+// parseJobInfo parses the output of a qstat -f command which is formatted as:
+//
+//	Job Id: 1234.pbsserver
+//	    Job_Name = myjob
+//	    job_state = R
+//	    Variable_List = PBS_O_HOME=/home/john,PBS_O_LOGNAME=john,
+//		PBS_O_PATH=/usr/bin
+//
+// Long values are wrapped on lines starting with a tabulation.
+func parseJobInfo(r io.Reader) (map[string]string, error) {
+	data := make(map[string]string)
+	var lastKey string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+		case strings.HasPrefix(line, "Job Id:"):
+			data["Job_Id"] = strings.TrimSpace(strings.TrimPrefix(line, "Job Id:"))
+			lastKey = ""
+		case strings.HasPrefix(line, "\t") && lastKey != "":
+			data[lastKey] += strings.TrimSpace(line)
+		case strings.Contains(line, " = "):
+			t := strings.SplitN(line, " = ", 2)
+			lastKey = strings.TrimSpace(t[0])
+			data[lastKey] = strings.TrimSpace(t[1])
+		}
+	}
+	return data, scanner.Err()
+}
+
+// getJobInfo returns the information of a job retrieved using qstat.
+// PBS Pro requires the -x flag to get information on finished jobs while Torque keeps them for a while.
+func getJobInfo(client sshutil.Client, jobID string, torque bool) (map[string]string, error) {
+	cmd := fmt.Sprintf("qstat -f -x %s", jobID)
+	if torque {
+		cmd = fmt.Sprintf("qstat -f %s", jobID)
+	}
+	output, err := client.RunCommand(cmd)
+	out := strings.Trim(output, "\" \t\n\x00")
+	if err != nil {
+		if strings.Contains(out, unknownJob) || strings.Contains(out, finishedJob) {
+			return nil, &noJobFound{msg: err.Error()}
+		}
+		return nil, errors.Wrap(err, out)
+	}
+	if out != "" {
+		return parseJobInfo(strings.NewReader(out))
+	}
+	return nil, &noJobFound{msg: fmt.Sprintf("no information found for job with id:%q", jobID)}
+}
+
+// getJobState returns a human readable state of a job from its PBS job_state.
+// Finished jobs are considered as COMPLETED if their exit status is 0 and FAILED otherwise.
+func getJobState(info map[string]string) string {
+	switch info["job_state"] {
+	case "Q":
+		return "QUEUED"
+	case "R":
+		return "RUNNING"
+	case "E":
+		return "EXITING"
+	case "H":
+		return "HELD"
+	case "W":
+		return "WAITING"
+	case "T":
+		return "TRANSITING"
+	case "B":
+		return "BEGUN"
+	case "S", "U":
+		return "SUSPENDED"
+	case "M":
+		return "MOVED"
+	case "F", "C", "X":
+		exitStatus, ok := info["Exit_status"]
+		if !ok {
+			// Torque
+			exitStatus = info["exit_status"]
+		}
+		if exitStatus == "" || exitStatus == "0" {
+			return "COMPLETED"
+		}
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// getExecHost returns the first host on which a job is executed from its exec_host attribute
+// formatted as "node01/0*4+node02/0*4" with PBS Pro or "node01/0+node01/1" with Torque
+func getExecHost(info map[string]string) string {
+	execHost := strings.SplitN(info["exec_host"], "+", 2)[0]
+	return strings.SplitN(execHost, "/", 2)[0]
+}
+
+// getOutputPath returns the path of a job output file from its PBS attribute value formatted as "host:/path/to/file"
+func getOutputPath(value string) string {
+	if i := strings.Index(value, ":"); i >= 0 {
+		return value[i+1:]
+	}
+	return value
+}
+
+func cancelJobID(jobID string, client sshutil.Client) error {
+	qdelCmd := fmt.Sprintf("qdel %s", jobID)
+	qdelOutput, err := client.RunCommand(qdelCmd)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to cancel PBS job: %s:", qdelOutput)
+	}
+	return nil
+}
+
+func retrieveJobID(out string) (string, error) {
+	// expected: "4507.pbsserver" possibly preceded by some logs
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	jobID := strings.TrimSpace(lines[len(lines)-1])
+	if !regexp.MustCompile(reQsub).MatchString(jobID) {
+		return "", errors.Errorf("Unable to parse Job ID from stdout:%q", out)
+	}
+	return jobID, nil
+}
+
+func parseKeyValue(str string) (bool, string, string) {
+	keyVal := strings.Split(str, "=")
+	if len(keyVal) == 2 && strings.TrimSpace(keyVal[0]) != "" && strings.TrimSpace(keyVal[1]) != "" {
+		return true, keyVal[0], keyVal[1]
+	}
+	return false, "", ""
+}
+
+func quoteArgs(t []string) string {
+	var args string
+	for _, v := range t {
+		if !strings.HasPrefix(v, "'") && !strings.HasSuffix(v, "'") {
+			v = strings.Replace(v, "'", "\"", -1)
+			v = "'" + v + "'"
+		}
+		args += v + " "
+	}
+	return args
+}
+
+// Convert scalar-unit size to KiB as kb for PBS
+func toPBSMemFormat(memStr string) (string, error) {
+	mem, err := humanize.ParseBytes(memStr)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to convert to PBS memory format value:%q", memStr)
+	}
+
+	return strconv.FormatUint(mem/1024, 10) + "kb", nil
+}
+
+// buildResourcesOptions returns the qsub options requesting nodes, cpus per node and memory per node resources.
+// PBS Pro resources are requested as chunks using the select statement while Torque uses a nodes specification
+// and a memory limit for the whole job.
+func buildResourcesOptions(nodes int, cpus, mem string, torque bool) (string, error) {
+	if nodes < 1 {
+		nodes = 1
+	}
+	if torque {
+		opts := fmt.Sprintf(" -l nodes=%d", nodes)
+		if cpus != "" {
+			opts += fmt.Sprintf(":ppn=%s", cpus)
+		}
+		if mem != "" {
+			memKb, err := strconv.ParseUint(strings.TrimSuffix(mem, "kb"), 10, 64)
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid memory value %q", mem)
+			}
+			opts += fmt.Sprintf(" -l mem=%dkb", memKb*uint64(nodes))
+		}
+		return opts, nil
+	}
+
+	opts := fmt.Sprintf(" -l select=%d", nodes)
+	if cpus != "" {
+		opts += fmt.Sprintf(":ncpus=%s", cpus)
+	}
+	if mem != "" {
+		opts += fmt.Sprintf(":mem=%s", mem)
+	}
+	return opts, nil
+}
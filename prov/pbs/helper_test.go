@@ -0,0 +1,186 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/helper/sshutil"
+)
+
+func TestParseJobInfo(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open("testdata/qstat_running.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	info, err := parseJobInfo(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1234.pbsserver", info["Job_Id"])
+	assert.Equal(t, "xyz", info["Job_Name"])
+	assert.Equal(t, "R", info["job_state"])
+	assert.Equal(t, "workq", info["queue"])
+	assert.Equal(t, "node01/0*4", info["exec_host"])
+	assert.Equal(t, "1:ncpus=4:mem=2097152kb", info["Resource_List.select"])
+	assert.Equal(t, "PBS_O_HOME=/home/john,PBS_O_LOGNAME=john,PBS_O_WORKDIR=/home/john,PBS_O_QUEUE=workq", info["Variable_List"])
+}
+
+func TestGetJobState(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		info map[string]string
+		want string
+	}{
+		{"Queued", map[string]string{"job_state": "Q"}, "QUEUED"},
+		{"Running", map[string]string{"job_state": "R"}, "RUNNING"},
+		{"Held", map[string]string{"job_state": "H"}, "HELD"},
+		{"Suspended", map[string]string{"job_state": "S"}, "SUSPENDED"},
+		{"PBSProFinished", map[string]string{"job_state": "F", "Exit_status": "0"}, "COMPLETED"},
+		{"PBSProFailed", map[string]string{"job_state": "F", "Exit_status": "271"}, "FAILED"},
+		{"TorqueCompleted", map[string]string{"job_state": "C", "exit_status": "0"}, "COMPLETED"},
+		{"TorqueFailed", map[string]string{"job_state": "C", "exit_status": "-11"}, "FAILED"},
+		{"Unknown", map[string]string{}, "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getJobState(tt.info))
+		})
+	}
+}
+
+func TestGetExecHost(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "node01", getExecHost(map[string]string{"exec_host": "node01/0*4+node02/0*4"}))
+	assert.Equal(t, "node01", getExecHost(map[string]string{"exec_host": "node01/0+node01/1"}))
+	assert.Equal(t, "", getExecHost(map[string]string{}))
+}
+
+func TestGetOutputPath(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "/home/john/xyz.o1234", getOutputPath("login01:/home/john/xyz.o1234"))
+	assert.Equal(t, "/home/john/xyz.o1234", getOutputPath("/home/john/xyz.o1234"))
+}
+
+func TestRetrieveJobID(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		out     string
+		want    string
+		wantErr bool
+	}{
+		{"PBSProJobID", "1234.pbsserver\n", "1234.pbsserver", false},
+		{"TorqueJobID", "1234.torque.example.com", "1234.torque.example.com", false},
+		{"JobArrayID", "1234[].pbsserver", "1234[].pbsserver", false},
+		{"JobIDAfterLogs", "some logs\n1234", "1234", false},
+		{"Error", "qsub: Unknown queue", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := retrieveJobID(tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("retrieveJobID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToPBSMemFormat(t *testing.T) {
+	t.Parallel()
+	mem, err := toPBSMemFormat("1 GiB")
+	require.NoError(t, err)
+	assert.Equal(t, "1048576kb", mem)
+	mem, err = toPBSMemFormat("2 GB")
+	require.NoError(t, err)
+	assert.Equal(t, "1953125kb", mem)
+	_, err = toPBSMemFormat("two gigs")
+	require.Error(t, err)
+}
+
+func TestBuildResourcesOptions(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		nodes   int
+		cpus    string
+		mem     string
+		torque  bool
+		want    string
+		wantErr bool
+	}{
+		{"PBSProDefault", 0, "", "", false, " -l select=1", false},
+		{"PBSProChunks", 2, "4", "1024kb", false, " -l select=2:ncpus=4:mem=1024kb", false},
+		{"TorqueDefault", 1, "", "", true, " -l nodes=1", false},
+		{"TorqueNodes", 2, "4", "1024kb", true, " -l nodes=2:ppn=4 -l mem=2048kb", false},
+		{"TorqueInvalidMemory", 2, "4", "1G", true, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildResourcesOptions(tt.nodes, tt.cpus, tt.mem, tt.torque)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("buildResourcesOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetJobInfo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		torque         bool
+		output         string
+		err            error
+		wantCmd        string
+		wantNoJobFound bool
+		wantErr        bool
+	}{
+		{"PBSProFinishedJob", false, "Job Id: 1234.pbsserver\n    job_state = F\n", nil, "qstat -f -x 1234.pbsserver", false, false},
+		{"TorqueJob", true, "Job Id: 1234.pbsserver\n    job_state = C\n", nil, "qstat -f 1234.pbsserver", false, false},
+		{"UnknownJob", false, "qstat: Unknown Job Id 1234.pbsserver", errors.New("exit status 153"), "qstat -f -x 1234.pbsserver", true, true},
+		{"FinishedJob", false, "qstat: 1234.pbsserver Job has finished, use -x or -H to obtain historical job information", errors.New("exit status 35"), "qstat -f -x 1234.pbsserver", true, true},
+		{"EmptyOutput", false, "", nil, "qstat -f -x 1234.pbsserver", true, true},
+		{"OtherError", false, "qstat: cannot connect to server", errors.New("exit status 1"), "qstat -f -x 1234.pbsserver", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &sshutil.MockSSHClient{
+				MockRunCommand: func(cmd string) (string, error) {
+					assert.Equal(t, tt.wantCmd, cmd)
+					return tt.output, tt.err
+				},
+			}
+			info, err := getJobInfo(s, "1234.pbsserver", tt.torque)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getJobInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.wantNoJobFound, isNoJobFoundError(err))
+			if err == nil {
+				assert.Equal(t, "1234.pbsserver", info["Job_Id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/testutil"
+)
+
+func testActionOperatorAnalyzeJob(t *testing.T, cfg config.Configuration) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/jobMonitoringTest.yaml")
+	require.NoError(t, err)
+
+	cc, err := cfg.GetConsulClient()
+	require.NoError(t, err)
+
+	newAction := func() *prov.Action {
+		return &prov.Action{ActionType: jobMonitoringActionType, Data: map[string]string{
+			"nodeName":   "Job",
+			"jobID":      "1234.pbsserver",
+			"stepName":   "run",
+			"taskID":     "t1",
+			"workingDir": "~",
+			"artifacts":  "b-1234.pbs",
+		}}
+	}
+
+	tests := []struct {
+		name              string
+		torque            bool
+		jobInfoFile       string
+		want              bool
+		wantErr           bool
+		wantState         string
+		wantArtifactsRemo bool
+	}{
+		{"MonitorQueuedJob", false, "qstat_queued.txt", false, false, "QUEUED", false},
+		{"MonitorRunningJob", false, "qstat_running.txt", false, false, "RUNNING", false},
+		{"MonitorCompletedJob", false, "qstat_finished.txt", true, false, "COMPLETED", true},
+		{"MonitorCompletedTorqueJob", true, "qstat_finished.txt", true, false, "COMPLETED", true},
+		{"MonitorFailedJob", false, "qstat_failed.txt", true, true, "FAILED", false},
+		{"JobNotFound", false, "", true, true, "UNKNOWN", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &actionOperator{}
+
+			var mu sync.Mutex
+			var commands []string
+			sshClient := &sshutil.MockSSHClient{
+				MockRunCommand: func(cmd string) (string, error) {
+					mu.Lock()
+					commands = append(commands, cmd)
+					mu.Unlock()
+					if strings.HasPrefix(cmd, "qstat") && tt.jobInfoFile != "" {
+						content, err := ioutil.ReadFile(filepath.Join("testdata", tt.jobInfoFile))
+						require.NoError(t, err)
+						return string(content), nil
+					}
+					return "", nil
+				},
+			}
+
+			got, err := o.analyzeJob(ctx, cc, sshClient, deploymentID, "Job", newAction(), false, tt.torque)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("actionOperator.analyzeJob() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			state, err := deployments.GetInstanceStateString(ctx, deploymentID, "Job", "0")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, state)
+
+			if tt.torque {
+				assert.Equal(t, "qstat -f 1234.pbsserver", commands[0])
+			} else {
+				assert.Equal(t, "qstat -f -x 1234.pbsserver", commands[0])
+			}
+			var outputLogged, artifactsRemoved bool
+			for _, cmd := range commands {
+				outputLogged = outputLogged || strings.Contains(cmd, "tail -n +1 /home/john/xyz.o1234")
+				artifactsRemoved = artifactsRemoved || cmd == "rm -rf ~/b-1234.pbs"
+			}
+			assert.Equal(t, tt.wantState != "QUEUED" && tt.jobInfoFile != "", outputLogged, "unexpected job output logging")
+			assert.Equal(t, tt.wantArtifactsRemo, artifactsRemoved, "unexpected artifacts removal")
+		})
+	}
+}
+
+func Test_getMonitoringJobActionData(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    *actionData
+		wantErr bool
+	}{
+		{"MissingJobID", map[string]string{"stepName": "s1", "workingDir": "~", "taskID": "t1"}, nil, true},
+		{"MissingStepName", map[string]string{"jobID": "1", "workingDir": "~", "taskID": "t1"}, nil, true},
+		{"MissingWorkingDir", map[string]string{"jobID": "1", "stepName": "s1", "taskID": "t1"}, nil, true},
+		{"MissingTaskID", map[string]string{"jobID": "1", "stepName": "s1", "workingDir": "~"}, nil, true},
+		{"NothingMissing", map[string]string{"jobID": "1", "stepName": "s1", "workingDir": "~", "taskID": "t1", "artifacts": "a1,a2"},
+			&actionData{jobID: "1", stepName: "s1", workingDir: "~", taskID: "t1", artifacts: []string{"a1", "a2"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getMonitoringJobActionData(&prov.Action{ActionType: jobMonitoringActionType, Data: tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getMonitoringJobActionData() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
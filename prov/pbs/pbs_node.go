@@ -0,0 +1,106 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+)
+
+func generateNodeAllocation(ctx context.Context, locationProps config.DynamicMap, deploymentID string, nodeName, instanceName string, infra *infrastructure) error {
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	if nodeType != "yorc.nodes.pbs.Compute" {
+		return errors.Errorf("Unsupported node type for %q: %s", nodeName, nodeType)
+	}
+	node := &nodeAllocation{instanceName: instanceName}
+
+	// Set the node CPU and memory property from Tosca Compute 'host' capability property
+	cpu, err := deployments.GetCapabilityPropertyValue(ctx, deploymentID, nodeName, "host", "num_cpus")
+	if err != nil {
+		return err
+	}
+	if cpu != nil {
+		node.cpu = cpu.RawString()
+	}
+
+	memory, err := deployments.GetCapabilityPropertyValue(ctx, deploymentID, nodeName, "host", "mem_size")
+	if err != nil {
+		return err
+	}
+	if memory != nil && memory.RawString() != "" {
+		if node.memory, err = toPBSMemFormat(memory.RawString()); err != nil {
+			return err
+		}
+	}
+
+	// Get user credentials from capability endpoint credentials property, if values are provided
+	node.credentials, err = getUserCredentials(ctx, locationProps, deploymentID, nodeName, "endpoint")
+	if err != nil {
+		return err
+	}
+
+	// Set the job name property
+	// first: with the prop
+	jobName, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "job_name")
+	if err != nil {
+		return err
+	}
+	if jobName == nil || jobName.RawString() == "" {
+		// Second: with the config
+		node.jobName = locationProps.GetString("default_job_name")
+		if node.jobName == "" {
+			// Third: with the deploymentID
+			node.jobName = deploymentID
+		}
+	} else {
+		node.jobName = jobName.RawString()
+	}
+
+	queue, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "queue")
+	if err != nil {
+		return err
+	}
+	if queue != nil {
+		node.queue = queue.RawString()
+	}
+
+	walltime, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "walltime")
+	if err != nil {
+		return err
+	}
+	if walltime != nil {
+		node.walltime = walltime.RawString()
+	}
+
+	account, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "account")
+	if err != nil {
+		return err
+	}
+	if account != nil && account.RawString() != "" {
+		node.account = account.RawString()
+	} else if locationProps.GetBool("enforce_accounting") {
+		return errors.Errorf("Compute account must be set as configuration enforces accounting")
+	}
+
+	infra.nodes = append(infra.nodes, node)
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+)
+
+func loadTestYaml(t *testing.T) string {
+	deploymentID := path.Base(t.Name())
+	yamlName := "testdata/" + deploymentID + ".yaml"
+	err := deployments.StoreDeploymentDefinition(context.Background(), deploymentID, yamlName)
+	require.Nil(t, err, "Failed to parse "+yamlName+" definition")
+	return deploymentID
+}
+
+func testSimplePBSNodeAllocation(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := infrastructure{}
+
+	err := generateNodeAllocation(context.Background(), pbsTestLocationProps, deploymentID, "Compute", "0", &infrastructure)
+	require.Nil(t, err)
+
+	require.Len(t, infrastructure.nodes, 1)
+	require.Equal(t, "0", infrastructure.nodes[0].instanceName)
+	require.Equal(t, "workq", infrastructure.nodes[0].queue)
+	require.Equal(t, "01:00:00", infrastructure.nodes[0].walltime)
+	require.Equal(t, "1953125kb", infrastructure.nodes[0].memory)
+	require.Equal(t, "4", infrastructure.nodes[0].cpu)
+	require.Equal(t, "xyz", infrastructure.nodes[0].jobName)
+	require.Equal(t, "johndoe", infrastructure.nodes[0].credentials.User)
+	require.Equal(t, "passpass", infrastructure.nodes[0].credentials.Token)
+	require.Equal(t, "account_test", infrastructure.nodes[0].account)
+}
+
+func testSimplePBSNodeAllocationWithoutProps(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := infrastructure{}
+
+	err := generateNodeAllocation(context.Background(), pbsTestLocationProps, deploymentID, "Compute", "0", &infrastructure)
+	require.Nil(t, err)
+
+	require.Len(t, infrastructure.nodes, 1)
+	require.Equal(t, "0", infrastructure.nodes[0].instanceName)
+	require.Equal(t, "", infrastructure.nodes[0].queue)
+	require.Equal(t, "", infrastructure.nodes[0].walltime)
+	require.Equal(t, "", infrastructure.nodes[0].memory)
+	require.Equal(t, "", infrastructure.nodes[0].cpu)
+	require.Equal(t, "", infrastructure.nodes[0].account)
+	require.Equal(t, "root", infrastructure.nodes[0].credentials.User)
+	require.Equal(t, "pwd", infrastructure.nodes[0].credentials.Token)
+	require.Equal(t, "simplePBSNodeAllocationWithoutProps", infrastructure.nodes[0].jobName)
+}
+
+func testPBSNodeAllocationEnforceAccounting(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := path.Base(t.Name())
+	err := deployments.StoreDeploymentDefinition(context.Background(), deploymentID, "testdata/simplePBSNodeAllocationWithoutProps.yaml")
+	require.NoError(t, err)
+
+	locationProps := config.DynamicMap{"user_name": "root", "password": "pwd", "enforce_accounting": true}
+	err = generateNodeAllocation(context.Background(), locationProps, deploymentID, "Compute", "0", &infrastructure{})
+	require.Error(t, err, "an error is expected as accounting is enforced and no account is set")
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbs
+
+import (
+	"time"
+
+	"github.com/ystia/yorc/v4/tosca/types"
+)
+
+type infrastructure struct {
+	nodes []*nodeAllocation
+}
+
+type nodeAllocation struct {
+	cpu          string
+	memory       string
+	queue        string
+	walltime     string
+	jobName      string
+	credentials  *types.Credential
+	instanceName string
+	account      string
+}
+
+// executionOptions is backed by the yorc.datatypes.pbs.ExecutionOptions TOSCA data type
+type executionOptions struct {
+	Command         string   `mapstructure:"command" json:"command,omitempty"`
+	Args            []string `mapstructure:"args" json:"args,omitempty"`
+	EnvVars         []string `mapstructure:"env_vars" json:"env_vars,omitempty"`
+	InScriptOptions []string `mapstructure:"in_script_options" json:"in_script_options,omitempty"`
+}
+
+type jobInfo struct {
+	ID                     string            `json:"id,omitempty"`
+	Name                   string            `json:"name,omitempty"`
+	Nodes                  int               `json:"nodes,omitempty"`
+	Cpus                   int               `json:"cpus,omitempty"`
+	Mem                    string            `json:"mem,omitempty"`
+	Walltime               string            `json:"walltime,omitempty"`
+	Queue                  string            `json:"queue,omitempty"`
+	Account                string            `json:"account,omitempty"`
+	Opts                   []string          `json:"opts,omitempty"`
+	ExecutionOptions       executionOptions  `json:"execution_options,omitempty"`
+	Inputs                 map[string]string `json:"inputs,omitempty"`
+	MonitoringTimeInterval time.Duration     `json:"monitoring_time_interval,omitempty"`
+	WorkingDir             string            `json:"working_directory,omitempty"`
+	Artifacts              []string          `json:"artifacts,omitempty"`
+	EnvFile                string            `json:"env_file,omitempty"`
+}
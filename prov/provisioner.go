@@ -49,6 +49,8 @@ type Operation struct {
 	OperationHost string `json:"operation_host,omitempty"`
 	// Inputs parameters provided by the execution context of the operation
 	Inputs map[string]tosca.ParameterDefinition `json:"inputs,omitempty"`
+	// Defines how the operation is run on node instances if set at workflow step level
+	InstancesExecution *tosca.InstancesExecution `json:"instances_execution,omitempty"`
 }
 
 // String implements the fmt.Stringer interface
@@ -126,3 +128,27 @@ type AsyncOperation struct {
 type ActionOperator interface {
 	ExecAction(ctx context.Context, conf config.Configuration, taskID, deploymentID string, action *Action) (deregister bool, err error)
 }
+
+// DriftDetector is an optional interface implemented by delegate executors able to check if the
+// infrastructure of a node differs from the state they recorded when deploying it.
+//
+// DetectDrift never modifies the infrastructure, it returns a report describing the changes that
+// would be required to reconcile the infrastructure with its expected state.
+type DriftDetector interface {
+	DetectDrift(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string) (*DriftReport, error)
+}
+
+// DriftReport is the result of a drift check of a node infrastructure
+type DriftReport struct {
+	NodeName  string    `json:"node_name"`
+	TaskID    string    `json:"task_id,omitempty"`
+	CheckDate time.Time `json:"check_date"`
+	// Drifted is true if the infrastructure differs from its expected state
+	Drifted bool `json:"drifted"`
+	// Changes describes the resources that differ from their expected state
+	Changes []string `json:"changes,omitempty"`
+	// Summary is a human readable summary of the changes
+	Summary string `json:"summary,omitempty"`
+	// Error is the reason why the check failed, if any
+	Error string `json:"error,omitempty"`
+}
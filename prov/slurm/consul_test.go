@@ -74,11 +74,17 @@ func TestRunConsulSlurmPackageTests(t *testing.T) {
 		t.Run("ExecutionCommonBuildJobInfo", func(t *testing.T) {
 			testExecutionCommonBuildJobInfo(t)
 		})
+		t.Run("ExecutionCommonBuildJobInfoWithArrayAndDependencies", func(t *testing.T) {
+			testExecutionCommonBuildJobInfoWithArrayAndDependencies(t)
+		})
 		t.Run("ExecutionCommonPrepareAndSubmitJob", func(t *testing.T) {
 			testExecutionCommonPrepareAndSubmitJob(t)
 		})
 		t.Run("ActionOperatorAnalyzeJob", func(t *testing.T) {
 			testActionOperatorAnalyzeJob(t, srv, cfg)
 		})
+		t.Run("ActionOperatorRetrieveOutputFiles", func(t *testing.T) {
+			testActionOperatorRetrieveOutputFiles(t)
+		})
 	})
 }
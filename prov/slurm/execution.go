@@ -49,6 +49,10 @@ const home = "~"
 const batchScript = "b-%s.batch"
 const srunCommand = "srun"
 
+// arrayIndexInput is the environment variable providing its index to a job array task
+const arrayIndexInput = "ARRAY_INDEX"
+const defaultDependencyType = "afterok"
+
 type execution interface {
 	resolveExecution(ctx context.Context) error
 	executeAsync(ctx context.Context) (*prov.Action, time.Duration, error)
@@ -260,6 +264,9 @@ func (e *executionCommon) buildJobMonitoringAction() *prov.Action {
 	data["nodeName"] = e.NodeName
 	data["workingDir"] = e.jobInfo.WorkingDir
 	data["artifacts"] = strings.Join(e.jobInfo.Artifacts, ",")
+	if len(e.jobInfo.OutputFiles) > 0 {
+		data["outputFiles"] = strings.Join(e.jobInfo.OutputFiles, ",")
+	}
 
 	return &prov.Action{ActionType: "job-monitoring", Data: data}
 }
@@ -343,6 +350,12 @@ func (e *executionCommon) buildJobInfo(ctx context.Context) error {
 			return err
 		}
 	}
+	if array, err := deployments.GetNodePropertyValue(ctx, e.deploymentID, e.NodeName, "slurm_options", "array"); err != nil {
+		return err
+	} else if array != nil {
+		e.jobInfo.Array = strings.TrimSpace(array.RawString())
+	}
+
 	e.jobInfo.Inputs = make(map[string]string)
 	for _, input := range e.EnvInputs {
 		if !strings.Contains(input.Name, "credentials") {
@@ -407,7 +420,71 @@ func (e *executionCommon) buildJobInfo(ctx context.Context) error {
 	if envFile != nil {
 		e.jobInfo.EnvFile = envFile.RawString()
 	}
-	return nil
+
+	if outputFiles, err := deployments.GetNodePropertyValue(ctx, e.deploymentID, e.NodeName, "output_files"); err != nil {
+		return err
+	} else if outputFiles != nil && outputFiles.RawString() != "" {
+		if err = json.Unmarshal([]byte(outputFiles.RawString()), &e.jobInfo.OutputFiles); err != nil {
+			return err
+		}
+	}
+
+	e.jobInfo.Dependency, err = e.buildJobDependency(ctx)
+	return err
+}
+
+// buildJobDependency returns the dependency of the job on jobs targeted by its dependency requirements,
+// formatted as expected by the sbatch --dependency option (ex: "afterok:42:43,afterany:44")
+func (e *executionCommon) buildJobDependency(ctx context.Context) (string, error) {
+	reqs, err := deployments.GetRequirementsByTypeForNode(ctx, e.deploymentID, e.NodeName, "dependency")
+	if err != nil {
+		return "", err
+	}
+
+	var dependencyTypes []string
+	jobIDsByType := make(map[string][]string)
+	for _, req := range reqs {
+		targetType, err := deployments.GetNodeType(ctx, e.deploymentID, req.Node)
+		if err != nil {
+			return "", err
+		}
+		isJob, err := deployments.IsTypeDerivedFrom(ctx, e.deploymentID, targetType, "yorc.nodes.slurm.Job")
+		if err != nil {
+			return "", err
+		}
+		if !isJob {
+			continue
+		}
+
+		// The workflow ensures the target job has been submitted before this one
+		jobID, err := deployments.GetInstanceAttributeValue(ctx, e.deploymentID, req.Node, "0", "job_id")
+		if err != nil {
+			return "", err
+		}
+		if jobID == nil || jobID.RawString() == "" {
+			return "", errors.Errorf("job %q depends on job %q which has not been submitted", e.NodeName, req.Node)
+		}
+
+		dependencyType := defaultDependencyType
+		depType, err := deployments.GetRelationshipPropertyValueFromRequirement(ctx, e.deploymentID, e.NodeName, req.Index, "dependency_type")
+		if err != nil {
+			return "", err
+		}
+		if depType != nil && depType.RawString() != "" {
+			dependencyType = depType.RawString()
+		}
+
+		if _, ok := jobIDsByType[dependencyType]; !ok {
+			dependencyTypes = append(dependencyTypes, dependencyType)
+		}
+		jobIDsByType[dependencyType] = append(jobIDsByType[dependencyType], jobID.RawString())
+	}
+
+	dependencies := make([]string, 0, len(dependencyTypes))
+	for _, dependencyType := range dependencyTypes {
+		dependencies = append(dependencies, dependencyType+":"+strings.Join(jobIDsByType[dependencyType], ":"))
+	}
+	return strings.Join(dependencies, ","), nil
 }
 
 func (e *executionCommon) buildJobOpts() string {
@@ -435,6 +512,12 @@ func (e *executionCommon) buildJobOpts() string {
 	if e.jobInfo.Account != "" {
 		opts += fmt.Sprintf(" --account='%s'", e.jobInfo.Account)
 	}
+	if e.jobInfo.Array != "" {
+		opts += fmt.Sprintf(" --array=%s", shellQuote(e.jobInfo.Array))
+	}
+	if e.jobInfo.Dependency != "" {
+		opts += fmt.Sprintf(" --dependency=%s", shellQuote(e.jobInfo.Dependency))
+	}
 	log.Debugf("opts=%q", opts)
 	return opts
 }
@@ -473,9 +556,9 @@ func (e *executionCommon) wrapCommand(innerCmd string) (string, error) {
 	cat := fmt.Sprintf(`cat <<'EOF' > %s
 #!/bin/bash
 %s
-%s
+%s%s
 EOF
-`, pathScript, e.buildInlineSBatchoptions(), innerCmd)
+`, pathScript, e.buildInlineSBatchoptions(), e.buildArrayIndexExport(), innerCmd)
 	// Ensure generated script removal after its submission
 	return fmt.Sprintf("%s%s%s%ssbatch -D %s%s %s; rm -f %s", e.sourceEnvFile(), e.addWorkingDirCmd(), e.buildEnvVars(), cat, e.jobInfo.WorkingDir, e.buildJobOpts(), pathScript, pathScript), nil
 }
@@ -492,6 +575,37 @@ func (e *executionCommon) buildInlineSBatchoptions() string {
 	return b.String()
 }
 
+// buildArrayIndexExport returns the command providing its index to a job array task
+func (e *executionCommon) buildArrayIndexExport() string {
+	if e.jobInfo.Array == "" {
+		return ""
+	}
+	return fmt.Sprintf("export %s=${SLURM_ARRAY_TASK_ID}\n", arrayIndexInput)
+}
+
+// injectArrayIndexExport adds the array index export to a batch script.
+// The export is inserted after the script header as sbatch stops processing
+// #SBATCH directives at the first line which is neither a comment nor blank.
+func (e *executionCommon) injectArrayIndexExport(script []byte) []byte {
+	export := e.buildArrayIndexExport()
+	if export == "" {
+		return script
+	}
+	lines := strings.SplitAfter(string(script), "\n")
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+	}
+	header := strings.Join(lines[:i], "")
+	if header != "" && !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+	return []byte(header + export + strings.Join(lines[i:], ""))
+}
+
 func (e *executionCommon) addWorkingDirCmd() string {
 	var cmd string
 	if e.jobInfo.WorkingDir != home {
@@ -601,6 +715,9 @@ func (e *executionCommon) uploadArtifact(ctx context.Context, pathFile, artifact
 	if err != nil {
 		return err
 	}
+	if relPath == e.PrimaryFile {
+		source = e.injectArrayIndexExport(source)
+	}
 
 	remotePath := path.Join(e.jobInfo.WorkingDir, relPath)
 	log.Debugf("uploadArtifact file from source path:%q to:%q", pathFile, remotePath)
@@ -55,6 +55,12 @@ func Test_executionCommon_wrapCommand(t *testing.T) {
 		{"TestWithSourceEnvFile", fields{
 			jobInfo: &jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "~", EnvFile: "~/.bash_profile"}},
 			args{"ping -c 3 1.1.1.1"}, regexp.MustCompile(`\[ -f ~/.bash_profile \] && \{ source ~/.bash_profile ; \} ;cat <<'EOF' > ~/b-[-a-f0-9]+.batch\n#!/bin/bash\n\nping -c 3 1.1.1.1\nEOF\nsbatch -D ~ --job-name='MyJob' --nodes=1 ~/b-[-a-f0-9]+.batch; rm -f ~/b-[-a-f0-9]+.batch`), false},
+		{"TestJobArrayWithDependency", fields{
+			jobInfo: &jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "~", Array: "0-15%4", Dependency: "afterok:42"}},
+			args{"ping -c 3 1.1.1.1"}, regexp.MustCompile(`cat <<'EOF' > ~/b-[-a-f0-9]+.batch\n#!/bin/bash\n\nexport ARRAY_INDEX=\$\{SLURM_ARRAY_TASK_ID\}\nping -c 3 1.1.1.1\nEOF\nsbatch -D ~ --job-name='MyJob' --nodes=1 --array='0-15%4' --dependency='afterok:42' ~/b-[-a-f0-9]+.batch; rm -f ~/b-[-a-f0-9]+.batch`), false},
+		{"TestJobArrayWithQuotes", fields{
+			jobInfo: &jobInfo{Name: "MyJob", Nodes: 1, WorkingDir: "~", Array: "1-3'; rm -rf ~; '", Dependency: "afterok:42'"}},
+			args{"ping -c 3 1.1.1.1"}, regexp.MustCompile(regexp.QuoteMeta(`sbatch -D ~ --job-name='MyJob' --nodes=1 --array='1-3'\''; rm -rf ~; '\''' --dependency='afterok:42'\''' ~/b-`)), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -74,6 +80,27 @@ func Test_executionCommon_wrapCommand(t *testing.T) {
 	}
 }
 
+func Test_executionCommon_injectArrayIndexExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		array  string
+		script string
+		want   string
+	}{
+		{"NotAnArray", "", "#!/bin/bash\n#SBATCH --ntasks=1\nhostname\n", "#!/bin/bash\n#SBATCH --ntasks=1\nhostname\n"},
+		{"AfterDirectives", "1-3", "#!/bin/bash\n#SBATCH --ntasks=1\n\n#SBATCH --mem=1G\nhostname\necho done\n",
+			"#!/bin/bash\n#SBATCH --ntasks=1\n\n#SBATCH --mem=1G\nexport ARRAY_INDEX=${SLURM_ARRAY_TASK_ID}\nhostname\necho done\n"},
+		{"WithoutHeader", "1-3", "hostname\n", "export ARRAY_INDEX=${SLURM_ARRAY_TASK_ID}\nhostname\n"},
+		{"OnlyHeaderWithoutFinalNewLine", "1-3", "#!/bin/bash", "#!/bin/bash\nexport ARRAY_INDEX=${SLURM_ARRAY_TASK_ID}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{jobInfo: &jobInfo{Array: tt.array}}
+			assert.Equal(t, tt.want, string(e.injectArrayIndexExport([]byte(tt.script))))
+		})
+	}
+}
+
 func testExecutionCommonBuildJobInfo(t *testing.T) {
 
 	deploymentID := testutil.BuildDeploymentID(t)
@@ -135,6 +162,45 @@ func testExecutionCommonBuildJobInfo(t *testing.T) {
 	}
 }
 
+func testExecutionCommonBuildJobInfoWithArrayAndDependencies(t *testing.T) {
+	deploymentID := testutil.BuildDeploymentID(t)
+	ctx := context.Background()
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/job_array_with_dependencies.yaml")
+	require.NoError(t, err)
+	require.NoError(t, deployments.SetInstanceAttribute(ctx, deploymentID, "PreprocessJob", "0", "job_id", "42"))
+	require.NoError(t, deployments.SetInstanceAttribute(ctx, deploymentID, "CleanupJob", "0", "job_id", "43"))
+
+	tests := []struct {
+		name            string
+		nodeName        string
+		wantErr         bool
+		expectedJobInfo jobInfo
+	}{
+		{"JobArrayWithDependencies", "ArrayJob", false,
+			jobInfo{Name: "ArrayJob", Tasks: 1, Nodes: 1, MonitoringTimeInterval: 5 * time.Second, Inputs: make(map[string]string), WorkingDir: home,
+				ExecutionOptions: types.SlurmExecutionOptions{Command: "hostname"},
+				Array:            "0-15%4", Dependency: "afterok:42,afterany:43", OutputFiles: []string{"results_*.csv", "summary.txt"}}},
+		{"DependencyOnJobNotSubmitted", "DependentOnPendingJob", true, jobInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &executionCommon{
+				locationProps: config.DynamicMap{},
+				deploymentID:  deploymentID,
+				NodeName:      tt.nodeName,
+				EnvInputs:     make([]*operations.EnvInput, 0),
+			}
+			err := e.buildJobInfo(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("executionCommon.buildJobInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.expectedJobInfo, *e.jobInfo)
+			}
+		})
+	}
+}
+
 func testExecutionCommonPrepareAndSubmitJob(t *testing.T) {
 
 	deploymentID := testutil.BuildDeploymentID(t)
@@ -303,6 +303,41 @@ func parseJobInfo(r io.Reader) (map[string]string, error) {
 	return data, nil
 }
 
+// parseJobsInfo parses the output of a scontrol show job command which contains a record per job
+// (several records are returned for job arrays), records being separated by blank lines
+func parseJobsInfo(r io.Reader) ([]map[string]string, error) {
+	var infos []map[string]string
+	var record strings.Builder
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if record.Len() > 0 {
+				info, err := parseJobInfo(strings.NewReader(record.String()))
+				if err != nil {
+					return nil, err
+				}
+				infos = append(infos, info)
+				record.Reset()
+			}
+			continue
+		}
+		record.WriteString(line)
+		record.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if record.Len() > 0 {
+		info, err := parseJobInfo(strings.NewReader(record.String()))
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
 func parseJobID(str string, regexp *regexp.Regexp) (string, error) {
 	subMatch := regexp.FindStringSubmatch(str)
 	if subMatch != nil && len(subMatch) == 2 {
@@ -341,7 +376,8 @@ func parseKeyValue(str string) (bool, string, string) {
 	return false, "", ""
 }
 
-func getJobInfo(client sshutil.Client, jobID string) (map[string]string, error) {
+// getJobsInfo returns the information of a job, or of each task of a job array
+func getJobsInfo(client sshutil.Client, jobID string) ([]map[string]string, error) {
 	cmd := fmt.Sprintf("scontrol show job %s", jobID)
 	output, err := client.RunCommand(cmd)
 	out := strings.Trim(output, "\" \t\n\x00")
@@ -352,7 +388,7 @@ func getJobInfo(client sshutil.Client, jobID string) (map[string]string, error)
 		return nil, errors.Wrap(err, out)
 	}
 	if out != "" {
-		return parseJobInfo(strings.NewReader(out))
+		return parseJobsInfo(strings.NewReader(out))
 	}
 	return nil, &noJobFound{msg: fmt.Sprintf("no information found for job with id:%q", jobID)}
 }
@@ -369,6 +405,23 @@ func quoteArgs(t []string) string {
 	return args
 }
 
+// shellQuote quotes a string so that it is interpreted literally by a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// quotePath quotes a remote path so that it is interpreted literally by a POSIX shell,
+// except for a leading ~ which is expanded to the user's home directory
+func quotePath(p string) string {
+	if p == home {
+		return p
+	}
+	if strings.HasPrefix(p, home+"/") {
+		return home + "/" + shellQuote(strings.TrimPrefix(p, home+"/"))
+	}
+	return shellQuote(p)
+}
+
 // Convert scalar-unit size to Kib as K for Slurm
 func toSlurmMemFormat(memStr string) (string, error) {
 	mem, err := humanize.ParseBytes(memStr)
@@ -354,7 +354,7 @@ func TestGetJobInfoWithInvalidJob(t *testing.T) {
 			return "slurm_load_jobs error: Invalid job id specified", errors.New("")
 		},
 	}
-	info, err := getJobInfo(s, "1234")
+	info, err := getJobsInfo(s, "1234")
 	require.Nil(t, info, "info should be nil")
 
 	require.Equal(t, true, isNoJobFoundError(err), "expected no job found error")
@@ -374,11 +374,11 @@ func TestGetJobInfo(t *testing.T) {
 	require.Nil(t, err, "unexpected error while opening test file")
 	expected, err := parseJobInfo(data)
 	require.Nil(t, err, "Unexpected error parsing job info")
-	info, err := getJobInfo(s, "1234")
+	infos, err := getJobsInfo(s, "1234")
 	require.Nil(t, err, "Unexpected error retrieving job info")
-	require.NotNil(t, info, "info should not be nil")
+	require.Len(t, infos, 1, "expected a single job info")
 
-	require.Equal(t, expected, info, "unexpected job info")
+	require.Equal(t, expected, infos[0], "unexpected job info")
 }
 
 func TestGetJobInfoWithEmptyResponse(t *testing.T) {
@@ -388,7 +388,7 @@ func TestGetJobInfoWithEmptyResponse(t *testing.T) {
 			return "", nil
 		},
 	}
-	info, err := getJobInfo(s, "1234")
+	info, err := getJobsInfo(s, "1234")
 	require.Nil(t, info, "info should be nil")
 
 	require.Equal(t, true, isNoJobFoundError(err), "expected no job found error")
@@ -401,12 +401,33 @@ func TestGetJobInfoWithError(t *testing.T) {
 			return "oups, it's bad", errors.New("this is an error !")
 		},
 	}
-	info, err := getJobInfo(s, "1234")
+	info, err := getJobsInfo(s, "1234")
 	require.Nil(t, info, "info should be nil")
 
 	require.Equal(t, "oups, it's bad: this is an error !", err.Error(), "expected error")
 }
 
+func TestParseJobsInfoWithJobArray(t *testing.T) {
+	t.Parallel()
+	data, err := os.Open("testdata/scontrol_show_job_array_running.txt")
+	require.Nil(t, err, "unexpected error while opening test file")
+	infos, err := parseJobsInfo(data)
+	require.Nil(t, err, "unexpected error while parsing jobs info")
+	require.Len(t, infos, 4, "unexpected number of jobs info")
+	expected := []struct{ jobID, arrayTaskID, jobState string }{
+		{"6265", "3-4", "PENDING"},
+		{"6264", "2", "RUNNING"},
+		{"6263", "1", "FAILED"},
+		{"6262", "0", "COMPLETED"},
+	}
+	for i, e := range expected {
+		require.Equal(t, e.jobID, infos[i]["JobId"], "unexpected value for \"JobId\" key")
+		require.Equal(t, "6262", infos[i]["ArrayJobId"], "unexpected value for \"ArrayJobId\" key")
+		require.Equal(t, e.arrayTaskID, infos[i]["ArrayTaskId"], "unexpected value for \"ArrayTaskId\" key")
+		require.Equal(t, e.jobState, infos[i]["JobState"], "unexpected value for \"JobState\" key")
+	}
+}
+
 func TestToSlurmMemFormat(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -16,6 +16,7 @@ package slurm
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"path"
 	"strconv"
@@ -32,6 +33,7 @@ import (
 	"github.com/ystia/yorc/v4/log"
 	"github.com/ystia/yorc/v4/prov"
 	"github.com/ystia/yorc/v4/prov/scheduling"
+	"github.com/ystia/yorc/v4/tasks"
 )
 
 const bashLogger = `
@@ -41,15 +43,27 @@ fi
 
 `
 
+// listOutputFiles prints the size and the path of existing files matching output files patterns.
+// Patterns are given as quoted words and expanded without field splitting, so they can't run any command.
+const listOutputFiles = `cd %s && IFS= && for p in %s; do for f in $p; do if [ -f "$f" ]; then echo "$(wc -c < "$f") $f"; fi; done; done`
+
+// maxOutputFileSize is the maximum size of a retrieved output file.
+// Task outputs are stored in Consul which limits the size of values.
+const maxOutputFileSize = 256 * 1024
+
+// maxOutputFilesSize is the maximum size of all the output files retrieved for a job
+const maxOutputFilesSize = 1024 * 1024
+
 type actionOperator struct {
 }
 
 type actionData struct {
-	stepName   string
-	jobID      string
-	taskID     string
-	workingDir string
-	artifacts  []string
+	stepName    string
+	jobID       string
+	taskID      string
+	workingDir  string
+	artifacts   []string
+	outputFiles []string
 }
 
 func (o *actionOperator) ExecAction(ctx context.Context, cfg config.Configuration, taskID, deploymentID string, action *prov.Action) (bool, error) {
@@ -113,6 +127,11 @@ func getMonitoringJobActionData(action *prov.Action) (*actionData, error) {
 	if ok {
 		actionData.artifacts = strings.Split(artifactsStr, ",")
 	}
+	// Check output files (optional)
+	outputFilesStr, ok := action.Data["outputFiles"]
+	if ok && outputFilesStr != "" {
+		actionData.outputFiles = strings.Split(outputFilesStr, ",")
+	}
 
 	return actionData, nil
 
@@ -129,7 +148,7 @@ func (o *actionOperator) analyzeJob(ctx context.Context, cc *api.Client, sshClie
 		return true, err
 	}
 
-	info, err := getJobInfo(sshClient, actionData.jobID)
+	infos, err := getJobsInfo(sshClient, actionData.jobID)
 
 	// TODO(loicalbertin): This should be improved instance name should not be hard-coded (https://github.com/ystia/yorc/issues/670)
 	instanceName := "0"
@@ -141,35 +160,37 @@ func (o *actionOperator) analyzeJob(ctx context.Context, cc *api.Client, sshClie
 		}
 		return true, errors.Wrapf(err, "failed to get job info with jobID:%q", actionData.jobID)
 	}
+	info := infos[0]
+	jobArray := isJobArray(infos)
+	if jobArray {
+		info = aggregateJobArrayInfo(infos)
+	}
 	err = o.updateJobAttributes(ctx, deploymentID, nodeName, instanceName, info)
 	if err != nil {
 		return true, errors.Wrapf(err, "failed to update job attributes with jobID: %q", actionData.jobID)
 	}
 
 	var mess string
-	if info["Reason"] != "None" {
+	if jobArray {
+		mess = fmt.Sprintf("Job Array Name:%s, ID:%s, State:%s, Tasks: %s completed, %s running, %s pending, %s failed", info["JobName"], info["JobId"], info["JobState"],
+			info["ArrayTasksCompleted"], info["ArrayTasksRunning"], info["ArrayTasksPending"], info["ArrayTasksFailed"])
+	} else if info["Reason"] != "None" {
 		mess = fmt.Sprintf("Job Name:%s, ID:%s, State:%s, Reason:%s, Execution Time:%s", info["JobName"], info["JobId"], info["JobState"], info["Reason"], info["RunTime"])
 	} else {
 		mess = fmt.Sprintf("Job Name:%s, ID:%s, State:%s, Execution Time:%s", info["JobName"], info["JobId"], info["JobState"], info["RunTime"])
 	}
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString(mess)
 
-	stdOut, existStdOut := info["StdOut"]
-	stdErr, existStdErr := info["StdErr"]
-	if existStdOut && existStdErr && stdOut == stdErr {
-		o.logFile(ctx, cc, action, deploymentID, stdOut, "StdOut/StdErr", sshClient)
-	} else {
-		if existStdOut {
-			o.logFile(ctx, cc, action, deploymentID, stdOut, "StdOut", sshClient)
-		}
-		if existStdErr {
-			o.logFile(ctx, cc, action, deploymentID, stdErr, "StdErr", sshClient)
+	if jobArray {
+		for _, taskInfo := range infos {
+			// Pending tasks have no output yet
+			if taskInfo["JobState"] != "PENDING" {
+				o.logJobOutput(ctx, cc, action, deploymentID, taskInfo, fmt.Sprintf("Array Task %s ", taskInfo["ArrayTaskId"]),
+					fmt.Sprintf("slurm-%s_%s.out", taskInfo["ArrayJobId"], taskInfo["ArrayTaskId"]), sshClient)
+			}
 		}
-	}
-
-	// See default output if nothing is specified here
-	if !existStdOut && !existStdErr {
-		o.logFile(ctx, cc, action, deploymentID, fmt.Sprintf("slurm-%s.out", actionData.jobID), "StdOut/Stderr", sshClient)
+	} else {
+		o.logJobOutput(ctx, cc, action, deploymentID, info, "", fmt.Sprintf("slurm-%s.out", actionData.jobID), sshClient)
 	}
 
 	previousJobState, err := deployments.GetInstanceStateString(ctx, deploymentID, nodeName, instanceName)
@@ -193,7 +214,18 @@ func (o *actionOperator) analyzeJob(ctx context.Context, cc *api.Client, sshClie
 		// Log event containing all the slurm information
 		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(fmt.Sprintf("job info:%+v", info))
 		// Error to be returned
-		err = errors.Errorf("job with ID:%q finished unsuccessfully with state:%q", actionData.jobID, info["JobState"])
+		if jobArray {
+			err = errors.Errorf("job array with ID:%q finished unsuccessfully with %s failed tasks out of %s: %s", actionData.jobID, info["ArrayTasksFailed"], info["ArrayTasksCount"], info["FailedArrayTasks"])
+		} else {
+			err = errors.Errorf("job with ID:%q finished unsuccessfully with state:%q", actionData.jobID, info["JobState"])
+		}
+	}
+
+	// Retrieve output files whatever the job final state as they may help to understand a failure
+	if deregister && len(actionData.outputFiles) > 0 {
+		if retrieveErr := o.retrieveOutputFiles(ctx, deploymentID, nodeName, actionData, sshClient); retrieveErr != nil && err == nil {
+			err = errors.Wrapf(retrieveErr, "failed to retrieve output files of job with ID:%q", actionData.jobID)
+		}
 	}
 
 	// cleanup except if error occurred or explicitly specified in config
@@ -205,6 +237,88 @@ func (o *actionOperator) analyzeJob(ctx context.Context, cc *api.Client, sshClie
 	return deregister, err
 }
 
+// isJobArray returns true if the jobs information describes the tasks of a job array
+func isJobArray(infos []map[string]string) bool {
+	return len(infos) > 0 && infos[0]["ArrayJobId"] != ""
+}
+
+// aggregateJobArrayInfo computes the information of a job array from the information of its tasks.
+// The job array is running as long as one of its tasks is running or pending, it is completed if all its
+// tasks are completed and it has failed otherwise.
+func aggregateJobArrayInfo(infos []map[string]string) map[string]string {
+	info := make(map[string]string, len(infos[0]))
+	for k, v := range infos[0] {
+		info[k] = v
+	}
+	// Remove tasks specific information
+	delete(info, "ArrayTaskId")
+	delete(info, "StdOut")
+	delete(info, "StdErr")
+	info["JobId"] = info["ArrayJobId"]
+
+	var completed, running, pending, failed int
+	var failedTasks []string
+	for _, taskInfo := range infos {
+		nbTasks := countArrayTasks(taskInfo["ArrayTaskId"])
+		switch taskInfo["JobState"] {
+		case "COMPLETED":
+			completed += nbTasks
+		case "PENDING":
+			pending += nbTasks
+		case "RUNNING", "COMPLETING", "CONFIGURING", "SIGNALING", "RESIZING":
+			running += nbTasks
+		default:
+			failed += nbTasks
+			failedTasks = append(failedTasks, fmt.Sprintf("%s(%s)", taskInfo["ArrayTaskId"], taskInfo["JobState"]))
+		}
+	}
+
+	switch {
+	case running > 0:
+		info["JobState"] = "RUNNING"
+	case pending > 0:
+		info["JobState"] = "PENDING"
+	case failed == 0:
+		info["JobState"] = "COMPLETED"
+	default:
+		info["JobState"] = "FAILED"
+	}
+	info["ArrayTasksCount"] = strconv.Itoa(completed + running + pending + failed)
+	info["ArrayTasksCompleted"] = strconv.Itoa(completed)
+	info["ArrayTasksRunning"] = strconv.Itoa(running)
+	info["ArrayTasksPending"] = strconv.Itoa(pending)
+	info["ArrayTasksFailed"] = strconv.Itoa(failed)
+	info["FailedArrayTasks"] = strings.Join(failedTasks, ",")
+	return info
+}
+
+// countArrayTasks returns the number of tasks described by an array task ID
+// which may be a single index or a set of indexes as "3-8:2,10%4" for pending tasks
+func countArrayTasks(arrayTaskID string) int {
+	indexes := strings.SplitN(arrayTaskID, "%", 2)[0]
+	var count int
+	for _, indexRange := range strings.Split(indexes, ",") {
+		step := 1
+		if i := strings.Index(indexRange, ":"); i >= 0 {
+			if s, err := strconv.Atoi(indexRange[i+1:]); err == nil && s > 0 {
+				step = s
+			}
+			indexRange = indexRange[:i]
+		}
+		bounds := strings.SplitN(indexRange, "-", 2)
+		if len(bounds) == 2 {
+			first, errFirst := strconv.Atoi(bounds[0])
+			last, errLast := strconv.Atoi(bounds[1])
+			if errFirst == nil && errLast == nil && last >= first {
+				count += (last-first)/step + 1
+				continue
+			}
+		}
+		count++
+	}
+	return count
+}
+
 func (o *actionOperator) monitorJob(ctx context.Context, cfg config.Configuration, deploymentID string, action *prov.Action) (bool, error) {
 	var (
 		err error
@@ -255,8 +369,86 @@ func (o *actionOperator) removeArtifacts(actionData *actionData, sshClient sshut
 	}
 }
 
+// retrieveOutputFiles stores the contents of the job output files as task outputs
+func (o *actionOperator) retrieveOutputFiles(ctx context.Context, deploymentID, nodeName string, actionData *actionData, sshClient sshutil.Client) error {
+	patterns := make([]string, 0, len(actionData.outputFiles))
+	for _, pattern := range actionData.outputFiles {
+		patterns = append(patterns, shellQuote(pattern))
+	}
+	output, err := sshClient.RunCommand(fmt.Sprintf(listOutputFiles, quotePath(actionData.workingDir), strings.Join(patterns, " ")))
+	if err != nil {
+		return errors.Wrap(err, output)
+	}
+
+	var retrieved, totalSize int
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		sizeAndPath := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(sizeAndPath) != 2 {
+			continue
+		}
+		size, err := strconv.Atoi(sizeAndPath[0])
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse size of output file %q", sizeAndPath[1])
+		}
+		filePath := sizeAndPath[1]
+		if size > maxOutputFileSize {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).RegisterAsString(
+				fmt.Sprintf("Output file %q of size %d bytes exceeds the maximum size of %d bytes and is not retrieved", filePath, size, maxOutputFileSize))
+			continue
+		}
+		if totalSize+size > maxOutputFilesSize {
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).RegisterAsString(
+				fmt.Sprintf("Output file %q is not retrieved as output files of job %q exceed the maximum total size of %d bytes", filePath, actionData.jobID, maxOutputFilesSize))
+			continue
+		}
+		totalSize += size
+
+		encoded, err := sshClient.RunCommand(fmt.Sprintf("cd %s && base64 %s", quotePath(actionData.workingDir), shellQuote(filePath)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read output file %q: %s", filePath, encoded)
+		}
+		content, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode output file %q", filePath)
+		}
+		outputName := nodeName + "-" + strings.Replace(filePath, "/", "_", -1)
+		err = tasks.SetTaskData(actionData.taskID, path.Join("outputs", outputName), string(content))
+		if err != nil {
+			return err
+		}
+		log.Debugf("Output file %q of job %q stored as task output %q", filePath, actionData.jobID, outputName)
+		retrieved++
+	}
+
+	if retrieved == 0 {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelWARN, deploymentID).RegisterAsString(
+			fmt.Sprintf("No output file retrieved for job %q matching %q", actionData.jobID, strings.Join(actionData.outputFiles, ",")))
+	}
+	return nil
+}
+
+func (o *actionOperator) logJobOutput(ctx context.Context, cc *api.Client, action *prov.Action, deploymentID string, info map[string]string, fileTypePrefix, defaultOutput string, sshClient sshutil.Client) {
+	stdOut, existStdOut := info["StdOut"]
+	stdErr, existStdErr := info["StdErr"]
+	if existStdOut && existStdErr && stdOut == stdErr {
+		o.logFile(ctx, cc, action, deploymentID, stdOut, fileTypePrefix+"StdOut/StdErr", sshClient)
+	} else {
+		if existStdOut {
+			o.logFile(ctx, cc, action, deploymentID, stdOut, fileTypePrefix+"StdOut", sshClient)
+		}
+		if existStdErr {
+			o.logFile(ctx, cc, action, deploymentID, stdErr, fileTypePrefix+"StdErr", sshClient)
+		}
+	}
+
+	// See default output if nothing is specified here
+	if !existStdOut && !existStdErr {
+		o.logFile(ctx, cc, action, deploymentID, defaultOutput, fileTypePrefix+"StdOut/Stderr", sshClient)
+	}
+}
+
 func (o *actionOperator) logFile(ctx context.Context, cc *api.Client, action *prov.Action, deploymentID, filePath, fileType string, sshClient sshutil.Client) {
-	fileTypeKey := fmt.Sprintf("lastIndex%s", strings.Replace(fileType, "/", "", -1))
+	fileTypeKey := fmt.Sprintf("lastIndex%s", strings.NewReplacer("/", "", " ", "").Replace(fileType))
 	// Get the log last index
 	lastInd, err := o.getLogLastIndex(action, fileTypeKey)
 	if err != nil {
@@ -16,16 +16,22 @@ package slurm
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	ctu "github.com/hashicorp/consul/testutil"
+	"github.com/pkg/errors"
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/deployments"
 	"github.com/ystia/yorc/v4/helper/sshutil"
 	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tasks"
 	"github.com/ystia/yorc/v4/testutil"
 	"gotest.tools/v3/assert"
 )
@@ -86,6 +92,27 @@ func testActionOperatorAnalyzeJob(t *testing.T, srv *ctu.TestServer, cfg config.
 			"taskID":     "t1",
 			"workingDir": filepath.Join(cfg.WorkingDirectory, t.Name()),
 		}}, keepArtifacts: false}, "", true, true},
+		{"MonitorRunningJobArray", args{deploymentID: deploymentID, nodeName: "Job", action: &prov.Action{ActionType: "job-monitoring", Data: map[string]string{
+			"nodeName":   "Job",
+			"jobID":      "6262",
+			"stepName":   "run",
+			"taskID":     "t1",
+			"workingDir": filepath.Join(cfg.WorkingDirectory, t.Name()),
+		}}, keepArtifacts: false}, "scontrol_show_job_array_running.txt", false, false},
+		{"MonitorCompletedJobArray", args{deploymentID: deploymentID, nodeName: "Job", action: &prov.Action{ActionType: "job-monitoring", Data: map[string]string{
+			"nodeName":   "Job",
+			"jobID":      "6262",
+			"stepName":   "run",
+			"taskID":     "t1",
+			"workingDir": filepath.Join(cfg.WorkingDirectory, t.Name()),
+		}}, keepArtifacts: false}, "scontrol_show_job_array_completed.txt", true, false},
+		{"MonitorPartiallyFailedJobArray", args{deploymentID: deploymentID, nodeName: "Job", action: &prov.Action{ActionType: "job-monitoring", Data: map[string]string{
+			"nodeName":   "Job",
+			"jobID":      "6262",
+			"stepName":   "run",
+			"taskID":     "t1",
+			"workingDir": filepath.Join(cfg.WorkingDirectory, t.Name()),
+		}}, keepArtifacts: false}, "scontrol_show_job_array_failed.txt", true, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -158,6 +185,13 @@ func Test_getMonitoringJobActionData(t *testing.T) {
 			"taskID":     "t1",
 			"artifacts":  "b1,a2",
 		}}}, &actionData{jobID: "1", stepName: "s1", workingDir: "~", taskID: "t1", artifacts: []string{"b1", "a2"}}, false},
+		{"WithOutputFiles", args{&prov.Action{Data: map[string]string{
+			"jobID":       "1",
+			"stepName":    "s1",
+			"workingDir":  "~",
+			"taskID":      "t1",
+			"outputFiles": "results_*.csv,summary.txt",
+		}}}, &actionData{jobID: "1", stepName: "s1", workingDir: "~", taskID: "t1", outputFiles: []string{"results_*.csv", "summary.txt"}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -172,3 +206,91 @@ func Test_getMonitoringJobActionData(t *testing.T) {
 		})
 	}
 }
+
+func Test_aggregateJobArrayInfo(t *testing.T) {
+	tests := []struct {
+		name                 string
+		jobInfoFile          string
+		wantJobState         string
+		wantTasksCount       string
+		wantFailedTasks      string
+		wantFailedTasksCount string
+	}{
+		{"RunningJobArray", "scontrol_show_job_array_running.txt", "RUNNING", "5", "1(FAILED)", "1"},
+		{"CompletedJobArray", "scontrol_show_job_array_completed.txt", "COMPLETED", "3", "", "0"},
+		{"PartiallyFailedJobArray", "scontrol_show_job_array_failed.txt", "FAILED", "3", "2(TIMEOUT),1(FAILED)", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.Open(filepath.Join("testdata", tt.jobInfoFile))
+			assert.NilError(t, err)
+			defer data.Close()
+			infos, err := parseJobsInfo(data)
+			assert.NilError(t, err)
+			assert.Assert(t, isJobArray(infos))
+
+			info := aggregateJobArrayInfo(infos)
+			assert.Equal(t, "6262", info["JobId"])
+			assert.Equal(t, tt.wantJobState, info["JobState"])
+			assert.Equal(t, tt.wantTasksCount, info["ArrayTasksCount"])
+			assert.Equal(t, tt.wantFailedTasks, info["FailedArrayTasks"])
+			assert.Equal(t, tt.wantFailedTasksCount, info["ArrayTasksFailed"])
+			_, exist := info["ArrayTaskId"]
+			assert.Assert(t, !exist, "array task ID should not be part of the job array info")
+		})
+	}
+}
+
+func Test_countArrayTasks(t *testing.T) {
+	tests := []struct {
+		arrayTaskID string
+		want        int
+	}{
+		{"3", 1},
+		{"3-8", 6},
+		{"3-8%2", 6},
+		{"1,3,5", 3},
+		{"0-15:4", 4},
+		{"0-3,7,10-11%2", 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arrayTaskID, func(t *testing.T) {
+			assert.Equal(t, tt.want, countArrayTasks(tt.arrayTaskID))
+		})
+	}
+}
+
+func testActionOperatorRetrieveOutputFiles(t *testing.T) {
+	taskID := "t-" + testutil.BuildDeploymentID(t)
+	summary := "3 items processed\n"
+	actionData := &actionData{jobID: "42", taskID: taskID, workingDir: "~/my job", outputFiles: []string{"results/*.csv", "summary.txt", "$(rm -rf ~)"}}
+
+	sshClient := &sshutil.MockSSHClient{
+		MockRunCommand: func(cmd string) (string, error) {
+			switch {
+			case strings.HasPrefix(cmd, `cd ~/'my job' && IFS= && for p in 'results/*.csv' 'summary.txt' '$(rm -rf ~)';`):
+				return fmt.Sprintf("%d results/a.csv\n%d results/huge.csv\n%d summary.txt\n%d it's.txt\n%d big-1.txt\n%d big-2.txt\n%d big-3.txt\n",
+					4, maxOutputFileSize+1, len(summary), maxOutputFileSize, maxOutputFileSize, maxOutputFileSize, maxOutputFileSize), nil
+			case cmd == "cd ~/'my job' && base64 'results/a.csv'":
+				return base64.StdEncoding.EncodeToString([]byte("a,b\n")), nil
+			case cmd == "cd ~/'my job' && base64 'summary.txt'":
+				return base64.StdEncoding.EncodeToString([]byte(summary)), nil
+			case cmd == `cd ~/'my job' && base64 'it'\''s.txt'`:
+				return base64.StdEncoding.EncodeToString([]byte("quoted")), nil
+			case strings.HasPrefix(cmd, "cd ~/'my job' && base64 'big-"):
+				return base64.StdEncoding.EncodeToString([]byte("big")), nil
+			}
+			return "", errors.Errorf("unexpected command %q", cmd)
+		},
+	}
+
+	o := &actionOperator{}
+	err := o.retrieveOutputFiles(context.Background(), "dep", "Job", actionData, sshClient)
+	assert.NilError(t, err)
+
+	outputs, err := tasks.GetTaskOutputs(taskID)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, map[string]string{"Job-results_a.csv": "a,b\n", "Job-summary.txt": summary, "Job-it's.txt": "quoted",
+		// the third big file exceeds the maximum total size
+		"Job-big-1.txt": "big", "Job-big-2.txt": "big"}, outputs)
+}
@@ -54,4 +54,7 @@ type jobInfo struct {
 	WorkingDir             string                      `json:"working_directory,omitempty"`
 	Artifacts              []string                    `json:"artifacts,omitempty"`
 	EnvFile                string                      `json:"env_file,omitempty"`
+	Array                  string                      `json:"array,omitempty"`
+	Dependency             string                      `json:"dependency,omitempty"`
+	OutputFiles            []string                    `json:"output_files,omitempty"`
 }
@@ -75,18 +75,32 @@ func (g *awsGenerator) generateAWSInstance(ctx context.Context, cfg config.Confi
 	}
 	instance.KeyName = keyName.RawString()
 
-	// security_groups needs to contain a least one occurrence
+	// Security groups are either existing ones listed in security_groups or yorc.nodes.aws.SecurityGroup nodes
 	secGroups, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "security_groups")
 	if err != nil {
 		return err
 	}
-	if secGroups == nil || secGroups.RawString() == "" {
-		return errors.Errorf("Missing mandatory parameter 'security_groups' node type for %s", nodeName)
+	if secGroups != nil && secGroups.RawString() != "" {
+		for _, secGroup := range strings.Split(strings.NewReplacer("\"", "", "'", "").Replace(secGroups.RawString()), ",") {
+			secGroup = strings.TrimSpace(secGroup)
+			instance.SecurityGroups = append(instance.SecurityGroups, secGroup)
+		}
+	}
+
+	instance.VPCSecurityGroupIDs, err = getSecurityGroupIDs(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	// security groups need to contain a least one occurrence
+	if len(instance.SecurityGroups) == 0 && len(instance.VPCSecurityGroupIDs) == 0 {
+		return errors.Errorf("Missing mandatory parameter 'security_groups' or security_group requirement for %s", nodeName)
 	}
 
-	for _, secGroup := range strings.Split(strings.NewReplacer("\"", "", "'", "").Replace(secGroups.RawString()), ",") {
-		secGroup = strings.TrimSpace(secGroup)
-		instance.SecurityGroups = append(instance.SecurityGroups, secGroup)
+	// Optional subnet on which to create the compute instance
+	instance.SubnetID, err = getSubnetID(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
 	}
 
 	// Get connection info (user, private key)
@@ -252,6 +266,49 @@ func addAttachedDisks(ctx context.Context, cfg config.Configuration, deploymentI
 	return nil
 }
 
+func getSecurityGroupIDs(ctx context.Context, deploymentID, nodeName string) ([]string, error) {
+	secGroupReqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, nodeName, "security_group")
+	if err != nil {
+		return nil, err
+	}
+
+	var securityGroupIDs []string
+	for _, secGroupReq := range secGroupReqs {
+		securityGroupID, err := deployments.LookupInstanceAttributeValue(ctx, deploymentID, secGroupReq.Node, "0", "security_group_id")
+		if err != nil {
+			return nil, err
+		}
+		securityGroupIDs = append(securityGroupIDs, securityGroupID)
+	}
+	return securityGroupIDs, nil
+}
+
+func getSubnetID(ctx context.Context, deploymentID, nodeName string) (string, error) {
+	networkReqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, nodeName, "network")
+	if err != nil {
+		return "", err
+	}
+
+	var subnetNode string
+	for _, networkReq := range networkReqs {
+		networkType, err := deployments.GetNodeType(ctx, deploymentID, networkReq.Node)
+		if err != nil {
+			return "", err
+		}
+		if networkType != "yorc.nodes.aws.Subnet" {
+			continue
+		}
+		if subnetNode != "" {
+			return "", errors.Errorf("Compute %q can't be connected to several subnets: %q and %q", nodeName, subnetNode, networkReq.Node)
+		}
+		subnetNode = networkReq.Node
+	}
+	if subnetNode == "" {
+		return "", nil
+	}
+	return deployments.LookupInstanceAttributeValue(ctx, deploymentID, subnetNode, "0", "subnet_id")
+}
+
 func associateEIP(infrastructure *commons.Infrastructure, instance *ComputeInstance, providedEIP string) string {
 	// Add the association EIP/Instance
 	eipAssociation := ElasticIPAssociation{InstanceID: fmt.Sprintf("${aws_instance.%s.id}", instance.Tags.Name)}
@@ -373,3 +373,29 @@ func testSimpleAWSInstanceWithPersistentDisk(t *testing.T, cfg config.Configurat
 	assert.Equal(t, "/dev/sdf", attachedDisk.DeviceName)
 
 }
+
+func testSimpleAWSInstanceWithSubnetAndSecurityGroup(t *testing.T, cfg config.Configuration, srv *testutil.TestServer) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	g := awsGenerator{}
+	infrastructure := commons.Infrastructure{}
+	env := make([]string, 0)
+	outputs := make(map[string]string)
+
+	// Simulate the subnet "subnet_id" and security group "security_group_id" attributes registration
+	srv.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID+"/topology/instances/Subnet/0/attributes/subnet_id"):                []byte("subnet-1234"),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID+"/topology/instances/SecurityGroup/0/attributes/security_group_id"): []byte("sg-1234"),
+	})
+
+	err := g.generateAWSInstance(context.Background(), cfg, deploymentID, "ComputeAWS", "0", &infrastructure, outputs, &env)
+	require.NoError(t, err)
+
+	instancesMap := infrastructure.Resource["aws_instance"].(map[string]interface{})
+	require.Len(t, instancesMap, 1)
+	compute, ok := instancesMap["ComputeAWS-0"].(*ComputeInstance)
+	require.True(t, ok, "ComputeAWS-0 is not a ComputeInstance")
+	assert.Equal(t, "subnet-1234", compute.SubnetID)
+	assert.Equal(t, []string{"sg-1234"}, compute.VPCSecurityGroupIDs)
+	assert.Len(t, compute.SecurityGroups, 0)
+}
@@ -76,5 +76,17 @@ func TestRunConsulAWSPackageTests(t *testing.T) {
 		t.Run("simpleAWSInstanceWithPersistentDisk", func(t *testing.T) {
 			testSimpleAWSInstanceWithPersistentDisk(t, cfg, srv)
 		})
+		t.Run("simpleAWSInstanceWithSubnetAndSecurityGroup", func(t *testing.T) {
+			testSimpleAWSInstanceWithSubnetAndSecurityGroup(t, cfg, srv)
+		})
+		t.Run("simpleVPC", func(t *testing.T) {
+			testSimpleVPC(t, cfg)
+		})
+		t.Run("simpleSubnet", func(t *testing.T) {
+			testSimpleSubnet(t, cfg, srv)
+		})
+		t.Run("simpleSecurityGroup", func(t *testing.T) {
+			testSimpleSecurityGroup(t, cfg)
+		})
 	})
 }
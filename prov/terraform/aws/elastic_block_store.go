@@ -17,7 +17,6 @@ package aws
 import (
 	"context"
 	"fmt"
-	"github.com/pkg/errors"
 	"path"
 	"strings"
 
@@ -129,22 +128,6 @@ func (g *awsGenerator) getEBSProperties(ctx context.Context, nodeParams nodePara
 	}
 
 	// Get tags map
-	tagsVal, err := deployments.GetNodePropertyValue(ctx, nodeParams.deploymentID, nodeParams.nodeName, "tags")
-	if tagsVal != nil && tagsVal.RawString() != "" {
-		d, ok := tagsVal.Value.(map[string]interface{})
-		if !ok {
-			return errors.New("failed to retrieve tags map from Tosca Value: not expected type")
-		}
-
-		ebs.Tags = make(map[string]string, len(d))
-		for k, v := range d {
-			v, ok := v.(string)
-			if !ok {
-				return errors.Errorf("failed to retrieve string value from tags map from Tosca Value:%q not expected type", v)
-			}
-			ebs.Tags[k] = v
-		}
-	}
-
-	return nil
+	ebs.Tags, err = getTagsProperty(ctx, nodeParams)
+	return err
 }
@@ -136,6 +136,21 @@ func (g *awsGenerator) generateInstances(ctx context.Context, cfg config.Configu
 			}
 		case "yorc.nodes.aws.PublicNetwork":
 			// Nothing to do
+		case "yorc.nodes.aws.VPC":
+			err = g.generateVPC(ctx, cfg, *nodeParams, outputs)
+			if err != nil {
+				return err
+			}
+		case "yorc.nodes.aws.Subnet":
+			err = g.generateSubnet(ctx, cfg, *nodeParams, outputs)
+			if err != nil {
+				return err
+			}
+		case "yorc.nodes.aws.SecurityGroup":
+			err = g.generateSecurityGroup(ctx, cfg, *nodeParams, outputs)
+			if err != nil {
+				return err
+			}
 		case "yorc.nodes.aws.EBSVolume":
 			err = g.generateEBS(ctx, *nodeParams, instanceName, instNb, outputs)
 			if err != nil {
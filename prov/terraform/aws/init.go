@@ -20,7 +20,11 @@ import (
 	"github.com/ystia/yorc/v4/registry"
 )
 
+const awsDeploymentArtifact = "yorc.artifacts.aws.Deployment"
+
 func init() {
 	reg := registry.GetRegistry()
 	reg.RegisterDelegates([]string{`yorc\.nodes\.aws\..*`}, terraform.NewExecutor(&awsGenerator{}, commons.PreDestroyStorageInfraCallback), registry.BuiltinOrigin)
+	reg.RegisterOperationExecutor(
+		[]string{awsDeploymentArtifact}, &defaultExecutor{generator: &awsGenerator{}}, registry.BuiltinOrigin)
 }
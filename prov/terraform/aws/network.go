@@ -0,0 +1,184 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *awsGenerator) generateVPC(ctx context.Context, cfg config.Configuration, nodeParams nodeParams, outputs map[string]string) error {
+	err := verifyThatNodeIsTypeOf(ctx, nodeParams, "yorc.nodes.aws.VPC")
+	if err != nil {
+		return err
+	}
+
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, nodeParams.deploymentID, "topology", "instances", nodeParams.nodeName, "0")
+	vpcKey := nodeParams.nodeName + "-vpc"
+
+	// Use existing VPC if defined with vpc_id
+	vpcID, err := deployments.GetStringNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, "vpc_id", false)
+	if err != nil {
+		return err
+	}
+	if vpcID != "" {
+		commons.AddOutput(nodeParams.infrastructure, vpcKey, &commons.Output{Value: vpcID})
+		outputs[path.Join(nodeInstanceKey, "/attributes/vpc_id")] = vpcKey
+		return nil
+	}
+
+	vpc := &VPC{}
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&vpc.CIDRBlock, "cidr_block", true},
+		{&vpc.InstanceTenancy, "instance_tenancy", false},
+	}
+	for _, stringParam := range stringParams {
+		*stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, stringParam.propertyName, stringParam.mandatory)
+		if err != nil {
+			return err
+		}
+	}
+
+	boolParams := []struct {
+		pAttr        *bool
+		propertyName string
+	}{
+		{&vpc.EnableDNSSupport, "enable_dns_support"},
+		{&vpc.EnableDNSHostnames, "enable_dns_hostnames"},
+	}
+	for _, boolParam := range boolParams {
+		*boolParam.pAttr, err = deployments.GetBooleanNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, boolParam.propertyName)
+		if err != nil {
+			return err
+		}
+	}
+
+	vpc.Tags, err = getResourceTags(ctx, cfg, nodeParams)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(nodeParams.nodeName)
+	log.Debugf("Add VPC:%+v", vpc)
+	commons.AddResource(nodeParams.infrastructure, "aws_vpc", name, vpc)
+
+	// Route the VPC traffic to the Internet if required
+	internetGateway, err := deployments.GetBooleanNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, "internet_gateway")
+	if err != nil {
+		return err
+	}
+	if internetGateway {
+		igw := &InternetGateway{
+			VPCID: fmt.Sprintf("${aws_vpc.%s.id}", name),
+			Tags:  map[string]string{"Name": vpc.Tags["Name"]},
+		}
+		commons.AddResource(nodeParams.infrastructure, "aws_internet_gateway", name, igw)
+
+		route := &Route{
+			RouteTableID:         fmt.Sprintf("${aws_vpc.%s.main_route_table_id}", name),
+			DestinationCIDRBlock: "0.0.0.0/0",
+			GatewayID:            fmt.Sprintf("${aws_internet_gateway.%s.id}", name),
+		}
+		commons.AddResource(nodeParams.infrastructure, "aws_route", name+"-default-route", route)
+	}
+
+	commons.AddOutput(nodeParams.infrastructure, vpcKey, &commons.Output{Value: fmt.Sprintf("${aws_vpc.%s.id}", name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/vpc_id")] = vpcKey
+	return nil
+}
+
+func (g *awsGenerator) generateSubnet(ctx context.Context, cfg config.Configuration, nodeParams nodeParams, outputs map[string]string) error {
+	err := verifyThatNodeIsTypeOf(ctx, nodeParams, "yorc.nodes.aws.Subnet")
+	if err != nil {
+		return err
+	}
+
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, nodeParams.deploymentID, "topology", "instances", nodeParams.nodeName, "0")
+	subnet := &Subnet{}
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&subnet.CIDRBlock, "cidr_block", true},
+		{&subnet.AvailabilityZone, "availability_zone", false},
+	}
+	for _, stringParam := range stringParams {
+		*stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, stringParam.propertyName, stringParam.mandatory)
+		if err != nil {
+			return err
+		}
+	}
+
+	subnet.MapPublicIPOnLaunch, err = deployments.GetBooleanNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, "map_public_ip_on_launch")
+	if err != nil {
+		return err
+	}
+
+	subnet.VPCID, err = getVPCID(ctx, nodeParams)
+	if err != nil {
+		return err
+	}
+	if subnet.VPCID == "" {
+		return errors.Errorf("failed to retrieve the VPC of subnet %q: either the vpc_id property or a dependency on a yorc.nodes.aws.VPC node should be defined", nodeParams.nodeName)
+	}
+
+	subnet.Tags, err = getResourceTags(ctx, cfg, nodeParams)
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(nodeParams.nodeName)
+	log.Debugf("Add subnet:%+v", subnet)
+	commons.AddResource(nodeParams.infrastructure, "aws_subnet", name, subnet)
+
+	subnetKey := nodeParams.nodeName + "-subnet"
+	commons.AddOutput(nodeParams.infrastructure, subnetKey, &commons.Output{Value: fmt.Sprintf("${aws_subnet.%s.id}", name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/subnet_id")] = subnetKey
+
+	vpcKey := nodeParams.nodeName + "-vpc"
+	commons.AddOutput(nodeParams.infrastructure, vpcKey, &commons.Output{Value: subnet.VPCID})
+	outputs[path.Join(nodeInstanceKey, "/attributes/vpc_id")] = vpcKey
+	return nil
+}
+
+// getVPCID returns the VPC ID either set by user or retrieved via a dependency relationship with a VPC node.
+// An empty string is returned if none is defined.
+func getVPCID(ctx context.Context, nodeParams nodeParams) (string, error) {
+	vpcID, err := deployments.GetStringNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, "vpc_id", false)
+	if err != nil || vpcID != "" {
+		return vpcID, err
+	}
+
+	hasDep, vpcNode, err := deployments.HasAnyRequirementFromNodeType(ctx, nodeParams.deploymentID, nodeParams.nodeName, "dependency", "yorc.nodes.aws.VPC")
+	if err != nil || !hasDep {
+		return "", err
+	}
+	return deployments.LookupInstanceAttributeValue(ctx, nodeParams.deploymentID, vpcNode, "0", "vpc_id")
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func testSimpleVPC(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := commons.Infrastructure{}
+	outputs := make(map[string]string)
+	g := awsGenerator{}
+	nodeParams := nodeParams{
+		deploymentID:   deploymentID,
+		nodeName:       "VPC",
+		infrastructure: &infrastructure,
+	}
+
+	err := g.generateVPC(context.Background(), cfg, nodeParams, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate VPC for %s", deploymentID)
+
+	require.Len(t, infrastructure.Resource["aws_vpc"], 1, "Expected one VPC")
+	instancesMap := infrastructure.Resource["aws_vpc"].(map[string]interface{})
+	require.Contains(t, instancesMap, "vpc")
+	vpc, ok := instancesMap["vpc"].(*VPC)
+	require.True(t, ok, "vpc is not a VPC")
+	assert.Equal(t, "10.0.0.0/16", vpc.CIDRBlock)
+	assert.Equal(t, "dedicated", vpc.InstanceTenancy)
+	assert.Equal(t, true, vpc.EnableDNSSupport)
+	assert.Equal(t, true, vpc.EnableDNSHostnames)
+	assert.Equal(t, map[string]string{"env": "test", "Name": cfg.ResourcesPrefix + "VPC"}, vpc.Tags)
+
+	require.Len(t, infrastructure.Resource["aws_internet_gateway"], 1, "Expected one internet gateway")
+	igwMap := infrastructure.Resource["aws_internet_gateway"].(map[string]interface{})
+	igw, ok := igwMap["vpc"].(*InternetGateway)
+	require.True(t, ok, "vpc is not an InternetGateway")
+	assert.Equal(t, "${aws_vpc.vpc.id}", igw.VPCID)
+
+	require.Len(t, infrastructure.Resource["aws_route"], 1, "Expected one route")
+	routesMap := infrastructure.Resource["aws_route"].(map[string]interface{})
+	route, ok := routesMap["vpc-default-route"].(*Route)
+	require.True(t, ok, "vpc-default-route is not a Route")
+	assert.Equal(t, "${aws_vpc.vpc.main_route_table_id}", route.RouteTableID)
+	assert.Equal(t, "0.0.0.0/0", route.DestinationCIDRBlock)
+	assert.Equal(t, "${aws_internet_gateway.vpc.id}", route.GatewayID)
+
+	assert.Equal(t, map[string]string{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/VPC/0/attributes/vpc_id"): "VPC-vpc",
+	}, outputs)
+	assert.Equal(t, "${aws_vpc.vpc.id}", infrastructure.Output["VPC-vpc"].Value)
+
+	// Existing VPC
+	infrastructure = commons.Infrastructure{}
+	outputs = make(map[string]string)
+	nodeParams.nodeName = "ExistingVPC"
+	nodeParams.infrastructure = &infrastructure
+	err = g.generateVPC(context.Background(), cfg, nodeParams, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate VPC for %s", deploymentID)
+	assert.Len(t, infrastructure.Resource, 0, "No resource expected for an existing VPC")
+	assert.Equal(t, "vpc-0a1b2c3d", infrastructure.Output["ExistingVPC-vpc"].Value)
+
+	// VPC without internet gateway
+	infrastructure = commons.Infrastructure{}
+	outputs = make(map[string]string)
+	nodeParams.nodeName = "PrivateVPC"
+	nodeParams.infrastructure = &infrastructure
+	err = g.generateVPC(context.Background(), cfg, nodeParams, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate VPC for %s", deploymentID)
+	require.Len(t, infrastructure.Resource["aws_vpc"], 1, "Expected one VPC")
+	vpc, ok = infrastructure.Resource["aws_vpc"].(map[string]interface{})["privatevpc"].(*VPC)
+	require.True(t, ok, "privatevpc is not a VPC")
+	assert.Equal(t, map[string]string{"Name": "my-private-vpc"}, vpc.Tags)
+	assert.NotContains(t, infrastructure.Resource, "aws_internet_gateway")
+	assert.NotContains(t, infrastructure.Resource, "aws_route")
+}
+
+func testSimpleSubnet(t *testing.T, cfg config.Configuration, srv *testutil.TestServer) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := commons.Infrastructure{}
+	outputs := make(map[string]string)
+	g := awsGenerator{}
+	nodeParams := nodeParams{
+		deploymentID:   deploymentID,
+		nodeName:       "Subnet",
+		infrastructure: &infrastructure,
+	}
+
+	// Simulate the VPC "vpc_id" attribute registration
+	srv.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/VPC/0/attributes/vpc_id"): []byte("vpc-1234"),
+	})
+
+	err := g.generateSubnet(context.Background(), cfg, nodeParams, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate subnet for %s", deploymentID)
+
+	require.Len(t, infrastructure.Resource["aws_subnet"], 1, "Expected one subnet")
+	instancesMap := infrastructure.Resource["aws_subnet"].(map[string]interface{})
+	subnet, ok := instancesMap["subnet"].(*Subnet)
+	require.True(t, ok, "subnet is not a Subnet")
+	assert.Equal(t, "vpc-1234", subnet.VPCID)
+	assert.Equal(t, "10.0.1.0/24", subnet.CIDRBlock)
+	assert.Equal(t, "us-east-2c", subnet.AvailabilityZone)
+	assert.Equal(t, true, subnet.MapPublicIPOnLaunch)
+	assert.Equal(t, map[string]string{"Name": cfg.ResourcesPrefix + "Subnet"}, subnet.Tags)
+
+	instancePrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/Subnet/0/attributes")
+	assert.Equal(t, map[string]string{
+		path.Join(instancePrefix, "subnet_id"): "Subnet-subnet",
+		path.Join(instancePrefix, "vpc_id"):    "Subnet-vpc",
+	}, outputs)
+	assert.Equal(t, "${aws_subnet.subnet.id}", infrastructure.Output["Subnet-subnet"].Value)
+	assert.Equal(t, "vpc-1234", infrastructure.Output["Subnet-vpc"].Value)
+
+	// VPC defined by property
+	infrastructure = commons.Infrastructure{}
+	nodeParams.nodeName = "SubnetWithVPCID"
+	nodeParams.infrastructure = &infrastructure
+	err = g.generateSubnet(context.Background(), cfg, nodeParams, make(map[string]string))
+	require.NoError(t, err, "Unexpected error attempting to generate subnet for %s", deploymentID)
+	subnet, ok = infrastructure.Resource["aws_subnet"].(map[string]interface{})["subnetwithvpcid"].(*Subnet)
+	require.True(t, ok, "subnetwithvpcid is not a Subnet")
+	assert.Equal(t, "vpc-0a1b2c3d", subnet.VPCID)
+	assert.Equal(t, false, subnet.MapPublicIPOnLaunch)
+
+	// No VPC
+	infrastructure = commons.Infrastructure{}
+	nodeParams.nodeName = "SubnetWithoutVPC"
+	nodeParams.infrastructure = &infrastructure
+	err = g.generateSubnet(context.Background(), cfg, nodeParams, make(map[string]string))
+	require.Error(t, err, "Expected an error for a subnet without VPC")
+}
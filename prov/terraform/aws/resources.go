@@ -16,15 +16,17 @@ package aws
 
 // A ComputeInstance represent an AWS compute
 type ComputeInstance struct {
-	ImageID          string      `json:"ami,omitempty"`
-	InstanceType     string      `json:"instance_type,omitempty"`
-	AvailabilityZone string      `json:"availability_zone,omitempty"`
-	PlacementGroup   string      `json:"placement_group,omitempty"`
-	SecurityGroups   []string    `json:"security_groups,omitempty"`
-	KeyName          string      `json:"key_name,omitempty"`
-	Tags             Tags        `json:"tags,omitempty"`
-	ElasticIps       []string    `json:"-"`
-	RootBlockDevice  BlockDevice `json:"root_block_device,omitempty"`
+	ImageID             string      `json:"ami,omitempty"`
+	InstanceType        string      `json:"instance_type,omitempty"`
+	AvailabilityZone    string      `json:"availability_zone,omitempty"`
+	PlacementGroup      string      `json:"placement_group,omitempty"`
+	SecurityGroups      []string    `json:"security_groups,omitempty"`
+	VPCSecurityGroupIDs []string    `json:"vpc_security_group_ids,omitempty"`
+	SubnetID            string      `json:"subnet_id,omitempty"`
+	KeyName             string      `json:"key_name,omitempty"`
+	Tags                Tags        `json:"tags,omitempty"`
+	ElasticIps          []string    `json:"-"`
+	RootBlockDevice     BlockDevice `json:"root_block_device,omitempty"`
 
 	Provisioners map[string]interface{} `json:"provisioner,omitempty"`
 }
@@ -71,3 +73,58 @@ type VolumeAttachment struct {
 	InstanceID string `json:"instance_id,omitempty"`
 	VolumeID   string `json:"volume_id,omitempty"`
 }
+
+// VPC represents an AWS Virtual Private Cloud
+// see : https://www.terraform.io/docs/providers/aws/r/vpc.html
+type VPC struct {
+	CIDRBlock          string            `json:"cidr_block"`
+	InstanceTenancy    string            `json:"instance_tenancy,omitempty"`
+	EnableDNSSupport   bool              `json:"enable_dns_support"`
+	EnableDNSHostnames bool              `json:"enable_dns_hostnames"`
+	Tags               map[string]string `json:"tags,omitempty"`
+}
+
+// InternetGateway provides a way to route the traffic of a VPC to the Internet
+// see : https://www.terraform.io/docs/providers/aws/r/internet_gateway.html
+type InternetGateway struct {
+	VPCID string            `json:"vpc_id"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// Route represents an entry of a VPC routing table
+// see : https://www.terraform.io/docs/providers/aws/r/route.html
+type Route struct {
+	RouteTableID         string `json:"route_table_id"`
+	DestinationCIDRBlock string `json:"destination_cidr_block"`
+	GatewayID            string `json:"gateway_id,omitempty"`
+}
+
+// Subnet represents a subnet of a VPC
+// see : https://www.terraform.io/docs/providers/aws/r/subnet.html
+type Subnet struct {
+	VPCID               string            `json:"vpc_id"`
+	CIDRBlock           string            `json:"cidr_block"`
+	AvailabilityZone    string            `json:"availability_zone,omitempty"`
+	MapPublicIPOnLaunch bool              `json:"map_public_ip_on_launch"`
+	Tags                map[string]string `json:"tags,omitempty"`
+}
+
+// SecurityGroup represents an AWS Security Group
+// see : https://www.terraform.io/docs/providers/aws/r/security_group.html
+type SecurityGroup struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	VPCID       string              `json:"vpc_id,omitempty"`
+	Ingress     []SecurityGroupRule `json:"ingress,omitempty"`
+	Egress      []SecurityGroupRule `json:"egress,omitempty"`
+	Tags        map[string]string   `json:"tags,omitempty"`
+}
+
+// SecurityGroupRule is an ingress or egress rule of a Security Group
+type SecurityGroupRule struct {
+	FromPort   int      `json:"from_port"`
+	ToPort     int      `json:"to_port"`
+	Protocol   string   `json:"protocol"`
+	CIDRBlocks []string `json:"cidr_blocks,omitempty"`
+	Self       bool     `json:"self,omitempty"`
+}
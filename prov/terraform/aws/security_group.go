@@ -0,0 +1,101 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *awsGenerator) generateSecurityGroup(ctx context.Context, cfg config.Configuration, nodeParams nodeParams, outputs map[string]string) error {
+	err := verifyThatNodeIsTypeOf(ctx, nodeParams, "yorc.nodes.aws.SecurityGroup")
+	if err != nil {
+		return err
+	}
+
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, nodeParams.deploymentID, "topology", "instances", nodeParams.nodeName, "0")
+	// Security group names must be unique within a VPC
+	securityGroup := &SecurityGroup{Name: cfg.ResourcesPrefix + nodeParams.deploymentID + "-" + nodeParams.nodeName}
+
+	securityGroup.Description, err = deployments.GetStringNodeProperty(ctx, nodeParams.deploymentID, nodeParams.nodeName, "description", false)
+	if err != nil {
+		return err
+	}
+	if securityGroup.Description == "" {
+		securityGroup.Description = fmt.Sprintf("Security group %s of deployment %s", nodeParams.nodeName, nodeParams.deploymentID)
+	}
+
+	// Use the default VPC if none is defined
+	securityGroup.VPCID, err = getVPCID(ctx, nodeParams)
+	if err != nil {
+		return err
+	}
+
+	securityGroup.Tags, err = getResourceTags(ctx, cfg, nodeParams)
+	if err != nil {
+		return err
+	}
+
+	securityGroup.Ingress, err = getSecurityGroupIngressRules(ctx, nodeParams.deploymentID, nodeParams.nodeName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compute ingress rules of security group %q", nodeParams.nodeName)
+	}
+	// Terraform removes the AWS default egress rule allowing all outbound traffic, so define it explicitly
+	securityGroup.Egress = []SecurityGroupRule{
+		{FromPort: 0, ToPort: 0, Protocol: "-1", CIDRBlocks: []string{"0.0.0.0/0"}},
+	}
+
+	name := strings.ToLower(nodeParams.nodeName)
+	log.Debugf("Add security group:%+v", securityGroup)
+	commons.AddResource(nodeParams.infrastructure, "aws_security_group", name, securityGroup)
+
+	securityGroupKey := nodeParams.nodeName + "-security-group"
+	commons.AddOutput(nodeParams.infrastructure, securityGroupKey, &commons.Output{Value: fmt.Sprintf("${aws_security_group.%s.id}", name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/security_group_id")] = securityGroupKey
+	return nil
+}
+
+// getSecurityGroupIngressRules computes ingress rules of a security group from the endpoint capabilities
+// of Computes requiring this security group and of nodes hosted on these Computes.
+//
+// Public endpoints are opened to any address while other endpoints are opened to members of the security group only.
+func getSecurityGroupIngressRules(ctx context.Context, deploymentID, securityGroupNode string) ([]SecurityGroupRule, error) {
+	endpointRules, err := commons.GetSecurityGroupEndpointRules(ctx, deploymentID, securityGroupNode)
+	if err != nil {
+		return nil, err
+	}
+
+	ingress := make([]SecurityGroupRule, 0, len(endpointRules))
+	for _, endpointRule := range endpointRules {
+		rule := SecurityGroupRule{FromPort: endpointRule.Port, ToPort: endpointRule.Port, Protocol: endpointRule.Protocol}
+		if endpointRule.Public {
+			rule.CIDRBlocks = []string{"0.0.0.0/0"}
+		} else {
+			rule.Self = true
+		}
+		ingress = append(ingress, rule)
+	}
+	return ingress, nil
+}
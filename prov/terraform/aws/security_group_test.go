@@ -0,0 +1,79 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func testSimpleSecurityGroup(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := commons.Infrastructure{}
+	outputs := make(map[string]string)
+	g := awsGenerator{}
+	nodeParams := nodeParams{
+		deploymentID:   deploymentID,
+		nodeName:       "SecurityGroup",
+		infrastructure: &infrastructure,
+	}
+
+	err := g.generateSecurityGroup(context.Background(), cfg, nodeParams, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate security group for %s", deploymentID)
+
+	require.Len(t, infrastructure.Resource["aws_security_group"], 1, "Expected one security group")
+	instancesMap := infrastructure.Resource["aws_security_group"].(map[string]interface{})
+	securityGroup, ok := instancesMap["securitygroup"].(*SecurityGroup)
+	require.True(t, ok, "securitygroup is not a SecurityGroup")
+	assert.Equal(t, cfg.ResourcesPrefix+deploymentID+"-SecurityGroup", securityGroup.Name)
+	assert.Equal(t, "Web application security group", securityGroup.Description)
+	assert.Equal(t, "vpc-0a1b2c3d", securityGroup.VPCID)
+	assert.Equal(t, map[string]string{"env": "test", "Name": cfg.ResourcesPrefix + "SecurityGroup"}, securityGroup.Tags)
+
+	assert.Equal(t, []SecurityGroupRule{
+		{FromPort: 22, ToPort: 22, Protocol: "tcp", CIDRBlocks: []string{"0.0.0.0/0"}},
+		{FromPort: 7946, ToPort: 7946, Protocol: "udp", Self: true},
+		{FromPort: 8080, ToPort: 8080, Protocol: "tcp", CIDRBlocks: []string{"0.0.0.0/0"}},
+	}, securityGroup.Ingress)
+	assert.Equal(t, []SecurityGroupRule{
+		{FromPort: 0, ToPort: 0, Protocol: "-1", CIDRBlocks: []string{"0.0.0.0/0"}},
+	}, securityGroup.Egress)
+
+	assert.Equal(t, map[string]string{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/SecurityGroup/0/attributes/security_group_id"): "SecurityGroup-security-group",
+	}, outputs)
+	assert.Equal(t, "${aws_security_group.securitygroup.id}", infrastructure.Output["SecurityGroup-security-group"].Value)
+
+	// Security group not required by any Compute, in the default VPC
+	infrastructure = commons.Infrastructure{}
+	nodeParams.nodeName = "UnusedSecurityGroup"
+	nodeParams.infrastructure = &infrastructure
+	err = g.generateSecurityGroup(context.Background(), cfg, nodeParams, make(map[string]string))
+	require.NoError(t, err, "Unexpected error attempting to generate security group for %s", deploymentID)
+	securityGroup, ok = infrastructure.Resource["aws_security_group"].(map[string]interface{})["unusedsecuritygroup"].(*SecurityGroup)
+	require.True(t, ok, "unusedsecuritygroup is not a SecurityGroup")
+	assert.Equal(t, "", securityGroup.VPCID)
+	assert.Equal(t, "Security group UnusedSecurityGroup of deployment "+deploymentID, securityGroup.Description)
+	assert.Len(t, securityGroup.Ingress, 0)
+}
@@ -16,7 +16,10 @@ package aws
 
 import (
 	"context"
+
 	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/deployments"
 )
 
@@ -30,3 +33,44 @@ func verifyThatNodeIsTypeOf(ctx context.Context, nodeParams nodeParams, nodeType
 	}
 	return nil
 }
+
+// getTagsProperty returns the map of tags defined in the tags property of a node
+func getTagsProperty(ctx context.Context, nodeParams nodeParams) (map[string]string, error) {
+	tagsVal, err := deployments.GetNodePropertyValue(ctx, nodeParams.deploymentID, nodeParams.nodeName, "tags")
+	if err != nil {
+		return nil, err
+	}
+	if tagsVal == nil || tagsVal.RawString() == "" {
+		return nil, nil
+	}
+	d, ok := tagsVal.Value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("failed to retrieve tags map from Tosca Value: not expected type")
+	}
+
+	tags := make(map[string]string, len(d))
+	for k, v := range d {
+		v, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("failed to retrieve string value from tags map from Tosca Value:%q not expected type", v)
+		}
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+// getResourceTags returns the tags of a resource defined in the tags property of a node,
+// adding a Name tag if not defined by the user
+func getResourceTags(ctx context.Context, cfg config.Configuration, nodeParams nodeParams) (map[string]string, error) {
+	tags, err := getTagsProperty(ctx, nodeParams)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	if _, ok := tags["Name"]; !ok {
+		tags["Name"] = cfg.ResourcesPrefix + nodeParams.nodeName
+	}
+	return tags, nil
+}
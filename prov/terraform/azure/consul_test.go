@@ -0,0 +1,62 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/locations"
+	"github.com/ystia/yorc/v4/testutil"
+)
+
+// The aim of this function is to run all package tests with consul server dependency with only one consul server start
+func TestRunConsulAzurePackageTests(t *testing.T) {
+	cfg := testutil.SetupTestConfig(t)
+	srv, _ := testutil.NewTestConsulInstance(t, &cfg)
+	defer func() {
+		srv.Stop()
+		os.RemoveAll(cfg.WorkingDirectory)
+	}()
+
+	locationMgr, err := locations.GetManager(cfg)
+	require.NoError(t, err, "Error initializing locations")
+
+	t.Run("groupAzure", func(t *testing.T) {
+		t.Run("simpleVirtualMachine", func(t *testing.T) {
+			testSimpleVirtualMachine(t, cfg)
+		})
+		t.Run("virtualMachineMissingImage", func(t *testing.T) {
+			testVirtualMachineMissingImage(t, cfg)
+		})
+		t.Run("virtualMachineWithResources", func(t *testing.T) {
+			testVirtualMachineWithResources(t, cfg)
+		})
+		t.Run("simpleNetwork", func(t *testing.T) {
+			testSimpleNetwork(t, cfg)
+		})
+		t.Run("simpleManagedDisk", func(t *testing.T) {
+			testSimpleManagedDisk(t, cfg)
+		})
+		t.Run("simplePublicIP", func(t *testing.T) {
+			testSimplePublicIP(t, cfg)
+		})
+		t.Run("generateTerraformInfraForAzureNode", func(t *testing.T) {
+			testGenerateTerraformInfraForAzureNode(t, cfg, locationMgr)
+		})
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/locations"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of generated infrastructures")
+
+// testLocationProps are the azure location properties used by tests
+var testLocationProps = config.DynamicMap{
+	"subscription_id": "sub1",
+	"client_id":       "client1",
+	"client_secret":   "secret1",
+	"tenant_id":       "tenant1",
+	"resource_group":  "yorc-rg",
+	"region":          "westeurope",
+}
+
+func loadTestYaml(t *testing.T) string {
+	deploymentID := path.Base(t.Name())
+	yamlName := "testdata/" + deploymentID + ".yaml"
+	err := deployments.StoreDeploymentDefinition(context.Background(), deploymentID, yamlName)
+	require.Nil(t, err, "Failed to parse "+yamlName+" definition")
+	return deploymentID
+}
+
+// assertGoldenInfrastructure checks a generated infrastructure and its outputs against
+// the golden file testdata/<test name>.golden.json.
+// Run tests with -update to regenerate golden files.
+func assertGoldenInfrastructure(t *testing.T, infrastructure *commons.Infrastructure, outputs map[string]string) {
+	t.Helper()
+	actual, err := json.MarshalIndent(struct {
+		Infrastructure *commons.Infrastructure `json:"infrastructure"`
+		Outputs        map[string]string       `json:"outputs"`
+	}{infrastructure, outputs}, "", "  ")
+	require.NoError(t, err)
+
+	goldenFile := filepath.Join("testdata", path.Base(t.Name())+".golden.json")
+	if *updateGolden {
+		require.NoError(t, ioutil.WriteFile(goldenFile, append(actual, '\n'), 0664))
+	}
+	expected, err := ioutil.ReadFile(goldenFile)
+	require.NoError(t, err, "Failed to read golden file, run tests with -update to create it")
+	assert.JSONEq(t, string(expected), string(actual))
+}
+
+func testGenerateTerraformInfraForAzureNode(t *testing.T, cfg config.Configuration, locationMgr locations.Manager) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+
+	err := locationMgr.CreateLocation(
+		locations.LocationConfiguration{
+			Name:       "testAzureLocation",
+			Type:       infrastructureType,
+			Properties: testLocationProps,
+		})
+	require.NoError(t, err, "Failed to create a location")
+	defer func() {
+		locationMgr.RemoveLocation("testAzureLocation")
+	}()
+
+	cfg.Terraform.AzureRMPluginVersionConstraint = "~> 1.44"
+	g := azureGenerator{}
+	infrastructurePath, err := ioutil.TempDir("", deploymentID)
+	require.NoError(t, err, "Failed to to create temporary directory")
+	defer os.RemoveAll(infrastructurePath)
+
+	_, outputs, env, _, err := g.GenerateTerraformInfraForNode(
+		context.Background(), cfg, deploymentID, "Network", infrastructurePath)
+	require.NoError(t, err, "Unexpected error generating terraform infra")
+
+	assert.Equal(t, []string{
+		"ARM_SUBSCRIPTION_ID=sub1",
+		"ARM_CLIENT_ID=client1",
+		"ARM_CLIENT_SECRET=secret1",
+		"ARM_TENANT_ID=tenant1",
+	}, env)
+	assert.Len(t, outputs, 1)
+
+	content, err := ioutil.ReadFile(filepath.Join(infrastructurePath, "infra.tf.json"))
+	require.NoError(t, err, "Expected a generated infra.tf.json file")
+	var infrastructure commons.Infrastructure
+	require.NoError(t, json.Unmarshal(content, &infrastructure))
+	require.Contains(t, infrastructure.Provider, "azurerm")
+	assert.Equal(t, map[string]interface{}{"version": "~> 1.44"}, infrastructure.Provider["azurerm"])
+	require.Contains(t, infrastructure.Resource, "azurerm_virtual_network")
+
+	_, _, _, _, err = g.GenerateTerraformInfraForNode(
+		context.Background(), cfg, deploymentID, "Unsupported", infrastructurePath)
+	require.Error(t, err, "Expected an error on an unsupported node type")
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/helper/sizeutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *azureGenerator) generateManagedDisk(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName, instanceName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	instancesKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName)
+
+	var size, volumes string
+	disk := &ManagedDisk{Name: getResourceName(cfg, deploymentID, nodeName, instanceName)}
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&volumes, "volume_id", false},
+		{&disk.SourceResourceID, "snapshot_id", false},
+		{&disk.StorageAccountType, "storage_account_type", true},
+		{&size, "size", false},
+	}
+
+	var err error
+	for _, stringParam := range stringParams {
+		if *stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName,
+			stringParam.propertyName, stringParam.mandatory); err != nil {
+			return err
+		}
+	}
+
+	// An existing disk may be provided for each instance
+	var volumeID string
+	if volumes != "" {
+		instanceID, err := strconv.Atoi(instanceName)
+		if err != nil {
+			return err
+		}
+		tabVol := strings.Split(volumes, ",")
+		if len(tabVol) > instanceID {
+			volumeID = strings.TrimSpace(tabVol[instanceID])
+		}
+	}
+
+	if volumeID == "" {
+		disk.ResourceGroupName, disk.Location, err = getResourceGroupAndRegion(ctx, locationProps, deploymentID, nodeName)
+		if err != nil {
+			return err
+		}
+		disk.CreateOption = "Empty"
+		if disk.SourceResourceID != "" {
+			disk.CreateOption = "Copy"
+		}
+		if size != "" {
+			// Default size unit is MB
+			log.Debugf("Initial size property value (default is MB): %q", size)
+			if disk.DiskSizeGB, err = sizeutil.ConvertToGB(size); err != nil {
+				return err
+			}
+			log.Debugf("Computed size (in GB): %d", disk.DiskSizeGB)
+		}
+		if disk.Zones, err = getZones(ctx, deploymentID, nodeName); err != nil {
+			return err
+		}
+		if disk.Tags, err = deployments.GetKeyValuePairsNodeProperty(ctx, deploymentID, nodeName, "tags"); err != nil {
+			return err
+		}
+
+		commons.AddResource(infrastructure, "azurerm_managed_disk", disk.Name, disk)
+		volumeID = fmt.Sprintf("${azurerm_managed_disk.%s.id}", disk.Name)
+	}
+
+	// Provide output for attribute volume_id
+	volumeKey := nodeName + "-" + instanceName + "-volume"
+	commons.AddOutput(infrastructure, volumeKey, &commons.Output{Value: volumeID})
+	outputs[path.Join(instancesKey, instanceName, "/attributes/volume_id")] = volumeKey
+	return nil
+}
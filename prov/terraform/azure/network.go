@@ -0,0 +1,187 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *azureGenerator) generateVirtualNetwork(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName, "0")
+	networkKey := nodeName + "-network"
+
+	existingNetwork, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "network_name", false)
+	if err != nil {
+		return err
+	}
+	// Use existing virtual network if defined with network_name
+	if existingNetwork != "" {
+		commons.AddOutput(infrastructure, networkKey, &commons.Output{Value: existingNetwork})
+		outputs[path.Join(nodeInstanceKey, "/attributes/network_name")] = networkKey
+		return nil
+	}
+
+	network := &VirtualNetwork{Name: getResourceName(cfg, deploymentID, nodeName)}
+	network.ResourceGroupName, network.Location, err = getResourceGroupAndRegion(ctx, locationProps, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	var addressSpace, dnsServers string
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&addressSpace, "address_space", true},
+		{&dnsServers, "dns_servers", false},
+	}
+
+	for _, stringParam := range stringParams {
+		if *stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName,
+			stringParam.propertyName, stringParam.mandatory); err != nil {
+			return err
+		}
+	}
+	network.AddressSpace = splitList(addressSpace)
+	network.DNSServers = splitList(dnsServers)
+
+	if network.Tags, err = deployments.GetKeyValuePairsNodeProperty(ctx, deploymentID, nodeName, "tags"); err != nil {
+		return err
+	}
+
+	commons.AddResource(infrastructure, "azurerm_virtual_network", network.Name, network)
+
+	commons.AddOutput(infrastructure, networkKey, &commons.Output{Value: fmt.Sprintf("${azurerm_virtual_network.%s.name}", network.Name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/network_name")] = networkKey
+	return nil
+}
+
+func (g *azureGenerator) generateSubnet(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName, "0")
+
+	subnet := &Subnet{Name: getResourceName(cfg, deploymentID, nodeName)}
+	var err error
+	if subnet.ResourceGroupName, err = getResourceGroup(ctx, locationProps, deploymentID, nodeName); err != nil {
+		return err
+	}
+	if subnet.AddressPrefix, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "address_prefix", true); err != nil {
+		return err
+	}
+
+	// The virtual network is either provided by a dependency on a VirtualNetwork node or by its name
+	hasNetworkReq, networkNode, err := deployments.HasAnyRequirementFromNodeType(ctx, deploymentID, nodeName, "virtual_network", "yorc.nodes.azure.VirtualNetwork")
+	if err != nil {
+		return err
+	}
+	if hasNetworkReq {
+		subnet.VirtualNetworkName, err = deployments.LookupInstanceAttributeValue(ctx, deploymentID, networkNode, "0", "network_name")
+	} else {
+		subnet.VirtualNetworkName, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "network_name", false)
+	}
+	if err != nil {
+		return err
+	}
+	if subnet.VirtualNetworkName == "" {
+		return errors.Errorf("Missing virtual network for subnet %q: expected a virtual_network requirement or a network_name property", nodeName)
+	}
+
+	commons.AddResource(infrastructure, "azurerm_subnet", subnet.Name, subnet)
+
+	subnetKey := nodeName + "-subnet"
+	commons.AddOutput(infrastructure, subnetKey, &commons.Output{Value: fmt.Sprintf("${azurerm_subnet.%s.id}", subnet.Name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/subnet_id")] = subnetKey
+	return nil
+}
+
+func (g *azureGenerator) generateSecurityGroup(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	nodeInstanceKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName, "0")
+
+	securityGroup := &NetworkSecurityGroup{Name: getResourceName(cfg, deploymentID, nodeName)}
+	var err error
+	securityGroup.ResourceGroupName, securityGroup.Location, err = getResourceGroupAndRegion(ctx, locationProps, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	if securityGroup.Tags, err = deployments.GetKeyValuePairsNodeProperty(ctx, deploymentID, nodeName, "tags"); err != nil {
+		return err
+	}
+
+	rules, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, "rules")
+	if err != nil {
+		return err
+	}
+	if rules != nil && rules.RawString() != "" {
+		if err = mapstructure.WeakDecode(rules.Value, &securityGroup.SecurityRules); err != nil {
+			return errors.Wrapf(err, "failed to retrieve security rules of node %q", nodeName)
+		}
+		for i := range securityGroup.SecurityRules {
+			setSecurityRuleDefaults(&securityGroup.SecurityRules[i])
+		}
+	}
+
+	commons.AddResource(infrastructure, "azurerm_network_security_group", securityGroup.Name, securityGroup)
+
+	securityGroupKey := nodeName + "-security-group"
+	commons.AddOutput(infrastructure, securityGroupKey, &commons.Output{Value: fmt.Sprintf("${azurerm_network_security_group.%s.id}", securityGroup.Name)})
+	outputs[path.Join(nodeInstanceKey, "/attributes/security_group_id")] = securityGroupKey
+	return nil
+}
+
+// setSecurityRuleDefaults applies the yorc.datatypes.azure.SecurityRule default values
+// to the fields not set in the topology
+func setSecurityRuleDefaults(rule *SecurityRule) {
+	defaults := []struct {
+		field *string
+		value string
+	}{
+		{&rule.Direction, "Inbound"},
+		{&rule.Access, "Allow"},
+		{&rule.Protocol, "Tcp"},
+		{&rule.SourcePortRange, "*"},
+		{&rule.DestinationPortRange, "*"},
+		{&rule.SourceAddressPrefix, "*"},
+		{&rule.DestinationAddressPrefix, "*"},
+	}
+	for _, d := range defaults {
+		if *d.field == "" {
+			*d.field = d.value
+		}
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var result []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
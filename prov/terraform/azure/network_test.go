@@ -0,0 +1,55 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func testSimpleNetwork(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	ctx := context.Background()
+	g := azureGenerator{}
+	infrastructure := commons.Infrastructure{}
+	outputs := make(map[string]string)
+
+	err := g.generateVirtualNetwork(ctx, cfg, testLocationProps, deploymentID, "VirtualNetwork", &infrastructure, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate virtual network for %s", deploymentID)
+
+	// Simulate the virtual network "network_name" attribute registration
+	err = deployments.SetInstanceAttribute(ctx, deploymentID, "VirtualNetwork", "0", "network_name", "vnet")
+	require.NoError(t, err)
+	err = g.generateSubnet(ctx, cfg, testLocationProps, deploymentID, "Subnet", &infrastructure, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate subnet for %s", deploymentID)
+
+	err = g.generateSecurityGroup(ctx, cfg, testLocationProps, deploymentID, "SecurityGroup", &infrastructure, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate security group for %s", deploymentID)
+
+	assertGoldenInfrastructure(t, &infrastructure, outputs)
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Nil(t, splitList(""))
+	assert.Equal(t, []string{"a", "b"}, splitList(" a,, b "))
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *azureGenerator) generatePublicIP(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName, instanceName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	instancesKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName)
+
+	publicIP := &PublicIP{Name: getResourceName(cfg, deploymentID, nodeName, instanceName)}
+	var err error
+	publicIP.ResourceGroupName, publicIP.Location, err = getResourceGroupAndRegion(ctx, locationProps, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&publicIP.AllocationMethod, "allocation_method", true},
+		{&publicIP.SKU, "sku", false},
+		{&publicIP.DomainNameLabel, "domain_name_label", false},
+	}
+
+	for _, stringParam := range stringParams {
+		if *stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName,
+			stringParam.propertyName, stringParam.mandatory); err != nil {
+			return err
+		}
+	}
+
+	if publicIP.Zones, err = getZones(ctx, deploymentID, nodeName); err != nil {
+		return err
+	}
+	if publicIP.Tags, err = deployments.GetKeyValuePairsNodeProperty(ctx, deploymentID, nodeName, "tags"); err != nil {
+		return err
+	}
+
+	commons.AddResource(infrastructure, "azurerm_public_ip", publicIP.Name, publicIP)
+
+	// Provide outputs for attributes ip_address and public_ip_id
+	addressKey := nodeName + "-" + instanceName + "-address"
+	commons.AddOutput(infrastructure, addressKey, &commons.Output{Value: fmt.Sprintf("${azurerm_public_ip.%s.ip_address}", publicIP.Name)})
+	outputs[path.Join(instancesKey, instanceName, "/attributes/ip_address")] = addressKey
+
+	idKey := nodeName + "-" + instanceName + "-id"
+	commons.AddOutput(infrastructure, idKey, &commons.Output{Value: fmt.Sprintf("${azurerm_public_ip.%s.id}", publicIP.Name)})
+	outputs[path.Join(instancesKey, instanceName, "/attributes/public_ip_id")] = idKey
+	return nil
+}
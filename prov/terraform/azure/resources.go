@@ -0,0 +1,170 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import "github.com/ystia/yorc/v4/prov/terraform/commons"
+
+// VirtualMachine represents an Azure Virtual Machine
+// See https://www.terraform.io/docs/providers/azurerm/r/virtual_machine.html
+// for the latest documentation.
+type VirtualMachine struct {
+	commons.Resource
+	Name                      string                `json:"name"`
+	Location                  string                `json:"location"`
+	ResourceGroupName         string                `json:"resource_group_name"`
+	VMSize                    string                `json:"vm_size"`
+	NetworkInterfaceIDs       []string              `json:"network_interface_ids"`
+	Zones                     []string              `json:"zones,omitempty"`
+	DeleteOSDiskOnTermination bool                  `json:"delete_os_disk_on_termination"`
+	StorageImageReference     StorageImageReference `json:"storage_image_reference"`
+	StorageOSDisk             StorageOSDisk         `json:"storage_os_disk"`
+	OSProfile                 OSProfile             `json:"os_profile"`
+	OSProfileLinuxConfig      OSProfileLinuxConfig  `json:"os_profile_linux_config"`
+	Tags                      map[string]string     `json:"tags,omitempty"`
+}
+
+// StorageImageReference defines the image used to create a Virtual Machine
+type StorageImageReference struct {
+	ID        string `json:"id,omitempty"`
+	Publisher string `json:"publisher,omitempty"`
+	Offer     string `json:"offer,omitempty"`
+	SKU       string `json:"sku,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// StorageOSDisk defines the managed OS disk of a Virtual Machine
+type StorageOSDisk struct {
+	Name            string `json:"name"`
+	CreateOption    string `json:"create_option"`
+	Caching         string `json:"caching,omitempty"`
+	ManagedDiskType string `json:"managed_disk_type,omitempty"`
+	DiskSizeGB      int    `json:"disk_size_gb,omitempty"`
+}
+
+// OSProfile defines the Operating System settings of a Virtual Machine
+type OSProfile struct {
+	ComputerName  string `json:"computer_name"`
+	AdminUsername string `json:"admin_username"`
+}
+
+// OSProfileLinuxConfig defines the Linux settings of a Virtual Machine
+type OSProfileLinuxConfig struct {
+	DisablePasswordAuthentication bool     `json:"disable_password_authentication"`
+	SSHKeys                       []SSHKey `json:"ssh_keys,omitempty"`
+}
+
+// SSHKey is an SSH public key allowed to connect to a Virtual Machine
+type SSHKey struct {
+	Path    string `json:"path"`
+	KeyData string `json:"key_data"`
+}
+
+// NetworkInterface represents an Azure Network Interface
+// See https://www.terraform.io/docs/providers/azurerm/r/network_interface.html
+type NetworkInterface struct {
+	Name                   string            `json:"name"`
+	Location               string            `json:"location"`
+	ResourceGroupName      string            `json:"resource_group_name"`
+	NetworkSecurityGroupID string            `json:"network_security_group_id,omitempty"`
+	IPConfigurations       []IPConfiguration `json:"ip_configuration"`
+	Tags                   map[string]string `json:"tags,omitempty"`
+}
+
+// IPConfiguration defines an IP configuration of a Network Interface
+type IPConfiguration struct {
+	Name                       string `json:"name"`
+	SubnetID                   string `json:"subnet_id"`
+	PrivateIPAddressAllocation string `json:"private_ip_address_allocation"`
+	PublicIPAddressID          string `json:"public_ip_address_id,omitempty"`
+}
+
+// PublicIP represents an Azure Public IP address
+// See https://www.terraform.io/docs/providers/azurerm/r/public_ip.html
+type PublicIP struct {
+	Name              string            `json:"name"`
+	Location          string            `json:"location"`
+	ResourceGroupName string            `json:"resource_group_name"`
+	AllocationMethod  string            `json:"allocation_method"`
+	SKU               string            `json:"sku,omitempty"`
+	DomainNameLabel   string            `json:"domain_name_label,omitempty"`
+	Zones             []string          `json:"zones,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+}
+
+// ManagedDisk represents an Azure Managed Disk
+// See https://www.terraform.io/docs/providers/azurerm/r/managed_disk.html
+type ManagedDisk struct {
+	Name               string            `json:"name"`
+	Location           string            `json:"location"`
+	ResourceGroupName  string            `json:"resource_group_name"`
+	StorageAccountType string            `json:"storage_account_type"`
+	CreateOption       string            `json:"create_option"`
+	DiskSizeGB         int               `json:"disk_size_gb,omitempty"`
+	SourceResourceID   string            `json:"source_resource_id,omitempty"`
+	Zones              []string          `json:"zones,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+}
+
+// DataDiskAttachment represents the attachment of a Managed Disk to a Virtual Machine
+// See https://www.terraform.io/docs/providers/azurerm/r/virtual_machine_data_disk_attachment.html
+type DataDiskAttachment struct {
+	ManagedDiskID    string `json:"managed_disk_id"`
+	VirtualMachineID string `json:"virtual_machine_id"`
+	LUN              int    `json:"lun"`
+	Caching          string `json:"caching"`
+}
+
+// VirtualNetwork represents an Azure Virtual Network
+// See https://www.terraform.io/docs/providers/azurerm/r/virtual_network.html
+type VirtualNetwork struct {
+	Name              string            `json:"name"`
+	Location          string            `json:"location"`
+	ResourceGroupName string            `json:"resource_group_name"`
+	AddressSpace      []string          `json:"address_space"`
+	DNSServers        []string          `json:"dns_servers,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+}
+
+// Subnet represents a subnet of an Azure Virtual Network
+// See https://www.terraform.io/docs/providers/azurerm/r/subnet.html
+type Subnet struct {
+	Name               string `json:"name"`
+	ResourceGroupName  string `json:"resource_group_name"`
+	VirtualNetworkName string `json:"virtual_network_name"`
+	AddressPrefix      string `json:"address_prefix"`
+}
+
+// NetworkSecurityGroup represents an Azure Network Security Group
+// See https://www.terraform.io/docs/providers/azurerm/r/network_security_group.html
+type NetworkSecurityGroup struct {
+	Name              string            `json:"name"`
+	Location          string            `json:"location"`
+	ResourceGroupName string            `json:"resource_group_name"`
+	SecurityRules     []SecurityRule    `json:"security_rule,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+}
+
+// SecurityRule is a rule of a Network Security Group
+type SecurityRule struct {
+	Name                     string `json:"name" mapstructure:"name"`
+	Priority                 int    `json:"priority" mapstructure:"priority"`
+	Direction                string `json:"direction" mapstructure:"direction"`
+	Access                   string `json:"access" mapstructure:"access"`
+	Protocol                 string `json:"protocol" mapstructure:"protocol"`
+	SourcePortRange          string `json:"source_port_range" mapstructure:"source_port_range"`
+	DestinationPortRange     string `json:"destination_port_range" mapstructure:"destination_port_range"`
+	SourceAddressPrefix      string `json:"source_address_prefix" mapstructure:"source_address_prefix"`
+	DestinationAddressPrefix string `json:"destination_address_prefix" mapstructure:"destination_address_prefix"`
+}
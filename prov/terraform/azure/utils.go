@@ -0,0 +1,91 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/sshutil"
+)
+
+func getResourcesPrefix(cfg config.Configuration, deploymentID string) string {
+	b := sha1.Sum([]byte(deploymentID))
+	return fmt.Sprintf("%s%x-", cfg.ResourcesPrefix, b[0:3])
+}
+
+// getResourceName returns a name valid for Azure resources and Linux hostnames
+func getResourceName(cfg config.Configuration, deploymentID string, parts ...string) string {
+	name := strings.ToLower(getResourcesPrefix(cfg, deploymentID) + strings.Join(parts, "-"))
+	return strings.Replace(name, "_", "-", -1)
+}
+
+// getResourceGroupAndRegion returns the resource group and the region where to create resources of the
+// given node, using the location configuration as default
+func getResourceGroupAndRegion(ctx context.Context, locationProps config.DynamicMap, deploymentID, nodeName string) (string, string, error) {
+	resourceGroup, err := getResourceGroup(ctx, locationProps, deploymentID, nodeName)
+	if err != nil {
+		return "", "", err
+	}
+	region, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "region", false)
+	if err != nil {
+		return "", "", err
+	}
+	if region == "" {
+		region = locationProps.GetString("region")
+		if region == "" {
+			return "", "", errors.Errorf("Region must be set for node %q or in azure location configuration", nodeName)
+		}
+	}
+	return resourceGroup, region, nil
+}
+
+func getResourceGroup(ctx context.Context, locationProps config.DynamicMap, deploymentID, nodeName string) (string, error) {
+	resourceGroup, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "resource_group", false)
+	if err != nil {
+		return "", err
+	}
+	if resourceGroup == "" {
+		resourceGroup = locationProps.GetString("resource_group")
+		if resourceGroup == "" {
+			return "", errors.Errorf("Resource group must be set for node %q or in azure location configuration", nodeName)
+		}
+	}
+	return resourceGroup, nil
+}
+
+func getZones(ctx context.Context, deploymentID, nodeName string) ([]string, error) {
+	zone, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "zone", false)
+	if err != nil || zone == "" {
+		return nil, err
+	}
+	return []string{zone}, nil
+}
+
+// getAuthorizedKey returns the SSH public key matching a private key in the authorized_keys format
+func getAuthorizedKey(privateKey *sshutil.PrivateKey) (string, error) {
+	signer, err := ssh.ParsePrivateKey(privateKey.Content)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to compute the SSH public key from the endpoint credentials private key")
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))), nil
+}
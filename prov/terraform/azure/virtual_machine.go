@@ -0,0 +1,282 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/helper/sizeutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *azureGenerator) generateVirtualMachine(ctx context.Context, cfg config.Configuration, locationProps config.DynamicMap, deploymentID, nodeName, instanceName string, infrastructure *commons.Infrastructure, outputs map[string]string, env *[]string) error {
+	instancesKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName)
+
+	vm := VirtualMachine{Name: getResourceName(cfg, deploymentID, nodeName, instanceName)}
+	var err error
+	vm.ResourceGroupName, vm.Location, err = getResourceGroupAndRegion(ctx, locationProps, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+
+	// Getting string parameters
+	var osDiskSize, publicKey string
+	image := &vm.StorageImageReference
+	stringParams := []struct {
+		pAttr        *string
+		propertyName string
+		mandatory    bool
+	}{
+		{&vm.VMSize, "vm_size", true},
+		{&image.ID, "image_id", false},
+		{&image.Publisher, "image_publisher", false},
+		{&image.Offer, "image_offer", false},
+		{&image.SKU, "image_sku", false},
+		{&image.Version, "image_version", false},
+		{&vm.StorageOSDisk.ManagedDiskType, "os_disk_type", false},
+		{&osDiskSize, "os_disk_size", false},
+		{&publicKey, "public_key", false},
+	}
+
+	for _, stringParam := range stringParams {
+		if *stringParam.pAttr, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName,
+			stringParam.propertyName, stringParam.mandatory); err != nil {
+			return err
+		}
+	}
+
+	// Define the image, either a custom one or a Marketplace one
+	if image.ID != "" {
+		*image = StorageImageReference{ID: image.ID}
+	} else if image.Publisher == "" || image.Offer == "" || image.SKU == "" {
+		return errors.Errorf("Expected an image_id or an image_publisher, image_offer and image_sku for %s", nodeName)
+	}
+
+	vm.StorageOSDisk.Name = vm.Name + "-osdisk"
+	vm.StorageOSDisk.CreateOption = "FromImage"
+	vm.StorageOSDisk.Caching = "ReadWrite"
+	if osDiskSize != "" {
+		if vm.StorageOSDisk.DiskSizeGB, err = sizeutil.ConvertToGB(osDiskSize); err != nil {
+			return err
+		}
+	}
+	vm.DeleteOSDiskOnTermination = true
+
+	if vm.Zones, err = getZones(ctx, deploymentID, nodeName); err != nil {
+		return err
+	}
+	if vm.Tags, err = deployments.GetKeyValuePairsNodeProperty(ctx, deploymentID, nodeName, "tags"); err != nil {
+		return err
+	}
+
+	// Get connection info (user, private key)
+	user, privateKey, err := commons.GetConnInfoFromEndpointCredentials(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	if publicKey == "" {
+		if publicKey, err = getAuthorizedKey(privateKey); err != nil {
+			return err
+		}
+	}
+	vm.OSProfile = OSProfile{ComputerName: vm.Name, AdminUsername: user}
+	vm.OSProfileLinuxConfig = OSProfileLinuxConfig{
+		DisablePasswordAuthentication: true,
+		SSHKeys: []SSHKey{{
+			Path:    fmt.Sprintf("/home/%s/.ssh/authorized_keys", user),
+			KeyData: publicKey,
+		}},
+	}
+
+	// Network interface definition
+	nic, publicIP, err := g.generateNetworkInterface(ctx, cfg, deploymentID, nodeName, instanceName, vm, infrastructure)
+	if err != nil {
+		return err
+	}
+	vm.NetworkInterfaceIDs = []string{fmt.Sprintf("${azurerm_network_interface.%s.id}", nic.Name)}
+
+	// Add the virtual machine
+	commons.AddResource(infrastructure, "azurerm_virtual_machine", vm.Name, &vm)
+
+	// Define the private IP address using the value exported by Terraform
+	privateIP := fmt.Sprintf("${azurerm_network_interface.%s.private_ip_address}", nic.Name)
+	privateIPKey := nodeName + "-" + instanceName + "-privateIP"
+	commons.AddOutput(infrastructure, privateIPKey, &commons.Output{Value: privateIP})
+	outputs[path.Join(instancesKey, instanceName, "/attributes/private_address")] = privateIPKey
+
+	// Define the public IP if any, otherwise the private IP is used to access the VM
+	accessIP := privateIP
+	accessIPKey := privateIPKey
+	if publicIP != "" {
+		accessIP = publicIP
+		accessIPKey = nodeName + "-" + instanceName + "-publicIP"
+		commons.AddOutput(infrastructure, accessIPKey, &commons.Output{Value: accessIP})
+		outputs[path.Join(instancesKey, instanceName, "/attributes/public_address")] = accessIPKey
+		outputs[path.Join(instancesKey, instanceName, "/attributes/public_ip_address")] = accessIPKey
+	}
+
+	// ip_address attribute and endpoint capability
+	outputs[path.Join(instancesKey, instanceName, "/capabilities/endpoint/attributes/ip_address")] = accessIPKey
+	outputs[path.Join(instancesKey, instanceName, "/attributes/ip_address")] = accessIPKey
+
+	// Add Connection check
+	if err = commons.AddConnectionCheckResource(ctx, deploymentID, nodeName, infrastructure, user, privateKey, accessIP, vm.Name, env); err != nil {
+		return err
+	}
+
+	// Attach Managed disks
+	return addAttachedDisks(ctx, cfg, deploymentID, nodeName, instanceName, vm.Name, infrastructure, outputs)
+}
+
+// generateNetworkInterface adds the network interface of a virtual machine and returns it with the
+// public IP address of the virtual machine if any
+func (g *azureGenerator) generateNetworkInterface(ctx context.Context, cfg config.Configuration, deploymentID, nodeName, instanceName string, vm VirtualMachine, infrastructure *commons.Infrastructure) (*NetworkInterface, string, error) {
+	nic := &NetworkInterface{
+		Name:              vm.Name + "-nic",
+		Location:          vm.Location,
+		ResourceGroupName: vm.ResourceGroupName,
+		Tags:              vm.Tags,
+	}
+	ipConfig := IPConfiguration{Name: vm.Name + "-ipconfig", PrivateIPAddressAllocation: "Dynamic"}
+
+	// The subnet is either provided by a network requirement on a Subnet node or by its ID
+	hasSubnetReq, subnetNode, err := deployments.HasAnyRequirementFromNodeType(ctx, deploymentID, nodeName, "network", "yorc.nodes.azure.Subnet")
+	if err != nil {
+		return nil, "", err
+	}
+	if hasSubnetReq {
+		ipConfig.SubnetID, err = deployments.LookupInstanceAttributeValue(ctx, deploymentID, subnetNode, "0", "subnet_id")
+	} else {
+		ipConfig.SubnetID, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "subnet_id", false)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if ipConfig.SubnetID == "" {
+		return nil, "", errors.Errorf("Missing subnet for %q: expected a network requirement on a yorc.nodes.azure.Subnet node or a subnet_id property", nodeName)
+	}
+
+	hasSecurityGroupReq, securityGroupNode, err := deployments.HasAnyRequirementFromNodeType(ctx, deploymentID, nodeName, "security_group", "yorc.nodes.azure.SecurityGroup")
+	if err != nil {
+		return nil, "", err
+	}
+	if hasSecurityGroupReq {
+		nic.NetworkSecurityGroupID, err = deployments.LookupInstanceAttributeValue(ctx, deploymentID, securityGroupNode, "0", "security_group_id")
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Define an external access, either with a PublicIP node or with a public IP owned by the virtual machine
+	var publicIP string
+	hasPublicIPReq, publicIPNode, err := deployments.HasAnyRequirementCapability(ctx, deploymentID, nodeName, "assignment", "yorc.capabilities.Assignable")
+	if err != nil {
+		return nil, "", err
+	}
+	if hasPublicIPReq {
+		if ipConfig.PublicIPAddressID, err = deployments.LookupInstanceAttributeValue(ctx, deploymentID, publicIPNode, instanceName, "public_ip_id"); err != nil {
+			return nil, "", err
+		}
+		if publicIP, err = deployments.LookupInstanceAttributeValue(ctx, deploymentID, publicIPNode, instanceName, "ip_address"); err != nil {
+			return nil, "", err
+		}
+		if publicIP == "" {
+			return nil, "", errors.Errorf("No IP address allocated by %q for %q, only Static allocation method is supported to assign a public IP", publicIPNode, nodeName)
+		}
+	} else {
+		noAddress, err := deployments.GetBooleanNodeProperty(ctx, deploymentID, nodeName, "no_address")
+		if err != nil {
+			return nil, "", err
+		}
+		if !noAddress {
+			// Static allocation is needed to know the IP address once the public IP is created
+			pip := &PublicIP{
+				Name:              vm.Name + "-pip",
+				Location:          vm.Location,
+				ResourceGroupName: vm.ResourceGroupName,
+				AllocationMethod:  "Static",
+				Zones:             vm.Zones,
+				Tags:              vm.Tags,
+			}
+			commons.AddResource(infrastructure, "azurerm_public_ip", pip.Name, pip)
+			ipConfig.PublicIPAddressID = fmt.Sprintf("${azurerm_public_ip.%s.id}", pip.Name)
+			publicIP = fmt.Sprintf("${azurerm_public_ip.%s.ip_address}", pip.Name)
+		}
+	}
+
+	nic.IPConfigurations = []IPConfiguration{ipConfig}
+	commons.AddResource(infrastructure, "azurerm_network_interface", nic.Name, nic)
+	return nic, publicIP, nil
+}
+
+func addAttachedDisks(ctx context.Context, cfg config.Configuration, deploymentID, nodeName, instanceName, vmName string, infrastructure *commons.Infrastructure, outputs map[string]string) error {
+	storageReqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, nodeName, "local_storage")
+	if err != nil {
+		return err
+	}
+	for i, storageReq := range storageReqs {
+		volumeNodeName := storageReq.Node
+		log.Debugf("Volume attachment required form Volume named %s", volumeNodeName)
+
+		volumeID, err := deployments.LookupInstanceAttributeValue(ctx, deploymentID, volumeNodeName, instanceName, "volume_id")
+		if err != nil {
+			return err
+		}
+
+		attachment := &DataDiskAttachment{
+			ManagedDiskID:    volumeID,
+			VirtualMachineID: fmt.Sprintf("${azurerm_virtual_machine.%s.id}", vmName),
+			LUN:              i,
+			Caching:          "None",
+		}
+		lunValue, err := deployments.GetRelationshipPropertyValueFromRequirement(ctx, deploymentID, nodeName, storageReq.Index, "lun")
+		if err != nil {
+			return err
+		}
+		if lunValue != nil && lunValue.RawString() != "" {
+			if attachment.LUN, err = strconv.Atoi(lunValue.RawString()); err != nil {
+				return errors.Wrapf(err, "invalid lun %q to attach %q to %q", lunValue.RawString(), volumeNodeName, nodeName)
+			}
+		}
+		cachingValue, err := deployments.GetRelationshipPropertyValueFromRequirement(ctx, deploymentID, nodeName, storageReq.Index, "caching")
+		if err != nil {
+			return err
+		}
+		if cachingValue != nil && cachingValue.RawString() != "" {
+			attachment.Caching = cachingValue.RawString()
+		}
+
+		attachName := getResourceName(cfg, deploymentID, volumeNodeName, instanceName, "to", nodeName, instanceName)
+		commons.AddResource(infrastructure, "azurerm_virtual_machine_data_disk_attachment", attachName, attachment)
+
+		// Azure Linux images expose data disks under a path depending on their LUN
+		deviceKey := attachName + "-device"
+		commons.AddOutput(infrastructure, deviceKey, &commons.Output{Value: fmt.Sprintf("/dev/disk/azure/scsi1/lun%d", attachment.LUN)})
+		instancesPrefix := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances")
+		outputs[path.Join(instancesPrefix, volumeNodeName, instanceName, "attributes/device")] = deviceKey
+		outputs[path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "relationship_instances", nodeName, storageReq.Index, instanceName, "attributes/device")] = deviceKey
+		outputs[path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "relationship_instances", volumeNodeName, storageReq.Index, instanceName, "attributes/device")] = deviceKey
+	}
+	return nil
+}
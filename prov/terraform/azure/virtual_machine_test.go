@@ -0,0 +1,86 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func testSimpleVirtualMachine(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	g := azureGenerator{}
+	infrastructure := commons.Infrastructure{}
+	env := make([]string, 0)
+	outputs := make(map[string]string)
+
+	err := g.generateVirtualMachine(context.Background(), cfg, testLocationProps, deploymentID, "Compute", "0", &infrastructure, outputs, &env)
+	require.NoError(t, err, "Unexpected error attempting to generate virtual machine for %s", deploymentID)
+
+	require.Len(t, env, 1)
+	assert.Contains(t, env[0], "TF_VAR_private_key=", "env var for private key expected")
+	assertGoldenInfrastructure(t, &infrastructure, outputs)
+}
+
+func testVirtualMachineMissingImage(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	g := azureGenerator{}
+	infrastructure := commons.Infrastructure{}
+	env := make([]string, 0)
+
+	err := g.generateVirtualMachine(context.Background(), cfg, testLocationProps, deploymentID, "Compute", "0", &infrastructure, make(map[string]string), &env)
+	require.Error(t, err, "Expected an error on incomplete image definition")
+	assert.Contains(t, err.Error(), "image_sku")
+}
+
+func testVirtualMachineWithResources(t *testing.T, cfg config.Configuration) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	ctx := context.Background()
+
+	// Simulate the attributes registration of resources created before the virtual machine
+	attributes := []struct {
+		nodeName, attributeName, value string
+	}{
+		{"Subnet", "subnet_id", "/subscriptions/sub1/resourceGroups/yorc-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"},
+		{"PublicIP", "public_ip_id", "/subscriptions/sub1/resourceGroups/yorc-rg/providers/Microsoft.Network/publicIPAddresses/pip"},
+		{"PublicIP", "ip_address", "20.1.2.3"},
+		{"SecurityGroup", "security_group_id", "/subscriptions/sub1/resourceGroups/yorc-rg/providers/Microsoft.Network/networkSecurityGroups/nsg"},
+		{"Disk", "volume_id", "/subscriptions/sub1/resourceGroups/yorc-rg/providers/Microsoft.Compute/disks/disk"},
+	}
+	for _, attr := range attributes {
+		err := deployments.SetInstanceAttribute(ctx, deploymentID, attr.nodeName, "0", attr.attributeName, attr.value)
+		require.NoError(t, err)
+	}
+
+	g := azureGenerator{}
+	infrastructure := commons.Infrastructure{}
+	env := make([]string, 0)
+	outputs := make(map[string]string)
+	err := g.generateVirtualMachine(ctx, cfg, testLocationProps, deploymentID, "Compute", "0", &infrastructure, outputs, &env)
+	require.NoError(t, err, "Unexpected error attempting to generate virtual machine for %s", deploymentID)
+
+	require.NotContains(t, infrastructure.Resource, "azurerm_public_ip", "No public IP expected as a PublicIP node is assigned")
+	assertGoldenInfrastructure(t, &infrastructure, outputs)
+}
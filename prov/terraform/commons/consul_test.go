@@ -47,4 +47,7 @@ func TestRunConsulTerraformCommonsPackageTests(t *testing.T) {
 	t.Run("AddConnectionCheckResource", func(t *testing.T) {
 		testAddConnectionCheckResource(t, client.KV())
 	})
+	t.Run("SecurityGroupEndpointRules", func(t *testing.T) {
+		testSecurityGroupEndpointRules(t)
+	})
 }
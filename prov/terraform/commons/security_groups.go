@@ -0,0 +1,213 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commons
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+)
+
+// DefaultAdminEndpointPort is the default port of the Computes admin endpoint used by the orchestrator to connect
+const DefaultAdminEndpointPort = 22
+
+// EndpointRule is an ingress traffic rule derived from a TOSCA endpoint capability
+type EndpointRule struct {
+	// Protocol is the transport protocol of the endpoint: tcp, udp or icmp
+	Protocol string
+	Port     int
+	// Public is true if the endpoint should be reachable from any address,
+	// otherwise it should be reachable only from members of the security group
+	Public bool
+}
+
+// GetSecurityGroupEndpointRules returns the ingress rules of a security group derived from the endpoint capabilities
+// of the nodes having a security_group requirement on it and of the nodes hosted on them.
+//
+// See GetEndpointRules for the way rules are derived from endpoints.
+func GetSecurityGroupEndpointRules(ctx context.Context, deploymentID, securityGroupNode string) ([]EndpointRule, error) {
+	members, err := GetSecurityGroupMembers(ctx, deploymentID, securityGroupNode)
+	if err != nil {
+		return nil, err
+	}
+	var rules []EndpointRule
+	for _, member := range members {
+		memberRules, err := GetEndpointRules(ctx, deploymentID, member)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, memberRules...)
+	}
+	return UniqueEndpointRules(rules), nil
+}
+
+// GetSecurityGroupMembers returns the names of the nodes having a security_group requirement on a given security group node
+func GetSecurityGroupMembers(ctx context.Context, deploymentID, securityGroupNode string) ([]string, error) {
+	nodes, err := deployments.GetNodes(ctx, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, nodeName := range nodes {
+		reqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, nodeName, "security_group")
+		if err != nil {
+			return nil, err
+		}
+		for _, req := range reqs {
+			if req.Node == securityGroupNode {
+				members = append(members, nodeName)
+				break
+			}
+		}
+	}
+	return members, nil
+}
+
+// GetEndpointRules returns ingress rules derived from the endpoint capabilities of a Compute node
+// and of the nodes hosted on it.
+//
+// The admin endpoint of the Compute (port 22 by default) and endpoints which network_name is PUBLIC
+// are considered as public, other endpoints are considered as private. Endpoints without port are ignored.
+func GetEndpointRules(ctx context.Context, deploymentID, computeNode string) ([]EndpointRule, error) {
+	hostedNodes, err := deployments.GetNodesHostedOn(ctx, deploymentID, computeNode)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []EndpointRule
+	for _, nodeName := range append([]string{computeNode}, hostedNodes...) {
+		nodeRules, err := getNodeEndpointRules(ctx, deploymentID, nodeName, nodeName == computeNode)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, nodeRules...)
+	}
+	return rules, nil
+}
+
+// UniqueEndpointRules returns the given rules without duplicates, sorted by port and protocol
+func UniqueEndpointRules(rules []EndpointRule) []EndpointRule {
+	set := make(map[EndpointRule]struct{}, len(rules))
+	unique := make([]EndpointRule, 0, len(rules))
+	for _, rule := range rules {
+		if _, ok := set[rule]; !ok {
+			set[rule] = struct{}{}
+			unique = append(unique, rule)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].Port != unique[j].Port {
+			return unique[i].Port < unique[j].Port
+		}
+		if unique[i].Protocol != unique[j].Protocol {
+			return unique[i].Protocol < unique[j].Protocol
+		}
+		return unique[i].Public && !unique[j].Public
+	})
+	return unique
+}
+
+func getNodeEndpointRules(ctx context.Context, deploymentID, nodeName string, isCompute bool) ([]EndpointRule, error) {
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	capabilities, err := deployments.GetCapabilitiesOfType(ctx, deploymentID, nodeType, "tosca.capabilities.Endpoint")
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []EndpointRule
+	// Capabilities redefined in a type hierarchy are returned several times
+	seen := make(map[string]struct{}, len(capabilities))
+	for _, capabilityName := range capabilities {
+		if _, ok := seen[capabilityName]; ok {
+			continue
+		}
+		seen[capabilityName] = struct{}{}
+
+		var isAdmin bool
+		if isCompute {
+			capabilityType, err := deployments.GetNodeCapabilityType(ctx, deploymentID, nodeName, capabilityName)
+			if err != nil {
+				return nil, err
+			}
+			isAdmin, err = deployments.IsTypeDerivedFrom(ctx, deploymentID, capabilityType, "tosca.capabilities.Endpoint.Admin")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		port, err := deployments.GetCapabilityPropertyValue(ctx, deploymentID, nodeName, capabilityName, "port")
+		if err != nil {
+			return nil, err
+		}
+		var portNumber int
+		if port != nil && port.RawString() != "" {
+			portNumber, err = strconv.Atoi(port.RawString())
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid port %q for endpoint %q of node %q", port.RawString(), capabilityName, nodeName)
+			}
+		} else if isAdmin {
+			portNumber = DefaultAdminEndpointPort
+		} else {
+			// No port exposed by this endpoint
+			continue
+		}
+
+		var protocol, networkName string
+		stringParams := []struct {
+			pAttr        *string
+			propertyName string
+		}{
+			{&protocol, "protocol"},
+			{&networkName, "network_name"},
+		}
+		for _, stringParam := range stringParams {
+			val, err := deployments.GetCapabilityPropertyValue(ctx, deploymentID, nodeName, capabilityName, stringParam.propertyName)
+			if err != nil {
+				return nil, err
+			}
+			if val != nil {
+				*stringParam.pAttr = val.RawString()
+			}
+		}
+
+		rules = append(rules, EndpointRule{
+			Protocol: getEndpointTransportProtocol(protocol),
+			Port:     portNumber,
+			// The orchestrator needs to reach the admin endpoint of Computes
+			Public: isAdmin || strings.EqualFold(networkName, "PUBLIC"),
+		})
+	}
+	return rules, nil
+}
+
+// getEndpointTransportProtocol maps an endpoint protocol to a transport protocol.
+// Endpoints protocols may be any OSI Layer 4-7 protocol, considered as tcp unless udp or icmp is specified.
+func getEndpointTransportProtocol(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "udp", "icmp":
+		return strings.ToLower(protocol)
+	default:
+		return "tcp"
+	}
+}
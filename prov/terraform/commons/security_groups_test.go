@@ -0,0 +1,81 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commons
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSecurityGroupEndpointRules(t *testing.T) {
+	deploymentID := loadTestYaml(t, nil)
+	ctx := context.Background()
+
+	members, err := GetSecurityGroupMembers(ctx, deploymentID, "SecurityGroup")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Compute", "Compute2"}, members)
+
+	rules, err := GetSecurityGroupEndpointRules(ctx, deploymentID, "SecurityGroup")
+	require.NoError(t, err)
+	assert.Equal(t, []EndpointRule{
+		{Protocol: "tcp", Port: 22, Public: true},
+		{Protocol: "tcp", Port: 2222, Public: true},
+		{Protocol: "udp", Port: 7946, Public: false},
+		{Protocol: "tcp", Port: 8080, Public: true},
+		{Protocol: "tcp", Port: 9100, Public: false},
+	}, rules)
+
+	rules, err = GetEndpointRules(ctx, deploymentID, "OtherCompute")
+	require.NoError(t, err)
+	assert.Equal(t, []EndpointRule{{Protocol: "tcp", Port: 22, Public: true}}, rules)
+}
+
+func TestGetEndpointTransportProtocol(t *testing.T) {
+	tests := []struct {
+		protocol string
+		want     string
+	}{
+		{"", "tcp"},
+		{"tcp", "tcp"},
+		{"http", "tcp"},
+		{"ssh", "tcp"},
+		{"UDP", "udp"},
+		{"icmp", "icmp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.protocol, func(t *testing.T) {
+			assert.Equal(t, tt.want, getEndpointTransportProtocol(tt.protocol))
+		})
+	}
+}
+
+func TestUniqueEndpointRules(t *testing.T) {
+	rules := UniqueEndpointRules([]EndpointRule{
+		{Protocol: "tcp", Port: 8080, Public: false},
+		{Protocol: "tcp", Port: 22, Public: true},
+		{Protocol: "tcp", Port: 8080, Public: true},
+		{Protocol: "udp", Port: 8080, Public: false},
+		{Protocol: "tcp", Port: 22, Public: true},
+	})
+	assert.Equal(t, []EndpointRule{
+		{Protocol: "tcp", Port: 22, Public: true},
+		{Protocol: "tcp", Port: 8080, Public: true},
+		{Protocol: "tcp", Port: 8080, Public: false},
+		{Protocol: "udp", Port: 8080, Public: false},
+	}, rules)
+}
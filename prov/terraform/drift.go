@@ -0,0 +1,126 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/executil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
+)
+
+// planDriftExitCode is the exit code of terraform plan -detailed-exitcode when the plan contains changes
+const planDriftExitCode = 2
+
+// planResourceChangeRegexp matches resources changes in a plan output
+// like "  ~ openstack_compute_instance_v2.Compute-0" or "-/+ openstack_blockstorage_volume_v1.BlockStorage-0 (new resource required)"
+var planResourceChangeRegexp = regexp.MustCompile(`^\s*(-/\+|<=|[-+~])\s+(\S+)`)
+
+// DetectDrift checks if the infrastructure of a node differs from its Terraform state.
+//
+// The infrastructure of the node is generated as for its installation and terraform plan is run
+// against the state stored in Consul. The plan is never applied.
+func (e *defaultExecutor) DetectDrift(ctx context.Context, cfg config.Configuration, taskID, deploymentID, nodeName string) (*prov.DriftReport, error) {
+	infrastructurePath := filepath.Join(cfg.WorkingDirectory, "deployments", deploymentID, "terraform", taskID, nodeName)
+	if err := os.MkdirAll(infrastructurePath, 0775); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create infrastructure working directory %q", infrastructurePath)
+	}
+	defer func() {
+		if !cfg.Terraform.KeepGeneratedFiles {
+			err := os.RemoveAll(infrastructurePath)
+			if err != nil {
+				log.Debugf("%+v", errors.Wrapf(err, "Failed to remove Terraform infrastructure directory %q for node %q drift check", infrastructurePath, nodeName))
+			}
+		}
+	}()
+
+	infraGenerated, _, env, cb, err := e.generator.GenerateTerraformInfraForNode(ctx, cfg, deploymentID, nodeName, infrastructurePath)
+	// Execute callback if needed even if there is an error
+	defer func() {
+		if cb != nil {
+			cb()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	report := &prov.DriftReport{NodeName: nodeName}
+	if !infraGenerated {
+		return report, nil
+	}
+
+	if err = e.remoteConfigInfrastructure(ctx, cfg, deploymentID, nodeName, infrastructurePath, env); err != nil {
+		return nil, err
+	}
+
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Checking the infrastructure drift")
+	cmd := executil.Command(ctx, "terraform", "plan", "-input=false", "-no-color", "-detailed-exitcode")
+	cmd.Dir = infrastructurePath
+	cmd.Env = mergeEnvironments(env)
+	out := new(bytes.Buffer)
+	errbuf := events.NewBufferedLogEntryWriter()
+	cmd.Stdout = out
+	cmd.Stderr = errbuf
+
+	quit := make(chan bool)
+	defer close(quit)
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RunBufferedRegistration(errbuf, quit)
+
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == planDriftExitCode {
+		report.Drifted = true
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Failed to plan the infrastructure changes via terraform")
+	}
+	report.Changes, report.Summary = parsePlanOutput(out.String())
+	return report, nil
+}
+
+// parsePlanOutput returns the resources changes and the summary of a terraform plan output
+//
+// Resources changes are listed after the actions header, the symbols legend preceding it is ignored.
+func parsePlanOutput(output string) ([]string, string) {
+	var changes []string
+	var summary string
+	var inActions bool
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Plan:") || strings.HasPrefix(line, "No changes."):
+			summary = strings.TrimSpace(line)
+			inActions = false
+		case strings.HasPrefix(line, "Terraform will perform the following actions:"):
+			inActions = true
+		case inActions:
+			if m := planResourceChangeRegexp.FindStringSubmatch(line); m != nil {
+				changes = append(changes, m[1]+" "+m[2])
+			}
+		}
+	}
+	return changes, summary
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/prov"
+)
+
+func TestExecutorIsDriftDetector(t *testing.T) {
+	t.Parallel()
+	_, ok := NewExecutor(nil, nil).(prov.DriftDetector)
+	require.True(t, ok, "terraform delegate executor should support drift detection")
+}
+
+func TestParsePlanOutput(t *testing.T) {
+	t.Parallel()
+	output := `Refreshing Terraform state in-memory prior to plan...
+The refreshed state will be used to calculate this plan, but will not be
+persisted to local or remote state storage.
+
+openstack_compute_instance_v2.Compute-0: Refreshing state... (ID: 0b0e0a2c)
+
+------------------------------------------------------------------------
+
+An execution plan has been generated and is shown below.
+Resource actions are indicated with the following symbols:
+  + create
+  ~ update in-place
+-/+ destroy and then create replacement
+
+Terraform will perform the following actions:
+
+  ~ openstack_compute_instance_v2.Compute-0
+      security_groups.#:          "2" => "1"
+
+-/+ openstack_blockstorage_volume_v1.BlockStorage-0 (new resource required)
+      id:                         "8e4e1f5a" => <computed> (forces new resource)
+
+  + openstack_compute_volume_attach_v2.BlockStorage-0-to-Compute-0
+      id:                         <computed>
+
+
+Plan: 2 to add, 1 to change, 1 to destroy.
+`
+	changes, summary := parsePlanOutput(output)
+	require.Equal(t, []string{
+		"~ openstack_compute_instance_v2.Compute-0",
+		"-/+ openstack_blockstorage_volume_v1.BlockStorage-0",
+		"+ openstack_compute_volume_attach_v2.BlockStorage-0-to-Compute-0",
+	}, changes)
+	require.Equal(t, "Plan: 2 to add, 1 to change, 1 to destroy.", summary)
+
+	changes, summary = parsePlanOutput(`Refreshing Terraform state in-memory prior to plan...
+
+No changes. Infrastructure is up-to-date.
+`)
+	require.Len(t, changes, 0)
+	require.Equal(t, "No changes. Infrastructure is up-to-date.", summary)
+}
@@ -288,7 +288,18 @@ func (e *defaultExecutor) applyInfrastructure(ctx context.Context, cfg config.Co
 	}
 
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Applying the infrastructure")
-	cmd := executil.Command(ctx, "terraform", "apply", "-input=false", "-auto-approve")
+	if err := runTerraformCommand(ctx, deploymentID, infrastructurePath, env, "apply", "-input=false", "-auto-approve"); err != nil {
+		return errors.Wrap(err, "Failed to apply the infrastructure changes via terraform")
+	}
+
+	return e.retrieveOutputs(ctx, infrastructurePath, outputs)
+
+}
+
+// runTerraformCommand runs a terraform command in the given infrastructure directory,
+// registering its standard output and error as log entries
+func runTerraformCommand(ctx context.Context, deploymentID, infrastructurePath string, env []string, args ...string) error {
+	cmd := executil.Command(ctx, "terraform", args...)
 	cmd.Dir = infrastructurePath
 	cmd.Env = mergeEnvironments(env)
 	errbuf := events.NewBufferedLogEntryWriter()
@@ -303,12 +314,7 @@ func (e *defaultExecutor) applyInfrastructure(ctx context.Context, cfg config.Co
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RunBufferedRegistration(errbuf, quit)
 	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RunBufferedRegistration(out, quit)
 
-	if err := cmd.Run(); err != nil {
-		return errors.Wrap(err, "Failed to apply the infrastructure changes via terraform")
-	}
-
-	return e.retrieveOutputs(ctx, infrastructurePath, outputs)
-
+	return cmd.Run()
 }
 
 func (e *defaultExecutor) destroyInfrastructure(ctx context.Context, cfg config.Configuration, deploymentID, nodeName, infrastructurePath string, outputs map[string]string, env []string) error {
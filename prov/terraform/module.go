@@ -0,0 +1,278 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/helper/executil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/prov/operations"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// moduleArtifact is the implementation artifact type of operations applying a Terraform module shipped in a CSAR
+const moduleArtifact = "yorc.artifacts.Terraform"
+
+// moduleBackendFile is the name of the file generated in the module directory to configure the Consul backend
+const moduleBackendFile = "yorc_backend.tf.json"
+
+// moduleExecutor applies or destroys a Terraform module on create and delete operations of a node.
+//
+// Node properties and operation inputs are given to the module as variables and module outputs are stored
+// as attributes of the node instances.
+type moduleExecutor struct {
+	defaultExecutor
+}
+
+func (e *moduleExecutor) ExecOperation(ctx context.Context, cfg config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
+	log.Debugf("Terraform module executor: Execute the operation:%+v", operation)
+	if operation.RelOp.IsRelationshipOperation {
+		return errors.Errorf("Unsupported relationship operation %q for a Terraform module", operation.Name)
+	}
+	var destroy bool
+	switch strings.ToLower(operation.Name) {
+	case "standard.create":
+	case "standard.delete":
+		destroy = true
+	default:
+		return errors.Errorf("Unsupported operation %q for a Terraform module", operation.Name)
+	}
+
+	modulePath, err := getModulePath(ctx, cfg, taskID, deploymentID, nodeName, operation)
+	if err != nil {
+		return err
+	}
+	infrastructurePath := filepath.Join(cfg.WorkingDirectory, "deployments", deploymentID, "terraform", taskID, nodeName)
+	if err = os.MkdirAll(filepath.Dir(infrastructurePath), 0775); err != nil {
+		return errors.Wrapf(err, "Failed to create infrastructure working directory %q", infrastructurePath)
+	}
+	defer func() {
+		if !cfg.Terraform.KeepGeneratedFiles {
+			err := os.RemoveAll(infrastructurePath)
+			if err != nil {
+				err = errors.Wrapf(err, "Failed to remove Terraform infrastructure directory %q for node %q operation %q", infrastructurePath, nodeName, operation.Name)
+				log.Debugf("%+v", err)
+				events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelERROR, deploymentID).RegisterAsString(err.Error())
+			}
+		}
+	}()
+	if err = copyModule(modulePath, infrastructurePath); err != nil {
+		return errors.Wrapf(err, "Failed to copy Terraform module %q", modulePath)
+	}
+
+	// Remote Configuration for Terraform State to store it in the Consul KV store
+	terraformStateKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "terraform-state", nodeName)
+	backend, err := json.MarshalIndent(map[string]interface{}{"terraform": commons.GetBackendConfiguration(terraformStateKey, cfg)}, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to generate JSON of terraform backend configuration")
+	}
+	if err = ioutil.WriteFile(filepath.Join(infrastructurePath, moduleBackendFile), backend, 0664); err != nil {
+		return errors.Wrapf(err, "Failed to write file %q", filepath.Join(infrastructurePath, moduleBackendFile))
+	}
+
+	variables, err := getModuleVariables(ctx, deploymentID, nodeName, taskID, operation)
+	if err != nil {
+		return err
+	}
+	env := getModuleVariablesEnv(variables)
+	if err = e.remoteConfigInfrastructure(ctx, cfg, deploymentID, nodeName, infrastructurePath, env); err != nil {
+		return err
+	}
+
+	if destroy {
+		events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Destroying the infrastructure")
+		return errors.Wrap(runTerraformCommand(ctx, deploymentID, infrastructurePath, env, "destroy", "-input=false", "-auto-approve"),
+			"Failed to destroy the infrastructure via terraform")
+	}
+	events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).RegisterAsString("Applying the infrastructure")
+	if err = runTerraformCommand(ctx, deploymentID, infrastructurePath, env, "apply", "-input=false", "-auto-approve"); err != nil {
+		return errors.Wrap(err, "Failed to apply the infrastructure changes via terraform")
+	}
+	return storeModuleOutputs(ctx, taskID, deploymentID, nodeName, infrastructurePath)
+}
+
+func (e *moduleExecutor) ExecAsyncOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation, stepName string) (*prov.Action, time.Duration, error) {
+	return nil, 0, errors.New("asynchronous operation is not yet handled by this executor")
+}
+
+// getModulePath returns the absolute path of the module directory implementing the operation
+func getModulePath(ctx context.Context, cfg config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) (string, error) {
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return "", err
+	}
+	implementation, err := deployments.GetOperationImplementation(ctx, deploymentID, operation.ImplementedInNodeTemplate, nodeType, operation.Name)
+	if err != nil {
+		return "", err
+	}
+	primary := strings.TrimSpace(implementation.Primary)
+	if primary == "" {
+		return "", errors.Errorf("primary implementation missing for operation %q of node %q", operation.Name, nodeName)
+	}
+	overlayPath, err := operations.GetOverlayPath(cfg, taskID, deploymentID)
+	if err != nil {
+		return "", err
+	}
+	modulePath, err := filepath.Abs(filepath.Join(overlayPath, primary))
+	if err != nil {
+		return "", err
+	}
+	if fi, err := os.Stat(modulePath); err != nil || !fi.IsDir() {
+		return "", errors.Errorf("Terraform module %q of operation %q on node %q should be a directory of the deployment archive", primary, operation.Name, nodeName)
+	}
+	return modulePath, nil
+}
+
+// copyModule copies the module directory into the infrastructure directory
+func copyModule(modulePath, infrastructurePath string) error {
+	return filepath.Walk(modulePath, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(modulePath, p)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(infrastructurePath, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(dest, 0775)
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	})
+}
+
+// getModuleVariables returns the values of the module variables, which are the properties of the node
+// overridden by the inputs of the operation
+func getModuleVariables(ctx context.Context, deploymentID, nodeName, taskID string, operation prov.Operation) (map[string]string, error) {
+	variables := make(map[string]string)
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	propNames, err := deployments.GetTypeProperties(ctx, deploymentID, nodeType, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, propName := range propNames {
+		value, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, propName)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			variables[propName] = value.RawString()
+		}
+	}
+
+	envInputs, _, err := operations.ResolveInputs(ctx, deploymentID, nodeName, taskID, operation)
+	if err != nil {
+		return nil, err
+	}
+	// The module is applied once for all instances, the value of the first instance is used for inputs
+	// depending on the instance
+	inputs := make(map[string]bool)
+	for _, envInput := range envInputs {
+		if !inputs[envInput.Name] {
+			inputs[envInput.Name] = true
+			variables[envInput.Name] = envInput.Value
+		}
+	}
+	return variables, nil
+}
+
+// getModuleVariablesEnv returns the environment variables used to give variables values to Terraform.
+//
+// Terraform ignores environment variables of undeclared variables so unused properties and inputs have no effect.
+func getModuleVariablesEnv(variables map[string]string) []string {
+	env := make([]string, 0, len(variables))
+	for name, value := range variables {
+		env = append(env, fmt.Sprintf("TF_VAR_%s=%s", name, value))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// storeModuleOutputs stores the outputs of the module as attributes of the same name of the node instances
+func storeModuleOutputs(ctx context.Context, taskID, deploymentID, nodeName, infrastructurePath string) error {
+	cmd := executil.Command(ctx, "terraform", "output", "-json")
+	cmd.Dir = infrastructurePath
+	result, err := cmd.Output()
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve the infrastructure outputs via terraform")
+	}
+	outputs, err := parseModuleOutputs(result)
+	if err != nil {
+		return err
+	}
+	instances, err := tasks.GetInstances(ctx, taskID, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		for name, value := range outputs {
+			err = deployments.SetInstanceAttributeComplex(ctx, deploymentID, nodeName, instance, name, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseModuleOutputs parses the result of the terraform output command, complex values are kept as is
+func parseModuleOutputs(result []byte) (map[string]interface{}, error) {
+	var outputsList map[string]struct {
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(result, &outputsList); err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve the infrastructure outputs via terraform")
+	}
+	outputs := make(map[string]interface{}, len(outputsList))
+	for name, output := range outputsList {
+		outputs[name] = output.Value
+	}
+	return outputs, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/prov"
+)
+
+func TestModuleExecutorUnsupportedOperations(t *testing.T) {
+	e := &moduleExecutor{}
+	err := e.ExecOperation(context.Background(), config.Configuration{}, "taskID", "dep", "Node", prov.Operation{Name: "standard.start"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unsupported operation")
+
+	err = e.ExecOperation(context.Background(), config.Configuration{}, "taskID", "dep", "Node",
+		prov.Operation{Name: "configure.pre_configure_source", RelOp: prov.RelationshipOperation{IsRelationshipOperation: true}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unsupported relationship operation")
+}
+
+func TestCopyModule(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "TestCopyModule")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	modulePath := filepath.Join(tempdir, "module")
+	require.NoError(t, os.MkdirAll(filepath.Join(modulePath, "modules", "network"), 0775))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(modulePath, "main.tf"), []byte(`module "network" { source = "./modules/network" }`), 0664))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(modulePath, "modules", "network", "main.tf"), []byte(`variable "cidr" {}`), 0664))
+
+	infrastructurePath := filepath.Join(tempdir, "infra")
+	require.NoError(t, copyModule(modulePath, infrastructurePath))
+
+	content, err := ioutil.ReadFile(filepath.Join(infrastructurePath, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, `module "network" { source = "./modules/network" }`, string(content))
+	content, err = ioutil.ReadFile(filepath.Join(infrastructurePath, "modules", "network", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, `variable "cidr" {}`, string(content))
+}
+
+func TestGetModuleVariablesEnv(t *testing.T) {
+	env := getModuleVariablesEnv(map[string]string{
+		"region": "eu-west-1",
+		"tags":   `{"env":"prod"}`,
+		"empty":  "",
+	})
+	assert.Equal(t, []string{"TF_VAR_empty=", "TF_VAR_region=eu-west-1", `TF_VAR_tags={"env":"prod"}`}, env)
+}
+
+func TestParseModuleOutputs(t *testing.T) {
+	outputs, err := parseModuleOutputs([]byte(`{
+  "network_id": {"sensitive": false, "type": "string", "value": "net-1234"},
+  "subnets": {"sensitive": false, "type": ["list", "string"], "value": ["10.0.1.0/24", "10.0.2.0/24"]},
+  "password": {"sensitive": true, "type": "string", "value": "secret"}
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"network_id": "net-1234",
+		"subnets":    []interface{}{"10.0.1.0/24", "10.0.2.0/24"},
+		"password":   "secret",
+	}, outputs)
+
+	_, err = parseModuleOutputs([]byte(`not json`))
+	assert.Error(t, err)
+}
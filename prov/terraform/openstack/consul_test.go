@@ -83,6 +83,15 @@ func TestRunConsulOpenstackPackageTests(t *testing.T) {
 		t.Run("OSInstanceWithServerGroup", func(t *testing.T) {
 			testOSInstanceWithServerGroup(t, srv)
 		})
+		t.Run("simpleLoadBalancer", func(t *testing.T) {
+			testSimpleLoadBalancer(t)
+		})
+		t.Run("OSInstanceWithLoadBalancerAndSecurityGroup", func(t *testing.T) {
+			testOSInstanceWithLoadBalancerAndSecurityGroup(t, srv)
+		})
+		t.Run("simpleOSSecurityGroup", func(t *testing.T) {
+			testSimpleOSSecurityGroup(t)
+		})
 		t.Run("TestGenerateTerraformInfo", func(t *testing.T) {
 			testGenerateTerraformInfo(t, srv, locationMgr)
 		})
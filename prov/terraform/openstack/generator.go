@@ -155,6 +155,10 @@ func getOpenStackProviderEnv(cfg config.Configuration, locationProps config.Dyna
 			"version": commons.NullPluginVersionConstraint,
 		},
 	}
+	// Let the provider choose the load balancers API unless the location defines it
+	if locationProps.IsSet("use_octavia") {
+		provider["openstack"].(map[string]interface{})["use_octavia"] = locationProps.GetString("use_octavia")
+	}
 
 	return provider, cmdEnv
 }
@@ -203,6 +207,13 @@ func (g *osGenerator) generateInstanceInfra(ctx context.Context, opts generateIn
 				resourceTypes: opts.resourceTypes,
 			},
 			opts.infrastructure, outputs, cmdEnv)
+
+	case "yorc.nodes.openstack.LoadBalancer":
+		err = g.generateLoadBalancer(ctx, opts, outputs)
+
+	case "yorc.nodes.openstack.SecurityGroup":
+		err = g.generateSecurityGroup(ctx, opts, outputs)
+
 	default:
 		err = errors.Errorf("Unsupported node type '%s' for node '%s' in deployment '%s'",
 			opts.nodeType, opts.nodeName, opts.deploymentID)
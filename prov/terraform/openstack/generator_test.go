@@ -60,6 +60,14 @@ func Test_addOutput(t *testing.T) {
 	}
 }
 
+func Test_getOpenStackProviderEnv(t *testing.T) {
+	provider, _ := getOpenStackProviderEnv(config.Configuration{}, config.DynamicMap{"user_name": "user"})
+	require.NotContains(t, provider["openstack"], "use_octavia", "use_octavia should be set only if defined by the location")
+
+	provider, _ = getOpenStackProviderEnv(config.Configuration{}, config.DynamicMap{"use_octavia": true})
+	require.Equal(t, "true", provider["openstack"].(map[string]interface{})["use_octavia"])
+}
+
 func testGenerateTerraformInfo(t *testing.T, srv1 *testutil.TestServer, locationMgr locations.Manager) {
 	t.Parallel()
 	log.SetDebug(true)
@@ -0,0 +1,236 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *osGenerator) generateLoadBalancer(ctx context.Context, opts generateInfraOptions, outputs map[string]string) error {
+	deploymentID := opts.deploymentID
+	nodeName := opts.nodeName
+
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	if nodeType != "yorc.nodes.openstack.LoadBalancer" {
+		return errors.Errorf("Unsupported node type for %q: %s", nodeName, nodeType)
+	}
+
+	region, err := getRegion(ctx, opts, nodeName)
+	if err != nil {
+		return err
+	}
+	name := opts.cfg.ResourcesPrefix + nodeName
+
+	loadBalancer := &LoadBalancer{Region: region, Name: name}
+	loadBalancer.VIPSubnetID, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "vip_subnet_id", true)
+	if err != nil {
+		return err
+	}
+	commons.AddResource(opts.infrastructure, opts.resourceTypes[lbLoadBalancer], name, loadBalancer)
+
+	protocol, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "protocol", false)
+	if err != nil {
+		return err
+	}
+	if protocol == "" {
+		protocol = "HTTP"
+	}
+	protocolPort, err := getIntNodeProperty(ctx, deploymentID, nodeName, "protocol_port", 80)
+	if err != nil {
+		return err
+	}
+	listener := &Listener{
+		Region:         region,
+		Name:           name,
+		Protocol:       protocol,
+		ProtocolPort:   protocolPort,
+		LoadBalancerID: fmt.Sprintf("${%s.%s.id}", opts.resourceTypes[lbLoadBalancer], name),
+	}
+	commons.AddResource(opts.infrastructure, opts.resourceTypes[lbListener], name, listener)
+
+	lbMethod, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "algorithm", false)
+	if err != nil {
+		return err
+	}
+	if lbMethod == "" {
+		lbMethod = "ROUND_ROBIN"
+	}
+	pool := &Pool{
+		Region:     region,
+		Name:       name,
+		Protocol:   protocol,
+		LBMethod:   lbMethod,
+		ListenerID: fmt.Sprintf("${%s.%s.id}", opts.resourceTypes[lbListener], name),
+	}
+	commons.AddResource(opts.infrastructure, opts.resourceTypes[lbPool], name, pool)
+	poolID := fmt.Sprintf("${%s.%s.id}", opts.resourceTypes[lbPool], name)
+
+	err = addLoadBalancerMonitor(ctx, opts, region, name, poolID)
+	if err != nil {
+		return err
+	}
+
+	instanceKey := path.Join(opts.instancesKey, opts.instanceName)
+	idKey := nodeName + "-id"
+	commons.AddOutput(opts.infrastructure, idKey, &commons.Output{
+		Value: fmt.Sprintf("${%s.%s.id}", opts.resourceTypes[lbLoadBalancer], name)})
+	outputs[path.Join(instanceKey, "/attributes/loadbalancer_id")] = idKey
+
+	poolIDKey := nodeName + "-pool-id"
+	commons.AddOutput(opts.infrastructure, poolIDKey, &commons.Output{Value: poolID})
+	outputs[path.Join(instanceKey, "/attributes/pool_id")] = poolIDKey
+
+	vipKey := nodeName + "-vip"
+	commons.AddOutput(opts.infrastructure, vipKey, &commons.Output{
+		Value: fmt.Sprintf("${%s.%s.vip_address}", opts.resourceTypes[lbLoadBalancer], name)})
+	outputs[path.Join(instanceKey, "/attributes/vip_address")] = vipKey
+
+	// Clients connect through the floating IP if any, otherwise through the virtual IP
+	clientIPKey := vipKey
+	floatingNetworkName, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "floating_network_name", false)
+	if err != nil {
+		return err
+	}
+	if floatingNetworkName != "" {
+		floatingIP := &NetworkingFloatingIP{
+			Region: region,
+			Pool:   floatingNetworkName,
+			PortID: fmt.Sprintf("${%s.%s.vip_port_id}", opts.resourceTypes[lbLoadBalancer], name),
+		}
+		commons.AddResource(opts.infrastructure, opts.resourceTypes[networkingFloatingIP], name, floatingIP)
+
+		clientIPKey = nodeName + "-public-vip"
+		commons.AddOutput(opts.infrastructure, clientIPKey, &commons.Output{
+			Value: fmt.Sprintf("${%s.%s.address}", opts.resourceTypes[networkingFloatingIP], name)})
+		outputs[path.Join(instanceKey, "/attributes/public_vip_address")] = clientIPKey
+	}
+	outputs[path.Join(instanceKey, "/capabilities/client/attributes/ip_address")] = clientIPKey
+
+	log.Debugf("Add load balancer %q listening on %s port %d", name, protocol, protocolPort)
+	return nil
+}
+
+func addLoadBalancerMonitor(ctx context.Context, opts generateInfraOptions, region, name, poolID string) error {
+	deploymentID := opts.deploymentID
+	nodeName := opts.nodeName
+
+	monitorType, err := deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "monitor_type", false)
+	if err != nil || monitorType == "" {
+		return err
+	}
+
+	monitor := &Monitor{Region: region, Name: name, PoolID: poolID, Type: monitorType}
+	intParams := []struct {
+		pAttr        *int
+		propertyName string
+		defaultValue int
+	}{
+		{&monitor.Delay, "monitor_delay", 10},
+		{&monitor.Timeout, "monitor_timeout", 5},
+		{&monitor.MaxRetries, "monitor_max_retries", 3},
+	}
+	for _, intParam := range intParams {
+		*intParam.pAttr, err = getIntNodeProperty(ctx, deploymentID, nodeName, intParam.propertyName, intParam.defaultValue)
+		if err != nil {
+			return err
+		}
+	}
+	commons.AddResource(opts.infrastructure, opts.resourceTypes[lbMonitor], name, monitor)
+	return nil
+}
+
+// addLoadBalancerMembership adds a compute instance as a member of the pool of each
+// load balancer required by the compute node.
+// Members are part of the compute instance infrastructure so that they are
+// added and removed when the compute node is scaled out and in.
+func addLoadBalancerMembership(ctx context.Context, opts osInstanceOptions, instance *ComputeInstance) error {
+	deploymentID := opts.deploymentID
+
+	reqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, opts.nodeName, "load_balancer")
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		poolID, err := deployments.LookupInstanceAttributeValue(ctx, deploymentID, req.Node, deployments.DefaultInstanceName, "pool_id")
+		if err != nil {
+			return err
+		}
+		member := &Member{
+			Region: instance.Region,
+			PoolID: poolID,
+			Address: fmt.Sprintf("${%s.%s.network.0.fixed_ip_v4}",
+				opts.resourceTypes[computeInstance], instance.Name),
+		}
+		member.ProtocolPort, err = getLoadBalancerMemberPort(ctx, deploymentID, req.Node)
+		if err != nil {
+			return err
+		}
+		member.SubnetID, err = deployments.GetStringNodeProperty(ctx, deploymentID, req.Node, "member_subnet_id", false)
+		if err != nil {
+			return err
+		}
+		if member.SubnetID == "" {
+			member.SubnetID, err = deployments.GetStringNodeProperty(ctx, deploymentID, req.Node, "vip_subnet_id", true)
+			if err != nil {
+				return err
+			}
+		}
+		commons.AddResource(opts.infrastructure, opts.resourceTypes[lbMember], "Member"+req.Node+"-"+instance.Name, member)
+	}
+	return nil
+}
+
+// getLoadBalancerMemberPort returns the port on which members of a load balancer pool receive traffic
+func getLoadBalancerMemberPort(ctx context.Context, deploymentID, loadBalancerNode string) (int, error) {
+	protocolPort, err := getIntNodeProperty(ctx, deploymentID, loadBalancerNode, "protocol_port", 80)
+	if err != nil {
+		return 0, err
+	}
+	return getIntNodeProperty(ctx, deploymentID, loadBalancerNode, "member_port", protocolPort)
+}
+
+func getIntNodeProperty(ctx context.Context, deploymentID, nodeName, propertyName string, defaultValue int) (int, error) {
+	value, err := deployments.GetNodePropertyValue(ctx, deploymentID, nodeName, propertyName)
+	if err != nil || value == nil || strings.TrimSpace(value.RawString()) == "" {
+		return defaultValue, err
+	}
+	intValue, err := strconv.Atoi(strings.TrimSpace(value.RawString()))
+	if err != nil {
+		return defaultValue, errors.Wrapf(err, "expected an integer for property %q of node %q, got %q",
+			propertyName, nodeName, value.RawString())
+	}
+	return intValue, nil
+}
+
+func getRegion(ctx context.Context, opts generateInfraOptions, nodeName string) (string, error) {
+	region, err := deployments.GetStringNodeProperty(ctx, opts.deploymentID, nodeName, "region", false)
+	if err != nil || region != "" {
+		return region, err
+	}
+	return opts.locationProps.GetStringOrDefault("region", defaultOSRegion), nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstack
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func generateTestLoadBalancer(t *testing.T, deploymentID, nodeName string, infrastructure *commons.Infrastructure, outputs map[string]string) {
+	cfg := config.Configuration{ResourcesPrefix: "yorc-"}
+	locationProps := config.DynamicMap{"region": "RegionOne"}
+	g := osGenerator{}
+	err := g.generateLoadBalancer(context.Background(),
+		generateInfraOptions{
+			cfg:            cfg,
+			infrastructure: infrastructure,
+			locationProps:  locationProps,
+			instancesKey:   path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName),
+			deploymentID:   deploymentID,
+			nodeName:       nodeName,
+			instanceName:   "0",
+			resourceTypes:  getOpenstackResourceTypes(locationProps),
+		}, outputs)
+	require.NoError(t, err, "Unexpected error attempting to generate load balancer %s for %s", nodeName, deploymentID)
+}
+
+func testSimpleLoadBalancer(t *testing.T) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	infrastructure := commons.Infrastructure{}
+	outputs := make(map[string]string)
+	generateTestLoadBalancer(t, deploymentID, "LoadBalancer", &infrastructure, outputs)
+
+	name := "yorc-LoadBalancer"
+	require.Contains(t, infrastructure.Resource, "openstack_lb_loadbalancer_v2")
+	loadBalancer, ok := infrastructure.Resource["openstack_lb_loadbalancer_v2"].(map[string]interface{})[name].(*LoadBalancer)
+	require.True(t, ok, "%s is not a LoadBalancer", name)
+	assert.Equal(t, "RegionTwo", loadBalancer.Region)
+	assert.Equal(t, "a8f2b5c3-4d6e-4f7a-8b9c-0d1e2f3a4b5c", loadBalancer.VIPSubnetID)
+
+	require.Contains(t, infrastructure.Resource, "openstack_lb_listener_v2")
+	listener, ok := infrastructure.Resource["openstack_lb_listener_v2"].(map[string]interface{})[name].(*Listener)
+	require.True(t, ok, "%s is not a Listener", name)
+	assert.Equal(t, "HTTP", listener.Protocol)
+	assert.Equal(t, 80, listener.ProtocolPort)
+	assert.Equal(t, "${openstack_lb_loadbalancer_v2.yorc-LoadBalancer.id}", listener.LoadBalancerID)
+
+	require.Contains(t, infrastructure.Resource, "openstack_lb_pool_v2")
+	pool, ok := infrastructure.Resource["openstack_lb_pool_v2"].(map[string]interface{})[name].(*Pool)
+	require.True(t, ok, "%s is not a Pool", name)
+	assert.Equal(t, "HTTP", pool.Protocol)
+	assert.Equal(t, "LEAST_CONNECTIONS", pool.LBMethod)
+	assert.Equal(t, "${openstack_lb_listener_v2.yorc-LoadBalancer.id}", pool.ListenerID)
+
+	require.Contains(t, infrastructure.Resource, "openstack_lb_monitor_v2")
+	monitor, ok := infrastructure.Resource["openstack_lb_monitor_v2"].(map[string]interface{})[name].(*Monitor)
+	require.True(t, ok, "%s is not a Monitor", name)
+	assert.Equal(t, "HTTP", monitor.Type)
+	assert.Equal(t, "${openstack_lb_pool_v2.yorc-LoadBalancer.id}", monitor.PoolID)
+	assert.Equal(t, 10, monitor.Delay)
+	assert.Equal(t, 5, monitor.Timeout)
+	assert.Equal(t, 5, monitor.MaxRetries)
+
+	require.Contains(t, infrastructure.Resource, "openstack_networking_floatingip_v2")
+	floatingIP, ok := infrastructure.Resource["openstack_networking_floatingip_v2"].(map[string]interface{})[name].(*NetworkingFloatingIP)
+	require.True(t, ok, "%s is not a NetworkingFloatingIP", name)
+	assert.Equal(t, "public-net", floatingIP.Pool)
+	assert.Equal(t, "${openstack_lb_loadbalancer_v2.yorc-LoadBalancer.vip_port_id}", floatingIP.PortID)
+
+	instanceKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", "LoadBalancer", "0")
+	assert.Equal(t, map[string]string{
+		path.Join(instanceKey, "attributes/loadbalancer_id"):                "LoadBalancer-id",
+		path.Join(instanceKey, "attributes/pool_id"):                        "LoadBalancer-pool-id",
+		path.Join(instanceKey, "attributes/vip_address"):                    "LoadBalancer-vip",
+		path.Join(instanceKey, "attributes/public_vip_address"):             "LoadBalancer-public-vip",
+		path.Join(instanceKey, "capabilities/client/attributes/ip_address"): "LoadBalancer-public-vip",
+	}, outputs)
+
+	// Defaults
+	infrastructure = commons.Infrastructure{}
+	outputs = make(map[string]string)
+	generateTestLoadBalancer(t, deploymentID, "MinimalLoadBalancer", &infrastructure, outputs)
+	name = "yorc-MinimalLoadBalancer"
+	loadBalancer = infrastructure.Resource["openstack_lb_loadbalancer_v2"].(map[string]interface{})[name].(*LoadBalancer)
+	assert.Equal(t, "RegionOne", loadBalancer.Region)
+	listener = infrastructure.Resource["openstack_lb_listener_v2"].(map[string]interface{})[name].(*Listener)
+	assert.Equal(t, "HTTP", listener.Protocol)
+	assert.Equal(t, 80, listener.ProtocolPort)
+	pool = infrastructure.Resource["openstack_lb_pool_v2"].(map[string]interface{})[name].(*Pool)
+	assert.Equal(t, "ROUND_ROBIN", pool.LBMethod)
+	assert.NotContains(t, infrastructure.Resource, "openstack_lb_monitor_v2")
+	assert.NotContains(t, infrastructure.Resource, "openstack_networking_floatingip_v2")
+	instanceKey = path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", "MinimalLoadBalancer", "0")
+	assert.Equal(t, "MinimalLoadBalancer-vip", outputs[path.Join(instanceKey, "capabilities/client/attributes/ip_address")])
+	assert.NotContains(t, outputs, path.Join(instanceKey, "attributes/public_vip_address"))
+}
+
+func testOSInstanceWithLoadBalancerAndSecurityGroup(t *testing.T, srv *testutil.TestServer) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	locationProps := config.DynamicMap{
+		"private_network_name":    "test",
+		"default_security_groups": []string{"sec1"},
+	}
+	var cfg config.Configuration
+	g := osGenerator{}
+
+	srv.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/LoadBalancer/0/attributes/pool_id"):              []byte("my_pool_id"),
+		path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology/instances/SecurityGroup/0/attributes/security_group_name"): []byte("my_sg_name"),
+	})
+
+	resourceTypes := getOpenstackResourceTypes(locationProps)
+	infrastructure := commons.Infrastructure{}
+	for _, instanceName := range []string{"0", "1"} {
+		env := make([]string, 0)
+		outputs := make(map[string]string)
+		err := g.generateOSInstance(
+			context.Background(),
+			osInstanceOptions{
+				cfg:            cfg,
+				infrastructure: &infrastructure,
+				locationProps:  locationProps,
+				deploymentID:   deploymentID,
+				nodeName:       "ComputeA",
+				instanceName:   instanceName,
+				resourceTypes:  resourceTypes,
+			},
+			outputs, &env)
+		require.NoError(t, err)
+	}
+
+	instancesMap := infrastructure.Resource["openstack_compute_instance_v2"].(map[string]interface{})
+	require.Len(t, instancesMap, 2)
+	compute, ok := instancesMap["ComputeA-0"].(*ComputeInstance)
+	require.True(t, ok, "ComputeA-0 is not a ComputeInstance")
+	assert.Equal(t, []string{"sec1", "default", "my_sg_name"}, compute.SecurityGroups)
+
+	require.Contains(t, infrastructure.Resource, "openstack_lb_member_v2")
+	membersMap := infrastructure.Resource["openstack_lb_member_v2"].(map[string]interface{})
+	require.Len(t, membersMap, 2)
+	for _, instanceName := range []string{"ComputeA-0", "ComputeA-1"} {
+		member, ok := membersMap["MemberLoadBalancer-"+instanceName].(*Member)
+		require.True(t, ok, "expected a member of LoadBalancer for %s", instanceName)
+		assert.Equal(t, "my_pool_id", member.PoolID)
+		assert.Equal(t, "${openstack_compute_instance_v2."+instanceName+".network.0.fixed_ip_v4}", member.Address)
+		assert.Equal(t, 443, member.ProtocolPort)
+		assert.Equal(t, "0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f", member.SubnetID)
+		assert.Equal(t, defaultOSRegion, member.Region)
+	}
+}
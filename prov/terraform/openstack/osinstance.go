@@ -74,6 +74,18 @@ func (g *osGenerator) generateOSInstance(ctx context.Context, opts osInstanceOpt
 			deploymentID, nodeName, instanceName)
 	}
 
+	err = addSecurityGroupsMembership(ctx, deploymentID, nodeName, &instance)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add security groups membership for deploymentID:%q, nodeName:%q, instance:%q",
+			deploymentID, nodeName, instanceName)
+	}
+
+	err = addLoadBalancerMembership(ctx, opts, &instance)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add load balancer membership for deploymentID:%q, nodeName:%q, instance:%q",
+			deploymentID, nodeName, instanceName)
+	}
+
 	instance.Networks, err = getComputeInstanceNetworks(ctx, opts)
 	if err != nil {
 		return err
@@ -30,7 +30,15 @@ const (
 	computeFloatingIPAssociate = "compute_floatingip_associate"
 	computeServerGroup         = "compute_servergroup"
 	computeVolumeAttach        = "compute_volume_attach"
+	lbLoadBalancer             = "lb_loadbalancer"
+	lbListener                 = "lb_listener"
+	lbPool                     = "lb_pool"
+	lbMember                   = "lb_member"
+	lbMonitor                  = "lb_monitor"
+	networkingFloatingIP       = "networking_floatingip"
 	networkingNetwork          = "networking_network"
+	networkingSecGroup         = "networking_secgroup"
+	networkingSecGroupRule     = "networking_secgroup_rule"
 	networkingSubnet           = "networking_subnet"
 	resourceTypeFormat         = "openstack_%s_%s"
 	version2                   = "v2"
@@ -49,7 +57,9 @@ func getOpenstackResourceTypes(locationProps config.DynamicMap) map[string]strin
 	// Resources for which there is just one possible version supported
 	v2Resources := []string{
 		computeInstance, computeFloatingIP, computeFloatingIPAssociate, computeServerGroup,
-		computeVolumeAttach, networkingNetwork, networkingSubnet,
+		computeVolumeAttach, lbLoadBalancer, lbListener, lbPool, lbMember, lbMonitor,
+		networkingFloatingIP, networkingNetwork, networkingSecGroup, networkingSecGroupRule,
+		networkingSubnet,
 	}
 
 	for _, resource := range v2Resources {
@@ -142,3 +142,83 @@ type ServerGroup struct {
 type SchedulerHints struct {
 	Group string `json:"group"`
 }
+
+// LoadBalancer represents an OpenStack Octavia load balancer
+// https://www.terraform.io/docs/providers/openstack/r/lb_loadbalancer_v2.html
+type LoadBalancer struct {
+	Region      string `json:"region"`
+	Name        string `json:"name"`
+	VIPSubnetID string `json:"vip_subnet_id"`
+}
+
+// Listener represents a load balancer listener
+// https://www.terraform.io/docs/providers/openstack/r/lb_listener_v2.html
+type Listener struct {
+	Region         string `json:"region"`
+	Name           string `json:"name"`
+	Protocol       string `json:"protocol"`
+	ProtocolPort   int    `json:"protocol_port"`
+	LoadBalancerID string `json:"loadbalancer_id"`
+}
+
+// Pool represents a pool of load balancer members
+// https://www.terraform.io/docs/providers/openstack/r/lb_pool_v2.html
+type Pool struct {
+	Region     string `json:"region"`
+	Name       string `json:"name"`
+	Protocol   string `json:"protocol"`
+	LBMethod   string `json:"lb_method"`
+	ListenerID string `json:"listener_id"`
+}
+
+// Member represents a member of a load balancer pool
+// https://www.terraform.io/docs/providers/openstack/r/lb_member_v2.html
+type Member struct {
+	Region       string `json:"region"`
+	PoolID       string `json:"pool_id"`
+	Address      string `json:"address"`
+	ProtocolPort int    `json:"protocol_port"`
+	SubnetID     string `json:"subnet_id,omitempty"`
+}
+
+// Monitor represents a health monitor of a load balancer pool
+// https://www.terraform.io/docs/providers/openstack/r/lb_monitor_v2.html
+type Monitor struct {
+	Region     string `json:"region"`
+	Name       string `json:"name"`
+	PoolID     string `json:"pool_id"`
+	Type       string `json:"type"`
+	Delay      int    `json:"delay"`
+	Timeout    int    `json:"timeout"`
+	MaxRetries int    `json:"max_retries"`
+}
+
+// NetworkingFloatingIP represents a floating IP associated to a port
+// https://www.terraform.io/docs/providers/openstack/r/networking_floatingip_v2.html
+type NetworkingFloatingIP struct {
+	Region string `json:"region"`
+	Pool   string `json:"pool"`
+	PortID string `json:"port_id,omitempty"`
+}
+
+// SecurityGroup represents an OpenStack networking security group
+// https://www.terraform.io/docs/providers/openstack/r/networking_secgroup_v2.html
+type SecurityGroup struct {
+	Region      string `json:"region"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityGroupRule represents a rule of an OpenStack networking security group
+// https://www.terraform.io/docs/providers/openstack/r/networking_secgroup_rule_v2.html
+type SecurityGroupRule struct {
+	Region          string `json:"region"`
+	Direction       string `json:"direction"`
+	EtherType       string `json:"ethertype"`
+	Protocol        string `json:"protocol,omitempty"`
+	PortRangeMin    int    `json:"port_range_min,omitempty"`
+	PortRangeMax    int    `json:"port_range_max,omitempty"`
+	RemoteIPPrefix  string `json:"remote_ip_prefix,omitempty"`
+	RemoteGroupID   string `json:"remote_group_id,omitempty"`
+	SecurityGroupID string `json:"security_group_id"`
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func (g *osGenerator) generateSecurityGroup(ctx context.Context, opts generateInfraOptions, outputs map[string]string) error {
+	deploymentID := opts.deploymentID
+	nodeName := opts.nodeName
+
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return err
+	}
+	if nodeType != "yorc.nodes.openstack.SecurityGroup" {
+		return errors.Errorf("Unsupported node type for %q: %s", nodeName, nodeType)
+	}
+
+	region, err := getRegion(ctx, opts, nodeName)
+	if err != nil {
+		return err
+	}
+	// Security group names should be unique within a project
+	securityGroup := &SecurityGroup{Region: region, Name: opts.cfg.ResourcesPrefix + deploymentID + "-" + nodeName}
+	securityGroup.Description, err = deployments.GetStringNodeProperty(ctx, deploymentID, nodeName, "description", false)
+	if err != nil {
+		return err
+	}
+	if securityGroup.Description == "" {
+		securityGroup.Description = fmt.Sprintf("Security group %s of deployment %s", nodeName, deploymentID)
+	}
+	commons.AddResource(opts.infrastructure, opts.resourceTypes[networkingSecGroup], nodeName, securityGroup)
+	securityGroupID := fmt.Sprintf("${%s.%s.id}", opts.resourceTypes[networkingSecGroup], nodeName)
+
+	endpointRules, err := getSecurityGroupEndpointRules(ctx, deploymentID, nodeName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to compute ingress rules of security group %q", nodeName)
+	}
+	for _, endpointRule := range endpointRules {
+		rule := &SecurityGroupRule{
+			Region:          region,
+			Direction:       "ingress",
+			EtherType:       "IPv4",
+			Protocol:        endpointRule.Protocol,
+			SecurityGroupID: securityGroupID,
+		}
+		// For icmp, port ranges are icmp types and codes
+		if endpointRule.Protocol != "icmp" {
+			rule.PortRangeMin = endpointRule.Port
+			rule.PortRangeMax = endpointRule.Port
+		}
+		if endpointRule.Public {
+			rule.RemoteIPPrefix = "0.0.0.0/0"
+		} else {
+			rule.RemoteGroupID = securityGroupID
+		}
+		ruleName := fmt.Sprintf("%s-%s-%d", nodeName, endpointRule.Protocol, endpointRule.Port)
+		if !endpointRule.Public {
+			ruleName += "-private"
+		}
+		commons.AddResource(opts.infrastructure, opts.resourceTypes[networkingSecGroupRule], ruleName, rule)
+	}
+
+	instanceKey := path.Join(opts.instancesKey, opts.instanceName)
+	idKey := nodeName + "-id"
+	commons.AddOutput(opts.infrastructure, idKey, &commons.Output{Value: securityGroupID})
+	outputs[path.Join(instanceKey, "/attributes/security_group_id")] = idKey
+	nameKey := nodeName + "-name"
+	commons.AddOutput(opts.infrastructure, nameKey, &commons.Output{
+		Value: fmt.Sprintf("${%s.%s.name}", opts.resourceTypes[networkingSecGroup], nodeName)})
+	outputs[path.Join(instanceKey, "/attributes/security_group_name")] = nameKey
+
+	log.Debugf("Add security group %q with %d ingress rules", securityGroup.Name, len(endpointRules))
+	return nil
+}
+
+// getSecurityGroupEndpointRules computes ingress rules of a security group from the endpoint capabilities
+// of Computes requiring this security group and of nodes hosted on these Computes.
+//
+// Load balancers reach their members from their virtual IP subnet, so ports of load balancers
+// pools members are opened to any address.
+func getSecurityGroupEndpointRules(ctx context.Context, deploymentID, securityGroupNode string) ([]commons.EndpointRule, error) {
+	members, err := commons.GetSecurityGroupMembers(ctx, deploymentID, securityGroupNode)
+	if err != nil {
+		return nil, err
+	}
+	var rules []commons.EndpointRule
+	for _, member := range members {
+		memberRules, err := commons.GetEndpointRules(ctx, deploymentID, member)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, memberRules...)
+
+		reqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, member, "load_balancer")
+		if err != nil {
+			return nil, err
+		}
+		for _, req := range reqs {
+			memberPort, err := getLoadBalancerMemberPort(ctx, deploymentID, req.Node)
+			if err != nil {
+				return nil, err
+			}
+			protocol, err := deployments.GetStringNodeProperty(ctx, deploymentID, req.Node, "protocol", false)
+			if err != nil {
+				return nil, err
+			}
+			transportProtocol := "tcp"
+			if protocol == "UDP" {
+				transportProtocol = "udp"
+			}
+			rules = append(rules, commons.EndpointRule{Protocol: transportProtocol, Port: memberPort, Public: true})
+		}
+	}
+	return commons.UniqueEndpointRules(rules), nil
+}
+
+// addSecurityGroupsMembership adds the security groups required by a compute node
+// to the security groups of its instance
+func addSecurityGroupsMembership(ctx context.Context, deploymentID, nodeName string, compute *ComputeInstance) error {
+	reqs, err := deployments.GetRequirementsByTypeForNode(ctx, deploymentID, nodeName, "security_group")
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		name, err := deployments.LookupInstanceAttributeValue(ctx, deploymentID, req.Node, deployments.DefaultInstanceName, "security_group_name")
+		if err != nil {
+			return err
+		}
+		compute.SecurityGroups = append(compute.SecurityGroups, name)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openstack
+
+import (
+	"context"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov/terraform/commons"
+)
+
+func testSimpleOSSecurityGroup(t *testing.T) {
+	t.Parallel()
+	deploymentID := loadTestYaml(t)
+	cfg := config.Configuration{ResourcesPrefix: "yorc-"}
+	locationProps := config.DynamicMap{"region": "RegionTwo"}
+	resourceTypes := getOpenstackResourceTypes(locationProps)
+	g := osGenerator{}
+
+	generate := func(nodeName string) (commons.Infrastructure, map[string]string) {
+		infrastructure := commons.Infrastructure{}
+		outputs := make(map[string]string)
+		err := g.generateSecurityGroup(context.Background(),
+			generateInfraOptions{
+				cfg:            cfg,
+				infrastructure: &infrastructure,
+				locationProps:  locationProps,
+				instancesKey:   path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", nodeName),
+				deploymentID:   deploymentID,
+				nodeName:       nodeName,
+				instanceName:   "0",
+				resourceTypes:  resourceTypes,
+			}, outputs)
+		require.NoError(t, err, "Unexpected error attempting to generate security group %s for %s", nodeName, deploymentID)
+		return infrastructure, outputs
+	}
+
+	infrastructure, outputs := generate("SecurityGroup")
+	require.Contains(t, infrastructure.Resource, "openstack_networking_secgroup_v2")
+	securityGroup, ok := infrastructure.Resource["openstack_networking_secgroup_v2"].(map[string]interface{})["SecurityGroup"].(*SecurityGroup)
+	require.True(t, ok, "SecurityGroup is not a SecurityGroup")
+	assert.Equal(t, "yorc-"+deploymentID+"-SecurityGroup", securityGroup.Name)
+	assert.Equal(t, "Web application security group", securityGroup.Description)
+	assert.Equal(t, "RegionTwo", securityGroup.Region)
+
+	securityGroupID := "${openstack_networking_secgroup_v2.SecurityGroup.id}"
+	require.Contains(t, infrastructure.Resource, "openstack_networking_secgroup_rule_v2")
+	rulesMap := infrastructure.Resource["openstack_networking_secgroup_rule_v2"].(map[string]interface{})
+	expectedRules := map[string]SecurityGroupRule{
+		// Compute admin endpoint
+		"SecurityGroup-tcp-22": {PortRangeMin: 22, PortRangeMax: 22, Protocol: "tcp", RemoteIPPrefix: "0.0.0.0/0"},
+		// Private endpoint of the hosted component
+		"SecurityGroup-udp-7946-private": {PortRangeMin: 7946, PortRangeMax: 7946, Protocol: "udp", RemoteGroupID: securityGroupID},
+		// Public endpoint of the hosted component
+		"SecurityGroup-tcp-8080": {PortRangeMin: 8080, PortRangeMax: 8080, Protocol: "tcp", RemoteIPPrefix: "0.0.0.0/0"},
+		// Load balancer member port
+		"SecurityGroup-tcp-9000": {PortRangeMin: 9000, PortRangeMax: 9000, Protocol: "tcp", RemoteIPPrefix: "0.0.0.0/0"},
+	}
+	require.Len(t, rulesMap, len(expectedRules))
+	for ruleName, expected := range expectedRules {
+		rule, ok := rulesMap[ruleName].(*SecurityGroupRule)
+		require.True(t, ok, "expected a security group rule %s", ruleName)
+		expected.Region = "RegionTwo"
+		expected.Direction = "ingress"
+		expected.EtherType = "IPv4"
+		expected.SecurityGroupID = securityGroupID
+		assert.Equal(t, expected, *rule, "unexpected rule %s", ruleName)
+	}
+
+	instanceKey := path.Join(consulutil.DeploymentKVPrefix, deploymentID, "topology", "instances", "SecurityGroup", "0")
+	assert.Equal(t, map[string]string{
+		path.Join(instanceKey, "attributes/security_group_id"):   "SecurityGroup-id",
+		path.Join(instanceKey, "attributes/security_group_name"): "SecurityGroup-name",
+	}, outputs)
+
+	// A security group without members has no rules
+	infrastructure, _ = generate("UnusedSecurityGroup")
+	securityGroup = infrastructure.Resource["openstack_networking_secgroup_v2"].(map[string]interface{})["UnusedSecurityGroup"].(*SecurityGroup)
+	assert.Equal(t, "Security group UnusedSecurityGroup of deployment "+deploymentID, securityGroup.Description)
+	assert.NotContains(t, infrastructure.Resource, "openstack_networking_secgroup_rule_v2")
+}
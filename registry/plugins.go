@@ -0,0 +1,78 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PluginManager allows to manage plugins at runtime
+type PluginManager interface {
+	// LoadPlugin loads the plugin with the given ID from the plugins directory.
+	//
+	// If the plugin is already loaded then it is upgraded: its registry entries are replaced by the new plugin ones
+	// and in-flight executions on the previous plugin are drained before stopping it.
+	LoadPlugin(pluginID string) error
+	// UnloadPlugin removes registry entries of the plugin with the given ID, drains its in-flight executions and stops it.
+	//
+	// If the plugin is not loaded an error checkable with IsPluginNotFoundError is returned.
+	UnloadPlugin(pluginID string) error
+	// ListPlugins returns the state of loaded plugins
+	ListPlugins() []PluginInfo
+}
+
+const (
+	// PluginStatusRunning is the status of a plugin that successfully passed its last health check
+	PluginStatusRunning = "running"
+	// PluginStatusCrashed is the status of a plugin that failed its last health check and waits to be restarted
+	PluginStatusCrashed = "crashed"
+)
+
+// PluginInfo represents the state of a plugin loaded by a Yorc server
+type PluginInfo struct {
+	ID              string     `json:"id"`
+	Version         string     `json:"version,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	Path            string     `json:"path"`
+	ProtocolVersion int        `json:"protocol_version"`
+	PID             int        `json:"pid,omitempty"`
+	Status          string     `json:"status"`
+	StartTime       time.Time  `json:"start_time"`
+	Restarts        int        `json:"restarts"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorTime   *time.Time `json:"last_error_time,omitempty"`
+}
+
+type pluginNotFoundError struct {
+	pluginID string
+}
+
+func (e pluginNotFoundError) Error() string {
+	return fmt.Sprintf("plugin %q not found", e.pluginID)
+}
+
+// NewPluginNotFoundError returns an error indicating that the given plugin can't be found
+func NewPluginNotFoundError(pluginID string) error {
+	return pluginNotFoundError{pluginID: pluginID}
+}
+
+// IsPluginNotFoundError checks if an error is a "plugin not found" error
+func IsPluginNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(pluginNotFoundError)
+	return ok
+}
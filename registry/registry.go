@@ -36,6 +36,10 @@ type Registry interface {
 	//
 	// If the given nodeType can't match any prov.DelegateExecutor an error is returned
 	GetDelegateExecutor(nodeType string) (prov.DelegateExecutor, error)
+	// Returns the first DelegateMatch that matches the given nodeType
+	//
+	// If the given nodeType can't match any prov.DelegateExecutor an error is returned
+	GetDelegateExecutorMatch(nodeType string) (DelegateMatch, error)
 	// ListDelegateExecutors returns a map of node types matches to prov.DelegateExecutor origin
 	ListDelegateExecutors() []DelegateMatch
 
@@ -79,6 +83,10 @@ type Registry interface {
 	GetActionOperator(actionType string) (prov.ActionOperator, error)
 	// ListActionOperators returns a map of actionTypes matches to prov.ActionOperator origin
 	ListActionOperators() []ActionTypeMatch
+
+	// UnregisterOrigin removes every delegate executor, operation executor, action operator, vault client builder
+	// and infrastructure usage collector registered with the given origin.
+	UnregisterOrigin(origin string)
 }
 
 var defaultReg Registry
@@ -155,18 +163,26 @@ func (r *defaultRegistry) RegisterDelegates(matches []string, executor prov.Dele
 }
 
 func (r *defaultRegistry) GetDelegateExecutor(nodeType string) (prov.DelegateExecutor, error) {
+	m, err := r.GetDelegateExecutorMatch(nodeType)
+	if err != nil {
+		return nil, err
+	}
+	return m.Executor, nil
+}
+
+func (r *defaultRegistry) GetDelegateExecutorMatch(nodeType string) (DelegateMatch, error) {
 	r.delegatesLock.RLock()
 	defer r.delegatesLock.RUnlock()
 	for _, m := range r.delegateMatches {
 		ok, err := regexp.MatchString(m.Match, nodeType)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Failed to match delegate executor from nodeType %q", nodeType)
+			return DelegateMatch{}, errors.Wrapf(err, "Failed to match delegate executor from nodeType %q", nodeType)
 		}
 		if ok {
-			return m.Executor, nil
+			return m, nil
 		}
 	}
-	return nil, errors.Errorf("Unsupported node type %q for a delegate operation", nodeType)
+	return DelegateMatch{}, errors.Errorf("Unsupported node type %q for a delegate operation", nodeType)
 }
 
 func (r *defaultRegistry) ListDelegateExecutors() []DelegateMatch {
@@ -235,9 +251,10 @@ func (r *defaultRegistry) ListVaultClientBuilders() []VaultClientBuilder {
 }
 
 func (r *defaultRegistry) RegisterInfraUsageCollector(name string, infraUsageCollector prov.InfraUsageCollector, origin string) {
-	r.infraUsageCollectorsLock.RLock()
-	defer r.infraUsageCollectorsLock.RUnlock()
-	r.infraUsageCollectors = append([]InfraUsageCollector{{Name: name, Origin: origin, InfraUsageCollector: infraUsageCollector}})
+	r.infraUsageCollectorsLock.Lock()
+	defer r.infraUsageCollectorsLock.Unlock()
+	// Insert as first
+	r.infraUsageCollectors = append([]InfraUsageCollector{{Name: name, Origin: origin, InfraUsageCollector: infraUsageCollector}}, r.infraUsageCollectors...)
 }
 
 func (r *defaultRegistry) GetInfraUsageCollector(name string) (prov.InfraUsageCollector, error) {
@@ -290,3 +307,61 @@ func (r *defaultRegistry) ListActionOperators() []ActionTypeMatch {
 	copy(result, r.actionTypeMatches)
 	return result
 }
+
+func (r *defaultRegistry) UnregisterOrigin(origin string) {
+	func() {
+		r.delegatesLock.Lock()
+		defer r.delegatesLock.Unlock()
+		delegates := r.delegateMatches[:0]
+		for _, m := range r.delegateMatches {
+			if m.Origin != origin {
+				delegates = append(delegates, m)
+			}
+		}
+		r.delegateMatches = delegates
+	}()
+	func() {
+		r.operationsLock.Lock()
+		defer r.operationsLock.Unlock()
+		operations := r.operationMatches[:0]
+		for _, m := range r.operationMatches {
+			if m.Origin != origin {
+				operations = append(operations, m)
+			}
+		}
+		r.operationMatches = operations
+	}()
+	func() {
+		r.actionOperatorsLock.Lock()
+		defer r.actionOperatorsLock.Unlock()
+		actionTypes := r.actionTypeMatches[:0]
+		for _, m := range r.actionTypeMatches {
+			if m.Origin != origin {
+				actionTypes = append(actionTypes, m)
+			}
+		}
+		r.actionTypeMatches = actionTypes
+	}()
+	func() {
+		r.vaultsLock.Lock()
+		defer r.vaultsLock.Unlock()
+		vaults := r.vaultClientBuilders[:0]
+		for _, v := range r.vaultClientBuilders {
+			if v.Origin != origin {
+				vaults = append(vaults, v)
+			}
+		}
+		r.vaultClientBuilders = vaults
+	}()
+	func() {
+		r.infraUsageCollectorsLock.Lock()
+		defer r.infraUsageCollectorsLock.Unlock()
+		collectors := r.infraUsageCollectors[:0]
+		for _, c := range r.infraUsageCollectors {
+			if c.Origin != origin {
+				collectors = append(collectors, c)
+			}
+		}
+		r.infraUsageCollectors = collectors
+	}()
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/prov"
+)
+
+type mockExecutor struct{}
+
+func (m *mockExecutor) ExecDelegate(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
+	return nil
+}
+
+func (m *mockExecutor) ExecAction(ctx context.Context, conf config.Configuration, taskID, deploymentID string, action *prov.Action) (bool, error) {
+	return false, nil
+}
+
+func (m *mockExecutor) GetUsageInfo(ctx context.Context, cfg config.Configuration, taskID, infraName, locationName string, params map[string]string) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestUnregisterOrigin(t *testing.T) {
+	r := &defaultRegistry{}
+	builtin := &mockExecutor{}
+	fromPlugin := &mockExecutor{}
+	r.RegisterDelegates([]string{"yorc.nodes.Compute"}, builtin, BuiltinOrigin)
+	r.RegisterDelegates([]string{"myplugin.nodes.Compute", "yorc.nodes.Compute"}, fromPlugin, "myplugin")
+	r.RegisterActionOperator([]string{"builtin-action"}, builtin, BuiltinOrigin)
+	r.RegisterActionOperator([]string{"plugin-action"}, fromPlugin, "myplugin")
+	r.RegisterInfraUsageCollector("builtin-infra", builtin, BuiltinOrigin)
+	r.RegisterInfraUsageCollector("plugin-infra", fromPlugin, "myplugin")
+
+	require.Len(t, r.ListDelegateExecutors(), 3)
+	require.Len(t, r.ListInfraUsageCollectors(), 2)
+	exec, err := r.GetDelegateExecutor("yorc.nodes.Compute")
+	require.NoError(t, err)
+	require.True(t, exec == fromPlugin, "plugin executor should take precedence over builtin one")
+
+	r.UnregisterOrigin("myplugin")
+
+	require.Len(t, r.ListDelegateExecutors(), 1)
+	exec, err = r.GetDelegateExecutor("yorc.nodes.Compute")
+	require.NoError(t, err)
+	require.True(t, exec == builtin, "builtin executor should be used once plugin is unregistered")
+	_, err = r.GetDelegateExecutor("myplugin.nodes.Compute")
+	require.Error(t, err)
+	_, err = r.GetActionOperator("plugin-action")
+	require.Error(t, err)
+	_, err = r.GetActionOperator("builtin-action")
+	require.NoError(t, err)
+	_, err = r.GetInfraUsageCollector("plugin-infra")
+	require.Error(t, err)
+	_, err = r.GetInfraUsageCollector("builtin-infra")
+	require.NoError(t, err)
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/internal/backup"
+	"github.com/ystia/yorc/v4/log"
+)
+
+func (s *Server) backupHandler(w http.ResponseWriter, r *http.Request) {
+	// Build the archive into a temporary file to be able to return an error if something goes wrong
+	archive, err := ioutil.TempFile("", "yorc-backup-")
+	if err != nil {
+		log.Panic(err)
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+	err = backup.Backup(r.Context(), s.consulClient, archive)
+	if err != nil {
+		if backup.IsMaintenanceInProgressError(err) || backup.IsRunningExecutionsError(err) {
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Panic(err)
+	}
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		log.Panic(err)
+	}
+	w.Header().Set("Content-Type", mimeTypeApplicationZip)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "yorc-backup-"+time.Now().Format("20060102-150405")+".zip"))
+	w.Header().Set("Content-Length", fmt.Sprint(size))
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, archive)
+	if err != nil {
+		log.Printf("[WARNING] failed to send backup archive: %v", err)
+	}
+}
+
+func (s *Server) restoreHandler(w http.ResponseWriter, r *http.Request) {
+	archive, err := ioutil.TempFile("", "yorc-restore-")
+	if err != nil {
+		log.Panic(err)
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+	size, err := io.Copy(archive, r.Body)
+	if err != nil {
+		writeError(w, r, newBadRequestError(errors.Wrap(err, "failed to read backup archive")))
+		return
+	}
+	zipReader, err := zip.NewReader(archive, size)
+	if err != nil {
+		writeError(w, r, newBadRequestError(errors.Wrap(err, "failed to open backup archive")))
+		return
+	}
+
+	err = backup.Restore(r.Context(), s.consulClient, zipReader)
+	if err != nil {
+		if backup.IsInvalidBackupError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		if backup.IsMaintenanceInProgressError(err) || backup.IsRunningExecutionsError(err) {
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
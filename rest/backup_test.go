@@ -0,0 +1,105 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+)
+
+func TestRestoreHandlerBadRequests(t *testing.T) {
+	srv := &Server{router: newRouter()}
+	srv.registerHandlers()
+
+	emptyZip := new(bytes.Buffer)
+	require.NoError(t, zip.NewWriter(emptyZip).Close())
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantCode    int
+	}{
+		{"BadContentType", mimeTypeApplicationJSON, []byte("{}"), http.StatusUnsupportedMediaType},
+		{"NotAZip", mimeTypeApplicationZip, []byte("not a zip"), http.StatusBadRequest},
+		{"NotABackup", mimeTypeApplicationZip, emptyZip.Bytes(), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/server/restore", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			srv.router.ServeHTTP(w, req)
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+func testNewStorageMigrationHandlerBadRequests(t *testing.T, client *api.Client, cfg config.Configuration) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantCode    int
+	}{
+		{"BadContentType", mimeTypeApplicationZip, []byte("{}"), http.StatusUnsupportedMediaType},
+		{"NotJSON", mimeTypeApplicationJSON, []byte("not json"), http.StatusBadRequest},
+		{"MissingStores", mimeTypeApplicationJSON, []byte(`{"types":["Log"]}`), http.StatusBadRequest},
+		{"SameStores", mimeTypeApplicationJSON, []byte(`{"from":"s1","to":"s1"}`), http.StatusBadRequest},
+		{"UnknownType", mimeTypeApplicationJSON, []byte(`{"from":"s1","to":"s2","types":["log"]}`), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/server/storage/migrations", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Header.Set("Accept", mimeTypeApplicationJSON)
+			resp := newTestHTTPRouter(client, cfg, req)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}
+
+func testRotateStoreKeyHandlerErrors(t *testing.T, client *api.Client, cfg config.Configuration) {
+	tests := []struct {
+		name     string
+		body     []byte
+		wantCode int
+	}{
+		{"NotJSON", []byte("not json"), http.StatusBadRequest},
+		{"UnknownStore", []byte(`{"key_id":"key2"}`), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/server/storage/stores/unknownStore/rotate_key", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", mimeTypeApplicationJSON)
+			req.Header.Set("Accept", mimeTypeApplicationJSON)
+			resp := newTestHTTPRouter(client, cfg, req)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/server/storage/stores/unknownStore/rotate_key", nil)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp := newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
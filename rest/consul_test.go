@@ -94,5 +94,26 @@ func TestRunConsulRestPackageTests(t *testing.T) {
 		t.Run("testDeploymentTaskHandlers", func(t *testing.T) {
 			testDeploymentTaskHandlers(t, client, cfg, srv)
 		})
+		t.Run("testDeploymentDriftHandlers", func(t *testing.T) {
+			testDeploymentDriftHandlers(t, client, cfg, srv)
+		})
+		t.Run("testRegistryPluginsHandlers", func(t *testing.T) {
+			testRegistryPluginsHandlers(t, client, cfg)
+		})
+		t.Run("testPluginsManagementHandlers", func(t *testing.T) {
+			testPluginsManagementHandlers(t, client)
+		})
+		t.Run("testNewStorageMigrationHandlerBadRequests", func(t *testing.T) {
+			testNewStorageMigrationHandlerBadRequests(t, client, cfg)
+		})
+		t.Run("testRotateStoreKeyHandlerErrors", func(t *testing.T) {
+			testRotateStoreKeyHandlerErrors(t, client, cfg)
+		})
+		t.Run("testImportDeploymentHandlerBadRequests", func(t *testing.T) {
+			testImportDeploymentHandlerBadRequests(t, client, cfg)
+		})
+		t.Run("testMaintenanceHandler", func(t *testing.T) {
+			testMaintenanceHandler(t, client, cfg)
+		})
 	})
 }
@@ -109,6 +109,7 @@ func (s *Server) newCustomCommandHandler(w http.ResponseWriter, r *http.Request)
 		data[path.Join("inputs", name)] = ccRequest.Inputs[name].String()
 	}
 
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
 	taskID, err := s.tasksCollector.RegisterTaskWithData(id, tasks.TaskTypeCustomCommand, data)
 	if err != nil {
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
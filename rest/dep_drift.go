@@ -0,0 +1,103 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+func (s *Server) newDriftCheckHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+
+	status, err := deployments.GetDeploymentStatus(ctx, id)
+	if err != nil {
+		if deployments.IsDeploymentNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panicf("%v", err)
+	}
+	if status != deployments.DEPLOYED {
+		writeError(w, r, newBadRequestError(errors.Errorf("drift of deployment %q can't be checked as its status is %q", id, status.String())))
+		return
+	}
+	if !checkBlockingOperationOnDeployment(ctx, id, w, r) {
+		return
+	}
+
+	data := make(map[string]string)
+	for _, nodeName := range r.URL.Query()["node"] {
+		nodeExists, err := deployments.DoesNodeExist(ctx, id, nodeName)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		if !nodeExists {
+			writeError(w, r, newBadRequestParameter("node", errors.Errorf("Node %q must exist", nodeName)))
+			return
+		}
+		data[path.Join("nodes", nodeName)] = ""
+	}
+
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
+	taskID, err := s.tasksCollector.RegisterTaskWithData(id, tasks.TaskTypeDriftCheck, data)
+	if err != nil {
+		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		log.Panic(err)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/deployments/%s/tasks/%s", id, taskID))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) getDriftHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+
+	exists, err := deployments.DoesDeploymentExists(ctx, id)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if !exists {
+		writeError(w, r, errNotFound)
+		return
+	}
+
+	reports, err := deployments.GetDriftReports(ctx, id)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if len(reports) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	encodeJSONResponse(w, r, DriftReportsCollection{Reports: reports})
+}
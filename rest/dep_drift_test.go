@@ -0,0 +1,112 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+func testDeploymentDriftHandlers(t *testing.T, client *api.Client, cfg config.Configuration, srv *testutil.TestServer) {
+	t.Run("testGetDrift", func(t *testing.T) {
+		testGetDrift(t, client, cfg, srv)
+	})
+	t.Run("testNewDriftCheck", func(t *testing.T) {
+		testNewDriftCheck(t, client, cfg, srv)
+	})
+}
+
+func testGetDrift(t *testing.T, client *api.Client, cfg config.Configuration, srv *testutil.TestServer) {
+	t.Parallel()
+	deploymentID := "testGetDrift"
+	prepareTest(t, deploymentID, client, srv)
+	defer cleanTest(deploymentID, "")
+
+	req := httptest.NewRequest("GET", "/deployments/noDeployment/drift", nil)
+	req.Header.Add("Accept", mimeTypeApplicationJSON)
+	resp := newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req = httptest.NewRequest("GET", "/deployments/"+deploymentID+"/drift", nil)
+	req.Header.Add("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	err := deployments.StoreDriftReport(context.Background(), deploymentID, prov.DriftReport{NodeName: "Compute", TaskID: "t1", Drifted: true,
+		Changes: []string{"~ google_compute_instance.compute-0"}, Summary: "Plan: 0 to add, 1 to change, 0 to destroy."})
+	require.NoError(t, err)
+
+	req = httptest.NewRequest("GET", "/deployments/"+deploymentID+"/drift", nil)
+	req.Header.Add("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var collection DriftReportsCollection
+	require.NoError(t, json.Unmarshal(body, &collection))
+	require.Len(t, collection.Reports, 1)
+	require.Equal(t, "Compute", collection.Reports[0].NodeName)
+	require.True(t, collection.Reports[0].Drifted)
+	require.Equal(t, []string{"~ google_compute_instance.compute-0"}, collection.Reports[0].Changes)
+}
+
+func testNewDriftCheck(t *testing.T, client *api.Client, cfg config.Configuration, srv *testutil.TestServer) {
+	t.Parallel()
+	deploymentID := "testNewDriftCheck"
+	prepareTest(t, deploymentID, client, srv)
+
+	req := httptest.NewRequest("POST", "/deployments/noDeployment/drift", nil)
+	resp := newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req = httptest.NewRequest("POST", "/deployments/"+deploymentID+"/drift?node=Unknown", nil)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req = httptest.NewRequest("POST", "/deployments/"+deploymentID+"/drift?node=Compute", nil)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	location := resp.Header.Get("Location")
+	require.True(t, strings.HasPrefix(location, "/deployments/"+deploymentID+"/tasks/"), "unexpected location %q", location)
+	taskID := path.Base(location)
+	defer cleanTest(deploymentID, taskID)
+
+	taskType, err := tasks.GetTaskType(taskID)
+	require.NoError(t, err)
+	require.Equal(t, tasks.TaskTypeDriftCheck, taskType)
+	nodes, err := tasks.GetTaskRelatedNodes(taskID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Compute"}, nodes)
+
+	// Only one drift check at a time
+	req = httptest.NewRequest("POST", "/deployments/"+deploymentID+"/drift", nil)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/internal/operations"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// postDeploymentHandler handles POST /deployments/import. It can't be registered as a dedicated route
+// as it conflicts with POST /deployments/:id/... routes.
+func (s *Server) postDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	if params.ByName("id") != reservedDeploymentID {
+		writeError(w, r, errNotFound)
+		return
+	}
+	if r.Header.Get("Content-Type") != mimeTypeApplicationZip {
+		writeError(w, r, newUnsupportedMediaTypeError(mimeTypeApplicationZip))
+		return
+	}
+	s.importDeploymentHandler(w, r)
+}
+
+func (s *Server) exportDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+
+	if !checkBlockingOperationOnDeployment(ctx, id, w, r) {
+		return
+	}
+
+	// Build the archive into a temporary file to be able to return an error if something goes wrong
+	archive, err := ioutil.TempFile("", "yorc-export-")
+	if err != nil {
+		log.Panic(err)
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+	err = operations.ExportDeployment(ctx, id, s.config.WorkingDirectory, archive)
+	if err != nil {
+		if deployments.IsDeploymentNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		log.Panic(err)
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Panic(err)
+	}
+	_, err = archive.Seek(0, io.SeekStart)
+	if err != nil {
+		log.Panic(err)
+	}
+	w.Header().Set("Content-Type", mimeTypeApplicationZip)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".zip"))
+	w.Header().Set("Content-Length", fmt.Sprint(size))
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, archive)
+	if err != nil {
+		log.Printf("[WARNING] failed to send export archive of deployment %q: %v", id, err)
+	}
+}
+
+func (s *Server) importDeploymentHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id != "" {
+		matched, err := regexp.MatchString(YorcDeploymentIDPattern, id)
+		if err != nil {
+			log.Panicf("%v", errors.Wrapf(err, "Failed to parse given deployment id %q", id))
+		}
+		if !matched {
+			writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should respect the following format: %q", YorcDeploymentIDPattern)))
+			return
+		}
+		if id == reservedDeploymentID {
+			writeError(w, r, newBadRequestError(errors.Errorf("%q can't be used as deployment id", id)))
+			return
+		}
+	}
+
+	archive, err := ioutil.TempFile("", "yorc-import-")
+	if err != nil {
+		log.Panic(err)
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+	size, err := io.Copy(archive, r.Body)
+	if err != nil {
+		writeError(w, r, newBadRequestError(errors.Wrap(err, "failed to read deployment archive")))
+		return
+	}
+	zipReader, err := zip.NewReader(archive, size)
+	if err != nil {
+		writeError(w, r, newBadRequestError(errors.Wrap(err, "failed to open deployment archive")))
+		return
+	}
+
+	ctx := r.Context()
+	result, err := operations.ImportDeployment(ctx, zipReader, id, s.config.WorkingDirectory)
+	if err != nil {
+		if operations.IsInvalidArchiveError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		if operations.IsDeploymentAlreadyExistsError(err) {
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+	// Remove events and logs of a previously purged deployment with the same ID
+	// as they are not part of the imported deployment
+	err = deployments.CleanupPurgedDeployments(context.Background(), s.consulClient, s.config.PurgedDeploymentsEvictionTimeout, result.DeploymentID)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/deployments/%s", result.DeploymentID))
+	w.Header().Set("Content-Type", mimeTypeApplicationJSON)
+	w.WriteHeader(http.StatusCreated)
+	encodeJSONResponse(w, r, DeploymentImportResult{
+		DeploymentID:         result.DeploymentID,
+		OriginalDeploymentID: result.OriginalDeploymentID,
+		RemappedTasks:        result.RemappedTasks,
+	})
+}
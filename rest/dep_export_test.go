@@ -0,0 +1,101 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+)
+
+func testImportDeploymentHandlerBadRequests(t *testing.T, client *api.Client, cfg config.Configuration) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{"NotImportPath", "/deployments/myDep", mimeTypeApplicationZip, "", http.StatusNotFound},
+		{"BadContentType", "/deployments/import", mimeTypeApplicationJSON, "{}", http.StatusUnsupportedMediaType},
+		{"BadDeploymentID", "/deployments/import?id=my%20dep", mimeTypeApplicationZip, "", http.StatusBadRequest},
+		{"ReservedDeploymentID", "/deployments/import?id=import", mimeTypeApplicationZip, "", http.StatusBadRequest},
+		{"NotAZip", "/deployments/import", mimeTypeApplicationZip, "not a zip", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			resp := newTestHTTPRouter(client, cfg, req)
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}
+
+func testMaintenanceHandler(t *testing.T, client *api.Client, cfg config.Configuration) {
+	sessionID, _, err := client.Session().Create(&api.SessionEntry{TTL: "10s", Behavior: api.SessionBehaviorDelete}, nil)
+	require.NoError(t, err)
+	defer client.Session().Destroy(sessionID, nil)
+	acquired, _, err := client.KV().Acquire(&api.KVPair{Key: consulutil.MaintenanceLockKey, Session: sessionID}, nil)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	defer client.KV().Release(&api.KVPair{Key: consulutil.MaintenanceLockKey, Session: sessionID}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/deployments/maintenanceDep/workflows/install", nil)
+	resp := newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/deployments/import", strings.NewReader("not a zip"))
+	req.Header.Set("Content-Type", mimeTypeApplicationZip)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/server/storage/migrations", strings.NewReader(`{"from":"s1","to":"s2"}`))
+	req.Header.Set("Content-Type", mimeTypeApplicationJSON)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/server/storage/stores/myStore/rotate_key", nil)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		req = httptest.NewRequest(method, "/registry/plugins/myPlugin", nil)
+		resp = newTestHTTPRouter(client, cfg, req)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "%s of a plugin should be rejected", method)
+	}
+
+	// Read requests are still allowed
+	req = httptest.NewRequest(http.MethodGet, "/deployments/maintenanceDep", nil)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Plugins requests are handled once the maintenance ends, plugins management is not available in tests
+	_, _, err = client.KV().Release(&api.KVPair{Key: consulutil.MaintenanceLockKey, Session: sessionID}, nil)
+	require.NoError(t, err)
+	req = httptest.NewRequest(http.MethodPut, "/registry/plugins/myPlugin", nil)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -86,9 +86,9 @@ func (s *Server) scaleHandler(w http.ResponseWriter, r *http.Request) {
 	log.Debugf("Scaling %d instances of node %q", instancesDelta, nodeName)
 	var taskID string
 	if instancesDelta > 0 {
-		taskID, err = s.scaleOut(ctx, id, nodeName, uint32(instancesDelta))
+		taskID, err = s.scaleOut(ctx, id, nodeName, uint32(instancesDelta), getTaskCreator(r))
 	} else {
-		taskID, err = s.scaleIn(ctx, id, nodeName, uint32(-instancesDelta))
+		taskID, err = s.scaleIn(ctx, id, nodeName, uint32(-instancesDelta), getTaskCreator(r))
 	}
 	if err != nil {
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
@@ -105,7 +105,7 @@ func (s *Server) scaleHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 }
 
-func (s *Server) scaleOut(ctx context.Context, id, nodeName string, instancesDelta uint32) (string, error) {
+func (s *Server) scaleOut(ctx context.Context, id, nodeName string, instancesDelta uint32, creator string) (string, error) {
 	maxInstances, err := deployments.GetMaxNbInstancesForNode(ctx, id, nodeName)
 	if err != nil {
 		return "", err
@@ -128,10 +128,11 @@ func (s *Server) scaleOut(ctx context.Context, id, nodeName string, instancesDel
 	data["instancesDelta"] = strconv.Itoa(int(instancesDelta))
 	data["workflowName"] = "install"
 	data["nodeName"] = nodeName
+	data[tasks.CreatorDataKey] = creator
 	return s.tasksCollector.RegisterTaskWithData(id, tasks.TaskTypeScaleOut, data)
 }
 
-func (s *Server) scaleIn(ctx context.Context, id, nodeName string, instancesDelta uint32) (string, error) {
+func (s *Server) scaleIn(ctx context.Context, id, nodeName string, instancesDelta uint32, creator string) (string, error) {
 	minInstances, err := deployments.GetMinNbInstancesForNode(ctx, id, nodeName)
 	if err != nil {
 		return "", err
@@ -161,6 +162,7 @@ func (s *Server) scaleIn(ctx context.Context, id, nodeName string, instancesDelt
 	// Add related workflow
 	data["workflowName"] = "uninstall"
 
+	data[tasks.CreatorDataKey] = creator
 	return s.tasksCollector.RegisterTaskWithData(id, tasks.TaskTypeScaleIn, data)
 
 }
@@ -18,7 +18,9 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
+	"sort"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
@@ -113,9 +115,95 @@ func (s *Server) getTaskHandler(w http.ResponseWriter, r *http.Request) {
 		log.Panic(err)
 	}
 	task.ErrorMessage = taskErrorMessage
+
+	timing, err := tasks.GetTaskTiming(taskID)
+	if err != nil {
+		log.Panic(err)
+	}
+	task.CreatedBy = timing.CreatedBy
+	task.CreationDate = timing.CreationDate
+	task.StartDate = timing.StartDate
+	task.EndDate = timing.EndDate
+	task.Retries = timing.Retries
 	encodeJSONResponse(w, r, task)
 }
 
+func (s *Server) getTaskTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	var params httprouter.Params
+	ctx := r.Context()
+	params = ctx.Value(paramsLookupKey).(httprouter.Params)
+	id := params.ByName("id")
+	taskID := params.ByName("taskId")
+
+	if !s.tasksPreChecks(w, r, id, taskID) {
+		return
+	}
+
+	timeline := TaskTimeline{ID: taskID, TargetID: id}
+	status, err := tasks.GetTaskStatus(taskID)
+	if err != nil {
+		log.Panic(err)
+	}
+	timeline.Status = status.String()
+
+	taskType, err := tasks.GetTaskType(taskID)
+	if err != nil {
+		log.Panic(err)
+	}
+	timeline.Type = taskType.String()
+
+	timing, err := tasks.GetTaskTiming(taskID)
+	if err != nil {
+		log.Panic(err)
+	}
+	timeline.CreatedBy = timing.CreatedBy
+	timeline.CreationDate = timing.CreationDate
+	timeline.StartDate = timing.StartDate
+	timeline.EndDate = timing.EndDate
+	timeline.Retries = timing.Retries
+
+	timeline.Steps, err = tasks.GetTaskRelatedSteps(taskID)
+	if err != nil {
+		log.Panic(err)
+	}
+	sortStepsByStartDate(timeline.Steps)
+	encodeJSONResponse(w, r, timeline)
+}
+
+// sortStepsByStartDate sorts steps by start date, steps that did not start yet are sorted by name at the end
+func sortStepsByStartDate(steps []tasks.TaskStep) {
+	sort.Slice(steps, func(i, j int) bool {
+		si, sj := steps[i].StartDate, steps[j].StartDate
+		switch {
+		case si != nil && sj != nil:
+			if si.Equal(*sj) {
+				return steps[i].Name < steps[j].Name
+			}
+			return si.Before(*sj)
+		case si != nil:
+			return true
+		case sj != nil:
+			return false
+		default:
+			return steps[i].Name < steps[j].Name
+		}
+	})
+}
+
+// getTaskCreator returns the identity of the client creating a task with the given request.
+//
+// It is the common name of the client certificate if any, otherwise the client address.
+func getTaskCreator(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 && r.TLS.PeerCertificates[0].Subject.CommonName != "" {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func (s *Server) getTaskStepsHandler(w http.ResponseWriter, r *http.Request) {
 	var params httprouter.Params
 	ctx := r.Context()
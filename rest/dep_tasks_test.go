@@ -15,11 +15,15 @@
 package rest
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
@@ -27,6 +31,7 @@ import (
 
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/tasks"
 )
 
 func testDeploymentTaskHandlers(t *testing.T, client *api.Client, cfg config.Configuration, srv *testutil.TestServer) {
@@ -114,3 +119,30 @@ func testGetTaskHandlerWithTaskNotFound(t *testing.T, client *api.Client, cfg co
 	require.NotNil(t, resp, "unexpected nil response")
 	require.Equal(t, http.StatusNotFound, resp.StatusCode, "unexpected status code %d instead of %d", resp.StatusCode, http.StatusNotFound)
 }
+
+func TestSortStepsByStartDate(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Minute)
+	steps := []tasks.TaskStep{
+		{Name: "notStartedB"},
+		{Name: "second", StartDate: &later},
+		{Name: "notStartedA"},
+		{Name: "firstB", StartDate: &now},
+		{Name: "firstA", StartDate: &now},
+	}
+	sortStepsByStartDate(steps)
+	names := make([]string, len(steps))
+	for i := range steps {
+		names[i] = steps[i].Name
+	}
+	require.Equal(t, []string{"firstA", "firstB", "second", "notStartedA", "notStartedB"}, names)
+}
+
+func TestGetTaskCreator(t *testing.T) {
+	req := httptest.NewRequest("GET", "/deployments/myDepID/tasks/task123", nil)
+	req.RemoteAddr = "10.0.0.1:34567"
+	require.Equal(t, "10.0.0.1", getTaskCreator(req))
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "john"}}}}
+	require.Equal(t, "john", getTaskCreator(req))
+}
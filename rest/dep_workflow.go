@@ -137,6 +137,7 @@ func (s *Server) newWorkflowHandler(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
 	taskID, err := s.tasksCollector.RegisterTaskWithData(deploymentID, tasks.TaskTypeCustomWorkflow, data)
 	if err != nil {
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
@@ -147,6 +147,10 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 			writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should respect the following format: %q", YorcDeploymentIDPattern)))
 			return
 		}
+		if id == reservedDeploymentID {
+			writeError(w, r, newBadRequestError(errors.Errorf("%q can't be used as deployment id", id)))
+			return
+		}
 		// Do not impose a max id length as it doesn't have a concrete impact for now
 		// if len(id) > YorcDeploymentIDMaxLength {
 		// 	writeError(w, r, newBadRequestError(errors.Errorf("Deployment id should be less than %d characters (actual size %d)", YorcDeploymentIDMaxLength, len(id))))
@@ -201,6 +205,7 @@ func (s *Server) newDeploymentHandler(w http.ResponseWriter, r *http.Request) {
 	data := map[string]string{
 		"workflowName": "install",
 	}
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
 	taskID, err := s.tasksCollector.RegisterTaskWithData(uid, tasks.TaskTypeDeploy, data)
 	if err != nil {
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
@@ -298,6 +303,7 @@ func (s *Server) deleteDeploymentHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	data["continueOnError"] = strconv.FormatBool(!stopOnError)
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
 	if taskID, err := s.tasksCollector.RegisterTaskWithData(id, taskType, data); err != nil {
 		log.Debugf("register task has returned an err:%q", err.Error())
 		if ok, _ := tasks.IsAnotherLivingTaskAlreadyExistsError(err); ok {
@@ -309,7 +315,7 @@ func (s *Server) deleteDeploymentHandler(w http.ResponseWriter, r *http.Request)
 		// Inconsistent deployment: force purge enters in action
 		if ok := deployments.IsInconsistentDeploymentError(err); ok {
 			log.Debugf("inconsistent deployment with ID:%q. We force purge it.", id)
-			newTaskID, err := s.tasksCollector.RegisterTask(id, tasks.TaskTypeForcePurge)
+			newTaskID, err := s.tasksCollector.RegisterTaskWithData(id, tasks.TaskTypeForcePurge, map[string]string{tasks.CreatorDataKey: getTaskCreator(r)})
 			if err != nil {
 				log.Printf("Failed to force purge deployment with ID:%q due to error:%+v", id, err)
 				writeError(w, r, newInternalServerError(err))
@@ -81,3 +81,7 @@ func newConflictRequest(message string) *Error {
 func newForbiddenRequest(message string) *Error {
 	return &Error{"forbidden", http.StatusForbidden, "Forbidden", message}
 }
+
+func newServiceUnavailableError(message string) *Error {
+	return &Error{"service_unavailable", http.StatusServiceUnavailable, "Service Unavailable", message}
+}
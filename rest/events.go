@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -25,6 +26,7 @@ import (
 	"github.com/ystia/yorc/v4/deployments"
 	"github.com/ystia/yorc/v4/events"
 	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage/store"
 )
 
 func (s *Server) pollEvents(w http.ResponseWriter, r *http.Request) {
@@ -163,3 +165,83 @@ func (s *Server) headLogsEventsIndex(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add(YorcIndexHeader, strconv.FormatUint(lastIdx, 10))
 	w.WriteHeader(http.StatusOK)
 }
+
+func (s *Server) searchLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query, paramErr := parseLogSearchQuery(r)
+	if paramErr != nil {
+		writeError(w, r, paramErr)
+		return
+	}
+	if query.DeploymentID != "" {
+		if depExist, err := deployments.DoesDeploymentExists(ctx, query.DeploymentID); err != nil {
+			log.Panic(err)
+		} else if !depExist {
+			writeError(w, r, errNotFound)
+			return
+		}
+	}
+
+	result, err := events.SearchLogs(ctx, query)
+	if store.IsTooManyLogsError(err) {
+		writeError(w, r, newBadRequestError(err))
+		return
+	}
+	if err != nil {
+		log.Panicf("Can't search logs: %v", err)
+	}
+	searchResult := LogsSearchResult{Total: result.Total, Logs: result.Logs}
+	for _, bucket := range result.Histogram {
+		searchResult.Histogram = append(searchResult.Histogram, LogsHistogramBucket{Start: bucket.Start, Count: bucket.Count})
+	}
+	encodeJSONResponse(w, r, searchResult)
+}
+
+// parseLogSearchQuery builds a logs search query from the request parameters
+func parseLogSearchQuery(r *http.Request) (store.LogSearchQuery, *Error) {
+	values := r.URL.Query()
+	query := store.LogSearchQuery{
+		DeploymentID: values.Get("deployment"),
+		Contains:     values.Get("contains"),
+		NodePattern:  values.Get("node"),
+	}
+	var err error
+	for _, levels := range values["level"] {
+		for _, level := range strings.Split(levels, ",") {
+			logLevel, err := events.ParseLogLevel(strings.ToUpper(strings.TrimSpace(level)))
+			if err != nil {
+				return query, newBadRequestParameter("level", err)
+			}
+			query.Levels = append(query.Levels, logLevel.String())
+		}
+	}
+	if from := values.Get("from"); from != "" {
+		if query.From, err = time.Parse(time.RFC3339Nano, from); err != nil {
+			return query, newBadRequestParameter("from", err)
+		}
+	}
+	if to := values.Get("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339Nano, to); err != nil {
+			return query, newBadRequestParameter("to", err)
+		}
+	}
+	if interval := values.Get("interval"); interval != "" {
+		if query.Interval, err = time.ParseDuration(interval); err != nil {
+			return query, newBadRequestParameter("interval", err)
+		}
+	}
+	if offset := values.Get("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			return query, newBadRequestParameter("offset", err)
+		}
+	}
+	if size := values.Get("size"); size != "" {
+		if query.Size, err = strconv.Atoi(size); err != nil {
+			return query, newBadRequestParameter("size", err)
+		}
+	}
+	if err = query.Validate(); err != nil {
+		return query, newBadRequestError(err)
+	}
+	return query, nil
+}
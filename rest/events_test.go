@@ -0,0 +1,68 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchLogsBadParameters(t *testing.T) {
+	srv := &Server{router: newRouter()}
+	srv.registerHandlers()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"UnknownLevel", "level=INFO,VERBOSE"},
+		{"InvalidFrom", "from=yesterday"},
+		{"InvalidTo", "to=2020-01-01"},
+		{"FromAfterTo", "from=2020-01-02T00:00:00Z&to=2020-01-01T00:00:00Z"},
+		{"InvalidInterval", "interval=1"},
+		{"NegativeOffset", "offset=-1"},
+		{"InvalidSize", "size=ten"},
+		{"SizeTooLarge", "size=20000"},
+		{"InvalidNodePattern", "node=Compute("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/logs/search?"+tt.query, nil)
+			req.Header.Set("Accept", mimeTypeApplicationJSON)
+			w := httptest.NewRecorder()
+			srv.router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestParseLogSearchQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/logs/search?deployment=dep&contains=ansible+playbook&node=Comp.*&level=info,Error&level=debug&from=2020-01-01T10:00:00Z&to=2020-01-01T11:00:00Z&interval=5m&offset=10&size=20", nil)
+	query, err := parseLogSearchQuery(req)
+	require.Nil(t, err)
+	require.Equal(t, "dep", query.DeploymentID)
+	require.Equal(t, "ansible playbook", query.Contains)
+	require.Equal(t, "Comp.*", query.NodePattern)
+	require.Equal(t, []string{"INFO", "ERROR", "DEBUG"}, query.Levels)
+	require.Equal(t, "2020-01-01T10:00:00Z", query.From.Format(time.RFC3339))
+	require.Equal(t, "2020-01-01T11:00:00Z", query.To.Format(time.RFC3339))
+	require.Equal(t, 5*time.Minute, query.Interval)
+	require.Equal(t, 10, query.Offset)
+	require.Equal(t, 20, query.Size)
+}
@@ -14,9 +14,22 @@
 
 package rest
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ystia/yorc/v4/registry"
+)
 
 func (s *Server) getHealthHandler(w http.ResponseWriter, r *http.Request) {
-	health := Health{"passing"}
+	health := Health{Value: "passing"}
+	if s.pluginMgr != nil {
+		for _, p := range s.pluginMgr.ListPlugins() {
+			if p.Status != registry.PluginStatusRunning {
+				health.Value = "warning"
+				health.Details = append(health.Details, fmt.Sprintf("plugin %q is %s: %s", p.ID, p.Status, p.LastError))
+			}
+		}
+	}
 	encodeJSONResponse(w, r, health)
 }
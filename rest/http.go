@@ -31,6 +31,7 @@ import (
 	"github.com/ystia/yorc/v4/locations"
 	"github.com/ystia/yorc/v4/log"
 	"github.com/ystia/yorc/v4/prov/hostspool"
+	"github.com/ystia/yorc/v4/registry"
 	"github.com/ystia/yorc/v4/tasks/collector"
 )
 
@@ -97,6 +98,7 @@ type Server struct {
 	config         config.Configuration
 	hostsPoolMgr   hostspool.Manager
 	locationMgr    locations.Manager
+	pluginMgr      registry.PluginManager
 }
 
 // Shutdown stops the HTTP server
@@ -111,7 +113,7 @@ func (s *Server) Shutdown() {
 }
 
 // NewServer create a Server to serve the REST API
-func NewServer(configuration config.Configuration, client *api.Client, shutdownCh chan struct{}) (*Server, error) {
+func NewServer(configuration config.Configuration, client *api.Client, pluginMgr registry.PluginManager, shutdownCh chan struct{}) (*Server, error) {
 	addr, err := getAddress(configuration)
 	if err != nil {
 		return nil, err
@@ -136,6 +138,7 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 		config:         configuration,
 		hostsPoolMgr:   hostspool.NewManager(client, configuration),
 		locationMgr:    locations.NewManager(client, configuration),
+		pluginMgr:      pluginMgr,
 	}
 
 	httpServer.registerHandlers()
@@ -157,12 +160,20 @@ func NewServer(configuration config.Configuration, client *api.Client, shutdownC
 
 func (s *Server) registerHandlers() {
 	commonHandlers := alice.New(telemetryHandler, loggingHandler, recoverHandler)
+	// Requests modifying deployments, tasks or resources are rejected during maintenance operations
+	writeHandlers := commonHandlers.Append(maintenanceHandler)
 	s.router.Get("/server/info", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getInfoHandler))
 	s.router.Get("/server/health", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getHealthHandler))
-	s.router.Post("/deployments", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.newDeploymentHandler))
-	s.router.Put("/deployments/:id", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.newDeploymentHandler))
-	s.router.Patch("/deployments/:id", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.updateDeploymentHandler))
-	s.router.Delete("/deployments/:id", commonHandlers.ThenFunc(s.deleteDeploymentHandler))
+	s.router.Get("/server/backup", commonHandlers.Append(acceptHandler(mimeTypeApplicationZip)).ThenFunc(s.backupHandler))
+	s.router.Post("/server/restore", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.restoreHandler))
+	s.router.Post("/server/storage/migrations", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON), acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.newStorageMigrationHandler))
+	s.router.Get("/server/storage/migrations/:id", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getStorageMigrationHandler))
+	s.router.Post("/server/storage/stores/:name/rotate_key", writeHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.rotateStoreKeyHandler))
+	s.router.Get("/server/storage/stores/:name/rotate_key", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getStoreKeyRotationHandler))
+	s.router.Post("/deployments", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.newDeploymentHandler))
+	s.router.Put("/deployments/:id", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.newDeploymentHandler))
+	s.router.Patch("/deployments/:id", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationZip)).ThenFunc(s.updateDeploymentHandler))
+	s.router.Delete("/deployments/:id", writeHandlers.ThenFunc(s.deleteDeploymentHandler))
 	s.router.Get("/deployments/:id", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getDeploymentHandler))
 	s.router.Get("/deployments", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listDeploymentsHandler))
 	s.router.Get("/deployments/:id/events", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.pollEvents))
@@ -171,6 +182,7 @@ func (s *Server) registerHandlers() {
 	s.router.Head("/events", commonHandlers.ThenFunc(s.headEventsIndex))
 	s.router.Get("/deployments/:id/logs", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.pollLogs))
 	s.router.Get("/logs", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.pollLogs))
+	s.router.Get("/logs/search", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.searchLogs))
 	s.router.Head("/deployments/:id/logs", commonHandlers.ThenFunc(s.headLogsEventsIndex))
 	s.router.Head("/logs", commonHandlers.ThenFunc(s.headLogsEventsIndex))
 	s.router.Get("/deployments/:id/nodes/:nodeName", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getNodeHandler))
@@ -179,43 +191,53 @@ func (s *Server) registerHandlers() {
 	s.router.Get("/deployments/:id/outputs/:opt", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getOutputHandler))
 	s.router.Get("/deployments/:id/tasks/:taskId", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getTaskHandler))
 	s.router.Get("/deployments/:id/tasks/:taskId/steps", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getTaskStepsHandler))
-	s.router.Delete("/deployments/:id/tasks/:taskId", commonHandlers.ThenFunc(s.cancelTaskHandler))
-	s.router.Put("/deployments/:id/tasks/:taskId", commonHandlers.ThenFunc(s.resumeTaskHandler))
-	s.router.Put("/deployments/:id/tasks/:taskId/steps/:stepId", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.updateTaskStepStatusHandler))
-	s.router.Post("/deployments/:id/scale/:nodeName", commonHandlers.ThenFunc(s.scaleHandler))
+	s.router.Get("/deployments/:id/tasks/:taskId/timeline", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getTaskTimelineHandler))
+	s.router.Delete("/deployments/:id/tasks/:taskId", writeHandlers.ThenFunc(s.cancelTaskHandler))
+	s.router.Put("/deployments/:id/tasks/:taskId", writeHandlers.ThenFunc(s.resumeTaskHandler))
+	s.router.Put("/deployments/:id/tasks/:taskId/steps/:stepId", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.updateTaskStepStatusHandler))
+	s.router.Post("/deployments/:id/scale/:nodeName", writeHandlers.ThenFunc(s.scaleHandler))
 	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getNodeInstanceAttributesListHandler))
 	s.router.Get("/deployments/:id/nodes/:nodeName/instances/:instanceId/attributes/:attributeName", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getNodeInstanceAttributeHandler))
-	s.router.Post("/deployments/:id/custom", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.newCustomCommandHandler))
-	s.router.Post("/deployments/:id/workflows/:workflowName", commonHandlers.ThenFunc(s.newWorkflowHandler))
+	s.router.Post("/deployments/:id/custom", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.newCustomCommandHandler))
+	s.router.Post("/deployments/:id/workflows/:workflowName", writeHandlers.ThenFunc(s.newWorkflowHandler))
 	s.router.Get("/deployments/:id/workflows/:workflowName", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getWorkflowHandler))
 	s.router.Get("/deployments/:id/workflows", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listWorkflowsHandler))
-	s.router.Post("/deployments/:id/purge", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.purgeDeploymentHandler))
+	s.router.Post("/deployments/:id/purge", writeHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.purgeDeploymentHandler))
+	s.router.Get("/deployments/:id/export", commonHandlers.Append(acceptHandler(mimeTypeApplicationZip)).ThenFunc(s.exportDeploymentHandler))
+	s.router.Post("/deployments/:id/drift", writeHandlers.ThenFunc(s.newDriftCheckHandler))
+	s.router.Get("/deployments/:id/drift", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getDriftHandler))
+	// Handles POST /deployments/import
+	s.router.Post("/deployments/:id", writeHandlers.ThenFunc(s.postDeploymentHandler))
 
 	s.router.Get("/registry/delegates", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listRegistryDelegatesHandler))
 	s.router.Get("/registry/implementations", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listRegistryImplementationsHandler))
 	s.router.Get("/registry/definitions", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listRegistryDefinitionsHandler))
 	s.router.Get("/registry/vaults", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listVaultsBuilderHandler))
 	s.router.Get("/registry/infra_usage_collectors", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listInfraHandler))
+	s.router.Get("/registry/action_operators", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listRegistryActionOperatorsHandler))
+	s.router.Get("/registry/plugins", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listPluginsHandler))
+	s.router.Put("/registry/plugins/:pluginID", writeHandlers.ThenFunc(s.loadPluginHandler))
+	s.router.Delete("/registry/plugins/:pluginID", writeHandlers.ThenFunc(s.unloadPluginHandler))
 
-	s.router.Post("/infra_usage/:infraName/:locationName", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.postInfraUsageHandler))
+	s.router.Post("/infra_usage/:infraName/:locationName", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.postInfraUsageHandler))
 	s.router.Get("/infra_usage/:infraName/:locationName/tasks/:taskId", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getTaskQueryHandler))
-	s.router.Delete("/infra_usage/:infraName/:locationName/tasks/:taskId", commonHandlers.ThenFunc(s.deleteTaskQueryHandler))
+	s.router.Delete("/infra_usage/:infraName/:locationName/tasks/:taskId", writeHandlers.ThenFunc(s.deleteTaskQueryHandler))
 	s.router.Get("/infra_usage", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listTaskQueryHandler))
 
-	s.router.Put("/hosts_pool/:location/:host", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.newHostInPool))
-	s.router.Patch("/hosts_pool/:location/:host", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.updateHostInPool))
-	s.router.Delete("/hosts_pool/:location/:host", commonHandlers.ThenFunc(s.deleteHostInPool))
-	s.router.Post("/hosts_pool/:location", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.applyHostsPool))
-	s.router.Put("/hosts_pool/:location", commonHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.applyHostsPool))
+	s.router.Put("/hosts_pool/:location/:host", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.newHostInPool))
+	s.router.Patch("/hosts_pool/:location/:host", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.updateHostInPool))
+	s.router.Delete("/hosts_pool/:location/:host", writeHandlers.ThenFunc(s.deleteHostInPool))
+	s.router.Post("/hosts_pool/:location", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.applyHostsPool))
+	s.router.Put("/hosts_pool/:location", writeHandlers.Append(contentTypeHandler(mimeTypeApplicationJSON)).ThenFunc(s.applyHostsPool))
 	s.router.Get("/hosts_pool/:location", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listHostsInPool))
 	s.router.Get("/hosts_pool/:location/:host", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.getHostInPool))
 	s.router.Get("/hosts_pool", commonHandlers.Append(acceptHandler(mimeTypeApplicationJSON)).ThenFunc(s.listHostsPoolLocations))
 
 	s.router.Get(LOCATIONS, commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.listLocationsHandler))
 	s.router.Get(LOCATIONURI, commonHandlers.Append(acceptHandler("application/json")).ThenFunc(s.getLocationHandler))
-	s.router.Put(LOCATIONURI, commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.createLocationHandler))
-	s.router.Patch(LOCATIONURI, commonHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateLocationHandler))
-	s.router.Delete(LOCATIONURI, commonHandlers.ThenFunc(s.deleteLocationHandler))
+	s.router.Put(LOCATIONURI, writeHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.createLocationHandler))
+	s.router.Patch(LOCATIONURI, writeHandlers.Append(contentTypeHandler("application/json")).ThenFunc(s.updateLocationHandler))
+	s.router.Delete(LOCATIONURI, writeHandlers.ThenFunc(s.deleteLocationHandler))
 
 	if s.config.Telemetry.PrometheusEndpoint {
 		s.router.Get("/metrics", commonHandlers.Then(promhttp.Handler()))
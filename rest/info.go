@@ -15,11 +15,21 @@
 package rest
 
 import (
-	"github.com/ystia/yorc/v4/server/info"
 	"net/http"
+
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/server/info"
+	"github.com/ystia/yorc/v4/storage/retention"
 )
 
 func (s *Server) getInfoHandler(w http.ResponseWriter, r *http.Request) {
-	info := Info{info.YorcVersion, info.GitCommit}
+	info := Info{YorcVersion: info.YorcVersion, GitCommit: info.GitCommit, ServerID: s.config.ServerID, ServerTags: s.config.ServerTags}
+	if s.consulClient != nil {
+		usage, err := retention.GetUsage(s.consulClient)
+		if err != nil {
+			log.Printf("[WARNING] Failed to retrieve storage usage: %v", err)
+		}
+		info.StorageUsage = usage
+	}
 	encodeJSONResponse(w, r, info)
 }
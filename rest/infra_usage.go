@@ -54,6 +54,7 @@ func (s *Server) postInfraUsageHandler(w http.ResponseWriter, r *http.Request) {
 	for k, v := range values {
 		data[k] = strings.Join(v, ",")
 	}
+	data[tasks.CreatorDataKey] = getTaskCreator(r)
 	taskID, err := s.tasksCollector.RegisterTaskWithData(targetID, tasks.TaskTypeQuery, data)
 	if err != nil {
 		log.Panic(err)
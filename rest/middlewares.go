@@ -21,6 +21,7 @@ import (
 
 	"github.com/armon/go-metrics"
 
+	"github.com/ystia/yorc/v4/helper/consulutil"
 	"github.com/ystia/yorc/v4/helper/metricsutil"
 	"github.com/ystia/yorc/v4/log"
 )
@@ -40,6 +41,25 @@ func recoverHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// maintenanceHandler rejects requests modifying deployments, tasks or resources while a maintenance
+// operation (backup or restore) is in progress, so that backups are consistent
+func maintenanceHandler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		maintenance, err := consulutil.IsMaintenanceInProgress()
+		if err != nil {
+			log.Panic(err)
+		}
+		if maintenance {
+			writeError(w, r, newServiceUnavailableError("A maintenance operation (backup or restore) is in progress, please retry later."))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
 func loggingHandler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		t1 := time.Now()
@@ -16,6 +16,9 @@ package rest
 
 import (
 	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
 
 	"github.com/ystia/yorc/v4/deployments/store"
 	"github.com/ystia/yorc/v4/log"
@@ -57,3 +60,100 @@ func (s *Server) listInfraHandler(w http.ResponseWriter, r *http.Request) {
 	infraCollection := RegistryInfraUsageCollectorsCollection{InfraUsageCollectors: infras}
 	encodeJSONResponse(w, r, infraCollection)
 }
+
+func (s *Server) listRegistryActionOperatorsHandler(w http.ResponseWriter, r *http.Request) {
+	operators := reg.ListActionOperators()
+	operatorsCollection := RegistryActionOperatorsCollection{ActionOperators: operators}
+	encodeJSONResponse(w, r, operatorsCollection)
+}
+
+func (s *Server) listPluginsHandler(w http.ResponseWriter, r *http.Request) {
+	origins := make(map[string]*RegistryOrigin)
+	getOrigin := func(origin string) *RegistryOrigin {
+		o, ok := origins[origin]
+		if !ok {
+			o = &RegistryOrigin{Origin: origin}
+			origins[origin] = o
+		}
+		return o
+	}
+	if s.pluginMgr != nil {
+		for _, p := range s.pluginMgr.ListPlugins() {
+			p := p
+			getOrigin(p.ID).Plugin = &p
+		}
+	}
+	for _, d := range reg.ListDelegateExecutors() {
+		o := getOrigin(d.Origin)
+		o.Delegates = append(o.Delegates, d.Match)
+	}
+	for _, i := range reg.ListOperationExecutors() {
+		o := getOrigin(i.Origin)
+		o.Implementations = append(o.Implementations, i.Artifact)
+	}
+	for _, a := range reg.ListActionOperators() {
+		o := getOrigin(a.Origin)
+		o.ActionOperators = append(o.ActionOperators, a.ActionType)
+	}
+	for _, v := range reg.ListVaultClientBuilders() {
+		o := getOrigin(v.Origin)
+		o.VaultClientBuilders = append(o.VaultClientBuilders, v.ID)
+	}
+	for _, c := range reg.ListInfraUsageCollectors() {
+		o := getOrigin(c.Origin)
+		o.InfraUsageCollectors = append(o.InfraUsageCollectors, c.Name)
+	}
+	defs, err := store.GetCommonsDefinitionsList()
+	if err != nil {
+		log.Panic(err)
+	}
+	for _, d := range defs {
+		o := getOrigin(d.Origin)
+		o.Definitions = append(o.Definitions, d.Name+":"+d.Version)
+	}
+
+	collection := RegistryPluginsCollection{Plugins: make([]RegistryOrigin, 0, len(origins))}
+	for _, o := range origins {
+		collection.Plugins = append(collection.Plugins, *o)
+	}
+	sort.Slice(collection.Plugins, func(i, j int) bool {
+		return collection.Plugins[i].Origin < collection.Plugins[j].Origin
+	})
+	encodeJSONResponse(w, r, collection)
+}
+
+func (s *Server) loadPluginHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	pluginID := params.ByName("pluginID")
+	if s.pluginMgr == nil {
+		writeError(w, r, newForbiddenRequest("plugins management is not available on this server"))
+		return
+	}
+	err := s.pluginMgr.LoadPlugin(pluginID)
+	if err != nil {
+		if registry.IsPluginNotFoundError(err) {
+			writeError(w, r, newContentNotFoundError("Plugin "+pluginID))
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) unloadPluginHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	pluginID := params.ByName("pluginID")
+	if s.pluginMgr == nil {
+		writeError(w, r, newForbiddenRequest("plugins management is not available on this server"))
+		return
+	}
+	err := s.pluginMgr.UnloadPlugin(pluginID)
+	if err != nil {
+		if registry.IsPluginNotFoundError(err) {
+			writeError(w, r, newContentNotFoundError("Plugin "+pluginID))
+			return
+		}
+		log.Panic(err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/registry"
+)
+
+type mockPluginManager struct {
+	loaded  []string
+	crashed map[string]bool
+}
+
+func (m *mockPluginManager) LoadPlugin(pluginID string) error {
+	if pluginID == "unknown" {
+		return registry.NewPluginNotFoundError(pluginID)
+	}
+	m.loaded = append(m.loaded, pluginID)
+	return nil
+}
+
+func (m *mockPluginManager) UnloadPlugin(pluginID string) error {
+	for i, p := range m.loaded {
+		if p == pluginID {
+			m.loaded = append(m.loaded[:i], m.loaded[i+1:]...)
+			return nil
+		}
+	}
+	return registry.NewPluginNotFoundError(pluginID)
+}
+
+func (m *mockPluginManager) ListPlugins() []registry.PluginInfo {
+	res := make([]registry.PluginInfo, 0)
+	for _, p := range m.loaded {
+		pi := registry.PluginInfo{ID: p, Status: registry.PluginStatusRunning}
+		if m.crashed[p] {
+			pi.Status = registry.PluginStatusCrashed
+			pi.LastError = "plugin process exited"
+		}
+		res = append(res, pi)
+	}
+	return res
+}
+
+func TestPluginsHealth(t *testing.T) {
+	pm := &mockPluginManager{loaded: []string{"p1", "p2"}, crashed: make(map[string]bool)}
+	srv := &Server{router: newRouter(), pluginMgr: pm}
+	srv.registerHandlers()
+
+	getHealth := func() Health {
+		req := httptest.NewRequest(http.MethodGet, "/server/health", nil)
+		req.Header.Set("Accept", mimeTypeApplicationJSON)
+		w := httptest.NewRecorder()
+		srv.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var health Health
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+		return health
+	}
+
+	require.Equal(t, Health{Value: "passing"}, getHealth())
+
+	pm.crashed["p2"] = true
+	health := getHealth()
+	require.Equal(t, "warning", health.Value)
+	require.Len(t, health.Details, 1)
+	require.Contains(t, health.Details[0], "p2")
+
+}
+
+func testRegistryPluginsHandlers(t *testing.T, client *api.Client, cfg config.Configuration) {
+	reg := registry.GetRegistry()
+	reg.RegisterActionOperator([]string{"test-registry-action"}, &mockActionOperator{}, "myplugin")
+	reg.RegisterInfraUsageCollector("test-registry-infra", &mockInfraUsageCollector{}, "myplugin")
+	defer reg.UnregisterOrigin("myplugin")
+
+	req := httptest.NewRequest(http.MethodGet, "/registry/action_operators", nil)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp := newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var operators RegistryActionOperatorsCollection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&operators))
+	require.Contains(t, operators.ActionOperators, registry.ActionTypeMatch{ActionType: "test-registry-action", Origin: "myplugin"})
+
+	req = httptest.NewRequest(http.MethodGet, "/registry/plugins", nil)
+	req.Header.Set("Accept", mimeTypeApplicationJSON)
+	resp = newTestHTTPRouter(client, cfg, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var plugins RegistryPluginsCollection
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plugins))
+	var found bool
+	for _, o := range plugins.Plugins {
+		if o.Origin == "myplugin" {
+			found = true
+			require.Equal(t, []string{"test-registry-action"}, o.ActionOperators)
+			require.Equal(t, []string{"test-registry-infra"}, o.InfraUsageCollectors)
+			require.Nil(t, o.Plugin)
+		}
+	}
+	require.True(t, found, "expecting myplugin origin in %+v", plugins.Plugins)
+}
+
+type mockActionOperator struct{}
+
+func (m *mockActionOperator) ExecAction(ctx context.Context, conf config.Configuration, taskID, deploymentID string, action *prov.Action) (bool, error) {
+	return false, nil
+}
+
+func testPluginsManagementHandlers(t *testing.T, client *api.Client) {
+	// plugins management routes check that no maintenance operation is in progress
+	pm := &mockPluginManager{}
+	srv := &Server{router: newRouter(), consulClient: client, pluginMgr: pm}
+	srv.registerHandlers()
+
+	tests := []struct {
+		name         string
+		method       string
+		pluginID     string
+		expectedCode int
+		expectLoaded []string
+	}{
+		{"LoadPlugin", http.MethodPut, "myplugin", http.StatusOK, []string{"myplugin"}},
+		{"UpgradePlugin", http.MethodPut, "myplugin", http.StatusOK, []string{"myplugin", "myplugin"}},
+		{"LoadUnknownPlugin", http.MethodPut, "unknown", http.StatusNotFound, []string{"myplugin", "myplugin"}},
+		{"UnloadPlugin", http.MethodDelete, "myplugin", http.StatusOK, []string{"myplugin"}},
+		{"UnloadUnknownPlugin", http.MethodDelete, "unknown", http.StatusNotFound, []string{"myplugin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/registry/plugins/"+tt.pluginID, nil)
+			w := httptest.NewRecorder()
+			srv.router.ServeHTTP(w, req)
+			require.Equal(t, tt.expectedCode, w.Code)
+			require.Equal(t, tt.expectLoaded, pm.loaded)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage"
+)
+
+func (s *Server) rotateStoreKeyHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Panic(err)
+	}
+	var request storage.KeyRotationRequest
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &request); err != nil {
+			writeError(w, r, newBadRequestError(errors.Wrap(err, "invalid key rotation request")))
+			return
+		}
+	}
+
+	storeName := params.ByName("name")
+	rotation, err := storage.StartKeyRotation(s.config, storeName, request)
+	if err != nil {
+		if storage.IsStoreNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		if storage.IsInvalidKeyRotationError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		if storage.IsKeyRotationInProgressError(err) {
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+	w.Header().Set("Location", fmt.Sprintf("/server/storage/stores/%s/rotate_key", storeName))
+	w.Header().Set("Content-Type", mimeTypeApplicationJSON)
+	w.WriteHeader(http.StatusAccepted)
+	encodeJSONResponse(w, r, rotation)
+}
+
+func (s *Server) getStoreKeyRotationHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	rotation, err := storage.GetKeyRotation(params.ByName("name"))
+	if err != nil {
+		if storage.IsKeyRotationNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	encodeJSONResponse(w, r, rotation)
+}
@@ -0,0 +1,70 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/internal/backup"
+	"github.com/ystia/yorc/v4/log"
+)
+
+func (s *Server) newStorageMigrationHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Panic(err)
+	}
+	var request backup.MigrationRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		writeError(w, r, newBadRequestError(errors.Wrap(err, "invalid storage migration request")))
+		return
+	}
+
+	migration, err := backup.StartMigration(s.consulClient, s.config, request)
+	if err != nil {
+		if backup.IsInvalidMigrationError(err) {
+			writeError(w, r, newBadRequestError(err))
+			return
+		}
+		if backup.IsMaintenanceInProgressError(err) {
+			writeError(w, r, newConflictRequest(err.Error()))
+			return
+		}
+		log.Panic(err)
+	}
+	w.Header().Set("Location", fmt.Sprintf("/server/storage/migrations/%s", migration.ID))
+	w.Header().Set("Content-Type", mimeTypeApplicationJSON)
+	w.WriteHeader(http.StatusAccepted)
+	encodeJSONResponse(w, r, migration)
+}
+
+func (s *Server) getStorageMigrationHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.Context().Value(paramsLookupKey).(httprouter.Params)
+	migration, err := backup.GetMigration(params.ByName("id"))
+	if err != nil {
+		if backup.IsMigrationNotFoundError(err) {
+			writeError(w, r, errNotFound)
+			return
+		}
+		log.Panic(err)
+	}
+	encodeJSONResponse(w, r, migration)
+}
@@ -19,11 +19,15 @@ package rest
 import (
 	"bytes"
 	"encoding/json"
+	"time"
 
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/deployments/store"
+	"github.com/ystia/yorc/v4/prov"
 	"github.com/ystia/yorc/v4/prov/hostspool"
 	"github.com/ystia/yorc/v4/registry"
+	"github.com/ystia/yorc/v4/storage/retention"
+	"github.com/ystia/yorc/v4/tasks"
 	"github.com/ystia/yorc/v4/tosca"
 )
 
@@ -59,6 +63,10 @@ const (
 	// YorcDeploymentIDPattern is the allowed pattern for Yorc deployments IDs
 	YorcDeploymentIDPattern string = "^[-_0-9a-zA-Z]+$"
 
+	// reservedDeploymentID can't be used as deployment ID as POST /deployments/import
+	// is handled by the POST /deployments/:id route
+	reservedDeploymentID string = "import"
+
 	// Disable this for now as it doesn't have a concrete impact for now
 	// YorcDeploymentIDMaxLength is the maximum allowed length for Yorc deployments IDs
 	//YorcDeploymentIDMaxLength int = 36
@@ -77,8 +85,11 @@ func newAtomLink(rel, href string) AtomLink {
 }
 
 // Health of a Yorc instance
+//
+// Value is "passing" or "warning" if the instance is degraded, in this case Details explain why.
 type Health struct {
-	Value string `json:"value"`
+	Value   string   `json:"value"`
+	Details []string `json:"details,omitempty"`
 }
 
 // LocationRequest represents a request for creating or updating a location
@@ -121,6 +132,21 @@ type DeploymentsCollection struct {
 	Deployments []Deployment `json:"deployments"`
 }
 
+// DeploymentImportResult is the result of a deployment import
+//
+// RemappedTasks contains tasks IDs that were already used on the Yorc cluster and were changed during import.
+// Keys are the original tasks IDs and values the new ones.
+type DeploymentImportResult struct {
+	DeploymentID         string            `json:"deployment_id"`
+	OriginalDeploymentID string            `json:"original_deployment_id"`
+	RemappedTasks        map[string]string `json:"remapped_tasks,omitempty"`
+}
+
+// DriftReportsCollection is a collection of infrastructure drift reports of deployment nodes
+type DriftReportsCollection struct {
+	Reports []prov.DriftReport `json:"reports"`
+}
+
 // EventsCollection is a collection of instances status change events
 type EventsCollection struct {
 	Events    []json.RawMessage `json:"events"`
@@ -133,6 +159,19 @@ type LogsCollection struct {
 	LastIndex uint64            `json:"last_index"`
 }
 
+// LogsSearchResult is the result of a logs search
+type LogsSearchResult struct {
+	Total     int                   `json:"total"`
+	Logs      []json.RawMessage     `json:"logs"`
+	Histogram []LogsHistogramBucket `json:"histogram,omitempty"`
+}
+
+// LogsHistogramBucket is the number of logs matching a search within a time interval
+type LogsHistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
 // Node is the representation of a TOSCA node
 //
 // Node's links are of type LinkRelSelf, LinkRelDeployment and LinkRelInstance.
@@ -164,6 +203,25 @@ type Task struct {
 	ErrorMessage string            `json:"error_message,omitempty"`
 	ResultSet    json.RawMessage   `json:"result_set,omitempty"`
 	Outputs      map[string]string `json:"outputs,omitempty"`
+	CreatedBy    string            `json:"created_by,omitempty"`
+	CreationDate *time.Time        `json:"creation_date,omitempty"`
+	StartDate    *time.Time        `json:"start_date,omitempty"`
+	EndDate      *time.Time        `json:"end_date,omitempty"`
+	Retries      int               `json:"retries,omitempty"`
+}
+
+// TaskTimeline is the timeline of a task and of its workflow steps
+type TaskTimeline struct {
+	ID           string           `json:"id"`
+	TargetID     string           `json:"target_id"`
+	Type         string           `json:"type"`
+	Status       string           `json:"status"`
+	CreatedBy    string           `json:"created_by,omitempty"`
+	CreationDate *time.Time       `json:"creation_date,omitempty"`
+	StartDate    *time.Time       `json:"start_date,omitempty"`
+	EndDate      *time.Time       `json:"end_date,omitempty"`
+	Retries      int              `json:"retries,omitempty"`
+	Steps        []tasks.TaskStep `json:"steps"`
 }
 
 // TasksCollection is the collection of task's links
@@ -332,8 +390,36 @@ type RegistryInfraUsageCollectorsCollection struct {
 	InfraUsageCollectors []registry.InfraUsageCollector `json:"infrastructure_usage_collectors"`
 }
 
+// RegistryActionOperatorsCollection is the collection of action operators registered in the Yorc registry
+type RegistryActionOperatorsCollection struct {
+	ActionOperators []registry.ActionTypeMatch `json:"action_operators"`
+}
+
+// RegistryOrigin groups all capabilities registered in the Yorc registry by a given origin.
+//
+// Plugin is set only if the origin is a plugin loaded by the Yorc server.
+type RegistryOrigin struct {
+	Origin               string               `json:"origin"`
+	Plugin               *registry.PluginInfo `json:"plugin,omitempty"`
+	Delegates            []string             `json:"delegates,omitempty"`
+	Implementations      []string             `json:"implementations,omitempty"`
+	ActionOperators      []string             `json:"action_operators,omitempty"`
+	Definitions          []string             `json:"definitions,omitempty"`
+	VaultClientBuilders  []string             `json:"vaults,omitempty"`
+	InfraUsageCollectors []string             `json:"infrastructure_usage_collectors,omitempty"`
+}
+
+// RegistryPluginsCollection is the collection of registry capabilities grouped by origin
+type RegistryPluginsCollection struct {
+	Plugins []RegistryOrigin `json:"plugins"`
+}
+
 // Info are the infos about the current YORC server
 type Info struct {
-	YorcVersion string `json:"yorc_version"`
-	GitCommit   string `json:"git_commit"`
+	YorcVersion string   `json:"yorc_version"`
+	GitCommit   string   `json:"git_commit"`
+	ServerID    string   `json:"server_id,omitempty"`
+	ServerTags  []string `json:"server_tags,omitempty"`
+	// StorageUsage is the usage of logs and events stores computed by the last compaction
+	StorageUsage *retention.Usage `json:"storage_usage,omitempty"`
 }
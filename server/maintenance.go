@@ -0,0 +1,69 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/internal/backup"
+	"github.com/ystia/yorc/v4/storage"
+)
+
+// initMaintenanceClients setups Consul and stores accesses needed by maintenance operations run
+// outside of a running Yorc server
+func initMaintenanceClients(configuration config.Configuration) (*api.Client, error) {
+	err := initVaultClient(configuration)
+	if err != nil {
+		return nil, err
+	}
+	client, err := configuration.GetConsulClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't connect to Consul")
+	}
+	maxConsulPubRoutines := configuration.Consul.PubMaxRoutines
+	if maxConsulPubRoutines <= 0 {
+		maxConsulPubRoutines = config.DefaultConsulPubMaxRoutines
+	}
+	consulutil.InitConsulPublisher(maxConsulPubRoutines, client.KV())
+	return client, storage.LoadStores(configuration)
+}
+
+// BackupCluster writes into w a backup of the whole state of the Yorc cluster using the given server configuration
+func BackupCluster(configuration config.Configuration, w io.Writer) error {
+	client, err := initMaintenanceClients(configuration)
+	defer closeStores()
+	if err != nil {
+		return err
+	}
+	return backup.Backup(context.Background(), client, w)
+}
+
+// RestoreCluster replaces the whole state of the Yorc cluster by the one saved into the given backup archive
+// using the given server configuration
+func RestoreCluster(configuration config.Configuration, archive *zip.Reader) error {
+	client, err := initMaintenanceClients(configuration)
+	defer closeStores()
+	if err != nil {
+		return err
+	}
+	return backup.Restore(context.Background(), client, archive)
+}
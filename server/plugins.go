@@ -18,16 +18,22 @@ package server
 import (
 	// Registering AWS delegate executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/terraform/aws"
+	// Registering Azure delegate executor in the registry
+	_ "github.com/ystia/yorc/v4/prov/terraform/azure"
 	// Registering Google Cloud delegate executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/terraform/google"
 	// Registering openstack delegate executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/terraform/openstack"
+	// Registering Terraform module operation executor in the registry
+	_ "github.com/ystia/yorc/v4/prov/terraform"
 	// Registering ansible operation executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/ansible"
 	// Registering kubernetes operation executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/kubernetes"
 	// Registering slurm delegate executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/slurm"
+	// Registering PBS delegate executor in the registry
+	_ "github.com/ystia/yorc/v4/prov/pbs"
 	// Registering hosts pool delegate executor in the registry
 	_ "github.com/ystia/yorc/v4/prov/hostspool"
 	// Registering builtin Tosca definition files
@@ -42,44 +48,113 @@ import (
 	"context"
 	"os"
 	"path/filepath"
-
-	"github.com/ystia/yorc/v4/deployments/store"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	gplugin "github.com/hashicorp/go-plugin"
 	"github.com/pkg/errors"
 
 	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments/store"
 	"github.com/ystia/yorc/v4/log"
 	"github.com/ystia/yorc/v4/plugin"
+	"github.com/ystia/yorc/v4/prov"
 	"github.com/ystia/yorc/v4/registry"
 )
 
 type pluginManager struct {
-	pluginClients []*gplugin.Client
+	cfg      config.Configuration
+	lock     sync.Mutex
+	plugins  map[string]*loadedPlugin
+	draining map[*loadedPlugin]struct{}
+	drainWg  sync.WaitGroup
+}
+
+// loadedPlugin is a plugin subprocess along with what it provides to the registry
+type loadedPlugin struct {
+	id     string
+	path   string
+	client *gplugin.Client
+	// inflight is the number of executions currently running on this plugin
+	inflight  int64
+	startTime time.Time
+
+	// Health state of the plugin, protected by the pluginManager lock
+	status        string
+	restarts      int
+	failures      int
+	lastError     string
+	lastErrorTime time.Time
+	nextRestart   time.Time
+
+	delegateExecutor    prov.DelegateExecutor
+	delegateTypes       []string
+	operationExecutor   prov.OperationExecutor
+	artifactTypes       []string
+	actionOperator      prov.ActionOperator
+	actionTypes         []string
+	definitions         map[string][]byte
+	infraUsageCollector prov.InfraUsageCollector
+	infras              []string
+	metadata            plugin.Metadata
 }
 
-func newPluginManager() *pluginManager {
+// trackExecution increments the number of in-flight executions of this plugin,
+// the returned function should be called when the execution is done.
+func (lp *loadedPlugin) trackExecution() func() {
+	atomic.AddInt64(&lp.inflight, 1)
+	return func() {
+		atomic.AddInt64(&lp.inflight, -1)
+	}
+}
+
+func newPluginManager(cfg config.Configuration) *pluginManager {
 	pm := &pluginManager{
-		pluginClients: make([]*gplugin.Client, 0),
+		cfg:      cfg,
+		plugins:  make(map[string]*loadedPlugin),
+		draining: make(map[*loadedPlugin]struct{}),
 	}
 	return pm
 }
 
 func (pm *pluginManager) cleanup() {
-	for _, client := range pm.pluginClients {
-		client.Kill()
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	for _, lp := range pm.plugins {
+		lp.client.Kill()
+	}
+	for lp := range pm.draining {
+		lp.client.Kill()
 	}
-	pm.pluginClients = nil
+	pm.plugins = make(map[string]*loadedPlugin)
+	pm.draining = make(map[*loadedPlugin]struct{})
 }
 
-func (pm *pluginManager) loadPlugins(cfg config.Configuration) error {
-	pluginsPath := cfg.PluginsDirectory
+func (pm *pluginManager) pluginsDirectory() (string, error) {
+	pluginsPath := pm.cfg.PluginsDirectory
 	if pluginsPath == "" {
 		pluginsPath = config.DefaultPluginDir
 	}
 	pluginPath, err := filepath.Abs(pluginsPath)
+	return pluginPath, errors.Wrap(err, "Failed to explore plugins directory")
+}
+
+func isPluginFile(pFile string) (bool, error) {
+	fInfo, err := os.Stat(pFile)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
 	if err != nil {
-		return errors.Wrap(err, "Failed to explore plugins directory")
+		return false, errors.Wrap(err, "Failed to explore plugins directory")
+	}
+	return !fInfo.IsDir() && fInfo.Mode().Perm()&0111 != 0, nil
+}
+
+func (pm *pluginManager) loadPlugins() error {
+	pluginPath, err := pm.pluginsDirectory()
+	if err != nil {
+		return err
 	}
 	pluginsFiles, err := filepath.Glob(filepath.Join(pluginPath, "*"))
 	if err != nil {
@@ -87,146 +162,265 @@ func (pm *pluginManager) loadPlugins(cfg config.Configuration) error {
 	}
 	plugins := make([]string, 0)
 	for _, pFile := range pluginsFiles {
-		fInfo, err := os.Stat(pFile)
+		isPlugin, err := isPluginFile(pFile)
 		if err != nil {
-			return errors.Wrap(err, "Failed to explore plugins directory")
+			return err
 		}
-		if !fInfo.IsDir() && fInfo.Mode().Perm()&0111 != 0 {
+		if isPlugin {
 			plugins = append(plugins, pFile)
 		}
 	}
-	reg := registry.GetRegistry()
-	ctx := context.Background()
 	for _, pFile := range plugins {
-		log.Debugf("Loading plugin %q...", pFile)
 		// OK the idea here is to _try_ to load the plugin if we can't we give up with this plugin and try the others
 		// There is no reason to stop the server loading if we can't load a plugin.
-		pluginID := filepath.Base(pFile)
-		client := plugin.NewClient(pFile)
-		// Connect via RPC
-		rpcClient, err := client.Client()
+		err = pm.LoadPlugin(filepath.Base(pFile))
 		if err != nil {
 			log.Printf("[Warning] Failed to load %q as a plugin: %v. Skipping it and continue loading plugins.", pFile, err)
 			log.Debugf("Error details: %+v", err)
-			continue
 		}
+	}
+	return nil
+}
 
-		// Request the configManager plugin
-		raw, err := rpcClient.Dispense(plugin.ConfigManagerPluginName)
-		if err != nil {
-			log.Printf("[Warning] Failed to load %q as a plugin: %v. Skipping it and continue loading plugins.", pFile, err)
-			log.Debugf("Error details: %+v", err)
-			client.Kill()
-			continue
+// LoadPlugin implements registry.PluginManager
+func (pm *pluginManager) LoadPlugin(pluginID string) error {
+	pluginPath, err := pm.pluginsDirectory()
+	if err != nil {
+		return err
+	}
+	if pluginID == "" || filepath.Base(pluginID) != pluginID {
+		return registry.NewPluginNotFoundError(pluginID)
+	}
+	pFile := filepath.Join(pluginPath, pluginID)
+	isPlugin, err := isPluginFile(pFile)
+	if err != nil {
+		return err
+	}
+	if !isPlugin {
+		return registry.NewPluginNotFoundError(pluginID)
+	}
+
+	// The plugin process is started without holding the lock as a slow plugin
+	// should not block health checks and other registry calls
+	log.Debugf("Loading plugin %q...", pFile)
+	lp, err := startPlugin(pluginID, pFile, pm.cfg)
+	if err != nil {
+		return err
+	}
+
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	previous, upgrade := pm.plugins[pluginID]
+	if upgrade {
+		// Previous registrations are replaced by the new plugin ones
+		registry.GetRegistry().UnregisterOrigin(pluginID)
+		store.UnregisterCommonDefinitions(pluginID)
+	}
+	registerPlugin(lp)
+	pm.plugins[pluginID] = lp
+
+	if upgrade {
+		pm.drain(previous)
+		log.Printf("Plugin %q successfully upgraded from version %q to version %q", pluginID, previous.metadata.Version, lp.metadata.Version)
+	} else {
+		log.Printf("Plugin %q version %q successfully loaded", pluginID, lp.metadata.Version)
+	}
+	return nil
+}
+
+// UnloadPlugin implements registry.PluginManager
+func (pm *pluginManager) UnloadPlugin(pluginID string) error {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	lp, ok := pm.plugins[pluginID]
+	if !ok {
+		return registry.NewPluginNotFoundError(pluginID)
+	}
+	delete(pm.plugins, pluginID)
+	registry.GetRegistry().UnregisterOrigin(pluginID)
+	store.UnregisterCommonDefinitions(pluginID)
+	pm.drain(lp)
+	log.Printf("Plugin %q unloaded", pluginID)
+	return nil
+}
+
+// drain waits in background for in-flight executions of a plugin to finish before stopping it.
+//
+// It should be called with pm.lock held and after having removed the plugin from the registry
+// so no new execution could start on it.
+func (pm *pluginManager) drain(lp *loadedPlugin) {
+	pm.draining[lp] = struct{}{}
+	pm.drainWg.Add(1)
+	go func() {
+		defer pm.drainWg.Done()
+		timeout := pm.cfg.PluginsDrainTimeout
+		if timeout <= 0 {
+			timeout = config.DefaultPluginsDrainTimeout
 		}
-		cfgManager := raw.(plugin.ConfigManager)
-		err = cfgManager.SetupConfig(cfg)
-		if err != nil {
-			log.Printf("[Warning] Failed to load %q as a plugin: %v. Skipping it and continue loading plugins.", pFile, err)
-			log.Debugf("Error details: %+v", err)
-			client.Kill()
-			continue
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+	drainLoop:
+		for atomic.LoadInt64(&lp.inflight) > 0 {
+			select {
+			case <-ticker.C:
+			case <-timer.C:
+				log.Printf("[Warning] Plugin %q still has %d in-flight executions after %v, stopping it anyway.", lp.id, atomic.LoadInt64(&lp.inflight), timeout)
+				break drainLoop
+			}
+		}
+		pm.lock.Lock()
+		defer pm.lock.Unlock()
+		if _, ok := pm.draining[lp]; ok {
+			delete(pm.draining, lp)
+			lp.client.Kill()
+			log.Debugf("Previous instance of plugin %q stopped", lp.id)
 		}
+	}()
+}
 
-		// Request the delegate plugin
-		raw, err = rpcClient.Dispense(plugin.DelegatePluginName)
-		if err == nil {
-			delegateExecutor := raw.(plugin.DelegateExecutor)
-			supportedTypes, err := delegateExecutor.GetSupportedTypes()
-			if err != nil {
-				log.Printf("[Warning] Failed to retrieve delegate executor supported type for plugin %q.", pluginID)
-				log.Debugf("%+v", err)
-			}
-			if len(supportedTypes) > 0 {
-				log.Debugf("Registering supported node types %v into registry for plugin %q", supportedTypes, pluginID)
-				reg.RegisterDelegates(supportedTypes, delegateExecutor, pluginID)
-			}
-		} else {
-			log.Printf("[Warning] Can't retrieve delegate executor from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+// startPlugin starts a plugin subprocess and retrieves what it provides without registering it
+func startPlugin(pluginID, pFile string, cfg config.Configuration) (*loadedPlugin, error) {
+	client := plugin.NewClient(pFile)
+	// Connect via RPC
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, errors.Wrapf(err, "failed to connect to plugin %q", pluginID)
+	}
+
+	// Request the configManager plugin
+	raw, err := rpcClient.Dispense(plugin.ConfigManagerPluginName)
+	if err != nil {
+		client.Kill()
+		return nil, errors.Wrapf(err, "failed to retrieve configuration manager of plugin %q", pluginID)
+	}
+	cfgManager := raw.(plugin.ConfigManager)
+	err = cfgManager.SetupConfig(cfg)
+	if err != nil {
+		client.Kill()
+		return nil, errors.Wrapf(err, "failed to setup configuration of plugin %q", pluginID)
+	}
+
+	lp := &loadedPlugin{id: pluginID, path: pFile, client: client, startTime: time.Now(), status: registry.PluginStatusRunning}
+
+	// Request the delegate plugin
+	raw, err = rpcClient.Dispense(plugin.DelegatePluginName)
+	if err == nil {
+		delegateExecutor := raw.(plugin.DelegateExecutor)
+		lp.delegateTypes, err = delegateExecutor.GetSupportedTypes()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve delegate executor supported type for plugin %q.", pluginID)
 			log.Debugf("%+v", err)
 		}
+		lp.delegateExecutor = delegateExecutor
+	} else {
+		log.Printf("[Warning] Can't retrieve delegate executor from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		// Request the operation plugin
-		raw, err = rpcClient.Dispense(plugin.OperationPluginName)
-		if err == nil {
-			operationExecutor := raw.(plugin.OperationExecutor)
-			supportedArtTypes, err := operationExecutor.GetSupportedArtifactTypes()
-			if err != nil {
-				log.Printf("[Warning] Failed to retrieve operation executor supported implementation artifacts for plugin %q.", pluginID)
-				log.Debugf("%+v", err)
-			}
-			if len(supportedArtTypes) > 0 {
-				log.Debugf("Registering supported implementation artifact types %v into registry for plugin %q", supportedArtTypes, pluginID)
-				reg.RegisterOperationExecutor(supportedArtTypes, operationExecutor, pluginID)
-			}
-		} else {
-			log.Printf("[Warning] Can't retrieve operation executor from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+	// Request the operation plugin
+	raw, err = rpcClient.Dispense(plugin.OperationPluginName)
+	if err == nil {
+		operationExecutor := raw.(plugin.OperationExecutor)
+		lp.artifactTypes, err = operationExecutor.GetSupportedArtifactTypes()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve operation executor supported implementation artifacts for plugin %q.", pluginID)
 			log.Debugf("%+v", err)
 		}
+		lp.operationExecutor = operationExecutor
+	} else {
+		log.Printf("[Warning] Can't retrieve operation executor from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		// Request the action plugin
-		raw, err = rpcClient.Dispense(plugin.ActionPluginName)
-		if err == nil {
-			actionOperator := raw.(plugin.ActionOperator)
-			actionTypes, err := actionOperator.GetActionTypes()
-			if err != nil {
-				log.Printf("[Warning] Failed to retrieve action types for plugin %q.", pluginID)
-				log.Debugf("%+v", err)
-			}
-			if len(actionTypes) > 0 {
-				log.Debugf("Registering action types %v into registry for plugin %q", actionTypes, pluginID)
-				reg.RegisterActionOperator(actionTypes, actionOperator, pluginID)
-			}
-		} else {
-			log.Printf("[Warning] Can't retrieve action operator from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+	// Request the action plugin
+	raw, err = rpcClient.Dispense(plugin.ActionPluginName)
+	if err == nil {
+		actionOperator := raw.(plugin.ActionOperator)
+		lp.actionTypes, err = actionOperator.GetActionTypes()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve action types for plugin %q.", pluginID)
 			log.Debugf("%+v", err)
 		}
+		lp.actionOperator = actionOperator
+	} else {
+		log.Printf("[Warning] Can't retrieve action operator from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		// Request the definitions plugin
-		raw, err = rpcClient.Dispense(plugin.DefinitionsPluginName)
-		if err == nil {
-			definitionPlugin := raw.(plugin.Definitions)
-			definitions, err := definitionPlugin.GetDefinitions()
-			if err != nil {
-				log.Printf("[Warning] Failed to retrieve TOSCA definitions for plugin %q.", pluginID)
-				log.Debugf("%+v", err)
-			}
-			if len(definitions) > 0 {
-				for defName, defContent := range definitions {
-					log.Debugf("Registering TOSCA definition %q into registry for plugin %q", defName, pluginID)
-					store.CommonDefinition(ctx, defName, pluginID, defContent)
-				}
-			}
-		} else {
-			log.Printf("[Warning] Can't retrieve TOSCA definitions from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+	// Request the definitions plugin
+	raw, err = rpcClient.Dispense(plugin.DefinitionsPluginName)
+	if err == nil {
+		definitionPlugin := raw.(plugin.Definitions)
+		lp.definitions, err = definitionPlugin.GetDefinitions()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve TOSCA definitions for plugin %q.", pluginID)
 			log.Debugf("%+v", err)
 		}
+	} else {
+		log.Printf("[Warning] Can't retrieve TOSCA definitions from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		// Request the infra usage collector plugin
-		raw, err = rpcClient.Dispense(plugin.InfraUsageCollectorPluginName)
-		if err == nil {
-			infraUsageCollectorPlugin := raw.(plugin.InfraUsageCollector)
-			infras, err := infraUsageCollectorPlugin.GetSupportedInfras()
-			if err != nil {
-				log.Printf("[Warning] Failed to retrieve supported infrastructure for plugin %q.", pluginID)
-				log.Debugf("%+v", err)
-			}
-			if len(infras) > 0 {
-				for _, infra := range infras {
-					log.Debugf("Registering infrastructure usage collector %q into registry for plugin %q", infra, pluginID)
-					reg.RegisterInfraUsageCollector(infra, infraUsageCollectorPlugin, pluginID)
-				}
-			}
-		} else {
-			log.Printf("[Warning] Can't get collector supported infra from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+	// Request the infra usage collector plugin
+	raw, err = rpcClient.Dispense(plugin.InfraUsageCollectorPluginName)
+	if err == nil {
+		infraUsageCollectorPlugin := raw.(plugin.InfraUsageCollector)
+		lp.infras, err = infraUsageCollectorPlugin.GetSupportedInfras()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve supported infrastructure for plugin %q.", pluginID)
 			log.Debugf("%+v", err)
 		}
+		lp.infraUsageCollector = infraUsageCollectorPlugin
+	} else {
+		log.Printf("[Warning] Can't get collector supported infra from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		pm.pluginClients = append(pm.pluginClients, client)
+	// Request the metadata plugin
+	raw, err = rpcClient.Dispense(plugin.MetadataPluginName)
+	if err == nil {
+		lp.metadata, err = raw.(plugin.MetadataProvider).GetMetadata()
+		if err != nil {
+			log.Printf("[Warning] Failed to retrieve metadata for plugin %q.", pluginID)
+			log.Debugf("%+v", err)
+		}
+	} else {
+		log.Printf("[Warning] Can't retrieve metadata from plugin %q: %v. This is likely due to a outdated plugin.", pluginID, err)
+		log.Debugf("%+v", err)
+	}
 
-		log.Printf("Plugin %q successfully loaded", pluginID)
+	return lp, nil
+}
 
+// registerPlugin registers what a plugin provides into the registry using the plugin ID as origin
+func registerPlugin(lp *loadedPlugin) {
+	reg := registry.GetRegistry()
+	ctx := context.Background()
+	if len(lp.delegateTypes) > 0 {
+		log.Debugf("Registering supported node types %v into registry for plugin %q", lp.delegateTypes, lp.id)
+		reg.RegisterDelegates(lp.delegateTypes, &pluginDelegateExecutor{lp: lp, executor: lp.delegateExecutor}, lp.id)
+	}
+	if len(lp.artifactTypes) > 0 {
+		log.Debugf("Registering supported implementation artifact types %v into registry for plugin %q", lp.artifactTypes, lp.id)
+		reg.RegisterOperationExecutor(lp.artifactTypes, &pluginOperationExecutor{lp: lp, executor: lp.operationExecutor}, lp.id)
+	}
+	if len(lp.actionTypes) > 0 {
+		log.Debugf("Registering action types %v into registry for plugin %q", lp.actionTypes, lp.id)
+		reg.RegisterActionOperator(lp.actionTypes, &pluginActionOperator{lp: lp, operator: lp.actionOperator}, lp.id)
+	}
+	for defName, defContent := range lp.definitions {
+		log.Debugf("Registering TOSCA definition %q into registry for plugin %q", defName, lp.id)
+		err := store.CommonDefinition(ctx, defName, lp.id, defContent)
+		if err != nil {
+			log.Printf("[Warning] Failed to register TOSCA definition %q for plugin %q: %v", defName, lp.id, err)
+			log.Debugf("%+v", err)
+		}
+	}
+	for _, infra := range lp.infras {
+		log.Debugf("Registering infrastructure usage collector %q into registry for plugin %q", infra, lp.id)
+		reg.RegisterInfraUsageCollector(infra, &pluginInfraUsageCollector{lp: lp, collector: lp.infraUsageCollector}, lp.id)
 	}
-
-	return nil
 }
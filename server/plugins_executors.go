@@ -0,0 +1,71 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/prov"
+)
+
+// The types below wrap executors provided by plugins in order to track in-flight executions
+// and allow to drain them before stopping a plugin.
+
+type pluginDelegateExecutor struct {
+	lp       *loadedPlugin
+	executor prov.DelegateExecutor
+}
+
+func (e *pluginDelegateExecutor) ExecDelegate(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
+	defer e.lp.trackExecution()()
+	return e.executor.ExecDelegate(ctx, conf, taskID, deploymentID, nodeName, delegateOperation)
+}
+
+type pluginOperationExecutor struct {
+	lp       *loadedPlugin
+	executor prov.OperationExecutor
+}
+
+func (e *pluginOperationExecutor) ExecOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation) error {
+	defer e.lp.trackExecution()()
+	return e.executor.ExecOperation(ctx, conf, taskID, deploymentID, nodeName, operation)
+}
+
+func (e *pluginOperationExecutor) ExecAsyncOperation(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string, operation prov.Operation, stepName string) (*prov.Action, time.Duration, error) {
+	defer e.lp.trackExecution()()
+	return e.executor.ExecAsyncOperation(ctx, conf, taskID, deploymentID, nodeName, operation, stepName)
+}
+
+type pluginActionOperator struct {
+	lp       *loadedPlugin
+	operator prov.ActionOperator
+}
+
+func (o *pluginActionOperator) ExecAction(ctx context.Context, conf config.Configuration, taskID, deploymentID string, action *prov.Action) (bool, error) {
+	defer o.lp.trackExecution()()
+	return o.operator.ExecAction(ctx, conf, taskID, deploymentID, action)
+}
+
+type pluginInfraUsageCollector struct {
+	lp        *loadedPlugin
+	collector prov.InfraUsageCollector
+}
+
+func (c *pluginInfraUsageCollector) GetUsageInfo(ctx context.Context, cfg config.Configuration, taskID, infraName, locationName string, params map[string]string) (map[string]interface{}, error) {
+	defer c.lp.trackExecution()()
+	return c.collector.GetUsageInfo(ctx, cfg, taskID, infraName, locationName, params)
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments/store"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/registry"
+)
+
+// ListPlugins implements registry.PluginManager
+func (pm *pluginManager) ListPlugins() []registry.PluginInfo {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	res := make([]registry.PluginInfo, 0, len(pm.plugins))
+	for _, lp := range pm.plugins {
+		pi := registry.PluginInfo{
+			ID:              lp.id,
+			Version:         lp.metadata.Version,
+			Description:     lp.metadata.Description,
+			Path:            lp.path,
+			ProtocolVersion: lp.client.NegotiatedVersion(),
+			Status:          lp.status,
+			StartTime:       lp.startTime,
+			Restarts:        lp.restarts,
+			LastError:       lp.lastError,
+		}
+		if !lp.lastErrorTime.IsZero() {
+			t := lp.lastErrorTime
+			pi.LastErrorTime = &t
+		}
+		if lp.status == registry.PluginStatusRunning {
+			if rc := lp.client.ReattachConfig(); rc != nil {
+				pi.PID = rc.Pid
+			}
+		}
+		res = append(res, pi)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// monitorPlugins periodically checks plugins health and restarts crashed ones until shutdownCh is closed
+func (pm *pluginManager) monitorPlugins(shutdownCh chan struct{}) {
+	interval := pm.cfg.PluginsHealthCheckInterval
+	if interval <= 0 {
+		interval = config.DefaultPluginsHealthCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-shutdownCh:
+				return
+			case <-ticker.C:
+				pm.checkPlugins()
+			}
+		}
+	}()
+}
+
+func (pm *pluginManager) checkPlugins() {
+	pm.lock.Lock()
+	plugins := make([]*loadedPlugin, 0, len(pm.plugins))
+	for _, lp := range pm.plugins {
+		plugins = append(plugins, lp)
+	}
+	pm.lock.Unlock()
+
+	for _, lp := range plugins {
+		// Ping is done without holding the lock as it may take some time on a stuck plugin
+		err := pingPlugin(lp)
+		pm.lock.Lock()
+		if pm.plugins[lp.id] != lp {
+			// Plugin was upgraded or unloaded meanwhile
+			pm.lock.Unlock()
+			continue
+		}
+		if err == nil {
+			if lp.status == registry.PluginStatusRunning {
+				lp.failures = 0
+			}
+			pm.lock.Unlock()
+			continue
+		}
+		if lp.status == registry.PluginStatusRunning {
+			log.Printf("[Warning] Plugin %q failed its health check: %v", lp.id, err)
+			lp.status = registry.PluginStatusCrashed
+			lp.failures++
+			lp.lastError = err.Error()
+			lp.lastErrorTime = time.Now()
+			lp.nextRestart = lp.lastErrorTime.Add(pm.restartBackoff(lp.failures))
+		}
+		if time.Now().Before(lp.nextRestart) {
+			pm.lock.Unlock()
+			continue
+		}
+		pm.lock.Unlock()
+		pm.restartPlugin(lp)
+	}
+}
+
+// restartBackoff returns the delay before restarting a plugin given its number of consecutive failures.
+//
+// The first restart is immediate then the delay doubles at each consecutive failure
+// starting from the health check interval up to the configured maximum.
+func (pm *pluginManager) restartBackoff(failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	backoff := pm.cfg.PluginsHealthCheckInterval
+	if backoff <= 0 {
+		backoff = config.DefaultPluginsHealthCheckInterval
+	}
+	maxBackoff := pm.cfg.PluginsRestartMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = config.DefaultPluginsRestartMaxBackoff
+	}
+	for i := 2; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// restartPlugin restarts a crashed plugin and registers the new process in place of the crashed one.
+//
+// It should be called without holding pm.lock as starting the plugin may take some time.
+func (pm *pluginManager) restartPlugin(crashed *loadedPlugin) {
+	log.Printf("Restarting plugin %q (restart #%d)", crashed.id, crashed.restarts+1)
+	crashed.client.Kill()
+	lp, err := startPlugin(crashed.id, crashed.path, pm.cfg)
+
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+	if pm.plugins[crashed.id] != crashed {
+		// Plugin was upgraded or unloaded meanwhile
+		if err == nil {
+			lp.client.Kill()
+		}
+		return
+	}
+	if err != nil {
+		crashed.failures++
+		crashed.lastError = err.Error()
+		crashed.lastErrorTime = time.Now()
+		crashed.nextRestart = crashed.lastErrorTime.Add(pm.restartBackoff(crashed.failures))
+		log.Printf("[Warning] Failed to restart plugin %q, next attempt in %v: %v", crashed.id, crashed.nextRestart.Sub(crashed.lastErrorTime), err)
+		log.Debugf("Error details: %+v", err)
+		return
+	}
+	lp.restarts = crashed.restarts + 1
+	lp.failures = crashed.failures
+	lp.lastError = crashed.lastError
+	lp.lastErrorTime = crashed.lastErrorTime
+
+	registry.GetRegistry().UnregisterOrigin(crashed.id)
+	store.UnregisterCommonDefinitions(crashed.id)
+	registerPlugin(lp)
+	pm.plugins[crashed.id] = lp
+	log.Printf("Plugin %q successfully restarted", crashed.id)
+}
+
+func pingPlugin(lp *loadedPlugin) error {
+	if lp.client.Exited() {
+		return errors.New("plugin process exited")
+	}
+	rpcClient, err := lp.client.Client()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to plugin")
+	}
+	return errors.Wrap(rpcClient.Ping(), "failed to ping plugin")
+}
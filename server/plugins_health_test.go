@@ -0,0 +1,46 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ystia/yorc/v4/config"
+)
+
+func TestPluginManagerRestartBackoff(t *testing.T) {
+	pm := newPluginManager(config.Configuration{
+		PluginsHealthCheckInterval: 10 * time.Second,
+		PluginsRestartMaxBackoff:   time.Minute,
+	})
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{50, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := pm.restartBackoff(tt.failures); got != tt.want {
+			t.Errorf("restartBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
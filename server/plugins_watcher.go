@@ -0,0 +1,107 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/registry"
+)
+
+// pluginsWatcherSettleTime is the time without any change on a plugin file before loading,
+// upgrading or unloading it. It prevents from loading a plugin while it is still being copied.
+const pluginsWatcherSettleTime = 2 * time.Second
+
+// watchPluginsDirectory watches the plugins directory and loads, upgrades or unloads plugins
+// when their files are created, updated or removed until shutdownCh is closed.
+func (pm *pluginManager) watchPluginsDirectory(shutdownCh chan struct{}) error {
+	pluginsPath, err := pm.pluginsDirectory()
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to watch plugins directory")
+	}
+	err = watcher.Add(pluginsPath)
+	if err != nil {
+		watcher.Close()
+		return errors.Wrapf(err, "failed to watch plugins directory %q", pluginsPath)
+	}
+	log.Printf("Watching plugins directory %q for changes", pluginsPath)
+
+	go func() {
+		defer watcher.Close()
+		changed := make(chan string)
+		timers := make(map[string]*time.Timer)
+		for {
+			select {
+			case <-shutdownCh:
+				for _, t := range timers {
+					t.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				pluginID := filepath.Base(event.Name)
+				if t, ok := timers[pluginID]; ok {
+					t.Stop()
+				}
+				timers[pluginID] = time.AfterFunc(pluginsWatcherSettleTime, func() {
+					select {
+					case changed <- pluginID:
+					case <-shutdownCh:
+					}
+				})
+			case pluginID := <-changed:
+				delete(timers, pluginID)
+				pm.handlePluginFileChange(pluginsPath, pluginID)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[Warning] Error while watching plugins directory %q: %v", pluginsPath, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (pm *pluginManager) handlePluginFileChange(pluginsPath, pluginID string) {
+	isPlugin, err := isPluginFile(filepath.Join(pluginsPath, pluginID))
+	if err != nil {
+		log.Printf("[Warning] Failed to check plugin %q: %v", pluginID, err)
+		return
+	}
+	if isPlugin {
+		err = pm.LoadPlugin(pluginID)
+		if err != nil {
+			log.Printf("[Warning] Failed to load %q as a plugin: %v", pluginID, err)
+			log.Debugf("Error details: %+v", err)
+		}
+		return
+	}
+	err = pm.UnloadPlugin(pluginID)
+	if err != nil && !registry.IsPluginNotFoundError(err) {
+		log.Printf("[Warning] Failed to unload plugin %q: %v", pluginID, err)
+	}
+}
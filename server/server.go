@@ -34,6 +34,7 @@ import (
 	"github.com/ystia/yorc/v4/prov/scheduling/scheduler"
 	"github.com/ystia/yorc/v4/rest"
 	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/retention"
 	"github.com/ystia/yorc/v4/tasks/workflow"
 )
 
@@ -106,6 +107,13 @@ func initLocationManager(configuration config.Configuration) error {
 	return nil
 }
 
+// closeStores releases stores resources on shutdown, errors are only logged as nothing more can be done
+func closeStores() {
+	if err := storage.CloseStores(); err != nil {
+		log.Printf("[WARN] %v", err)
+	}
+}
+
 // RunServer starts the Yorc server
 func RunServer(configuration config.Configuration, shutdownCh chan struct{}) error {
 	err := setupTelemetry(configuration)
@@ -118,24 +126,40 @@ func RunServer(configuration config.Configuration, shutdownCh chan struct{}) err
 		return err
 	}
 
+	if configuration.Standalone.Enabled {
+		agent, err := startStandaloneAgent(&configuration)
+		if err != nil {
+			return err
+		}
+		defer agent.Stop()
+	}
+
 	client, err := initConsulClient(configuration)
 	if err != nil {
 		return err
 	}
+	defer closeStores()
 
 	err = initLocationManager(configuration)
 	if err != nil {
 		return err
 	}
 
-	pm := newPluginManager()
+	pm := newPluginManager(configuration)
 	defer pm.cleanup()
-	err = pm.loadPlugins(configuration)
+	err = pm.loadPlugins()
 	if err != nil {
 		return err
 	}
+	pm.monitorPlugins(shutdownCh)
+	if configuration.PluginsWatchDirectory {
+		err = pm.watchPluginsDirectory(shutdownCh)
+		if err != nil {
+			return err
+		}
+	}
 
-	httpServer, err := rest.NewServer(configuration, client, shutdownCh)
+	httpServer, err := rest.NewServer(configuration, client, pm, shutdownCh)
 	if err != nil {
 		return err
 	}
@@ -158,6 +182,15 @@ func RunServer(configuration config.Configuration, shutdownCh chan struct{}) err
 	scheduler.Start(configuration, client)
 	defer scheduler.Stop()
 
+	// Update keyrings of encrypted stores on keys rotations
+	go storage.WatchKeyrings(client, shutdownCh)
+
+	// Start logs and events stores compaction
+	if err = retention.Start(configuration, client); err != nil {
+		return err
+	}
+	defer retention.Stop()
+
 	signalCh := make(chan os.Signal, 4)
 	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	for {
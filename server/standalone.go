@@ -0,0 +1,45 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"path/filepath"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/internal/standalone"
+)
+
+// startStandaloneAgent starts the embedded agent replacing Consul in standalone mode
+// and updates the Consul configuration to use it
+func startStandaloneAgent(cfg *config.Configuration) (*standalone.Agent, error) {
+	dataDir := cfg.Standalone.DataDirectory
+	if dataDir == "" {
+		dataDir = filepath.Join(cfg.WorkingDirectory, "standalone")
+	}
+	agent, err := standalone.Start(standalone.Config{
+		DataDirectory: dataDir,
+		Address:       cfg.Standalone.Address,
+		NodeName:      cfg.ServerID,
+		Datacenter:    cfg.Consul.Datacenter,
+		// Health checks only target the local Yorc server
+		ChecksTLSSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg.Consul.Address = agent.Address()
+	cfg.Consul.SSL = false
+	return agent, nil
+}
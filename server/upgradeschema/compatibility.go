@@ -0,0 +1,45 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upgradeschema
+
+import (
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+)
+
+// CheckSchemaVersionCompatibility checks that data stored using the given schema version
+// can be used as is by this version of Yorc.
+//
+// Data are compatible only if they were stored using exactly the same schema version,
+// as upgrades are only performed at server startup.
+func CheckSchemaVersionCompatibility(version string) error {
+	vData, err := semver.Make(version)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse data schema version %q", version)
+	}
+	vCurrent, err := semver.Make(consulutil.YorcSchemaVersion)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse current version of consul db schema")
+	}
+	switch vCurrent.Compare(vData) {
+	case 1:
+		return errors.Errorf("data schema version %s is older than the current one (%s), restore it using a version of Yorc matching this schema and then upgrade Yorc", vData, vCurrent)
+	case -1:
+		return errors.Errorf("this version of Yorc is too old compared to the data schema version (%s), an upgrade is needed", vData)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+// Compact removes logs or events stored under the key k that are not retained by the given policy.
+//
+// Stores implementing store.Compactor remove values by themselves, values of other stores are listed and
+// removed one by one. Values are only read when the policy removes some levels, otherwise timestamps are
+// read from keys.
+func Compact(ctx context.Context, s store.Store, k string, policy store.RetentionPolicy) (store.CompactionResult, error) {
+	if c, ok := s.(store.Compactor); ok {
+		return c.Compact(ctx, k, policy)
+	}
+
+	var result store.CompactionResult
+	var entries []store.RetentionEntry
+	var count int
+	if len(policy.RemovedLevels) == 0 {
+		keys, err := s.Keys(strings.TrimSuffix(k, "/"))
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to list keys of key %q", k)
+		}
+		count = len(keys)
+		if policy.IsEmpty() {
+			result.Remaining = count
+			return result, nil
+		}
+		entries = make([]store.RetentionEntry, 0, len(keys))
+		for _, key := range keys {
+			if entry, ok := store.NewRetentionEntry(key, nil); ok {
+				entries = append(entries, entry)
+			}
+		}
+	} else {
+		kvs, _, err := s.List(ctx, k, 0, 0)
+		if err != nil {
+			return result, errors.Wrapf(err, "failed to list values of key %q", k)
+		}
+		count = len(kvs)
+		entries = make([]store.RetentionEntry, 0, len(kvs))
+		for _, kv := range kvs {
+			if entry, ok := store.NewRetentionEntry(kv.Key, kv.Value); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	removed := policy.RemovedKeys(entries, time.Now())
+	for _, key := range removed {
+		if err := s.Delete(ctx, key, false); err != nil {
+			return result, errors.Wrapf(err, "failed to remove value of key %q", key)
+		}
+		result.Removed++
+	}
+	result.Remaining = count - result.Removed
+	return result, nil
+}
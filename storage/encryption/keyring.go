@@ -0,0 +1,281 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+
+	"github.com/ystia/yorc/v4/config"
+)
+
+// DefaultKeyID is the ID of the key defined by the passphrase property of a store
+const DefaultKeyID = "default"
+
+// keyLength is the required length of passphrases defining AES-256 keys
+const keyLength = 32
+
+// header prefixes data encrypted by a keyring, it is followed by the length of the key ID and by the key ID
+var header = []byte("YKR1")
+
+// Keyring allows to encrypt data with a primary key and to decrypt data encrypted with any of its keys.
+//
+// The ID of the key used to encrypt data is embedded into encrypted data. Data encrypted by an Encryptor
+// without any key ID is also decrypted, using the default key at first and then other keys.
+// A Keyring is safe for concurrent use and its keys may be replaced while it is in use.
+type Keyring struct {
+	lock       sync.RWMutex
+	primaryID  string
+	encryptors map[string]*Encryptor
+}
+
+// NewKeyring returns a new Keyring from keys encoded in hexadecimal indexed by their IDs.
+//
+// The primary key used to encrypt data should be part of the given keys.
+func NewKeyring(primaryID string, keys map[string]string) (*Keyring, error) {
+	if primaryID == "" {
+		return nil, errors.New("missing primary key ID")
+	}
+	if _, ok := keys[primaryID]; !ok {
+		return nil, errors.Errorf("primary key %q is not defined", primaryID)
+	}
+	k := &Keyring{primaryID: primaryID, encryptors: make(map[string]*Encryptor, len(keys))}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, errors.Errorf("invalid key ID %q: it should be a non-empty string of at most 255 characters", id)
+		}
+		e, err := NewEncryptor(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q", id)
+		}
+		k.encryptors[id] = e
+	}
+	return k, nil
+}
+
+// NewKeyringFromProperties returns a new Keyring from the properties of an encrypted store.
+//
+// Keys are 32 characters passphrases defined by the passphrase property, whose ID is DefaultKeyID,
+// and by the keys property, a map of passphrases indexed by their IDs. The primary_key property defines
+// the ID of the primary key, it is optional if there is a single key.
+func NewKeyringFromProperties(storeID string, properties config.DynamicMap) (*Keyring, error) {
+	passphrases := make(map[string]string)
+	if properties.IsSet("keys") {
+		keys, err := cast.ToStringMapStringE(properties.Get("keys"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid keys property for encryption of store with ID:%q", storeID)
+		}
+		for id, key := range keys {
+			passphrases[id] = key
+		}
+	}
+	if passphrase := properties.GetString("passphrase"); passphrase != "" {
+		if key, ok := passphrases[DefaultKeyID]; ok && key != passphrase {
+			return nil, errors.Errorf("Key %q for encryption of store with ID:%q is defined by both passphrase and keys properties", DefaultKeyID, storeID)
+		}
+		passphrases[DefaultKeyID] = passphrase
+	}
+	if len(passphrases) == 0 {
+		return nil, errors.Errorf("Missing passphrase for encryption of store with ID:%q", storeID)
+	}
+
+	primaryID := properties.GetString("primary_key")
+	if primaryID == "" {
+		if len(passphrases) > 1 {
+			return nil, errors.Errorf("Missing primary_key property for encryption of store with ID:%q using several keys", storeID)
+		}
+		for id := range passphrases {
+			primaryID = id
+		}
+	}
+
+	keys := make(map[string]string, len(passphrases))
+	for id, passphrase := range passphrases {
+		if err := CheckPassphrase(passphrase); err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q for encryption of store with ID:%q", id, storeID)
+		}
+		keys[id] = hex.EncodeToString([]byte(passphrase))
+	}
+	k, err := NewKeyring(primaryID, keys)
+	return k, errors.Wrapf(err, "failed to build keyring for encryption of store with ID:%q", storeID)
+}
+
+// CheckPassphrase checks that a passphrase can be used as an encryption key
+func CheckPassphrase(passphrase string) error {
+	if len(passphrase) != keyLength {
+		return errors.Errorf("passphrase must be %d characters long", keyLength)
+	}
+	return nil
+}
+
+// PrimaryKeyID returns the ID of the key used to encrypt data
+func (k *Keyring) PrimaryKeyID() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.primaryID
+}
+
+// KeyIDs returns the sorted IDs of the keyring keys
+func (k *Keyring) KeyIDs() []string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	ids := make([]string, 0, len(k.encryptors))
+	for id := range k.encryptors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Equal returns true if both keyrings have the same keys and the same primary key
+func (k *Keyring) Equal(other *Keyring) bool {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+	if k.primaryID != other.primaryID || len(k.encryptors) != len(other.encryptors) {
+		return false
+	}
+	for id, e := range k.encryptors {
+		o, ok := other.encryptors[id]
+		if !ok || o.Key != e.Key {
+			return false
+		}
+	}
+	return true
+}
+
+// Replace replaces the keys of the keyring by the ones of the given keyring
+func (k *Keyring) Replace(other *Keyring) {
+	other.lock.RLock()
+	primaryID := other.primaryID
+	encryptors := make(map[string]*Encryptor, len(other.encryptors))
+	for id, e := range other.encryptors {
+		encryptors[id] = e
+	}
+	other.lock.RUnlock()
+
+	k.lock.Lock()
+	k.primaryID = primaryID
+	k.encryptors = encryptors
+	k.lock.Unlock()
+}
+
+// Encrypt encrypts data with the primary key and prefixes it with the key ID
+func (k *Keyring) Encrypt(data []byte) ([]byte, error) {
+	k.lock.RLock()
+	id := k.primaryID
+	e := k.encryptors[id]
+	k.lock.RUnlock()
+
+	encrypted, err := e.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, len(header)+1+len(id)+len(encrypted))
+	result = append(result, header...)
+	result = append(result, byte(len(id)))
+	result = append(result, id...)
+	return append(result, encrypted...), nil
+}
+
+// Decrypt decrypts data encrypted by the keyring or by an Encryptor using one of the keyring keys.
+//
+// Data is decrypted with the key whose ID is embedded into it first. If this key is unknown or fails, for
+// instance because a key ID was given a new key before existing values were re-encrypted, other keys are tried.
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	id, encrypted, ok := splitKeyID(data)
+	if ok {
+		e, found := k.encryptors[id]
+		if found {
+			if decrypted, err := e.Decrypt(encrypted); err == nil {
+				return decrypted, nil
+			}
+		}
+		if decrypted, ok := k.decryptWithOtherKeys(encrypted, id); ok {
+			return decrypted, nil
+		}
+		if !found {
+			return nil, errors.Errorf("failed to decrypt data: unknown key %q", id)
+		}
+		return nil, errors.Errorf("failed to decrypt data with key %q or any other key of the keyring", id)
+	}
+
+	// Data encrypted without key ID, try the default key first
+	if e, found := k.encryptors[DefaultKeyID]; found {
+		if decrypted, err := e.Decrypt(data); err == nil {
+			return decrypted, nil
+		}
+	}
+	if decrypted, ok := k.decryptWithOtherKeys(data, DefaultKeyID); ok {
+		return decrypted, nil
+	}
+	return nil, errors.New("failed to decrypt data with any key of the keyring")
+}
+
+// decryptWithOtherKeys tries to decrypt data with the keys of the keyring except the one with the given ID
+func (k *Keyring) decryptWithOtherKeys(data []byte, excludedID string) ([]byte, bool) {
+	for id, e := range k.encryptors {
+		if id == excludedID {
+			continue
+		}
+		if decrypted, err := e.Decrypt(data); err == nil {
+			return decrypted, true
+		}
+	}
+	return nil, false
+}
+
+// NeedsReencryption returns true if the given data was not encrypted with the primary key
+func (k *Keyring) NeedsReencryption(data []byte) bool {
+	id, _, ok := splitKeyID(data)
+	return !ok || id != k.PrimaryKeyID()
+}
+
+// Reencrypt decrypts data and encrypts it with the primary key
+func (k *Keyring) Reencrypt(data []byte) ([]byte, error) {
+	decrypted, err := k.Decrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	return k.Encrypt(decrypted)
+}
+
+// KeyID returns the ID of the key used to encrypt the given data.
+//
+// It returns false if the data was not encrypted by a Keyring.
+func KeyID(data []byte) (string, bool) {
+	id, _, ok := splitKeyID(data)
+	return id, ok
+}
+
+func splitKeyID(data []byte) (string, []byte, bool) {
+	if len(data) < len(header)+1 || !bytes.HasPrefix(data, header) {
+		return "", nil, false
+	}
+	idLength := int(data[len(header)])
+	start := len(header) + 1
+	if idLength == 0 || len(data) < start+idLength {
+		return "", nil, false
+	}
+	return string(data[start : start+idLength]), data[start+idLength:], true
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+)
+
+const (
+	testPassphrase1 = "myverystrongpasswordo32bitlength"
+	testPassphrase2 = "anotherverystrongpassword32chars"
+)
+
+func TestNewKeyringFromProperties(t *testing.T) {
+	tests := []struct {
+		name        string
+		properties  config.DynamicMap
+		wantPrimary string
+		wantIDs     []string
+		wantErr     bool
+	}{
+		{"Passphrase", config.DynamicMap{"passphrase": testPassphrase1}, DefaultKeyID, []string{DefaultKeyID}, false},
+		{"SingleKey", config.DynamicMap{"keys": map[string]interface{}{"k1": testPassphrase1}}, "k1", []string{"k1"}, false},
+		{"PassphraseAndKeys", config.DynamicMap{"passphrase": testPassphrase1, "keys": map[string]interface{}{"k2": testPassphrase2}, "primary_key": "k2"}, "k2", []string{DefaultKeyID, "k2"}, false},
+		{"MissingKey", config.DynamicMap{}, "", nil, true},
+		{"MissingPrimaryKey", config.DynamicMap{"passphrase": testPassphrase1, "keys": map[string]interface{}{"k2": testPassphrase2}}, "", nil, true},
+		{"UnknownPrimaryKey", config.DynamicMap{"passphrase": testPassphrase1, "primary_key": "k2"}, "", nil, true},
+		{"InvalidKeyLength", config.DynamicMap{"keys": map[string]interface{}{"k1": "short"}}, "", nil, true},
+		{"ConflictingDefaultKey", config.DynamicMap{"passphrase": testPassphrase1, "keys": map[string]interface{}{DefaultKeyID: testPassphrase2}}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := NewKeyringFromProperties("testStoreID", tt.properties)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPrimary, k.PrimaryKeyID())
+			require.Equal(t, tt.wantIDs, k.KeyIDs())
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	data := []byte(`{"Bar":"value"}`)
+
+	// Data encrypted before keyrings were introduced
+	legacyEncryptor, err := NewEncryptor(hex.EncodeToString([]byte(testPassphrase1)))
+	require.NoError(t, err)
+	legacy, err := legacyEncryptor.Encrypt(data)
+	require.NoError(t, err)
+
+	k1, err := NewKeyringFromProperties("testStoreID", config.DynamicMap{"passphrase": testPassphrase1})
+	require.NoError(t, err)
+	encrypted1, err := k1.Encrypt(data)
+	require.NoError(t, err)
+	id, ok := KeyID(encrypted1)
+	require.True(t, ok)
+	require.Equal(t, DefaultKeyID, id)
+	_, ok = KeyID(legacy)
+	require.False(t, ok)
+	require.True(t, k1.NeedsReencryption(legacy))
+	require.False(t, k1.NeedsReencryption(encrypted1))
+
+	k2, err := NewKeyringFromProperties("testStoreID", config.DynamicMap{"passphrase": testPassphrase1, "keys": map[string]interface{}{"k2": testPassphrase2}, "primary_key": "k2"})
+	require.NoError(t, err)
+	require.False(t, k1.Equal(k2))
+	k1.Replace(k2)
+	require.True(t, k1.Equal(k2))
+	require.Equal(t, "k2", k1.PrimaryKeyID())
+
+	for _, encrypted := range [][]byte{legacy, encrypted1} {
+		require.True(t, k1.NeedsReencryption(encrypted))
+		decrypted, err := k1.Decrypt(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+
+		reencrypted, err := k1.Reencrypt(encrypted)
+		require.NoError(t, err)
+		id, ok := KeyID(reencrypted)
+		require.True(t, ok)
+		require.Equal(t, "k2", id)
+		require.False(t, k1.NeedsReencryption(reencrypted))
+	}
+
+	// Data encrypted with a key whose ID was given another key is decrypted with the other keys
+	k4, err := NewKeyringFromProperties("testStoreID", config.DynamicMap{"keys": map[string]interface{}{DefaultKeyID: testPassphrase2, "old": testPassphrase1}, "primary_key": DefaultKeyID})
+	require.NoError(t, err)
+	decrypted, err := k4.Decrypt(encrypted1)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	// Data encrypted with a removed key can't be decrypted anymore
+	k3, err := NewKeyringFromProperties("testStoreID", config.DynamicMap{"keys": map[string]interface{}{"k2": testPassphrase2}})
+	require.NoError(t, err)
+	_, err = k3.Decrypt(encrypted1)
+	require.Error(t, err)
+	_, err = k3.Decrypt(legacy)
+	require.Error(t, err)
+	_, err = k3.Decrypt([]byte("short"))
+	require.Error(t, err)
+}
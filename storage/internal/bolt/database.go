@@ -0,0 +1,290 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	// kvBucket holds stored values, keys are the full store keys
+	kvBucket = []byte("kv")
+	// tombstonesBucket keeps track of deleted keys in order to compute indexes of prefixes
+	tombstonesBucket = []byte("tombstones")
+	// metaBucket holds the store metadata
+	metaBucket = []byte("meta")
+	// reapIndexKey is the key of the highest index of reaped tombstones within metaBucket
+	reapIndexKey = []byte("reap_index")
+)
+
+// tombstoneTTL is the duration during which a tombstone is kept after a key deletion
+const tombstoneTTL = 15 * time.Minute
+
+// openTimeout is the maximum duration to wait for the database file lock
+const openTimeout = 10 * time.Second
+
+// databases keeps opened databases by file path as a database file can only be opened once
+var databases = struct {
+	sync.Mutex
+	m map[string]*database
+}{m: make(map[string]*database)}
+
+// database wraps a BoltDB database and notifies blocking queries of changes
+type database struct {
+	db *bbolt.DB
+
+	notifyLock sync.Mutex
+	// changed is closed and replaced on each committed change
+	changed chan struct{}
+}
+
+// openDatabase returns the database stored in the given file, opening it if it is not already opened
+func openDatabase(filePath string) (*database, error) {
+	databases.Lock()
+	defer databases.Unlock()
+	if d, ok := databases.m[filePath]; ok {
+		return d, nil
+	}
+	err := os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory for bolt store file %q", filePath)
+	}
+	db, err := bbolt.Open(filePath, 0600, &bbolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open bolt store file %q", filePath)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{kvBucket, tombstonesBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		// Index 1 is the index of an empty store, changes start at index 2
+		kv := tx.Bucket(kvBucket)
+		if kv.Sequence() == 0 {
+			return kv.SetSequence(1)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to initialize bolt store file %q", filePath)
+	}
+	d := &database{db: db, changed: make(chan struct{})}
+	databases.m[filePath] = d
+	return d, nil
+}
+
+// CloseDatabases closes all opened databases.
+//
+// It should be called on shutdown, stores using these databases can't be used anymore.
+func CloseDatabases() error {
+	databases.Lock()
+	defer databases.Unlock()
+	var err error
+	for filePath, d := range databases.m {
+		if closeErr := d.db.Close(); closeErr != nil && err == nil {
+			err = errors.Wrapf(closeErr, "failed to close bolt store file %q", filePath)
+		}
+		delete(databases.m, filePath)
+	}
+	return err
+}
+
+// update runs f within a read-write transaction and notifies blocking queries if f returns true
+func (d *database) update(f func(tx *bbolt.Tx) (bool, error)) error {
+	var changed bool
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		changed, err = f(tx)
+		return err
+	})
+	if err == nil && changed {
+		d.notify()
+	}
+	return err
+}
+
+func (d *database) notify() {
+	d.notifyLock.Lock()
+	close(d.changed)
+	d.changed = make(chan struct{})
+	d.notifyLock.Unlock()
+}
+
+// watch returns a channel closed on the next committed change
+func (d *database) watch() <-chan struct{} {
+	d.notifyLock.Lock()
+	defer d.notifyLock.Unlock()
+	return d.changed
+}
+
+// nextIndex returns the index of the change made by the given transaction
+func nextIndex(tx *bbolt.Tx) (uint64, error) {
+	return tx.Bucket(kvBucket).NextSequence()
+}
+
+// encodeEntry prefixes the data with its modify index
+func encodeEntry(index uint64, data []byte) []byte {
+	b := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(b, index)
+	copy(b[8:], data)
+	return b
+}
+
+// decodeEntry returns the modify index and a copy of the data of an entry
+func decodeEntry(b []byte) (uint64, []byte) {
+	if len(b) < 8 {
+		return 0, nil
+	}
+	data := make([]byte, len(b)-8)
+	copy(data, b[8:])
+	return binary.BigEndian.Uint64(b), data
+}
+
+func entryIndex(b []byte) uint64 {
+	if len(b) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// encodeTombstone stores the deletion index, date and whether sub-keys were deleted too
+func encodeTombstone(index uint64, deletedAt time.Time, recursive bool) []byte {
+	b := make([]byte, 17)
+	binary.BigEndian.PutUint64(b, index)
+	binary.BigEndian.PutUint64(b[8:], uint64(deletedAt.UnixNano()))
+	if recursive {
+		b[16] = 1
+	}
+	return b
+}
+
+func decodeTombstone(b []byte) (uint64, time.Time, bool) {
+	if len(b) < 17 {
+		return 0, time.Time{}, false
+	}
+	return binary.BigEndian.Uint64(b), time.Unix(0, int64(binary.BigEndian.Uint64(b[8:]))), b[16] == 1
+}
+
+// dirPrefix returns the prefix of sub-keys of k
+func dirPrefix(k string) []byte {
+	return []byte(strings.TrimSuffix(k, "/") + "/")
+}
+
+// walkPrefix calls f on each key/value of the bucket having the given prefix until f returns false
+func walkPrefix(b *bbolt.Bucket, prefix []byte, f func(k, v []byte) bool) {
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// hasPrefix returns true if a key of the bucket has the given prefix
+func hasPrefix(b *bbolt.Bucket, prefix []byte) bool {
+	k, _ := b.Cursor().Seek(prefix)
+	return k != nil && bytes.HasPrefix(k, prefix)
+}
+
+// lastModifyIndex returns the index of the last change of k or of one of its sub-keys.
+//
+// Deleted keys are taken into account using tombstones. As Consul does, the current index of
+// the whole store is returned if neither k nor its sub-keys have been modified.
+func lastModifyIndex(tx *bbolt.Tx, k string) uint64 {
+	k = strings.TrimSuffix(k, "/")
+	kv := tx.Bucket(kvBucket)
+	tombstones := tx.Bucket(tombstonesBucket)
+
+	var index uint64
+	max := func(i uint64) {
+		if i > index {
+			index = i
+		}
+	}
+	max(entryIndex(kv.Get([]byte(k))))
+	walkPrefix(kv, dirPrefix(k), func(_, v []byte) bool {
+		max(entryIndex(v))
+		return true
+	})
+	if i, _, _ := decodeTombstone(tombstones.Get([]byte(k))); i > 0 {
+		max(i)
+	}
+	walkPrefix(tombstones, dirPrefix(k), func(_, v []byte) bool {
+		i, _, _ := decodeTombstone(v)
+		max(i)
+		return true
+	})
+	// A recursive deletion of a parent key deleted k too
+	for parent := k; strings.Contains(parent, "/"); {
+		parent = parent[:strings.LastIndex(parent, "/")]
+		if i, _, recursive := decodeTombstone(tombstones.Get([]byte(parent))); recursive {
+			max(i)
+		}
+	}
+
+	if index == 0 {
+		index = kv.Sequence()
+	}
+	max(reapIndex(tx))
+	return index
+}
+
+// reapIndex returns the highest index of reaped tombstones, indexes of keys can't be lower than it
+func reapIndex(tx *bbolt.Tx) uint64 {
+	b := tx.Bucket(metaBucket).Get(reapIndexKey)
+	if len(b) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// reapTombstones removes tombstones older than tombstoneTTL
+func reapTombstones(tx *bbolt.Tx, now time.Time) error {
+	tombstones := tx.Bucket(tombstonesBucket)
+	highest := reapIndex(tx)
+	var reaped [][]byte
+	err := tombstones.ForEach(func(k, v []byte) error {
+		index, deletedAt, _ := decodeTombstone(v)
+		if now.Sub(deletedAt) > tombstoneTTL {
+			reaped = append(reaped, append([]byte(nil), k...))
+			if index > highest {
+				highest = index
+			}
+		}
+		return nil
+	})
+	if err != nil || len(reaped) == 0 {
+		return err
+	}
+	for _, k := range reaped {
+		if err = tombstones.Delete(k); err != nil {
+			return err
+		}
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, highest)
+	return tx.Bucket(metaBucket).Put(reapIndexKey, b)
+}
@@ -0,0 +1,419 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/bbolt"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage/encoding"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/utils"
+)
+
+const defaultBlockingQueryTimeout = 5 * time.Minute
+
+const maxBlockingQueryTimeout = 10 * time.Minute
+
+type boltStore struct {
+	id             string
+	properties     config.DynamicMap
+	database       *database
+	codec          encoding.Codec
+	withEncryption bool
+	keyring        *encryption.Keyring
+}
+
+// NewStore returns a new store embedding a BoltDB database stored into a single file.
+//
+// Values are stored with their modify index and collections are written within a single transaction.
+func NewStore(cfg config.Configuration, storeID string, properties config.DynamicMap, withEncryption bool) (store.Store, error) {
+	if properties == nil {
+		properties = config.DynamicMap{}
+	}
+
+	s := &boltStore{
+		id:             storeID,
+		properties:     properties,
+		codec:          encoding.JSON,
+		withEncryption: withEncryption,
+	}
+
+	// Instantiate keyring if necessary
+	if withEncryption {
+		var err error
+		s.keyring, err = encryption.NewKeyringFromProperties(storeID, properties)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filePath := properties.GetString("file")
+	if filePath == "" {
+		rootDir := properties.GetStringOrDefault("root_dir", path.Join(cfg.WorkingDirectory, "store"))
+		filePath = filepath.Join(rootDir, storeID+".db")
+	}
+	var err error
+	s.database, err = openDatabase(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *boltStore) encode(v interface{}) ([]byte, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal value %+v due to error:%+v", v, err)
+	}
+	if s.withEncryption {
+		return s.keyring.Encrypt(data)
+	}
+	return data, nil
+}
+
+func (s *boltStore) decode(data []byte) ([]byte, error) {
+	if s.withEncryption {
+		return s.keyring.Decrypt(data)
+	}
+	return data, nil
+}
+
+func (s *boltStore) Set(ctx context.Context, k string, v interface{}) error {
+	return s.SetCollection(ctx, []store.KeyValueIn{{Key: k, Value: v}})
+}
+
+// SetCollection stores all the given key-values within a single transaction
+func (s *boltStore) SetCollection(ctx context.Context, keyValues []store.KeyValueIn) error {
+	if len(keyValues) == 0 {
+		return nil
+	}
+	// Marshal and encrypt values outside of the transaction as it locks writes on the whole database
+	values := make([][]byte, len(keyValues))
+	for i, kv := range keyValues {
+		if err := utils.CheckKeyAndValue(kv.Key, kv.Value); err != nil {
+			return err
+		}
+		data, err := s.encode(kv.Value)
+		if err != nil {
+			return err
+		}
+		values[i] = data
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.database.update(func(tx *bbolt.Tx) (bool, error) {
+		index, err := nextIndex(tx)
+		if err != nil {
+			return false, err
+		}
+		kvB := tx.Bucket(kvBucket)
+		tombstones := tx.Bucket(tombstonesBucket)
+		for i, kv := range keyValues {
+			err = kvB.Put([]byte(kv.Key), encodeEntry(index, values[i]))
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to store key %q", kv.Key)
+			}
+			err = tombstones.Delete([]byte(kv.Key))
+			if err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+}
+
+func (s *boltStore) Get(k string, v interface{}) (bool, error) {
+	if err := utils.CheckKeyAndValue(k, v); err != nil {
+		return false, err
+	}
+
+	var found bool
+	var data []byte
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(kvBucket).Get([]byte(k))
+		if b == nil {
+			return nil
+		}
+		found = true
+		_, data = decodeEntry(b)
+		return nil
+	})
+	if err != nil || !found {
+		return false, err
+	}
+	data, err = s.decode(data)
+	if err != nil {
+		return false, err
+	}
+	return true, errors.Wrapf(s.codec.Unmarshal(data, v), "failed to unmarshal data:%q", string(data))
+}
+
+func (s *boltStore) Exist(k string) (bool, error) {
+	var found bool
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		found = tx.Bucket(kvBucket).Get([]byte(k)) != nil
+		return nil
+	})
+	return found, err
+}
+
+func (s *boltStore) Keys(k string) ([]string, error) {
+	var result []string
+	seen := make(map[string]struct{})
+	prefix := dirPrefix(k)
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(kvBucket).Cursor()
+		for key, _ := c.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); {
+			subKey := key[len(prefix):]
+			i := bytes.IndexByte(subKey, '/')
+			if i >= 0 {
+				subKey = subKey[:i]
+			}
+			if _, ok := seen[string(subKey)]; !ok {
+				seen[string(subKey)] = struct{}{}
+				result = append(result, path.Join(k, string(subKey)))
+			}
+			if i < 0 {
+				key, _ = c.Next()
+				continue
+			}
+			// Skip deeper keys of this sub-key: '0' is the character following '/'
+			key, _ = c.Seek(append(append(append([]byte(nil), prefix...), subKey...), '0'))
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *boltStore) Delete(ctx context.Context, k string, recursive bool) error {
+	if err := utils.CheckKey(k); err != nil {
+		return err
+	}
+
+	return s.database.update(func(tx *bbolt.Tx) (bool, error) {
+		kvB := tx.Bucket(kvBucket)
+		var deleted [][]byte
+		if kvB.Get([]byte(k)) != nil {
+			deleted = append(deleted, []byte(k))
+		}
+		if recursive {
+			walkPrefix(kvB, dirPrefix(k), func(key, _ []byte) bool {
+				deleted = append(deleted, append([]byte(nil), key...))
+				return true
+			})
+		}
+		if len(deleted) == 0 {
+			return false, nil
+		}
+		for _, key := range deleted {
+			if err := kvB.Delete(key); err != nil {
+				return false, errors.Wrapf(err, "failed to delete key %q", string(key))
+			}
+		}
+
+		index, err := nextIndex(tx)
+		if err != nil {
+			return false, err
+		}
+		now := time.Now()
+		err = tx.Bucket(tombstonesBucket).Put([]byte(strings.TrimSuffix(k, "/")), encodeTombstone(index, now, recursive))
+		if err != nil {
+			return false, err
+		}
+		return true, reapTombstones(tx, now)
+	})
+}
+
+func (s *boltStore) GetLastModifyIndex(k string) (uint64, error) {
+	var index uint64
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		index = lastModifyIndex(tx, k)
+		return nil
+	})
+	return index, errors.Wrapf(err, "failed to get last index for key:%q", k)
+}
+
+func (s *boltStore) List(ctx context.Context, k string, waitIndex uint64, timeout time.Duration) ([]store.KeyValueOut, uint64, error) {
+	if err := utils.CheckKey(k); err != nil {
+		return nil, 0, err
+	}
+
+	if waitIndex > 0 {
+		// Default timeout to 5 minutes if not set as param or as config property
+		if timeout == 0 {
+			timeout = s.properties.GetDuration("blocking_query_default_timeout")
+			if timeout == 0 {
+				timeout = defaultBlockingQueryTimeout
+			}
+		}
+		if timeout > maxBlockingQueryTimeout {
+			timeout = maxBlockingQueryTimeout
+		}
+		err := s.waitForChange(ctx, k, waitIndex, timeout)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var kvs []store.KeyValueOut
+	var lastIndex uint64
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		lastIndex = lastModifyIndex(tx, k)
+		kvB := tx.Bucket(kvBucket)
+		prefix := dirPrefix(k)
+		if !hasPrefix(kvB, prefix) {
+			return nil
+		}
+		kvs = make([]store.KeyValueOut, 0)
+		var err error
+		walkPrefix(kvB, prefix, func(key, v []byte) bool {
+			index, data := decodeEntry(v)
+			if index <= waitIndex {
+				return true
+			}
+			data, err = s.decode(data)
+			if err != nil {
+				return false
+			}
+			var value map[string]interface{}
+			if err = s.codec.Unmarshal(data, &value); err != nil {
+				err = errors.Wrapf(err, "failed to unmarshal data:%q", string(data))
+				return false
+			}
+			kvs = append(kvs, store.KeyValueOut{
+				Key:             string(key),
+				LastModifyIndex: index,
+				Value:           value,
+				RawValue:        data,
+			})
+			return true
+		})
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return kvs, lastIndex, nil
+}
+
+// waitForChange blocks until the last modify index of k is greater than waitIndex, the timeout is reached
+// or the context is cancelled
+func (s *boltStore) waitForChange(ctx context.Context, k string, waitIndex uint64, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		// Get the notification channel before checking the index to not miss a change
+		changed := s.database.watch()
+		index, err := s.GetLastModifyIndex(k)
+		if err != nil {
+			return err
+		}
+		if index > waitIndex {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			log.Debugf("Cancel signal has been received: the store List query is stopped")
+			return nil
+		case <-timer.C:
+			log.Debugf("Timeout has been reached: the store List query is stopped")
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+// reencryptionBatchSize is the maximum number of values re-encrypted within a single transaction
+const reencryptionBatchSize = 500
+
+func (s *boltStore) Keyring() *encryption.Keyring {
+	return s.keyring
+}
+
+// ReencryptValues re-encrypts values by batches of transactions.
+//
+// Modify indexes are kept unchanged as values themselves are not modified.
+func (s *boltStore) ReencryptValues(ctx context.Context) (int, error) {
+	if !s.withEncryption {
+		return 0, nil
+	}
+	var keys [][]byte
+	err := s.database.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(kvBucket).ForEach(func(k, v []byte) error {
+			_, data := decodeEntry(v)
+			if s.keyring.NeedsReencryption(data) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to re-encrypt values of bolt store with ID:%q", s.id)
+	}
+
+	var count int
+	for start := 0; start < len(keys); start += reencryptionBatchSize {
+		if err = ctx.Err(); err != nil {
+			return count, err
+		}
+		end := start + reencryptionBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		var batchCount int
+		err = s.database.update(func(tx *bbolt.Tx) (bool, error) {
+			batchCount = 0
+			kvB := tx.Bucket(kvBucket)
+			for _, k := range keys[start:end] {
+				b := kvB.Get(k)
+				if b == nil {
+					// Deleted in the meantime
+					continue
+				}
+				index, data := decodeEntry(b)
+				if !s.keyring.NeedsReencryption(data) {
+					continue
+				}
+				data, err := s.keyring.Reencrypt(data)
+				if err != nil {
+					return false, errors.Wrapf(err, "failed to re-encrypt key %q", string(k))
+				}
+				if err = kvB.Put(k, encodeEntry(index, data)); err != nil {
+					return false, errors.Wrapf(err, "failed to store key %q", string(k))
+				}
+				batchCount++
+			}
+			return false, nil
+		})
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to re-encrypt values of bolt store with ID:%q", s.id)
+		}
+		count += batchCount
+	}
+	return count, nil
+}
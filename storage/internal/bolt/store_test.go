@@ -0,0 +1,233 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bolt
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+func TestRunBoltStoragePackageTests(t *testing.T) {
+	cfg := store.SetupTestConfig(t)
+	defer func() {
+		os.RemoveAll(cfg.WorkingDirectory)
+	}()
+
+	t.Run("groupBoltStore", func(t *testing.T) {
+		t.Run("testBoltStore", func(t *testing.T) {
+			testBoltStore(t, cfg)
+		})
+		t.Run("testBoltStoreTypes", func(t *testing.T) {
+			testBoltStoreTypes(t, cfg)
+		})
+		t.Run("testBoltStoreWithEncryption", func(t *testing.T) {
+			testBoltStoreWithEncryption(t, cfg)
+		})
+		t.Run("testBoltStoreKeyRotation", func(t *testing.T) {
+			testBoltStoreKeyRotation(t, cfg)
+		})
+		t.Run("testBoltStoreWithEncryptionWithoutSecretKeyProvided", func(t *testing.T) {
+			testBoltStoreWithEncryptionWithoutSecretKeyProvided(t, cfg)
+		})
+		t.Run("testBoltStoreKeys", func(t *testing.T) {
+			testBoltStoreKeys(t, cfg)
+		})
+		t.Run("testBoltStoreIndexes", func(t *testing.T) {
+			testBoltStoreIndexes(t, cfg)
+		})
+		t.Run("testBoltStoreBlockingList", func(t *testing.T) {
+			testBoltStoreBlockingList(t, cfg)
+		})
+		t.Run("testCloseDatabases", func(t *testing.T) {
+			testCloseDatabases(t, cfg)
+		})
+	})
+}
+
+func newTestStore(t *testing.T, cfg config.Configuration, props config.DynamicMap, withEncryption bool) store.Store {
+	if props == nil {
+		props = config.DynamicMap{}
+	}
+	props["root_dir"] = path.Join(cfg.WorkingDirectory, t.Name())
+	s, err := NewStore(cfg, "testStoreID", props, withEncryption)
+	require.NoError(t, err, "failed to instantiate new store")
+	return s
+}
+
+func testBoltStore(t *testing.T, cfg config.Configuration) {
+	store.CommonStoreTest(t, newTestStore(t, cfg, nil, false))
+}
+
+func testBoltStoreTypes(t *testing.T, cfg config.Configuration) {
+	store.CommonStoreTestAllTypes(t, newTestStore(t, cfg, nil, false))
+}
+
+func testBoltStoreWithEncryption(t *testing.T, cfg config.Configuration) {
+	s := newTestStore(t, cfg, config.DynamicMap{"passphrase": "myverystrongpasswordo32bitlength"}, true)
+	store.CommonStoreTest(t, s)
+	store.CommonStoreTestAllTypes(t, s)
+}
+
+func testBoltStoreWithEncryptionWithoutSecretKeyProvided(t *testing.T, cfg config.Configuration) {
+	_, err := NewStore(cfg, "testStoreID", nil, true)
+	require.Error(t, err, "expected error as secret key is missing")
+}
+
+func testBoltStoreKeys(t *testing.T, cfg config.Configuration) {
+	s := newTestStore(t, cfg, nil, false)
+	ctx := context.Background()
+	err := s.SetCollection(ctx, []store.KeyValueIn{
+		{Key: "root/a", Value: "v"},
+		{Key: "root/a-b", Value: "v"},
+		{Key: "root/a/c", Value: "v"},
+		{Key: "root/a/d/e", Value: "v"},
+		{Key: "root/b/c", Value: "v"},
+		{Key: "rootOther/a", Value: "v"},
+	})
+	require.NoError(t, err)
+
+	keys, err := s.Keys("root")
+	require.NoError(t, err)
+	require.Equal(t, []string{"root/a", "root/a-b", "root/b"}, keys)
+
+	keys, err = s.Keys("root/a/")
+	require.NoError(t, err)
+	require.Equal(t, []string{"root/a/c", "root/a/d"}, keys)
+
+	keys, err = s.Keys("root/none")
+	require.NoError(t, err)
+	require.Nil(t, keys)
+}
+
+func testBoltStoreIndexes(t *testing.T, cfg config.Configuration) {
+	s := newTestStore(t, cfg, nil, false)
+	ctx := context.Background()
+
+	initialIndex, err := s.GetLastModifyIndex("dep")
+	require.NoError(t, err)
+	require.NotZero(t, initialIndex)
+
+	require.NoError(t, s.Set(ctx, "dep/logs/1", "v"))
+	setIndex, err := s.GetLastModifyIndex("dep")
+	require.NoError(t, err)
+	require.True(t, setIndex > initialIndex)
+
+	// Changes of other keys don't change the index of a prefix
+	require.NoError(t, s.Set(ctx, "other/1", "v"))
+	index, err := s.GetLastModifyIndex("dep")
+	require.NoError(t, err)
+	require.Equal(t, setIndex, index)
+
+	// Deleting keys changes the index of a prefix
+	require.NoError(t, s.Delete(ctx, "dep", true))
+	deleteIndex, err := s.GetLastModifyIndex("dep/logs")
+	require.NoError(t, err)
+	require.True(t, deleteIndex > setIndex)
+	index, err = s.GetLastModifyIndex("dep")
+	require.NoError(t, err)
+	require.Equal(t, deleteIndex, index)
+
+	// Deleting a missing key is not a change
+	require.NoError(t, s.Delete(ctx, "dep", true))
+	index, err = s.GetLastModifyIndex("dep")
+	require.NoError(t, err)
+	require.Equal(t, deleteIndex, index)
+}
+
+func testBoltStoreBlockingList(t *testing.T, cfg config.Configuration) {
+	s := newTestStore(t, cfg, nil, false)
+	ctx := context.Background()
+
+	require.NoError(t, s.Set(ctx, "logs/dep/1", map[string]string{"msg": "one"}))
+	kvs, index, err := s.List(ctx, "logs/dep", 0, 0)
+	require.NoError(t, err)
+	require.Len(t, kvs, 1)
+
+	type listResult struct {
+		kvs   []store.KeyValueOut
+		index uint64
+		err   error
+	}
+	results := make(chan listResult)
+	go func() {
+		kvs, index, err := s.List(ctx, "logs/dep", index, 10*time.Second)
+		results <- listResult{kvs, index, err}
+	}()
+
+	// A change of another prefix should not unblock the query
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, s.Set(ctx, "logs/other/1", map[string]string{"msg": "other"}))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, s.Set(ctx, "logs/dep/2", map[string]string{"msg": "two"}))
+
+	select {
+	case r := <-results:
+		require.NoError(t, r.err)
+		require.True(t, r.index > index)
+		require.Len(t, r.kvs, 1)
+		require.Equal(t, "logs/dep/2", r.kvs[0].Key)
+		require.Equal(t, "two", r.kvs[0].Value["msg"])
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "blocking query not released by a change")
+	}
+
+	// Cancelling the context releases a blocking query
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	kvs, _, err = s.List(cancelCtx, "logs/dep", index+100, 10*time.Second)
+	require.NoError(t, err)
+	require.Len(t, kvs, 0)
+}
+
+func testBoltStoreKeyRotation(t *testing.T, cfg config.Configuration) {
+	s := newTestStore(t, cfg, config.DynamicMap{"passphrase": "myverystrongpasswordo32bitlength"}, true)
+	newKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"passphrase":  "myverystrongpasswordo32bitlength",
+		"keys":        map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+		"primary_key": "key2",
+	})
+	require.NoError(t, err)
+	onlyNewKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"keys": map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+	})
+	require.NoError(t, err)
+	store.CommonKeyRotationTest(t, s, newKeyring, onlyNewKeyring)
+}
+
+func testCloseDatabases(t *testing.T, cfg config.Configuration) {
+	ctx := context.Background()
+	s := newTestStore(t, cfg, nil, false)
+	require.NoError(t, s.Set(ctx, "close/key", "value"))
+
+	require.NoError(t, CloseDatabases())
+	require.Len(t, databases.m, 0)
+
+	// The database file lock should be released
+	s = newTestStore(t, cfg, nil, false)
+	var value string
+	found, err := s.Get("close/key", &value)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "value", value)
+}
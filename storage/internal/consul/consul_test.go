@@ -16,10 +16,14 @@ package consul
 
 import (
 	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
 
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
 	"github.com/ystia/yorc/v4/storage/encoding"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"github.com/ystia/yorc/v4/storage/store"
 )
 
@@ -39,15 +43,58 @@ func TestRunConsulStoragePackageTests(t *testing.T) {
 		t.Run("testConsulStore", func(t *testing.T) {
 			testStore(t, srv)
 		})
+		t.Run("testConsulCompaction", func(t *testing.T) {
+			testCompaction(t, srv)
+		})
+		t.Run("testConsulStoreWithEncryption", func(t *testing.T) {
+			testStoreWithEncryption(t, srv)
+		})
+		t.Run("testConsulKeyRotation", func(t *testing.T) {
+			testKeyRotation(t, srv)
+		})
 	})
 }
 
 func testStore(t *testing.T, srv1 *testutil.TestServer) {
-	csStore := &consulStore{encoding.JSON}
+	csStore := &consulStore{codec: encoding.JSON}
 	store.CommonStoreTest(t, csStore)
 }
 
 func testTypes(t *testing.T, srv1 *testutil.TestServer) {
-	csStore := &consulStore{encoding.JSON}
+	csStore := &consulStore{codec: encoding.JSON}
+	store.CommonStoreTestAllTypes(t, csStore)
+}
+
+func testCompaction(t *testing.T, srv1 *testutil.TestServer) {
+	csStore := &consulStore{codec: encoding.JSON}
+	store.CommonCompactionTest(t, csStore, csStore.Compact)
+}
+
+func testStoreWithEncryption(t *testing.T, srv1 *testutil.TestServer) {
+	// Remove values stored without encryption by previous tests
+	_, err := consulutil.GetKV().DeleteTree("", nil)
+	require.NoError(t, err)
+	csStore, err := NewCipherStore("testStoreID", config.DynamicMap{"passphrase": "myverystrongpasswordo32bitlength"}, nil)
+	require.NoError(t, err)
+	store.CommonStoreTest(t, csStore)
 	store.CommonStoreTestAllTypes(t, csStore)
 }
+
+func testKeyRotation(t *testing.T, srv1 *testutil.TestServer) {
+	csStore, err := NewCipherStore("testStoreID", config.DynamicMap{"passphrase": "myverystrongpasswordo32bitlength"}, []string{"_yorc/deployments/keyRotationDep"})
+	require.NoError(t, err)
+	// A value that is not stored by an encrypted store is ignored
+	srv1.SetKV(t, "_yorc/deployments/keyRotationDep/status", []byte("DEPLOYED"))
+
+	newKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"passphrase":  "myverystrongpasswordo32bitlength",
+		"keys":        map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+		"primary_key": "key2",
+	})
+	require.NoError(t, err)
+	onlyNewKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"keys": map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+	})
+	require.NoError(t, err)
+	store.CommonKeyRotationTest(t, csStore, newKeyring, onlyNewKeyring)
+}
@@ -16,22 +16,61 @@ package consul
 
 import (
 	"context"
+	"time"
+
 	"github.com/hashicorp/consul/api"
 	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
 	"github.com/ystia/yorc/v4/storage/encoding"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"github.com/ystia/yorc/v4/storage/store"
 	"github.com/ystia/yorc/v4/storage/utils"
-	"time"
 )
 
 type consulStore struct {
 	codec encoding.Codec
+	id    string
+	// keyring is nil if values are not encrypted
+	keyring *encryption.Keyring
+	// prefixes are the root keys of values stored by this store
+	prefixes []string
 }
 
 // NewStore returns a new Consul store
 func NewStore() store.Store {
-	return &consulStore{encoding.JSON}
+	return &consulStore{codec: encoding.JSON}
+}
+
+// NewCipherStore returns a new Consul store encrypting values with a keyring defined by the given properties.
+//
+// Prefixes are the root keys of the stored values, they are used to retrieve values to re-encrypt.
+func NewCipherStore(storeID string, properties config.DynamicMap, prefixes []string) (store.Store, error) {
+	keyring, err := encryption.NewKeyringFromProperties(storeID, properties)
+	if err != nil {
+		return nil, err
+	}
+	return &consulStore{codec: encoding.JSON, id: storeID, keyring: keyring, prefixes: prefixes}, nil
+}
+
+func (c *consulStore) encode(v interface{}) ([]byte, error) {
+	data, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal value %+v due to error:%+v", v, err)
+	}
+	if c.keyring != nil {
+		return c.keyring.Encrypt(data)
+	}
+	return data, nil
+}
+
+func (c *consulStore) decode(data []byte) ([]byte, error) {
+	if c.keyring != nil {
+		return c.keyring.Decrypt(data)
+	}
+	return data, nil
 }
 
 func (c *consulStore) Set(ctx context.Context, k string, v interface{}) error {
@@ -39,9 +78,9 @@ func (c *consulStore) Set(ctx context.Context, k string, v interface{}) error {
 		return err
 	}
 
-	data, err := c.codec.Marshal(v)
+	data, err := c.encode(v)
 	if err != nil {
-		return errors.Wrapf(err, "failed to marshal value %+v due to error:%+v", v, err)
+		return err
 	}
 
 	return consulutil.StoreConsulKey(k, data)
@@ -57,9 +96,9 @@ func (c *consulStore) SetCollection(ctx context.Context, keyValues []store.KeyVa
 			return err
 		}
 
-		data, err := c.codec.Marshal(kv.Value)
+		data, err := c.encode(kv.Value)
 		if err != nil {
-			return errors.Wrapf(err, "failed to marshal value %+v due to error:%+v", kv.Value, err)
+			return err
 		}
 
 		consulStore.StoreConsulKey(kv.Key, data)
@@ -76,6 +115,10 @@ func (c *consulStore) Get(k string, v interface{}) (bool, error) {
 	if err != nil || !found {
 		return found, err
 	}
+	value, err = c.decode(value)
+	if err != nil {
+		return false, err
+	}
 
 	return true, errors.Wrapf(c.codec.Unmarshal(value, v), "failed to unmarshal data:%q", string(value))
 }
@@ -123,18 +166,152 @@ func (c *consulStore) List(ctx context.Context, k string, waitIndex uint64, time
 
 	values := make([]store.KeyValueOut, 0)
 	for _, kvp := range kvps {
+		data, err := c.decode(kvp.Value)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to decrypt stored value of key %q", kvp.Key)
+		}
 		var value map[string]interface{}
-		if err := c.codec.Unmarshal(kvp.Value, &value); err != nil {
-			return nil, 0, errors.Wrapf(err, "failed to unmarshal stored value: %q", string(kvp.Value))
+		if err := c.codec.Unmarshal(data, &value); err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to unmarshal stored value: %q", string(data))
 		}
 		if kvp.ModifyIndex > waitIndex {
 			values = append(values, store.KeyValueOut{
 				Key:             kvp.Key,
 				LastModifyIndex: kvp.ModifyIndex,
 				Value:           value,
-				RawValue:        kvp.Value,
+				RawValue:        data,
 			})
 		}
 	}
 	return values, qm.LastIndex, nil
 }
+
+// Compact removes logs or events stored under the key k that are not retained by the given policy.
+//
+// Only keys selected by the policy are removed, using transactions.
+func (c *consulStore) Compact(ctx context.Context, k string, policy store.RetentionPolicy) (store.CompactionResult, error) {
+	var result store.CompactionResult
+	if err := utils.CheckKey(k); err != nil {
+		return result, err
+	}
+
+	entries := make([]store.RetentionEntry, 0)
+	var count int
+	if len(policy.RemovedLevels) == 0 {
+		// Timestamps are read from keys, there is no need to retrieve values
+		keys, _, err := consulutil.GetKV().Keys(k, "", nil)
+		if err != nil {
+			return result, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		count = len(keys)
+		if policy.IsEmpty() {
+			result.Remaining = count
+			return result, nil
+		}
+		for _, key := range keys {
+			if entry, ok := store.NewRetentionEntry(key, nil); ok {
+				entries = append(entries, entry)
+			}
+		}
+	} else {
+		kvps, _, err := consulutil.GetKV().List(k, nil)
+		if err != nil {
+			return result, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		count = len(kvps)
+		for _, kvp := range kvps {
+			data, err := c.decode(kvp.Value)
+			if err != nil {
+				return result, errors.Wrapf(err, "failed to decrypt stored value of key %q", kvp.Key)
+			}
+			var value map[string]interface{}
+			if err := c.codec.Unmarshal(data, &value); err != nil {
+				return result, errors.Wrapf(err, "failed to unmarshal stored value: %q", string(data))
+			}
+			if entry, ok := store.NewRetentionEntry(kvp.Key, value); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	removed := policy.RemovedKeys(entries, time.Now())
+	result.Removed = len(removed)
+	result.Remaining = count - len(removed)
+	if len(removed) == 0 {
+		return result, nil
+	}
+	ops := make(api.KVTxnOps, 0, len(removed))
+	for _, key := range removed {
+		ops = append(ops, &api.KVTxnOp{Verb: api.KVDelete, Key: key})
+	}
+	err := consulutil.ExecuteSplittableTransaction(ops, nil, nil)
+	return result, errors.Wrapf(err, "failed to remove values under key %q", k)
+}
+
+// maxReencryptionAttempts is the maximum number of attempts to re-encrypt a value updated concurrently
+const maxReencryptionAttempts = 3
+
+func (c *consulStore) Keyring() *encryption.Keyring {
+	return c.keyring
+}
+
+// ReencryptValues re-encrypts values stored under the store prefixes.
+//
+// Values are updated using check-and-set operations in order to not overwrite concurrent updates.
+// Values that were not encrypted by a keyring are not managed by this store and are ignored.
+func (c *consulStore) ReencryptValues(ctx context.Context) (int, error) {
+	if c.keyring == nil {
+		return 0, nil
+	}
+	var count int
+	for _, prefix := range c.prefixes {
+		kvps, _, err := consulutil.GetKV().List(prefix, nil)
+		if err != nil {
+			return count, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		for _, kvp := range kvps {
+			if err = ctx.Err(); err != nil {
+				return count, err
+			}
+			reencrypted, err := c.reencryptValue(kvp)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to re-encrypt values of consul store with ID:%q", c.id)
+			}
+			if reencrypted {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (c *consulStore) reencryptValue(kvp *api.KVPair) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		if _, ok := encryption.KeyID(kvp.Value); !ok || !c.keyring.NeedsReencryption(kvp.Value) {
+			return false, nil
+		}
+		data, err := c.keyring.Reencrypt(kvp.Value)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to re-encrypt key %q", kvp.Key)
+		}
+		updated, _, err := consulutil.GetKV().CAS(&api.KVPair{Key: kvp.Key, Value: data, Flags: kvp.Flags, ModifyIndex: kvp.ModifyIndex}, nil)
+		if err != nil {
+			return false, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if updated {
+			return true, nil
+		}
+		if attempt == maxReencryptionAttempts {
+			log.Printf("[WARNING] Failed to re-encrypt key %q updated concurrently, it will be re-encrypted on next rotation", kvp.Key)
+			return false, nil
+		}
+		// The value was updated concurrently, check it again
+		kvp, _, err = consulutil.GetKV().Get(kvp.Key, nil)
+		if err != nil {
+			return false, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if kvp == nil {
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v6/esapi"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/utils"
+)
+
+// maxResultWindow is the default maximum value of from + size for ES searches
+const maxResultWindow = 10000
+
+var ptrue = true
+
+// Compact removes logs or events of a deployment that are not retained by the given policy using delete-by-query requests.
+//
+// Removal by levels only applies to documents indexed after the level field has been added to the index mapping.
+func (s *elasticStore) Compact(ctx context.Context, k string, policy store.RetentionPolicy) (store.CompactionResult, error) {
+	var result store.CompactionResult
+	if err := utils.CheckKey(k); err != nil {
+		return result, err
+	}
+	storeType, deploymentID := extractStoreTypeAndDeploymentID(k)
+	indexName := getIndexName(s.cfg, storeType)
+
+	conditions := make([]interface{}, 0)
+	if policy.MaxAge > 0 {
+		conditions = append(conditions, iidRangeCondition("lt", time.Now().Add(-policy.MaxAge).UnixNano()))
+	}
+	if len(policy.RemovedLevels) > 0 {
+		conditions = append(conditions, map[string]interface{}{"terms": map[string]interface{}{"level": policy.RemovedLevels}})
+	}
+	if len(conditions) > 0 {
+		removed, err := s.deleteByQuery(ctx, indexName, buildCompactionQuery(deploymentID, conditions))
+		if err != nil {
+			return result, err
+		}
+		result.Removed += removed
+	}
+
+	count, err := s.count(ctx, indexName, buildCompactionQuery(deploymentID, nil))
+	if err != nil {
+		return result, err
+	}
+	for policy.MaxEntries > 0 && count > policy.MaxEntries {
+		// Searches are limited to the first maxResultWindow documents, so oldest documents are removed by chunks
+		excess := count - policy.MaxEntries
+		if excess > maxResultWindow {
+			excess = maxResultWindow
+		}
+		iid, err := s.nthOldestIndex(ctx, indexName, deploymentID, excess)
+		if err != nil {
+			return result, err
+		}
+		removed, err := s.deleteByQuery(ctx, indexName, buildCompactionQuery(deploymentID, []interface{}{iidRangeCondition("lte", int64(iid))}))
+		if err != nil {
+			return result, err
+		}
+		if removed == 0 {
+			break
+		}
+		result.Removed += removed
+		count -= removed
+	}
+	result.Remaining = count
+	return result, nil
+}
+
+func iidRangeCondition(operator string, iid int64) map[string]interface{} {
+	return map[string]interface{}{"range": map[string]interface{}{"iid": map[string]interface{}{operator: iid}}}
+}
+
+// buildCompactionQuery returns a query matching documents of a deployment that match at least one of the given conditions.
+// All documents of the deployment are matched if there is no condition.
+func buildCompactionQuery(deploymentID string, conditions []interface{}) string {
+	boolQuery := make(map[string]interface{})
+	if deploymentID != "" {
+		boolQuery["must"] = []interface{}{map[string]interface{}{"term": map[string]interface{}{"deploymentId": deploymentID}}}
+	}
+	if len(conditions) > 0 {
+		boolQuery["should"] = conditions
+		boolQuery["minimum_should_match"] = 1
+	}
+	query, _ := json.Marshal(map[string]interface{}{"query": map[string]interface{}{"bool": boolQuery}})
+	return string(query)
+}
+
+// deleteByQuery removes documents matching the query and returns the number of removed documents
+func (s *elasticStore) deleteByQuery(ctx context.Context, indexName, query string) (int, error) {
+	req := esapi.DeleteByQueryRequest{
+		Index:     []string{indexName},
+		Body:      strings.NewReader(query),
+		Conflicts: "proceed",
+		Refresh:   &ptrue,
+	}
+	res, err := req.Do(ctx, s.esClient)
+	defer closeResponseBody("DeleteByQueryRequest:"+indexName, res)
+	if err = handleESResponseError(res, "DeleteByQueryRequest:"+indexName, query, err); err != nil {
+		return 0, err
+	}
+	var r struct {
+		Deleted int `json:"deleted"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, errors.Wrapf(err, "failed to decode ES response of DeleteByQueryRequest on index %s", indexName)
+	}
+	return r.Deleted, nil
+}
+
+// count returns the number of documents matching the query
+func (s *elasticStore) count(ctx context.Context, indexName, query string) (int, error) {
+	req := esapi.CountRequest{
+		Index: []string{indexName},
+		Body:  strings.NewReader(query),
+	}
+	res, err := req.Do(ctx, s.esClient)
+	defer closeResponseBody("CountRequest:"+indexName, res)
+	if err = handleESResponseError(res, "CountRequest:"+indexName, query, err); err != nil {
+		return 0, err
+	}
+	var r struct {
+		Count int `json:"count"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, errors.Wrapf(err, "failed to decode ES response of CountRequest on index %s", indexName)
+	}
+	return r.Count, nil
+}
+
+// nthOldestIndex returns the iid of the nth oldest document of a deployment
+func (s *elasticStore) nthOldestIndex(ctx context.Context, indexName, deploymentID string, n int) (uint64, error) {
+	query := buildCompactionQuery(deploymentID, nil)
+	res, err := s.esClient.Search(
+		s.esClient.Search.WithContext(ctx),
+		s.esClient.Search.WithIndex(indexName),
+		s.esClient.Search.WithFrom(n-1),
+		s.esClient.Search.WithSize(1),
+		s.esClient.Search.WithBody(strings.NewReader(query)),
+		s.esClient.Search.WithSort("iid:asc"),
+	)
+	defer closeResponseBody("Search:"+indexName, res)
+	if err = handleESResponseError(res, "Search:"+indexName, query, err); err != nil {
+		return 0, err
+	}
+	var r map[string]interface{}
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, errors.Wrapf(err, "failed to decode ES response of Search on index %s", indexName)
+	}
+	var values []store.KeyValueOut
+	iid := decodeEsQueryResponse(s.cfg, indexName, 0, 1, r, &values)
+	if len(values) == 0 {
+		return 0, errors.Errorf("no document found at position %d on index %s for deployment %q", n, indexName, deploymentID)
+	}
+	return iid, nil
+}
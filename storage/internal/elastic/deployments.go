@@ -0,0 +1,520 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v6/esapi"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/utils"
+)
+
+// deploymentsStoreType is the suffix of the index storing deployments data
+const deploymentsStoreType = "deployments"
+
+// maxChildKeys is the maximum number of sub-keys returned by Keys, an error is returned if a key has more sub-keys
+const maxChildKeys = 10000
+
+// listPageSize is the number of documents retrieved by each search request of List
+const listPageSize = 1000
+
+// maxDeploymentsPollPeriod is the maximum period of ES queries done while waiting for a change of deployments data
+const maxDeploymentsPollPeriod = time.Second
+
+// Mapping of the index storing deployments data, values are only kept in the document source
+const deploymentsMappingText = `{
+    "_all": {"enabled": false},
+    "dynamic": "false",
+    "properties": {
+        "key": { "type": "keyword", "index": true },
+        "paths": { "type": "keyword", "index": true },
+        "tombstone": { "type": "boolean", "index": true },
+        "iid": { "type": "long", "index": true },
+        "iidStr": { "type": "keyword", "index": false }
+    }
+}`
+
+// deploymentDocument is a document of the deployments index, one document is stored for each key.
+//
+// Paths are the key itself and all its parents, they allow to retrieve keys stored under a given key.
+// Tombstones are stored when keys are deleted so that deletions change the last modify index of their parents.
+type deploymentDocument struct {
+	Key       string   `json:"key"`
+	Paths     []string `json:"paths"`
+	Value     string   `json:"value,omitempty"`
+	Tombstone bool     `json:"tombstone,omitempty"`
+	IID       int64    `json:"iid"`
+	IIDStr    string   `json:"iidStr"`
+}
+
+var iidLock sync.Mutex
+var lastIID int64
+
+// nextIID returns a strictly increasing index based on the current time
+func nextIID() int64 {
+	iidLock.Lock()
+	defer iidLock.Unlock()
+	iid := time.Now().UnixNano()
+	if iid <= lastIID {
+		iid = lastIID + 1
+	}
+	lastIID = iid
+	return iid
+}
+
+// isDeploymentKey returns true if the key is stored in the deployments index
+func isDeploymentKey(k string) bool {
+	return strings.HasPrefix(k, consulutil.DeploymentKVPrefix) || strings.HasPrefix(k, consulutil.CommonsTypesKVPrefix)
+}
+
+// keyPaths returns the key and all its parents
+func keyPaths(k string) []string {
+	segments := strings.Split(k, "/")
+	paths := make([]string, 0, len(segments))
+	for i := range segments {
+		paths = append(paths, strings.Join(segments[:i+1], "/"))
+	}
+	return paths
+}
+
+// documentID returns the ID of the document storing a key, keys may be too long to be used as IDs
+func documentID(k string) string {
+	sum := sha256.Sum256([]byte(k))
+	return hex.EncodeToString(sum[:])
+}
+
+func tombstoneID(k string) string {
+	return documentID("tombstone:" + k)
+}
+
+// escapeRegexp escapes reserved characters of the ES regular expressions syntax
+func escapeRegexp(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func newDeploymentDocument(k string, value string, tombstone bool) deploymentDocument {
+	iid := nextIID()
+	return deploymentDocument{
+		Key:       k,
+		Paths:     keyPaths(k),
+		Value:     value,
+		Tombstone: tombstone,
+		IID:       iid,
+		IIDStr:    strconv.FormatInt(iid, 10),
+	}
+}
+
+// encodeValue marshals a value and encrypts it if the store uses encryption
+func (s *elasticStore) encodeValue(v interface{}) (string, error) {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal value %+v due to error:%+v", v, err)
+	}
+	if s.keyring == nil {
+		return string(data), nil
+	}
+	encrypted, err := s.keyring.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// decodeValue returns the marshaled value stored in a document
+func (s *elasticStore) decodeValue(value string) ([]byte, error) {
+	if s.keyring == nil {
+		return []byte(value), nil
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode encrypted value")
+	}
+	return s.keyring.Decrypt(encrypted)
+}
+
+func (s *elasticStore) deploymentsIndex() string {
+	return getIndexName(s.cfg, deploymentsStoreType)
+}
+
+// indexDeploymentDocument indexes a document, if waitForRefresh is true the request returns once the document is searchable
+func (s *elasticStore) indexDeploymentDocument(ctx context.Context, id string, doc deploymentDocument, seqNo, primaryTerm *int, waitForRefresh bool) (*esapi.Response, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal document for key %q", doc.Key)
+	}
+	req := esapi.IndexRequest{
+		Index:         s.deploymentsIndex(),
+		DocumentType:  "_doc",
+		DocumentID:    id,
+		Body:          bytes.NewReader(body),
+		IfSeqNo:       seqNo,
+		IfPrimaryTerm: primaryTerm,
+	}
+	if waitForRefresh {
+		req.Refresh = "wait_for"
+	}
+	return req.Do(ctx, s.esClient)
+}
+
+func (s *elasticStore) setDeploymentValue(ctx context.Context, k string, v interface{}) error {
+	value, err := s.encodeValue(v)
+	if err != nil {
+		return err
+	}
+	indexName := s.deploymentsIndex()
+	// Keys and List use searches, they should see the new value once it is set
+	res, err := s.indexDeploymentDocument(ctx, documentID(k), newDeploymentDocument(k, value, false), nil, nil, true)
+	defer closeResponseBody("IndexRequest:"+indexName, res)
+	return handleESResponseError(res, "IndexRequest:"+indexName, k, err)
+}
+
+// setDeploymentValues indexes values using a single bulk request
+func (s *elasticStore) setDeploymentValues(ctx context.Context, keyValues []store.KeyValueIn) error {
+	if len(keyValues) == 0 {
+		return nil
+	}
+	indexName := s.deploymentsIndex()
+	var body bytes.Buffer
+	for _, kv := range keyValues {
+		if err := utils.CheckKeyAndValue(kv.Key, kv.Value); err != nil {
+			return err
+		}
+		value, err := s.encodeValue(kv.Value)
+		if err != nil {
+			return err
+		}
+		doc, err := json.Marshal(newDeploymentDocument(kv.Key, value, false))
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal document for key %q", kv.Key)
+		}
+		body.WriteString(`{"index":{"_index":"` + indexName + `","_type":"_doc","_id":"` + documentID(kv.Key) + `"}}`)
+		body.WriteString("\n")
+		body.Write(doc)
+		body.WriteString("\n")
+	}
+	req := esapi.BulkRequest{
+		Body:    &body,
+		Refresh: "wait_for",
+	}
+	res, err := req.Do(ctx, s.esClient)
+	defer closeResponseBody("BulkRequest:"+indexName, res)
+	if err = handleESResponseError(res, "BulkRequest:"+indexName, "", err); err != nil {
+		return err
+	}
+	var rsp struct {
+		Errors bool `json:"errors"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&rsp); err != nil {
+		return errors.Wrapf(err, "failed to decode ES response of BulkRequest on index %s", indexName)
+	}
+	if rsp.Errors {
+		return errors.Errorf("The bulk request on index %s succeeded, but the response contains errors", indexName)
+	}
+	return nil
+}
+
+// getDeploymentDocument retrieves the document of a key using a realtime get request
+func (s *elasticStore) getDeploymentDocument(ctx context.Context, k string) (*deploymentDocument, error) {
+	indexName := s.deploymentsIndex()
+	req := esapi.GetRequest{
+		Index:        indexName,
+		DocumentType: "_doc",
+		DocumentID:   documentID(k),
+		Realtime:     &ptrue,
+	}
+	res, err := req.Do(ctx, s.esClient)
+	defer closeResponseBody("GetRequest:"+indexName, res)
+	if err == nil && res.StatusCode == 404 {
+		return nil, nil
+	}
+	if err = handleESResponseError(res, "GetRequest:"+indexName, k, err); err != nil {
+		return nil, err
+	}
+	var r struct {
+		Found  bool               `json:"found"`
+		Source deploymentDocument `json:"_source"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode ES response of GetRequest on index %s", indexName)
+	}
+	if !r.Found {
+		return nil, nil
+	}
+	return &r.Source, nil
+}
+
+func (s *elasticStore) getDeploymentValue(k string, v interface{}) (bool, error) {
+	doc, err := s.getDeploymentDocument(context.Background(), k)
+	if err != nil || doc == nil {
+		return false, err
+	}
+	data, err := s.decodeValue(doc.Value)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to decrypt stored value of key %q", k)
+	}
+	return true, errors.Wrapf(s.codec.Unmarshal(data, v), "failed to unmarshal data:%q", string(data))
+}
+
+// pathsQuery returns a query matching documents stored under the given key
+func pathsQuery(k string, withTombstones bool, conditions ...interface{}) map[string]interface{} {
+	filter := append([]interface{}{map[string]interface{}{"term": map[string]interface{}{"paths": strings.TrimSuffix(k, "/")}}}, conditions...)
+	boolQuery := map[string]interface{}{"filter": filter}
+	if !withTombstones {
+		boolQuery["must_not"] = []interface{}{map[string]interface{}{"term": map[string]interface{}{"tombstone": true}}}
+	}
+	return map[string]interface{}{"bool": boolQuery}
+}
+
+// searchDeployments runs a search request on the deployments index and decodes its response into r
+func (s *elasticStore) searchDeployments(ctx context.Context, body map[string]interface{}, r interface{}) error {
+	indexName := s.deploymentsIndex()
+	query, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal ES query")
+	}
+	res, err := s.esClient.Search(
+		s.esClient.Search.WithContext(ctx),
+		s.esClient.Search.WithIndex(indexName),
+		s.esClient.Search.WithBody(bytes.NewReader(query)),
+		s.esClient.Search.WithSeqNoPrimaryTerm(true),
+	)
+	defer closeResponseBody("Search:"+indexName, res)
+	if err = handleESResponseError(res, "Search:"+indexName, string(query), err); err != nil {
+		return err
+	}
+	return errors.Wrapf(json.NewDecoder(res.Body).Decode(r), "failed to decode ES response of Search on index %s", indexName)
+}
+
+// deploymentKeys returns the keys directly stored under the given key using a terms aggregation on documents paths.
+//
+// An error is returned if there are more than maxChildKeys keys, rather than an incomplete result.
+func (s *elasticStore) deploymentKeys(k string) ([]string, error) {
+	k = strings.TrimSuffix(k, "/")
+	body := map[string]interface{}{
+		"size":  0,
+		"query": pathsQuery(k, false),
+		"aggs": map[string]interface{}{
+			"keys": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field":   "paths",
+					"include": escapeRegexp(k) + "/[^/]+",
+					"size":    maxChildKeys,
+					"order":   map[string]interface{}{"_key": "asc"},
+				},
+			},
+		},
+	}
+	var r struct {
+		Aggregations struct {
+			Keys struct {
+				SumOtherDocCount int `json:"sum_other_doc_count"`
+				Buckets          []struct {
+					Key string `json:"key"`
+				} `json:"buckets"`
+			} `json:"keys"`
+		} `json:"aggregations"`
+	}
+	if err := s.searchDeployments(context.Background(), body, &r); err != nil {
+		return nil, err
+	}
+	if r.Aggregations.Keys.SumOtherDocCount > 0 {
+		return nil, errors.Errorf("failed to list keys of %q: there are more than %d keys", k, maxChildKeys)
+	}
+	keys := make([]string, 0, len(r.Aggregations.Keys.Buckets))
+	for _, bucket := range r.Aggregations.Keys.Buckets {
+		keys = append(keys, bucket.Key)
+	}
+	return keys, nil
+}
+
+// deleteDeploymentValues removes the document of a key and, if recursive, documents stored under this key.
+// A tombstone is then indexed so that the deletion is taken into account by GetLastModifyIndex.
+func (s *elasticStore) deleteDeploymentValues(ctx context.Context, k string, recursive bool) error {
+	k = strings.TrimSuffix(k, "/")
+	indexName := s.deploymentsIndex()
+	if recursive {
+		query, err := json.Marshal(map[string]interface{}{"query": pathsQuery(k, false)})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal ES query")
+		}
+		if _, err = s.deleteByQuery(ctx, indexName, string(query)); err != nil {
+			return err
+		}
+	} else {
+		req := esapi.DeleteRequest{
+			Index:        indexName,
+			DocumentType: "_doc",
+			DocumentID:   documentID(k),
+			Refresh:      "wait_for",
+		}
+		res, err := req.Do(ctx, s.esClient)
+		defer closeResponseBody("DeleteRequest:"+indexName, res)
+		if err != nil || res.StatusCode != 404 {
+			if err = handleESResponseError(res, "DeleteRequest:"+indexName, k, err); err != nil {
+				return err
+			}
+		}
+	}
+	// Tombstones are only used to compute last modify indexes which are polled, there is no need to wait for them
+	res, err := s.indexDeploymentDocument(ctx, tombstoneID(k), newDeploymentDocument(k, "", true), nil, nil, false)
+	defer closeResponseBody("IndexRequest:"+indexName, res)
+	return handleESResponseError(res, "IndexRequest:"+indexName, k, err)
+}
+
+// deploymentLastModifyIndex returns the index of the last modified document stored under the given key.
+// If there is no such document, the last index of the whole deployments index is returned.
+func (s *elasticStore) deploymentLastModifyIndex(ctx context.Context, k string) (uint64, error) {
+	queries := []map[string]interface{}{pathsQuery(k, true), {"match_all": map[string]interface{}{}}}
+	for _, query := range queries {
+		body := map[string]interface{}{
+			"size":    1,
+			"query":   query,
+			"sort":    []interface{}{map[string]interface{}{"iid": "desc"}},
+			"_source": []string{"iidStr"},
+		}
+		var r struct {
+			Hits struct {
+				Hits []struct {
+					Source deploymentDocument `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := s.searchDeployments(ctx, body, &r); err != nil {
+			return 0, err
+		}
+		if len(r.Hits.Hits) > 0 {
+			return parseInt64StringToUint64(r.Hits.Hits[0].Source.IIDStr)
+		}
+	}
+	return 0, nil
+}
+
+type deploymentHit struct {
+	ID          string             `json:"_id"`
+	SeqNo       int                `json:"_seq_no"`
+	PrimaryTerm int                `json:"_primary_term"`
+	Source      deploymentDocument `json:"_source"`
+	Sort        []interface{}      `json:"sort"`
+}
+
+// walkDeploymentDocuments calls fn for each document stored under the given key, with an index greater than waitIndex,
+// sorted by index. Documents are retrieved by pages using search_after requests.
+func (s *elasticStore) walkDeploymentDocuments(ctx context.Context, k string, waitIndex uint64, fn func(hit deploymentHit) error) error {
+	var searchAfter []interface{}
+	for {
+		body := map[string]interface{}{
+			"size":  listPageSize,
+			"query": pathsQuery(k, false, iidRangeCondition("gt", int64(waitIndex))),
+			"sort":  []interface{}{map[string]interface{}{"iid": "asc"}, map[string]interface{}{"key": "asc"}},
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+		var r struct {
+			Hits struct {
+				Hits []deploymentHit `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := s.searchDeployments(ctx, body, &r); err != nil {
+			return err
+		}
+		for _, hit := range r.Hits.Hits {
+			if err := fn(hit); err != nil {
+				return err
+			}
+		}
+		if len(r.Hits.Hits) < listPageSize {
+			return nil
+		}
+		searchAfter = r.Hits.Hits[len(r.Hits.Hits)-1].Sort
+	}
+}
+
+// listDeploymentValues simulates a blocking query by periodically checking the last modify index of the given key
+func (s *elasticStore) listDeploymentValues(ctx context.Context, k string, waitIndex uint64, timeout time.Duration) ([]store.KeyValueOut, uint64, error) {
+	lastIndex, err := s.deploymentLastModifyIndex(ctx, k)
+	if err != nil {
+		return nil, 0, err
+	}
+	period := s.cfg.esQueryPeriod
+	if period <= 0 || period > maxDeploymentsPollPeriod {
+		period = maxDeploymentsPollPeriod
+	}
+	end := time.Now().Add(timeout)
+	for waitIndex > 0 && lastIndex <= waitIndex && time.Now().Before(end) {
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return nil, lastIndex, nil
+		}
+		lastIndex, err = s.deploymentLastModifyIndex(ctx, k)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	values := make([]store.KeyValueOut, 0)
+	err = s.walkDeploymentDocuments(ctx, k, waitIndex, func(hit deploymentHit) error {
+		if !strings.HasPrefix(hit.Source.Key, k) {
+			return nil
+		}
+		data, err := s.decodeValue(hit.Source.Value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt stored value of key %q", hit.Source.Key)
+		}
+		var value map[string]interface{}
+		if err := s.codec.Unmarshal(data, &value); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal stored value: %q", string(data))
+		}
+		index, err := parseInt64StringToUint64(hit.Source.IIDStr)
+		if err != nil {
+			return err
+		}
+		if index > lastIndex {
+			lastIndex = index
+		}
+		values = append(values, store.KeyValueOut{
+			Key:             hit.Source.Key,
+			LastModifyIndex: index,
+			Value:           value,
+			RawValue:        data,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return values, lastIndex, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+func TestDeploymentDocumentPaths(t *testing.T) {
+	require.True(t, isDeploymentKey("_yorc/deployments/dep/topology"))
+	require.True(t, isDeploymentKey("_yorc/commons_types/tosca-normative-types"))
+	require.False(t, isDeploymentKey("_yorc/logs/dep/2020-01-01T10:00:00Z"))
+
+	require.Equal(t, []string{"_yorc", "_yorc/deployments", "_yorc/deployments/dep"}, keyPaths("_yorc/deployments/dep"))
+	require.NotEqual(t, documentID("_yorc/deployments/dep"), tombstoneID("_yorc/deployments/dep"))
+
+	// Escaped keys only match themselves, the syntax of ES regular expressions is a subset of Go's one here
+	k := "_yorc/deployments/my.dep-1/topology/nodes/a+b(c)"
+	re := regexp.MustCompile("^" + escapeRegexp(k) + "/[^/]+$")
+	require.True(t, re.MatchString(k+"/child"))
+	require.False(t, re.MatchString("_yorc/deployments/myXdep-1/topology/nodes/a+b(c)/child"))
+	require.False(t, re.MatchString(k+"/child/grandchild"))
+}
+
+func TestLogSearchResponse(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	interval := 5 * time.Minute
+	query := buildLogSearchQuery(store.LogSearchQuery{DeploymentID: "dep", Contains: "ansible", NodePattern: "Comp.*", Interval: interval})
+	require.Equal(t, store.DefaultLogSearchSize, query["size"])
+	require.Contains(t, query, "aggs")
+
+	var r logSearchResponse
+	err := json.Unmarshal([]byte(`{
+		"hits": {"total": 12, "hits": [{"_source": {"content": "ansible", "iid": "1577872800000000000", "iidStr": "1577872800000000000"}}]},
+		"aggregations": {"histogram": {"buckets": [{"key": 1.5778728E18, "doc_count": 10}, {"key": 1.5778731000000001E18, "doc_count": 2}]}}
+	}`), &r)
+	require.NoError(t, err)
+	result, err := r.toResult(interval)
+	require.NoError(t, err)
+	require.Equal(t, 12, result.Total)
+	require.Len(t, result.Logs, 1)
+	require.JSONEq(t, `{"content": "ansible"}`, string(result.Logs[0]))
+	require.Equal(t, []store.HistogramBucket{{Start: start, Count: 10}, {Start: start.Add(interval), Count: 2}}, result.Histogram)
+}
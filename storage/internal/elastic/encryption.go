@@ -0,0 +1,283 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/elastic/go-elasticsearch/v6/esapi"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+const (
+	// encryptedValueField is the field of encrypted documents holding the whole document encrypted and base64 encoded
+	encryptedValueField = "encryptedValue"
+	// keyIDField is the field of encrypted documents holding the ID of the key used to encrypt them
+	keyIDField = "keyId"
+)
+
+// clearFields are the fields of logs and events documents kept in clear when documents are encrypted,
+// as they are needed to query documents
+var clearFields = []string{"deploymentId", "level", "iid", "iidStr"}
+
+// reencryptPageSize is the number of documents retrieved by each search request of ReencryptValues
+const reencryptPageSize = 1000
+
+// Keyring returns the keyring used to encrypt documents or nil if the store doesn't use encryption
+func (s *elasticStore) Keyring() *encryption.Keyring {
+	return s.keyring
+}
+
+// encryptDocument encrypts a log or event document if a keyring is given.
+//
+// The whole document is encrypted into the encryptedValue field, only the fields needed to query documents are kept in clear.
+func encryptDocument(keyring *encryption.Keyring, document []byte) ([]byte, error) {
+	if keyring == nil {
+		return document, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(document, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to decode document to encrypt")
+	}
+	encrypted, err := keyring.Encrypt(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt document")
+	}
+	keyID, _ := encryption.KeyID(encrypted)
+	encryptedDocument := make(map[string]interface{}, len(clearFields)+2)
+	for _, field := range clearFields {
+		if value, ok := fields[field]; ok {
+			encryptedDocument[field] = value
+		}
+	}
+	encryptedDocument[encryptedValueField] = base64.StdEncoding.EncodeToString(encrypted)
+	encryptedDocument[keyIDField] = keyID
+	return json.Marshal(encryptedDocument)
+}
+
+// decryptValues replaces the values of encrypted documents by their clear content
+func (s *elasticStore) decryptValues(values []store.KeyValueOut) error {
+	for i := range values {
+		encoded, ok := values[i].Value[encryptedValueField].(string)
+		if !ok {
+			continue
+		}
+		if s.keyring == nil {
+			return errors.Errorf("document %q is encrypted but elastic store with ID:%q doesn't use encryption", values[i].Key, s.id)
+		}
+		encrypted, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode encrypted document %q", values[i].Key)
+		}
+		document, err := s.keyring.Decrypt(encrypted)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt document %q", values[i].Key)
+		}
+		value := make(map[string]interface{})
+		if err = json.Unmarshal(document, &value); err != nil {
+			return errors.Wrapf(err, "failed to decode decrypted document %q", values[i].Key)
+		}
+		values[i].Value = value
+		values[i].RawValue = document
+	}
+	return nil
+}
+
+// ReencryptValues re-encrypts logs, events and deployments documents that are not encrypted with the primary key of the keyring.
+//
+// Documents are updated only if they were not modified in the meantime.
+func (s *elasticStore) ReencryptValues(ctx context.Context) (int, error) {
+	if s.keyring == nil {
+		return 0, nil
+	}
+	var count int
+	for _, storeType := range []string{"logs", "events"} {
+		n, err := s.reencryptIndex(ctx, getIndexName(s.cfg, storeType))
+		count += n
+		if err != nil {
+			return count, errors.Wrapf(err, "failed to re-encrypt values of elastic store with ID:%q", s.id)
+		}
+	}
+	if !s.managesDeployments {
+		return count, nil
+	}
+	for _, prefix := range []string{consulutil.CommonsTypesKVPrefix, consulutil.DeploymentKVPrefix} {
+		err := s.walkDeploymentDocuments(ctx, prefix, 0, func(hit deploymentHit) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			reencrypted, err := s.reencryptDeploymentDocument(ctx, hit)
+			if err != nil {
+				return errors.Wrapf(err, "failed to re-encrypt values of elastic store with ID:%q", s.id)
+			}
+			if reencrypted {
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// encryptedHit is a document returned by searches of documents to re-encrypt
+type encryptedHit struct {
+	ID          string                 `json:"_id"`
+	SeqNo       int                    `json:"_seq_no"`
+	PrimaryTerm int                    `json:"_primary_term"`
+	Source      map[string]interface{} `json:"_source"`
+	Sort        []interface{}          `json:"sort"`
+}
+
+// reencryptIndex re-encrypts the documents of an index encrypted with another key than the primary key.
+// Documents are retrieved by pages sorted by index using search_after requests.
+func (s *elasticStore) reencryptIndex(ctx context.Context, indexName string) (int, error) {
+	var count int
+	var searchAfter []interface{}
+	for {
+		body := map[string]interface{}{
+			"size": reencryptPageSize,
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter":   []interface{}{map[string]interface{}{"exists": map[string]interface{}{"field": keyIDField}}},
+					"must_not": []interface{}{map[string]interface{}{"term": map[string]interface{}{keyIDField: s.keyring.PrimaryKeyID()}}},
+				},
+			},
+			"sort": []interface{}{map[string]interface{}{"iid": "asc"}, map[string]interface{}{"_id": "asc"}},
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+		query, err := json.Marshal(body)
+		if err != nil {
+			return count, errors.Wrap(err, "failed to marshal ES query")
+		}
+		res, err := s.esClient.Search(
+			s.esClient.Search.WithContext(ctx),
+			s.esClient.Search.WithIndex(indexName),
+			s.esClient.Search.WithBody(bytes.NewReader(query)),
+			s.esClient.Search.WithSeqNoPrimaryTerm(true),
+		)
+		err = handleESResponseError(res, "Search:"+indexName, string(query), err)
+		var r struct {
+			Hits struct {
+				Hits []encryptedHit `json:"hits"`
+			} `json:"hits"`
+		}
+		if err == nil {
+			err = errors.Wrapf(json.NewDecoder(res.Body).Decode(&r), "failed to decode ES response of Search on index %s", indexName)
+		}
+		closeResponseBody("Search:"+indexName, res)
+		if err != nil {
+			return count, err
+		}
+
+		for _, hit := range r.Hits.Hits {
+			if err = ctx.Err(); err != nil {
+				return count, err
+			}
+			reencrypted, err := s.reencryptDocument(ctx, indexName, hit)
+			if err != nil {
+				return count, err
+			}
+			if reencrypted {
+				count++
+			}
+		}
+		if len(r.Hits.Hits) < reencryptPageSize {
+			return count, nil
+		}
+		searchAfter = r.Hits.Hits[len(r.Hits.Hits)-1].Sort
+	}
+}
+
+// reencryptDocument re-encrypts a document and indexes it if it was not modified since it was read.
+func (s *elasticStore) reencryptDocument(ctx context.Context, indexName string, hit encryptedHit) (bool, error) {
+	encoded, _ := hit.Source[encryptedValueField].(string)
+	encrypted, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode encrypted document %q", hit.ID)
+	}
+	if !s.keyring.NeedsReencryption(encrypted) {
+		return false, nil
+	}
+	reencrypted, err := s.keyring.Reencrypt(encrypted)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to re-encrypt document %q", hit.ID)
+	}
+	keyID, _ := encryption.KeyID(reencrypted)
+	hit.Source[encryptedValueField] = base64.StdEncoding.EncodeToString(reencrypted)
+	hit.Source[keyIDField] = keyID
+	body, err := json.Marshal(hit.Source)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to marshal document %q", hit.ID)
+	}
+	req := esapi.IndexRequest{
+		Index:         indexName,
+		DocumentType:  "_doc",
+		DocumentID:    hit.ID,
+		Body:          bytes.NewReader(body),
+		IfSeqNo:       &hit.SeqNo,
+		IfPrimaryTerm: &hit.PrimaryTerm,
+	}
+	res, err := req.Do(ctx, s.esClient)
+	defer closeResponseBody("IndexRequest:"+indexName, res)
+	if err == nil && res.StatusCode == 409 {
+		// The document has been updated meanwhile, so it is encrypted with the current primary key
+		return false, nil
+	}
+	if err = handleESResponseError(res, "IndexRequest:"+indexName, hit.ID, err); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reencryptDeploymentDocument re-encrypts the value of a deployments document and indexes it if it was not modified since it was read.
+// The index of the document is kept as its value doesn't change.
+func (s *elasticStore) reencryptDeploymentDocument(ctx context.Context, hit deploymentHit) (bool, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(hit.Source.Value)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to decode encrypted value of key %q", hit.Source.Key)
+	}
+	if !s.keyring.NeedsReencryption(encrypted) {
+		return false, nil
+	}
+	reencrypted, err := s.keyring.Reencrypt(encrypted)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to re-encrypt value of key %q", hit.Source.Key)
+	}
+	doc := hit.Source
+	doc.Value = base64.StdEncoding.EncodeToString(reencrypted)
+	indexName := s.deploymentsIndex()
+	res, err := s.indexDeploymentDocument(ctx, hit.ID, doc, &hit.SeqNo, &hit.PrimaryTerm, false)
+	defer closeResponseBody("IndexRequest:"+indexName, res)
+	if err == nil && res.StatusCode == 409 {
+		// The document has been updated meanwhile, so it is encrypted with the current primary key
+		return false, nil
+	}
+	if err = handleESResponseError(res, "IndexRequest:"+indexName, hit.Source.Key, err); err != nil {
+		return false, err
+	}
+	return true, nil
+}
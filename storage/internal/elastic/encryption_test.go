@@ -0,0 +1,60 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+func TestEncryptDocument(t *testing.T) {
+	keyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{"keys": map[string]interface{}{"k1": "myverystrongpasswordo32bitlength"}})
+	require.NoError(t, err)
+
+	_, document, err := buildElasticDocument("_yorc/logs/MyApp/2020-06-07T21:03:17.812178429Z",
+		json.RawMessage(`{"deploymentId":"MyApp","level":"INFO","content":"my secret"}`))
+	require.NoError(t, err)
+
+	clear, err := encryptDocument(nil, document)
+	require.NoError(t, err)
+	require.Equal(t, document, clear)
+
+	encrypted, err := encryptDocument(keyring, document)
+	require.NoError(t, err)
+	require.NotContains(t, string(encrypted), "my secret")
+	source := make(map[string]interface{})
+	require.NoError(t, json.Unmarshal(encrypted, &source))
+	require.Equal(t, "MyApp", source["deploymentId"])
+	require.Equal(t, "INFO", source["level"])
+	require.Equal(t, "1591563797812178429", source["iidStr"])
+	require.Equal(t, "k1", source[keyIDField])
+	require.NotContains(t, source, "content")
+
+	s := &elasticStore{id: "testStoreID", keyring: keyring}
+	values := []store.KeyValueOut{{Key: "id1", Value: source}, {Key: "id2", Value: map[string]interface{}{"content": "clear"}}}
+	require.NoError(t, s.decryptValues(values))
+	require.Equal(t, "my secret", values[0].Value["content"])
+	require.JSONEq(t, string(document), string(values[0].RawValue))
+	require.Equal(t, "clear", values[1].Value["content"])
+
+	s.keyring = nil
+	require.Error(t, s.decryptValues([]store.KeyValueOut{{Key: "id1", Value: source}}))
+}
@@ -122,8 +122,16 @@ func initStorageIndex(c *elasticsearch6.Client, elasticStoreConfig elasticStoreC
 	}
 
 	if res.StatusCode == 200 {
-		log.Printf("Indice %s was found, nothing to do !", indexName)
-		return nil
+		log.Printf("Indice %s was found, checking that its mapping is up to date", indexName)
+		// Adding a field to a mapping is allowed, documents indexed before won't be searchable on this field
+		req := esapi.IndicesPutMappingRequest{
+			Index:        []string{indexName},
+			DocumentType: "_doc",
+			Body:         strings.NewReader(addedFieldsMappingText),
+		}
+		res, err := req.Do(context.Background(), c)
+		defer closeResponseBody("IndicesPutMappingRequest:"+indexName, res)
+		return handleESResponseError(res, "IndicesPutMappingRequest:"+indexName, addedFieldsMappingText, err)
 	} else if res.StatusCode == 404 {
 		log.Printf("Indice %s was not found, let's create it !", indexName)
 
@@ -145,6 +153,39 @@ func initStorageIndex(c *elasticsearch6.Client, elasticStoreConfig elasticStoreC
 	return nil
 }
 
+// Init ES index for deployments data: create it if not found.
+func initDeploymentsIndex(c *elasticsearch6.Client, elasticStoreConfig elasticStoreConf) error {
+	indexName := getIndexName(elasticStoreConfig, deploymentsStoreType)
+	log.Printf("Checking if index <%s> already exists", indexName)
+
+	req := esapi.IndicesExistsRequest{
+		Index:           []string{indexName},
+		ExpandWildcards: "none",
+		AllowNoIndices:  &pfalse,
+	}
+	res, err := req.Do(context.Background(), c)
+	defer closeResponseBody("IndicesExistsRequest:"+indexName, res)
+	if err != nil {
+		return err
+	}
+	switch res.StatusCode {
+	case 200:
+		return nil
+	case 404:
+		log.Printf("Indice %s was not found, let's create it !", indexName)
+		requestBodyData := buildInitDeploymentsIndexQuery(elasticStoreConfig)
+		req := esapi.IndicesCreateRequest{
+			Index: indexName,
+			Body:  strings.NewReader(requestBodyData),
+		}
+		res, err := req.Do(context.Background(), c)
+		defer closeResponseBody("IndicesCreateRequest:"+indexName, res)
+		return handleESResponseError(res, "IndicesCreateRequest:"+indexName, requestBodyData, err)
+	default:
+		return handleESResponseError(res, "IndicesExistsRequest:"+indexName, "", err)
+	}
+}
+
 // Perform a refresh query on ES cluster for this particular index.
 func refreshIndex(c *elasticsearch6.Client, indexName string) {
 	req := esapi.IndicesRefreshRequest{
@@ -34,13 +34,40 @@ const initStorageTemplateText = `
              "dynamic": "false",
              "properties": {
                  "deploymentId": { "type": "keyword", "index": true },
+                 "level": { "type": "keyword", "index": true },
+                 "nodeId": { "type": "keyword", "index": true },
+                 "content": { "type": "text", "index": true },
                  "iid": { "type": "long", "index": true },
-                 "iidStr": { "type": "keyword","index": false }
+                 "iidStr": { "type": "keyword","index": false },
+                 "keyId": { "type": "keyword", "index": true }
              }
          }
      }
 }`
 
+// Mapping of fields added to indexes created by previous versions
+const addedFieldsMappingText = `{
+    "properties": {
+        "level": { "type": "keyword", "index": true },
+        "nodeId": { "type": "keyword", "index": true },
+        "content": { "type": "text", "index": true },
+        "keyId": { "type": "keyword", "index": true }
+    }
+}`
+
+// Index creation request for deployments data
+const initDeploymentsTemplateText = `
+{
+     "settings": {
+        {{ if ne .InitialReplicas -1}}"number_of_replicas": {{ .InitialReplicas}},{{end}}
+        {{ if ne .InitialShards -1 }}"number_of_shards": {{ .InitialShards}},{{end}}
+        "refresh_interval": "1s"
+     },
+     "mappings": {
+         "_doc": ` + deploymentsMappingText + `
+     }
+}`
+
 // Get last Modified index
 const lastModifiedIndexTemplateText = `
 {
@@ -90,6 +117,7 @@ func init() {
 	funcMap := template.FuncMap{"conv": func(value uint64) string { return strconv.FormatUint(value, 10) }}
 
 	templates = template.Must(template.New("initStorage").Parse(initStorageTemplateText))
+	templates = template.Must(templates.New("initDeployments").Parse(initDeploymentsTemplateText))
 	templates = template.Must(templates.New("lastModifiedIndex").Parse(lastModifiedIndexTemplateText))
 
 	templates = template.Must(templates.New("rangeQuery").Funcs(funcMap).Parse(rangeQueryTemplateText))
@@ -104,6 +132,13 @@ func buildInitStorageIndexQuery(elasticStoreConfig elasticStoreConf) string {
 	return buffer.String()
 }
 
+// Return the query that is used to create the index storing deployments data.
+func buildInitDeploymentsIndexQuery(elasticStoreConfig elasticStoreConf) string {
+	var buffer bytes.Buffer
+	templates.ExecuteTemplate(&buffer, "initDeployments", elasticStoreConfig)
+	return buffer.String()
+}
+
 // This ES aggregation query is built using clusterId and eventually deploymentId.
 func buildLastModifiedIndexQuery(deploymentID string) (query string) {
 	var buffer bytes.Buffer
@@ -0,0 +1,175 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+// SearchLogs searches logs using a full-text query on their content and computes the histogram using an aggregation.
+//
+// Only documents indexed after the content and nodeId fields have been added to the index mapping can be
+// searched on these fields. Encrypted logs are decrypted and filtered within Yorc.
+func (s *elasticStore) SearchLogs(ctx context.Context, query store.LogSearchQuery) (store.LogSearchResult, error) {
+	result := store.LogSearchResult{Logs: make([]json.RawMessage, 0)}
+	if err := query.Validate(); err != nil {
+		return result, err
+	}
+	if s.keyring != nil {
+		// Content and node of encrypted logs can't be searched by ES
+		return s.searchEncryptedLogs(ctx, query)
+	}
+	indexName := getIndexName(s.cfg, "logs")
+	body, err := json.Marshal(buildLogSearchQuery(query))
+	if err != nil {
+		return result, errors.Wrap(err, "failed to marshal ES query")
+	}
+	res, err := s.esClient.Search(
+		s.esClient.Search.WithContext(ctx),
+		s.esClient.Search.WithIndex(indexName),
+		s.esClient.Search.WithBody(bytes.NewReader(body)),
+	)
+	defer closeResponseBody("Search:"+indexName, res)
+	if err = handleESResponseError(res, "Search:"+indexName, string(body), err); err != nil {
+		return result, err
+	}
+	var r logSearchResponse
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return result, errors.Wrapf(err, "failed to decode ES response of Search on index %s", indexName)
+	}
+	return r.toResult(query.Interval)
+}
+
+// buildLogSearchQuery returns the body of the ES search request matching the given query
+func buildLogSearchQuery(query store.LogSearchQuery) map[string]interface{} {
+	filter := make([]interface{}, 0)
+	if query.DeploymentID != "" {
+		filter = append(filter, map[string]interface{}{"term": map[string]interface{}{"deploymentId": query.DeploymentID}})
+	}
+	if !query.From.IsZero() || !query.To.IsZero() {
+		bounds := make(map[string]interface{})
+		if !query.From.IsZero() {
+			bounds["gte"] = query.From.UnixNano()
+		}
+		if !query.To.IsZero() {
+			bounds["lt"] = query.To.UnixNano()
+		}
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"iid": bounds}})
+	}
+	if len(query.Levels) > 0 {
+		filter = append(filter, map[string]interface{}{"terms": map[string]interface{}{"level": query.Levels}})
+	}
+	if query.NodePattern != "" {
+		// ES regular expressions are always anchored at both ends
+		filter = append(filter, map[string]interface{}{"regexp": map[string]interface{}{"nodeId": query.NodePattern}})
+	}
+	boolQuery := map[string]interface{}{"filter": filter}
+	if query.Contains != "" {
+		boolQuery["must"] = []interface{}{map[string]interface{}{"match_phrase": map[string]interface{}{"content": query.Contains}}}
+	}
+
+	body := map[string]interface{}{
+		"query": map[string]interface{}{"bool": boolQuery},
+		"sort":  []interface{}{map[string]interface{}{"iid": "asc"}},
+		"from":  query.Offset,
+		"size":  query.PageSize(),
+	}
+	if query.Interval > 0 {
+		body["aggs"] = map[string]interface{}{
+			"histogram": map[string]interface{}{
+				"histogram": map[string]interface{}{
+					"field":         "iid",
+					"interval":      int64(query.Interval),
+					"min_doc_count": 1,
+				},
+			},
+		}
+	}
+	return body
+}
+
+type logSearchResponse struct {
+	Hits struct {
+		Total int `json:"total"`
+		Hits  []struct {
+			Source map[string]interface{} `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Histogram struct {
+			Buckets []struct {
+				Key      float64 `json:"key"`
+				DocCount int     `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"histogram"`
+	} `json:"aggregations"`
+}
+
+// toResult converts the ES response into a search result, removing fields added to logs when indexing them
+func (r logSearchResponse) toResult(interval time.Duration) (store.LogSearchResult, error) {
+	result := store.LogSearchResult{Total: r.Hits.Total, Logs: make([]json.RawMessage, 0, len(r.Hits.Hits))}
+	for _, hit := range r.Hits.Hits {
+		delete(hit.Source, "iid")
+		delete(hit.Source, "iidStr")
+		raw, err := json.Marshal(hit.Source)
+		if err != nil {
+			return result, errors.Wrap(err, "failed to marshal log")
+		}
+		result.Logs = append(result.Logs, raw)
+	}
+	for _, bucket := range r.Aggregations.Histogram.Buckets {
+		// ES returns keys as floats, they are rounded to a multiple of the interval to fix the precision loss
+		start := int64(math.Round(bucket.Key/float64(interval))) * int64(interval)
+		result.Histogram = append(result.Histogram, store.HistogramBucket{Start: time.Unix(0, start).UTC(), Count: bucket.DocCount})
+	}
+	return result, nil
+}
+
+// searchEncryptedLogs lists and decrypts the logs of the query deployment and filters them within Yorc.
+//
+// Logs are listed by pages from the query start date, at most store.MaxSearchedLogs logs are read.
+func (s *elasticStore) searchEncryptedLogs(ctx context.Context, query store.LogSearchQuery) (store.LogSearchResult, error) {
+	indexName := getIndexName(s.cfg, "logs")
+	var index uint64
+	if !query.From.IsZero() && query.From.UnixNano() > 0 {
+		index = uint64(query.From.UnixNano() - 1)
+	}
+	kvs := make([]store.KeyValueOut, 0)
+	for {
+		_, values, lastIndex, err := doQueryEs(ctx, s.esClient, s.cfg, indexName, getListQuery(query.DeploymentID, index, 0), index, maxResultWindow, "asc")
+		if err != nil {
+			return store.LogSearchResult{}, errors.Wrapf(err, "failed to list logs of index %s", indexName)
+		}
+		if err = s.decryptValues(values); err != nil {
+			return store.LogSearchResult{}, err
+		}
+		kvs = append(kvs, values...)
+		if len(kvs) > store.MaxSearchedLogs {
+			return store.LogSearchResult{}, errors.WithStack(store.TooManyLogsError{})
+		}
+		if len(values) < maxResultWindow || lastIndex <= index {
+			return store.SearchLogEntries(kvs, query)
+		}
+		index = lastIndex
+	}
+}
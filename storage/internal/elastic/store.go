@@ -13,7 +13,7 @@
 // limitations under the License.
 
 // Package elastic provides an implementation of a storage that index/get documents to/from Elasticsearch 6.x.
-// This store can only manage logs and events for the moment. It will fail if you try to use it for other store types.
+// This store manages logs, events and deployments data, each of them being stored in a dedicated index.
 package elastic
 
 import (
@@ -27,6 +27,7 @@ import (
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/log"
 	"github.com/ystia/yorc/v4/storage/encoding"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"github.com/ystia/yorc/v4/storage/store"
 	"github.com/ystia/yorc/v4/storage/utils"
 	"math"
@@ -38,17 +39,35 @@ type elasticStore struct {
 	codec    encoding.Codec
 	esClient *elasticsearch6.Client
 	cfg      elasticStoreConf
+	id       string
+	// keyring is used to encrypt documents, it is nil if the store doesn't use encryption
+	keyring            *encryption.Keyring
+	managesDeployments bool
 }
 
 // NewStore returns a new Elastic store.
-// Since the elastic store can only manage logs or events, it will panic is it's configured for anything else.
 // At init stage, we display ES cluster info and initialise indexes if they are not found.
-func NewStore(cfg config.Configuration, storeConfig config.Store) (store.Store, error) {
+// If withEncryption is true, documents are encrypted using the keys defined in the store properties.
+func NewStore(cfg config.Configuration, storeConfig config.Store, withEncryption bool) (store.Store, error) {
 
-	// Just fail if this storage is used for anything different from logs or events
+	// Just fail if this storage is used for anything different from logs, events or deployments
+	managesDeployments := false
 	for _, t := range storeConfig.Types {
-		if t != "Log" && t != "Event" {
-			return nil, errors.Errorf("Elastic store is not able to manage <%s>, just Log or Event, please change your config", t)
+		switch t {
+		case "Log", "Event":
+		case "Deployment":
+			managesDeployments = true
+		default:
+			return nil, errors.Errorf("Elastic store is not able to manage <%s>, just Log, Event or Deployment, please change your config", t)
+		}
+	}
+
+	var keyring *encryption.Keyring
+	if withEncryption {
+		var err error
+		keyring, err = encryption.NewKeyringFromProperties(storeConfig.Name, storeConfig.Properties)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -71,8 +90,21 @@ func NewStore(cfg config.Configuration, storeConfig config.Store) (store.Store,
 	if err != nil {
 		return nil, errors.Wrapf(err, "Not able to init index for eventType <%s>", "events")
 	}
+	if managesDeployments {
+		err = initDeploymentsIndex(esClient, elasticStoreConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Not able to init index for <%s>", deploymentsStoreType)
+		}
+	}
 
-	return &elasticStore{encoding.JSON, esClient, elasticStoreConfig}, nil
+	return &elasticStore{
+		codec:              encoding.JSON,
+		esClient:           esClient,
+		cfg:                elasticStoreConfig,
+		id:                 storeConfig.Name,
+		keyring:            keyring,
+		managesDeployments: managesDeployments,
+	}, nil
 }
 
 // Set index a document (log or event) into ES.
@@ -82,11 +114,18 @@ func (s *elasticStore) Set(ctx context.Context, k string, v interface{}) error {
 	if err := utils.CheckKeyAndValue(k, v); err != nil {
 		return err
 	}
+	if isDeploymentKey(k) {
+		return s.setDeploymentValue(ctx, k, v)
+	}
 
 	storeType, body, err := buildElasticDocument(k, v)
 	if err != nil {
 		return err
 	}
+	body, err = encryptDocument(s.keyring, body)
+	if err != nil {
+		return err
+	}
 
 	indexName := getIndexName(s.cfg, storeType)
 	if log.IsDebug() {
@@ -110,12 +149,27 @@ func (s *elasticStore) Set(ctx context.Context, k string, v interface{}) error {
 
 // SetCollection index collections using ES bulk requests.
 // We consider both 'max_bulk_size' and 'max_bulk_count' to define bulk requests size.
+// Deployments data are indexed using a single bulk request.
 func (s *elasticStore) SetCollection(ctx context.Context, keyValues []store.KeyValueIn) error {
+	deploymentValues := make([]store.KeyValueIn, 0)
+	otherValues := make([]store.KeyValueIn, 0, len(keyValues))
+	for _, kv := range keyValues {
+		if isDeploymentKey(kv.Key) {
+			deploymentValues = append(deploymentValues, kv)
+		} else {
+			otherValues = append(otherValues, kv)
+		}
+	}
+	if err := s.setDeploymentValues(ctx, deploymentValues); err != nil {
+		return err
+	}
+	keyValues = otherValues
+
 	totalDocumentCount := len(keyValues)
 	log.Printf("SetCollection called with an array of size %d", totalDocumentCount)
 	start := time.Now()
 
-	if keyValues == nil || totalDocumentCount == 0 {
+	if totalDocumentCount == 0 {
 		return nil
 	}
 
@@ -149,7 +203,7 @@ func (s *elasticStore) SetCollection(ctx context.Context, keyValues []store.KeyV
 				// We have reached the end of []keyValues OR the max items allowed in a single bulk request (max_bulk_count)
 				break
 			}
-			added, err := eventuallyAppendValueToBulkRequest(s.cfg, &body, keyValues[kvi], maxBulkSizeInBytes)
+			added, err := eventuallyAppendValueToBulkRequest(s.cfg, s.keyring, &body, keyValues[kvi], maxBulkSizeInBytes)
 			if err != nil {
 				return err
 			} else if !added {
@@ -178,6 +232,9 @@ func (s *elasticStore) SetCollection(ctx context.Context, keyValues []store.KeyV
 // Delete removes ES documents using a deleteByRequest query.
 func (s *elasticStore) Delete(ctx context.Context, k string, recursive bool) error {
 	log.Debugf("Delete called k: %s, recursive: %t", k, recursive)
+	if isDeploymentKey(k) {
+		return s.deleteDeploymentValues(ctx, k, recursive)
+	}
 
 	// Extract index name and deploymentID by parsing the key
 	storeType, deploymentID := extractStoreTypeAndDeploymentID(k)
@@ -204,6 +261,9 @@ func (s *elasticStore) Delete(ctx context.Context, k string, recursive bool) err
 // GetLastModifyIndex return the last index which is found by querying ES using aggregation and a 0 size request.
 func (s *elasticStore) GetLastModifyIndex(k string) (lastIndex uint64, e error) {
 	log.Debugf("GetLastModifyIndex called k: %s", k)
+	if isDeploymentKey(k) {
+		return s.deploymentLastModifyIndex(context.Background(), k)
+	}
 
 	// Extract index name and deploymentID by parsing the key
 	storeType, deploymentID := extractStoreTypeAndDeploymentID(k)
@@ -276,6 +336,9 @@ func (s *elasticStore) List(ctx context.Context, k string, waitIndex uint64, tim
 	if err := utils.CheckKey(k); err != nil {
 		return nil, 0, err
 	}
+	if isDeploymentKey(k) {
+		return s.listDeploymentValues(ctx, k, waitIndex, timeout)
+	}
 
 	// Extract indice name by parsing the key
 	storeType, deploymentID := extractStoreTypeAndDeploymentID(k)
@@ -307,7 +370,7 @@ func (s *elasticStore) List(ctx context.Context, k string, waitIndex uint64, tim
 		case <-time.After(s.cfg.esQueryPeriod):
 			continue
 		case <-ctx.Done():
-			return values, lastIndex, nil
+			return values, lastIndex, s.decryptValues(values)
 		}
 	}
 	if hits > 0 {
@@ -332,26 +395,39 @@ func (s *elasticStore) List(ctx context.Context, k string, waitIndex uint64, tim
 	}
 	log.Debugf("List called result k: %s, waitIndex: %d, timeout: %v, LastIndex: %d, len(values): %d",
 		k, waitIndex, timeout, lastIndex, len(values))
+	if err == nil {
+		err = s.decryptValues(values)
+	}
 	return values, lastIndex, err
 }
 
-// Get is not used for logs nor events: fails in FATAL.
+// Get retrieves deployments data, it is not used for logs nor events.
 func (s *elasticStore) Get(k string, v interface{}) (bool, error) {
 	if err := utils.CheckKeyAndValue(k, v); err != nil {
 		return false, err
 	}
-	return false, errors.Errorf("Function Get(string, interface{}) not yet implemented for Elastic store !")
+	if !isDeploymentKey(k) {
+		return false, errors.Errorf("Function Get(string, interface{}) not implemented for logs and events in Elastic store !")
+	}
+	return s.getDeploymentValue(k, v)
 }
 
-// Exist is not used for logs nor events: fails in FATAL.
+// Exist checks if deployments data exist, it is not used for logs nor events.
 func (s *elasticStore) Exist(k string) (bool, error) {
 	if err := utils.CheckKey(k); err != nil {
 		return false, err
 	}
-	return false, errors.Errorf("Function Exist(string) not yet implemented for Elastic store !")
+	if !isDeploymentKey(k) {
+		return false, errors.Errorf("Function Exist(string) not implemented for logs and events in Elastic store !")
+	}
+	doc, err := s.getDeploymentDocument(context.Background(), k)
+	return doc != nil, err
 }
 
-// Keys is not used for logs nor events: fails in FATAL.
+// Keys returns the keys of deployments data stored under the given key, it is not used for logs nor events.
 func (s *elasticStore) Keys(k string) ([]string, error) {
-	return nil, errors.Errorf("Function Keys(string) not yet implemented for Elastic store !")
+	if !isDeploymentKey(k) {
+		return nil, errors.Errorf("Function Keys(string) not implemented for logs and events in Elastic store !")
+	}
+	return s.deploymentKeys(k)
 }
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"github.com/pkg/errors"
 	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"github.com/ystia/yorc/v4/storage/store"
 	"github.com/ystia/yorc/v4/storage/utils"
 	"regexp"
@@ -133,8 +134,9 @@ func buildElasticDocument(k string, rawMessage interface{}) (string, []byte, err
 // - it's not valid (key or value nil)
 // - the size of the resulting bulk operation exceed the maximum authorized for a bulk request
 // The value is not added if it's size + the current body size exceed the maximum authorized for a bulk request.
+// The document is encrypted if a keyring is given.
 // Return a bool indicating if the value has been added to the bulk request body.
-func eventuallyAppendValueToBulkRequest(c elasticStoreConf, body *[]byte, kv store.KeyValueIn, maxBulkSizeInBytes int) (bool, error) {
+func eventuallyAppendValueToBulkRequest(c elasticStoreConf, keyring *encryption.Keyring, body *[]byte, kv store.KeyValueIn, maxBulkSizeInBytes int) (bool, error) {
 	if err := utils.CheckKeyAndValue(kv.Key, kv.Value); err != nil {
 		return false, err
 	}
@@ -143,6 +145,10 @@ func eventuallyAppendValueToBulkRequest(c elasticStoreConf, body *[]byte, kv sto
 	if err != nil {
 		return false, err
 	}
+	document, err = encryptDocument(keyring, document)
+	if err != nil {
+		return false, err
+	}
 	log.Debugf("About to add a document of size %d bytes to bulk request", len(document))
 
 	// The bulk action
@@ -16,7 +16,6 @@ package file
 
 import (
 	"context"
-	"encoding/hex"
 	"github.com/ystia/yorc/v4/config"
 	"io/ioutil"
 	"os"
@@ -56,7 +55,7 @@ type fileStore struct {
 	withCache         bool
 	cache             *ristretto.Cache
 	withEncryption    bool
-	encryptor         *encryption.Encryptor
+	keyring           *encryption.Keyring
 	concurrencyLimit  int
 }
 
@@ -88,9 +87,9 @@ func NewStore(cfg config.Configuration, storeID string, properties config.Dynami
 		}
 	}
 
-	// Instantiate encryptor if necessary
+	// Instantiate keyring if necessary
 	if withEncryption {
-		err = fs.buildEncryptor()
+		fs.keyring, err = encryption.NewKeyringFromProperties(storeID, properties)
 		if err != nil {
 			return nil, err
 		}
@@ -126,20 +125,6 @@ func (s *fileStore) initCache() error {
 	return err
 }
 
-func (s *fileStore) buildEncryptor() error {
-	var err error
-	// Check a 32-bits passphrase key is provided
-	secretKey := s.properties.GetString("passphrase")
-	if secretKey == "" {
-		return errors.Errorf("Missing passphrase for file store encryption with ID:%q", s.id)
-	}
-	if len(secretKey) != 32 {
-		return errors.Errorf("The provided passphrase for file store encryption with ID:%q must be 32-bits length", s.id)
-	}
-	s.encryptor, err = encryption.NewEncryptor(hex.EncodeToString([]byte(secretKey)))
-	return err
-}
-
 // prepareFileLock returns an existing file lock or creates a new one
 func (s *fileStore) prepareFileLock(filePath string) *sync.RWMutex {
 	s.locksLock.Lock()
@@ -199,7 +184,7 @@ func (s *fileStore) Set(ctx context.Context, k string, v interface{}) error {
 
 	// encrypt if necessary
 	if s.withEncryption {
-		data, err = s.encryptor.Encrypt(data)
+		data, err = s.keyring.Encrypt(data)
 		if err != nil {
 			return err
 		}
@@ -267,7 +252,7 @@ func (s *fileStore) getValueFromFile(filePath string, v interface{}) (bool, []by
 
 	// decrypt if necessary
 	if s.withEncryption {
-		data, err = s.encryptor.Decrypt(data)
+		data, err = s.keyring.Decrypt(data)
 		if err != nil {
 			return false, nil, err
 		}
@@ -565,3 +550,57 @@ func (s *fileStore) addKeyValueToList(info os.FileInfo, pathFile string, waitInd
 	}
 	return nil, nil
 }
+
+func (s *fileStore) Keyring() *encryption.Keyring {
+	return s.keyring
+}
+
+func (s *fileStore) ReencryptValues(ctx context.Context) (int, error) {
+	if !s.withEncryption {
+		return 0, nil
+	}
+	var count int
+	err := filepath.Walk(s.directory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(filePath, "."+s.filenameExtension) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		reencrypted, err := s.reencryptFile(filePath)
+		if reencrypted {
+			count++
+		}
+		return err
+	})
+	return count, errors.Wrapf(err, "failed to re-encrypt values of file store with ID:%q", s.id)
+}
+
+// reencryptFile encrypts a file with the primary key if it was encrypted with another key
+func (s *fileStore) reencryptFile(filePath string) (bool, error) {
+	lock := s.prepareFileLock(filePath)
+	lock.Lock()
+	defer lock.Unlock()
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !s.keyring.NeedsReencryption(data) {
+		return false, nil
+	}
+	data, err = s.keyring.Reencrypt(data)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to re-encrypt file %q", filePath)
+	}
+	return true, ioutil.WriteFile(filePath, data, 0600)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"github.com/ystia/yorc/v4/storage/store"
 )
 
@@ -42,6 +43,9 @@ func TestRunFileStoragePackageTests(t *testing.T) {
 		t.Run("testFileStoreWithEncryptionWithoutSecretKeyProvided", func(t *testing.T) {
 			testFileStoreWithEncryptionWithoutSecretKeyProvided(t, cfg)
 		})
+		t.Run("testFileStoreKeyRotation", func(t *testing.T) {
+			testFileStoreKeyRotation(t, cfg)
+		})
 		t.Run("testFileStoreWithCache", func(t *testing.T) {
 			testFileStoreWithCache(t, cfg)
 		})
@@ -93,3 +97,23 @@ func testFileStoreTypesWithCache(t *testing.T, cfg config.Configuration) {
 	require.NoError(t, err, "failed to instantiate new store")
 	store.CommonStoreTestAllTypes(t, fileStore)
 }
+
+func testFileStoreKeyRotation(t *testing.T, cfg config.Configuration) {
+	props := config.DynamicMap{
+		"passphrase": "myverystrongpasswordo32bitlength",
+		"root_dir":   path.Join(cfg.WorkingDirectory, t.Name()),
+	}
+	fileStore, err := NewStore(cfg, "testStoreID", props, false, true)
+	require.NoError(t, err, "failed to instantiate new store")
+	newKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"passphrase":  "myverystrongpasswordo32bitlength",
+		"keys":        map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+		"primary_key": "key2",
+	})
+	require.NoError(t, err)
+	onlyNewKeyring, err := encryption.NewKeyringFromProperties("testStoreID", config.DynamicMap{
+		"keys": map[string]interface{}{"key2": "anotherverystrongpassword32chars"},
+	})
+	require.NoError(t, err)
+	store.CommonKeyRotationTest(t, fileStore, newKeyring, onlyNewKeyring)
+}
@@ -0,0 +1,376 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+// keyringPropagationDelay is the time given to other Yorc servers to use a new primary key
+// before re-encrypting values
+var keyringPropagationDelay = 5 * time.Second
+
+// keyRotationLockWaitTime is the time waited for the end of another key rotation of the same store
+const keyRotationLockWaitTime = time.Second
+
+// KeyRotationRequest defines a key rotation of an encrypted store
+type KeyRotationRequest struct {
+	// KeyID is the ID of the new primary key. The current primary key is kept if empty.
+	KeyID string `json:"key_id,omitempty"`
+	// Key is a 32 characters passphrase added to the keyring with the ID KeyID.
+	// If empty, KeyID should be the ID of a key already in the keyring.
+	Key string `json:"key,omitempty"`
+}
+
+// Statuses of a key rotation
+const (
+	KeyRotationStatusRunning = "running"
+	KeyRotationStatusDone    = "done"
+	KeyRotationStatusFailed  = "failed"
+)
+
+// KeyRotation describes the progress of a key rotation.
+//
+// It is saved into Consul when the rotation starts and when it completes.
+type KeyRotation struct {
+	Store             string     `json:"store"`
+	Status            string     `json:"status"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           *time.Time `json:"end_date,omitempty"`
+	PrimaryKeyID      string     `json:"primary_key_id"`
+	KeyIDs            []string   `json:"key_ids"`
+	ReencryptedValues int        `json:"reencrypted_values"`
+	Error             string     `json:"error,omitempty"`
+}
+
+type invalidKeyRotationError struct {
+	message string
+}
+
+func (e invalidKeyRotationError) Error() string {
+	return fmt.Sprintf("invalid key rotation: %s", e.message)
+}
+
+// IsInvalidKeyRotationError checks if an error is due to an invalid key rotation request
+func IsInvalidKeyRotationError(err error) bool {
+	_, ok := errors.Cause(err).(invalidKeyRotationError)
+	return ok
+}
+
+type keyRotationInProgressError struct {
+	storeName string
+}
+
+func (e keyRotationInProgressError) Error() string {
+	return fmt.Sprintf("a key rotation of store with name:%q is already in progress", e.storeName)
+}
+
+// IsKeyRotationInProgressError checks if an error is due to another key rotation of the same store
+func IsKeyRotationInProgressError(err error) bool {
+	_, ok := errors.Cause(err).(keyRotationInProgressError)
+	return ok
+}
+
+type keyRotationNotFoundError struct {
+	storeName string
+}
+
+func (e keyRotationNotFoundError) Error() string {
+	return fmt.Sprintf("no key rotation found for store with name:%q", e.storeName)
+}
+
+// IsKeyRotationNotFoundError checks if an error is due to a store whose key was never rotated
+func IsKeyRotationNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(keyRotationNotFoundError)
+	return ok
+}
+
+// StartKeyRotation makes the given key the primary key of an encrypted store in use and starts re-encrypting
+// all values of this store encrypted with another key.
+//
+// The store configuration saved in Consul is updated so other Yorc servers watching it with WatchKeyrings
+// start to encrypt values with the new primary key before values are re-encrypted.
+// If the request does not define any key ID, values are only re-encrypted with the current primary key.
+// Values are re-encrypted in background, the progress of the rotation is retrieved using GetKeyRotation.
+// An error checkable with IsStoreNotFoundError is returned if the store is not in use, an error checkable
+// with IsInvalidKeyRotationError is returned if the request is invalid or if the store is not encrypted and
+// an error checkable with IsKeyRotationInProgressError is returned if the key of this store is already rotating.
+func StartKeyRotation(cfg config.Configuration, storeName string, request KeyRotationRequest) (*KeyRotation, error) {
+	s, ok := storesByName[storeName]
+	if !ok {
+		return nil, errors.WithStack(storeNotFoundError{storeName})
+	}
+	kr, ok := s.(store.KeyRotator)
+	if !ok || kr.Keyring() == nil {
+		return nil, errors.WithStack(invalidKeyRotationError{fmt.Sprintf("store with name:%q is not encrypted", storeName)})
+	}
+	if request.KeyID == "" && request.Key != "" {
+		return nil, errors.WithStack(invalidKeyRotationError{"missing ID of the new key"})
+	}
+
+	consulClient, err := cfg.GetConsulClient()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := acquireKeyRotationLock(consulClient, storeName)
+	if err != nil {
+		return nil, err
+	}
+	delay := time.Duration(0)
+	if request.KeyID != "" {
+		keyring, err := updateStoreKeyring(cfg, storeName, request)
+		if err != nil {
+			releaseKeyRotationLock(lock)
+			return nil, err
+		}
+		kr.Keyring().Replace(keyring)
+		delay = keyringPropagationDelay
+		log.Printf("Primary key of store with name:%q is now %q, values will be re-encrypted in %s", storeName, request.KeyID, delay)
+	}
+
+	rotation := &KeyRotation{
+		Store:        storeName,
+		Status:       KeyRotationStatusRunning,
+		StartDate:    time.Now(),
+		PrimaryKeyID: kr.Keyring().PrimaryKeyID(),
+		KeyIDs:       kr.Keyring().KeyIDs(),
+	}
+	if err = saveKeyRotation(rotation); err != nil {
+		releaseKeyRotationLock(lock)
+		return nil, err
+	}
+	result := *rotation
+
+	go func() {
+		defer releaseKeyRotationLock(lock)
+		// The rotation is not bound to the request which started it, as values encrypted
+		// with mixed keys would be left if it was cancelled
+		time.Sleep(delay)
+		count, err := kr.ReencryptValues(context.Background())
+		endDate := time.Now()
+		rotation.EndDate = &endDate
+		rotation.ReencryptedValues = count
+		rotation.Status = KeyRotationStatusDone
+		if err != nil {
+			log.Printf("[ERROR] Key rotation of store with name:%q failed: %+v", storeName, err)
+			rotation.Status = KeyRotationStatusFailed
+			rotation.Error = err.Error()
+		} else {
+			log.Printf("%d values of store with name:%q re-encrypted with key %q", count, storeName, rotation.PrimaryKeyID)
+		}
+		if err = saveKeyRotation(rotation); err != nil {
+			log.Printf("[WARNING] failed to save key rotation status of store with name:%q: %v", storeName, err)
+		}
+	}()
+	return &result, nil
+}
+
+// GetKeyRotation returns the progress of the last key rotation of a store
+//
+// An error checkable with IsKeyRotationNotFoundError is returned if the key of this store was never rotated.
+func GetKeyRotation(storeName string) (*KeyRotation, error) {
+	kvp, _, err := consulutil.GetKV().Get(path.Join(consulutil.StoresKeyRotationsPrefix, storeName), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return nil, errors.WithStack(keyRotationNotFoundError{storeName})
+	}
+	rotation := new(KeyRotation)
+	err = json.Unmarshal(kvp.Value, rotation)
+	return rotation, errors.Wrapf(err, "failed to read key rotation of store with name:%q", storeName)
+}
+
+func saveKeyRotation(rotation *KeyRotation) error {
+	return consulutil.StoreConsulKeyWithJSONValue(path.Join(consulutil.StoresKeyRotationsPrefix, rotation.Store), rotation)
+}
+
+// acquireKeyRotationLock prevents concurrent key rotations of a store, it returns an error checkable
+// with IsKeyRotationInProgressError if the lock is already held
+func acquireKeyRotationLock(cc *api.Client, storeName string) (*api.Lock, error) {
+	lock, err := cc.LockOpts(&api.LockOptions{
+		Key:          path.Join(consulutil.StoresKeyRotationsPrefix, ".locks", storeName),
+		LockTryOnce:  true,
+		LockWaitTime: keyRotationLockWaitTime,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	lockCh, err := lock.Lock(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if lockCh == nil {
+		return nil, errors.WithStack(keyRotationInProgressError{storeName})
+	}
+	return lock, nil
+}
+
+func releaseKeyRotationLock(lock *api.Lock) {
+	err := lock.Unlock()
+	if err != nil {
+		log.Printf("[WARNING] failed to release key rotation lock: %v", err)
+		return
+	}
+	// will fail if another instance takes it
+	lock.Destroy()
+}
+
+// updateStoreKeyring adds the requested key to the store configuration saved in Consul, makes it the primary key
+// and returns the resulting keyring
+func updateStoreKeyring(cfg config.Configuration, storeName string, request KeyRotationRequest) (*encryption.Keyring, error) {
+	consulClient, err := cfg.GetConsulClient()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := consulutil.AcquireLock(consulClient, ".lock_stores", 0)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	storeKey := path.Join(consulutil.StoresPrefix, storeName)
+	kvp, _, err := consulClient.KV().Get(storeKey, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp == nil {
+		return nil, errors.WithStack(storeNotFoundError{storeName})
+	}
+	configStore := config.Store{}
+	if err = json.Unmarshal(kvp.Value, &configStore); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal store with name:%q", storeName)
+	}
+	if configStore.Properties == nil {
+		configStore.Properties = config.DynamicMap{}
+	}
+
+	keys := make(map[string]interface{})
+	if configStore.Properties.IsSet("keys") {
+		existingKeys, err := cast.ToStringMapStringE(configStore.Properties.Get("keys"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid keys property for store with name:%q", storeName)
+		}
+		for id, key := range existingKeys {
+			keys[id] = key
+		}
+	}
+	existingKey, exists := keys[request.KeyID]
+	if request.KeyID == encryption.DefaultKeyID && configStore.Properties.GetString("passphrase") != "" {
+		existingKey, exists = configStore.Properties.GetString("passphrase"), true
+	}
+	if request.Key != "" {
+		if err = encryption.CheckPassphrase(request.Key); err != nil {
+			return nil, errors.WithStack(invalidKeyRotationError{err.Error()})
+		}
+		if exists && existingKey != request.Key {
+			return nil, errors.WithStack(invalidKeyRotationError{fmt.Sprintf("a different key with ID %q already exists", request.KeyID)})
+		}
+		if !exists {
+			keys[request.KeyID] = request.Key
+		}
+	} else if !exists {
+		return nil, errors.WithStack(invalidKeyRotationError{fmt.Sprintf("unknown key %q", request.KeyID)})
+	}
+	if len(keys) > 0 {
+		configStore.Properties["keys"] = keys
+	}
+	configStore.Properties["primary_key"] = request.KeyID
+
+	keyring, err := encryption.NewKeyringFromProperties(storeName, configStore.Properties)
+	if err != nil {
+		return nil, errors.WithStack(invalidKeyRotationError{err.Error()})
+	}
+	err = consulutil.StoreConsulKeyWithJSONValue(storeKey, configStore)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to save store %s in consul", storeName)
+	}
+	return keyring, nil
+}
+
+// WatchKeyrings updates keyrings of encrypted stores in use when their configuration saved in Consul
+// is modified by a key rotation.
+//
+// It returns when shutdownCh is closed.
+func WatchKeyrings(cc *api.Client, shutdownCh chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-shutdownCh
+		cancel()
+	}()
+	var waitIndex uint64
+	for {
+		q := &api.QueryOptions{WaitIndex: waitIndex}
+		kvps, qm, err := cc.KV().List(consulutil.StoresPrefix+"/", q.WithContext(ctx))
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if err != nil || qm == nil {
+			log.Printf("[WARNING] Failed to watch stores configuration: %v", err)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		if qm.LastIndex == waitIndex {
+			continue
+		}
+		updateKeyrings(kvps)
+		waitIndex = qm.LastIndex
+	}
+}
+
+// updateKeyrings replaces keyrings of encrypted stores in use by the ones defined by the given stores configurations
+func updateKeyrings(kvps api.KVPairs) {
+	for _, kvp := range kvps {
+		name := path.Base(kvp.Key)
+		kr, ok := storesByName[name].(store.KeyRotator)
+		if !ok || kr.Keyring() == nil {
+			continue
+		}
+		configStore := config.Store{}
+		if err := json.Unmarshal(kvp.Value, &configStore); err != nil {
+			log.Printf("[WARNING] Failed to unmarshal store with name:%q: %v", name, err)
+			continue
+		}
+		keyring, err := encryption.NewKeyringFromProperties(name, configStore.Properties)
+		if err != nil {
+			log.Printf("[WARNING] Keyring of store with name:%q not updated: %v", name, err)
+			continue
+		}
+		if !kr.Keyring().Equal(keyring) {
+			kr.Keyring().Replace(keyring)
+			log.Printf("Keyring of store with name:%q updated, primary key is now %q", name, keyring.PrimaryKeyID())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/storage/encryption"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+// rotateKey rotates the key of a store and waits for values to be re-encrypted
+func rotateKey(t *testing.T, cfg config.Configuration, storeName string, request KeyRotationRequest) *KeyRotation {
+	var rotation *KeyRotation
+	var err error
+	// the lock of the previous rotation is released once its status is saved
+	for i := 0; i < 100; i++ {
+		rotation, err = StartKeyRotation(cfg, storeName, request)
+		if !IsKeyRotationInProgressError(err) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	require.Equal(t, KeyRotationStatusRunning, rotation.Status)
+	for rotation.Status == KeyRotationStatusRunning {
+		time.Sleep(10 * time.Millisecond)
+		rotation, err = GetKeyRotation(storeName)
+		require.NoError(t, err)
+	}
+	return rotation
+}
+
+func testRotateKey(t *testing.T, srv1 *testutil.TestServer, cfg config.Configuration) {
+	// Reset once to allow reload config
+	once.Reset()
+	keyringPropagationDelay = 0
+
+	cfg.Storage = config.Storage{
+		Reset: true,
+		Stores: []config.Store{{
+			Name:           "myCipherStore",
+			Implementation: "cipherConsul",
+			Types:          []string{"Deployment"},
+			Properties: config.DynamicMap{
+				"passphrase": "myverystrongpasswordo32bitlength",
+			},
+		}},
+	}
+	err := LoadStores(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key := path.Join(consulutil.DeploymentKVPrefix, t.Name(), "topology/value")
+	err = GetStore(types.StoreTypeDeployment).Set(ctx, key, store.Foo{Bar: "value"})
+	require.NoError(t, err)
+
+	invalidRequests := []struct {
+		name    string
+		store   string
+		request KeyRotationRequest
+	}{
+		{"NotEncryptedStore", "defaultConsulStoreEvent", KeyRotationRequest{}},
+		{"MissingKeyID", "myCipherStore", KeyRotationRequest{Key: "anotherverystrongpassword32chars"}},
+		{"InvalidKey", "myCipherStore", KeyRotationRequest{KeyID: "key2", Key: "short"}},
+		{"UnknownKey", "myCipherStore", KeyRotationRequest{KeyID: "key2"}},
+		{"ConflictingKey", "myCipherStore", KeyRotationRequest{KeyID: encryption.DefaultKeyID, Key: "anotherverystrongpassword32chars"}},
+	}
+	for _, tt := range invalidRequests {
+		_, err = StartKeyRotation(cfg, tt.store, tt.request)
+		require.Error(t, err, tt.name)
+		require.True(t, IsInvalidKeyRotationError(err), "%s: unexpected error %v", tt.name, err)
+	}
+	_, err = StartKeyRotation(cfg, "unknownStore", KeyRotationRequest{})
+	require.True(t, IsStoreNotFoundError(err))
+
+	// Keys of a store can't be rotated concurrently
+	cc, err := cfg.GetConsulClient()
+	require.NoError(t, err)
+	lock, err := acquireKeyRotationLock(cc, "myCipherStore")
+	require.NoError(t, err)
+	_, err = StartKeyRotation(cfg, "myCipherStore", KeyRotationRequest{})
+	require.True(t, IsKeyRotationInProgressError(err), "unexpected error %v", err)
+	releaseKeyRotationLock(lock)
+
+	_, err = GetKeyRotation("myCipherStore")
+	require.True(t, IsKeyRotationNotFoundError(err), "unexpected error %v", err)
+
+	result := rotateKey(t, cfg, "myCipherStore", KeyRotationRequest{KeyID: "key2", Key: "anotherverystrongpassword32chars"})
+	require.Equal(t, KeyRotationStatusDone, result.Status)
+	require.NotNil(t, result.EndDate)
+	require.Equal(t, "key2", result.PrimaryKeyID)
+	require.Equal(t, []string{encryption.DefaultKeyID, "key2"}, result.KeyIDs)
+	require.Equal(t, 1, result.ReencryptedValues)
+
+	// The new key is saved into the store configuration
+	kvp, _, err := consulutil.GetKV().Get(path.Join(consulutil.StoresPrefix, "myCipherStore"), nil)
+	require.NoError(t, err)
+	require.NotNil(t, kvp)
+	configStore := config.Store{}
+	err = json.Unmarshal(kvp.Value, &configStore)
+	require.NoError(t, err)
+	require.Equal(t, "key2", configStore.Properties.GetString("primary_key"))
+
+	value := store.Foo{}
+	found, err := GetStore(types.StoreTypeDeployment).Get(key, &value)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "value", value.Bar)
+
+	// Values are re-encrypted only once
+	result = rotateKey(t, cfg, "myCipherStore", KeyRotationRequest{})
+	require.Equal(t, 0, result.ReencryptedValues)
+
+	// Keyrings of other servers are updated from the saved configuration
+	kr := storesByName["myCipherStore"].(store.KeyRotator)
+	previous, err := encryption.NewKeyringFromProperties("myCipherStore", config.DynamicMap{"passphrase": "myverystrongpasswordo32bitlength"})
+	require.NoError(t, err)
+	kr.Keyring().Replace(previous)
+	kvps, _, err := consulutil.GetKV().List(consulutil.StoresPrefix+"/", nil)
+	require.NoError(t, err)
+	updateKeyrings(kvps)
+	require.Equal(t, "key2", kr.Keyring().PrimaryKeyID())
+
+	// A previous key can be the primary key again
+	result = rotateKey(t, cfg, "myCipherStore", KeyRotationRequest{KeyID: encryption.DefaultKeyID})
+	require.Equal(t, encryption.DefaultKeyID, result.PrimaryKeyID)
+	require.Equal(t, 1, result.ReencryptedValues)
+}
@@ -0,0 +1,254 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retention applies retention policies of logs and events stores.
+//
+// A compactor periodically removes logs and events that are not retained by the configured policies on the Yorc
+// server elected as leader of the compaction service. It also computes the usage of these stores.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+// Usage is the usage of logs and events stores computed by the last compaction
+type Usage struct {
+	LastCompaction time.Time    `json:"last_compaction"`
+	Stores         []StoreUsage `json:"stores"`
+}
+
+// StoreUsage is the usage of the store of a given type
+type StoreUsage struct {
+	Type           string `json:"type"`
+	Implementation string `json:"implementation,omitempty"`
+	// Deployments is the number of deployments having values in this store
+	Deployments int `json:"deployments"`
+	// Entries is the number of values kept in this store
+	Entries int `json:"entries"`
+	// RemovedEntries is the number of values removed by the last compaction
+	RemovedEntries int `json:"removed_entries"`
+	// Errors is the number of deployments whose values failed to be compacted
+	Errors int `json:"errors,omitempty"`
+}
+
+// compactedTypes are the types of stores having a retention policy with the prefix of their keys
+var compactedTypes = []struct {
+	storeType types.StoreType
+	prefix    string
+}{
+	{types.StoreTypeLog, consulutil.LogsPrefix},
+	{types.StoreTypeEvent, consulutil.EventsPrefix},
+}
+
+// levelsSeverity orders log levels from the less severe to the most severe one
+var levelsSeverity = []events.LogLevel{events.LogLevelDEBUG, events.LogLevelINFO, events.LogLevelWARN, events.LogLevelERROR}
+
+var defaultCompactor *compactor
+
+type compactor struct {
+	cc         *api.Client
+	interval   time.Duration
+	policies   map[types.StoreType]store.RetentionPolicy
+	serviceKey string
+	chShutdown chan struct{}
+	lock       sync.Mutex
+	// chStop is closed to stop compacting stores, it is nil if the compaction is not running
+	chStop chan struct{}
+}
+
+// Start checks the configured retention policies and starts compacting logs and events stores periodically
+// if this server is elected as leader of the compaction service.
+func Start(cfg config.Configuration, cc *api.Client) error {
+	policies, err := buildPolicies(cfg.Storage.Retention)
+	if err != nil {
+		return err
+	}
+	interval := cfg.Storage.Retention.CompactionInterval
+	if interval <= 0 {
+		interval = config.DefaultStorageCompactionInterval
+	}
+	defaultCompactor = &compactor{
+		cc:         cc,
+		interval:   interval,
+		policies:   policies,
+		serviceKey: path.Join(consulutil.YorcServicePrefix, "/compaction/leader"),
+		chShutdown: make(chan struct{}),
+	}
+	go consulutil.WatchLeaderElection(cc, defaultCompactor.serviceKey, defaultCompactor.chShutdown, defaultCompactor.startCompaction, defaultCompactor.stopCompaction)
+	return nil
+}
+
+// Stop stops compacting stores
+func Stop() {
+	if defaultCompactor == nil {
+		return
+	}
+	defaultCompactor.stopCompaction()
+	close(defaultCompactor.chShutdown)
+}
+
+// GetUsage returns the usage of logs and events stores computed by the last compaction.
+//
+// It returns nil if no compaction has been done yet.
+func GetUsage(cc *api.Client) (*Usage, error) {
+	kvp, _, err := cc.KV().Get(consulutil.StoresUsageKey, nil)
+	if err != nil || kvp == nil {
+		return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	usage := new(Usage)
+	return usage, errors.Wrap(json.Unmarshal(kvp.Value, usage), "failed to decode stores usage")
+}
+
+func buildPolicies(retention config.Retention) (map[types.StoreType]store.RetentionPolicy, error) {
+	logsPolicy, err := buildPolicy("logs", retention.Logs)
+	if err != nil {
+		return nil, err
+	}
+	eventsPolicy, err := buildPolicy("events", retention.Events)
+	if err != nil {
+		return nil, err
+	}
+	if retention.Events.MinLevel != "" {
+		return nil, errors.New("invalid events retention policy: min_level only applies to logs")
+	}
+	return map[types.StoreType]store.RetentionPolicy{
+		types.StoreTypeLog:   logsPolicy,
+		types.StoreTypeEvent: eventsPolicy,
+	}, nil
+}
+
+func buildPolicy(name string, cfgPolicy config.RetentionPolicy) (store.RetentionPolicy, error) {
+	policy := store.RetentionPolicy{MaxAge: cfgPolicy.MaxAge, MaxEntries: cfgPolicy.MaxEntriesPerDeployment}
+	if policy.MaxAge < 0 || policy.MaxEntries < 0 {
+		return policy, errors.Errorf("invalid %s retention policy: max_age and max_entries_per_deployment should not be negative", name)
+	}
+	if cfgPolicy.MinLevel == "" {
+		return policy, nil
+	}
+	minLevel, err := events.ParseLogLevel(strings.ToUpper(cfgPolicy.MinLevel))
+	if err != nil {
+		return policy, errors.Errorf("invalid %s retention policy: unknown min_level %q, expecting one of DEBUG, INFO, WARN or ERROR", name, cfgPolicy.MinLevel)
+	}
+	for _, level := range levelsSeverity {
+		if level == minLevel {
+			break
+		}
+		policy.RemovedLevels = append(policy.RemovedLevels, level.String())
+	}
+	return policy, nil
+}
+
+func (c *compactor) startCompaction() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.chStop != nil {
+		log.Println("Stores compaction service is already running.")
+		return
+	}
+	c.chStop = make(chan struct{})
+	go c.run(c.chStop)
+}
+
+func (c *compactor) stopCompaction() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.chStop != nil {
+		log.Debugf("Stores compaction service is about to be stopped")
+		close(c.chStop)
+		c.chStop = nil
+	}
+}
+
+func (c *compactor) run(chStop chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-chStop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		deploymentsIDs, err := deployments.GetDeploymentsIDs(ctx)
+		if err != nil {
+			log.Printf("[WARNING] Failed to compact logs and events stores: %v", err)
+		} else {
+			usage := compactStores(ctx, deploymentsIDs, c.policies, storage.GetStore)
+			if ctx.Err() == nil {
+				if err = consulutil.StoreConsulKeyWithJSONValue(consulutil.StoresUsageKey, usage); err != nil {
+					log.Printf("[WARNING] Failed to save logs and events stores usage: %v", err)
+				}
+			}
+		}
+		select {
+		case <-chStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// compactStores compacts logs and events of the given deployments and returns the resulting stores usage
+func compactStores(ctx context.Context, deploymentsIDs []string, policies map[types.StoreType]store.RetentionPolicy, getStore func(types.StoreType) store.Store) Usage {
+	usage := Usage{Stores: make([]StoreUsage, 0, len(compactedTypes))}
+	for _, compactedType := range compactedTypes {
+		storeUsage := StoreUsage{
+			Type:           compactedType.storeType.String(),
+			Implementation: storage.GetStoreImplementation(compactedType.storeType),
+		}
+		s := getStore(compactedType.storeType)
+		for _, deploymentID := range deploymentsIDs {
+			if ctx.Err() != nil {
+				return usage
+			}
+			result, err := storage.Compact(ctx, s, path.Join(compactedType.prefix, deploymentID)+"/", policies[compactedType.storeType])
+			if err != nil {
+				log.Printf("[WARNING] Failed to compact %s of deployment %q: %v", strings.ToLower(storeUsage.Type)+"s", deploymentID, err)
+				storeUsage.Errors++
+			}
+			if result.Remaining > 0 {
+				storeUsage.Deployments++
+			}
+			storeUsage.Entries += result.Remaining
+			storeUsage.RemovedEntries += result.Removed
+		}
+		if storeUsage.RemovedEntries > 0 {
+			log.Printf("Compaction removed %d %s, %d remaining", storeUsage.RemovedEntries, strings.ToLower(storeUsage.Type)+"s", storeUsage.Entries)
+		}
+		usage.Stores = append(usage.Stores, storeUsage)
+	}
+	usage.LastCompaction = time.Now()
+	return usage
+}
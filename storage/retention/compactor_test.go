@@ -0,0 +1,102 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/storage/internal/file"
+	"github.com/ystia/yorc/v4/storage/store"
+	"github.com/ystia/yorc/v4/storage/types"
+)
+
+func TestBuildPolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention config.Retention
+		want      map[types.StoreType]store.RetentionPolicy
+		wantErr   bool
+	}{
+		{"Empty", config.Retention{}, map[types.StoreType]store.RetentionPolicy{types.StoreTypeLog: {}, types.StoreTypeEvent: {}}, false},
+		{"Full", config.Retention{
+			Logs:   config.RetentionPolicy{MaxAge: time.Hour, MaxEntriesPerDeployment: 10, MinLevel: "warn"},
+			Events: config.RetentionPolicy{MaxAge: 2 * time.Hour},
+		}, map[types.StoreType]store.RetentionPolicy{
+			types.StoreTypeLog:   {MaxAge: time.Hour, MaxEntries: 10, RemovedLevels: []string{"DEBUG", "INFO"}},
+			types.StoreTypeEvent: {MaxAge: 2 * time.Hour},
+		}, false},
+		{"DebugMinLevel", config.Retention{Logs: config.RetentionPolicy{MinLevel: "DEBUG"}},
+			map[types.StoreType]store.RetentionPolicy{types.StoreTypeLog: {}, types.StoreTypeEvent: {}}, false},
+		{"UnknownLevel", config.Retention{Logs: config.RetentionPolicy{MinLevel: "TRACE"}}, nil, true},
+		{"EventsLevel", config.Retention{Events: config.RetentionPolicy{MinLevel: "INFO"}}, nil, true},
+		{"NegativeMaxEntries", config.Retention{Logs: config.RetentionPolicy{MaxEntriesPerDeployment: -1}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildPolicies(tt.retention)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompactStores(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "yorc-retention-")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	cfg := config.Configuration{WorkingDirectory: workingDir}
+	props := config.DynamicMap{
+		"root_dir":                       filepath.Join(workingDir, "store"),
+		"blocking_query_default_timeout": "5m",
+		"concurrency_limit":              1000,
+	}
+	s, err := file.NewStore(cfg, "retentionStore", props, false, false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	now := time.Now()
+	keyValues := make([]store.KeyValueIn, 0)
+	for i := 0; i < 3; i++ {
+		timestamp := now.Add(time.Duration(i-3) * time.Minute).Format(time.RFC3339Nano)
+		keyValues = append(keyValues,
+			store.KeyValueIn{Key: "_yorc/logs/dep1/" + timestamp, Value: map[string]interface{}{"timestamp": timestamp, "level": "INFO"}},
+			store.KeyValueIn{Key: "_yorc/events/dep1/" + timestamp, Value: map[string]interface{}{"timestamp": timestamp}},
+		)
+	}
+	require.NoError(t, s.SetCollection(ctx, keyValues))
+
+	policies := map[types.StoreType]store.RetentionPolicy{
+		types.StoreTypeLog:   {MaxEntries: 1},
+		types.StoreTypeEvent: {},
+	}
+	usage := compactStores(ctx, []string{"dep1", "dep2"}, policies, func(types.StoreType) store.Store { return s })
+	require.False(t, usage.LastCompaction.IsZero())
+	require.Equal(t, []StoreUsage{
+		{Type: "Log", Deployments: 1, Entries: 1, RemovedEntries: 2},
+		{Type: "Event", Deployments: 1, Entries: 3},
+	}, usage.Stores)
+}
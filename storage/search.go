@@ -0,0 +1,68 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+// SearchLogs returns the logs stored in the given store that match the query.
+//
+// Stores implementing store.LogSearcher search logs by themselves, logs of other stores are listed and
+// filtered by Yorc. Logs are then counted deployment by deployment using their keys and a TooManyLogsError
+// is returned before reading them if there are more than store.MaxSearchedLogs logs.
+func SearchLogs(ctx context.Context, s store.Store, query store.LogSearchQuery) (store.LogSearchResult, error) {
+	if err := query.Validate(); err != nil {
+		return store.LogSearchResult{}, err
+	}
+	if searcher, ok := s.(store.LogSearcher); ok {
+		return searcher.SearchLogs(ctx, query)
+	}
+
+	prefixes := []string{path.Join(consulutil.LogsPrefix, query.DeploymentID)}
+	if query.DeploymentID == "" {
+		var err error
+		prefixes, err = s.Keys(consulutil.LogsPrefix)
+		if err != nil {
+			return store.LogSearchResult{}, errors.Wrapf(err, "failed to list keys of key %q", consulutil.LogsPrefix)
+		}
+	}
+	var count int
+	for _, prefix := range prefixes {
+		keys, err := s.Keys(prefix)
+		if err != nil {
+			return store.LogSearchResult{}, errors.Wrapf(err, "failed to list keys of key %q", prefix)
+		}
+		count += len(keys)
+		if count > store.MaxSearchedLogs {
+			return store.LogSearchResult{}, errors.WithStack(store.TooManyLogsError{})
+		}
+	}
+	kvs := make([]store.KeyValueOut, 0, count)
+	for _, prefix := range prefixes {
+		values, _, err := s.List(ctx, prefix+"/", 0, 0)
+		if err != nil {
+			return store.LogSearchResult{}, errors.Wrapf(err, "failed to list values of key %q", prefix)
+		}
+		kvs = append(kvs, values...)
+	}
+	return store.SearchLogEntries(kvs, query)
+}
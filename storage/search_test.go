@@ -0,0 +1,53 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/storage/internal/bolt"
+	"github.com/ystia/yorc/v4/storage/internal/file"
+	"github.com/ystia/yorc/v4/storage/store"
+)
+
+func TestSearchLogsWithoutNativeSearch(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "yorc-search-")
+	require.NoError(t, err)
+	defer os.RemoveAll(workingDir)
+	cfg := config.Configuration{WorkingDirectory: workingDir}
+
+	t.Run("FileStore", func(t *testing.T) {
+		props := completePropertiesWithDefault(cfg, config.DynamicMap{"root_dir": filepath.Join(workingDir, "file")})
+		s, err := file.NewStore(cfg, "searchFileStore", props, false, false)
+		require.NoError(t, err)
+		store.CommonLogSearchTest(t, s, func(ctx context.Context, query store.LogSearchQuery) (store.LogSearchResult, error) {
+			return SearchLogs(ctx, s, query)
+		})
+	})
+	t.Run("BoltStore", func(t *testing.T) {
+		s, err := bolt.NewStore(cfg, "searchBoltStore", config.DynamicMap{"file": filepath.Join(workingDir, "bolt.db")}, false)
+		require.NoError(t, err)
+		store.CommonLogSearchTest(t, s, func(ctx context.Context, query store.LogSearchQuery) (store.LogSearchResult, error) {
+			return SearchLogs(ctx, s, query)
+		})
+	})
+}
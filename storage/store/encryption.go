@@ -0,0 +1,32 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+
+	"github.com/ystia/yorc/v4/storage/encryption"
+)
+
+// KeyRotator is an optional interface implemented by stores encrypting their values with a keyring.
+type KeyRotator interface {
+	// Keyring returns the keyring used to encrypt and decrypt values.
+	// Its keys may be replaced while the store is in use.
+	Keyring() *encryption.Keyring
+	// ReencryptValues encrypts with the primary key of the keyring all values encrypted with another key.
+	// It returns the number of re-encrypted values.
+	// Values may be updated concurrently, a value updated during its re-encryption is not overwritten.
+	ReencryptValues(ctx context.Context) (int, error)
+}
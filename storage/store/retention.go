@@ -0,0 +1,121 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"path"
+	"sort"
+	"time"
+)
+
+// RetentionPolicy defines which values stored under a key are removed by a compaction.
+//
+// It applies to logs and events which are identified by their timestamp and optionally by their level.
+type RetentionPolicy struct {
+	// MaxAge is the maximum age of kept values, 0 means no limit
+	MaxAge time.Duration
+	// MaxEntries is the maximum number of kept values, oldest values are removed first, 0 means no limit
+	MaxEntries int
+	// RemovedLevels are the levels of values to remove whatever their age
+	RemovedLevels []string
+}
+
+// RetentionEntry describes a stored value on which a retention policy applies
+type RetentionEntry struct {
+	Key       string
+	Timestamp time.Time
+	Level     string
+}
+
+// CompactionResult is the result of the compaction of values stored under a key
+type CompactionResult struct {
+	// Remaining is the number of values kept under the key
+	Remaining int
+	// Removed is the number of removed values
+	Removed int
+}
+
+// Compactor is an optional interface implemented by stores having a native way to remove values
+// according to a retention policy.
+//
+// Values of stores that do not implement it are listed and removed one by one.
+type Compactor interface {
+	// Compact removes values stored under the key k that are not retained by the given policy.
+	Compact(ctx context.Context, k string, policy RetentionPolicy) (CompactionResult, error)
+}
+
+// NewRetentionEntry returns the RetentionEntry of a log or an event.
+//
+// The timestamp is read from the "timestamp" field of the value if any, otherwise from the last segment of the key.
+// It returns false if the timestamp can't be parsed.
+func NewRetentionEntry(key string, value map[string]interface{}) (RetentionEntry, bool) {
+	entry := RetentionEntry{Key: key}
+	timestamp, _ := value["timestamp"].(string)
+	if timestamp == "" {
+		timestamp = path.Base(key)
+	}
+	var err error
+	entry.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return entry, false
+	}
+	entry.Level, _ = value["level"].(string)
+	return entry, true
+}
+
+// IsEmpty returns true if the policy does not remove any value
+func (p RetentionPolicy) IsEmpty() bool {
+	return p.MaxAge <= 0 && p.MaxEntries <= 0 && len(p.RemovedLevels) == 0
+}
+
+// RemovedKeys returns the keys of the given entries that are not retained by the policy at the given date.
+//
+// Keys are returned from the oldest entry to the newest one.
+func (p RetentionPolicy) RemovedKeys(entries []RetentionEntry, now time.Time) []string {
+	sorted := make([]RetentionEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	removedLevels := make(map[string]bool, len(p.RemovedLevels))
+	for _, level := range p.RemovedLevels {
+		removedLevels[level] = true
+	}
+	removed := make([]bool, len(sorted))
+	var kept int
+	for i, entry := range sorted {
+		removed[i] = (p.MaxAge > 0 && now.Sub(entry.Timestamp) > p.MaxAge) || removedLevels[entry.Level]
+		if !removed[i] {
+			kept++
+		}
+	}
+	// Oldest kept entries are removed first when there are too many of them
+	for i := 0; p.MaxEntries > 0 && kept > p.MaxEntries && i < len(sorted); i++ {
+		if !removed[i] {
+			removed[i] = true
+			kept--
+		}
+	}
+
+	keys := make([]string, 0, len(sorted)-kept)
+	for i, entry := range sorted {
+		if removed[i] {
+			keys = append(keys, entry.Key)
+		}
+	}
+	return keys
+}
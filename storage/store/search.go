@@ -0,0 +1,233 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLogSearchSize is the number of logs returned by a search when no size is specified
+const DefaultLogSearchSize = 100
+
+// MaxLogSearchWindow is the maximum value of the offset plus the size of a search
+const MaxLogSearchWindow = 10000
+
+// MaxSearchedLogs is the maximum number of logs read by a search on stores which filter logs within Yorc
+const MaxSearchedLogs = 100000
+
+// TooManyLogsError is returned by searches which would read more than MaxSearchedLogs logs
+type TooManyLogsError struct{}
+
+func (e TooManyLogsError) Error() string {
+	return fmt.Sprintf("logs search should not read more than %d logs, restrict it to a deployment", MaxSearchedLogs)
+}
+
+// IsTooManyLogsError checks if an error is a TooManyLogsError
+func IsTooManyLogsError(err error) bool {
+	_, ok := errors.Cause(err).(TooManyLogsError)
+	return ok
+}
+
+// LogSearchQuery defines the criteria of a logs search, logs match all defined criteria.
+type LogSearchQuery struct {
+	// DeploymentID restricts the search to the logs of a deployment, logs of all deployments are searched if empty
+	DeploymentID string
+	// Contains is a phrase that the log content should contain, words are matched regardless of case and punctuation
+	Contains string
+	// NodePattern is a regular expression that should match the whole node name of logs
+	NodePattern string
+	// Levels are the accepted log levels, all levels are accepted if empty
+	Levels []string
+	// From is the inclusive lower bound of logs timestamps, ignored if zero
+	From time.Time
+	// To is the exclusive upper bound of logs timestamps, ignored if zero
+	To time.Time
+	// Interval is the width of the buckets of the logs histogram, no histogram is computed if zero
+	Interval time.Duration
+	// Offset is the number of matching logs to skip
+	Offset int
+	// Size is the maximum number of returned logs, DefaultLogSearchSize is used if zero
+	Size int
+}
+
+// HistogramBucket is the number of logs matching a search within a time interval
+type HistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// LogSearchResult is the result of a logs search
+type LogSearchResult struct {
+	// Total is the number of logs matching the search
+	Total int `json:"total"`
+	// Logs are the matching logs sorted by timestamp, starting at the query offset
+	Logs []json.RawMessage `json:"logs"`
+	// Histogram contains non-empty buckets of matching logs sorted by start date
+	Histogram []HistogramBucket `json:"histogram,omitempty"`
+}
+
+// LogSearcher is an optional interface implemented by stores having a native way to search logs.
+//
+// Logs of stores that do not implement it are listed and filtered using SearchLogEntries.
+type LogSearcher interface {
+	// SearchLogs returns the logs matching the given query
+	SearchLogs(ctx context.Context, query LogSearchQuery) (LogSearchResult, error)
+}
+
+// Validate checks that the query is consistent
+func (q LogSearchQuery) Validate() error {
+	if q.Offset < 0 || q.Size < 0 {
+		return errors.New("offset and size of a logs search should not be negative")
+	}
+	if q.Offset+q.PageSize() > MaxLogSearchWindow {
+		return errors.Errorf("offset plus size of a logs search should not be greater than %d", MaxLogSearchWindow)
+	}
+	if q.Interval < 0 {
+		return errors.New("histogram interval of a logs search should not be negative")
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && !q.From.Before(q.To) {
+		return errors.New("start date of a logs search should be before its end date")
+	}
+	_, err := q.NodeRegexp()
+	return err
+}
+
+// PageSize returns the maximum number of logs returned by the search
+func (q LogSearchQuery) PageSize() int {
+	if q.Size == 0 {
+		return DefaultLogSearchSize
+	}
+	return q.Size
+}
+
+// NodeRegexp returns the compiled node pattern anchored at both ends, or nil if there is no pattern
+func (q LogSearchQuery) NodeRegexp() (*regexp.Regexp, error) {
+	if q.NodePattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + q.NodePattern + ")$")
+	return re, errors.Wrapf(err, "invalid node pattern %q", q.NodePattern)
+}
+
+// ContentTokens splits a text into lowercase words made of letters and digits
+func ContentTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsPhrase returns true if the phrase tokens are found consecutively in the text tokens
+func containsPhrase(text, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(text); i++ {
+		found := true
+		for j := range phrase {
+			if text[i+j] != phrase[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchLogEntries filters the given logs according to the query and computes the search result.
+//
+// Logs whose timestamp can't be determined are ignored. A phrase without any word matches no log.
+func SearchLogEntries(kvs []KeyValueOut, query LogSearchQuery) (LogSearchResult, error) {
+	result := LogSearchResult{Logs: make([]json.RawMessage, 0)}
+	if err := query.Validate(); err != nil {
+		return result, err
+	}
+	nodeRegexp, _ := query.NodeRegexp()
+	phrase := ContentTokens(query.Contains)
+	levels := make(map[string]bool, len(query.Levels))
+	for _, level := range query.Levels {
+		levels[level] = true
+	}
+
+	type match struct {
+		entry RetentionEntry
+		raw   json.RawMessage
+	}
+	matches := make([]match, 0)
+	for _, kv := range kvs {
+		entry, ok := NewRetentionEntry(kv.Key, kv.Value)
+		if !ok {
+			continue
+		}
+		if query.DeploymentID != "" && kv.Value["deploymentId"] != query.DeploymentID {
+			continue
+		}
+		if (!query.From.IsZero() && entry.Timestamp.Before(query.From)) || (!query.To.IsZero() && !entry.Timestamp.Before(query.To)) {
+			continue
+		}
+		if len(levels) > 0 && !levels[entry.Level] {
+			continue
+		}
+		if nodeRegexp != nil {
+			nodeID, _ := kv.Value["nodeId"].(string)
+			if !nodeRegexp.MatchString(nodeID) {
+				continue
+			}
+		}
+		if query.Contains != "" {
+			content, _ := kv.Value["content"].(string)
+			if len(phrase) == 0 || !containsPhrase(ContentTokens(content), phrase) {
+				continue
+			}
+		}
+		matches = append(matches, match{entry, kv.RawValue})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].entry.Timestamp.Equal(matches[j].entry.Timestamp) {
+			return matches[i].entry.Key < matches[j].entry.Key
+		}
+		return matches[i].entry.Timestamp.Before(matches[j].entry.Timestamp)
+	})
+
+	result.Total = len(matches)
+	for i := query.Offset; i < len(matches) && i < query.Offset+query.PageSize(); i++ {
+		result.Logs = append(result.Logs, matches[i].raw)
+	}
+	if query.Interval > 0 {
+		interval := int64(query.Interval)
+		for _, m := range matches {
+			ts := m.entry.Timestamp.UnixNano()
+			start := ts - ts%interval
+			if ts%interval < 0 {
+				start -= interval
+			}
+			last := len(result.Histogram) - 1
+			if last >= 0 && result.Histogram[last].Start.UnixNano() == start {
+				result.Histogram[last].Count++
+			} else {
+				result.Histogram = append(result.Histogram, HistogramBucket{Start: time.Unix(0, start).UTC(), Count: 1})
+			}
+		}
+	}
+	return result, nil
+}
@@ -16,6 +16,7 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testutil"
 	"github.com/mitchellh/mapstructure"
@@ -23,6 +24,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/internal/standalone"
+	"github.com/ystia/yorc/v4/storage/encryption"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -90,7 +93,11 @@ func NewTestConsulInstance(t testing.TB, cfg *config.Configuration) (*testutil.T
 		c.LogLevel = logLevel
 	}
 
-	srv1, err := testutil.NewTestServerConfig(cb)
+	newTestServer := testutil.NewTestServerConfig
+	if standalone.TestServerEnabled() {
+		newTestServer = standalone.NewTestServer
+	}
+	srv1, err := newTestServer(cb)
 	if err != nil {
 		t.Fatalf("Failed to create consul server: %v", err)
 	}
@@ -537,3 +544,200 @@ func CommonStoreTestAllTypes(t *testing.T, store Store) {
 		})
 	}
 }
+
+// CommonCompactionTest allows to test the removal of logs according to retention policies using the given compaction function
+func CommonCompactionTest(t *testing.T, store Store, compact func(ctx context.Context, k string, policy RetentionPolicy) (CompactionResult, error)) {
+	ctx := context.Background()
+	now := time.Now()
+	prefix := "_yorc/logs/compactedDep/"
+	otherPrefix := "_yorc/logs/otherDep/"
+	logs := []struct {
+		timestamp time.Time
+		level     string
+	}{
+		{time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC), "INFO"},
+		{time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC), "DEBUG"},
+		{now.Add(-2 * time.Hour), "DEBUG"},
+		{now.Add(-time.Hour), "INFO"},
+		{now.Add(-time.Minute), "ERROR"},
+	}
+	keyValues := make([]KeyValueIn, 0)
+	for _, l := range logs {
+		timestamp := l.timestamp.Format(time.RFC3339Nano)
+		value := map[string]interface{}{"timestamp": timestamp, "level": l.level, "content": "some log"}
+		keyValues = append(keyValues, KeyValueIn{Key: prefix + timestamp, Value: value})
+		keyValues = append(keyValues, KeyValueIn{Key: otherPrefix + timestamp, Value: value})
+	}
+	// Values which are not logs are never removed even if they share a prefix with removed logs
+	unknownKey := prefix + "2020-01-01-unknown"
+	keyValues = append(keyValues, KeyValueIn{Key: unknownKey, Value: map[string]interface{}{"content": "not a log"}})
+	err := store.SetCollection(ctx, keyValues)
+	require.NoError(t, err)
+
+	checkRemainingKeys := func(expectedLogs ...int) {
+		kvs, _, err := store.List(ctx, prefix, 0, 0)
+		require.NoError(t, err)
+		keys := make([]string, 0)
+		for _, kv := range kvs {
+			keys = append(keys, kv.Key)
+		}
+		expectedKeys := []string{unknownKey}
+		for _, i := range expectedLogs {
+			expectedKeys = append(expectedKeys, prefix+logs[i].timestamp.Format(time.RFC3339Nano))
+		}
+		require.ElementsMatch(t, expectedKeys, keys)
+	}
+
+	tests := []struct {
+		name          string
+		policy        RetentionPolicy
+		wantResult    CompactionResult
+		remainingLogs []int
+	}{
+		{"EmptyPolicy", RetentionPolicy{}, CompactionResult{Remaining: 6}, []int{0, 1, 2, 3, 4}},
+		{"RemovedLevels", RetentionPolicy{RemovedLevels: []string{"DEBUG"}}, CompactionResult{Remaining: 4, Removed: 2}, []int{0, 3, 4}},
+		{"MaxAge", RetentionPolicy{MaxAge: 24 * time.Hour}, CompactionResult{Remaining: 3, Removed: 1}, []int{3, 4}},
+		{"MaxEntries", RetentionPolicy{MaxEntries: 1}, CompactionResult{Remaining: 2, Removed: 1}, []int{4}},
+		{"NothingToRemove", RetentionPolicy{MaxAge: 24 * time.Hour, MaxEntries: 1}, CompactionResult{Remaining: 2}, []int{4}},
+	}
+	for _, tt := range tests {
+		result, err := compact(ctx, prefix, tt.policy)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.wantResult, result, tt.name)
+		checkRemainingKeys(tt.remainingLogs...)
+	}
+
+	// Logs of other deployments are kept
+	kvs, _, err := store.List(ctx, otherPrefix, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, kvs, len(logs))
+}
+
+// CommonKeyRotationTest checks that values of an encrypted store are re-encrypted with a new primary key.
+//
+// newKeyring should contain the keys of the store keyring and a new primary key, onlyNewKeyring should
+// only contain this new key.
+func CommonKeyRotationTest(t *testing.T, store Store, newKeyring, onlyNewKeyring *encryption.Keyring) {
+	ctx := context.Background()
+	kr, ok := store.(KeyRotator)
+	require.True(t, ok, "store should implement KeyRotator")
+	require.NotNil(t, kr.Keyring())
+
+	prefix := "_yorc/deployments/keyRotationDep/topology/"
+	keyValues := make([]KeyValueIn, 0)
+	for i := 0; i < 3; i++ {
+		keyValues = append(keyValues, KeyValueIn{Key: prefix + strconv.Itoa(i), Value: Foo{Bar: "value" + strconv.Itoa(i)}})
+	}
+	err := store.SetCollection(ctx, keyValues)
+	require.NoError(t, err)
+
+	kr.Keyring().Replace(newKeyring)
+	checkValues := func() {
+		for _, kv := range keyValues {
+			value := Foo{}
+			found, err := store.Get(kv.Key, &value)
+			require.NoError(t, err)
+			require.True(t, found)
+			require.Equal(t, kv.Value, value)
+		}
+	}
+	// Values encrypted with the previous key are still readable
+	checkValues()
+
+	count, err := kr.ReencryptValues(ctx)
+	require.NoError(t, err)
+	require.Equal(t, len(keyValues), count)
+
+	// Values written with the new primary key do not need to be re-encrypted
+	err = store.Set(ctx, prefix+"new", Foo{Bar: "new"})
+	require.NoError(t, err)
+	count, err = kr.ReencryptValues(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	// Previous keys are not needed anymore
+	kr.Keyring().Replace(onlyNewKeyring)
+	checkValues()
+}
+
+// CommonLogSearchTest checks that logs are searched by content, node, level and time range
+// and that the histogram of matching logs is computed.
+func CommonLogSearchTest(t *testing.T, store Store, search func(ctx context.Context, query LogSearchQuery) (LogSearchResult, error)) {
+	ctx := context.Background()
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	logs := []struct {
+		deploymentID string
+		nodeID       string
+		level        string
+		content      string
+	}{
+		{"searchDep", "Compute", "INFO", "Starting the installation of Compute"},
+		{"searchDep", "Compute", "DEBUG", "Running ansible playbook: create.yml"},
+		{"searchDep", "ComputeBis", "ERROR", "Failed to run ansible playbook: connection refused"},
+		{"searchDep", "Database", "INFO", "Database installed, connection is up"},
+		{"otherSearchDep", "Compute", "ERROR", "Failed to run ansible playbook: timeout"},
+	}
+	keyValues := make([]KeyValueIn, 0)
+	for i, l := range logs {
+		timestamp := start.Add(time.Duration(i) * time.Minute).Format(time.RFC3339Nano)
+		value := map[string]interface{}{
+			"timestamp":    timestamp,
+			"deploymentId": l.deploymentID,
+			"nodeId":       l.nodeID,
+			"level":        l.level,
+			"content":      l.content,
+		}
+		keyValues = append(keyValues, KeyValueIn{Key: "_yorc/logs/" + l.deploymentID + "/" + timestamp, Value: value})
+	}
+	err := store.SetCollection(ctx, keyValues)
+	require.NoError(t, err)
+
+	checkContents := func(result LogSearchResult, expectedLogs ...int) {
+		contents := make([]string, 0)
+		for _, raw := range result.Logs {
+			var value map[string]interface{}
+			require.NoError(t, json.Unmarshal(raw, &value))
+			contents = append(contents, value["content"].(string))
+		}
+		expected := make([]string, 0)
+		for _, i := range expectedLogs {
+			expected = append(expected, logs[i].content)
+		}
+		require.Equal(t, expected, contents)
+	}
+
+	tests := []struct {
+		name      string
+		query     LogSearchQuery
+		wantTotal int
+		wantLogs  []int
+	}{
+		{"AllLogs", LogSearchQuery{}, 5, []int{0, 1, 2, 3, 4}},
+		{"Deployment", LogSearchQuery{DeploymentID: "searchDep"}, 4, []int{0, 1, 2, 3}},
+		{"ContainsPhrase", LogSearchQuery{Contains: "ANSIBLE playbook"}, 3, []int{1, 2, 4}},
+		{"ContainsPhraseInOrder", LogSearchQuery{Contains: "playbook ansible"}, 0, []int{}},
+		{"ContainsWithoutWords", LogSearchQuery{Contains: "!!"}, 0, []int{}},
+		{"NodePatternMatchesWholeName", LogSearchQuery{NodePattern: "Comp.*"}, 4, []int{0, 1, 2, 4}},
+		{"NodePattern", LogSearchQuery{NodePattern: "Compute"}, 3, []int{0, 1, 4}},
+		{"Levels", LogSearchQuery{Levels: []string{"ERROR", "DEBUG"}}, 3, []int{1, 2, 4}},
+		{"TimeRange", LogSearchQuery{From: start.Add(time.Minute), To: start.Add(3 * time.Minute)}, 2, []int{1, 2}},
+		{"Combined", LogSearchQuery{DeploymentID: "searchDep", Contains: "connection", Levels: []string{"ERROR"}}, 1, []int{2}},
+		{"Paging", LogSearchQuery{Offset: 1, Size: 2}, 5, []int{1, 2}},
+		{"OffsetAfterLast", LogSearchQuery{Offset: 10}, 5, []int{}},
+	}
+	for _, tt := range tests {
+		result, err := search(ctx, tt.query)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.wantTotal, result.Total, tt.name)
+		checkContents(result, tt.wantLogs...)
+	}
+
+	result, err := search(ctx, LogSearchQuery{DeploymentID: "searchDep", Interval: 2 * time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, []HistogramBucket{{Start: start, Count: 2}, {Start: start.Add(2 * time.Minute), Count: 2}}, result.Histogram)
+
+	_, err = search(ctx, LogSearchQuery{NodePattern: "Compute("})
+	require.Error(t, err)
+	_, err = search(ctx, LogSearchQuery{Offset: MaxLogSearchWindow})
+	require.Error(t, err)
+}
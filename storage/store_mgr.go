@@ -30,6 +30,7 @@ import (
 	"github.com/ystia/yorc/v4/helper/collections"
 	"github.com/ystia/yorc/v4/helper/consulutil"
 	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/storage/internal/bolt"
 	"github.com/ystia/yorc/v4/storage/internal/consul"
 	"github.com/ystia/yorc/v4/storage/internal/elastic"
 	"github.com/ystia/yorc/v4/storage/internal/file"
@@ -39,8 +40,12 @@ import (
 
 const consulStoreImpl = "consul"
 
+const consulStoreWithEncryptionImpl = "cipherConsul"
+
 const elasticStoreImpl = "elastic"
 
+const elasticStoreWithEncryptionImpl = "cipherElastic"
+
 const fileStoreImpl = "file"
 
 const fileStoreWithEncryptionImpl = "cipherFile"
@@ -49,6 +54,10 @@ const fileStoreWithCacheImpl = "fileCache"
 
 const fileStoreWithCacheAndEncryptionImpl = "cipherFileCache"
 
+const boltStoreImpl = "bolt"
+
+const boltStoreWithEncryptionImpl = "cipherBolt"
+
 const defaultRelativeRootDir = "store"
 
 const defaultBlockingQueryTimeout = "5m0s"
@@ -68,6 +77,19 @@ var once resync.Once
 // stores implementations provided with GetStore(types.StoreType)
 var stores map[types.StoreType]store.Store
 
+// storesImplementations keeps the implementation name of stores provided with GetStore(types.StoreType)
+var storesImplementations map[types.StoreType]string
+
+// storesByName keeps the store implementations provided with GetStore(types.StoreType) by store name
+var storesByName map[string]store.Store
+
+// typesPrefixes are the root keys of values stored by type of store
+var typesPrefixes = map[types.StoreType][]string{
+	types.StoreTypeDeployment: {consulutil.CommonsTypesKVPrefix, consulutil.DeploymentKVPrefix},
+	types.StoreTypeLog:        {consulutil.LogsPrefix},
+	types.StoreTypeEvent:      {consulutil.EventsPrefix},
+}
+
 // default config stores loaded at init
 var defaultConfigStores map[string]config.Store
 
@@ -151,17 +173,21 @@ func LoadStores(cfg config.Configuration) error {
 
 		// load stores implementations
 		stores = make(map[types.StoreType]store.Store, 0)
+		storesImplementations = make(map[types.StoreType]string, 0)
+		storesByName = make(map[string]store.Store, 0)
 		for _, configStore := range cfgStores {
 			var storeImpl store.Store
 			storeImpl, err = createStoreImpl(cfg, configStore)
 			if err != nil {
 				return
 			}
+			storesByName[configStore.Name] = storeImpl
 			for _, storeTypeName := range configStore.Types {
 				st, _ := types.ParseStoreType(storeTypeName)
 				if _, ok := stores[st]; !ok {
 					log.Printf("Using store with name:%q, implementation:%q for type: %q", configStore.Name, configStore.Implementation, storeTypeName)
 					stores[st] = storeImpl
+					storesImplementations[st] = strings.ToLower(configStore.Implementation)
 
 					// Handle Consul data migration for log/event stores
 					if configStore.MigrateDataFromConsul && init && configStore.Implementation != consulStoreImpl {
@@ -237,6 +263,13 @@ func initConfigStores(cfg config.Configuration) ([]config.Store, error) {
 	return cfgStores, nil
 }
 
+// CloseStores releases resources held by stores implementations, like opened database files.
+//
+// It should be called on shutdown.
+func CloseStores() error {
+	return bolt.CloseDatabases()
+}
+
 // Clear config stores in Consul
 func clearConfigStore() error {
 	return consulutil.Delete(consulutil.StoresPrefix, true)
@@ -310,11 +343,7 @@ func checkAndBuildConfigStores(cfg config.Configuration) ([]config.Store, error)
 			return nil, errors.Errorf("At least, 2 different stores have the same name:%q", cfgStore.Name)
 		}
 
-		// Complete store properties with default for fileCache implementation
-		if cfgStore.Implementation == fileStoreWithCacheAndEncryptionImpl || cfgStore.Implementation == fileStoreWithCacheImpl || cfgStore.Implementation == fileStoreImpl {
-			props := completePropertiesWithDefault(cfg, cfgStore.Properties)
-			cfgStore.Properties = props
-		}
+		cfgStore = completeStoreProperties(cfg, cfgStore)
 
 		checkStoreNames = append(checkStoreNames, cfgStore.Name)
 
@@ -346,6 +375,15 @@ func checkAndBuildConfigStores(cfg config.Configuration) ([]config.Store, error)
 	return cfgStores, nil
 }
 
+// completeStoreProperties completes store properties with default for file and bolt implementations
+func completeStoreProperties(cfg config.Configuration, cfgStore config.Store) config.Store {
+	switch cfgStore.Implementation {
+	case fileStoreWithCacheAndEncryptionImpl, fileStoreWithCacheImpl, fileStoreImpl, boltStoreImpl, boltStoreWithEncryptionImpl:
+		cfgStore.Properties = completePropertiesWithDefault(cfg, cfgStore.Properties)
+	}
+	return cfgStore
+}
+
 // Create store implementations
 func createStoreImpl(cfg config.Configuration, configStore config.Store) (store.Store, error) {
 	var storeImpl store.Store
@@ -362,10 +400,28 @@ func createStoreImpl(cfg config.Configuration, configStore config.Store) (store.
 		if err != nil {
 			return nil, err
 		}
+	case strings.ToLower(boltStoreImpl), strings.ToLower(boltStoreWithEncryptionImpl):
+		storeImpl, err = bolt.NewStore(cfg, configStore.Name, configStore.Properties, impl == strings.ToLower(boltStoreWithEncryptionImpl))
+		if err != nil {
+			return nil, err
+		}
 	case strings.ToLower(consulStoreImpl):
 		storeImpl = consul.NewStore()
-	case strings.ToLower(elasticStoreImpl):
-		storeImpl, err = elastic.NewStore(cfg, configStore)
+	case strings.ToLower(consulStoreWithEncryptionImpl):
+		prefixes := make([]string, 0)
+		for _, storeTypeName := range configStore.Types {
+			st, err := types.ParseStoreType(storeTypeName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid type for store with name:%q", configStore.Name)
+			}
+			prefixes = append(prefixes, typesPrefixes[st]...)
+		}
+		storeImpl, err = consul.NewCipherStore(configStore.Name, configStore.Properties, prefixes)
+		if err != nil {
+			return nil, err
+		}
+	case strings.ToLower(elasticStoreImpl), strings.ToLower(elasticStoreWithEncryptionImpl):
+		storeImpl, err = elastic.NewStore(cfg, configStore, impl == strings.ToLower(elasticStoreWithEncryptionImpl))
 		if err != nil {
 			return nil, err
 		}
@@ -421,3 +477,71 @@ func GetStore(tType types.StoreType) store.Store {
 	}
 	return store
 }
+
+// GetStoreImplementation returns the implementation name of the store related to a defined store type
+func GetStoreImplementation(tType types.StoreType) string {
+	return storesImplementations[tType]
+}
+
+// IsConsulStore returns true if the store related to a defined store type keeps its data into the Consul KV store
+func IsConsulStore(tType types.StoreType) bool {
+	return storesImplementations[tType] == strings.ToLower(consulStoreImpl)
+}
+
+type storeNotFoundError struct {
+	name string
+}
+
+func (e storeNotFoundError) Error() string {
+	return fmt.Sprintf("no store configuration found with name:%q", e.name)
+}
+
+// IsStoreNotFoundError checks if an error is due to an unknown store name
+func IsStoreNotFoundError(err error) bool {
+	_, ok := errors.Cause(err).(storeNotFoundError)
+	return ok
+}
+
+// NewStoreByName returns a new instance of the store having the given name.
+//
+// The store configuration is looked up into stores configurations saved in Consul, then into stores
+// of the given configuration and finally into default stores configurations.
+// An error checkable with IsStoreNotFoundError is returned if there is no store with this name.
+func NewStoreByName(cfg config.Configuration, name string) (store.Store, error) {
+	cfgStore, err := getConfigStoreByName(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	storeImpl, err := createStoreImpl(cfg, cfgStore)
+	if err != nil {
+		return nil, err
+	}
+	if storeImpl == nil {
+		return nil, errors.Errorf("unknown implementation %q for store with name:%q", cfgStore.Implementation, name)
+	}
+	return storeImpl, nil
+}
+
+func getConfigStoreByName(cfg config.Configuration, name string) (config.Store, error) {
+	kvp, _, err := consulutil.GetKV().Get(path.Join(consulutil.StoresPrefix, name), nil)
+	if err != nil {
+		return config.Store{}, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if kvp != nil {
+		configStore := config.Store{}
+		err = json.Unmarshal(kvp.Value, &configStore)
+		return configStore, errors.Wrapf(err, "failed to unmarshal store with name:%q", name)
+	}
+	for _, cfgStore := range cfg.Storage.Stores {
+		if cfgStore.Name == name {
+			return completeStoreProperties(cfg, cfgStore), nil
+		}
+	}
+	if defaultConfigStores == nil {
+		initDefaultConfigStores(cfg)
+	}
+	if cfgStore, ok := defaultConfigStores[name]; ok {
+		return cfgStore, nil
+	}
+	return config.Store{}, errors.WithStack(storeNotFoundError{name})
+}
@@ -15,6 +15,7 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path"
@@ -50,6 +51,9 @@ func TestRunConsulStoragePackageTests(t *testing.T) {
 		t.Run("testLoadStoresWithPartialStorageConfig2", func(t *testing.T) {
 			testLoadStoresWithPartialStorageConfig2(t, srv, cfg)
 		})
+		t.Run("testLoadStoresWithBoltStoreAndMigration", func(t *testing.T) {
+			testLoadStoresWithBoltStoreAndMigration(t, srv, cfg)
+		})
 		t.Run("testLoadStoresWithMissingPassphraseForCipherFileCache", func(t *testing.T) {
 			testLoadStoresWithMissingPassphraseForCipherFileCache(t, srv, cfg)
 		})
@@ -59,6 +63,9 @@ func TestRunConsulStoragePackageTests(t *testing.T) {
 		t.Run("testLoadStoresWithGeneratedName", func(t *testing.T) {
 			testLoadStoresWithGeneratedName(t, srv, cfg)
 		})
+		t.Run("testRotateKey", func(t *testing.T) {
+			testRotateKey(t, srv, cfg)
+		})
 	})
 }
 
@@ -205,6 +212,55 @@ func testLoadStoresWithPartialStorageConfig2(t *testing.T, srv1 *testutil.TestSe
 	}
 }
 
+func testLoadStoresWithBoltStoreAndMigration(t *testing.T, srv1 *testutil.TestServer, cfg config.Configuration) {
+	// Reset once to allow reload config
+	once.Reset()
+
+	deploymentID := t.Name()
+	b, err := json.Marshal(map[string]string{"key1": "content1"})
+	require.NoError(t, err)
+
+	logsPrefix := path.Join(consulutil.LogsPrefix, deploymentID)
+	eventsPrefix := path.Join(consulutil.EventsPrefix, deploymentID)
+	srv1.PopulateKV(t, map[string][]byte{
+		path.Join(logsPrefix, "log0001"):     b,
+		path.Join(logsPrefix, "log0002"):     b,
+		path.Join(eventsPrefix, "event0001"): b,
+	})
+
+	myStore := config.Store{
+		Name:                  "myBoltStore",
+		MigrateDataFromConsul: true,
+		Implementation:        "bolt",
+		Types:                 []string{"Log", "Event"},
+	}
+	cfg.Storage = config.Storage{
+		Reset:  true,
+		Stores: []config.Store{myStore},
+	}
+
+	err = LoadStores(cfg)
+	require.NoError(t, err)
+
+	// Migrated data are listed from the bolt store and removed from Consul
+	kvs, index, err := GetStore(types.StoreTypeLog).List(context.Background(), logsPrefix, 0, 0)
+	require.NoError(t, err)
+	require.NotZero(t, index)
+	require.Len(t, kvs, 2)
+	for _, kv := range kvs {
+		require.Equal(t, "content1", kv.Value["key1"])
+	}
+	value := make(map[string]string)
+	exist, err := GetStore(types.StoreTypeEvent).Get(path.Join(eventsPrefix, "event0001"), &value)
+	require.NoError(t, err)
+	require.True(t, exist)
+	require.Equal(t, "content1", value["key1"])
+
+	consulKeys, err := consulutil.List(logsPrefix)
+	require.NoError(t, err)
+	require.Len(t, consulKeys, 0)
+}
+
 func testLoadStoresWithPartialStorageConfig(t *testing.T, srv1 *testutil.TestServer, cfg config.Configuration) {
 	// Reset once to allow reload config
 	once.Reset()
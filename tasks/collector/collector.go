@@ -53,6 +53,8 @@ func NewCollector(consulClient *api.Client) *Collector {
 
 // RegisterTaskWithData register a new Task of a given type with some data
 //
+// The priority of the task may be set using the tasks.PriorityDataKey data, otherwise the default
+// priority of the task type is used. The creator of the task may be set using the tasks.CreatorDataKey data.
 // The task id is returned.
 func (c *Collector) RegisterTaskWithData(targetID string, taskType tasks.TaskType, data map[string]string) (string, error) {
 	return c.registerTask(targetID, taskType, data)
@@ -113,6 +115,10 @@ func (c *Collector) ResumeTask(ctx context.Context, taskID string) error {
 	if err != nil {
 		return err
 	}
+	err = tasks.IncrementTaskRetries(taskID)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to resume task with taskID;%q", taskID)
+	}
 
 	tasks.EmitTaskEventWithContextualLogs(ctx, targetID, taskID, taskType, workflowName, tasks.TaskStatusINITIAL.String())
 	return nil
@@ -151,6 +157,15 @@ func (c *Collector) registerTask(targetID string, taskType tasks.TaskType, data
 		}
 	}
 
+	priority := tasks.DefaultTaskPriority(taskType)
+	if p, ok := data[tasks.PriorityDataKey]; ok {
+		var err error
+		priority, err = tasks.ParseTaskPriorityName(p)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid priority for task of type %q on target %q", taskType.String(), targetID)
+		}
+	}
+
 	taskID := fmt.Sprint(uuid.NewV4())
 	taskPath := path.Join(consulutil.TasksPrefix, taskID)
 	creationDate, err := time.Now().MarshalBinary()
@@ -179,6 +194,18 @@ func (c *Collector) registerTask(targetID string, taskType tasks.TaskType, data
 			Key:   path.Join(taskPath, "creationDate"),
 			Value: creationDate,
 		},
+		&api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(taskPath, "priority"),
+			Value: []byte(strconv.Itoa(int(priority))),
+		},
+	}
+	if creator := data[tasks.CreatorDataKey]; creator != "" {
+		taskOps = append(taskOps, &api.KVTxnOp{
+			Verb:  api.KVSet,
+			Key:   path.Join(taskPath, "creator"),
+			Value: []byte(creator),
+		})
 	}
 
 	if tasks.IsDeploymentRelatedTask(taskType) {
@@ -193,6 +220,10 @@ func (c *Collector) registerTask(targetID string, taskType tasks.TaskType, data
 
 	if data != nil {
 		for k, v := range data {
+			if k == tasks.PriorityDataKey || k == tasks.CreatorDataKey {
+				// Stored as task properties
+				continue
+			}
 			taskOps = append(taskOps, &api.KVTxnOp{
 				Verb:  api.KVSet,
 				Key:   path.Join(taskPath, "data", k),
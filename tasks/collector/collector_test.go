@@ -101,3 +101,26 @@ func testRegisterTaskWithBigWorkflow(t *testing.T, client *api.Client) {
 	require.Error(t, err, "Expected to fail on consul error")
 
 }
+
+func testRegisterTaskWithPriority(t *testing.T, client *api.Client) {
+	testCollector := NewCollector(client)
+
+	// Default priority of the task type
+	taskID, err := testCollector.RegisterTask("infra/location", tasks.TaskTypeQuery)
+	require.NoError(t, err)
+	priority, err := tasks.GetTaskPriority(taskID)
+	require.NoError(t, err)
+	require.Equal(t, tasks.TaskPriorityLOW, priority)
+
+	// Priority given at registration
+	taskID, err = testCollector.RegisterTaskWithData("infra/location", tasks.TaskTypeQuery, map[string]string{tasks.PriorityDataKey: "high"})
+	require.NoError(t, err)
+	priority, err = tasks.GetTaskPriority(taskID)
+	require.NoError(t, err)
+	require.Equal(t, tasks.TaskPriorityHIGH, priority)
+	_, err = tasks.GetTaskData(taskID, tasks.PriorityDataKey)
+	require.True(t, tasks.IsTaskDataNotFoundError(err), "priority should not be stored as task data")
+
+	_, err = testCollector.RegisterTaskWithData("infra/location", tasks.TaskTypeQuery, map[string]string{tasks.PriorityDataKey: "urgent"})
+	require.Error(t, err)
+}
@@ -94,5 +94,8 @@ func TestRunConsulTasksPackageTests(t *testing.T) {
 		t.Run("TestCheckAndSetTaskErrorMessage", func(t *testing.T) {
 			testCheckAndSetTaskErrorMessage(t)
 		})
+		t.Run("testTaskTiming", func(t *testing.T) {
+			testTaskTiming(t)
+		})
 	})
 }
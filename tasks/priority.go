@@ -0,0 +1,77 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+)
+
+// PriorityDataKey is the key of task data allowing to set the priority of a task at registration.
+//
+// Its value should be the name of a TaskPriority (case insensitive).
+// If not set the default priority of the task type is used (see DefaultTaskPriority).
+const PriorityDataKey = "priority"
+
+// DefaultTaskPriority returns the priority of tasks of the given type when not specified at registration
+//
+// Actions (like asynchronous operations monitoring) and custom commands are short-lived executions
+// that users or running workflows wait for, so they have a high priority. Infrastructure usage queries
+// and drift checks have a low priority.
+func DefaultTaskPriority(taskType TaskType) TaskPriority {
+	switch taskType {
+	case TaskTypeAction, TaskTypeCustomCommand:
+		return TaskPriorityHIGH
+	case TaskTypeQuery, TaskTypeDriftCheck:
+		return TaskPriorityLOW
+	default:
+		return TaskPriorityNORMAL
+	}
+}
+
+// ParseTaskPriorityName converts a case insensitive priority name to a TaskPriority
+func ParseTaskPriorityName(name string) (TaskPriority, error) {
+	return ParseTaskPriority(strings.ToUpper(strings.TrimSpace(name)))
+}
+
+// GetTaskPriority retrieves the TaskPriority of a task
+//
+// Tasks registered without priority have the default priority of their type.
+func GetTaskPriority(taskID string) (TaskPriority, error) {
+	exist, value, err := consulutil.GetStringValue(path.Join(consulutil.TasksPrefix, taskID, "priority"))
+	if err != nil {
+		return TaskPriorityNORMAL, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if !exist || value == "" {
+		taskType, err := GetTaskType(taskID)
+		if err != nil {
+			return TaskPriorityNORMAL, err
+		}
+		return DefaultTaskPriority(taskType), nil
+	}
+	priorityInt, err := strconv.Atoi(value)
+	if err != nil {
+		return TaskPriorityNORMAL, errors.Wrapf(err, "Invalid priority for task with id %q", taskID)
+	}
+	if priorityInt < int(TaskPriorityLOW) || priorityInt > int(TaskPriorityHIGH) {
+		return TaskPriorityNORMAL, errors.Errorf("Invalid priority for task with id %q: %q", taskID, value)
+	}
+	return TaskPriority(priorityInt), nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTaskPriority(t *testing.T) {
+	tests := []struct {
+		taskType TaskType
+		want     TaskPriority
+	}{
+		{TaskTypeDeploy, TaskPriorityNORMAL},
+		{TaskTypeUnDeploy, TaskPriorityNORMAL},
+		{TaskTypeScaleOut, TaskPriorityNORMAL},
+		{TaskTypeCustomWorkflow, TaskPriorityNORMAL},
+		{TaskTypeCustomCommand, TaskPriorityHIGH},
+		{TaskTypeAction, TaskPriorityHIGH},
+		{TaskTypeQuery, TaskPriorityLOW},
+		{TaskTypeDriftCheck, TaskPriorityLOW},
+	}
+	for _, tt := range tests {
+		t.Run(tt.taskType.String(), func(t *testing.T) {
+			require.Equal(t, tt.want, DefaultTaskPriority(tt.taskType))
+		})
+	}
+}
+
+func TestParseTaskPriorityName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    TaskPriority
+		wantErr bool
+	}{
+		{"high", TaskPriorityHIGH, false},
+		{"Normal", TaskPriorityNORMAL, false},
+		{" LOW ", TaskPriorityLOW, false},
+		{"urgent", TaskPriorityLOW, true},
+		{"", TaskPriorityLOW, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTaskPriorityName(tt.name)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -31,6 +31,7 @@ Action
 ForcePurge // ForcePurge is deprecated and should not be used anymore this stay here to prevent task renumbering colision
 AddNodes
 RemoveNodes
+DriftCheck
 )
 */
 type TaskType int
@@ -47,6 +48,18 @@ CANCELED
 */
 type TaskStatus int
 
+// TaskPriority is an enumerated type for tasks priorities
+//
+// Executions of tasks with higher priorities are dispatched first to workers.
+/*
+ENUM(
+LOW
+NORMAL
+HIGH
+)
+*/
+type TaskPriority int
+
 // IsDeploymentRelatedTask returns true if the task is related to a deployment
 //
 // Typically query and action tasks are not necessary related to a deployment.
@@ -21,6 +21,45 @@ import (
 	"fmt"
 )
 
+const (
+	// TaskPriorityLOW is a TaskPriority of type LOW
+	TaskPriorityLOW TaskPriority = iota
+	// TaskPriorityNORMAL is a TaskPriority of type NORMAL
+	TaskPriorityNORMAL
+	// TaskPriorityHIGH is a TaskPriority of type HIGH
+	TaskPriorityHIGH
+)
+
+const _TaskPriorityName = "LOWNORMALHIGH"
+
+var _TaskPriorityMap = map[TaskPriority]string{
+	0: _TaskPriorityName[0:3],
+	1: _TaskPriorityName[3:9],
+	2: _TaskPriorityName[9:13],
+}
+
+// String implements the Stringer interface.
+func (x TaskPriority) String() string {
+	if str, ok := _TaskPriorityMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("TaskPriority(%d)", x)
+}
+
+var _TaskPriorityValue = map[string]TaskPriority{
+	_TaskPriorityName[0:3]:  0,
+	_TaskPriorityName[3:9]:  1,
+	_TaskPriorityName[9:13]: 2,
+}
+
+// ParseTaskPriority attempts to convert a string to a TaskPriority
+func ParseTaskPriority(name string) (TaskPriority, error) {
+	if x, ok := _TaskPriorityValue[name]; ok {
+		return x, nil
+	}
+	return TaskPriority(0), fmt.Errorf("%s is not a valid TaskPriority", name)
+}
+
 const (
 	// TaskStatusINITIAL is a TaskStatus of type INITIAL
 	TaskStatusINITIAL TaskStatus = iota
@@ -94,9 +133,11 @@ const (
 	TaskTypeAddNodes
 	// TaskTypeRemoveNodes is a TaskType of type RemoveNodes
 	TaskTypeRemoveNodes
+	// TaskTypeDriftCheck is a TaskType of type DriftCheck
+	TaskTypeDriftCheck
 )
 
-const _TaskTypeName = "DeployUnDeployScaleOutScaleInPurgeCustomCommandCustomWorkflowQueryActionForcePurgeAddNodesRemoveNodes"
+const _TaskTypeName = "DeployUnDeployScaleOutScaleInPurgeCustomCommandCustomWorkflowQueryActionForcePurgeAddNodesRemoveNodesDriftCheck"
 
 var _TaskTypeMap = map[TaskType]string{
 	0:  _TaskTypeName[0:6],
@@ -111,6 +152,7 @@ var _TaskTypeMap = map[TaskType]string{
 	9:  _TaskTypeName[72:82],
 	10: _TaskTypeName[82:90],
 	11: _TaskTypeName[90:101],
+	12: _TaskTypeName[101:111],
 }
 
 // String implements the Stringer interface.
@@ -122,18 +164,19 @@ func (x TaskType) String() string {
 }
 
 var _TaskTypeValue = map[string]TaskType{
-	_TaskTypeName[0:6]:    0,
-	_TaskTypeName[6:14]:   1,
-	_TaskTypeName[14:22]:  2,
-	_TaskTypeName[22:29]:  3,
-	_TaskTypeName[29:34]:  4,
-	_TaskTypeName[34:47]:  5,
-	_TaskTypeName[47:61]:  6,
-	_TaskTypeName[61:66]:  7,
-	_TaskTypeName[66:72]:  8,
-	_TaskTypeName[72:82]:  9,
-	_TaskTypeName[82:90]:  10,
-	_TaskTypeName[90:101]: 11,
+	_TaskTypeName[0:6]:     0,
+	_TaskTypeName[6:14]:    1,
+	_TaskTypeName[14:22]:   2,
+	_TaskTypeName[22:29]:   3,
+	_TaskTypeName[29:34]:   4,
+	_TaskTypeName[34:47]:   5,
+	_TaskTypeName[47:61]:   6,
+	_TaskTypeName[61:66]:   7,
+	_TaskTypeName[66:72]:   8,
+	_TaskTypeName[72:82]:   9,
+	_TaskTypeName[82:90]:   10,
+	_TaskTypeName[90:101]:  11,
+	_TaskTypeName[101:111]: 12,
 }
 
 // ParseTaskType attempts to convert a string to a TaskType
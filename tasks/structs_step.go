@@ -14,6 +14,8 @@
 
 package tasks
 
+import "time"
+
 //go:generate go-enum -f=structs_step.go --lower
 
 // TaskStepStatus is an enumerated type for tasks steps statuses
@@ -24,6 +26,7 @@ RUNNING
 DONE
 ERROR
 CANCELED
+WAITING
 )
 */
 type TaskStepStatus int
@@ -32,4 +35,12 @@ type TaskStepStatus int
 type TaskStep struct {
 	Name   string `json:"name"`
 	Status string `json:"status"`
+	// ServerID is the ID of the server that processed this step
+	ServerID string `json:"server_id,omitempty"`
+	// StartDate is the date of the last start of this step
+	StartDate *time.Time `json:"start_date,omitempty"`
+	// EndDate is the date of the end of this step
+	EndDate *time.Time `json:"end_date,omitempty"`
+	// Retries is the number of times this step was run again
+	Retries int `json:"retries,omitempty"`
 }
@@ -42,6 +42,12 @@ const (
 	// stepRegistrationInProgressKey is the Consul key name, whose presence means the
 	// new steps are being registered in Consul for a given task
 	stepRegistrationInProgressKey = "stepRegistrationInProgress"
+	// stepsInfoKey is the Consul key name under which are stored information about the steps
+	// of a given task, like the IDs of the servers that processed them
+	stepsInfoKey = "stepsInfo"
+	// serverIDKey is the Consul key name of a step information storing the ID of the server
+	// that processed this step
+	serverIDKey = "serverID"
 )
 
 func (e anotherLivingTaskAlreadyExistsError) Error() string {
@@ -229,7 +235,7 @@ func GetTaskType(taskID string) (TaskType, error) {
 	if err != nil {
 		return TaskTypeDeploy, errors.Wrapf(err, "Invalid task type:")
 	}
-	if typeInt < 0 || typeInt > int(TaskTypeRemoveNodes) {
+	if typeInt < 0 || typeInt > int(TaskTypeDriftCheck) {
 		return TaskTypeDeploy, errors.Errorf("Invalid type for task with id %q: %q", taskID, value)
 	}
 	return TaskType(typeInt), nil
@@ -488,7 +494,12 @@ func GetTaskRelatedSteps(taskID string) ([]TaskStep, error) {
 	for key, value := range kvs {
 		steps = append(steps, TaskStep{Name: path.Base(key), Status: string(value)})
 	}
-	return steps, nil
+	return steps, getTaskStepsInfo(taskID, steps)
+}
+
+// SetTaskStepServer stores the ID of the server processing the given task step
+func SetTaskStepServer(taskID, stepName, serverID string) error {
+	return consulutil.StoreConsulKeyAsString(path.Join(consulutil.TasksPrefix, taskID, stepsInfoKey, stepName, serverIDKey), serverID)
 }
 
 // GetTaskStepStatus returns the step status of the related step name
@@ -526,7 +537,15 @@ func UpdateTaskStepStatus(taskID string, step *TaskStep) error {
 
 // UpdateTaskStepWithStatus allows to update the task step status
 func UpdateTaskStepWithStatus(taskID, stepName string, status TaskStepStatus) error {
-	return consulutil.StoreConsulKeyAsString(path.Join(consulutil.WorkflowsPrefix, taskID, stepName), status.String())
+	err := consulutil.StoreConsulKeyAsString(path.Join(consulutil.WorkflowsPrefix, taskID, stepName), status.String())
+	if err != nil {
+		return err
+	}
+	switch status {
+	case TaskStepStatusDONE, TaskStepStatusERROR, TaskStepStatusCANCELED:
+		return notifyTaskStepEnd(taskID, stepName)
+	}
+	return nil
 }
 
 // CheckTaskStepStatusChange checks if a status change is allowed
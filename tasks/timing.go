@@ -0,0 +1,214 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/helper/consulutil"
+)
+
+// CreatorDataKey is the key of task data allowing to set the creator of a task at registration.
+//
+// Its value is stored as a task property and is not available as a task data.
+const CreatorDataKey = "creator"
+
+const (
+	creatorKey   = "creator"
+	startDateKey = "startDate"
+	endDateKey   = "endDate"
+	retriesKey   = "retries"
+)
+
+// TaskTiming holds provenance and timing information of a task
+type TaskTiming struct {
+	CreatedBy string
+	// CreationDate is the registration date of the task, it is nil for tasks registered without it
+	CreationDate *time.Time
+	// StartDate is the date of the first start of the task, it is nil if the task was not started yet
+	StartDate *time.Time
+	// EndDate is the date of the end of the task, it is nil if the task is not ended
+	EndDate *time.Time
+	// Retries is the number of times the task was resumed
+	Retries int
+}
+
+// GetTaskTiming retrieves provenance and timing information of a task
+func GetTaskTiming(taskID string) (TaskTiming, error) {
+	timing := TaskTiming{}
+	taskPath := path.Join(consulutil.TasksPrefix, taskID)
+	values := make(map[string][]byte)
+	for _, key := range []string{"creationDate", creatorKey, startDateKey, endDateKey, retriesKey} {
+		_, value, err := consulutil.GetValue(path.Join(taskPath, key))
+		if err != nil {
+			return timing, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		values[key] = value
+	}
+	if len(values["creationDate"]) > 0 {
+		creationDate := time.Time{}
+		err := creationDate.UnmarshalBinary(values["creationDate"])
+		if err != nil {
+			return timing, errors.Wrapf(err, "invalid creation date for task %q", taskID)
+		}
+		timing.CreationDate = &creationDate
+	}
+	timing.CreatedBy = string(values[creatorKey])
+	var err error
+	timing.StartDate, err = parseDate(values[startDateKey])
+	if err != nil {
+		return timing, errors.Wrapf(err, "invalid start date for task %q", taskID)
+	}
+	timing.EndDate, err = parseDate(values[endDateKey])
+	if err != nil {
+		return timing, errors.Wrapf(err, "invalid end date for task %q", taskID)
+	}
+	timing.Retries, err = parseRetries(values[retriesKey])
+	return timing, errors.Wrapf(err, "invalid retries number for task %q", taskID)
+}
+
+// NotifyTaskStatusChange records timing information of a task according to its new status
+//
+// The start date is set the first time the task runs and the end date is set when the task reaches
+// a final status. It is removed if the task runs again (when resumed).
+func NotifyTaskStatusChange(taskID string, status TaskStatus) error {
+	taskPath := path.Join(consulutil.TasksPrefix, taskID)
+	switch status {
+	case TaskStatusRUNNING:
+		exist, _, err := consulutil.GetValue(path.Join(taskPath, startDateKey))
+		if err != nil {
+			return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		if !exist {
+			err = storeDate(path.Join(taskPath, startDateKey), time.Now())
+			if err != nil {
+				return err
+			}
+		}
+		return errors.Wrap(consulutil.Delete(path.Join(taskPath, endDateKey), false), consulutil.ConsulGenericErrMsg)
+	case TaskStatusDONE, TaskStatusFAILED, TaskStatusCANCELED:
+		return storeDate(path.Join(taskPath, endDateKey), time.Now())
+	}
+	return nil
+}
+
+// IncrementTaskRetries increments the number of times a task was resumed
+func IncrementTaskRetries(taskID string) error {
+	return incrementRetries(path.Join(consulutil.TasksPrefix, taskID, retriesKey))
+}
+
+// NotifyTaskStepStart records that a task step starts to be processed by the given server
+//
+// If the step already started before, its number of retries is incremented.
+func NotifyTaskStepStart(taskID, stepName, serverID string) error {
+	stepPath := path.Join(consulutil.TasksPrefix, taskID, stepsInfoKey, stepName)
+	exist, _, err := consulutil.GetValue(path.Join(stepPath, startDateKey))
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if exist {
+		err = incrementRetries(path.Join(stepPath, retriesKey))
+		if err != nil {
+			return err
+		}
+	}
+	err = consulutil.Delete(path.Join(stepPath, endDateKey), false)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	err = SetTaskStepServer(taskID, stepName, serverID)
+	if err != nil {
+		return err
+	}
+	return storeDate(path.Join(stepPath, startDateKey), time.Now())
+}
+
+// notifyTaskStepEnd records the end date of a task step if it was started
+func notifyTaskStepEnd(taskID, stepName string) error {
+	stepPath := path.Join(consulutil.TasksPrefix, taskID, stepsInfoKey, stepName)
+	exist, _, err := consulutil.GetValue(path.Join(stepPath, startDateKey))
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	if !exist {
+		return nil
+	}
+	return storeDate(path.Join(stepPath, endDateKey), time.Now())
+}
+
+// getTaskStepsInfo fills the given steps with their timing information and the server that processed them
+func getTaskStepsInfo(taskID string, steps []TaskStep) error {
+	stepsPath := path.Join(consulutil.TasksPrefix, taskID, stepsInfoKey)
+	kvs, err := consulutil.List(stepsPath + "/")
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	for i := range steps {
+		stepPath := path.Join(stepsPath, steps[i].Name)
+		steps[i].ServerID = string(kvs[path.Join(stepPath, serverIDKey)])
+		steps[i].StartDate, err = parseDate(kvs[path.Join(stepPath, startDateKey)])
+		if err != nil {
+			return errors.Wrapf(err, "invalid start date for step %q of task %q", steps[i].Name, taskID)
+		}
+		steps[i].EndDate, err = parseDate(kvs[path.Join(stepPath, endDateKey)])
+		if err != nil {
+			return errors.Wrapf(err, "invalid end date for step %q of task %q", steps[i].Name, taskID)
+		}
+		steps[i].Retries, err = parseRetries(kvs[path.Join(stepPath, retriesKey)])
+		if err != nil {
+			return errors.Wrapf(err, "invalid retries number for step %q of task %q", steps[i].Name, taskID)
+		}
+	}
+	return nil
+}
+
+func storeDate(key string, date time.Time) error {
+	return consulutil.StoreConsulKeyAsString(key, date.Format(time.RFC3339Nano))
+}
+
+func parseDate(value []byte) (*time.Time, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	date, err := time.Parse(time.RFC3339Nano, string(value))
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func incrementRetries(key string) error {
+	_, value, err := consulutil.GetValue(key)
+	if err != nil {
+		return errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+	}
+	retries, err := parseRetries(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid retries number for key %q", key)
+	}
+	return consulutil.StoreConsulKeyAsString(key, strconv.Itoa(retries+1))
+}
+
+func parseRetries(value []byte) (int, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	return strconv.Atoi(strings.TrimSpace(string(value)))
+}
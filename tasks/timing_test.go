@@ -0,0 +1,108 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	date, err := parseDate(nil)
+	require.NoError(t, err)
+	assert.Nil(t, date)
+
+	now := time.Now()
+	date, err = parseDate([]byte(now.Format(time.RFC3339Nano)))
+	require.NoError(t, err)
+	require.NotNil(t, date)
+	assert.True(t, now.Equal(*date))
+
+	_, err = parseDate([]byte("not a date"))
+	assert.Error(t, err)
+}
+
+func TestParseRetries(t *testing.T) {
+	retries, err := parseRetries(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, retries)
+
+	retries, err = parseRetries([]byte("3"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, retries)
+
+	_, err = parseRetries([]byte("three"))
+	assert.Error(t, err)
+}
+
+func testTaskTiming(t *testing.T) {
+	taskID := "taskTiming"
+	timing, err := GetTaskTiming(taskID)
+	require.NoError(t, err)
+	assert.Nil(t, timing.CreationDate)
+	assert.Nil(t, timing.StartDate)
+	assert.Nil(t, timing.EndDate)
+
+	require.NoError(t, NotifyTaskStatusChange(taskID, TaskStatusRUNNING))
+	timing, err = GetTaskTiming(taskID)
+	require.NoError(t, err)
+	require.NotNil(t, timing.StartDate)
+	assert.Nil(t, timing.EndDate)
+	firstStart := *timing.StartDate
+
+	require.NoError(t, UpdateTaskStepWithStatus(taskID, "step1", TaskStepStatusINITIAL))
+	require.NoError(t, UpdateTaskStepWithStatus(taskID, "step2", TaskStepStatusINITIAL))
+	require.NoError(t, NotifyTaskStepStart(taskID, "step1", "server1"))
+	require.NoError(t, UpdateTaskStepWithStatus(taskID, "step1", TaskStepStatusERROR))
+	// Skipped step without start
+	require.NoError(t, UpdateTaskStepWithStatus(taskID, "step2", TaskStepStatusDONE))
+	require.NoError(t, NotifyTaskStatusChange(taskID, TaskStatusFAILED))
+
+	timing, err = GetTaskTiming(taskID)
+	require.NoError(t, err)
+	require.NotNil(t, timing.EndDate)
+
+	// Resume the task and rerun the failed step on another server
+	require.NoError(t, IncrementTaskRetries(taskID))
+	require.NoError(t, NotifyTaskStatusChange(taskID, TaskStatusRUNNING))
+	require.NoError(t, NotifyTaskStepStart(taskID, "step1", "server2"))
+
+	timing, err = GetTaskTiming(taskID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, timing.Retries)
+	require.NotNil(t, timing.StartDate)
+	assert.True(t, firstStart.Equal(*timing.StartDate), "start date should be kept when the task is resumed")
+	assert.Nil(t, timing.EndDate)
+
+	steps, err := GetTaskRelatedSteps(taskID)
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	for _, step := range steps {
+		switch step.Name {
+		case "step1":
+			assert.Equal(t, "server2", step.ServerID)
+			assert.Equal(t, 1, step.Retries)
+			assert.NotNil(t, step.StartDate)
+			assert.Nil(t, step.EndDate)
+		case "step2":
+			assert.Equal(t, "", step.ServerID)
+			assert.Nil(t, step.StartDate)
+			assert.Nil(t, step.EndDate)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/locations"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// LocationRequiredServerTagsProperty is the location property defining the tags a Yorc server should
+// advertise to process task executions on this location
+const LocationRequiredServerTagsProperty = "required_server_tags"
+
+// getLocationProperties returns properties of the location having the given name
+func getLocationProperties(cfg config.Configuration, locationName string) (config.DynamicMap, bool, error) {
+	locationMgr, err := locations.GetManager(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	locationsConfigs, err := locationMgr.GetLocations()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, l := range locationsConfigs {
+		if l.Name == locationName {
+			return l.Properties, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// taskAffinity caches what is needed to check if the executions of a task could be processed by this server,
+// so that the task workflow and the locations of its nodes are not read again for each pending execution
+type taskAffinity struct {
+	taskType tasks.TaskType
+	// node is the node targeted by a custom command task
+	node string
+	// stepsTargets are the nodes targeted by the steps of a workflow task
+	stepsTargets map[string]string
+	// requiredTags are the server tags required to process operations on nodes
+	requiredTags map[string][]string
+}
+
+func newTaskAffinity(ctx context.Context, taskID, targetID string) (*taskAffinity, error) {
+	taskType, err := tasks.GetTaskType(taskID)
+	if err != nil {
+		return nil, err
+	}
+	a := &taskAffinity{taskType: taskType, requiredTags: make(map[string][]string)}
+	if taskType == tasks.TaskTypeCustomCommand {
+		nodes, err := tasks.GetTaskRelatedNodes(taskID)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) == 1 {
+			a.node = nodes[0]
+		}
+		return a, nil
+	}
+	if !tasks.IsWorkflowTask(taskType) {
+		return a, nil
+	}
+	workflowName, err := tasks.GetTaskData(taskID, "workflowName")
+	if err != nil {
+		return nil, err
+	}
+	wf, err := deployments.GetWorkflow(ctx, targetID, workflowName)
+	if err != nil {
+		return nil, err
+	}
+	a.stepsTargets = make(map[string]string)
+	if wf != nil {
+		for stepName, step := range wf.Steps {
+			if step != nil {
+				a.stepsTargets[stepName] = step.Target
+			}
+		}
+	}
+	return a, nil
+}
+
+// executionNode returns the node targeted by the given task execution or an empty string if it doesn't target a specific node
+func (a *taskAffinity) executionNode(execID string) (string, error) {
+	if !tasks.IsWorkflowTask(a.taskType) {
+		return a.node, nil
+	}
+	stepName, err := getExecutionKeyValue(execID, "step")
+	if err != nil {
+		return "", err
+	}
+	return a.stepsTargets[stepName], nil
+}
+
+// getRequiredServerTags returns the tags a server should advertise to process operations on the given node
+func getRequiredServerTags(ctx context.Context, cfg config.Configuration, deploymentID, nodeName string) ([]string, error) {
+	if nodeName == "" {
+		return nil, nil
+	}
+	locationName, err := deployments.GetNodeLocationName(ctx, deploymentID, nodeName)
+	if err != nil || locationName == "" {
+		return nil, err
+	}
+	props, found, err := getLocationProperties(cfg, locationName)
+	if err != nil || !found {
+		return nil, err
+	}
+	return props.GetStringSlice(LocationRequiredServerTagsProperty), nil
+}
+
+// matchServerTags checks if all required tags are advertised by the server
+func matchServerTags(serverTags, requiredTags []string) bool {
+	for _, required := range requiredTags {
+		found := false
+		for _, tag := range serverTags {
+			if tag == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// isExecutionAllowedOnServer checks if the given task execution could be processed by this server
+// according to tags required by the location of its target node
+func (a *taskAffinity) isExecutionAllowedOnServer(ctx context.Context, cfg config.Configuration, execID, targetID string) (bool, error) {
+	nodeName, err := a.executionNode(execID)
+	if err != nil {
+		return false, err
+	}
+	requiredTags, ok := a.requiredTags[nodeName]
+	if !ok {
+		requiredTags, err = getRequiredServerTags(ctx, cfg, targetID, nodeName)
+		if err != nil {
+			return false, err
+		}
+		a.requiredTags[nodeName] = requiredTags
+	}
+	return matchServerTags(cfg.ServerTags, requiredTags), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchServerTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverTags   []string
+		requiredTags []string
+		want         bool
+	}{
+		{"NoRequiredTags", []string{"site-a"}, nil, true},
+		{"NoTags", nil, nil, true},
+		{"AllTagsMatch", []string{"site-a", "slurm"}, []string{"slurm", "site-a"}, true},
+		{"SubsetOfServerTags", []string{"site-a", "slurm", "gpu"}, []string{"site-a"}, true},
+		{"MissingTag", []string{"site-a"}, []string{"site-a", "slurm"}, false},
+		{"ServerWithoutTags", nil, []string{"site-b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchServerTags(tt.serverTags, tt.requiredTags))
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/registry"
+)
+
+// LocationMaxConcurrentOperationsProperty is the location property defining the maximum number
+// of operations that could be run at the same time on a location across the Yorc cluster
+const LocationMaxConcurrentOperationsProperty = "max_concurrent_operations"
+
+// concurrencyLimitsPrefix is the Consul prefix under which are stored semaphores used to enforce concurrency limits
+var concurrencyLimitsPrefix = path.Join(consulutil.YorcManagementPrefix, "concurrency_limits")
+
+// concurrencyLimitWaitNotificationDelay is the delay after which an operation that didn't get a slot yet is
+// considered as waiting for it
+const concurrencyLimitWaitNotificationDelay = time.Second
+
+type concurrencyLimit struct {
+	kind  string
+	name  string
+	limit int
+}
+
+func (l concurrencyLimit) String() string {
+	return fmt.Sprintf("%s %q (limited to %d concurrent operations)", l.kind, l.name, l.limit)
+}
+
+func (l concurrencyLimit) prefix() string {
+	return path.Join(concurrencyLimitsPrefix, l.kind+"s", url.PathEscape(l.name))
+}
+
+// getExecutorConcurrencyLimits returns concurrency limits configured for the given executor.
+//
+// The executor of a configured limit is the one registered under the configured node type match or implementation
+// artifact type. Limits are keyed on this configured name rather than on the match used to run an operation,
+// so an executor registered under several overlapping matches shares the same slots.
+func getExecutorConcurrencyLimits(cfg config.Configuration, executor interface{}) []concurrencyLimit {
+	limits := make([]concurrencyLimit, 0)
+	for _, l := range cfg.Tasks.ExecutorsConcurrencyLimits {
+		if l.MaxConcurrentOperations > 0 && isExecutorRegisteredAs(executor, l.Executor) {
+			limits = append(limits, concurrencyLimit{kind: "executor", name: l.Executor, limit: l.MaxConcurrentOperations})
+		}
+	}
+	return limits
+}
+
+// isExecutorRegisteredAs checks if an executor is registered under the given node type match or implementation artifact type
+func isExecutorRegisteredAs(executor interface{}, name string) bool {
+	reg := registry.GetRegistry()
+	for _, d := range reg.ListDelegateExecutors() {
+		if d.Match == name && isSameExecutor(d.Executor, executor) {
+			return true
+		}
+	}
+	for _, o := range reg.ListOperationExecutors() {
+		if o.Artifact == name && isSameExecutor(o.Executor, executor) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSameExecutor checks if two executors are the same instance, executors of non comparable types are never the same
+func isSameExecutor(e1, e2 interface{}) bool {
+	t := reflect.TypeOf(e1)
+	return t != nil && t == reflect.TypeOf(e2) && t.Comparable() && e1 == e2
+}
+
+// getConcurrencyLimits returns concurrency limits applying to an operation run by the given executor on the given node.
+//
+// Location limits are returned before executor limits so that slots are always acquired in the same order.
+func getConcurrencyLimits(ctx context.Context, cfg config.Configuration, deploymentID, nodeName string, executor interface{}) ([]concurrencyLimit, error) {
+	limits := make([]concurrencyLimit, 0)
+	locationName, err := deployments.GetNodeLocationName(ctx, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if locationName != "" {
+		props, found, err := getLocationProperties(cfg, locationName)
+		if err != nil {
+			return nil, err
+		}
+		if max := props.GetInt(LocationMaxConcurrentOperationsProperty); found && max > 0 {
+			limits = append(limits, concurrencyLimit{kind: "location", name: locationName, limit: max})
+		}
+	}
+	return append(limits, getExecutorConcurrencyLimits(cfg, executor)...), nil
+}
+
+// acquireConcurrencySlots acquires a slot for each given concurrency limit.
+//
+// onWait is called with true if a slot can't be acquired immediately and with false once all slots are acquired after having waited.
+// The returned function should be called to release acquired slots.
+func acquireConcurrencySlots(ctx context.Context, cc *api.Client, deploymentID string, limits []concurrencyLimit, onWait func(waiting bool)) (func(), error) {
+	semaphores := make([]*api.Semaphore, 0, len(limits))
+	release := func() {
+		for i := len(semaphores) - 1; i >= 0; i-- {
+			if err := semaphores[i].Release(); err != nil {
+				log.Printf("[WARNING] failed to release concurrency slot %q: %v", limits[i].prefix(), err)
+			}
+		}
+	}
+	var waited bool
+	for _, l := range limits {
+		sem, err := cc.SemaphoreOpts(&api.SemaphoreOptions{
+			Prefix: l.prefix(),
+			Limit:  l.limit,
+		})
+		if err != nil {
+			release()
+			return nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
+		}
+		l := l
+		waiting := make(chan struct{})
+		timer := time.AfterFunc(concurrencyLimitWaitNotificationDelay, func() {
+			defer close(waiting)
+			if !waited {
+				onWait(true)
+			}
+			events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelINFO, deploymentID).Registerf("Waiting for a free slot on %s", l)
+		})
+		lostCh, err := sem.Acquire(ctx.Done())
+		if !timer.Stop() {
+			<-waiting
+			waited = true
+		}
+		if err != nil {
+			release()
+			return nil, errors.Wrapf(err, "failed to acquire a slot on %s", l)
+		}
+		if lostCh == nil {
+			// Acquisition was stopped by the context
+			release()
+			return nil, errors.Wrapf(ctx.Err(), "failed to acquire a slot on %s", l)
+		}
+		semaphores = append(semaphores, sem)
+	}
+	if waited {
+		onWait(false)
+	}
+	return release, nil
+}
+
+// runWithConcurrencyLimits runs the given function once a slot is acquired for every concurrency limit
+// applying to an operation run by the given executor on the given node.
+func runWithConcurrencyLimits(ctx context.Context, cc *api.Client, cfg config.Configuration, deploymentID, nodeName string, executor interface{}, onWait func(waiting bool), f func() error) error {
+	limits, err := getConcurrencyLimits(ctx, cfg, deploymentID, nodeName, executor)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return f()
+	}
+	release, err := acquireConcurrencySlots(ctx, cc, deploymentID, limits, onWait)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return f()
+}
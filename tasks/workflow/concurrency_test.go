@@ -0,0 +1,119 @@
+// Copyright 2018 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/registry"
+)
+
+func TestGetExecutorConcurrencyLimits(t *testing.T) {
+	artifactExecutor := &mockExecutor{}
+	delegateExecutor := &mockExecutor{}
+	disabledExecutor := &mockExecutor{}
+	otherExecutor := &mockExecutor{}
+	reg := registry.GetRegistry()
+	reg.RegisterOperationExecutor([]string{"ystia.yorc.tests.concurrency.Artifact"}, artifactExecutor, "concurrencyTests")
+	// Overlapping matches of the same executor
+	reg.RegisterDelegates([]string{`ystia\.yorc\.tests\.concurrency\..*`, `ystia\.yorc\.tests\.concurrency\.Compute`}, delegateExecutor, "concurrencyTests")
+	reg.RegisterDelegates([]string{`ystia\.yorc\.tests\.concurrency\.Disabled`}, disabledExecutor, "concurrencyTests")
+	defer reg.UnregisterOrigin("concurrencyTests")
+
+	cfg := config.Configuration{Tasks: config.Tasks{ExecutorsConcurrencyLimits: []config.ExecutorConcurrencyLimit{
+		{Executor: "ystia.yorc.tests.concurrency.Artifact", MaxConcurrentOperations: 3},
+		{Executor: `ystia\.yorc\.tests\.concurrency\..*`, MaxConcurrentOperations: 5},
+		{Executor: `ystia\.yorc\.tests\.concurrency\.Disabled`, MaxConcurrentOperations: 0},
+	}}}
+	tests := []struct {
+		name     string
+		executor interface{}
+		want     []concurrencyLimit
+	}{
+		{"ArtifactLimit", artifactExecutor, []concurrencyLimit{{kind: "executor", name: "ystia.yorc.tests.concurrency.Artifact", limit: 3}}},
+		{"DelegateLimit", delegateExecutor, []concurrencyLimit{{kind: "executor", name: `ystia\.yorc\.tests\.concurrency\..*`, limit: 5}}},
+		{"DisabledLimit", disabledExecutor, []concurrencyLimit{}},
+		{"NoLimit", otherExecutor, []concurrencyLimit{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getExecutorConcurrencyLimits(cfg, tt.executor))
+		})
+	}
+}
+
+func TestConcurrencyLimitPrefix(t *testing.T) {
+	l := concurrencyLimit{kind: "executor", name: "yorc/nodes/openstack.*", limit: 1}
+	assert.Equal(t, concurrencyLimitsPrefix+"/executors/yorc%2Fnodes%2Fopenstack.%2A", l.prefix())
+}
+
+func testAcquireConcurrencySlots(t *testing.T, client *api.Client) {
+	limits := []concurrencyLimit{
+		{kind: "location", name: "testLocation", limit: 2},
+		{kind: "executor", name: "testExecutor", limit: 1},
+	}
+	ctx := context.Background()
+	release, err := acquireConcurrencySlots(ctx, client, "testDeployment", limits, func(bool) {
+		t.Error("first acquisition should not wait")
+	})
+	require.NoError(t, err)
+
+	waitNotifications := make(chan bool, 2)
+	acquired := make(chan func(), 1)
+	go func() {
+		release2, err := acquireConcurrencySlots(ctx, client, "testDeployment", limits, func(waiting bool) {
+			waitNotifications <- waiting
+		})
+		assert.NoError(t, err)
+		acquired <- release2
+	}()
+
+	select {
+	case waiting := <-waitNotifications:
+		assert.True(t, waiting)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "second acquisition should wait for a free slot")
+	}
+	select {
+	case <-acquired:
+		require.Fail(t, "second acquisition should not succeed before the first one is released")
+	default:
+	}
+
+	release()
+	select {
+	case release2 := <-acquired:
+		assert.False(t, <-waitNotifications)
+		release2()
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "second acquisition should succeed after the first one is released")
+	}
+
+	// Acquisition is canceled with the context
+	release, err = acquireConcurrencySlots(ctx, client, "testDeployment", limits, func(bool) {})
+	require.NoError(t, err)
+	defer release()
+	cancelCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err = acquireConcurrencySlots(cancelCtx, client, "testDeployment", limits, func(bool) {})
+	require.Error(t, err)
+}
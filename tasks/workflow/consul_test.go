@@ -54,6 +54,9 @@ func TestRunConsulWorkflowPackageTests(t *testing.T) {
 		t.Run("testDeleteTaskExecutionSamePrefix", func(t *testing.T) {
 			testDeleteTaskExecutionSamePrefix(t, client)
 		})
+		t.Run("testGetPendingInvalidExecutions", func(t *testing.T) {
+			testGetPendingInvalidExecutions(t, client)
+		})
 		t.Run("testDispatcherRun", func(t *testing.T) {
 			testDispatcherRun(t, srv, client)
 		})
@@ -63,6 +66,9 @@ func TestRunConsulWorkflowPackageTests(t *testing.T) {
 		t.Run("testWorkflowOutputs", func(t *testing.T) {
 			testWorkflowOutputs(t, srv, client)
 		})
+		t.Run("testAcquireConcurrencySlots", func(t *testing.T) {
+			testAcquireConcurrencySlots(t, client)
+		})
 	})
 
 	populateKV(t, srv)
@@ -76,6 +82,9 @@ func TestRunConsulWorkflowPackageTests(t *testing.T) {
 		t.Run("TestRunPurgeFails", func(t *testing.T) {
 			testRunPurgeFails(t, srv, client)
 		})
+		t.Run("TestRunDriftCheck", func(t *testing.T) {
+			testRunDriftCheck(t, srv, client)
+		})
 	})
 }
 
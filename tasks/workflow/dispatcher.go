@@ -15,7 +15,10 @@
 package workflow
 
 import (
+	"context"
+	"math"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +35,12 @@ import (
 
 const executionLockPrefix = ".processingLock-"
 
+// maintenanceCheckInterval is the delay between two checks of the end of a maintenance operation
+const maintenanceCheckInterval = 2 * time.Second
+
+// throttledExecutionsCheckInterval is the delay between two checks of executions not dispatched due to fair-share limits
+const throttledExecutionsCheckInterval = time.Second
+
 // Dispatcher concern is polling executions task and dispatch them across available workers
 // It has to acquire a lock on the execution task as other distributed dispatchers can try to do the same
 // If it gets the lock, it instantiates an execution task and push it to workers pool
@@ -44,35 +53,61 @@ type Dispatcher struct {
 	cfg              config.Configuration
 	wg               *sync.WaitGroup
 	createWorkerFunc func(*Dispatcher)
+	// executionsInfo caches information on pending executions, it is only used by the Run loop
+	executionsInfo map[string]executionInfo
+	// tasksAffinity caches information needed to check if executions of tasks having pending executions
+	// could be processed by this server, it is only used by the Run loop
+	tasksAffinity map[string]*taskAffinity
+	// runningByTarget counts executions handled by workers of this dispatcher per target
+	runningByTarget map[string]int
+	runningLock     sync.Mutex
+}
+
+// executionInfo contains information used to order and limit executions before dispatching them
+type executionInfo struct {
+	execID   string
+	execKey  string
+	taskID   string
+	targetID string
+	priority tasks.TaskPriority
+	// allowed is false if the server doesn't advertise all tags required by the location of the execution target node
+	allowed bool
 }
 
 // NewDispatcher create a new Dispatcher with a given number of workers
 func NewDispatcher(cfg config.Configuration, shutdownCh chan struct{}, client *api.Client, wg *sync.WaitGroup) *Dispatcher {
 	pool := make(chan chan *taskExecution, cfg.WorkersNumber)
-	dispatcher := &Dispatcher{WorkerPool: pool, client: client, shutdownCh: shutdownCh, maxWorkers: cfg.WorkersNumber, cfg: cfg, wg: wg, createWorkerFunc: createWorker}
+	dispatcher := &Dispatcher{WorkerPool: pool, client: client, shutdownCh: shutdownCh, maxWorkers: cfg.WorkersNumber, cfg: cfg, wg: wg, createWorkerFunc: createWorker,
+		executionsInfo: make(map[string]executionInfo), tasksAffinity: make(map[string]*taskAffinity), runningByTarget: make(map[string]int)}
 	dispatcher.emitMetrics(client)
 	return dispatcher
 }
 
-// getTaskExecsNbWait calculates the number of task executions that wait
-func (d *Dispatcher) getTaskExecsNbWait(client *api.Client) (float32, error) {
+// getTaskExecsNbWait calculates the number of task executions that wait, in total and by task priority
+func (d *Dispatcher) getTaskExecsNbWait(client *api.Client) (float32, map[tasks.TaskPriority]float32, error) {
 	var nb float32
+	nbByPriority := make(map[tasks.TaskPriority]float32)
 	tasksKeys, err := consulutil.GetKeys(consulutil.TasksPrefix)
 	if err != nil {
-		return 0, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
-	}
-	if tasksKeys == nil {
-		return 0, nil
+		return 0, nil, errors.Wrap(err, consulutil.ConsulGenericErrMsg)
 	}
 	for _, taskKey := range tasksKeys {
 		taskID := path.Base(taskKey)
 		nbExec, err := numberOfWaitingExecutionsForTask(client, taskID)
 		if err != nil {
-			return 0, err
+			return 0, nil, err
+		}
+		if nbExec == 0 {
+			continue
+		}
+		priority, err := tasks.GetTaskPriority(taskID)
+		if err != nil {
+			return 0, nil, err
 		}
 		nb = nb + float32(nbExec)
+		nbByPriority[priority] = nbByPriority[priority] + float32(nbExec)
 	}
-	return nb, nil
+	return nb, nbByPriority, nil
 }
 
 func (d *Dispatcher) emitMetrics(client *api.Client) {
@@ -97,7 +132,7 @@ func (d *Dispatcher) emitWorkersMetrics() {
 }
 
 func (d *Dispatcher) emitTaskExecutionsMetrics(client *api.Client, lastWarn *time.Time) {
-	nbWaiting, err := d.getTaskExecsNbWait(client)
+	nbWaiting, nbWaitingByPriority, err := d.getTaskExecsNbWait(client)
 	if err != nil {
 		now := time.Now()
 		if now.Sub(*lastWarn) > 5*time.Minute {
@@ -108,6 +143,118 @@ func (d *Dispatcher) emitTaskExecutionsMetrics(client *api.Client, lastWarn *tim
 		return
 	}
 	metrics.SetGauge([]string{"taskExecutions", "nbWaiting"}, nbWaiting)
+	for priority := tasks.TaskPriorityLOW; priority <= tasks.TaskPriorityHIGH; priority++ {
+		metrics.SetGaugeWithLabels([]string{"taskExecutions", "nbWaitingByPriority"}, nbWaitingByPriority[priority],
+			[]metrics.Label{{Name: "Priority", Value: priority.String()}})
+	}
+}
+
+// maxExecutionsPerTarget returns the maximum number of executions of a same target that could be handled
+// at the same time by workers of this dispatcher
+func (d *Dispatcher) maxExecutionsPerTarget() int {
+	share := d.cfg.Tasks.Dispatcher.MaxWorkersSharePerTarget
+	if share <= 0 || share >= 1 {
+		return d.maxWorkers
+	}
+	return int(math.Max(1, math.Floor(share*float64(d.maxWorkers))))
+}
+
+// isTargetWorkersShareReached checks if the fair-share limit of a target is reached
+func (d *Dispatcher) isTargetWorkersShareReached(targetID string) bool {
+	d.runningLock.Lock()
+	defer d.runningLock.Unlock()
+	return d.runningByTarget[targetID] >= d.maxExecutionsPerTarget()
+}
+
+// acquireWorkerForTarget accounts a worker for the target of the given execution until the end of this execution
+func (d *Dispatcher) acquireWorkerForTarget(t *taskExecution) {
+	d.runningLock.Lock()
+	defer d.runningLock.Unlock()
+	d.runningByTarget[t.targetID]++
+	t.endCallback = func() {
+		d.releaseWorkerForTarget(t.targetID)
+	}
+}
+
+// releaseWorkerForTarget releases a worker previously acquired by acquireWorkerForTarget
+func (d *Dispatcher) releaseWorkerForTarget(targetID string) {
+	d.runningLock.Lock()
+	defer d.runningLock.Unlock()
+	d.runningByTarget[targetID]--
+	if d.runningByTarget[targetID] <= 0 {
+		delete(d.runningByTarget, targetID)
+	}
+}
+
+// getPendingExecutions returns information on executions from the given keys sorted by decreasing task priority.
+// Executions of a same priority keep the keys order.
+func (d *Dispatcher) getPendingExecutions(execKeys []string) []executionInfo {
+	result := make([]executionInfo, 0, len(execKeys))
+	known := make(map[string]executionInfo, len(execKeys))
+	for _, execKey := range execKeys {
+		execID := path.Base(execKey)
+		// Ignore locks
+		if strings.HasPrefix(execID, executionLockPrefix) {
+			continue
+		}
+		info, ok := d.executionsInfo[execID]
+		if !ok {
+			info = executionInfo{execID: execID, execKey: execKey, priority: tasks.TaskPriorityNORMAL, allowed: true}
+			taskID, err := getExecutionKeyValue(execID, "taskID")
+			if err == nil {
+				info.taskID = taskID
+				info.targetID, err = tasks.GetTaskTarget(taskID)
+			}
+			if err == nil {
+				info.priority, err = tasks.GetTaskPriority(taskID)
+			}
+			if err == nil {
+				info.allowed, err = d.isExecutionAllowedOnServer(execID, taskID, info.targetID)
+			}
+			if err != nil {
+				// Invalid executions are handled (removed) once their lock is acquired
+				// so they should be dispatched whatever the server tags
+				log.Debugf("Failed to get information on task execution %q: %v", execID, err)
+				info.allowed = true
+			} else {
+				known[execID] = info
+			}
+		} else {
+			known[execID] = info
+		}
+		result = append(result, info)
+	}
+	// Forget executions that are no longer pending and tasks that no longer have pending executions
+	d.executionsInfo = known
+	pendingTasks := make(map[string]struct{}, len(known))
+	for _, info := range known {
+		pendingTasks[info.taskID] = struct{}{}
+	}
+	for taskID := range d.tasksAffinity {
+		if _, ok := pendingTasks[taskID]; !ok {
+			delete(d.tasksAffinity, taskID)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].priority > result[j].priority
+	})
+	return result
+}
+
+// isExecutionAllowedOnServer checks if the given task execution could be processed by this server
+// according to tags required by the location of its target node
+func (d *Dispatcher) isExecutionAllowedOnServer(execID, taskID, targetID string) (bool, error) {
+	ctx := context.Background()
+	affinity, ok := d.tasksAffinity[taskID]
+	if !ok {
+		var err error
+		affinity, err = newTaskAffinity(ctx, taskID, targetID)
+		if err != nil {
+			return false, err
+		}
+		d.tasksAffinity[taskID] = affinity
+	}
+	return affinity.isExecutionAllowedOnServer(ctx, d.cfg, execID, targetID)
 }
 
 func getExecutionKeyValue(execID, execKey string) (string, error) {
@@ -169,12 +316,31 @@ func (d *Dispatcher) Run() {
 			log.Debugf("%+v", err)
 			continue
 		}
-		waitIndex = rMeta.LastIndex
-		log.Debugf("Got response new wait index is %d", waitIndex)
-		for _, execKey := range execKeys {
-			execID := path.Base(execKey)
-			// Ignore locks
-			if strings.HasPrefix(execID, executionLockPrefix) {
+		// Do not dispatch new executions while a maintenance operation (backup, restore) is in progress.
+		// The wait index is not updated to get those executions once the maintenance is over.
+		maintenance, err := consulutil.IsMaintenanceInProgress()
+		if err != nil {
+			log.Printf("Failed to check if a maintenance operation is in progress: %v", err)
+		} else if maintenance {
+			log.Debugf("Maintenance operation in progress, waiting before dispatching task executions")
+			select {
+			case <-d.shutdownCh:
+			case <-time.After(maintenanceCheckInterval):
+			}
+			continue
+		}
+		log.Debugf("Got response new wait index is %d", rMeta.LastIndex)
+		throttled := false
+		for _, pendingExec := range d.getPendingExecutions(execKeys) {
+			execID := pendingExec.execID
+			execKey := pendingExec.execKey
+			if !pendingExec.allowed {
+				log.Debugf("Server tags %v do not match tags required by the location of execution %q, let another server process it", d.cfg.ServerTags, execID)
+				continue
+			}
+			if pendingExec.targetID != "" && d.isTargetWorkersShareReached(pendingExec.targetID) {
+				log.Debugf("Maximum share of workers reached for target %q, execution %q will be dispatched later", pendingExec.targetID, execID)
+				throttled = true
 				continue
 			}
 
@@ -261,6 +427,7 @@ func (d *Dispatcher) Run() {
 			// try to obtain a worker TaskExecution channel until timeout
 			select {
 			case taskChannel := <-d.WorkerPool:
+				d.acquireWorkerForTarget(t)
 				taskChannel <- t
 			case <-leaderChan:
 				// lock lost
@@ -278,5 +445,15 @@ func (d *Dispatcher) Run() {
 				break
 			}
 		}
+		if throttled {
+			// Some executions were not dispatched due to fair-share limits, do not wait for a change
+			// of executions to check them again as ending executions may not change them
+			select {
+			case <-d.shutdownCh:
+			case <-time.After(throttledExecutionsCheckInterval):
+			}
+			continue
+		}
+		waitIndex = rMeta.LastIndex
 	}
 }
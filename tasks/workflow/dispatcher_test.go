@@ -52,6 +52,23 @@ func testDeleteExecutionTreeSamePrefix(t *testing.T, client *api.Client) {
 
 }
 
+func testGetPendingInvalidExecutions(t *testing.T, client *api.Client) {
+	shutdownCh := make(chan struct{})
+	defer close(shutdownCh)
+
+	cfg := config.Configuration{WorkersNumber: 1, ServerTags: []string{"site-a"}}
+	dispatcher := NewDispatcher(cfg, shutdownCh, client, &sync.WaitGroup{})
+
+	// An execution without task is invalid, it should be dispatched to be removed once its lock is acquired
+	createTaskExecutionKVWithKey(t, "testGetPendingInvalidExecutionsExecID", "somekey", "val")
+	execKey := path.Join(consulutil.ExecutionsTaskPrefix, "testGetPendingInvalidExecutionsExecID") + "/"
+	pending := dispatcher.getPendingExecutions([]string{execKey})
+	require.Len(t, pending, 1)
+	assert.Equal(t, "testGetPendingInvalidExecutionsExecID", pending[0].execID)
+	assert.True(t, pending[0].allowed)
+	assert.Empty(t, dispatcher.tasksAffinity)
+}
+
 type workerMock struct{}
 
 func createWorkerFuncMock(d *Dispatcher, tchan chan *taskExecution) {
@@ -124,3 +141,44 @@ func testDispatcherRun(t *testing.T, srv *testutil.TestServer, client *api.Clien
 		require.Fail(t, "timeout awaiting dispatcher to take execution")
 	}
 }
+
+func TestDispatcherMaxExecutionsPerTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxWorkers int
+		share      float64
+		want       int
+	}{
+		{"NoLimitByDefault", 10, 0, 10},
+		{"NoLimitWithFullShare", 10, 1, 10},
+		{"HalfShare", 10, 0.5, 5},
+		{"RoundedDown", 10, 0.25, 2},
+		{"AtLeastOneWorker", 3, 0.1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Dispatcher{maxWorkers: tt.maxWorkers}
+			d.cfg.Tasks.Dispatcher.MaxWorkersSharePerTarget = tt.share
+			assert.Equal(t, tt.want, d.maxExecutionsPerTarget())
+		})
+	}
+}
+
+func TestDispatcherTargetWorkersShare(t *testing.T) {
+	d := &Dispatcher{maxWorkers: 4, runningByTarget: make(map[string]int)}
+	d.cfg.Tasks.Dispatcher.MaxWorkersSharePerTarget = 0.5
+
+	t1 := &taskExecution{targetID: "dep1"}
+	t2 := &taskExecution{targetID: "dep1"}
+	require.False(t, d.isTargetWorkersShareReached("dep1"))
+	d.acquireWorkerForTarget(t1)
+	require.False(t, d.isTargetWorkersShareReached("dep1"))
+	d.acquireWorkerForTarget(t2)
+	require.True(t, d.isTargetWorkersShareReached("dep1"))
+	require.False(t, d.isTargetWorkersShareReached("dep2"), "limits should be per target")
+
+	t1.endCallback()
+	require.False(t, d.isTargetWorkersShareReached("dep1"))
+	t2.endCallback()
+	require.Empty(t, d.runningByTarget)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/events"
+	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/registry"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+// runDriftCheck checks the infrastructure drift of the nodes related to the task or of all the deployment nodes
+// if the task is not related to specific nodes.
+//
+// Only nodes whose delegate executor implements prov.DriftDetector are checked. A report is stored for each
+// checked node even if the check fails and drifted instances are flagged with a warning event.
+func (w *worker) runDriftCheck(ctx context.Context, t *taskExecution) error {
+	nodes, err := tasks.GetTaskRelatedNodes(t.taskID)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		nodes, err = deployments.GetNodes(ctx, t.targetID)
+		if err != nil {
+			return err
+		}
+	}
+	var failedNodes []string
+	for _, nodeName := range nodes {
+		detector, err := getDriftDetector(ctx, t.targetID, nodeName)
+		if err != nil {
+			return err
+		}
+		if detector == nil {
+			log.Debugf("Deployment %q: node %q does not support drift detection, skipping it", t.targetID, nodeName)
+			continue
+		}
+		instances, err := deployments.GetNodeInstancesIds(ctx, t.targetID, nodeName)
+		if err != nil {
+			return err
+		}
+		if len(instances) == 0 {
+			continue
+		}
+		nodeCtx := events.AddLogOptionalFields(ctx, events.LogOptionalFields{events.NodeID: nodeName})
+		report, err := detector.DetectDrift(nodeCtx, w.cfg, t.taskID, t.targetID, nodeName)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			events.WithContextOptionalFields(nodeCtx).NewLogEntry(events.LogLevelERROR, t.targetID).Registerf("Failed to check infrastructure drift of node %q: %v", nodeName, err)
+			failedNodes = append(failedNodes, nodeName)
+			report = &prov.DriftReport{Error: err.Error()}
+		}
+		report.NodeName = nodeName
+		report.TaskID = t.taskID
+		report.CheckDate = time.Now()
+		if err = deployments.StoreDriftReport(ctx, t.targetID, *report); err != nil {
+			return err
+		}
+		if report.Error != "" {
+			continue
+		}
+		if !report.Drifted {
+			events.WithContextOptionalFields(nodeCtx).NewLogEntry(events.LogLevelINFO, t.targetID).Registerf("No infrastructure drift detected for node %q", nodeName)
+			continue
+		}
+		for _, instanceName := range instances {
+			events.WithContextOptionalFields(events.AddLogOptionalFields(nodeCtx, events.LogOptionalFields{events.InstanceID: instanceName})).
+				NewLogEntry(events.LogLevelWARN, t.targetID).Registerf("Infrastructure of node %q instance %q drifted from its expected state (%s): %s",
+				nodeName, instanceName, report.Summary, strings.Join(report.Changes, ", "))
+		}
+	}
+	if len(failedNodes) > 0 {
+		return errors.Errorf("failed to check infrastructure drift of nodes %s", strings.Join(failedNodes, ", "))
+	}
+	return nil
+}
+
+// getDriftDetector returns the drift detector of a node or nil if its delegate executor doesn't support drift detection
+func getDriftDetector(ctx context.Context, deploymentID, nodeName string) (prov.DriftDetector, error) {
+	nodeType, err := deployments.GetNodeType(ctx, deploymentID, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	delegateMatch, err := registry.GetRegistry().GetDelegateExecutorMatch(nodeType)
+	if err != nil {
+		// Not a delegate node
+		return nil, nil
+	}
+	detector, _ := delegateMatch.Executor.(prov.DriftDetector)
+	return detector, nil
+}
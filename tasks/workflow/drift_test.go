@@ -0,0 +1,111 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystia/yorc/v4/config"
+	"github.com/ystia/yorc/v4/deployments"
+	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/registry"
+	"github.com/ystia/yorc/v4/tosca"
+)
+
+type mockDriftDetector struct {
+	lock    sync.Mutex
+	checked []string
+	drifted bool
+	fails   bool
+}
+
+func (m *mockDriftDetector) ExecDelegate(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName, delegateOperation string) error {
+	return nil
+}
+
+func (m *mockDriftDetector) DetectDrift(ctx context.Context, conf config.Configuration, taskID, deploymentID, nodeName string) (*prov.DriftReport, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.checked = append(m.checked, nodeName)
+	if m.fails {
+		return nil, errors.New("Failed required for mock")
+	}
+	return &prov.DriftReport{Drifted: m.drifted, Changes: []string{"~ resource.changed"}, Summary: "Plan: 0 to add, 1 to change, 0 to destroy."}, nil
+}
+
+func testRunDriftCheck(t *testing.T, srv *testutil.TestServer, client *api.Client) {
+	ctx := context.Background()
+	deploymentID := strings.Replace(t.Name(), "/", "_", -1)
+	err := deployments.StoreDeploymentDefinition(ctx, deploymentID, "testdata/drift.yaml")
+	require.NoError(t, err)
+	for _, nodeName := range []string{"Compute", "Network", "App"} {
+		err = deployments.SetInstanceStateWithContextualLogs(ctx, deploymentID, nodeName, "0", tosca.NodeStateStarted)
+		require.NoError(t, err)
+	}
+
+	computeDetector := &mockDriftDetector{drifted: true}
+	networkDetector := &mockDriftDetector{fails: true}
+	registry.GetRegistry().RegisterDelegates([]string{`ystia\.yorc\.tests\.nodes\.DriftCompute`}, computeDetector, "tests")
+	registry.GetRegistry().RegisterDelegates([]string{`ystia\.yorc\.tests\.nodes\.DriftNetwork`}, networkDetector, "tests")
+
+	myWorker := &worker{
+		consulClient: client,
+		cfg: config.Configuration{
+			// Ensure we are not deleting filesystem files elsewhere
+			WorkingDirectory: "./testdata/work/",
+		},
+	}
+	err = myWorker.runDriftCheck(ctx, &taskExecution{cc: client, targetID: deploymentID, taskID: "tDrift1"})
+	require.Error(t, err, "a failing node check should fail the task")
+	require.Contains(t, err.Error(), "Network")
+	require.Equal(t, []string{"Compute"}, computeDetector.checked)
+	require.Equal(t, []string{"Network"}, networkDetector.checked)
+
+	reports, err := deployments.GetDriftReports(ctx, deploymentID)
+	require.NoError(t, err)
+	require.Len(t, reports, 2, "only delegate nodes supporting drift detection should be checked")
+	require.Equal(t, "Compute", reports[0].NodeName)
+	require.Equal(t, "tDrift1", reports[0].TaskID)
+	require.True(t, reports[0].Drifted)
+	require.False(t, reports[0].CheckDate.IsZero())
+	require.Equal(t, "Network", reports[1].NodeName)
+	require.Equal(t, "Failed required for mock", reports[1].Error)
+
+	// A task related to nodes only checks these nodes
+	srv.PopulateKV(t, map[string][]byte{
+		path.Join(consulutil.TasksPrefix, "tDrift2", "data", "nodes", "Compute"): []byte(""),
+	})
+	computeDetector.drifted = false
+	err = myWorker.runDriftCheck(ctx, &taskExecution{cc: client, targetID: deploymentID, taskID: "tDrift2"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Compute", "Compute"}, computeDetector.checked)
+	require.Equal(t, []string{"Network"}, networkDetector.checked)
+
+	reports, err = deployments.GetDriftReports(ctx, deploymentID)
+	require.NoError(t, err)
+	require.Equal(t, "tDrift2", reports[0].TaskID)
+	require.False(t, reports[0].Drifted)
+	require.Equal(t, "tDrift1", reports[1].TaskID)
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ystia/yorc/v4/helper/consulutil"
 	"github.com/ystia/yorc/v4/helper/metricsutil"
 	"github.com/ystia/yorc/v4/log"
+	"github.com/ystia/yorc/v4/prov"
 	"github.com/ystia/yorc/v4/prov/operations"
 	"github.com/ystia/yorc/v4/prov/scheduling"
 	"github.com/ystia/yorc/v4/registry"
@@ -59,6 +60,19 @@ func (s *step) setStatus(status tasks.TaskStepStatus) error {
 	return tasks.UpdateTaskStepWithStatus(s.t.taskID, s.Name, status)
 }
 
+// setWaitingStatus sets the step status to WAITING while it waits for a free slot on a concurrency limit
+// and back to RUNNING once it gets one
+func (s *step) setWaitingStatus(waiting bool) {
+	status := tasks.TaskStepStatusRUNNING
+	if waiting {
+		status = tasks.TaskStepStatusWAITING
+	}
+	err := s.setStatus(status)
+	if err != nil {
+		log.Printf("Failed to set status %q on step %q of task %q: %v", status, s.Name, s.t.taskID, err)
+	}
+}
+
 func (s *step) cancelNextSteps() {
 	for _, ns := range s.Next {
 		log.Debugf("cancel step name:%q", ns.Name)
@@ -169,6 +183,9 @@ func (s *step) run(ctx context.Context, cfg config.Configuration, deploymentID s
 		return nil
 	}
 	s.setStatus(tasks.TaskStepStatusRUNNING)
+	if err := tasks.NotifyTaskStepStart(s.t.taskID, s.Name, cfg.ServerID); err != nil {
+		log.Printf("Failed to record the start of step %q of task %q: %v", s.Name, s.t.taskID, err)
+	}
 
 	ctx, cancelWf := context.WithCancel(ctx)
 	defer cancelWf()
@@ -210,7 +227,7 @@ func (s *step) run(ctx context.Context, cfg config.Configuration, deploymentID s
 			}()
 			err := s.runActivity(ctx, cfg, deploymentID, workflowName, bypassErrors, w, activity)
 			if err != nil {
-				setNodeStatus(ctx, s.t.taskID, deploymentID, s.Target, tosca.NodeStateError.String())
+				setNodeErrorStatus(ctx, s.t.taskID, deploymentID, s.Target, err)
 				events.WithContextOptionalFields(ctx).NewLogEntry(events.LogLevelDEBUG, deploymentID).Registerf("TaskStep %q: error details: %+v", s.Name, err)
 				// Set step in error but continue if needed
 				s.setStatus(tasks.TaskStepStatusERROR)
@@ -275,7 +292,9 @@ func (s *step) runActivity(wfCtx context.Context, cfg config.Configuration, depl
 
 		err = func() error {
 			defer metrics.MeasureSinceWithLabels(metricsutil.CleanupMetricKey([]string{"executor", "delegate", "duration"}), time.Now(), executorDelegateLabels)
-			return provisioner.ExecDelegate(wfCtx, cfg, s.t.taskID, deploymentID, s.Target, delegateOp)
+			return runWithConcurrencyLimits(wfCtx, s.cc, cfg, deploymentID, s.Target, provisioner, s.setWaitingStatus, func() error {
+				return provisioner.ExecDelegate(wfCtx, cfg, s.t.taskID, deploymentID, s.Target, delegateOp)
+			})
 		}()
 
 		if err != nil {
@@ -305,6 +324,7 @@ func (s *step) runActivity(wfCtx context.Context, cfg config.Configuration, depl
 			}
 			return err
 		}
+		op.InstancesExecution = s.InstancesExecution
 
 		exec, err := getOperationExecutor(wfCtx, deploymentID, op.ImplementationArtifact)
 		if err != nil {
@@ -364,13 +384,15 @@ func (s *step) runActivity(wfCtx context.Context, cfg config.Configuration, depl
 		} else {
 			err = func() error {
 				defer metrics.MeasureSinceWithLabels(metricsutil.CleanupMetricKey([]string{"executor", "operation", "duration"}), time.Now(), executorOperationLabels)
-				return exec.ExecOperation(wfCtx, cfg, s.t.taskID, deploymentID, s.Target, op)
+				return runWithConcurrencyLimits(wfCtx, s.cc, cfg, deploymentID, s.Target, exec, s.setWaitingStatus, func() error {
+					return exec.ExecOperation(wfCtx, cfg, s.t.taskID, deploymentID, s.Target, op)
+				})
 			}()
 		}
 		if err != nil {
 			metrics.IncrCounterWithLabels(metricsutil.CleanupMetricKey([]string{"executor", "operation", "failures"}), 1, executorOperationLabels)
 			for _, instanceName := range instances {
-				s.publishInstanceRelatedEvents(wfCtx, deploymentID, instanceName, eventInfo, tasks.TaskStepStatusERROR)
+				s.publishInstanceRelatedEvents(wfCtx, deploymentID, instanceName, eventInfo, getInstanceStatusOnError(err, instanceName))
 			}
 			return err
 		}
@@ -649,6 +671,17 @@ func (s *step) registerOnCancelOrFailureSteps(ctx context.Context, workflowName
 	return err
 }
 
+// getInstanceStatusOnError returns the status of an instance on which an operation was run when it returned the given error
+func getInstanceStatusOnError(err error, instanceName string) tasks.TaskStepStatus {
+	if prov.IsFailedInstance(err, instanceName) {
+		return tasks.TaskStepStatusERROR
+	}
+	if prov.IsSkippedInstance(err, instanceName) {
+		return tasks.TaskStepStatusCANCELED
+	}
+	return tasks.TaskStepStatusDONE
+}
+
 func (s *step) publishInstanceRelatedEvents(ctx context.Context, deploymentID, instanceName string, eventInfo *events.WorkflowStepInfo, statuses ...tasks.TaskStepStatus) {
 	// taskExecutionID has to be unique for each instance, so we concat it to instanceName
 	eventInfo.InstanceName = instanceName
@@ -0,0 +1,36 @@
+// Copyright 2019 Bull S.A.S. Atos Technologies - Bull, Rue Jean Jaures, B.P.68, 78340, Les Clayes-sous-Bois, France.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workflow
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystia/yorc/v4/prov"
+	"github.com/ystia/yorc/v4/tasks"
+)
+
+func TestGetInstanceStatusOnError(t *testing.T) {
+	t.Parallel()
+	err := errors.New("operation failed")
+	assert.Equal(t, tasks.TaskStepStatusERROR, getInstanceStatusOnError(err, "0"))
+
+	instErr := errors.Wrap(&prov.InstancesError{Err: err, Failed: []string{"1"}, Skipped: []string{"2"}}, "ansible execution failed")
+	assert.Equal(t, tasks.TaskStepStatusDONE, getInstanceStatusOnError(instErr, "0"))
+	assert.Equal(t, tasks.TaskStepStatusERROR, getInstanceStatusOnError(instErr, "1"))
+	assert.Equal(t, tasks.TaskStepStatusCANCELED, getInstanceStatusOnError(instErr, "2"))
+}
@@ -41,6 +41,8 @@ type taskExecution struct {
 	step         string
 	// finalFunction is function a function called at the end of the taskExecution if no other taskExecution are running
 	finalFunction func() error
+	// endCallback is called by the worker once it is done with this taskExecution
+	endCallback func()
 }
 
 func (t *taskExecution) releaseLock() {
@@ -214,6 +216,11 @@ func setTaskStatus(ctx context.Context, targetID, taskID string, status tasks.Ta
 		return checkAndSetTaskStatus(ctx, targetID, taskID, status, errReason)
 	}
 
+	err = tasks.NotifyTaskStatusChange(taskID, status)
+	if err != nil {
+		log.Printf("[WARNING] Failed to record timing information of status change to %q for taskID:%q due to error:%+v", status.String(), taskID, err)
+	}
+
 	// Emit event for status change
 	// wfName may be empty as this data is not filled for non-workflow task type (as for custom command by instance)
 	wfName, _ := tasks.GetTaskData(taskID, "workflowName")
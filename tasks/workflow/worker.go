@@ -106,6 +106,25 @@ func setNodeStatus(ctx context.Context, taskID, deploymentID, nodeName, status s
 	return nil
 }
 
+// setNodeErrorStatus sets the error state on instances of the given node on which an operation failed with the given error
+func setNodeErrorStatus(ctx context.Context, taskID, deploymentID, nodeName string, err error) error {
+	instancesIDs, err2 := tasks.GetInstances(ctx, taskID, deploymentID, nodeName)
+	if err2 != nil {
+		return err2
+	}
+
+	for _, id := range instancesIDs {
+		if !prov.IsFailedInstance(err, id) {
+			continue
+		}
+		err2 = deployments.SetInstanceStateStringWithContextualLogs(ctx, deploymentID, nodeName, id, tosca.NodeStateError.String())
+		if err2 != nil {
+			return err2
+		}
+	}
+	return nil
+}
+
 func getOperationExecutor(ctx context.Context, deploymentID, artifact string) (prov.OperationExecutor, error) {
 	reg := registry.GetRegistry()
 
@@ -157,6 +176,9 @@ func (w *worker) cleanupScaledDownNodes(ctx context.Context, t *taskExecution) e
 // worker handle a taskExecution
 func (w *worker) handleExecution(t *taskExecution) {
 	log.Debugf("Handle task execution:%+v", t)
+	if t.endCallback != nil {
+		defer t.endCallback()
+	}
 	err := t.notifyStart()
 	if err != nil {
 		log.Printf("%+v", err)
@@ -219,7 +241,7 @@ func (w *worker) handleExecution(t *taskExecution) {
 		err = w.runCustomWorkflow(ctx, t, wfName)
 	case tasks.TaskTypeAction:
 		err = w.runAction(ctx, t)
-	case tasks.TaskTypeQuery, tasks.TaskTypeCustomCommand, tasks.TaskTypeForcePurge:
+	case tasks.TaskTypeQuery, tasks.TaskTypeCustomCommand, tasks.TaskTypeForcePurge, tasks.TaskTypeDriftCheck:
 		// Those kind of task will manage monitoring of taskFailure differently
 		err = w.runOneExecutionTask(ctx, t)
 	default:
@@ -267,6 +289,8 @@ func (w *worker) runOneExecutionTask(ctx context.Context, t *taskExecution) erro
 		ctx, err = w.runCustomCommand(ctx, t)
 	case tasks.TaskTypeForcePurge:
 		err = w.runPurge(ctx, t)
+	case tasks.TaskTypeDriftCheck:
+		err = w.runDriftCheck(ctx, t)
 	default:
 		err = errors.Errorf("Unknown TaskType %d (%s) for TaskExecution with id %q", t.taskType, t.taskType.String(), t.taskID)
 	}
@@ -324,7 +348,9 @@ func (w *worker) runCustomCommand(ctx context.Context, t *taskExecution) (contex
 
 	err = func() error {
 		defer metrics.MeasureSinceWithLabels(metricsutil.CleanupMetricKey([]string{"executor", "operation"}), time.Now(), executorOperationLabels)
-		return exec.ExecOperation(ctx, w.cfg, t.taskID, t.targetID, nodeName, op)
+		return runWithConcurrencyLimits(ctx, w.consulClient, w.cfg, t.targetID, nodeName, exec, func(bool) {}, func() error {
+			return exec.ExecOperation(ctx, w.cfg, t.taskID, t.targetID, nodeName, op)
+		})
 	}()
 	if err != nil {
 		metrics.IncrCounterWithLabels(metricsutil.CleanupMetricKey([]string{"executor", "operation", "failures"}), 1, executorOperationLabels)
@@ -27,6 +27,7 @@ import (
 
 	"github.com/ystia/yorc/v4/config"
 	"github.com/ystia/yorc/v4/helper/consulutil"
+	"github.com/ystia/yorc/v4/internal/standalone"
 )
 
 // NewTestConsulInstance allows to :
@@ -60,7 +61,11 @@ func NewTestConsulInstanceWithConfigAndStore(t testing.TB, cb testutil.ServerCon
 //  - stores common-types to Consul only if storeCommons bool parameter is true
 // Warning: You need to defer the server stop command in the caller
 func NewTestConsulInstanceWithConfig(t testing.TB, cb testutil.ServerConfigCallback, cfg *config.Configuration, storeCommons bool) (*testutil.TestServer, *api.Client) {
-	srv1, err := testutil.NewTestServerConfig(cb)
+	newTestServer := testutil.NewTestServerConfig
+	if standalone.TestServerEnabled() {
+		newTestServer = standalone.NewTestServer
+	}
+	srv1, err := newTestServer(cb)
 	if err != nil {
 		t.Fatalf("Failed to create consul server: %v", err)
 	}
@@ -37,8 +37,10 @@ func TestAssets(t *testing.T) {
 		t.Run("OpenstackTypes", testAssetYorcOpenStackParsing)
 		t.Run("AWSTypes", testAssetYorcAwsParsing)
 		t.Run("GoogleTypes", testAssetYorcGoogleParsing)
+		t.Run("AzureTypes", testAssetYorcAzureParsing)
 		t.Run("YorcTypes", testAssetYorcParsing)
 		t.Run("SlurmTypes", testAssetYorcSlurmParsing)
+		t.Run("PBSTypes", testAssetYorcPBSParsing)
 		t.Run("HostsPoolTypes", testAssetYorcHostsPoolParsing)
 	})
 }
@@ -74,6 +76,11 @@ func testAssetYorcGoogleParsing(t *testing.T) {
 	checkBuiltinTypesPath(t, "yorc-google-types")
 }
 
+func testAssetYorcAzureParsing(t *testing.T) {
+	t.Parallel()
+	checkBuiltinTypesPath(t, "yorc-azure-types")
+}
+
 func testAssetYorcParsing(t *testing.T) {
 	t.Parallel()
 	checkBuiltinTypesPath(t, "yorc-types")
@@ -84,6 +91,11 @@ func testAssetYorcSlurmParsing(t *testing.T) {
 	checkBuiltinTypesPath(t, "yorc-slurm-types")
 }
 
+func testAssetYorcPBSParsing(t *testing.T) {
+	t.Parallel()
+	checkBuiltinTypesPath(t, "yorc-pbs-types")
+}
+
 func testAssetYorcHostsPoolParsing(t *testing.T) {
 	t.Parallel()
 	checkBuiltinTypesPath(t, "yorc-hostspool-types")
@@ -22,6 +22,9 @@ type Implementation struct {
 	Dependencies  []string           `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
 	Artifact      ArtifactDefinition `yaml:",inline" json:"artifact,omitempty"`
 	OperationHost string             `yaml:"operation_host,omitempty" json:"operation_host,omitempty"`
+
+	// Non standard
+	InstancesExecution *InstancesExecution `yaml:"instances_execution,omitempty" json:"instances_execution,omitempty"`
 }
 
 // UnmarshalYAML unmarshals a yaml into an Implementation
@@ -38,12 +41,15 @@ func (i *Implementation) UnmarshalYAML(unmarshal func(interface{}) error) error
 		Dependencies  []string           `yaml:"dependencies,omitempty"`
 		Artifact      ArtifactDefinition `yaml:",inline"`
 		OperationHost string             `yaml:"operation_host,omitempty"`
+
+		InstancesExecution *InstancesExecution `yaml:"instances_execution,omitempty"`
 	}
 	if err = unmarshal(&str); err == nil {
 		i.Primary = str.Primary
 		i.Dependencies = str.Dependencies
 		i.Artifact = str.Artifact
 		i.OperationHost = str.OperationHost
+		i.InstancesExecution = str.InstancesExecution
 		return nil
 	}
 
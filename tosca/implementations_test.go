@@ -16,6 +16,7 @@ package tosca
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
@@ -28,6 +29,7 @@ func TestGroupedImplementationsParallel(t *testing.T) {
 		t.Run("TestImplementationArtifact", implementationArtifact)
 		t.Run("TestImplementationComplexGrammarWithDependencies", implementationComplexGrammarWithDependencies)
 		t.Run("TestImplementationFailing", implementationFailing)
+		t.Run("TestImplementationInstancesExecution", implementationInstancesExecution)
 	})
 }
 
@@ -110,3 +112,21 @@ implementation:
 	assert.NotNil(t, err, "Expecting an error when unmarshaling Implementation with an array as primary")
 
 }
+
+func implementationInstancesExecution(t *testing.T) {
+	t.Parallel()
+	var inputYaml = `
+implementation:
+  primary: scripts/upgrade.sh
+  instances_execution:
+    parallelism: 2
+    batch_size: 4
+    pause: 30s
+    max_failures: 1`
+	implem := implementationTestType{}
+
+	err := yaml.Unmarshal([]byte(inputYaml), &implem)
+	assert.Nil(t, err, "Expecting no error when unmarshaling Implementation with instances execution")
+	assert.Equal(t, "scripts/upgrade.sh", implem.Implementation.Primary)
+	assert.Equal(t, &InstancesExecution{Parallelism: 2, BatchSize: 4, Pause: 30 * time.Second, MaxFailures: 1}, implem.Implementation.InstancesExecution)
+}
@@ -14,6 +14,8 @@
 
 package tosca
 
+import "time"
+
 // A Workflow is the representation of a TOSCA Workflow
 //
 type Workflow struct {
@@ -35,7 +37,24 @@ type Step struct {
 	OperationHost      string     `yaml:"operation_host,omitempty" json:"operation_host,omitempty"`
 
 	// Non standard
-	OnCancel []string `yaml:"on_cancel,omitempty" json:"on_cancel,omitempty"`
+	OnCancel           []string            `yaml:"on_cancel,omitempty" json:"on_cancel,omitempty"`
+	InstancesExecution *InstancesExecution `yaml:"instances_execution,omitempty" json:"instances_execution,omitempty"`
+}
+
+// InstancesExecution defines how an operation is run on the instances of a node (Non standard)
+//
+// Instances are split into batches of BatchSize instances run one after the other, up to Parallelism instances
+// of a batch being run concurrently. The execution is aborted when more than MaxFailures instances failed.
+type InstancesExecution struct {
+	// Parallelism is the maximum number of instances run concurrently, 0 means all instances of a batch
+	// if BatchSize is set and one instance at a time otherwise
+	Parallelism int `yaml:"parallelism,omitempty" json:"parallelism,omitempty"`
+	// BatchSize is the number of instances of a batch, 0 means that all instances are in the same batch
+	BatchSize int `yaml:"batch_size,omitempty" json:"batch_size,omitempty"`
+	// Pause is the time to wait between two batches
+	Pause time.Duration `yaml:"pause,omitempty" json:"pause,omitempty"`
+	// MaxFailures is the number of failed instances tolerated before aborting the execution
+	MaxFailures int `yaml:"max_failures,omitempty" json:"max_failures,omitempty"`
 }
 
 // An Activity is the representation of a TOSCA Workflow Step Activity